@@ -0,0 +1,302 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/teilomillet/gollm"
+)
+
+// Runnable is a unit of a multi-agent system that turns an input into an
+// output. The orchestration primitives are Runnables themselves, so chains,
+// fan-outs and routers can be nested freely. Every Runnable of a run shares
+// the same blackboard.
+type Runnable interface {
+	Execute(ctx context.Context, input string, board *Blackboard) (string, error)
+}
+
+// RunnableFunc adapts a function to the Runnable interface.
+type RunnableFunc func(ctx context.Context, input string, board *Blackboard) (string, error)
+
+// Execute calls f.
+func (f RunnableFunc) Execute(ctx context.Context, input string, board *Blackboard) (string, error) {
+	return f(ctx, input, board)
+}
+
+// Blackboard is memory shared by the agents of a run. It is safe for
+// concurrent use by parallel branches.
+type Blackboard struct {
+	mu      sync.RWMutex
+	entries map[string]string
+	log     []BlackboardEntry
+}
+
+// BlackboardEntry records a write to the blackboard.
+type BlackboardEntry struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// NewBlackboard creates an empty blackboard.
+func NewBlackboard() *Blackboard {
+	return &Blackboard{entries: make(map[string]string)}
+}
+
+// Set stores a value, replacing any previous value of the key.
+func (b *Blackboard) Set(key, value string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.entries[key] = value
+	b.log = append(b.log, BlackboardEntry{Key: key, Value: value})
+}
+
+// Get returns the value of a key.
+func (b *Blackboard) Get(key string) (string, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	value, ok := b.entries[key]
+	return value, ok
+}
+
+// Snapshot returns a copy of the current values.
+func (b *Blackboard) Snapshot() map[string]string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	snapshot := make(map[string]string, len(b.entries))
+	for key, value := range b.entries {
+		snapshot[key] = value
+	}
+	return snapshot
+}
+
+// History returns every write in order.
+func (b *Blackboard) History() []BlackboardEntry {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	history := make([]BlackboardEntry, len(b.log))
+	copy(history, b.log)
+	return history
+}
+
+// String renders the current values sorted by key, for use as prompt context.
+func (b *Blackboard) String() string {
+	snapshot := b.Snapshot()
+	keys := make([]string, 0, len(snapshot))
+	for key := range snapshot {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var s strings.Builder
+	for _, key := range keys {
+		fmt.Fprintf(&s, "[%s]\n%s\n\n", key, snapshot[key])
+	}
+	return s.String()
+}
+
+// Execute runs a Runnable with a fresh blackboard and returns the output with
+// the final blackboard.
+//
+// Example usage:
+//
+//	pipeline := agent.Sequence(
+//	    agent.Named("research", agent.Parallel(agent.SynthesizeWith(llm),
+//	        agent.FromReAct(webResearcher),
+//	        agent.FromLLM(expert, "Answer from your own knowledge."),
+//	    )),
+//	    agent.Named("draft", agent.FromLLM(writer, "Write a report from the research.")),
+//	)
+//	report, board, err := agent.Execute(ctx, pipeline, "State of WebAssembly in 2024")
+func Execute(ctx context.Context, r Runnable, input string) (string, *Blackboard, error) {
+	board := NewBlackboard()
+	output, err := r.Execute(ctx, input, board)
+	return output, board, err
+}
+
+// FromLLM wraps a model as a Runnable. The instructions become the system
+// prompt and the blackboard contents are provided as context.
+func FromLLM(l gollm.LLM, instructions string) Runnable {
+	return RunnableFunc(func(ctx context.Context, input string, board *Blackboard) (string, error) {
+		var opts []gollm.PromptOption
+		if instructions != "" {
+			opts = append(opts, gollm.WithSystemPrompt(instructions, ""))
+		}
+		if board != nil {
+			if shared := board.String(); shared != "" {
+				opts = append(opts, gollm.WithContext("Shared notes from other agents:\n"+shared))
+			}
+		}
+		return l.Generate(ctx, gollm.NewPrompt(input, opts...))
+	})
+}
+
+// FromReAct wraps a ReAct agent as a Runnable.
+func FromReAct(a *ReActAgent) Runnable {
+	return RunnableFunc(func(ctx context.Context, input string, board *Blackboard) (string, error) {
+		if board != nil {
+			if shared := board.String(); shared != "" {
+				input = fmt.Sprintf("%s\n\nShared notes from other agents:\n%s", input, shared)
+			}
+		}
+		result, err := a.Run(ctx, input)
+		if err != nil {
+			return "", err
+		}
+		return result.Answer, nil
+	})
+}
+
+// FromPlanExecute wraps a plan-and-execute agent as a Runnable.
+func FromPlanExecute(a *PlanExecuteAgent) Runnable {
+	return RunnableFunc(func(ctx context.Context, input string, board *Blackboard) (string, error) {
+		result, err := a.Run(ctx, input)
+		if err != nil {
+			return "", err
+		}
+		return result.Answer, nil
+	})
+}
+
+// Named records the output of r on the blackboard under name.
+func Named(name string, r Runnable) Runnable {
+	return RunnableFunc(func(ctx context.Context, input string, board *Blackboard) (string, error) {
+		output, err := r.Execute(ctx, input, board)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", name, err)
+		}
+		if board != nil {
+			board.Set(name, output)
+		}
+		return output, nil
+	})
+}
+
+// Sequence runs the Runnables in order, passing each output as the input of
+// the next one. It stops at the first error.
+func Sequence(steps ...Runnable) Runnable {
+	return RunnableFunc(func(ctx context.Context, input string, board *Blackboard) (string, error) {
+		if len(steps) == 0 {
+			return "", fmt.Errorf("sequence has no steps")
+		}
+		output := input
+		for i, step := range steps {
+			if err := ctx.Err(); err != nil {
+				return "", err
+			}
+			var err error
+			output, err = step.Execute(ctx, output, board)
+			if err != nil {
+				return "", fmt.Errorf("sequence step %d: %w", i+1, err)
+			}
+		}
+		return output, nil
+	})
+}
+
+// Combiner merges the outputs of parallel branches, given in branch order.
+type Combiner func(ctx context.Context, input string, outputs []string, board *Blackboard) (string, error)
+
+// JoinOutputs returns a Combiner that concatenates the outputs with sep.
+func JoinOutputs(sep string) Combiner {
+	return func(ctx context.Context, input string, outputs []string, board *Blackboard) (string, error) {
+		return strings.Join(outputs, sep), nil
+	}
+}
+
+// SynthesizeWith returns a Combiner that asks the model to merge the outputs
+// into a single answer.
+func SynthesizeWith(l gollm.LLM) Combiner {
+	return func(ctx context.Context, input string, outputs []string, board *Blackboard) (string, error) {
+		var b strings.Builder
+		fmt.Fprintf(&b, "Task: %s\n\n", input)
+		for i, output := range outputs {
+			fmt.Fprintf(&b, "<response id=\"%d\">\n%s\n</response>\n\n", i+1, output)
+		}
+		prompt := gollm.NewPrompt(b.String(),
+			gollm.WithDirectives(
+				"Combine the responses above into a single, complete answer to the task",
+				"Resolve contradictions and remove repetition",
+			),
+		)
+		return l.Generate(ctx, prompt)
+	}
+}
+
+// Parallel runs every branch concurrently on the same input and merges the
+// outputs with combine. If any branch fails, the errors of all failed
+// branches are returned.
+func Parallel(combine Combiner, branches ...Runnable) Runnable {
+	return RunnableFunc(func(ctx context.Context, input string, board *Blackboard) (string, error) {
+		if len(branches) == 0 {
+			return "", fmt.Errorf("parallel has no branches")
+		}
+		if combine == nil {
+			combine = JoinOutputs("\n\n")
+		}
+		outputs := make([]string, len(branches))
+		errs := make([]error, len(branches))
+		var wg sync.WaitGroup
+		for i, branch := range branches {
+			wg.Add(1)
+			go func(i int, branch Runnable) {
+				defer wg.Done()
+				output, err := branch.Execute(ctx, input, board)
+				if err != nil {
+					errs[i] = fmt.Errorf("branch %d: %w", i+1, err)
+					return
+				}
+				outputs[i] = output
+			}(i, branch)
+		}
+		wg.Wait()
+		if err := errors.Join(errs...); err != nil {
+			return "", err
+		}
+		return combine(ctx, input, outputs, board)
+	})
+}
+
+// Route is a specialist a router can dispatch to.
+type Route struct {
+	Name        string
+	Description string
+	Agent       Runnable
+}
+
+// Router returns a Runnable that asks the model which route best handles the
+// input and dispatches to it. The chosen route is recorded on the blackboard
+// under "route". Inputs the model cannot route go to the first route.
+func Router(l gollm.LLM, routes ...Route) Runnable {
+	return RunnableFunc(func(ctx context.Context, input string, board *Blackboard) (string, error) {
+		if len(routes) == 0 {
+			return "", fmt.Errorf("router has no routes")
+		}
+		var b strings.Builder
+		for _, route := range routes {
+			fmt.Fprintf(&b, "- %s: %s\n", route.Name, route.Description)
+		}
+		prompt := gollm.NewPrompt(fmt.Sprintf("Choose the specialist best suited to handle the request.\n\nSpecialists:\n%s\n<request>\n%s\n</request>", b.String(), input),
+			gollm.WithOutput("Respond ONLY with the name of the specialist."),
+		)
+		response, err := l.Generate(ctx, prompt)
+		if err != nil {
+			return "", fmt.Errorf("failed to route request: %w", err)
+		}
+
+		chosen := routes[0]
+		name := strings.ToLower(strings.Trim(strings.TrimSpace(response), "\"'`.*"))
+		for _, route := range routes {
+			if strings.ToLower(route.Name) == name {
+				chosen = route
+				break
+			}
+		}
+		if board != nil {
+			board.Set("route", chosen.Name)
+		}
+		return chosen.Agent.Execute(ctx, input, board)
+	})
+}
@@ -0,0 +1,72 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm/gollmtest"
+)
+
+func upper() Runnable {
+	return RunnableFunc(func(ctx context.Context, input string, board *Blackboard) (string, error) {
+		return strings.ToUpper(input), nil
+	})
+}
+
+func suffix(s string) Runnable {
+	return RunnableFunc(func(ctx context.Context, input string, board *Blackboard) (string, error) {
+		return input + s, nil
+	})
+}
+
+func TestOrchestration(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("SequenceAndParallel", func(t *testing.T) {
+		pipeline := Sequence(
+			Named("upper", upper()),
+			Named("fanout", Parallel(JoinOutputs("|"), suffix("-a"), suffix("-b"))),
+		)
+		output, board, err := Execute(ctx, pipeline, "go")
+		require.NoError(t, err)
+		assert.Equal(t, "GO-a|GO-b", output)
+		assert.Equal(t, map[string]string{"upper": "GO", "fanout": "GO-a|GO-b"}, board.Snapshot())
+		assert.Len(t, board.History(), 2)
+	})
+
+	t.Run("ParallelError", func(t *testing.T) {
+		failing := RunnableFunc(func(ctx context.Context, input string, board *Blackboard) (string, error) {
+			return "", assert.AnError
+		})
+		_, _, err := Execute(ctx, Parallel(nil, upper(), failing), "go")
+		assert.ErrorIs(t, err, assert.AnError)
+	})
+
+	t.Run("Router", func(t *testing.T) {
+		l := &gollmtest.ScriptedLLM{Responses: []string{"  \"Math\"\n"}}
+		router := Router(l,
+			Route{Name: "writing", Description: "Prose", Agent: suffix("-writing")},
+			Route{Name: "math", Description: "Arithmetic", Agent: suffix("-math")},
+		)
+		output, board, err := Execute(ctx, router, "2+2")
+		require.NoError(t, err)
+		assert.Equal(t, "2+2-math", output)
+		route, _ := board.Get("route")
+		assert.Equal(t, "math", route)
+		assert.Contains(t, l.Prompts()[0].Input, "- writing: Prose")
+	})
+
+	t.Run("FromLLMSharesBlackboard", func(t *testing.T) {
+		l := &gollmtest.ScriptedLLM{Responses: []string{"answer"}}
+		board := NewBlackboard()
+		board.Set("facts", "The sky is blue")
+		output, err := FromLLM(l, "Be brief").Execute(ctx, "Color?", board)
+		require.NoError(t, err)
+		assert.Equal(t, "answer", output)
+		assert.Equal(t, "Be brief", l.Prompts()[0].SystemPrompt)
+		assert.Contains(t, l.Prompts()[0].Context, "The sky is blue")
+	})
+}
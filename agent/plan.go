@@ -0,0 +1,328 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/teilomillet/gollm"
+)
+
+// Task is a step of a plan.
+type Task struct {
+	ID          int    `json:"id"`
+	Description string `json:"description"`
+	// Executor names the executor that runs the task; empty for the default
+	Executor string `json:"executor,omitempty"`
+}
+
+// Plan is an ordered list of tasks that achieves a goal.
+type Plan struct {
+	Goal  string `json:"goal"`
+	Tasks []Task `json:"tasks"`
+}
+
+// TaskResult is the outcome of executing a task.
+type TaskResult struct {
+	Task   Task   `json:"task"`
+	Output string `json:"output,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// PlanResult is the outcome of a plan-and-execute run.
+type PlanResult struct {
+	Answer string `json:"answer"`
+	// Plan is the last plan, including tasks completed before any re-planning
+	Plan *Plan `json:"plan"`
+	// Results holds the outcome of every executed task, in execution order
+	Results []TaskResult `json:"results"`
+	Replans int          `json:"replans"`
+}
+
+// TaskExecutor runs a task. Results of previously completed tasks are passed
+// as context.
+type TaskExecutor func(ctx context.Context, goal string, task Task, completed []TaskResult) (string, error)
+
+// LLMExecutor returns an executor that asks the model to perform the task directly.
+func LLMExecutor(l gollm.LLM) TaskExecutor {
+	return func(ctx context.Context, goal string, task Task, completed []TaskResult) (string, error) {
+		prompt := gollm.NewPrompt(fmt.Sprintf("Overall goal: %s\n\n%sCurrent task: %s", goal, formatResults(completed), task.Description),
+			gollm.WithDirectives("Complete only the current task and respond with its result"),
+		)
+		return l.Generate(ctx, prompt)
+	}
+}
+
+// ReActExecutor returns an executor that runs the task with a ReAct agent, so
+// tasks can use tools.
+func ReActExecutor(a *ReActAgent) TaskExecutor {
+	return func(ctx context.Context, goal string, task Task, completed []TaskResult) (string, error) {
+		result, err := a.Run(ctx, fmt.Sprintf("%s\n\nThis task is part of the goal: %s\n\n%s", task.Description, goal, formatResults(completed)))
+		if err != nil {
+			return "", err
+		}
+		return result.Answer, nil
+	}
+}
+
+// ProgressType identifies a progress event of a plan-and-execute run.
+type ProgressType string
+
+// Progress event types.
+const (
+	ProgressPlanned       ProgressType = "planned"
+	ProgressTaskStarted   ProgressType = "task_started"
+	ProgressTaskCompleted ProgressType = "task_completed"
+	ProgressTaskFailed    ProgressType = "task_failed"
+	ProgressReplanned     ProgressType = "replanned"
+	ProgressFinished      ProgressType = "finished"
+)
+
+// Progress reports the state of a plan-and-execute run.
+type Progress struct {
+	Type   ProgressType
+	Plan   *Plan
+	Task   *Task
+	Result *TaskResult
+	Answer string
+}
+
+// PlanExecuteAgent first asks a planner model for a structured plan, then
+// executes its tasks in order, re-planning the remaining work when a task
+// fails, and finally synthesizes an answer from the task results.
+type PlanExecuteAgent struct {
+	planner         gollm.LLM
+	executors       map[string]TaskExecutor
+	defaultExecutor TaskExecutor
+	maxTasks        int
+	maxReplans      int
+	onProgress      func(Progress)
+}
+
+// PlanOption configures a PlanExecuteAgent.
+type PlanOption func(*PlanExecuteAgent)
+
+// WithExecutor registers a named executor the planner can assign tasks to,
+// e.g. a cheaper model for simple steps or a tool-using agent for research.
+func WithExecutor(name string, executor TaskExecutor) PlanOption {
+	return func(a *PlanExecuteAgent) {
+		a.executors[name] = executor
+	}
+}
+
+// WithDefaultExecutor sets the executor of tasks without an assigned
+// executor. Defaults to LLMExecutor with the planner model.
+func WithDefaultExecutor(executor TaskExecutor) PlanOption {
+	return func(a *PlanExecuteAgent) {
+		a.defaultExecutor = executor
+	}
+}
+
+// WithMaxTasks limits the number of tasks of a plan. Defaults to 10.
+func WithMaxTasks(n int) PlanOption {
+	return func(a *PlanExecuteAgent) {
+		a.maxTasks = n
+	}
+}
+
+// WithMaxReplans limits how many times the plan is revised after failures.
+// Defaults to 2; 0 disables re-planning.
+func WithMaxReplans(n int) PlanOption {
+	return func(a *PlanExecuteAgent) {
+		a.maxReplans = n
+	}
+}
+
+// WithProgress calls fn on every progress event, in order.
+func WithProgress(fn func(Progress)) PlanOption {
+	return func(a *PlanExecuteAgent) {
+		a.onProgress = fn
+	}
+}
+
+// NewPlanExecuteAgent creates a plan-and-execute agent using the planner
+// model for planning, re-planning and the final answer.
+//
+// Example usage:
+//
+//	a, _ := agent.NewPlanExecuteAgent(planner,
+//	    agent.WithExecutor("research", agent.ReActExecutor(researcher)),
+//	    agent.WithExecutor("writer", agent.LLMExecutor(writer)),
+//	    agent.WithProgress(func(p agent.Progress) { log.Println(p.Type) }),
+//	)
+//	result, err := a.Run(ctx, "Write a briefing on this week's Go releases")
+func NewPlanExecuteAgent(planner gollm.LLM, opts ...PlanOption) (*PlanExecuteAgent, error) {
+	if planner == nil {
+		return nil, fmt.Errorf("planner LLM cannot be nil")
+	}
+	a := &PlanExecuteAgent{
+		planner:    planner,
+		executors:  make(map[string]TaskExecutor),
+		maxTasks:   10,
+		maxReplans: 2,
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	if a.defaultExecutor == nil {
+		a.defaultExecutor = LLMExecutor(planner)
+	}
+	if a.maxTasks < 1 {
+		return nil, fmt.Errorf("max tasks must be at least 1")
+	}
+	return a, nil
+}
+
+// Run plans and executes the goal. When a task fails and no re-planning is
+// left, the partial result is returned with the error.
+func (a *PlanExecuteAgent) Run(ctx context.Context, goal string) (*PlanResult, error) {
+	if strings.TrimSpace(goal) == "" {
+		return nil, fmt.Errorf("goal cannot be empty")
+	}
+
+	plan, err := a.plan(ctx, goal, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	result := &PlanResult{Plan: plan}
+	a.progress(Progress{Type: ProgressPlanned, Plan: plan})
+
+	var completed []TaskResult
+	for next := 0; next < len(plan.Tasks); {
+		task := plan.Tasks[next]
+		a.progress(Progress{Type: ProgressTaskStarted, Plan: plan, Task: &task})
+
+		executor, err := a.executorFor(task)
+		var output string
+		if err == nil {
+			output, err = executor(ctx, goal, task, completed)
+		}
+		taskResult := TaskResult{Task: task, Output: output}
+		if err != nil {
+			taskResult.Error = err.Error()
+		}
+		result.Results = append(result.Results, taskResult)
+
+		if err == nil {
+			completed = append(completed, taskResult)
+			a.progress(Progress{Type: ProgressTaskCompleted, Plan: plan, Task: &task, Result: &taskResult})
+			next++
+			continue
+		}
+
+		a.progress(Progress{Type: ProgressTaskFailed, Plan: plan, Task: &task, Result: &taskResult})
+		if ctx.Err() != nil || result.Replans >= a.maxReplans {
+			return result, fmt.Errorf("task %d failed: %w", task.ID, err)
+		}
+		revised, err := a.plan(ctx, goal, completed, &taskResult)
+		if err != nil {
+			return result, err
+		}
+		result.Replans++
+		// Completed tasks are kept so that the final plan describes the whole run
+		plan = &Plan{Goal: goal, Tasks: append(append([]Task{}, plan.Tasks[:next]...), renumber(revised.Tasks, next)...)}
+		result.Plan = plan
+		a.progress(Progress{Type: ProgressReplanned, Plan: plan})
+	}
+
+	prompt := gollm.NewPrompt(fmt.Sprintf("Goal: %s\n\n%s", goal, formatResults(completed)),
+		gollm.WithDirectives("Using the task results above, give the final answer to the goal"),
+	)
+	answer, err := a.planner.Generate(ctx, prompt)
+	if err != nil {
+		return result, fmt.Errorf("failed to generate final answer: %w", err)
+	}
+	result.Answer = answer
+	a.progress(Progress{Type: ProgressFinished, Plan: plan, Answer: answer})
+	return result, nil
+}
+
+// plan asks the planner for a plan. When failed is set, the plan covers only
+// the work remaining after the completed tasks.
+func (a *PlanExecuteAgent) plan(ctx context.Context, goal string, completed []TaskResult, failed *TaskResult) (*Plan, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Goal: %s\n\n", goal)
+	if len(a.executors) > 0 {
+		names := make([]string, 0, len(a.executors))
+		for name := range a.executors {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		fmt.Fprintf(&b, "Available executors: %s. Assign each task to the most suitable one, or leave executor empty for the default.\n\n", strings.Join(names, ", "))
+	}
+	instruction := "Break the goal down into a short sequence of concrete tasks that can be executed one after another."
+	if failed != nil {
+		b.WriteString(formatResults(completed))
+		fmt.Fprintf(&b, "The task %q failed with: %s\n\n", failed.Task.Description, failed.Error)
+		instruction = "Revise the plan: list only the tasks still needed to achieve the goal, working around the failure."
+	}
+
+	prompt := gollm.NewPrompt(b.String(),
+		gollm.WithDirectives(instruction, fmt.Sprintf("Use at most %d tasks", a.maxTasks)),
+		gollm.WithOutput(`Respond ONLY with a JSON object of the form {"tasks": [{"id": 1, "description": "...", "executor": ""}]}.`),
+	)
+	response, err := a.planner.Generate(ctx, prompt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate plan: %w", err)
+	}
+
+	var plan Plan
+	cleaned, _ := gollm.SanitizeJSON(response)
+	if err := json.Unmarshal([]byte(cleaned), &plan); err != nil {
+		return nil, fmt.Errorf("failed to parse plan: %w", err)
+	}
+	if len(plan.Tasks) == 0 {
+		return nil, fmt.Errorf("plan has no tasks")
+	}
+	if len(plan.Tasks) > a.maxTasks {
+		plan.Tasks = plan.Tasks[:a.maxTasks]
+	}
+	plan.Goal = goal
+	plan.Tasks = renumber(plan.Tasks, 0)
+	return &plan, nil
+}
+
+// executorFor returns the executor assigned to a task.
+func (a *PlanExecuteAgent) executorFor(task Task) (TaskExecutor, error) {
+	if task.Executor == "" {
+		return a.defaultExecutor, nil
+	}
+	executor, ok := a.executors[task.Executor]
+	if !ok {
+		return nil, fmt.Errorf("unknown executor %q", task.Executor)
+	}
+	return executor, nil
+}
+
+// progress reports an event to the progress callback, if any.
+func (a *PlanExecuteAgent) progress(p Progress) {
+	if a.onProgress != nil {
+		a.onProgress(p)
+	}
+}
+
+// renumber assigns sequential IDs to tasks, starting after offset.
+func renumber(tasks []Task, offset int) []Task {
+	numbered := make([]Task, len(tasks))
+	for i, task := range tasks {
+		task.ID = offset + i + 1
+		numbered[i] = task
+	}
+	return numbered
+}
+
+// formatResults renders completed task results as prompt context.
+func formatResults(results []TaskResult) string {
+	if len(results) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("Completed tasks:\n")
+	for _, r := range results {
+		fmt.Fprintf(&b, "%d. %s\nResult: %s\n", r.Task.ID, r.Task.Description, r.Output)
+	}
+	b.WriteString("\n")
+	return b.String()
+}
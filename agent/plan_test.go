@@ -0,0 +1,73 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm/gollmtest"
+)
+
+func TestPlanExecuteAgent(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Replan", func(t *testing.T) {
+		planner := &gollmtest.ScriptedLLM{Responses: []string{
+			`{"tasks": [{"description": "Collect data", "executor": "research"}, {"description": "Write summary"}]}`,
+			"```json\n{\"tasks\": [{\"description\": \"Collect data from the archive\"}, {\"description\": \"Write summary\"}]}\n```",
+			"Final briefing",
+		}}
+		executed := []string{}
+		executor := func(ctx context.Context, goal string, task Task, completed []TaskResult) (string, error) {
+			executed = append(executed, task.Description)
+			return fmt.Sprintf("done %d with %d prior", task.ID, len(completed)), nil
+		}
+		research := func(ctx context.Context, goal string, task Task, completed []TaskResult) (string, error) {
+			return "", fmt.Errorf("search unavailable")
+		}
+
+		var events []ProgressType
+		a, err := NewPlanExecuteAgent(planner,
+			WithExecutor("research", research),
+			WithDefaultExecutor(executor),
+			WithProgress(func(p Progress) { events = append(events, p.Type) }),
+		)
+		require.NoError(t, err)
+
+		result, err := a.Run(ctx, "Write a briefing")
+		require.NoError(t, err)
+		assert.Equal(t, "Final briefing", result.Answer)
+		assert.Equal(t, 1, result.Replans)
+		assert.Equal(t, []string{"Collect data from the archive", "Write summary"}, executed)
+		require.Len(t, result.Results, 3)
+		assert.Equal(t, "search unavailable", result.Results[0].Error)
+		assert.Equal(t, "done 2 with 1 prior", result.Results[2].Output)
+		assert.Len(t, result.Plan.Tasks, 2)
+		assert.Contains(t, planner.Prompts()[0].Input, "Available executors: research")
+		assert.Contains(t, planner.Prompts()[1].Input, "search unavailable")
+		assert.Equal(t, []ProgressType{
+			ProgressPlanned, ProgressTaskStarted, ProgressTaskFailed, ProgressReplanned,
+			ProgressTaskStarted, ProgressTaskCompleted, ProgressTaskStarted, ProgressTaskCompleted, ProgressFinished,
+		}, events)
+	})
+
+	t.Run("NoReplanning", func(t *testing.T) {
+		planner := &gollmtest.ScriptedLLM{Responses: []string{`{"tasks": [{"description": "Step", "executor": "missing"}]}`}}
+		a, err := NewPlanExecuteAgent(planner, WithMaxReplans(0))
+		require.NoError(t, err)
+		result, err := a.Run(ctx, "Goal")
+		assert.ErrorContains(t, err, "unknown executor")
+		require.NotNil(t, result)
+		assert.Len(t, result.Results, 1)
+	})
+
+	t.Run("InvalidPlan", func(t *testing.T) {
+		planner := &gollmtest.ScriptedLLM{Responses: []string{`{"tasks": []}`}}
+		a, err := NewPlanExecuteAgent(planner)
+		require.NoError(t, err)
+		_, err = a.Run(ctx, "Goal")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,424 @@
+// Package agent provides agent runtimes that let language models use tools
+// to solve multi-step tasks.
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/teilomillet/gollm"
+	"github.com/teilomillet/gollm/llm"
+	"github.com/teilomillet/gollm/tools"
+	"github.com/teilomillet/gollm/utils"
+)
+
+// ErrMaxSteps is returned when the agent does not reach a final answer within
+// its step limit.
+var ErrMaxSteps = errors.New("agent reached the maximum number of steps without a final answer")
+
+// observationMarker starts the observations the model must not write itself.
+const observationMarker = "\nObservation:"
+
+// Step is one iteration of the ReAct loop: a thought followed by either a
+// tool call and its observation, or the final answer.
+type Step struct {
+	Index       int                    `json:"index"`
+	Thought     string                 `json:"thought,omitempty"`
+	Action      string                 `json:"action,omitempty"`
+	ActionInput map[string]interface{} `json:"action_input,omitempty"`
+	Observation string                 `json:"observation,omitempty"`
+	FinalAnswer string                 `json:"final_answer,omitempty"`
+	// Approval is the decision on a tool call that required approval
+	Approval *tools.Decision `json:"approval,omitempty"`
+}
+
+// Final reports whether the step holds the final answer.
+func (s Step) Final() bool {
+	return s.Action == ""
+}
+
+// Scratchpad is the working memory of a run: the steps taken so far, replayed
+// to the model on every iteration.
+type Scratchpad struct {
+	Steps []Step `json:"steps"`
+
+	// limit is the number of most recent steps rendered, 0 for all
+	limit int
+}
+
+// String renders the scratchpad in the ReAct text format.
+func (s *Scratchpad) String() string {
+	steps := s.Steps
+	var b strings.Builder
+	if s.limit > 0 && len(steps) > s.limit {
+		fmt.Fprintf(&b, "(%d earlier steps omitted)\n", len(steps)-s.limit)
+		steps = steps[len(steps)-s.limit:]
+	}
+	for _, step := range steps {
+		if step.Thought != "" {
+			fmt.Fprintf(&b, "Thought: %s\n", step.Thought)
+		}
+		if step.Final() {
+			fmt.Fprintf(&b, "Final Answer: %s\n", step.FinalAnswer)
+			continue
+		}
+		input, _ := json.Marshal(step.ActionInput)
+		fmt.Fprintf(&b, "Action: %s\nAction Input: %s\nObservation: %s\n", step.Action, input, step.Observation)
+	}
+	return b.String()
+}
+
+// Result is the outcome of an agent run.
+type Result struct {
+	Answer     string      `json:"answer"`
+	Scratchpad *Scratchpad `json:"scratchpad"`
+}
+
+// Event is sent on the channel returned by Stream. Exactly one of its fields is set.
+type Event struct {
+	Step   *Step
+	Result *Result
+	Err    error
+}
+
+// ReActAgent answers questions by interleaving reasoning with tool calls,
+// following the ReAct pattern: the model writes a thought, picks an action,
+// receives the tool output as an observation, and repeats until it can give
+// a final answer.
+type ReActAgent struct {
+	llm          gollm.LLM
+	registry     *tools.Registry
+	maxSteps     int
+	instructions string
+	nativeTools  bool
+	historyLimit int
+	onStep       func(Step)
+	generateOpts []llm.GenerateOption
+}
+
+// ReActOption configures a ReActAgent.
+type ReActOption func(*ReActAgent)
+
+// WithMaxSteps limits the number of iterations of a run. Defaults to 10.
+func WithMaxSteps(n int) ReActOption {
+	return func(a *ReActAgent) {
+		a.maxSteps = n
+	}
+}
+
+// WithInstructions adds task-specific guidance to the agent's system prompt.
+func WithInstructions(instructions string) ReActOption {
+	return func(a *ReActAgent) {
+		a.instructions = instructions
+	}
+}
+
+// WithNativeTools also sends the tool definitions with each request, so
+// providers with function calling can answer with native tool calls. The
+// text format remains accepted.
+func WithNativeTools() ReActOption {
+	return func(a *ReActAgent) {
+		a.nativeTools = true
+	}
+}
+
+// WithScratchpadLimit renders only the n most recent steps to the model,
+// bounding the prompt size of long runs. Defaults to all steps.
+func WithScratchpadLimit(n int) ReActOption {
+	return func(a *ReActAgent) {
+		a.historyLimit = n
+	}
+}
+
+// WithStepHandler calls fn after every completed step, in order.
+func WithStepHandler(fn func(Step)) ReActOption {
+	return func(a *ReActAgent) {
+		a.onStep = fn
+	}
+}
+
+// WithGenerateOptions adds options to every model request of the agent.
+func WithGenerateOptions(opts ...llm.GenerateOption) ReActOption {
+	return func(a *ReActAgent) {
+		a.generateOpts = append(a.generateOpts, opts...)
+	}
+}
+
+// NewReActAgent creates an agent that can call the tools of the registry.
+//
+// Example usage:
+//
+//	registry, _ := tools.NewRegistry(tools.Tool{
+//	    Name:        "calculator",
+//	    Description: "Evaluates an arithmetic expression",
+//	    Parameters:  map[string]interface{}{"type": "object", "properties": map[string]interface{}{"expression": map[string]interface{}{"type": "string"}}},
+//	    Handler:     calculate,
+//	})
+//	a, _ := agent.NewReActAgent(llm, registry, agent.WithMaxSteps(5))
+//	result, err := a.Run(ctx, "What is 17% of 2340?")
+func NewReActAgent(l gollm.LLM, registry *tools.Registry, opts ...ReActOption) (*ReActAgent, error) {
+	if l == nil {
+		return nil, fmt.Errorf("LLM instance cannot be nil")
+	}
+	if registry == nil {
+		registry, _ = tools.NewRegistry()
+	}
+	a := &ReActAgent{llm: l, registry: registry, maxSteps: 10}
+	for _, opt := range opts {
+		opt(a)
+	}
+	if a.maxSteps < 1 {
+		return nil, fmt.Errorf("max steps must be at least 1")
+	}
+	return a, nil
+}
+
+// Run executes the ReAct loop until the model gives a final answer. If the
+// step limit is reached, the partial result is returned with ErrMaxSteps.
+func (a *ReActAgent) Run(ctx context.Context, input string) (*Result, error) {
+	return a.run(ctx, input, runHooks{step: a.onStep})
+}
+
+// Stream executes the ReAct loop in the background and sends every step as it
+// completes, followed by the result or the error. The channel is closed when
+// the run ends.
+func (a *ReActAgent) Stream(ctx context.Context, input string) <-chan Event {
+	events := make(chan Event)
+	send := func(event Event) {
+		select {
+		case events <- event:
+		case <-ctx.Done():
+		}
+	}
+	go func() {
+		defer close(events)
+		result, err := a.run(ctx, input, runHooks{step: func(step Step) {
+			if a.onStep != nil {
+				a.onStep(step)
+			}
+			send(Event{Step: &step})
+		}})
+		if err != nil {
+			send(Event{Err: err})
+			return
+		}
+		send(Event{Result: result})
+	}()
+	return events
+}
+
+// runHooks are the callbacks of a run. Nil hooks are skipped.
+type runHooks struct {
+	// text receives the model output as it is generated; when set, the
+	// model responses are streamed
+	text func(string)
+	// toolStart is called before the tool call of a step is executed
+	toolStart func(Step)
+	// step is called after every completed step
+	step func(Step)
+}
+
+// run is the ReAct loop shared by Run and the streaming methods.
+func (a *ReActAgent) run(ctx context.Context, input string, hooks runHooks) (*Result, error) {
+	if strings.TrimSpace(input) == "" {
+		return nil, fmt.Errorf("input cannot be empty")
+	}
+
+	pad := &Scratchpad{limit: a.historyLimit}
+	system := a.systemPrompt()
+	for len(pad.Steps) < a.maxSteps {
+		if err := ctx.Err(); err != nil {
+			return &Result{Scratchpad: pad}, err
+		}
+
+		text := fmt.Sprintf("Question: %s\n\n%s", input, pad.String())
+		opts := []gollm.PromptOption{gollm.WithSystemPrompt(system, "")}
+		if a.nativeTools {
+			opts = append(opts, gollm.WithTools(a.registry.Definitions()))
+		}
+		prompt := gollm.NewPrompt(text, opts...)
+		var steps []Step
+		var err error
+		if hooks.text != nil {
+			steps, err = a.streamSteps(ctx, prompt, hooks.text)
+		} else {
+			var response string
+			response, err = a.llm.Generate(ctx, prompt, a.generateOpts...)
+			steps = parseResponse(response)
+		}
+		if err != nil {
+			return &Result{Scratchpad: pad}, fmt.Errorf("failed to generate step %d: %w", len(pad.Steps)+1, err)
+		}
+
+		for _, step := range steps {
+			if len(pad.Steps) >= a.maxSteps {
+				break
+			}
+			step.Index = len(pad.Steps) + 1
+			if !step.Final() {
+				if hooks.toolStart != nil {
+					hooks.toolStart(step)
+				}
+				a.observe(ctx, &step)
+				if err := ctx.Err(); err != nil {
+					return &Result{Scratchpad: pad}, err
+				}
+			}
+			pad.Steps = append(pad.Steps, step)
+			if hooks.step != nil {
+				hooks.step(step)
+			}
+			if step.Final() {
+				return &Result{Answer: step.FinalAnswer, Scratchpad: pad}, nil
+			}
+		}
+	}
+	return &Result{Scratchpad: pad}, ErrMaxSteps
+}
+
+// observe executes the tool call of a step and records any approval
+// decision. Tool failures and rejections are reported to the model as
+// observations so that it can recover.
+func (a *ReActAgent) observe(ctx context.Context, step *Step) {
+	observation, decision, err := a.registry.Execute(ctx, step.Action, step.ActionInput)
+	step.Approval = decision
+	if decision != nil && decision.Arguments != nil {
+		step.ActionInput = decision.Arguments
+	}
+	switch {
+	case errors.Is(err, tools.ErrRejected):
+		step.Observation = "The user rejected this action"
+		if decision != nil && decision.Reason != "" {
+			step.Observation += ": " + decision.Reason
+		}
+	case err != nil:
+		step.Observation = "Error: " + err.Error()
+	default:
+		step.Observation = observation
+	}
+}
+
+// systemPrompt describes the tools and the ReAct response format.
+func (a *ReActAgent) systemPrompt() string {
+	var b strings.Builder
+	b.WriteString("Answer the question as well as you can. You have access to the following tools:\n\n")
+	list := a.registry.List()
+	names := make([]string, len(list))
+	for i, tool := range list {
+		names[i] = tool.Name
+		parameters, _ := json.Marshal(tool.Definition().Function.Parameters)
+		fmt.Fprintf(&b, "- %s: %s\n  Arguments schema: %s\n", tool.Name, tool.Description, parameters)
+	}
+	if len(list) == 0 {
+		b.WriteString("(no tools)\n")
+	}
+	fmt.Fprintf(&b, `
+Use the following format:
+
+Thought: reason about what to do next
+Action: the tool to use, one of [%s]
+Action Input: the tool arguments as a JSON object
+Observation: the result of the tool, provided to you
+
+Thought, Action, Action Input and Observation can repeat several times. Write only one Action per response and stop after its Action Input; never write an Observation yourself. When you know the answer, respond with:
+
+Thought: I know the final answer
+Final Answer: the answer to the question
+`, strings.Join(names, ", "))
+	if a.instructions != "" {
+		b.WriteString("\n")
+		b.WriteString(a.instructions)
+	}
+	return b.String()
+}
+
+// parseResponse extracts the steps of a model response. Native function calls
+// take precedence over the text format; a response with neither an action nor
+// a final answer marker is treated as the final answer.
+func parseResponse(response string) []Step {
+	if calls, err := utils.ExtractFunctionCalls(response); err == nil && len(calls) > 0 {
+		cleaned, _, _ := utils.CleanResponse(response)
+		thought := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(cleaned), "Thought:"))
+		steps := make([]Step, 0, len(calls))
+		for _, call := range calls {
+			name, _ := call["name"].(string)
+			args, _ := call["arguments"].(map[string]interface{})
+			steps = append(steps, Step{Thought: thought, Action: name, ActionInput: args})
+			thought = ""
+		}
+		return steps
+	}
+
+	response = cutObservation(response)
+
+	if i := strings.Index(response, "Final Answer:"); i != -1 {
+		return []Step{{
+			Thought:     extractThought(response[:i]),
+			FinalAnswer: strings.TrimSpace(response[i+len("Final Answer:"):]),
+		}}
+	}
+
+	actionIndex := strings.Index(response, "Action:")
+	if actionIndex == -1 {
+		return []Step{{FinalAnswer: strings.TrimSpace(response)}}
+	}
+	step := Step{Thought: extractThought(response[:actionIndex])}
+	rest := response[actionIndex+len("Action:"):]
+	inputIndex := strings.Index(rest, "Action Input:")
+	if inputIndex == -1 {
+		step.Action = strings.TrimSpace(firstLine(rest))
+		step.ActionInput = map[string]interface{}{}
+		return []Step{step}
+	}
+	step.Action = strings.TrimSpace(firstLine(rest[:inputIndex]))
+	step.ActionInput = parseActionInput(rest[inputIndex+len("Action Input:"):])
+	return []Step{step}
+}
+
+// cutObservation discards anything after a hallucinated observation.
+func cutObservation(response string) string {
+	if i := strings.Index(response, observationMarker); i != -1 {
+		return response[:i]
+	}
+	return response
+}
+
+// extractThought returns the reasoning text preceding an action or answer.
+func extractThought(text string) string {
+	text = strings.TrimSpace(text)
+	if i := strings.LastIndex(text, "Thought:"); i != -1 {
+		text = text[i+len("Thought:"):]
+	}
+	return strings.TrimSpace(text)
+}
+
+// parseActionInput decodes the action input. Inputs that are not a JSON
+// object are passed as {"input": text}.
+func parseActionInput(text string) map[string]interface{} {
+	text = strings.TrimSpace(text)
+	text = strings.TrimPrefix(text, "```json")
+	text = strings.TrimPrefix(text, "```")
+	text = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(text), "```"))
+	if start, end := strings.Index(text, "{"), strings.LastIndex(text, "}"); start != -1 && end > start {
+		var args map[string]interface{}
+		if err := json.Unmarshal([]byte(text[start:end+1]), &args); err == nil {
+			return args
+		}
+	}
+	if text == "" {
+		return map[string]interface{}{}
+	}
+	return map[string]interface{}{"input": text}
+}
+
+// firstLine returns the text up to the first newline.
+func firstLine(text string) string {
+	text = strings.TrimSpace(text)
+	if i := strings.IndexByte(text, '\n'); i != -1 {
+		return text[:i]
+	}
+	return text
+}
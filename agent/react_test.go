@@ -0,0 +1,155 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm/gollmtest"
+	"github.com/teilomillet/gollm/tools"
+)
+
+func newTestRegistry(t *testing.T) *tools.Registry {
+	t.Helper()
+	registry, err := tools.NewRegistry(tools.Tool{
+		Name:        "population",
+		Description: "Returns the population of a city",
+		Handler: func(ctx context.Context, args map[string]interface{}) (string, error) {
+			city, err := tools.StringArg(args, "city")
+			if err != nil {
+				return "", err
+			}
+			if city != "Paris" {
+				return "", fmt.Errorf("unknown city %s", city)
+			}
+			return "2.1 million", nil
+		},
+	})
+	require.NoError(t, err)
+	return registry
+}
+
+func TestReActAgent(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("ToolThenAnswer", func(t *testing.T) {
+		l := &gollmtest.ScriptedLLM{Responses: []string{
+			"Thought: I need the population.\nAction: population\nAction Input: {\"city\": \"Lyon\"}\nObservation: made up",
+			"Thought: Try Paris instead.\nAction: population\nAction Input: {\"city\": \"Paris\"}",
+			"Thought: I know the final answer\nFinal Answer: About 2.1 million people.",
+		}}
+		var streamed []Step
+		a, err := NewReActAgent(l, newTestRegistry(t), WithStepHandler(func(s Step) { streamed = append(streamed, s) }))
+		require.NoError(t, err)
+
+		result, err := a.Run(ctx, "How many people live in Paris?")
+		require.NoError(t, err)
+		assert.Equal(t, "About 2.1 million people.", result.Answer)
+		require.Len(t, result.Scratchpad.Steps, 3)
+		assert.Equal(t, "Error: unknown city Lyon", result.Scratchpad.Steps[0].Observation)
+		assert.Equal(t, "2.1 million", result.Scratchpad.Steps[1].Observation)
+		assert.Equal(t, "Try Paris instead.", result.Scratchpad.Steps[1].Thought)
+		assert.Equal(t, result.Scratchpad.Steps, streamed)
+
+		require.Len(t, l.Prompts(), 3)
+		assert.Contains(t, l.Prompts()[0].SystemPrompt, "population: Returns the population of a city")
+		assert.Contains(t, l.Prompts()[2].Input, "Observation: 2.1 million")
+		assert.NotContains(t, l.Prompts()[1].Input, "made up")
+	})
+
+	t.Run("NativeToolCall", func(t *testing.T) {
+		l := &gollmtest.ScriptedLLM{Responses: []string{
+			`<function_call>{"name":"population","arguments":{"city":"Paris"}}</function_call>`,
+			"Final Answer: 2.1 million",
+		}}
+		a, err := NewReActAgent(l, newTestRegistry(t), WithNativeTools())
+		require.NoError(t, err)
+		result, err := a.Run(ctx, "Population of Paris?")
+		require.NoError(t, err)
+		assert.Equal(t, "2.1 million", result.Answer)
+		assert.Equal(t, "2.1 million", result.Scratchpad.Steps[0].Observation)
+		require.Len(t, l.Prompts()[0].Tools, 1)
+	})
+
+	t.Run("MaxSteps", func(t *testing.T) {
+		l := &gollmtest.ScriptedLLM{Responses: []string{
+			"Action: population\nAction Input: {\"city\": \"Paris\"}",
+			"Action: population\nAction Input: {\"city\": \"Paris\"}",
+		}}
+		a, err := NewReActAgent(l, newTestRegistry(t), WithMaxSteps(2))
+		require.NoError(t, err)
+		result, err := a.Run(ctx, "Population of Paris?")
+		assert.ErrorIs(t, err, ErrMaxSteps)
+		assert.Len(t, result.Scratchpad.Steps, 2)
+	})
+
+	t.Run("RejectedToolCall", func(t *testing.T) {
+		registry := newTestRegistry(t)
+		require.NoError(t, registry.Register(tools.Tool{
+			Name:             "evacuate",
+			Description:      "Orders the evacuation of a city",
+			RequiresApproval: true,
+			Handler: func(ctx context.Context, args map[string]interface{}) (string, error) {
+				return "evacuation ordered", nil
+			},
+		}))
+		registry.SetApprover(tools.ApproverFunc(func(ctx context.Context, req tools.ApprovalRequest) (tools.Decision, error) {
+			return tools.Decision{Approved: false, Reason: "not an emergency"}, nil
+		}))
+		l := &gollmtest.ScriptedLLM{Responses: []string{
+			"Action: evacuate\nAction Input: {\"city\": \"Paris\"}",
+			"Final Answer: The evacuation was not approved.",
+		}}
+		a, err := NewReActAgent(l, registry)
+		require.NoError(t, err)
+		result, err := a.Run(ctx, "Evacuate Paris")
+		require.NoError(t, err)
+		step := result.Scratchpad.Steps[0]
+		require.NotNil(t, step.Approval)
+		assert.False(t, step.Approval.Approved)
+		assert.Equal(t, "The user rejected this action: not an emergency", step.Observation)
+		assert.Contains(t, l.Prompts()[1].Input, "rejected this action")
+	})
+
+	t.Run("Stream", func(t *testing.T) {
+		l := &gollmtest.ScriptedLLM{Responses: []string{
+			"Action: population\nAction Input: {\"city\": \"Paris\"}",
+			"Final Answer: 2.1 million",
+		}}
+		a, err := NewReActAgent(l, newTestRegistry(t))
+		require.NoError(t, err)
+		var steps int
+		var result *Result
+		for event := range a.Stream(ctx, "Population of Paris?") {
+			require.NoError(t, event.Err)
+			if event.Step != nil {
+				steps++
+			}
+			if event.Result != nil {
+				result = event.Result
+			}
+		}
+		assert.Equal(t, 2, steps)
+		require.NotNil(t, result)
+		assert.Equal(t, "2.1 million", result.Answer)
+	})
+}
+
+func TestParseResponse(t *testing.T) {
+	steps := parseResponse("I think so.\nAction: search\nAction Input: golang generics")
+	require.Len(t, steps, 1)
+	assert.Equal(t, "I think so.", steps[0].Thought)
+	assert.Equal(t, "search", steps[0].Action)
+	assert.Equal(t, map[string]interface{}{"input": "golang generics"}, steps[0].ActionInput)
+
+	steps = parseResponse("Plain answer without markers.")
+	require.Len(t, steps, 1)
+	assert.True(t, steps[0].Final())
+	assert.Equal(t, "Plain answer without markers.", steps[0].FinalAnswer)
+
+	pad := &Scratchpad{Steps: []Step{{Action: "a", ActionInput: map[string]interface{}{}}, {Action: "b"}, {FinalAnswer: "done"}}, limit: 2}
+	assert.True(t, strings.HasPrefix(pad.String(), "(1 earlier steps omitted)"))
+}
@@ -0,0 +1,154 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/teilomillet/gollm"
+	"github.com/teilomillet/gollm/llm"
+)
+
+// EventType is the kind of an OutputEvent.
+type EventType string
+
+const (
+	EventText       EventType = "text"        // A chunk of model output
+	EventToolStart  EventType = "tool_start"  // A tool call is about to run
+	EventToolResult EventType = "tool_result" // A tool call completed with its observation
+	EventDone       EventType = "done"        // The run ended with its result or error
+)
+
+// OutputEvent is sent on the channel returned by StreamOutput. The fields set
+// depend on its type.
+type OutputEvent struct {
+	Type EventType
+
+	// Text is the chunk of model output of an EventText
+	Text string
+
+	// Step is the step of the tool call, without its observation for an
+	// EventToolStart and with it for an EventToolResult
+	Step *Step
+
+	// Result is the result of an EventDone, partial when the run failed
+	Result *Result
+
+	// Err is the error that ended the run, for an EventDone
+	Err error
+}
+
+// StreamOutput executes the ReAct loop in the background and streams the
+// model output as it is generated. When the model calls a tool, the tool runs
+// between an EventToolStart and an EventToolResult, after which the output of
+// the next model response is streamed. Events arrive in order and, unless the
+// context is canceled, the last is an EventDone; the channel is then closed.
+//
+// The model responses are requested with Stream, so the generate options of
+// the agent don't apply. LLMs that don't support streaming are sent each
+// response as a single EventText.
+//
+// Example usage:
+//
+//	for event := range a.StreamOutput(ctx, "What is 17% of 2340?") {
+//	    switch event.Type {
+//	    case agent.EventText:
+//	        fmt.Print(event.Text)
+//	    case agent.EventToolStart:
+//	        fmt.Printf("\n[running %s]\n", event.Step.Action)
+//	    case agent.EventDone:
+//	        if event.Err != nil {
+//	            log.Fatal(event.Err)
+//	        }
+//	    }
+//	}
+func (a *ReActAgent) StreamOutput(ctx context.Context, input string) <-chan OutputEvent {
+	events := make(chan OutputEvent)
+	send := func(event OutputEvent) {
+		select {
+		case events <- event:
+		case <-ctx.Done():
+		}
+	}
+	go func() {
+		defer close(events)
+		result, err := a.run(ctx, input, runHooks{
+			text: func(text string) {
+				send(OutputEvent{Type: EventText, Text: text})
+			},
+			toolStart: func(step Step) {
+				send(OutputEvent{Type: EventToolStart, Step: &step})
+			},
+			step: func(step Step) {
+				if a.onStep != nil {
+					a.onStep(step)
+				}
+				if !step.Final() {
+					send(OutputEvent{Type: EventToolResult, Step: &step})
+				}
+			},
+		})
+		send(OutputEvent{Type: EventDone, Result: result, Err: err})
+	}()
+	return events
+}
+
+// streamSteps streams a model response to fn and returns its steps. The
+// stream stops before any observation the model starts writing.
+func (a *ReActAgent) streamSteps(ctx context.Context, prompt *gollm.Prompt, fn func(string)) ([]Step, error) {
+	if !a.llm.SupportsStreaming() {
+		response, err := a.llm.Generate(ctx, prompt, a.generateOpts...)
+		if err != nil {
+			return nil, err
+		}
+		if text := cutObservation(response); text != "" {
+			fn(text)
+		}
+		return parseResponse(response), nil
+	}
+
+	stream, err := a.llm.Stream(ctx, prompt, llm.WithStopConditions(llm.StopOnSequence(observationMarker)))
+	if err != nil {
+		return nil, err
+	}
+	result, err := llm.CollectStream(ctx, textTap{TokenStream: stream, fn: fn})
+	if err != nil {
+		return nil, err
+	}
+	if len(result.ToolCalls) == 0 {
+		return parseResponse(result.Text), nil
+	}
+
+	thought := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(result.Text), "Thought:"))
+	steps := make([]Step, 0, len(result.ToolCalls))
+	for _, call := range result.ToolCalls {
+		args := map[string]interface{}{}
+		if len(call.Function.Arguments) > 0 {
+			var raw string
+			// Some providers encode the arguments as a JSON string
+			if json.Unmarshal(call.Function.Arguments, &raw) == nil {
+				args = parseActionInput(raw)
+			} else {
+				_ = json.Unmarshal(call.Function.Arguments, &args)
+			}
+		}
+		steps = append(steps, Step{Thought: thought, Action: call.Function.Name, ActionInput: args})
+		thought = ""
+	}
+	return steps, nil
+}
+
+// textTap passes the text tokens of a stream to fn as they are read.
+type textTap struct {
+	llm.TokenStream
+	fn func(string)
+}
+
+// Next returns the next token of the stream.
+func (t textTap) Next(ctx context.Context) (*llm.StreamToken, error) {
+	token, err := t.TokenStream.Next(ctx)
+	if err == nil && token.Text != "" && token.Type != llm.TokenTypeToolCall && token.Type != llm.TokenTypeDone {
+		t.fn(token.Text)
+	}
+	return token, err
+}
@@ -0,0 +1,62 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm/gollmtest"
+)
+
+func TestStreamOutput(t *testing.T) {
+	l, mock := gollmtest.NewMockLLM(t)
+	mock.QueueResponse(
+		"Thought: I need the population.\nAction: population\nAction Input: {\"city\": \"Paris\"}\nObservation: made up",
+		"Thought: I know the final answer\nFinal Answer: About 2.1 million people.",
+	)
+	a, err := NewReActAgent(l, newTestRegistry(t))
+	require.NoError(t, err)
+
+	var types []EventType
+	var text strings.Builder
+	var last OutputEvent
+	for event := range a.StreamOutput(context.Background(), "How many people live in Paris?") {
+		if n := len(types); n == 0 || types[n-1] != event.Type {
+			types = append(types, event.Type)
+		}
+		switch event.Type {
+		case EventText:
+			text.WriteString(event.Text)
+		case EventToolStart:
+			assert.Equal(t, "population", event.Step.Action)
+			assert.Empty(t, event.Step.Observation, "the tool hasn't run yet")
+		case EventToolResult:
+			assert.Equal(t, "2.1 million", event.Step.Observation)
+		}
+		last = event
+	}
+
+	assert.Equal(t, []EventType{EventText, EventToolStart, EventToolResult, EventText, EventDone}, types)
+	assert.NotContains(t, text.String(), "made up", "the stream stops at observations written by the model")
+	assert.Contains(t, text.String(), "Final Answer: About 2.1 million people.")
+	require.NoError(t, last.Err)
+	assert.Equal(t, "About 2.1 million people.", last.Result.Answer)
+	assert.Len(t, last.Result.Scratchpad.Steps, 2)
+}
+
+func TestStreamOutputWithoutStreaming(t *testing.T) {
+	l := &gollmtest.ScriptedLLM{Responses: []string{"Final Answer: 42"}}
+	a, err := NewReActAgent(l, nil)
+	require.NoError(t, err)
+
+	var events []OutputEvent
+	for event := range a.StreamOutput(context.Background(), "What is the answer?") {
+		events = append(events, event)
+	}
+	require.Len(t, events, 2)
+	assert.Equal(t, OutputEvent{Type: EventText, Text: "Final Answer: 42"}, events[0])
+	assert.Equal(t, EventDone, events[1].Type)
+	assert.Equal(t, "42", events[1].Result.Answer)
+}
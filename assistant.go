@@ -0,0 +1,65 @@
+// Package gollm provides assistant functionality for Language Learning Models.
+// This file contains type definitions and re-exports for assistants with
+// persistent instructions, tools and conversation threads.
+package gollm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/teilomillet/gollm/llm"
+	"github.com/teilomillet/gollm/providers"
+)
+
+// Re-export assistant types from the llm package
+type (
+	// Assistant is a model with persistent instructions and tools, hosted by
+	// the provider or emulated.
+	Assistant = llm.Assistant
+
+	// AssistantConfig configures an assistant.
+	AssistantConfig = llm.AssistantConfig
+
+	// Thread is a conversation with an assistant.
+	Thread = llm.Thread
+
+	// Run is the outcome of running an assistant on a thread.
+	Run = llm.Run
+
+	// ToolExecution records a tool call made during a run.
+	ToolExecution = llm.ToolExecution
+
+	// ToolExecutor runs the tools of an assistant, such as a tools.Registry.
+	ToolExecutor = llm.ToolExecutor
+
+	// ThreadMessage is a message of a thread.
+	ThreadMessage = providers.ThreadMessage
+
+	// RunStatus is the state of a run.
+	RunStatus = providers.RunStatus
+)
+
+// Run states.
+const (
+	RunQueued         = providers.RunQueued
+	RunInProgress     = providers.RunInProgress
+	RunRequiresAction = providers.RunRequiresAction
+	RunCompleted      = providers.RunCompleted
+	RunFailed         = providers.RunFailed
+	RunCanceled       = providers.RunCanceled
+	RunExpired        = providers.RunExpired
+)
+
+type assistantCreator interface {
+	NewAssistant(context.Context, llm.AssistantConfig) (*llm.Assistant, error)
+}
+
+// NewAssistant creates an assistant. It is hosted by "openai" and emulated
+// with the other providers.
+func (l *llmImpl) NewAssistant(ctx context.Context, config AssistantConfig) (*Assistant, error) {
+	a, ok := l.LLM.(assistantCreator)
+	if !ok {
+		return nil, fmt.Errorf("assistants not supported by provider %s", l.provider.Name())
+	}
+	return a.NewAssistant(ctx, config)
+}
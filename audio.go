@@ -0,0 +1,110 @@
+// Package gollm provides audio functionality for Language Learning Models.
+// This file contains type definitions and re-exports for speech-to-text transcription
+// and text-to-speech synthesis.
+package gollm
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/teilomillet/gollm/llm"
+)
+
+// Re-export transcription and speech types from the llm package
+type (
+	// Transcription holds the result of a speech-to-text request.
+	Transcription = llm.Transcription
+
+	// TranscriptionSegment is a timed portion of a transcription.
+	TranscriptionSegment = llm.TranscriptionSegment
+
+	// TranscriptionOption configures a transcription request.
+	TranscriptionOption = llm.TranscriptionOption
+
+	// LongTranscriptionOption configures the chunked transcription of long audio.
+	LongTranscriptionOption = llm.LongTranscriptionOption
+
+	// SpeechOption configures a text-to-speech request.
+	SpeechOption = llm.SpeechOption
+)
+
+// Re-export transcription and speech options from the llm package
+var (
+	// WithTranscriptionModel overrides the provider's default transcription model.
+	WithTranscriptionModel = llm.WithTranscriptionModel
+
+	// WithTranscriptionLanguage sets the language of the audio.
+	WithTranscriptionLanguage = llm.WithTranscriptionLanguage
+
+	// WithTranscriptionPrompt provides text to guide the transcription.
+	WithTranscriptionPrompt = llm.WithTranscriptionPrompt
+
+	// WithTranscriptionTemperature sets the sampling temperature.
+	WithTranscriptionTemperature = llm.WithTranscriptionTemperature
+
+	// WithTranscriptionFilename sets the file name of the uploaded audio.
+	WithTranscriptionFilename = llm.WithTranscriptionFilename
+
+	// WithTranscriptionMediaType sets the MIME type of the audio.
+	WithTranscriptionMediaType = llm.WithTranscriptionMediaType
+
+	// WithMaxChunkDuration sets the longest audio chunk of a long transcription.
+	WithMaxChunkDuration = llm.WithMaxChunkDuration
+
+	// WithMinSilence sets the shortest pause long audio is split on.
+	WithMinSilence = llm.WithMinSilence
+
+	// WithSilenceThreshold sets the loudness below which audio is silent.
+	WithSilenceThreshold = llm.WithSilenceThreshold
+
+	// WithChunkConcurrency sets the number of chunks transcribed at once.
+	WithChunkConcurrency = llm.WithChunkConcurrency
+
+	// WithTranscriptionOptions sets the options of the request of every chunk.
+	WithTranscriptionOptions = llm.WithTranscriptionOptions
+
+	// WithSpeechModel overrides the provider's default speech model.
+	WithSpeechModel = llm.WithSpeechModel
+
+	// WithSpeechFormat sets the audio output format.
+	WithSpeechFormat = llm.WithSpeechFormat
+
+	// WithSpeechSpeed sets the playback speed multiplier.
+	WithSpeechSpeed = llm.WithSpeechSpeed
+)
+
+// Transcribe converts speech audio to text using the configured provider.
+// Supported providers are "openai" (Whisper), "groq" and "deepgram".
+func (l *llmImpl) Transcribe(ctx context.Context, audio io.Reader, opts ...TranscriptionOption) (*Transcription, error) {
+	t, ok := l.LLM.(interface {
+		Transcribe(context.Context, io.Reader, ...llm.TranscriptionOption) (*llm.Transcription, error)
+	})
+	if !ok {
+		return nil, fmt.Errorf("transcription not supported by provider %s", l.provider.Name())
+	}
+	return t.Transcribe(ctx, audio, opts...)
+}
+
+// TranscribeLong transcribes long PCM WAV audio in chunks split at pauses.
+func (l *llmImpl) TranscribeLong(ctx context.Context, audio io.Reader, opts ...LongTranscriptionOption) (*Transcription, error) {
+	t, ok := l.LLM.(interface {
+		TranscribeLong(context.Context, io.Reader, ...llm.LongTranscriptionOption) (*llm.Transcription, error)
+	})
+	if !ok {
+		return nil, fmt.Errorf("transcription not supported by provider %s", l.provider.Name())
+	}
+	return t.TranscribeLong(ctx, audio, opts...)
+}
+
+// Speak converts text to speech using the configured provider and returns the audio stream.
+// The caller must close the returned stream. Supported providers are "openai" and "elevenlabs".
+func (l *llmImpl) Speak(ctx context.Context, text, voice string, opts ...SpeechOption) (io.ReadCloser, error) {
+	s, ok := l.LLM.(interface {
+		Speak(context.Context, string, string, ...llm.SpeechOption) (io.ReadCloser, error)
+	})
+	if !ok {
+		return nil, fmt.Errorf("speech synthesis not supported by provider %s", l.provider.Name())
+	}
+	return s.Speak(ctx, text, voice, opts...)
+}
@@ -0,0 +1,60 @@
+// Package gollm provides batch functionality for Language Learning Models.
+// This file contains type definitions and re-exports for running prompts
+// through a provider's asynchronous batch API.
+package gollm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/teilomillet/gollm/llm"
+	"github.com/teilomillet/gollm/providers"
+)
+
+// Re-export batch types from the llm package
+type (
+	// BatchItem is a prompt of a batch, identified by an ID unique within the batch.
+	BatchItem = llm.BatchItem
+
+	// BatchResult is the response or error of a prompt of a batch.
+	BatchResult = llm.BatchResult
+
+	// BatchJob tracks a batch run by the provider, whichever provider it is.
+	BatchJob = llm.BatchJob
+
+	// BatchStatus is the state of a batch.
+	BatchStatus = providers.BatchStatus
+)
+
+// Batch states.
+const (
+	BatchInProgress = providers.BatchInProgress
+	BatchCompleted  = providers.BatchCompleted
+	BatchFailed     = providers.BatchFailed
+	BatchCanceled   = providers.BatchCanceled
+	BatchExpired    = providers.BatchExpired
+)
+
+type batcher interface {
+	SubmitBatch(context.Context, []llm.BatchItem, ...llm.GenerateOption) (*llm.BatchJob, error)
+	GetBatch(context.Context, string) (*llm.BatchJob, error)
+}
+
+// SubmitBatch submits prompts to the provider's batch API.
+// Supported providers are "openai" and "anthropic".
+func (l *llmImpl) SubmitBatch(ctx context.Context, items []BatchItem, opts ...llm.GenerateOption) (*BatchJob, error) {
+	b, ok := l.LLM.(batcher)
+	if !ok {
+		return nil, fmt.Errorf("batches not supported by provider %s", l.provider.Name())
+	}
+	return b.SubmitBatch(ctx, items, opts...)
+}
+
+// GetBatch returns a previously submitted batch by ID.
+func (l *llmImpl) GetBatch(ctx context.Context, id string) (*BatchJob, error) {
+	b, ok := l.LLM.(batcher)
+	if !ok {
+		return nil, fmt.Errorf("batches not supported by provider %s", l.provider.Name())
+	}
+	return b.GetBatch(ctx, id)
+}
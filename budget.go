@@ -0,0 +1,347 @@
+package gollm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/teilomillet/gollm/llm"
+	"github.com/teilomillet/gollm/providers"
+)
+
+// Budget caps the spend of an LLM or a session, in tokens, USD or both.
+type Budget struct {
+	// Name identifies the budget in alerts and errors
+	Name string
+
+	// MaxTokens caps the input plus output tokens; zero means no token cap
+	MaxTokens int
+
+	// MaxCost caps the cost in USD of requests to models with known pricing;
+	// zero means no cost cap
+	MaxCost float64
+
+	// Thresholds are the fractions of the budget, e.g. 0.5 or 1, at which an
+	// alert is sent; defaults to 0.8 and 1. Each threshold fires once.
+	Thresholds []float64
+
+	// OnAlert is called when a threshold is crossed
+	OnAlert func(BudgetAlert)
+
+	// WebhookURL receives each alert as a JSON POST request, if set
+	WebhookURL string
+
+	// Downgrade serves requests once the budget is exhausted, e.g. a cheaper
+	// model. When nil, requests are rejected with ErrorTypeBudgetExceeded.
+	Downgrade LLM
+
+	// Catalog provides model prices; defaults to providers.DefaultModelCatalog()
+	Catalog *providers.ModelCatalog
+}
+
+// BudgetAlert reports that the spend of a budget crossed a threshold.
+type BudgetAlert struct {
+	Budget    string  `json:"budget"`
+	Threshold float64 `json:"threshold"`            // The threshold crossed, as a fraction of the budget
+	Used      float64 `json:"used"`                 // The fraction of the budget used
+	Tokens    int     `json:"tokens"`               // Tokens spent so far
+	Cost      float64 `json:"cost"`                 // USD spent so far
+	MaxTokens int     `json:"max_tokens,omitempty"` // The token cap, if any
+	MaxCost   float64 `json:"max_cost,omitempty"`   // The cost cap, if any
+}
+
+// BudgetedLLM is an LLM whose spend is tracked against a Budget. Once the
+// budget is exhausted, requests are sent to the Downgrade LLM or rejected.
+//
+// The budget is checked before each request, so the request crossing the cap
+// completes and may exceed it. Usage reported by the provider is used when
+// available; otherwise tokens are estimated from the prompt and response
+// lengths, as are those of streamed responses.
+type BudgetedLLM struct {
+	LLM
+	budget  Budget
+	tracker *CostTracker
+	parent  *BudgetedLLM
+	client  *http.Client
+
+	mu         sync.Mutex
+	thresholds []float64 // Sorted thresholds
+	alerted    int       // Number of thresholds already crossed
+}
+
+// NewBudgetedLLM wraps an LLM with a spend budget.
+//
+// Example usage:
+//
+//	budgeted := gollm.NewBudgetedLLM(l, gollm.Budget{
+//	    Name:       "production",
+//	    MaxCost:    50,
+//	    Thresholds: []float64{0.5, 0.9, 1},
+//	    WebhookURL: "https://hooks.example.com/llm-spend",
+//	    Downgrade:  cheap,
+//	})
+func NewBudgetedLLM(l LLM, budget Budget) *BudgetedLLM {
+	thresholds := append([]float64(nil), budget.Thresholds...)
+	if len(thresholds) == 0 {
+		thresholds = []float64{0.8, 1}
+	}
+	sort.Float64s(thresholds)
+	return &BudgetedLLM{
+		LLM:        l,
+		budget:     budget,
+		tracker:    NewCostTracker(budget.Catalog),
+		client:     &http.Client{Timeout: 10 * time.Second},
+		thresholds: thresholds,
+	}
+}
+
+// Session returns an LLM with its own budget, e.g. for a user or a
+// conversation, whose requests also count against this LLM's budget.
+// Requests are downgraded or rejected when either budget is exhausted.
+func (b *BudgetedLLM) Session(budget Budget) *BudgetedLLM {
+	if budget.Catalog == nil {
+		budget.Catalog = b.tracker.catalog
+	}
+	session := NewBudgetedLLM(b.LLM, budget)
+	session.parent = b
+	return session
+}
+
+// Spend returns the spend recorded against the budget.
+func (b *BudgetedLLM) Spend() Spend {
+	return b.tracker.Total()
+}
+
+// Tracker returns the tracker recording the spend, e.g. for a per-model
+// breakdown.
+func (b *BudgetedLLM) Tracker() *CostTracker {
+	return b.tracker
+}
+
+// Used returns the fraction of the budget used: the larger of the token and
+// cost fractions, zero without caps.
+func (b *BudgetedLLM) Used() float64 {
+	return b.used(b.Spend())
+}
+
+func (b *BudgetedLLM) used(spend Spend) float64 {
+	used := 0.0
+	if b.budget.MaxTokens > 0 {
+		used = float64(spend.Tokens()) / float64(b.budget.MaxTokens)
+	}
+	if b.budget.MaxCost > 0 {
+		used = math.Max(used, spend.Cost/b.budget.MaxCost)
+	}
+	return used
+}
+
+// Exceeded reports whether the budget is exhausted.
+func (b *BudgetedLLM) Exceeded() bool {
+	return b.Used() >= 1
+}
+
+// Generate sends the prompt to the LLM, or to the downgrade LLM once the
+// budget is exhausted, and records its usage.
+func (b *BudgetedLLM) Generate(ctx context.Context, prompt *Prompt, opts ...llm.GenerateOption) (string, error) {
+	return b.generate(ctx, prompt, opts, func(target LLM, opts []llm.GenerateOption) (string, error) {
+		return target.Generate(ctx, prompt, opts...)
+	})
+}
+
+// GenerateWithSchema is like Generate for responses conforming to a schema.
+func (b *BudgetedLLM) GenerateWithSchema(ctx context.Context, prompt *Prompt, schema interface{}, opts ...llm.GenerateOption) (string, error) {
+	return b.generate(ctx, prompt, opts, func(target LLM, opts []llm.GenerateOption) (string, error) {
+		return target.GenerateWithSchema(ctx, prompt, schema, opts...)
+	})
+}
+
+// GenerateFromTemplate executes the template and generates from the resulting
+// prompt within the budget.
+func (b *BudgetedLLM) GenerateFromTemplate(ctx context.Context, tmpl *PromptTemplate, vars map[string]interface{}, opts ...llm.GenerateOption) (string, error) {
+	prompt, err := tmpl.Execute(vars)
+	if err != nil {
+		return "", fmt.Errorf("failed to execute template %s: %w", tmpl.Name, err)
+	}
+	return b.Generate(ctx, prompt, opts...)
+}
+
+// Stream streams from the LLM, or from the downgrade LLM once the budget is
+// exhausted. The usage is recorded when the stream ends, estimated if the
+// provider does not report it.
+func (b *BudgetedLLM) Stream(ctx context.Context, prompt *Prompt, opts ...llm.StreamOption) (llm.TokenStream, error) {
+	target, err := b.target()
+	if err != nil {
+		return nil, err
+	}
+	stream, err := target.Stream(ctx, prompt, opts...)
+	if err != nil {
+		return nil, err
+	}
+	config := &llm.StreamConfig{}
+	for _, opt := range opts {
+		opt(config)
+	}
+	return &budgetStream{TokenStream: stream, budget: b, target: target, input: llm.EstimateTokens(prompt.String()), metadata: config.Metadata}, nil
+}
+
+func (b *BudgetedLLM) generate(ctx context.Context, prompt *Prompt, opts []llm.GenerateOption, call func(LLM, []llm.GenerateOption) (string, error)) (string, error) {
+	target, err := b.target()
+	if err != nil {
+		return "", err
+	}
+
+	// Capture the usage, sharing the caller's WithUsage if any
+	gen := &llm.GenerateConfig{}
+	for _, opt := range opts {
+		opt(gen)
+	}
+	usage := gen.Usage
+	if usage == nil {
+		usage = &llm.Usage{}
+		opts = append(opts[:len(opts):len(opts)], llm.WithUsage(usage))
+	}
+
+	response, err := call(target, opts)
+	if err != nil {
+		return "", err
+	}
+	recorded := *usage
+	if recorded.TotalTokens == 0 {
+		recorded = llm.Usage{InputTokens: llm.EstimateTokens(prompt.String()), OutputTokens: llm.EstimateTokens(response)}
+	}
+	b.record(target, recorded, gen.Metadata)
+	return response, nil
+}
+
+// target returns the LLM serving the next request: the wrapped LLM, or the
+// downgrade LLM of an exhausted budget of the session or its parents.
+func (b *BudgetedLLM) target() (LLM, error) {
+	target := b.LLM
+	for level := b; level != nil; level = level.parent {
+		if !level.Exceeded() {
+			continue
+		}
+		if level.budget.Downgrade == nil {
+			return nil, llm.NewLLMError(llm.ErrorTypeBudgetExceeded, fmt.Sprintf("budget %q exhausted", level.budget.Name), nil)
+		}
+		if target == b.LLM {
+			target = level.budget.Downgrade
+		}
+	}
+	return target, nil
+}
+
+// record adds the usage of a request to the budget and its parents and sends
+// the alerts of the thresholds crossed.
+func (b *BudgetedLLM) record(target LLM, usage llm.Usage, metadata map[string]string) {
+	for level := b; level != nil; level = level.parent {
+		level.tracker.RecordWithMetadata(target.GetProvider(), target.GetModel(), usage, metadata)
+		for _, alert := range level.crossed() {
+			level.alert(alert)
+		}
+	}
+}
+
+// crossed returns the alerts of the thresholds crossed since the last call.
+func (b *BudgetedLLM) crossed() []BudgetAlert {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	spend := b.tracker.Total()
+	used := b.used(spend)
+	var alerts []BudgetAlert
+	for b.alerted < len(b.thresholds) && used >= b.thresholds[b.alerted] {
+		alerts = append(alerts, BudgetAlert{
+			Budget:    b.budget.Name,
+			Threshold: b.thresholds[b.alerted],
+			Used:      used,
+			Tokens:    spend.Tokens(),
+			Cost:      spend.Cost,
+			MaxTokens: b.budget.MaxTokens,
+			MaxCost:   b.budget.MaxCost,
+		})
+		b.alerted++
+	}
+	return alerts
+}
+
+// alert calls the alert callback and posts the alert to the webhook in the
+// background.
+func (b *BudgetedLLM) alert(alert BudgetAlert) {
+	if b.budget.OnAlert != nil {
+		b.budget.OnAlert(alert)
+	}
+	if b.budget.WebhookURL == "" {
+		return
+	}
+	go func() {
+		if err := b.postAlert(alert); err != nil {
+			b.LLM.Debug("Failed to send budget alert", "budget", alert.Budget, "error", err)
+		}
+	}()
+}
+
+func (b *BudgetedLLM) postAlert(alert BudgetAlert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+	resp, err := b.client.Post(b.budget.WebhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// budgetStream records the usage of a stream once it ends: the usage
+// reported by the provider at the end of the stream, or an estimate.
+type budgetStream struct {
+	llm.TokenStream
+	budget   *BudgetedLLM
+	target   LLM
+	input    int
+	output   strings.Builder
+	reported *llm.Usage
+	metadata map[string]string
+	once     sync.Once
+}
+
+func (s *budgetStream) Next(ctx context.Context) (*llm.StreamToken, error) {
+	token, err := s.TokenStream.Next(ctx)
+	if token != nil {
+		s.output.WriteString(token.Text)
+		if token.Type == llm.TokenTypeDone && token.Done != nil && token.Done.Usage != nil {
+			s.reported = token.Done.Usage
+		}
+	}
+	if err == io.EOF {
+		s.finish()
+	}
+	return token, err
+}
+
+func (s *budgetStream) Close() error {
+	s.finish()
+	return s.TokenStream.Close()
+}
+
+func (s *budgetStream) finish() {
+	s.once.Do(func() {
+		if s.reported != nil && s.reported.TotalTokens > 0 {
+			s.budget.record(s.target, *s.reported, s.metadata)
+			return
+		}
+		s.budget.record(s.target, llm.Usage{InputTokens: s.input, OutputTokens: llm.EstimateTokens(s.output.String())}, s.metadata)
+	})
+}
@@ -0,0 +1,143 @@
+package gollm_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm"
+	"github.com/teilomillet/gollm/gollmtest"
+	"github.com/teilomillet/gollm/llm"
+	"github.com/teilomillet/gollm/providers"
+)
+
+// newBudgetLLM returns a mock LLM of the model always answering response.
+func newBudgetLLM(t *testing.T, model, response string) gollm.LLM {
+	t.Helper()
+	l, mock := gollmtest.NewMockLLM(t, gollm.SetModel(model))
+	mock.SetDefaultResponse(response)
+	return l
+}
+
+func TestBudgetedLLM(t *testing.T) {
+	// One dollar per token; the mock counts words as tokens
+	catalog := providers.NewModelCatalog(
+		providers.ModelInfo{Provider: "mock", Model: "main", InputPerMillion: 1e6, OutputPerMillion: 1e6},
+		providers.ModelInfo{Provider: "mock", Model: "cheap", InputPerMillion: 1, OutputPerMillion: 1},
+	)
+	prompt := gollm.NewPrompt("one two")
+	ctx := context.Background()
+
+	t.Run("TokenCapAndAlerts", func(t *testing.T) {
+		webhook := make(chan gollm.BudgetAlert, 2)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var alert gollm.BudgetAlert
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&alert))
+			webhook <- alert
+		}))
+		defer server.Close()
+
+		var alerts []gollm.BudgetAlert
+		budgeted := gollm.NewBudgetedLLM(newBudgetLLM(t, "main", "a b c"), gollm.Budget{
+			Name:       "test",
+			MaxTokens:  20,
+			Catalog:    catalog,
+			OnAlert:    func(a gollm.BudgetAlert) { alerts = append(alerts, a) },
+			WebhookURL: server.URL,
+		})
+
+		var usage llm.Usage
+		_, err := budgeted.Generate(ctx, prompt, llm.WithUsage(&usage))
+		require.NoError(t, err)
+		assert.Equal(t, 9, usage.TotalTokens, "the caller's usage is still reported")
+		assert.Empty(t, alerts)
+
+		_, err = budgeted.Generate(ctx, prompt)
+		require.NoError(t, err)
+		require.Len(t, alerts, 1)
+		assert.Equal(t, 0.8, alerts[0].Threshold)
+		assert.Equal(t, 18, alerts[0].Tokens)
+		assert.Equal(t, 18.0, alerts[0].Cost)
+
+		_, err = budgeted.Generate(ctx, prompt)
+		require.NoError(t, err, "the request crossing the cap completes")
+		require.Len(t, alerts, 2)
+		assert.Equal(t, 1.0, alerts[1].Threshold)
+		assert.True(t, budgeted.Exceeded())
+
+		_, err = budgeted.Generate(ctx, prompt)
+		var llmErr *llm.LLMError
+		require.True(t, errors.As(err, &llmErr))
+		assert.Equal(t, llm.ErrorTypeBudgetExceeded, llmErr.Type)
+		assert.Equal(t, 3, budgeted.Spend().Requests)
+
+		for i := 0; i < 2; i++ {
+			select {
+			case alert := <-webhook:
+				assert.Equal(t, "test", alert.Budget)
+			case <-time.After(5 * time.Second):
+				t.Fatal("webhook not called")
+			}
+		}
+	})
+
+	t.Run("Downgrade", func(t *testing.T) {
+		budgeted := gollm.NewBudgetedLLM(newBudgetLLM(t, "main", "main"), gollm.Budget{
+			MaxCost:   3,
+			Catalog:   catalog,
+			Downgrade: newBudgetLLM(t, "cheap", "cheap"),
+		})
+		response, err := budgeted.Generate(ctx, prompt)
+		require.NoError(t, err)
+		assert.Equal(t, "main", response)
+
+		response, err = budgeted.Generate(ctx, prompt)
+		require.NoError(t, err)
+		assert.Equal(t, "cheap", response)
+		byModel := budgeted.Tracker().ByModel()
+		assert.Equal(t, 1, byModel["mock/main"].Requests)
+		assert.Equal(t, 1, byModel["mock/cheap"].Requests)
+	})
+
+	t.Run("Sessions", func(t *testing.T) {
+		budgeted := gollm.NewBudgetedLLM(newBudgetLLM(t, "main", "a b c"), gollm.Budget{Name: "global", MaxTokens: 27, Catalog: catalog})
+		alice := budgeted.Session(gollm.Budget{Name: "alice", MaxTokens: 9})
+		bob := budgeted.Session(gollm.Budget{Name: "bob", MaxTokens: 100})
+
+		_, err := alice.Generate(ctx, prompt)
+		require.NoError(t, err)
+		_, err = alice.Generate(ctx, prompt)
+		assert.ErrorContains(t, err, `"alice"`)
+
+		_, err = bob.Generate(ctx, prompt)
+		require.NoError(t, err)
+		assert.Equal(t, 18, budgeted.Spend().Tokens(), "sessions count against the parent budget")
+
+		_, err = bob.Generate(ctx, prompt)
+		require.NoError(t, err)
+		_, err = bob.Generate(ctx, prompt)
+		assert.ErrorContains(t, err, `"global"`)
+	})
+
+	t.Run("Metadata", func(t *testing.T) {
+		budgeted := gollm.NewBudgetedLLM(newBudgetLLM(t, "main", "a b c"), gollm.Budget{Name: "features", Catalog: catalog})
+		_, err := budgeted.Generate(ctx, prompt, gollm.WithMetadata(map[string]string{"feature": "summary"}))
+		require.NoError(t, err)
+		_, err = budgeted.Generate(ctx, prompt, gollm.WithMetadata(map[string]string{"feature": "chat"}))
+		require.NoError(t, err)
+		_, err = budgeted.Generate(ctx, prompt)
+		require.NoError(t, err)
+
+		byFeature := budgeted.Tracker().ByMetadata("feature")
+		require.Len(t, byFeature, 2)
+		assert.Equal(t, 1, byFeature["summary"].Requests)
+		assert.Equal(t, 9.0, byFeature["chat"].Cost)
+		assert.Equal(t, 3, budgeted.Spend().Requests)
+	})
+}
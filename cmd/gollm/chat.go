@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/teilomillet/gollm"
+)
+
+// chatOptions configures an interactive chat session.
+type chatOptions struct {
+	system  string
+	history int // Number of previous messages sent with each turn
+	stream  bool
+}
+
+// runChat reads messages from in and writes the replies to out until EOF or
+// /exit. The conversation is kept in memory and sent with every turn;
+// /reset forgets it.
+func runChat(ctx context.Context, l gollm.LLM, in io.Reader, out io.Writer, opts chatOptions) error {
+	fmt.Fprintf(out, "Chatting with %s/%s. Type /reset to clear the conversation, /exit to quit.\n", l.GetProvider(), l.GetModel())
+
+	var messages []gollm.PromptMessage
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for {
+		fmt.Fprint(out, "> ")
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			return scanner.Err()
+		}
+		input := strings.TrimSpace(scanner.Text())
+		switch input {
+		case "":
+			continue
+		case "/exit", "/quit":
+			return nil
+		case "/reset":
+			messages = nil
+			fmt.Fprintln(out, "Conversation cleared.")
+			continue
+		}
+
+		messages = append(messages, gollm.PromptMessage{Role: "user", Content: input})
+		if opts.history >= 0 && len(messages) > opts.history+1 {
+			messages = messages[len(messages)-opts.history-1:]
+		}
+		// The history is sent without the input, which is the prompt's own
+		prompt := gollm.NewPrompt(input, gollm.WithMessages(messages[:len(messages)-1:len(messages)-1]))
+		if opts.system != "" {
+			prompt.Apply(gollm.WithSystemPrompt(opts.system, ""))
+		}
+
+		var reply string
+		var err error
+		if opts.stream && l.SupportsStreaming() {
+			reply, err = gollm.StreamToWriter(ctx, l, prompt, out)
+			fmt.Fprintln(out)
+		} else {
+			reply, err = l.Generate(ctx, prompt)
+			if err == nil {
+				fmt.Fprintln(out, reply)
+			}
+		}
+		if err != nil {
+			// Drop the unanswered message so the user can retry
+			messages = messages[:len(messages)-1]
+			fmt.Fprintf(out, "Error: %v\n", err)
+			continue
+		}
+		messages = append(messages, gollm.PromptMessage{Role: "assistant", Content: reply})
+	}
+}
+
+// readPipedInput returns the content piped to stdin, or an empty string when
+// stdin is a terminal.
+func readPipedInput(f *os.File) (string, error) {
+	info, err := f.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice != 0 {
+		return "", nil
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// readSchema loads a JSON schema file.
+func readSchema(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema: %w", err)
+	}
+	var schema map[string]interface{}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("invalid schema %s: %w", path, err)
+	}
+	return schema, nil
+}
@@ -35,6 +35,13 @@ func main() {
 	optimizeIterations := flag.Int("optimize-iterations", 5, "Number of optimization iterations")
 	optimizeMemory := flag.Int("optimize-memory", 2, "Number of previous iterations to remember")
 
+	// Flags for chat, streaming and structured output
+	chat := flag.Bool("chat", false, "Start an interactive chat session")
+	history := flag.Int("history", 20, "Number of previous chat messages sent with each turn")
+	stream := flag.Bool("stream", false, "Stream the response as it is generated")
+	schemaFile := flag.String("schema", "", "JSON schema file the response must conform to")
+	system := flag.String("system", "", "System prompt")
+
 	flag.Parse()
 
 	// Prepare configuration options
@@ -47,14 +54,30 @@ func main() {
 		os.Exit(1)
 	}
 
-	if len(flag.Args()) < 1 {
-		fmt.Fprintf(os.Stderr, "Usage: %s [flags] <prompt>\n", os.Args[0])
-		flag.PrintDefaults()
-		os.Exit(1)
+	ctx := context.Background()
+	if *chat {
+		if err := runChat(ctx, llmClient, os.Stdin, os.Stdout, chatOptions{system: *system, history: *history, stream: *stream}); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
 	}
 
+	// Piped input is used as context, or as the prompt when none is given
+	stdin, err := readPipedInput(os.Stdin)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading stdin: %v\n", err)
+		os.Exit(1)
+	}
 	rawPrompt := strings.Join(flag.Args(), " ")
-	ctx := context.Background()
+	if rawPrompt == "" {
+		rawPrompt, stdin = stdin, ""
+	}
+	if rawPrompt == "" {
+		fmt.Fprintf(os.Stderr, "Usage: %s [flags] <prompt>\n       <input> | %s [flags] [prompt]\n       %s -chat [flags]\n", os.Args[0], os.Args[0], os.Args[0])
+		flag.PrintDefaults()
+		os.Exit(1)
+	}
 
 	var response string
 	var fullPrompt string
@@ -84,11 +107,34 @@ func main() {
 		}
 	default:
 		prompt := gollm.NewPrompt(rawPrompt)
-		if *outputFormat == "json" {
-			prompt.Apply(gollm.WithOutput("Please provide your response in JSON format."))
+		if stdin != "" {
+			prompt.Apply(gollm.WithContext(stdin))
+		}
+		if *system != "" {
+			prompt.Apply(gollm.WithSystemPrompt(*system, ""))
 		}
-		response, err = llmClient.Generate(ctx, prompt, gollm.WithJSONSchemaValidation())
 		fullPrompt = prompt.String()
+		switch {
+		case *schemaFile != "":
+			var schema map[string]interface{}
+			if schema, err = readSchema(*schemaFile); err == nil {
+				response, err = llmClient.GenerateWithSchema(ctx, prompt, schema)
+				*outputFormat = "json"
+			}
+		case *stream && llmClient.SupportsStreaming():
+			if *verbose {
+				fmt.Printf("Prompt Type: %s\nFull Prompt:\n%s\n\nResponse:\n---------\n", *promptType, fullPrompt)
+			}
+			if _, err = gollm.StreamToWriter(ctx, llmClient, prompt, os.Stdout); err == nil {
+				fmt.Println()
+				return
+			}
+		default:
+			if *outputFormat == "json" {
+				prompt.Apply(gollm.WithOutput("Please provide your response in JSON format."))
+			}
+			response, err = llmClient.Generate(ctx, prompt, gollm.WithJSONSchemaValidation())
+		}
 	}
 
 	if err != nil {
@@ -36,6 +36,22 @@ type (
 	//   cfg = ApplyOptions(cfg, SetLogLevel(LogLevelInfo))
 	LogLevel = utils.LogLevel
 
+	// Clock tells the time and waits for retries and cooldowns. Set it with
+	// SetClock; FakeClock makes tests deterministic.
+	Clock = utils.Clock
+
+	// FakeClock is a Clock whose time only moves when advanced.
+	//
+	// Example usage:
+	//   clock := NewFakeClock(time.Now())
+	//   clock.SetAutoAdvance(true)
+	//   llm, _ := NewLLM(SetClock(clock), SetMaxRetries(3))
+	FakeClock = utils.FakeClock
+
+	// Rand is a source of random numbers for sampling decisions, such as the
+	// variant of an experiment.
+	Rand = utils.Rand
+
 	// MemoryOption configures the memory settings for conversation history.
 	// It controls how much context is retained between interactions.
 	//
@@ -43,6 +59,21 @@ type (
 	//   cfg := NewConfig()
 	//   cfg = ApplyOptions(cfg, SetMemory(MemoryOption{MaxHistory: 10}))
 	MemoryOption = config.MemoryOption
+
+	// Profile is a named provider, model and options that a request can
+	// select with WithProfile.
+	//
+	// Example usage:
+	//   llm, _ := NewLLM(SetProfile("fast", Profile{Provider: "openai", Model: "gpt-4o-mini"}))
+	//   llm.Generate(ctx, prompt, WithProfile("fast"))
+	Profile = config.Profile
+
+	// RawRequest is a provider HTTP request passed to the SetOnRawRequest hook,
+	// with credentials redacted.
+	RawRequest = config.RawRequest
+
+	// RawResponse is a provider HTTP response passed to the SetOnRawResponse hook.
+	RawResponse = config.RawResponse
 )
 
 // Re-export core configuration functions
@@ -58,6 +89,13 @@ var (
 	//   }
 	LoadConfig = config.LoadConfig
 
+	// LoadConfigFile loads configuration from environment variables and applies
+	// the settings of a YAML or JSON config file on top.
+	//
+	// Example usage:
+	//   cfg, err := LoadConfigFile("gollm.yaml")
+	LoadConfigFile = config.LoadConfigFile
+
 	// ApplyOptions applies a series of ConfigOption functions to a Config instance.
 	// This enables fluent configuration updates using the builder pattern.
 	//
@@ -74,8 +112,10 @@ var (
 	// Provider configuration
 	SetProvider       = config.SetProvider       // Sets the LLM provider (e.g., "openai", "anthropic")
 	SetModel          = config.SetModel          // Sets the model name for the selected provider
+	SetModelAlias     = config.SetModelAlias     // Maps a logical model name to a provider and model
 	SetOllamaEndpoint = config.SetOllamaEndpoint // Sets the endpoint URL for Ollama local deployment
 	SetAPIKey         = config.SetAPIKey         // Sets the API key for the current provider
+	SetAPIKeySecret   = config.SetAPIKeySecret   // Fetches a provider's API key from a secret source
 
 	// Generation parameters
 	SetTemperature      = config.SetTemperature      // Controls randomness in generation (0.0-1.0)
@@ -84,6 +124,9 @@ var (
 	SetFrequencyPenalty = config.SetFrequencyPenalty // Penalizes frequent token usage
 	SetPresencePenalty  = config.SetPresencePenalty  // Penalizes repeated tokens
 	SetSeed             = config.SetSeed             // Sets random seed for reproducible generation
+	SetDeterministic    = config.SetDeterministic    // Enforces temperature 0, top_p 1 and a fixed seed
+	SetLogprobs         = config.SetLogprobs         // Returns per-token log probabilities with the top N alternatives
+	SetReasoningBudget  = config.SetReasoningBudget  // Sets the tokens a reasoning model may spend thinking
 
 	// Advanced generation parameters
 	SetMinP          = config.SetMinP          // Sets minimum probability threshold
@@ -95,18 +138,35 @@ var (
 	SetTfsZ          = config.SetTfsZ          // Sets tail-free sampling parameter
 
 	// Runtime configuration
-	SetTimeout      = config.SetTimeout      // Sets request timeout duration
-	SetMaxRetries   = config.SetMaxRetries   // Sets maximum retry attempts
-	SetRetryDelay   = config.SetRetryDelay   // Sets delay between retries
-	SetLogLevel     = config.SetLogLevel     // Sets logging verbosity
-	SetExtraHeaders = config.SetExtraHeaders // Sets additional HTTP headers
+	SetTimeout            = config.SetTimeout            // Sets request timeout duration
+	SetMaxRetries         = config.SetMaxRetries         // Sets maximum retry attempts
+	SetRetryDelay         = config.SetRetryDelay         // Sets delay between retries
+	SetClock              = config.SetClock              // Sets the clock timing retries, waits and timestamps
+	SetRateLimit          = config.SetRateLimit          // Limits requests per second
+	SetMaxConcurrency     = config.SetMaxConcurrency     // Limits concurrent requests per provider
+	SetWarmUp             = config.SetWarmUp             // Opens a connection to the provider on creation
+	SetKeepAlive          = config.SetKeepAlive          // Pings idle HTTP/2 connections to the provider
+	SetRequestCompression = config.SetRequestCompression // Compresses large request bodies with gzip
+	SetLogLevel           = config.SetLogLevel           // Sets logging verbosity
+	SetExtraHeaders       = config.SetExtraHeaders       // Sets additional HTTP headers
+	SetFixtures           = config.SetFixtures           // Records or replays provider traffic with fixture files
+	SetOnRawRequest       = config.SetOnRawRequest       // Calls a hook with every raw provider request
+	SetOnRawResponse      = config.SetOnRawResponse      // Calls a hook with every raw provider response
 
 	// Feature toggles
-	SetEnableCaching = config.SetEnableCaching // Enables/disables response caching
-	SetMemory        = config.SetMemory        // Configures conversation memory
+	SetEnableCaching  = config.SetEnableCaching  // Enables/disables response caching
+	SetMemory         = config.SetMemory         // Configures conversation memory
+	SetMemoryStrategy = config.SetMemoryStrategy // Selects the messages memory keeps before each request
+	SetAutoModerate   = config.SetAutoModerate   // Moderates every prompt and response
+	SetProfile        = config.SetProfile        // Adds a named provider/model profile
 
 	// Configuration creation
 	NewConfig = config.NewConfig // Creates a new Config with default values
+
+	// Time and randomness
+	SystemClock  = utils.SystemClock  // Returns the clock of the system
+	NewFakeClock = utils.NewFakeClock // Creates a clock that only moves when advanced
+	SystemRand   = utils.SystemRand   // Returns the shared random source of math/rand
 )
 
 // LogLevel constants define available logging verbosity levels
@@ -117,3 +177,9 @@ const (
 	LogLevelInfo  = utils.LogLevelInfo  // Logs info, warnings, and errors
 	LogLevelDebug = utils.LogLevelDebug // Logs all messages including debug
 )
+
+// Fixture modes for SetFixtures
+const (
+	FixtureModeRecord = config.FixtureModeRecord // Saves provider traffic to fixture files
+	FixtureModeReplay = config.FixtureModeReplay // Serves responses from fixture files without network access
+)
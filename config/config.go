@@ -4,11 +4,15 @@
 package config
 
 import (
+	"context"
+	"fmt"
 	"os"
 	"strings"
 	"time"
 
 	"github.com/caarlos0/env/v11"
+	"github.com/teilomillet/gollm/secrets"
+	"github.com/teilomillet/gollm/types"
 	"github.com/teilomillet/gollm/utils"
 )
 
@@ -18,6 +22,11 @@ type MemoryOption struct {
 	// MaxTokens specifies the maximum number of tokens to retain in memory
 	// for context in subsequent interactions.
 	MaxTokens int
+
+	// Strategy selects the messages kept before each request, e.g. the last
+	// turns only. Without a strategy, the oldest messages are dropped once
+	// the memory exceeds MaxTokens.
+	Strategy types.MemoryStrategy
 }
 
 // Config represents the complete configuration for LLM interactions.
@@ -37,10 +46,19 @@ type MemoryOption struct {
 //   - LLM_TIMEOUT: Request timeout duration (default: 30s)
 //   - LLM_MAX_RETRIES: Maximum retry attempts (default: 3)
 //   - LLM_RETRY_DELAY: Delay between retries (default: 2s)
+//   - LLM_RATE_LIMIT: Maximum requests per second, 0 for no limit (default: 0)
+//   - LLM_WARM_UP: Open a connection to the provider on creation (default: false)
+//   - LLM_KEEP_ALIVE: Period after which idle HTTP/2 connections are pinged, 0 to disable (default: 0)
+//   - LLM_COMPRESS_REQUESTS: Minimum size in bytes of gzip-compressed request bodies, 0 to disable (default: 0)
 //   - LLM_LOG_LEVEL: Logging verbosity (default: "WARN")
 //   - LLM_SEED: Random seed for reproducible generation
+//   - LLM_DETERMINISTIC: Enforce reproducible sampling (default: false)
+//   - LLM_LOGPROBS: Number of alternatives returned with each token's log probability
+//   - LLM_REASONING_BUDGET: Tokens a reasoning model may spend thinking
 //   - LLM_ENABLE_CACHING: Enable response caching (default: false)
 //   - LLM_ENABLE_STREAMING: Enable streaming responses (default: false)
+//   - LLM_FIXTURE_MODE: Record or replay HTTP fixtures ("record" or "replay")
+//   - LLM_FIXTURE_DIR: Directory holding HTTP fixtures (default: "testdata/fixtures")
 //
 // Advanced Parameters:
 //   - LLM_MIN_P: Minimum token probability threshold
@@ -62,9 +80,17 @@ type Config struct {
 	Timeout               time.Duration     `env:"LLM_TIMEOUT" envDefault:"30s"`
 	MaxRetries            int               `env:"LLM_MAX_RETRIES" envDefault:"3"`
 	RetryDelay            time.Duration     `env:"LLM_RETRY_DELAY" envDefault:"2s"`
+	RateLimit             float64           `env:"LLM_RATE_LIMIT" envDefault:"0" validate:"gte=0"`
+	MaxConcurrency        int               `env:"LLM_MAX_CONCURRENCY" envDefault:"0" validate:"gte=0"`
+	WarmUp                bool              `env:"LLM_WARM_UP" envDefault:"false"`
+	KeepAlive             time.Duration     `env:"LLM_KEEP_ALIVE" envDefault:"0"`
+	CompressRequests      int               `env:"LLM_COMPRESS_REQUESTS" envDefault:"0" validate:"gte=0"`
 	APIKeys               map[string]string `validate:"required,apikey"`
 	LogLevel              utils.LogLevel    `env:"LLM_LOG_LEVEL" envDefault:"WARN"`
 	Seed                  *int              `env:"LLM_SEED"`
+	Deterministic         bool              `env:"LLM_DETERMINISTIC" envDefault:"false"`
+	Logprobs              *int              `env:"LLM_LOGPROBS" validate:"omitempty,gte=0,lte=20"`
+	ReasoningBudget       *int              `env:"LLM_REASONING_BUDGET" validate:"omitempty,gte=0"`
 	MinP                  *float64          `env:"LLM_MIN_P" envDefault:"0.05"`
 	RepeatPenalty         *float64          `env:"LLM_REPEAT_PENALTY" envDefault:"1.1"`
 	RepeatLastN           *int              `env:"LLM_REPEAT_LAST_N" envDefault:"64"`
@@ -75,11 +101,40 @@ type Config struct {
 	SystemPrompt          string
 	SystemPromptCacheType string
 	ExtraHeaders          map[string]string
-	EnableCaching         bool `env:"LLM_ENABLE_CACHING" envDefault:"false"`
-	EnableStreaming       bool `env:"LLM_ENABLE_STREAMING" envDefault:"false"`
+	EnableCaching         bool   `env:"LLM_ENABLE_CACHING" envDefault:"false"`
+	EnableStreaming       bool   `env:"LLM_ENABLE_STREAMING" envDefault:"false"`
+	AutoModerate          bool   `env:"LLM_AUTO_MODERATE" envDefault:"false"`
+	FixtureMode           string `env:"LLM_FIXTURE_MODE" validate:"omitempty,oneof=record replay"`
+	FixtureDir            string `env:"LLM_FIXTURE_DIR" envDefault:"testdata/fixtures"`
 	MemoryOption          *MemoryOption
+	Profiles              map[string]Profile
+	APIKeySecrets         map[string]SecretRef
+	ModelAliases          map[string]ModelRoute
+	OnRawRequest          RawRequestHook
+	OnRawResponse         RawResponseHook
+	Clock                 utils.Clock
+}
+
+// Profile is a named provider and model selectable per request, e.g. a
+// "fast" profile for simple tasks and a "smart" one for hard ones, so a
+// single LLM can serve both. The API key is taken from Config.APIKeys.
+type Profile struct {
+	Provider string                 `yaml:"provider"` // Provider name; defaults to the configured provider
+	Model    string                 `yaml:"model"`    // Model name
+	Options  map[string]interface{} `yaml:"options"`  // Provider options (e.g., "temperature") for requests using the profile
 }
 
+// Fixture modes for recording and replaying provider HTTP traffic.
+const (
+	// FixtureModeRecord sends requests to the provider and saves each
+	// request and response to a fixture file.
+	FixtureModeRecord = "record"
+
+	// FixtureModeReplay serves responses from fixture files without any
+	// network access or API key.
+	FixtureModeReplay = "replay"
+)
+
 // LoadConfig creates a new Config instance, loading values from environment
 // variables and automatically detecting API keys. It returns an error if
 // environment variable parsing fails.
@@ -239,6 +294,62 @@ func SetRetryDelay(retryDelay time.Duration) ConfigOption {
 	}
 }
 
+// SetClock sets the clock timing retry delays and other waits of the LLM,
+// e.g. a utils.FakeClock so tests run without waiting. Defaults to the
+// system clock.
+func SetClock(clock utils.Clock) ConfigOption {
+	return func(c *Config) {
+		c.Clock = clock
+	}
+}
+
+// SetRateLimit limits the requests sent to the provider to the given number
+// per second. Zero disables the limit.
+func SetRateLimit(requestsPerSecond float64) ConfigOption {
+	return func(c *Config) {
+		c.RateLimit = requestsPerSecond
+	}
+}
+
+// SetMaxConcurrency limits the requests in flight to the provider, across
+// every LLM using it, to n. Further requests wait in a queue ordered by
+// priority (see llm.WithPriority). Zero disables the limit.
+func SetMaxConcurrency(n int) ConfigOption {
+	return func(c *Config) {
+		c.MaxConcurrency = n
+	}
+}
+
+// SetWarmUp opens a connection to the provider in the background when the
+// LLM is created, so that the first request doesn't pay for the TCP and TLS
+// handshakes.
+func SetWarmUp(enabled bool) ConfigOption {
+	return func(c *Config) {
+		c.WarmUp = enabled
+	}
+}
+
+// SetKeepAlive pings idle HTTP/2 connections to the provider after the given
+// period, replacing broken ones before a request needs them. Zero keeps the
+// default, which doesn't ping. The connections of a provider are shared by
+// its LLMs: the period of the first one created applies.
+func SetKeepAlive(period time.Duration) ConfigOption {
+	return func(c *Config) {
+		c.KeepAlive = period
+	}
+}
+
+// SetRequestCompression compresses request bodies of at least minSize bytes
+// with gzip, e.g. large-context prompts over slow links. Only enable it for
+// endpoints that accept compressed requests, such as gateways in front of
+// the provider. Zero disables compression. Compressed responses are always
+// decompressed.
+func SetRequestCompression(minSize int) ConfigOption {
+	return func(c *Config) {
+		c.CompressRequests = minSize
+	}
+}
+
 // SetLogLevel sets the logging verbosity.
 func SetLogLevel(level utils.LogLevel) ConfigOption {
 	return func(c *Config) {
@@ -249,9 +360,22 @@ func SetLogLevel(level utils.LogLevel) ConfigOption {
 // SetMemory sets the conversation memory settings.
 func SetMemory(maxTokens int) ConfigOption {
 	return func(c *Config) {
-		c.MemoryOption = &MemoryOption{
-			MaxTokens: maxTokens,
+		if c.MemoryOption == nil {
+			c.MemoryOption = &MemoryOption{}
+		}
+		c.MemoryOption.MaxTokens = maxTokens
+	}
+}
+
+// SetMemoryStrategy enables conversation memory, trimmed before each request
+// by strategy. Combined with SetMemory, the strategy also receives the token
+// limit; alone, the memory has no token limit.
+func SetMemoryStrategy(strategy types.MemoryStrategy) ConfigOption {
+	return func(c *Config) {
+		if c.MemoryOption == nil {
+			c.MemoryOption = &MemoryOption{}
 		}
+		c.MemoryOption.Strategy = strategy
 	}
 }
 
@@ -267,6 +391,107 @@ func SetExtraHeaders(headers map[string]string) ConfigOption {
 	}
 }
 
+// SetFixtures records provider traffic to, or replays it from, fixture files
+// in dir. Mode is FixtureModeRecord, FixtureModeReplay, or empty to disable.
+func SetFixtures(mode, dir string) ConfigOption {
+	return func(c *Config) {
+		c.FixtureMode = mode
+		c.FixtureDir = dir
+	}
+}
+
+// SetAutoModerate checks every prompt and response with the provider's
+// moderation API and fails generation when either is flagged.
+func SetAutoModerate(enabled bool) ConfigOption {
+	return func(c *Config) {
+		c.AutoModerate = enabled
+	}
+}
+
+// SecretRef names the secret holding a provider's API key in a secret source.
+type SecretRef struct {
+	Source secrets.Source
+	Name   string
+}
+
+// SetAPIKeySecret fetches the API key of a provider from a secret source
+// instead of the environment. Wrap the source in a secrets.Cache to pick up
+// rotated keys without fetching the secret for every LLM.
+//
+// Example usage:
+//
+//	vault := secrets.NewCache(secrets.NewVaultSource("", ""), 5*time.Minute)
+//	cfg := NewConfig()
+//	ApplyOptions(cfg, SetAPIKeySecret("openai", vault, "secret/data/llm#openai"))
+func SetAPIKeySecret(provider string, source secrets.Source, name string) ConfigOption {
+	return func(c *Config) {
+		if c.APIKeySecrets == nil {
+			c.APIKeySecrets = make(map[string]SecretRef)
+		}
+		c.APIKeySecrets[provider] = SecretRef{Source: source, Name: name}
+	}
+}
+
+// ResolveSecrets fetches the API keys set with SetAPIKeySecret into APIKeys.
+// Keys fetched from secrets replace keys found in the environment.
+func (c *Config) ResolveSecrets(ctx context.Context) error {
+	for provider, ref := range c.APIKeySecrets {
+		key, err := ref.Source.GetSecret(ctx, ref.Name)
+		if err != nil {
+			return fmt.Errorf("failed to get %s API key from %s: %w", provider, ref.Source.Name(), err)
+		}
+		if c.APIKeys == nil {
+			c.APIKeys = make(map[string]string)
+		}
+		c.APIKeys[provider] = key
+	}
+	return nil
+}
+
+// ModelRoute is the provider and model a model alias resolves to.
+type ModelRoute struct {
+	Provider string `yaml:"provider"`
+	Model    string `yaml:"model"`
+}
+
+// SetModelAlias maps a logical model name such as "default-chat" or
+// "cheap-summarizer" to a provider and model. The alias can then be used
+// wherever a model name is expected: SetModel, profiles, or per request with
+// WithModel, so application code does not hardcode provider model IDs.
+func SetModelAlias(alias, provider, model string) ConfigOption {
+	return func(c *Config) {
+		if c.ModelAliases == nil {
+			c.ModelAliases = make(map[string]ModelRoute)
+		}
+		c.ModelAliases[alias] = ModelRoute{Provider: provider, Model: model}
+	}
+}
+
+// ResolveModel replaces Model, and Provider when the route sets one, with the
+// route of the model alias named by Model. It does nothing if Model is not an
+// alias.
+func (c *Config) ResolveModel() {
+	route, ok := c.ModelAliases[c.Model]
+	if !ok {
+		return
+	}
+	if route.Provider != "" {
+		c.Provider = route.Provider
+	}
+	c.Model = route.Model
+}
+
+// SetProfile adds a named profile that requests can select with
+// WithProfile. Adding a profile with an existing name replaces it.
+func SetProfile(name string, profile Profile) ConfigOption {
+	return func(c *Config) {
+		if c.Profiles == nil {
+			c.Profiles = make(map[string]Profile)
+		}
+		c.Profiles[name] = profile
+	}
+}
+
 // WithStream enables or disables streaming responses.
 func WithStream(enableStreaming bool) ConfigOption {
 	return func(c *Config) {
@@ -302,6 +527,53 @@ func SetSeed(seed int) ConfigOption {
 	}
 }
 
+// DeterministicSeed is the seed of deterministic mode when none is set with
+// SetSeed.
+const DeterministicSeed = 42
+
+// SetDeterministic enforces reproducible sampling: temperature 0, top_p 1
+// and a fixed seed (SetSeed, or DeterministicSeed) on providers that support
+// them, overriding per-request options. A warning is logged for providers
+// that do not accept a seed, whose responses may still vary. Even seeded
+// providers only make a best effort; compare the system fingerprint of
+// responses (llm.WithSystemFingerprint) to detect backend changes.
+func SetDeterministic(deterministic bool) ConfigOption {
+	return func(c *Config) {
+		c.Deterministic = deterministic
+	}
+}
+
+// SetLogprobs requests the log probability of each generated token, along
+// with the topN most likely alternatives (up to 20), from providers that
+// support it: OpenAI and OpenAI-compatible servers such as vLLM or Fireworks.
+// Read them with llm.WithLogprobs.
+func SetLogprobs(topN int) ConfigOption {
+	return func(c *Config) {
+		c.Logprobs = &topN
+	}
+}
+
+// SetReasoningBudget sets how many tokens a reasoning model may spend
+// thinking before it answers, mapped to each provider's control:
+//   - Anthropic: extended thinking with this budget_tokens, at least 1024.
+//     The temperature is dropped and max_tokens raised above the budget, as
+//     the API requires.
+//   - OpenAI: reasoning_effort "low" up to 2048 tokens, "medium" up to
+//     8192 and "high" above; the token limit becomes max_completion_tokens.
+//   - OpenRouter: its reasoning max_tokens, for the models it routes to.
+//   - DeepSeek: no budget can be set; deepseek-reasoner keeps its defaults.
+//
+// Models that the model catalog lists without the reasoning capability, such
+// as gpt-4o, get no reasoning parameters and a warning is logged; unlisted
+// models get them. A budget of 0 disables extended thinking on Anthropic and
+// OpenRouter, and is low effort on OpenAI, whose reasoning cannot be turned
+// off.
+func SetReasoningBudget(tokens int) ConfigOption {
+	return func(c *Config) {
+		c.ReasoningBudget = &tokens
+	}
+}
+
 // SetMinP sets the minimum token probability threshold.
 func SetMinP(minP float64) ConfigOption {
 	return func(c *Config) {
@@ -0,0 +1,157 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/teilomillet/gollm/utils"
+	"gopkg.in/yaml.v3"
+)
+
+// fileConfig is the format of configuration files. Every field is optional;
+// settings missing from the file keep their environment or default values.
+type fileConfig struct {
+	Provider         string                `yaml:"provider"`
+	Model            string                `yaml:"model"`
+	APIKeys          map[string]string     `yaml:"api_keys"`
+	Temperature      *float64              `yaml:"temperature"`
+	MaxTokens        *int                  `yaml:"max_tokens"`
+	TopP             *float64              `yaml:"top_p"`
+	Timeout          *time.Duration        `yaml:"timeout"`
+	MaxRetries       *int                  `yaml:"max_retries"`
+	RetryDelay       *time.Duration        `yaml:"retry_delay"`
+	RateLimit        *float64              `yaml:"rate_limit"`
+	MaxConcurrency   *int                  `yaml:"max_concurrency"`
+	LogLevel         *utils.LogLevel       `yaml:"log_level"`
+	OllamaEndpoint   string                `yaml:"ollama_endpoint"`
+	ExtraHeaders     map[string]string     `yaml:"extra_headers"`
+	Profiles         map[string]Profile    `yaml:"profiles"`
+	Models           map[string]ModelRoute `yaml:"models"`
+	EnableCaching    *bool                 `yaml:"enable_caching"`
+	EnableStreaming  *bool                 `yaml:"enable_streaming"`
+	AutoModerate     *bool                 `yaml:"auto_moderate"`
+	FrequencyPenalty *float64              `yaml:"frequency_penalty"`
+	PresencePenalty  *float64              `yaml:"presence_penalty"`
+}
+
+// LoadConfigFile loads the configuration from environment variables, like
+// LoadConfig, and applies the settings of a YAML or JSON file on top. API
+// keys in the file are added to those found in the environment.
+//
+// Example file:
+//
+//	provider: openai
+//	model: gpt-4o-mini
+//	rate_limit: 5
+//	timeout: 20s
+//	api_keys:
+//	  openai: sk-...
+//	models:
+//	  cheap-summarizer:
+//	    provider: anthropic
+//	    model: claude-3-5-haiku-latest
+//	profiles:
+//	  smart:
+//	    model: gpt-4o
+func LoadConfigFile(path string) (*Config, error) {
+	cfg, err := LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	opts, err := ReadConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+	ApplyOptions(cfg, opts...)
+	return cfg, nil
+}
+
+// ReadConfigFile reads a YAML or JSON configuration file and returns the
+// options that apply its settings.
+func ReadConfigFile(path string) ([]ConfigOption, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	var file fileConfig
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return file.options(), nil
+}
+
+func (f *fileConfig) options() []ConfigOption {
+	var opts []ConfigOption
+	if f.Provider != "" {
+		opts = append(opts, SetProvider(f.Provider))
+	}
+	if f.Model != "" {
+		opts = append(opts, SetModel(f.Model))
+	}
+	if f.OllamaEndpoint != "" {
+		opts = append(opts, SetOllamaEndpoint(f.OllamaEndpoint))
+	}
+	if len(f.APIKeys) > 0 {
+		opts = append(opts, func(c *Config) {
+			if c.APIKeys == nil {
+				c.APIKeys = make(map[string]string)
+			}
+			for provider, key := range f.APIKeys {
+				c.APIKeys[provider] = key
+			}
+		})
+	}
+	if len(f.ExtraHeaders) > 0 {
+		opts = append(opts, SetExtraHeaders(f.ExtraHeaders))
+	}
+	for alias, route := range f.Models {
+		opts = append(opts, SetModelAlias(alias, route.Provider, route.Model))
+	}
+	for name, profile := range f.Profiles {
+		opts = append(opts, SetProfile(name, profile))
+	}
+	if f.Temperature != nil {
+		opts = append(opts, SetTemperature(*f.Temperature))
+	}
+	if f.MaxTokens != nil {
+		opts = append(opts, SetMaxTokens(*f.MaxTokens))
+	}
+	if f.TopP != nil {
+		opts = append(opts, SetTopP(*f.TopP))
+	}
+	if f.FrequencyPenalty != nil {
+		opts = append(opts, SetFrequencyPenalty(*f.FrequencyPenalty))
+	}
+	if f.PresencePenalty != nil {
+		opts = append(opts, SetPresencePenalty(*f.PresencePenalty))
+	}
+	if f.Timeout != nil {
+		opts = append(opts, SetTimeout(*f.Timeout))
+	}
+	if f.MaxRetries != nil {
+		opts = append(opts, SetMaxRetries(*f.MaxRetries))
+	}
+	if f.RetryDelay != nil {
+		opts = append(opts, SetRetryDelay(*f.RetryDelay))
+	}
+	if f.RateLimit != nil {
+		opts = append(opts, SetRateLimit(*f.RateLimit))
+	}
+	if f.MaxConcurrency != nil {
+		opts = append(opts, SetMaxConcurrency(*f.MaxConcurrency))
+	}
+	if f.LogLevel != nil {
+		opts = append(opts, SetLogLevel(*f.LogLevel))
+	}
+	if f.EnableCaching != nil {
+		opts = append(opts, SetEnableCaching(*f.EnableCaching))
+	}
+	if f.EnableStreaming != nil {
+		opts = append(opts, WithStream(*f.EnableStreaming))
+	}
+	if f.AutoModerate != nil {
+		opts = append(opts, SetAutoModerate(*f.AutoModerate))
+	}
+	return opts
+}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"net/http"
+	"time"
+)
+
+// RawRequest is an HTTP request sent to a provider, as passed to the raw
+// request and response hooks. Credentials are redacted from the headers and
+// the URL.
+type RawRequest struct {
+	Provider string
+	Method   string
+	URL      string
+	Header   http.Header
+	Body     []byte
+}
+
+// RawResponse is the HTTP response of a provider, as passed to the raw
+// response hook.
+type RawResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte        // Complete body, including every event of a stream
+	Duration   time.Duration // From sending the request to the end of the body
+	Err        error         // Transport error, in which case there is no status or body
+}
+
+// RawRequestHook receives every request sent to the provider.
+type RawRequestHook func(RawRequest)
+
+// RawResponseHook receives every provider response with its request, once
+// its body has been read.
+type RawResponseHook func(RawRequest, RawResponse)
+
+// SetOnRawRequest calls hook with the exact payload of every provider
+// request, including retries, to debug provider quirks or feed tracing
+// exporters. The hook runs on the request path and should return quickly.
+func SetOnRawRequest(hook RawRequestHook) ConfigOption {
+	return func(c *Config) {
+		c.OnRawRequest = hook
+	}
+}
+
+// SetOnRawResponse calls hook with every provider response and its request.
+// Streamed responses are reported once the stream ends or is closed.
+func SetOnRawResponse(hook RawResponseHook) ConfigOption {
+	return func(c *Config) {
+		c.OnRawResponse = hook
+	}
+}
@@ -0,0 +1,154 @@
+package gollm
+
+import (
+	"sync"
+
+	"github.com/teilomillet/gollm/llm"
+	"github.com/teilomillet/gollm/providers"
+)
+
+// Spend is the accumulated token usage and cost of requests.
+type Spend struct {
+	Requests     int
+	InputTokens  int
+	OutputTokens int
+	Cost         float64 // USD, for models with known pricing
+	Unpriced     int     // Requests served by models without pricing, not included in Cost
+}
+
+// Tokens returns the input plus output tokens.
+func (s Spend) Tokens() int {
+	return s.InputTokens + s.OutputTokens
+}
+
+func (s *Spend) add(usage llm.Usage, cost float64, priced bool) {
+	s.Requests++
+	s.InputTokens += usage.InputTokens
+	s.OutputTokens += usage.OutputTokens
+	if priced {
+		s.Cost += cost
+	} else {
+		s.Unpriced++
+	}
+}
+
+// CostTracker accumulates the token usage and cost of requests, in total and
+// per model. Prices come from a model catalog. It is safe for concurrent use.
+//
+// Example usage:
+//
+//	tracker := gollm.NewCostTracker(nil)
+//	var usage llm.Usage
+//	response, err := l.Generate(ctx, prompt, llm.WithUsage(&usage))
+//	tracker.Record(l.GetProvider(), l.GetModel(), usage)
+//	fmt.Printf("$%.4f\n", tracker.Total().Cost)
+type CostTracker struct {
+	catalog *providers.ModelCatalog
+
+	mu         sync.Mutex
+	total      Spend
+	byModel    map[string]*Spend
+	byMetadata map[string]map[string]*Spend // Metadata key, then value
+}
+
+// NewCostTracker creates a tracker pricing requests with the catalog, or with
+// providers.DefaultModelCatalog() when nil.
+func NewCostTracker(catalog *providers.ModelCatalog) *CostTracker {
+	if catalog == nil {
+		catalog = providers.DefaultModelCatalog()
+	}
+	return &CostTracker{catalog: catalog, byModel: make(map[string]*Spend), byMetadata: make(map[string]map[string]*Spend)}
+}
+
+// Record adds the usage of a request served by a model and returns its cost
+// in USD, or false if the model has no pricing.
+func (t *CostTracker) Record(provider, model string, usage llm.Usage) (float64, bool) {
+	return t.RecordWithMetadata(provider, model, usage, nil)
+}
+
+// RecordWithMetadata is like Record for a request with metadata, e.g. set
+// with llm.WithMetadata; its spend is also broken down by each metadata pair.
+//
+// Example usage:
+//
+//	metadata := map[string]string{"feature": "summary"}
+//	response, err := l.Generate(ctx, prompt, llm.WithUsage(&usage), llm.WithMetadata(metadata))
+//	tracker.RecordWithMetadata(l.GetProvider(), l.GetModel(), usage, metadata)
+//	fmt.Println(tracker.ByMetadata("feature")["summary"].Cost)
+func (t *CostTracker) RecordWithMetadata(provider, model string, usage llm.Usage, metadata map[string]string) (float64, bool) {
+	cost, priced := t.Price(provider, model, usage)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	key := provider + "/" + model
+	spend, ok := t.byModel[key]
+	if !ok {
+		spend = &Spend{}
+		t.byModel[key] = spend
+	}
+	spend.add(usage, cost, priced)
+	t.total.add(usage, cost, priced)
+	for k, v := range metadata {
+		values, ok := t.byMetadata[k]
+		if !ok {
+			values = make(map[string]*Spend)
+			t.byMetadata[k] = values
+		}
+		spend, ok := values[v]
+		if !ok {
+			spend = &Spend{}
+			values[v] = spend
+		}
+		spend.add(usage, cost, priced)
+	}
+	return cost, priced
+}
+
+// Price returns the cost in USD of a request served by a model without
+// recording it, or false if the model has no pricing.
+func (t *CostTracker) Price(provider, model string, usage llm.Usage) (float64, bool) {
+	info, ok := t.catalog.Lookup(provider, model)
+	if !ok {
+		return 0, false
+	}
+	return info.Cost(usage.InputTokens, usage.OutputTokens), true
+}
+
+// Total returns the spend of all recorded requests.
+func (t *CostTracker) Total() Spend {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.total
+}
+
+// ByModel returns the spend of each model, keyed by "provider/model".
+func (t *CostTracker) ByModel() map[string]Spend {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	spends := make(map[string]Spend, len(t.byModel))
+	for key, spend := range t.byModel {
+		spends[key] = *spend
+	}
+	return spends
+}
+
+// ByMetadata returns the spend of the requests recorded with a metadata key,
+// keyed by its value, e.g. the spend per user or per feature.
+func (t *CostTracker) ByMetadata(key string) map[string]Spend {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	spends := make(map[string]Spend, len(t.byMetadata[key]))
+	for value, spend := range t.byMetadata[key] {
+		spends[value] = *spend
+	}
+	return spends
+}
+
+// Reset clears the recorded spend.
+func (t *CostTracker) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.total = Spend{}
+	t.byModel = make(map[string]*Spend)
+	t.byMetadata = make(map[string]map[string]*Spend)
+}
@@ -0,0 +1,112 @@
+package gollm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/teilomillet/gollm/llm"
+)
+
+// DraftApproved is the reply of a refiner that keeps the draft unchanged.
+const DraftApproved = "APPROVED"
+
+// DraftPipeline generates long-form responses with two models: a small,
+// cheap drafter writes the response and a larger refiner verifies it, either
+// approving the draft or returning an edited version. Approving costs the
+// refiner only its input tokens, which cuts the cost of responses the drafter
+// gets right.
+type DraftPipeline struct {
+	// Drafter writes the first version of each response
+	Drafter LLM
+
+	// Refiner verifies and edits the drafts
+	Refiner LLM
+
+	// Criteria tells the refiner what a draft must satisfy, e.g. "factually
+	// accurate and under 300 words"; defaults to correctness and completeness
+	Criteria string
+
+	// Accept, if set, is checked before the refiner is called; drafts it
+	// accepts are returned without refinement. Use it for cheap local checks
+	// such as validating JSON or a length limit.
+	Accept func(ctx context.Context, prompt *Prompt, draft string) (bool, error)
+}
+
+// DraftResult details how a DraftPipeline produced a response.
+type DraftResult struct {
+	Draft    string // The drafter's response
+	Final    string // The response returned: the draft or the refined version
+	Accepted bool   // Whether the draft passed the Accept check, skipping the refiner
+	Approved bool   // Whether the refiner approved the draft unchanged
+}
+
+// Generate drafts and refines a response to the prompt. The options apply to
+// both models.
+//
+// Example usage:
+//
+//	pipeline := &gollm.DraftPipeline{
+//	    Drafter:  small,
+//	    Refiner:  large,
+//	    Criteria: "technically accurate, with runnable code samples",
+//	}
+//	article, err := pipeline.Generate(ctx, gollm.NewPrompt("Write a tutorial on Go generics"))
+func (p *DraftPipeline) Generate(ctx context.Context, prompt *Prompt, opts ...llm.GenerateOption) (string, error) {
+	result, err := p.Run(ctx, prompt, opts...)
+	if err != nil {
+		return "", err
+	}
+	return result.Final, nil
+}
+
+// Run is like Generate but returns the draft along with the final response.
+func (p *DraftPipeline) Run(ctx context.Context, prompt *Prompt, opts ...llm.GenerateOption) (*DraftResult, error) {
+	if p.Drafter == nil || p.Refiner == nil {
+		return nil, llm.NewLLMError(llm.ErrorTypeInvalidInput, "draft pipeline needs a drafter and a refiner", nil)
+	}
+	draft, err := p.Drafter.Generate(ctx, prompt, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate draft: %w", err)
+	}
+	result := &DraftResult{Draft: draft, Final: draft}
+
+	if p.Accept != nil {
+		accepted, err := p.Accept(ctx, prompt, draft)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check draft: %w", err)
+		}
+		if accepted {
+			result.Accepted = true
+			return result, nil
+		}
+	}
+
+	refined, err := p.Refiner.Generate(ctx, p.refinePrompt(prompt, draft), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to refine draft: %w", err)
+	}
+	if strings.TrimSpace(refined) == DraftApproved {
+		result.Approved = true
+	} else {
+		result.Final = refined
+	}
+	return result, nil
+}
+
+// refinePrompt asks the refiner to approve or rewrite the draft. The request
+// includes the original system prompt and directives, so the refiner checks
+// the draft against the same instructions.
+func (p *DraftPipeline) refinePrompt(prompt *Prompt, draft string) *Prompt {
+	criteria := p.Criteria
+	if criteria == "" {
+		criteria = "correct, complete and well written"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Review the draft response to the request below. It must be %s.\n\n", criteria)
+	fmt.Fprintf(&b, "Request:\n%s\n\nDraft:\n%s\n\n", prompt.String(), draft)
+	fmt.Fprintf(&b, "If the draft meets these requirements, reply with %s and nothing else. ", DraftApproved)
+	b.WriteString("Otherwise reply with the complete corrected response only, without comments.")
+
+	return NewPrompt(b.String())
+}
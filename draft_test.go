@@ -0,0 +1,53 @@
+package gollm_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm"
+)
+
+func TestDraftPipeline(t *testing.T) {
+	ctx := context.Background()
+	newPipeline := func(draft, review string) (*gollm.DraftPipeline, *gollm.MockProvider) {
+		refiner := newBudgetLLM(t, "large", review)
+		refinerMock, err := gollm.GetMockProvider(refiner)
+		require.NoError(t, err)
+		return &gollm.DraftPipeline{Drafter: newBudgetLLM(t, "small", draft), Refiner: refiner, Criteria: "under ten words"}, refinerMock
+	}
+
+	t.Run("Approved", func(t *testing.T) {
+		pipeline, refiner := newPipeline("Paris.", " APPROVED\n")
+		result, err := pipeline.Run(ctx, gollm.NewPrompt("Capital of France?"))
+		require.NoError(t, err)
+		assert.Equal(t, &gollm.DraftResult{Draft: "Paris.", Final: "Paris.", Approved: true}, result)
+
+		call, ok := refiner.LastCall()
+		require.True(t, ok)
+		assert.Contains(t, call.Prompt, "under ten words")
+		assert.Contains(t, call.Prompt, "Capital of France?")
+		assert.Contains(t, call.Prompt, "Draft:\nParis.")
+	})
+
+	t.Run("Refined", func(t *testing.T) {
+		pipeline, _ := newPipeline("Lyon.", "Paris.")
+		final, err := pipeline.Generate(ctx, gollm.NewPrompt("Capital of France?"))
+		require.NoError(t, err)
+		assert.Equal(t, "Paris.", final)
+	})
+
+	t.Run("AcceptedWithoutRefiner", func(t *testing.T) {
+		pipeline, refiner := newPipeline("Paris.", "unused")
+		pipeline.Accept = func(ctx context.Context, prompt *gollm.Prompt, draft string) (bool, error) {
+			return strings.HasSuffix(draft, "."), nil
+		}
+		result, err := pipeline.Run(ctx, gollm.NewPrompt("Capital of France?"))
+		require.NoError(t, err)
+		assert.True(t, result.Accepted)
+		assert.Equal(t, "Paris.", result.Final)
+		assert.Equal(t, 0, refiner.CallCount())
+	})
+}
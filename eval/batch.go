@@ -0,0 +1,187 @@
+package eval
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Report summarizes the judgements of a dataset.
+type Report struct {
+	// Judgements holds one entry per sample, in dataset order.
+	// Samples that could not be judged have Error set and no scores
+	Judgements []Judgement `json:"judgements"`
+
+	// Means is the mean normalized score per criterion over judged samples
+	Means map[string]float64 `json:"means"`
+
+	// Overall is the mean overall score over judged samples
+	Overall float64 `json:"overall"`
+
+	// Failed is the number of samples that could not be judged
+	Failed int `json:"failed"`
+}
+
+// ScoreBatch judges every sample concurrently and aggregates the results.
+// Samples that fail to be judged are recorded in the report instead of
+// aborting the batch; an error is only returned if no sample was judged or
+// the context is cancelled.
+func (j *Judge) ScoreBatch(ctx context.Context, samples []Sample) (*Report, error) {
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("dataset cannot be empty")
+	}
+
+	report := &Report{
+		Judgements: make([]Judgement, len(samples)),
+		Means:      make(map[string]float64),
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, j.concurrency)
+	for i, sample := range samples {
+		wg.Add(1)
+		go func(i int, sample Sample) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				report.Judgements[i] = Judgement{Sample: sample, Error: ctx.Err().Error()}
+				return
+			}
+
+			judgement, err := j.Score(ctx, sample)
+			if err != nil {
+				report.Judgements[i] = Judgement{Sample: sample, Error: err.Error()}
+				return
+			}
+			report.Judgements[i] = *judgement
+		}(i, sample)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return report, err
+	}
+
+	judged := 0
+	for _, judgement := range report.Judgements {
+		if judgement.Error != "" {
+			report.Failed++
+			continue
+		}
+		judged++
+		report.Overall += judgement.Overall
+		for _, score := range judgement.Scores {
+			report.Means[score.Criterion] += score.Normalized
+		}
+	}
+	if judged == 0 {
+		return report, fmt.Errorf("no sample could be judged: %s", report.Judgements[0].Error)
+	}
+	report.Overall /= float64(judged)
+	for name := range report.Means {
+		report.Means[name] /= float64(judged)
+	}
+	return report, nil
+}
+
+// Thresholds defines the minimum quality a report must reach.
+type Thresholds struct {
+	// Overall is the minimum mean overall score
+	Overall float64
+
+	// Criteria maps criterion names to their minimum mean normalized score
+	Criteria map[string]float64
+
+	// MaxFailed is the maximum number of samples that may fail to be judged
+	MaxFailed int
+}
+
+// Gate checks the report against the thresholds and returns an error listing
+// every violation, so that CI pipelines can block releases on quality
+// regressions.
+//
+// Example usage:
+//
+//	report, err := judge.ScoreBatch(ctx, samples)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	if err := report.Gate(eval.Thresholds{Overall: 0.8, Criteria: map[string]float64{"toxicity": 0.95}}); err != nil {
+//	    log.Fatal(err)
+//	}
+func (r *Report) Gate(t Thresholds) error {
+	var violations []string
+	if r.Overall < t.Overall {
+		violations = append(violations, fmt.Sprintf("overall score %.3f is below %.3f", r.Overall, t.Overall))
+	}
+
+	names := make([]string, 0, len(t.Criteria))
+	for name := range t.Criteria {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		mean, ok := r.Means[name]
+		if !ok {
+			violations = append(violations, fmt.Sprintf("criterion %q was not scored", name))
+			continue
+		}
+		if mean < t.Criteria[name] {
+			violations = append(violations, fmt.Sprintf("%s score %.3f is below %.3f", name, mean, t.Criteria[name]))
+		}
+	}
+
+	if r.Failed > t.MaxFailed {
+		violations = append(violations, fmt.Sprintf("%d samples failed to be judged, at most %d allowed", r.Failed, t.MaxFailed))
+	}
+	if len(violations) > 0 {
+		return fmt.Errorf("quality gate failed: %s", strings.Join(violations, "; "))
+	}
+	return nil
+}
+
+// ReadDataset reads samples from JSON Lines, one Sample object per line.
+// Blank lines are skipped.
+func ReadDataset(r io.Reader) ([]Sample, error) {
+	var samples []Sample
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var sample Sample
+		if err := json.Unmarshal([]byte(text), &sample); err != nil {
+			return nil, fmt.Errorf("invalid sample on line %d: %w", line, err)
+		}
+		if sample.ID == "" {
+			sample.ID = fmt.Sprintf("%d", line)
+		}
+		samples = append(samples, sample)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read dataset: %w", err)
+	}
+	return samples, nil
+}
+
+// LoadDataset reads samples from a JSON Lines file. See ReadDataset.
+func LoadDataset(path string) ([]Sample, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open dataset: %w", err)
+	}
+	defer f.Close()
+	return ReadDataset(f)
+}
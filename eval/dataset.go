@@ -0,0 +1,116 @@
+package eval
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Row is a record of a dataset run through a Runner, such as the variables
+// of a prompt template and the expected answer.
+type Row struct {
+	// ID identifies the row in results and checkpoints: its "id" field, or
+	// its line number
+	ID string
+
+	// Fields maps column names to values
+	Fields map[string]interface{}
+}
+
+// String returns the value of a field as text, or an empty string.
+func (r Row) String(field string) string {
+	switch v := r.Fields[field].(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(data)
+	}
+}
+
+// ReadRowsJSONL reads rows from JSON Lines, one object per line. Blank lines
+// are skipped.
+func ReadRowsJSONL(r io.Reader) ([]Row, error) {
+	var rows []Row
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var fields map[string]interface{}
+		if err := json.Unmarshal([]byte(text), &fields); err != nil {
+			return nil, fmt.Errorf("invalid row on line %d: %w", line, err)
+		}
+		rows = append(rows, newRow(fields, line))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read dataset: %w", err)
+	}
+	return rows, nil
+}
+
+// ReadRowsCSV reads rows from CSV with a header line naming the columns.
+// Values are read as strings.
+func ReadRowsCSV(r io.Reader) ([]Row, error) {
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	for i := range header {
+		header[i] = strings.TrimSpace(strings.TrimPrefix(header[i], "\ufeff"))
+	}
+
+	var rows []Row
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid row on line %d: %w", line, err)
+		}
+		fields := make(map[string]interface{}, len(header))
+		for i, value := range record {
+			fields[header[i]] = value
+		}
+		rows = append(rows, newRow(fields, line))
+	}
+	return rows, nil
+}
+
+// LoadRows reads rows from a CSV file (.csv) or a JSON Lines file (any other
+// extension, e.g. .jsonl).
+func LoadRows(path string) ([]Row, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open dataset: %w", err)
+	}
+	defer f.Close()
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		return ReadRowsCSV(f)
+	}
+	return ReadRowsJSONL(f)
+}
+
+func newRow(fields map[string]interface{}, line int) Row {
+	row := Row{ID: fmt.Sprint(line), Fields: fields}
+	if id := (Row{Fields: fields}).String("id"); id != "" {
+		row.ID = id
+	}
+	return row
+}
@@ -0,0 +1,114 @@
+package eval
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm"
+	"github.com/teilomillet/gollm/llm"
+)
+
+// judgeLLM answers judge prompts through a function of the prompt input.
+type judgeLLM struct {
+	gollm.LLM
+	mu      sync.Mutex
+	calls   int
+	respond func(input string) (string, error)
+}
+
+func (j *judgeLLM) Generate(ctx context.Context, prompt *llm.Prompt, opts ...llm.GenerateOption) (string, error) {
+	j.mu.Lock()
+	j.calls++
+	j.mu.Unlock()
+	return j.respond(prompt.Input)
+}
+
+func TestJudgeScore(t *testing.T) {
+	l := &judgeLLM{respond: func(input string) (string, error) {
+		return "```json\n" + `{"scores": [
+			{"criterion": "Correctness", "score": 2, "reasoning": "Sydney is not the capital"},
+			{"criterion": "relevance", "score": 10, "reasoning": "on topic"},
+			{"criterion": "toxicity", "score": 0, "reasoning": "none"}
+		]}` + "\n```", nil
+	}}
+	judge, err := NewJudge(l)
+	require.NoError(t, err)
+
+	judgement, err := judge.Score(context.Background(), Sample{Input: "Capital of Australia?", Output: "Sydney", Reference: "Canberra"})
+	require.NoError(t, err)
+	require.Len(t, judgement.Scores, 3)
+
+	correctness, ok := judgement.Score("correctness")
+	require.True(t, ok)
+	assert.InDelta(t, 0.2, correctness.Normalized, 1e-9)
+	toxicity, _ := judgement.Score("toxicity")
+	assert.InDelta(t, 1.0, toxicity.Normalized, 1e-9, "no toxicity is the best score")
+	assert.InDelta(t, (0.2+1+1)/3, judgement.Overall, 1e-9)
+
+	_, err = NewJudge(l, WithRubric(Correctness, Correctness))
+	assert.Error(t, err)
+
+	missing, _ := NewJudge(l, WithRubric(Criterion{Name: "style", Description: "Is it well written?"}))
+	_, err = missing.Score(context.Background(), Sample{Input: "x", Output: "y"})
+	assert.ErrorContains(t, err, "style")
+}
+
+func TestJudgeCompareWithPositionSwap(t *testing.T) {
+	// A judge that always prefers whichever output comes first
+	l := &judgeLLM{respond: func(string) (string, error) { return `{"winner": "A", "reasoning": "first"}`, nil }}
+
+	judge, err := NewJudge(l)
+	require.NoError(t, err)
+	result, err := judge.Compare(context.Background(), "q", "one", "two")
+	require.NoError(t, err)
+	assert.Equal(t, VerdictA, result.Verdict)
+
+	judge, err = NewJudge(l, WithPositionSwap())
+	require.NoError(t, err)
+	result, err = judge.Compare(context.Background(), "q", "one", "two")
+	require.NoError(t, err)
+	assert.Equal(t, VerdictTie, result.Verdict)
+	assert.False(t, result.Consistent)
+}
+
+func TestScoreBatchAndGate(t *testing.T) {
+	samples, err := ReadDataset(strings.NewReader(`{"input": "2+2", "output": "4"}
+
+{"id": "bad", "input": "2+3", "output": "6"}
+{"input": "2+4", "output": "broken"}
+`))
+	require.NoError(t, err)
+	require.Len(t, samples, 3)
+	assert.Equal(t, "1", samples[0].ID)
+
+	l := &judgeLLM{respond: func(input string) (string, error) {
+		switch {
+		case strings.Contains(input, "broken"):
+			return "not json", nil
+		case strings.Contains(input, "\n6\n"):
+			return `{"scores": [{"criterion": "correctness", "score": 0}, {"criterion": "relevance", "score": 10}]}`, nil
+		default:
+			return `{"scores": [{"criterion": "correctness", "score": 10}, {"criterion": "relevance", "score": 10}]}`, nil
+		}
+	}}
+	judge, err := NewJudge(l, WithRubric(Correctness, Relevance), WithConcurrency(2))
+	require.NoError(t, err)
+
+	report, err := judge.ScoreBatch(context.Background(), samples)
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Failed)
+	assert.NotEmpty(t, report.Judgements[2].Error)
+	assert.InDelta(t, 0.5, report.Means["correctness"], 1e-9)
+	assert.InDelta(t, 0.75, report.Overall, 1e-9)
+
+	assert.NoError(t, report.Gate(Thresholds{Overall: 0.7, MaxFailed: 1}))
+	err = report.Gate(Thresholds{Criteria: map[string]float64{"correctness": 0.9, "toxicity": 0.9}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "correctness score 0.500 is below 0.900")
+	assert.Contains(t, err.Error(), `criterion "toxicity" was not scored`)
+	assert.Contains(t, err.Error(), "1 samples failed")
+}
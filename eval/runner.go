@@ -0,0 +1,339 @@
+package eval
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/teilomillet/gollm"
+	"github.com/teilomillet/gollm/llm"
+	"github.com/teilomillet/gollm/utils"
+)
+
+// Task produces the output of a dataset row, e.g. by running a prompt
+// template or an agent.
+type Task func(ctx context.Context, row Row) (string, error)
+
+// TemplateTask generates the output of each row from a prompt template
+// executed with the row's fields as variables.
+//
+// Example usage:
+//
+//	task := eval.TemplateTask(l, gollm.NewPromptTemplate("qa", "", "Answer: {{.question}}"))
+func TemplateTask(l gollm.LLM, tmpl *gollm.PromptTemplate, opts ...llm.GenerateOption) Task {
+	return func(ctx context.Context, row Row) (string, error) {
+		return l.GenerateFromTemplate(ctx, tmpl, row.Fields, opts...)
+	}
+}
+
+// FieldTask produces the output of each row by calling run with the value of
+// one of its fields, e.g. the input of an agent.
+//
+// Example usage:
+//
+//	task := eval.FieldTask("question", func(ctx context.Context, question string) (string, error) {
+//	    result, err := reactAgent.Run(ctx, question)
+//	    if err != nil {
+//	        return "", err
+//	    }
+//	    return result.Answer, nil
+//	})
+func FieldTask(field string, run func(ctx context.Context, input string) (string, error)) Task {
+	return func(ctx context.Context, row Row) (string, error) {
+		input := row.String(field)
+		if input == "" {
+			return "", fmt.Errorf("row %s has no %q field", row.ID, field)
+		}
+		return run(ctx, input)
+	}
+}
+
+// Scorer scores the output of a row, e.g. against its expected answer.
+// Scores are between 0 and 1 where 1 is best.
+type Scorer func(ctx context.Context, row Row, output string) (map[string]float64, error)
+
+// JudgeScorer scores outputs with a Judge, reading the input and the
+// optional reference answer from the given fields. The scores are the
+// normalized criterion scores and "overall".
+func JudgeScorer(judge *Judge, inputField, referenceField string) Scorer {
+	return func(ctx context.Context, row Row, output string) (map[string]float64, error) {
+		judgement, err := judge.Score(ctx, Sample{
+			ID:        row.ID,
+			Input:     row.String(inputField),
+			Output:    output,
+			Reference: row.String(referenceField),
+		})
+		if err != nil {
+			return nil, err
+		}
+		scores := map[string]float64{"overall": judgement.Overall}
+		for _, s := range judgement.Scores {
+			scores[s.Criterion] = s.Normalized
+		}
+		return scores, nil
+	}
+}
+
+// RowResult is the outcome of a dataset row, one line of a results file.
+type RowResult struct {
+	ID       string                 `json:"id"`
+	Fields   map[string]interface{} `json:"fields"`
+	Output   string                 `json:"output"`
+	Scores   map[string]float64     `json:"scores,omitempty"`
+	Error    string                 `json:"error,omitempty"`
+	Attempts int                    `json:"attempts"`
+	Latency  time.Duration          `json:"latency"` // Duration of the successful attempt
+}
+
+// RunReport summarizes a dataset run.
+type RunReport struct {
+	// Results holds one entry per row, in dataset order, including the rows
+	// resumed from the results file
+	Results []RowResult
+
+	// Means is the mean of each score over the scored rows
+	Means map[string]float64
+
+	// Failed is the number of rows without output
+	Failed int
+
+	// Resumed is the number of rows read from the results file instead of
+	// being run
+	Resumed int
+}
+
+// Runner executes a task against every row of a dataset concurrently, with
+// retries, scoring each output. With a results file, each row's result is
+// appended as it completes, and a run interrupted or partly failed can be
+// resumed: rows already completed are read back instead of being run again.
+//
+// Example usage:
+//
+//	rows, err := eval.LoadRows("questions.csv")
+//	runner := &eval.Runner{
+//	    Task:    eval.TemplateTask(l, template),
+//	    Scorer:  eval.JudgeScorer(judge, "question", "answer"),
+//	    Results: "results.jsonl",
+//	}
+//	report, err := runner.Run(ctx, rows)
+//	fmt.Println(report.Means["overall"], report.Failed)
+type Runner struct {
+	// Task produces the output of each row
+	Task Task
+
+	// Scorer scores the outputs, if set
+	Scorer Scorer
+
+	// Concurrency is the number of rows run in parallel; defaults to 4
+	Concurrency int
+
+	// Retries is the number of retries of a failing task or scorer; defaults
+	// to 0. Failing rows are recorded and the run continues.
+	Retries int
+
+	// RetryDelay is the delay before the first retry, doubled for each
+	// subsequent one; defaults to 1s
+	RetryDelay time.Duration
+
+	// Results is the JSON Lines file the results are appended to and
+	// resumed from, if set
+	Results string
+
+	// Clock times the retry delays and latencies; defaults to the system
+	// clock
+	Clock utils.Clock
+}
+
+// Run executes the task against the rows and returns the report. Failing
+// rows are recorded in the report; an error is returned only if the results
+// file cannot be used or the context is cancelled, with the partial report.
+func (r *Runner) Run(ctx context.Context, rows []Row) (*RunReport, error) {
+	if r.Task == nil {
+		return nil, fmt.Errorf("runner needs a task")
+	}
+	ids := make(map[string]bool, len(rows))
+	for _, row := range rows {
+		if ids[row.ID] {
+			return nil, fmt.Errorf("duplicate row ID %q", row.ID)
+		}
+		ids[row.ID] = true
+	}
+
+	completed, err := r.checkpoint()
+	if err != nil {
+		return nil, err
+	}
+	var results *os.File
+	if r.Results != "" {
+		if results, err = os.OpenFile(r.Results, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644); err != nil {
+			return nil, fmt.Errorf("failed to open results file: %w", err)
+		}
+		defer results.Close()
+	}
+
+	concurrency := r.Concurrency
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+	report := &RunReport{Results: make([]RowResult, len(rows)), Means: make(map[string]float64)}
+	var (
+		wg       sync.WaitGroup
+		writeMu  sync.Mutex
+		writeErr error
+	)
+	sem := make(chan struct{}, concurrency)
+	for i, row := range rows {
+		if result, ok := completed[row.ID]; ok {
+			report.Results[i] = result
+			report.Resumed++
+			continue
+		}
+		wg.Add(1)
+		go func(i int, row Row) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				report.Results[i] = RowResult{ID: row.ID, Fields: row.Fields, Error: ctx.Err().Error()}
+				return
+			}
+
+			result := r.runRow(ctx, row)
+			report.Results[i] = result
+			if results == nil || (result.Error != "" && ctx.Err() != nil) {
+				// Rows interrupted by the cancellation are run again on resume
+				return
+			}
+			line, err := json.Marshal(result)
+			if err == nil {
+				writeMu.Lock()
+				_, err = results.Write(append(line, '\n'))
+				if err != nil && writeErr == nil {
+					writeErr = err
+				}
+				writeMu.Unlock()
+			}
+		}(i, row)
+	}
+	wg.Wait()
+
+	report.aggregate()
+	if err := ctx.Err(); err != nil {
+		return report, err
+	}
+	if writeErr != nil {
+		return report, fmt.Errorf("failed to write results file: %w", writeErr)
+	}
+	return report, nil
+}
+
+// clock returns the clock of the runner, the system clock by default.
+func (r *Runner) clock() utils.Clock {
+	if r.Clock != nil {
+		return r.Clock
+	}
+	return utils.SystemClock()
+}
+
+// runRow runs and scores a row, retrying failures.
+func (r *Runner) runRow(ctx context.Context, row Row) RowResult {
+	result := RowResult{ID: row.ID, Fields: row.Fields}
+	delay := r.RetryDelay
+	if delay <= 0 {
+		delay = time.Second
+	}
+	for attempt := 0; attempt <= r.Retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				result.Error = ctx.Err().Error()
+				return result
+			case <-r.clock().After(delay):
+			}
+			delay *= 2
+		}
+		result.Attempts++
+
+		start := r.clock().Now()
+		output, err := r.Task(ctx, row)
+		if err != nil {
+			result.Error = err.Error()
+			continue
+		}
+		result.Output, result.Latency, result.Error = output, r.clock().Now().Sub(start), ""
+		if r.Scorer == nil {
+			return result
+		}
+		scores, err := r.Scorer(ctx, row, output)
+		if err != nil {
+			result.Error = fmt.Sprintf("failed to score output: %v", err)
+			continue
+		}
+		result.Scores = scores
+		return result
+	}
+	return result
+}
+
+// checkpoint reads the completed rows of the results file, keyed by ID.
+// Failed rows are left out so that they are run again; the last result of a
+// row wins.
+func (r *Runner) checkpoint() (map[string]RowResult, error) {
+	completed := make(map[string]RowResult)
+	if r.Results == "" {
+		return completed, nil
+	}
+	f, err := os.Open(r.Results)
+	if os.IsNotExist(err) {
+		return completed, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open results file: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var result RowResult
+		if err := json.Unmarshal(scanner.Bytes(), &result); err != nil {
+			// A run killed mid-write leaves a truncated last line
+			continue
+		}
+		if result.Error == "" {
+			completed[result.ID] = result
+		} else {
+			delete(completed, result.ID)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read results file: %w", err)
+	}
+	return completed, nil
+}
+
+// aggregate computes the failures and mean scores of the results.
+func (r *RunReport) aggregate() {
+	counts := make(map[string]int)
+	for _, result := range r.Results {
+		if result.Error != "" {
+			r.Failed++
+			continue
+		}
+		for name, score := range result.Scores {
+			r.Means[name] += score
+			counts[name]++
+		}
+	}
+	for name := range r.Means {
+		r.Means[name] /= float64(counts[name])
+	}
+}
@@ -0,0 +1,116 @@
+package eval
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm/utils"
+)
+
+func TestReadRows(t *testing.T) {
+	rows, err := ReadRowsJSONL(strings.NewReader(`{"id": "q1", "question": "2+2", "tags": ["math"]}
+
+{"question": "3+3"}`))
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, "q1", rows[0].ID)
+	assert.Equal(t, `["math"]`, rows[0].String("tags"))
+	assert.Equal(t, "3", rows[1].ID, "rows without an id are identified by their line")
+
+	rows, err = ReadRowsCSV(strings.NewReader("\ufeffquestion,answer\n\"Capital of France, please\",Paris\n"))
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, "2", rows[0].ID)
+	assert.Equal(t, "Capital of France, please", rows[0].String("question"))
+	assert.Equal(t, "Paris", rows[0].String("answer"))
+
+	_, err = ReadRowsJSONL(strings.NewReader("{\"a\": 1}\nnot json"))
+	assert.ErrorContains(t, err, "line 2")
+}
+
+func TestRunnerResume(t *testing.T) {
+	ctx := context.Background()
+	results := filepath.Join(t.TempDir(), "results.jsonl")
+	rows, err := ReadRowsJSONL(strings.NewReader(`{"id": "a", "n": "1"}
+{"id": "b", "n": "2"}
+{"id": "c", "n": "3"}
+{"id": "d", "n": "4"}`))
+	require.NoError(t, err)
+
+	clock := utils.NewFakeClock(time.Now())
+	clock.SetAutoAdvance(true) // Retries don't wait
+	var mu sync.Mutex
+	runs := map[string]int{}
+	failing := "c"
+	runner := &Runner{
+		Task: FieldTask("n", func(ctx context.Context, n string) (string, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			runs[n]++
+			if n == "2" && runs[n] == 1 {
+				return "", errors.New("transient")
+			}
+			if n == "3" && failing == "c" {
+				return "", errors.New("down")
+			}
+			return n + n, nil
+		}),
+		Scorer: func(ctx context.Context, row Row, output string) (map[string]float64, error) {
+			if row.ID == "a" {
+				return map[string]float64{"exact": 1}, nil
+			}
+			return map[string]float64{"exact": 0}, nil
+		},
+		Concurrency: 2,
+		Retries:     1,
+		RetryDelay:  time.Hour,
+		Results:     results,
+		Clock:       clock,
+	}
+
+	report, err := runner.Run(ctx, rows)
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Failed)
+	assert.Equal(t, "11", report.Results[0].Output)
+	assert.Equal(t, 2, report.Results[1].Attempts, "the transient failure is retried")
+	assert.Equal(t, "down", report.Results[2].Error)
+	assert.InDelta(t, 1.0/3, report.Means["exact"], 1e-9)
+
+	// The resumed run only reruns the failed row
+	failing = ""
+	report, err = runner.Run(ctx, rows)
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.Resumed)
+	assert.Zero(t, report.Failed)
+	assert.Equal(t, "33", report.Results[2].Output)
+	assert.Equal(t, map[string]int{"1": 1, "2": 2, "3": 3, "4": 1}, runs)
+
+	data, err := os.ReadFile(results)
+	require.NoError(t, err)
+	assert.Len(t, strings.Split(strings.TrimSpace(string(data)), "\n"), 5)
+
+	_, err = runner.Run(ctx, append(rows, rows[0]))
+	assert.ErrorContains(t, err, "duplicate")
+}
+
+func TestJudgeScorer(t *testing.T) {
+	l := &judgeLLM{respond: func(input string) (string, error) {
+		return `{"scores": [{"criterion": "correctness", "score": 8, "reasoning": "close"}]}`, nil
+	}}
+	judge, err := NewJudge(l, WithRubric(Correctness))
+	require.NoError(t, err)
+
+	scores, err := JudgeScorer(judge, "question", "answer")(context.Background(),
+		Row{ID: "1", Fields: map[string]interface{}{"question": "2+2", "answer": "4"}}, "4")
+	require.NoError(t, err)
+	assert.InDelta(t, 0.8, scores["correctness"], 1e-9)
+	assert.InDelta(t, 0.8, scores["overall"], 1e-9)
+}
@@ -0,0 +1,289 @@
+package eval
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"unicode"
+
+	"github.com/teilomillet/gollm"
+	"github.com/teilomillet/gollm/llm"
+)
+
+// UpdateSnapshotsEnv is the environment variable that, set to a non-empty
+// value, makes Snapshots approve the current outputs instead of checking
+// them.
+const UpdateSnapshotsEnv = "LLM_UPDATE_SNAPSHOTS"
+
+// Similarity scores how close two texts are, between 0 and 1 where 1 means
+// equivalent.
+type Similarity func(ctx context.Context, a, b string) (float64, error)
+
+// LexicalSimilarity is the cosine similarity of the word counts of the
+// texts, ignoring case and punctuation. It tolerates rewording and
+// reordering but not paraphrase; use EmbeddingSimilarity for that.
+func LexicalSimilarity(ctx context.Context, a, b string) (float64, error) {
+	countsA, countsB := wordCounts(a), wordCounts(b)
+	if len(countsA) == 0 || len(countsB) == 0 {
+		if len(countsA) == len(countsB) {
+			return 1, nil
+		}
+		return 0, nil
+	}
+	var dot, normA, normB float64
+	for word, n := range countsA {
+		dot += n * countsB[word]
+		normA += n * n
+	}
+	for _, n := range countsB {
+		normB += n * n
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB)), nil
+}
+
+// EmbeddingSimilarity is the cosine similarity of the embeddings of the
+// texts, computed by embed, e.g. a call to an embeddings API.
+func EmbeddingSimilarity(embed func(ctx context.Context, text string) ([]float64, error)) Similarity {
+	return func(ctx context.Context, a, b string) (float64, error) {
+		va, err := embed(ctx, a)
+		if err != nil {
+			return 0, fmt.Errorf("failed to embed text: %w", err)
+		}
+		vb, err := embed(ctx, b)
+		if err != nil {
+			return 0, fmt.Errorf("failed to embed text: %w", err)
+		}
+		if len(va) != len(vb) {
+			return 0, fmt.Errorf("embeddings have different dimensions: %d and %d", len(va), len(vb))
+		}
+		var dot, normA, normB float64
+		for i := range va {
+			dot += va[i] * vb[i]
+			normA += va[i] * va[i]
+			normB += vb[i] * vb[i]
+		}
+		if normA == 0 || normB == 0 {
+			return 0, nil
+		}
+		return dot / (math.Sqrt(normA) * math.Sqrt(normB)), nil
+	}
+}
+
+func wordCounts(text string) map[string]float64 {
+	counts := make(map[string]float64)
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}) {
+		counts[word]++
+	}
+	return counts
+}
+
+// SnapshotCase is a prompt of a snapshot suite.
+type SnapshotCase struct {
+	// Name identifies the approved output; it must be unique in the suite
+	Name string
+
+	Prompt  *gollm.Prompt
+	Options []llm.GenerateOption
+}
+
+// SnapshotResult is the outcome of checking an output against its approved
+// snapshot.
+type SnapshotResult struct {
+	Name       string
+	Approved   string
+	Output     string
+	Similarity float64
+
+	// Passed reports whether the similarity reaches the threshold
+	Passed bool
+
+	// Updated reports whether the output was stored as the approved
+	// snapshot, because there was none yet or updates were requested
+	Updated bool
+}
+
+// Diff returns a line diff from the approved output to the new one.
+func (r SnapshotResult) Diff() string {
+	return lineDiff(r.Approved, r.Output)
+}
+
+// Snapshots stores approved outputs as text files, one per name, and checks
+// new outputs against them. Outputs don't need to match exactly: they pass
+// when their similarity to the approved output reaches the threshold, so
+// that harmless rewording doesn't fail the suite but drift does.
+//
+// Outputs without an approved snapshot are approved as is. To approve new
+// outputs after an intended change, rerun with LLM_UPDATE_SNAPSHOTS=1 and
+// review the changes to the snapshot files.
+//
+// Example usage:
+//
+//	func TestPrompts(t *testing.T) {
+//	    snapshots := &eval.Snapshots{Dir: "testdata/snapshots", Threshold: 0.7}
+//	    snapshots.RunSuite(t, l, []eval.SnapshotCase{
+//	        {Name: "summary", Prompt: gollm.NewPrompt("Summarize: ...")},
+//	    })
+//	}
+type Snapshots struct {
+	// Dir is the directory of the snapshot files
+	Dir string
+
+	// Similarity compares outputs; defaults to LexicalSimilarity
+	Similarity Similarity
+
+	// Threshold is the minimum similarity to pass; defaults to 0.8
+	Threshold float64
+
+	// Update approves the outputs instead of checking them; defaults to
+	// whether LLM_UPDATE_SNAPSHOTS is set
+	Update bool
+}
+
+// Check compares an output with the approved snapshot of the name.
+func (s *Snapshots) Check(ctx context.Context, name, output string) (*SnapshotResult, error) {
+	path := s.path(name)
+	result := &SnapshotResult{Name: name, Output: output}
+
+	approved, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read snapshot %q: %w", name, err)
+	}
+	if os.IsNotExist(err) || s.Update || os.Getenv(UpdateSnapshotsEnv) != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create snapshot directory: %w", err)
+		}
+		if err := os.WriteFile(path, []byte(output), 0o644); err != nil {
+			return nil, fmt.Errorf("failed to write snapshot %q: %w", name, err)
+		}
+		result.Approved, result.Similarity, result.Passed, result.Updated = output, 1, true, true
+		return result, nil
+	}
+
+	result.Approved = string(approved)
+	if result.Approved == output {
+		result.Similarity = 1
+	} else {
+		similarity := s.Similarity
+		if similarity == nil {
+			similarity = LexicalSimilarity
+		}
+		if result.Similarity, err = similarity(ctx, result.Approved, output); err != nil {
+			return nil, fmt.Errorf("failed to compare snapshot %q: %w", name, err)
+		}
+	}
+	result.Passed = result.Similarity >= s.threshold()
+	return result, nil
+}
+
+// Run generates the output of every case and checks it against its
+// snapshot, stopping at the first error.
+func (s *Snapshots) Run(ctx context.Context, l gollm.LLM, cases []SnapshotCase) ([]SnapshotResult, error) {
+	names := make(map[string]bool, len(cases))
+	results := make([]SnapshotResult, 0, len(cases))
+	for _, c := range cases {
+		if names[c.Name] {
+			return results, fmt.Errorf("duplicate snapshot name %q", c.Name)
+		}
+		names[c.Name] = true
+
+		output, err := l.Generate(ctx, c.Prompt, c.Options...)
+		if err != nil {
+			return results, fmt.Errorf("failed to generate snapshot %q: %w", c.Name, err)
+		}
+		result, err := s.Check(ctx, c.Name, output)
+		if err != nil {
+			return results, err
+		}
+		results = append(results, *result)
+	}
+	return results, nil
+}
+
+// Assert checks an output against its snapshot, failing the test with a
+// diff when it drifted.
+func (s *Snapshots) Assert(t testing.TB, name, output string) {
+	t.Helper()
+	result, err := s.Check(context.Background(), name, output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Passed {
+		t.Errorf("snapshot %q drifted: similarity %.2f is below %.2f (rerun with %s=1 to approve)\n%s",
+			name, result.Similarity, s.threshold(), UpdateSnapshotsEnv, result.Diff())
+	}
+}
+
+// RunSuite generates and asserts every case in its own subtest.
+func (s *Snapshots) RunSuite(t *testing.T, l gollm.LLM, cases []SnapshotCase) {
+	t.Helper()
+	for _, c := range cases {
+		c := c
+		t.Run(c.Name, func(t *testing.T) {
+			output, err := l.Generate(context.Background(), c.Prompt, c.Options...)
+			if err != nil {
+				t.Fatalf("failed to generate: %v", err)
+			}
+			s.Assert(t, c.Name, output)
+		})
+	}
+}
+
+func (s *Snapshots) threshold() float64 {
+	if s.Threshold <= 0 {
+		return 0.8
+	}
+	return s.Threshold
+}
+
+var unsafeNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+func (s *Snapshots) path(name string) string {
+	return filepath.Join(s.Dir, unsafeNameChars.ReplaceAllString(name, "_")+".snap")
+}
+
+// lineDiff returns the lines of a and b aligned on their longest common
+// subsequence, prefixed with "-" when only in a, "+" when only in b.
+func lineDiff(a, b string) string {
+	linesA, linesB := strings.Split(a, "\n"), strings.Split(b, "\n")
+	// lcs[i][j] is the length of the longest common subsequence of
+	// linesA[i:] and linesB[j:]
+	lcs := make([][]int, len(linesA)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(linesB)+1)
+	}
+	for i := len(linesA) - 1; i >= 0; i-- {
+		for j := len(linesB) - 1; j >= 0; j-- {
+			if linesA[i] == linesB[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("--- approved\n+++ output\n")
+	i, j := 0, 0
+	for i < len(linesA) || j < len(linesB) {
+		switch {
+		case i < len(linesA) && j < len(linesB) && linesA[i] == linesB[j]:
+			sb.WriteString("  " + linesA[i] + "\n")
+			i++
+			j++
+		case j == len(linesB) || (i < len(linesA) && lcs[i+1][j] >= lcs[i][j+1]):
+			sb.WriteString("- " + linesA[i] + "\n")
+			i++
+		default:
+			sb.WriteString("+ " + linesB[j] + "\n")
+			j++
+		}
+	}
+	return sb.String()
+}
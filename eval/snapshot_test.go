@@ -0,0 +1,60 @@
+package eval
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm"
+)
+
+func TestSnapshots(t *testing.T) {
+	ctx := context.Background()
+	snapshots := &Snapshots{Dir: t.TempDir(), Threshold: 0.7}
+	answer := "The capital of France is Paris.\nIt is on the Seine."
+	l := &judgeLLM{respond: func(string) (string, error) { return answer, nil }}
+	cases := []SnapshotCase{{Name: "capital/france", Prompt: gollm.NewPrompt("Capital of France?")}}
+
+	results, err := snapshots.Run(ctx, l, cases)
+	require.NoError(t, err)
+	assert.True(t, results[0].Updated, "the first output is approved")
+	_, err = os.Stat(filepath.Join(snapshots.Dir, "capital_france.snap"))
+	require.NoError(t, err)
+
+	answer = "Paris is the capital of France.\nIt is on the Seine."
+	results, err = snapshots.Run(ctx, l, cases)
+	require.NoError(t, err)
+	assert.True(t, results[0].Passed, "rewording passes")
+	assert.False(t, results[0].Updated)
+
+	answer = "I cannot answer that.\nIt is on the Seine."
+	results, err = snapshots.Run(ctx, l, cases)
+	require.NoError(t, err)
+	assert.False(t, results[0].Passed)
+	assert.Less(t, results[0].Similarity, 0.7)
+	assert.Equal(t, "--- approved\n+++ output\n"+
+		"- The capital of France is Paris.\n"+
+		"+ I cannot answer that.\n"+
+		"  It is on the Seine.\n", results[0].Diff())
+
+	t.Setenv(UpdateSnapshotsEnv, "1")
+	results, err = snapshots.Run(ctx, l, cases)
+	require.NoError(t, err)
+	assert.True(t, results[0].Updated)
+	approved, err := os.ReadFile(filepath.Join(snapshots.Dir, "capital_france.snap"))
+	require.NoError(t, err)
+	assert.Equal(t, answer, string(approved))
+}
+
+func TestEmbeddingSimilarity(t *testing.T) {
+	vectors := map[string][]float64{"a": {1, 0}, "b": {1, 1}}
+	similarity := EmbeddingSimilarity(func(ctx context.Context, text string) ([]float64, error) {
+		return vectors[text], nil
+	})
+	score, err := similarity(context.Background(), "a", "b")
+	require.NoError(t, err)
+	assert.InDelta(t, 0.7071, score, 1e-4)
+}
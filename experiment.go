@@ -0,0 +1,451 @@
+package gollm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/teilomillet/gollm/llm"
+	"github.com/teilomillet/gollm/providers"
+	"github.com/teilomillet/gollm/utils"
+)
+
+// Variant is an arm of an Experiment: a model and, optionally, a change to
+// the prompt.
+type Variant struct {
+	// Name identifies the variant in the results and request metadata
+	Name string
+
+	// LLM serves the requests assigned to the variant
+	LLM LLM
+
+	// Weight is the share of traffic of the variant, relative to the others;
+	// variants share traffic equally when all weights are zero
+	Weight float64
+
+	// Prompt rewrites the prompt of the variant, e.g. to add a directive or
+	// switch templates; the prompt is sent unchanged if nil
+	Prompt func(*Prompt) *Prompt
+}
+
+// ExperimentConfig configures an Experiment.
+type ExperimentConfig struct {
+	// Name identifies the experiment; it salts the assignment hash, so the
+	// same user may get different variants in different experiments
+	Name string
+
+	// Variants are the arms of the experiment
+	Variants []Variant
+
+	// KeyMetadata is the request metadata key assigning requests to
+	// variants: requests with the same value, e.g. the same user, always get
+	// the same variant. Defaults to llm.MetadataUserKey; requests without it
+	// are assigned at random.
+	KeyMetadata string
+
+	// Evaluate scores the quality of each response, between 0 and 1, e.g.
+	// with an LLM judge; quality can also be reported later with Score
+	Evaluate func(ctx context.Context, prompt *Prompt, response string) (float64, error)
+
+	// Catalog prices the requests; defaults to providers.DefaultModelCatalog()
+	Catalog *providers.ModelCatalog
+
+	// Rand assigns the requests without key metadata; defaults to the shared
+	// source of math/rand. It is called under a lock, so a seeded *rand.Rand
+	// makes the assignment reproducible.
+	Rand utils.Rand
+}
+
+// ExperimentRun is the outcome of a request sent through an Experiment.
+type ExperimentRun struct {
+	Variant  string
+	Response string
+	Latency  time.Duration
+	Usage    llm.Usage
+	Quality  *float64 // The Evaluate score, nil without Evaluate or if it failed
+}
+
+// VariantResult aggregates the requests served by a variant.
+type VariantResult struct {
+	Name     string
+	Requests int
+	Errors   int
+	Latency  time.Duration // Mean latency of successful requests
+	Spend    Spend         // Token usage and cost of successful requests
+	Scores   int           // Number of quality scores
+	Quality  float64       // Mean quality score, between 0 and 1
+}
+
+// ErrorRate returns the fraction of failed requests.
+func (r VariantResult) ErrorRate() float64 {
+	if r.Requests == 0 {
+		return 0
+	}
+	return float64(r.Errors) / float64(r.Requests)
+}
+
+// CostPerRequest returns the mean cost in USD of successful requests.
+func (r VariantResult) CostPerRequest() float64 {
+	if succeeded := r.Requests - r.Errors; succeeded > 0 {
+		return r.Spend.Cost / float64(succeeded)
+	}
+	return 0
+}
+
+// variantStats accumulates the results of a variant.
+type variantStats struct {
+	requests int
+	errors   int
+	latency  time.Duration
+	scores   int
+	quality  float64
+}
+
+// Experiment is an LLM splitting traffic between prompt and model variants,
+// by weight or by a hash of a user key, and recording the error rate,
+// latency, cost and quality of each variant for analysis. The variant
+// serving a request is added to its metadata under "experiment" and
+// "variant", so cost trackers and tracers can break results down further.
+// Methods other than generation and streaming are served by the first
+// variant. It is safe for concurrent use.
+type Experiment struct {
+	LLM
+	name        string
+	variants    []Variant
+	cumulative  []float64 // Cumulative traffic shares, ending at 1
+	keyMetadata string
+	evaluate    func(ctx context.Context, prompt *Prompt, response string) (float64, error)
+	tracker     *CostTracker
+
+	randMu sync.Mutex // Guards rand, which may not be safe for concurrent use
+	rand   utils.Rand
+
+	mu    sync.Mutex
+	stats map[string]*variantStats
+}
+
+// NewExperiment creates an experiment over the configured variants.
+//
+// Example usage:
+//
+//	experiment, err := gollm.NewExperiment(gollm.ExperimentConfig{
+//	    Name: "summary-prompt",
+//	    Variants: []gollm.Variant{
+//	        {Name: "control", LLM: mini, Weight: 90},
+//	        {Name: "bullets", LLM: mini, Weight: 10, Prompt: func(p *gollm.Prompt) *gollm.Prompt {
+//	            p.Directives = append(p.Directives, "Answer in bullet points")
+//	            return p
+//	        }},
+//	    },
+//	})
+//	run, err := experiment.Run(ctx, prompt, gollm.WithMetadata(map[string]string{gollm.MetadataUserKey: userID}))
+//	// later, from user feedback
+//	experiment.Score(run.Variant, 1)
+//	for _, result := range experiment.Results() {
+//	    fmt.Println(result.Name, result.Quality, result.CostPerRequest())
+//	}
+func NewExperiment(cfg ExperimentConfig) (*Experiment, error) {
+	if len(cfg.Variants) == 0 {
+		return nil, fmt.Errorf("experiment needs at least one variant")
+	}
+	if cfg.KeyMetadata == "" {
+		cfg.KeyMetadata = llm.MetadataUserKey
+	}
+	if cfg.Rand == nil {
+		cfg.Rand = utils.SystemRand()
+	}
+
+	total := 0.0
+	names := make(map[string]bool)
+	for i, v := range cfg.Variants {
+		switch {
+		case v.LLM == nil:
+			return nil, fmt.Errorf("experiment variant %d has no LLM", i)
+		case v.Name == "":
+			return nil, fmt.Errorf("experiment variant %d has no name", i)
+		case names[v.Name]:
+			return nil, fmt.Errorf("duplicate experiment variant %q", v.Name)
+		case v.Weight < 0:
+			return nil, fmt.Errorf("experiment variant %q has a negative weight", v.Name)
+		}
+		names[v.Name] = true
+		total += v.Weight
+	}
+
+	e := &Experiment{
+		LLM:         cfg.Variants[0].LLM,
+		name:        cfg.Name,
+		variants:    append([]Variant(nil), cfg.Variants...),
+		keyMetadata: cfg.KeyMetadata,
+		evaluate:    cfg.Evaluate,
+		tracker:     NewCostTracker(cfg.Catalog),
+		rand:        cfg.Rand,
+		stats:       make(map[string]*variantStats),
+	}
+	sum := 0.0
+	for _, v := range e.variants {
+		if total > 0 {
+			sum += v.Weight / total
+		} else {
+			sum += 1 / float64(len(e.variants))
+		}
+		e.cumulative = append(e.cumulative, sum)
+		e.stats[v.Name] = &variantStats{}
+	}
+	e.cumulative[len(e.cumulative)-1] = 1
+	return e, nil
+}
+
+// Assign returns the variant of a key: always the same one for a given key
+// and experiment, with the configured traffic shares across keys.
+func (e *Experiment) Assign(key string) *Variant {
+	sum := sha256.Sum256([]byte(e.name + "/" + key))
+	return e.pick(float64(binary.BigEndian.Uint64(sum[:8])>>11) / (1 << 53))
+}
+
+// pick returns the variant whose traffic share covers x, between 0 and 1.
+func (e *Experiment) pick(x float64) *Variant {
+	for i, bound := range e.cumulative {
+		if x < bound {
+			return &e.variants[i]
+		}
+	}
+	return &e.variants[len(e.variants)-1]
+}
+
+// variant returns the variant of a request: assigned by its key metadata, or
+// at random.
+func (e *Experiment) variant(metadata map[string]string) *Variant {
+	if key := metadata[e.keyMetadata]; key != "" {
+		return e.Assign(key)
+	}
+	e.randMu.Lock()
+	x := e.rand.Float64()
+	e.randMu.Unlock()
+	return e.pick(x)
+}
+
+// Run sends the prompt to the variant assigned to the request and records
+// the outcome.
+func (e *Experiment) Run(ctx context.Context, prompt *Prompt, opts ...llm.GenerateOption) (*ExperimentRun, error) {
+	return e.run(ctx, prompt, opts, func(v *Variant, prompt *Prompt, opts []llm.GenerateOption) (string, error) {
+		return v.LLM.Generate(ctx, prompt, opts...)
+	})
+}
+
+// Generate sends the prompt to the variant assigned to the request.
+func (e *Experiment) Generate(ctx context.Context, prompt *Prompt, opts ...llm.GenerateOption) (string, error) {
+	run, err := e.Run(ctx, prompt, opts...)
+	if err != nil {
+		return "", err
+	}
+	return run.Response, nil
+}
+
+// GenerateWithSchema is like Generate for responses conforming to a schema.
+func (e *Experiment) GenerateWithSchema(ctx context.Context, prompt *Prompt, schema interface{}, opts ...llm.GenerateOption) (string, error) {
+	run, err := e.run(ctx, prompt, opts, func(v *Variant, prompt *Prompt, opts []llm.GenerateOption) (string, error) {
+		return v.LLM.GenerateWithSchema(ctx, prompt, schema, opts...)
+	})
+	if err != nil {
+		return "", err
+	}
+	return run.Response, nil
+}
+
+// GenerateFromTemplate executes the template and sends the resulting prompt
+// to the variant assigned to the request.
+func (e *Experiment) GenerateFromTemplate(ctx context.Context, tmpl *PromptTemplate, vars map[string]interface{}, opts ...llm.GenerateOption) (string, error) {
+	prompt, err := tmpl.Execute(vars)
+	if err != nil {
+		return "", fmt.Errorf("failed to execute template %s: %w", tmpl.Name, err)
+	}
+	return e.Generate(ctx, prompt, opts...)
+}
+
+// Stream streams from the variant assigned to the request. The usage is
+// recorded when the provider reports it at the end of the stream; quality
+// is not evaluated.
+func (e *Experiment) Stream(ctx context.Context, prompt *Prompt, opts ...llm.StreamOption) (llm.TokenStream, error) {
+	config := &llm.StreamConfig{}
+	for _, opt := range opts {
+		opt(config)
+	}
+	v := e.variant(config.Metadata)
+	opts = append(opts[:len(opts):len(opts)], llm.WithStreamMetadata(e.tags(v)))
+
+	start := time.Now()
+	stream, err := v.LLM.Stream(ctx, e.variantPrompt(v, prompt), opts...)
+	if err != nil {
+		e.record(v, 0, nil, err)
+		return nil, err
+	}
+	return &experimentStream{TokenStream: stream, experiment: e, variant: v, start: start}, nil
+}
+
+func (e *Experiment) run(ctx context.Context, prompt *Prompt, opts []llm.GenerateOption, call func(*Variant, *Prompt, []llm.GenerateOption) (string, error)) (*ExperimentRun, error) {
+	gen := &llm.GenerateConfig{}
+	for _, opt := range opts {
+		opt(gen)
+	}
+	v := e.variant(gen.Metadata)
+	opts = append(opts[:len(opts):len(opts)], llm.WithMetadata(e.tags(v)))
+
+	// Capture the usage, sharing the caller's WithUsage if any
+	usage := gen.Usage
+	if usage == nil {
+		usage = &llm.Usage{}
+		opts = append(opts, llm.WithUsage(usage))
+	}
+
+	prompt = e.variantPrompt(v, prompt)
+	start := time.Now()
+	response, err := call(v, prompt, opts)
+	latency := time.Since(start)
+	if err != nil {
+		e.record(v, latency, nil, err)
+		return nil, err
+	}
+	recorded := *usage
+	if recorded.TotalTokens == 0 {
+		recorded = llm.Usage{InputTokens: llm.EstimateTokens(prompt.String()), OutputTokens: llm.EstimateTokens(response)}
+	}
+	e.record(v, latency, &recorded, nil)
+
+	run := &ExperimentRun{Variant: v.Name, Response: response, Latency: latency, Usage: recorded}
+	if e.evaluate != nil {
+		if quality, err := e.evaluate(ctx, prompt, response); err == nil {
+			e.Score(v.Name, quality)
+			run.Quality = &quality
+		} else {
+			v.LLM.Debug("Failed to evaluate experiment response", "experiment", e.name, "variant", v.Name, "error", err)
+		}
+	}
+	return run, nil
+}
+
+// variantPrompt returns the prompt of a variant, leaving the caller's prompt
+// untouched.
+func (e *Experiment) variantPrompt(v *Variant, prompt *Prompt) *Prompt {
+	if v.Prompt == nil {
+		return prompt
+	}
+	clone := *prompt
+	clone.Directives = append([]string(nil), prompt.Directives...)
+	clone.Examples = append([]string(nil), prompt.Examples...)
+	clone.Messages = append([]PromptMessage(nil), prompt.Messages...)
+	return v.Prompt(&clone)
+}
+
+// tags returns the metadata naming the experiment and variant of a request.
+func (e *Experiment) tags(v *Variant) map[string]string {
+	return map[string]string{"experiment": e.name, "variant": v.Name}
+}
+
+// record adds the outcome of a request to the variant's results.
+func (e *Experiment) record(v *Variant, latency time.Duration, usage *llm.Usage, err error) {
+	if usage != nil {
+		e.tracker.RecordWithMetadata(v.LLM.GetProvider(), v.LLM.GetModel(), *usage, map[string]string{"variant": v.Name})
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	stats := e.stats[v.Name]
+	stats.requests++
+	if err != nil {
+		stats.errors++
+		return
+	}
+	stats.latency += latency
+}
+
+// Score records a quality score between 0 and 1 for a response of a
+// variant, e.g. from user feedback or an offline evaluation. Scores of
+// unknown variants are ignored.
+func (e *Experiment) Score(variant string, quality float64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if stats, ok := e.stats[variant]; ok {
+		stats.scores++
+		stats.quality += quality
+	}
+}
+
+// Variants returns the variants of the experiment.
+func (e *Experiment) Variants() []Variant {
+	return append([]Variant(nil), e.variants...)
+}
+
+// Results returns the results of each variant, in the configured order.
+func (e *Experiment) Results() []VariantResult {
+	spends := e.tracker.ByMetadata("variant")
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	results := make([]VariantResult, 0, len(e.variants))
+	for _, v := range e.variants {
+		stats := e.stats[v.Name]
+		result := VariantResult{
+			Name:     v.Name,
+			Requests: stats.requests,
+			Errors:   stats.errors,
+			Spend:    spends[v.Name],
+			Scores:   stats.scores,
+		}
+		if succeeded := stats.requests - stats.errors; succeeded > 0 {
+			result.Latency = stats.latency / time.Duration(succeeded)
+		}
+		if stats.scores > 0 {
+			result.Quality = stats.quality / float64(stats.scores)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// Reset clears the recorded results, e.g. after changing a variant.
+func (e *Experiment) Reset() {
+	e.tracker.Reset()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for name := range e.stats {
+		e.stats[name] = &variantStats{}
+	}
+}
+
+// experimentStream records the outcome of a stream once it ends.
+type experimentStream struct {
+	llm.TokenStream
+	experiment *Experiment
+	variant    *Variant
+	start      time.Time
+	usage      *llm.Usage
+	once       sync.Once
+}
+
+func (s *experimentStream) Next(ctx context.Context) (*llm.StreamToken, error) {
+	token, err := s.TokenStream.Next(ctx)
+	if token != nil && token.Type == llm.TokenTypeDone && token.Done != nil {
+		s.usage = token.Done.Usage
+	}
+	switch {
+	case err == io.EOF:
+		s.finish(nil)
+	case err != nil:
+		s.finish(err)
+	}
+	return token, err
+}
+
+func (s *experimentStream) Close() error {
+	s.finish(nil)
+	return s.TokenStream.Close()
+}
+
+func (s *experimentStream) finish(err error) {
+	s.once.Do(func() {
+		s.experiment.record(s.variant, time.Since(s.start), s.usage, err)
+	})
+}
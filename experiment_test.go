@@ -0,0 +1,152 @@
+package gollm_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm"
+	"github.com/teilomillet/gollm/providers"
+)
+
+func TestExperiment(t *testing.T) {
+	ctx := context.Background()
+	catalog := providers.NewModelCatalog(
+		providers.ModelInfo{Provider: "mock", Model: "a", InputPerMillion: 1e6, OutputPerMillion: 1e6},
+		providers.ModelInfo{Provider: "mock", Model: "b", InputPerMillion: 2e6, OutputPerMillion: 2e6},
+	)
+
+	t.Run("AssignmentByKey", func(t *testing.T) {
+		experiment, err := gollm.NewExperiment(gollm.ExperimentConfig{
+			Name: "split",
+			Variants: []gollm.Variant{
+				{Name: "control", LLM: newBudgetLLM(t, "a", "A"), Weight: 75},
+				{Name: "treatment", LLM: newBudgetLLM(t, "b", "B"), Weight: 25},
+			},
+		})
+		require.NoError(t, err)
+
+		counts := map[string]int{}
+		for i := 0; i < 2000; i++ {
+			key := fmt.Sprintf("user-%d", i)
+			variant := experiment.Assign(key)
+			assert.Same(t, variant, experiment.Assign(key), "a key always gets the same variant")
+			counts[variant.Name]++
+		}
+		assert.InDelta(t, 1500, counts["control"], 100)
+		assert.InDelta(t, 500, counts["treatment"], 100)
+
+		user := gollm.WithMetadata(map[string]string{gollm.MetadataUserKey: "user-7"})
+		expected := experiment.Assign("user-7").Name
+		for i := 0; i < 3; i++ {
+			run, err := experiment.Run(ctx, gollm.NewPrompt("Hi"), user)
+			require.NoError(t, err)
+			assert.Equal(t, expected, run.Variant)
+		}
+	})
+
+	t.Run("Results", func(t *testing.T) {
+		failing := newBudgetLLM(t, "b", "")
+		mock, err := gollm.GetMockProvider(failing)
+		require.NoError(t, err)
+		mock.SetResponder(func(call gollm.MockCall) (string, error) {
+			if strings.Contains(call.Prompt, "fail") {
+				return "", errors.New("boom")
+			}
+			assert.Contains(t, call.Prompt, "Be brief", "the variant rewrites the prompt")
+			return "one two three", nil
+		})
+		experiment, err := gollm.NewExperiment(gollm.ExperimentConfig{
+			Name: "results",
+			Variants: []gollm.Variant{
+				{Name: "control", LLM: newBudgetLLM(t, "a", "one")},
+				{Name: "brief", LLM: failing, Prompt: func(p *gollm.Prompt) *gollm.Prompt {
+					p.Directives = append(p.Directives, "Be brief")
+					return p
+				}},
+			},
+			KeyMetadata: "session",
+			Evaluate: func(ctx context.Context, prompt *gollm.Prompt, response string) (float64, error) {
+				return 1 / float64(len(strings.Fields(response))), nil
+			},
+			Catalog: catalog,
+		})
+		require.NoError(t, err)
+
+		keyFor := func(variant string) string {
+			for i := 0; ; i++ {
+				if key := fmt.Sprint(i); experiment.Assign(key).Name == variant {
+					return key
+				}
+			}
+		}
+		control := gollm.WithMetadata(map[string]string{"session": keyFor("control")})
+		brief := gollm.WithMetadata(map[string]string{"session": keyFor("brief")})
+
+		prompt := gollm.NewPrompt("Hi")
+		for i := 0; i < 2; i++ {
+			_, err := experiment.Generate(ctx, prompt, control)
+			require.NoError(t, err)
+		}
+		run, err := experiment.Run(ctx, prompt, brief)
+		require.NoError(t, err)
+		require.NotNil(t, run.Quality)
+		assert.InDelta(t, 1.0/3, *run.Quality, 1e-9)
+		assert.Empty(t, prompt.Directives, "the caller's prompt is untouched")
+		_, err = experiment.Generate(ctx, gollm.NewPrompt("fail"), brief)
+		assert.Error(t, err)
+		experiment.Score("brief", 1)
+
+		results := experiment.Results()
+		require.Len(t, results, 2)
+		assert.Equal(t, 2, results[0].Requests)
+		assert.Equal(t, 1.0, results[0].Quality)
+		assert.Equal(t, 2, results[0].Spend.Requests)
+		assert.Positive(t, results[0].CostPerRequest())
+
+		assert.Equal(t, 2, results[1].Requests)
+		assert.Equal(t, 0.5, results[1].ErrorRate())
+		assert.Equal(t, 2, results[1].Scores)
+		assert.InDelta(t, 2.0/3, results[1].Quality, 1e-9)
+
+		experiment.Reset()
+		assert.Zero(t, experiment.Results()[0].Requests)
+	})
+
+	t.Run("SeededRand", func(t *testing.T) {
+		assignments := func() []string {
+			experiment, err := gollm.NewExperiment(gollm.ExperimentConfig{
+				Variants: []gollm.Variant{
+					{Name: "control", LLM: newBudgetLLM(t, "a", "A")},
+					{Name: "treatment", LLM: newBudgetLLM(t, "b", "B")},
+				},
+				Rand: rand.New(rand.NewSource(1)),
+			})
+			require.NoError(t, err)
+			var variants []string
+			for i := 0; i < 20; i++ {
+				run, err := experiment.Run(ctx, gollm.NewPrompt("Hi"))
+				require.NoError(t, err)
+				variants = append(variants, run.Variant)
+			}
+			return variants
+		}
+		first := assignments()
+		assert.Equal(t, first, assignments(), "requests without a key follow the seeded source")
+		assert.Contains(t, first, "control")
+		assert.Contains(t, first, "treatment")
+	})
+
+	t.Run("InvalidConfig", func(t *testing.T) {
+		_, err := gollm.NewExperiment(gollm.ExperimentConfig{})
+		assert.Error(t, err)
+		l := newBudgetLLM(t, "a", "A")
+		_, err = gollm.NewExperiment(gollm.ExperimentConfig{Variants: []gollm.Variant{{Name: "x", LLM: l}, {Name: "x", LLM: l}}})
+		assert.ErrorContains(t, err, "duplicate")
+	})
+}
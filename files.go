@@ -0,0 +1,65 @@
+// Package gollm provides file management for Language Learning Models.
+// This file contains type definitions and re-exports for storing artifacts,
+// such as fine-tuning data, with a provider's Files API.
+package gollm
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/teilomillet/gollm/llm"
+)
+
+// Re-export file types from the llm package
+type (
+	// FileInfo describes a file stored with a provider.
+	FileInfo = llm.FileInfo
+
+	// FileOption configures a file upload.
+	FileOption = llm.FileOption
+)
+
+// Re-export file options from the llm package
+var (
+	// WithFilePurpose sets the use of an uploaded file, e.g. "fine-tune".
+	WithFilePurpose = llm.WithFilePurpose
+
+	// WithFileMediaType sets the MIME type of an uploaded file instead of detecting it.
+	WithFileMediaType = llm.WithFileMediaType
+)
+
+// fileManager is implemented by LLMs that manage provider files.
+type fileManager interface {
+	UploadFile(context.Context, string, io.Reader, ...llm.FileOption) (*llm.FileInfo, error)
+	ListFiles(context.Context) ([]llm.FileInfo, error)
+	DeleteFile(context.Context, string) error
+}
+
+// UploadFile stores a file with the configured provider's Files API.
+// Supported providers are "openai", "mistral" and "anthropic".
+func (l *llmImpl) UploadFile(ctx context.Context, filename string, content io.Reader, opts ...FileOption) (*FileInfo, error) {
+	f, ok := l.LLM.(fileManager)
+	if !ok {
+		return nil, fmt.Errorf("file management not supported by provider %s", l.provider.Name())
+	}
+	return f.UploadFile(ctx, filename, content, opts...)
+}
+
+// ListFiles returns the files stored with the configured provider.
+func (l *llmImpl) ListFiles(ctx context.Context) ([]FileInfo, error) {
+	f, ok := l.LLM.(fileManager)
+	if !ok {
+		return nil, fmt.Errorf("file management not supported by provider %s", l.provider.Name())
+	}
+	return f.ListFiles(ctx)
+}
+
+// DeleteFile removes a file from the configured provider.
+func (l *llmImpl) DeleteFile(ctx context.Context, fileID string) error {
+	f, ok := l.LLM.(fileManager)
+	if !ok {
+		return fmt.Errorf("file management not supported by provider %s", l.provider.Name())
+	}
+	return f.DeleteFile(ctx, fileID)
+}
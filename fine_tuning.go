@@ -0,0 +1,88 @@
+// Package gollm provides fine-tuning functionality for Language Learning Models.
+// This file contains type definitions and re-exports for creating and
+// monitoring fine-tuning jobs with a provider's fine-tuning API.
+package gollm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/teilomillet/gollm/llm"
+	"github.com/teilomillet/gollm/providers"
+)
+
+// Re-export fine-tuning types from the llm package
+type (
+	// FineTuneJob tracks a fine-tuning job run by the provider.
+	FineTuneJob = llm.FineTuneJob
+
+	// FineTuneOption configures a fine-tuning job.
+	FineTuneOption = llm.FineTuneOption
+
+	// FineTuneUpdate is an event or error received while watching a job.
+	FineTuneUpdate = llm.FineTuneUpdate
+
+	// FineTuneEvent is a progress message of a fine-tuning job.
+	FineTuneEvent = providers.FineTuneEvent
+
+	// FineTuneStatus is the state of a fine-tuning job.
+	FineTuneStatus = providers.FineTuneStatus
+)
+
+// Fine-tuning job states.
+const (
+	FineTuneQueued    = providers.FineTuneQueued
+	FineTuneRunning   = providers.FineTuneRunning
+	FineTuneSucceeded = providers.FineTuneSucceeded
+	FineTuneFailed    = providers.FineTuneFailed
+	FineTuneCanceled  = providers.FineTuneCanceled
+)
+
+// Re-export fine-tuning options and helpers from the llm package
+var (
+	// WithValidationFile sets the uploaded file evaluating the model during training.
+	WithValidationFile = llm.WithValidationFile
+
+	// WithFineTuneSuffix sets a suffix added to the name of the fine-tuned model.
+	WithFineTuneSuffix = llm.WithFineTuneSuffix
+
+	// WithHyperparameter sets a training hyperparameter, such as "n_epochs".
+	WithHyperparameter = llm.WithHyperparameter
+
+	// ValidateTrainingData checks JSONL fine-tuning data before it is uploaded.
+	ValidateTrainingData = llm.ValidateTrainingData
+)
+
+type fineTuner interface {
+	CreateFineTune(context.Context, string, string, ...llm.FineTuneOption) (*llm.FineTuneJob, error)
+	GetFineTune(context.Context, string) (*llm.FineTuneJob, error)
+	ListFineTunes(context.Context) ([]*llm.FineTuneJob, error)
+}
+
+// CreateFineTune starts fine-tuning a base model on an uploaded training file.
+// Supported providers are "openai" and "mistral".
+func (l *llmImpl) CreateFineTune(ctx context.Context, model, trainingFile string, opts ...FineTuneOption) (*FineTuneJob, error) {
+	f, ok := l.LLM.(fineTuner)
+	if !ok {
+		return nil, fmt.Errorf("fine-tuning not supported by provider %s", l.provider.Name())
+	}
+	return f.CreateFineTune(ctx, model, trainingFile, opts...)
+}
+
+// GetFineTune returns a previously created fine-tuning job by ID.
+func (l *llmImpl) GetFineTune(ctx context.Context, id string) (*FineTuneJob, error) {
+	f, ok := l.LLM.(fineTuner)
+	if !ok {
+		return nil, fmt.Errorf("fine-tuning not supported by provider %s", l.provider.Name())
+	}
+	return f.GetFineTune(ctx, id)
+}
+
+// ListFineTunes returns the most recent fine-tuning jobs of the account.
+func (l *llmImpl) ListFineTunes(ctx context.Context) ([]*FineTuneJob, error) {
+	f, ok := l.LLM.(fineTuner)
+	if !ok {
+		return nil, fmt.Errorf("fine-tuning not supported by provider %s", l.provider.Name())
+	}
+	return f.ListFineTunes(ctx)
+}
@@ -6,6 +6,7 @@ package gollm
 import (
 	"context"
 	"fmt"
+	"io"
 
 	"github.com/teilomillet/gollm/config"
 	"github.com/teilomillet/gollm/llm"
@@ -37,6 +38,58 @@ type LLM interface {
 	// SetSystemPrompt updates the system prompt with caching configuration.
 	// The cacheType parameter determines how the prompt should be cached.
 	SetSystemPrompt(prompt string, cacheType CacheType)
+	// SetToolChoice sets whether the model may, must or must not call tools, or
+	// which tool it must call, for requests whose prompt doesn't set it.
+	// The choice is ToolChoiceAuto, ToolChoiceNone, ToolChoiceRequired or a tool name.
+	SetToolChoice(choice string)
+	// Transcribe converts speech audio to text using the provider's speech-to-text API.
+	// Returns an error if the current provider doesn't support transcription.
+	Transcribe(ctx context.Context, audio io.Reader, opts ...TranscriptionOption) (*Transcription, error)
+	// TranscribeLong splits long PCM WAV audio at pauses, transcribes the chunks in
+	// parallel and stitches their text and timestamps together.
+	TranscribeLong(ctx context.Context, audio io.Reader, opts ...LongTranscriptionOption) (*Transcription, error)
+	// Speak converts text to speech and returns the audio stream, which the caller must close.
+	// Returns an error if the current provider doesn't support speech synthesis.
+	Speak(ctx context.Context, text, voice string, opts ...SpeechOption) (io.ReadCloser, error)
+	// Moderate classifies text with the provider's moderation API and returns the category scores.
+	// Returns an error if the current provider doesn't support moderation.
+	Moderate(ctx context.Context, text string, opts ...ModerationOption) (*ModerationResult, error)
+	// Realtime opens a low-latency voice and text session over the provider's WebSocket API.
+	// Returns an error if the current provider doesn't support realtime sessions.
+	Realtime(ctx context.Context, opts ...RealtimeOption) (*RealtimeSession, error)
+	// DeleteUploadedFiles removes documents that were automatically uploaded to the
+	// provider's Files API. It is a no-op for providers without a Files API.
+	DeleteUploadedFiles(ctx context.Context) error
+	// UploadFile stores a file, such as fine-tuning data, with the provider's Files API.
+	// Returns an error if the current provider doesn't have a Files API.
+	UploadFile(ctx context.Context, filename string, content io.Reader, opts ...FileOption) (*FileInfo, error)
+	// ListFiles returns the files stored with the provider's Files API.
+	ListFiles(ctx context.Context) ([]FileInfo, error)
+	// DeleteFile removes a file from the provider's Files API.
+	DeleteFile(ctx context.Context, fileID string) error
+	// HealthCheck returns nil when the provider is reachable and accepts the credentials.
+	HealthCheck(ctx context.Context) error
+	// ListModels returns the models available with the provider and adds them to the
+	// default model catalog. Returns an error if the provider can't list its models.
+	ListModels(ctx context.Context) ([]ModelInfo, error)
+	// SubmitBatch submits prompts to the provider's asynchronous batch API.
+	// Returns an error if the current provider doesn't support batches.
+	SubmitBatch(ctx context.Context, items []BatchItem, opts ...llm.GenerateOption) (*BatchJob, error)
+	// GetBatch returns a previously submitted batch by ID.
+	GetBatch(ctx context.Context, id string) (*BatchJob, error)
+	// CreateFineTune starts fine-tuning a base model on a file uploaded with UploadFile.
+	// Returns an error if the current provider doesn't support fine-tuning.
+	CreateFineTune(ctx context.Context, model, trainingFile string, opts ...FineTuneOption) (*FineTuneJob, error)
+	// GetFineTune returns a previously created fine-tuning job by ID.
+	GetFineTune(ctx context.Context, id string) (*FineTuneJob, error)
+	// ListFineTunes returns the most recent fine-tuning jobs.
+	ListFineTunes(ctx context.Context) ([]*FineTuneJob, error)
+	// NewAssistant creates an assistant with persistent instructions, tools and threads,
+	// hosted by the provider when it can and emulated otherwise.
+	NewAssistant(ctx context.Context, config AssistantConfig) (*Assistant, error)
+	// GenerateFromTemplate executes a prompt template with the given variables
+	// and generates a response from the resulting prompt.
+	GenerateFromTemplate(ctx context.Context, tmpl *PromptTemplate, vars map[string]interface{}, opts ...llm.GenerateOption) (string, error)
 }
 
 // llmImpl is the concrete implementation of the LLM interface.
@@ -56,6 +109,11 @@ func (l *llmImpl) SetSystemPrompt(prompt string, cacheType CacheType) {
 	l.SetOption("system_prompt", newPrompt)
 }
 
+// SetToolChoice sets the default tool choice of the LLM.
+func (l *llmImpl) SetToolChoice(choice string) {
+	l.SetOption("tool_choice", choice)
+}
+
 // GetProvider returns the provider of the LLM.
 func (l *llmImpl) GetProvider() string {
 	return l.provider.Name()
@@ -91,6 +149,44 @@ func (l *llmImpl) SetOllamaEndpoint(endpoint string) error {
 	return fmt.Errorf("current provider does not support setting custom endpoint")
 }
 
+// DeleteUploadedFiles removes documents uploaded to the provider's Files API.
+func (l *llmImpl) DeleteUploadedFiles(ctx context.Context) error {
+	if d, ok := l.LLM.(interface{ DeleteUploadedFiles(context.Context) error }); ok {
+		return d.DeleteUploadedFiles(ctx)
+	}
+	return nil
+}
+
+// HealthCheck checks that the provider is reachable and accepts the credentials.
+func (l *llmImpl) HealthCheck(ctx context.Context) error {
+	if c, ok := l.LLM.(llm.HealthChecker); ok {
+		return c.HealthCheck(ctx)
+	}
+	return nil
+}
+
+// WarmUp opens a connection to the provider ahead of the first request.
+func (l *llmImpl) WarmUp(ctx context.Context) error {
+	if w, ok := l.LLM.(llm.WarmUpper); ok {
+		return w.WarmUp(ctx)
+	}
+	return nil
+}
+
+// UsePromptProcessors appends processors run on every prompt before it is sent.
+func (l *llmImpl) UsePromptProcessors(processors ...PromptProcessor) {
+	if u, ok := l.LLM.(llm.ProcessorUser); ok {
+		u.UsePromptProcessors(processors...)
+	}
+}
+
+// UseResponseProcessors appends processors run on every response after it is parsed.
+func (l *llmImpl) UseResponseProcessors(processors ...ResponseProcessor) {
+	if u, ok := l.LLM.(llm.ProcessorUser); ok {
+		u.UseResponseProcessors(processors...)
+	}
+}
+
 // GetPromptJSONSchema generates and returns the JSON schema for the Prompt.
 func (l *llmImpl) GetPromptJSONSchema(opts ...SchemaOption) ([]byte, error) {
 	p := &Prompt{}
@@ -130,6 +226,15 @@ func (l *llmImpl) Generate(ctx context.Context, prompt *llm.Prompt, opts ...llm.
 	return response, nil
 }
 
+// GenerateFromTemplate executes the template and generates a response from the resulting prompt.
+func (l *llmImpl) GenerateFromTemplate(ctx context.Context, tmpl *PromptTemplate, vars map[string]interface{}, opts ...llm.GenerateOption) (string, error) {
+	prompt, err := tmpl.Execute(vars)
+	if err != nil {
+		return "", fmt.Errorf("failed to execute template %s: %w", tmpl.Name, err)
+	}
+	return l.Generate(ctx, prompt, opts...)
+}
+
 // NewLLM creates a new LLM instance with the specified configuration options.
 // It supports memory management, caching, and provider-specific optimizations.
 // If memory options are provided, it creates an LLM instance with conversation memory.
@@ -154,14 +259,28 @@ func NewLLM(opts ...ConfigOption) (LLM, error) {
 	for _, opt := range opts {
 		opt(cfg)
 	}
+	return newLLMFromConfig(cfg)
+}
+
+// newLLMFromConfig creates an LLM from a loaded configuration.
+func newLLMFromConfig(cfg *config.Config) (LLM, error) {
+	cfg.ResolveModel()
+	if len(cfg.APIKeySecrets) > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
+		err := cfg.ResolveSecrets(ctx)
+		cancel()
+		if err != nil {
+			return nil, err
+		}
+	}
 
-	// For Ollama, ensure we have a dummy API key if none is provided
-	if cfg.Provider == "ollama" {
+	// For local servers and the mock provider, ensure we have a dummy API key if none is provided
+	if cfg.Provider == "ollama" || cfg.Provider == "llamacpp" || cfg.Provider == "mock" {
 		if cfg.APIKeys == nil {
 			cfg.APIKeys = make(map[string]string)
 		}
 		if _, exists := cfg.APIKeys[cfg.Provider]; !exists || cfg.APIKeys[cfg.Provider] == "" {
-			cfg.APIKeys[cfg.Provider] = "ollama-local"
+			cfg.APIKeys[cfg.Provider] = cfg.Provider + "-local"
 		}
 	}
 
@@ -204,6 +323,9 @@ func NewLLM(opts ...ConfigOption) (LLM, error) {
 			logger.Error("Failed to create LLM with memory", "error", err)
 			return nil, fmt.Errorf("failed to create LLM with memory: %w", err)
 		}
+		if strategy := cfg.MemoryOption.Strategy; strategy != nil {
+			llmWithMemory.(*llm.LLMWithMemory).SetMemoryStrategy(strategy)
+		}
 		llmInstance.LLM = llmWithMemory
 	}
 
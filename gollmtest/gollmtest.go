@@ -0,0 +1,93 @@
+// Package gollmtest provides LLMs for testing code built on top of gollm.
+//
+// ScriptedLLM is a scripted gollm.LLM for code such as agents and prompt
+// presets. Unlike a MockProvider, which sees requests once they are built, it
+// records the prompts as they are passed to Generate, so tests can check
+// their input, system prompt, directives or tools.
+//
+// Example usage:
+//
+//	l := &gollmtest.ScriptedLLM{Responses: []string{`{"label": "billing"}`}}
+//	result, err := presets.Classify(ctx, l, "Where is my refund?", labels)
+//	prompts := l.Prompts() // prompts[0].Input contains the text to classify
+//
+// NewMockLLM creates a real LLM served by the mock provider, for tests that
+// go through request building, retries, streaming or usage reporting.
+package gollmtest
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/teilomillet/gollm"
+	"github.com/teilomillet/gollm/llm"
+)
+
+// ErrNoResponse is returned by ScriptedLLM.Generate once the responses are
+// exhausted.
+var ErrNoResponse = errors.New("no scripted response left")
+
+// ScriptedLLM returns canned responses in order and records the prompts it
+// receives. Only Generate and SupportsStreaming are implemented; other
+// methods panic through the nil embedded interface, so tests needing them
+// embed ScriptedLLM in their own type.
+type ScriptedLLM struct {
+	gollm.LLM
+
+	// Responses are returned by Generate in order
+	Responses []string
+
+	mu      sync.Mutex
+	prompts []*gollm.Prompt
+}
+
+// Generate records the prompt and returns the next response, or
+// ErrNoResponse once there is none left.
+func (s *ScriptedLLM) Generate(ctx context.Context, prompt *gollm.Prompt, opts ...llm.GenerateOption) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.prompts = append(s.prompts, prompt)
+	if len(s.Responses) == 0 {
+		return "", ErrNoResponse
+	}
+	response := s.Responses[0]
+	s.Responses = s.Responses[1:]
+	return response, nil
+}
+
+// SupportsStreaming returns false: scripted responses are only generated.
+func (s *ScriptedLLM) SupportsStreaming() bool {
+	return false
+}
+
+// Prompts returns the prompts received by Generate, in order.
+func (s *ScriptedLLM) Prompts() []*gollm.Prompt {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*gollm.Prompt(nil), s.prompts...)
+}
+
+// NewMockLLM creates an LLM served by the mock provider, without retries or
+// logs, and returns it with its provider to script replies and inspect
+// requests. Options are applied after these defaults, e.g. gollm.SetModel to
+// name the model. The test fails if the LLM cannot be created.
+//
+// Example usage:
+//
+//	l, mock := gollmtest.NewMockLLM(t, gollm.SetModel("fast"))
+//	mock.QueueResponse("Paris")
+func NewMockLLM(t testing.TB, opts ...gollm.ConfigOption) (gollm.LLM, *gollm.MockProvider) {
+	t.Helper()
+	defaults := []gollm.ConfigOption{gollm.SetProvider("mock"), gollm.SetMaxRetries(0), gollm.SetLogLevel(gollm.LogLevelOff)}
+	l, err := gollm.NewLLM(append(defaults, opts...)...)
+	if err != nil {
+		t.Fatalf("failed to create mock LLM: %v", err)
+	}
+	mock, err := gollm.GetMockProvider(l)
+	if err != nil {
+		t.Fatalf("failed to get mock provider: %v", err)
+	}
+	return l, mock
+}
@@ -0,0 +1,42 @@
+package gollmtest
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm"
+)
+
+func TestScriptedLLM(t *testing.T) {
+	ctx := context.Background()
+	l := &ScriptedLLM{Responses: []string{"one", "two"}}
+	var _ gollm.LLM = l
+
+	for _, want := range []string{"one", "two"} {
+		response, err := l.Generate(ctx, gollm.NewPrompt("Count"))
+		require.NoError(t, err)
+		assert.Equal(t, want, response)
+	}
+	_, err := l.Generate(ctx, gollm.NewPrompt("Again", gollm.WithDirectives("Be brief")))
+	assert.ErrorIs(t, err, ErrNoResponse)
+
+	prompts := l.Prompts()
+	require.Len(t, prompts, 3)
+	assert.Equal(t, "Again", prompts[2].Input)
+	assert.Equal(t, []string{"Be brief"}, prompts[2].Directives)
+	assert.False(t, l.SupportsStreaming())
+}
+
+func TestNewMockLLM(t *testing.T) {
+	l, mock := NewMockLLM(t, gollm.SetModel("fast"))
+	assert.Equal(t, "mock", l.GetProvider())
+	assert.Equal(t, "fast", l.GetModel())
+
+	mock.QueueError(http.StatusInternalServerError, "down")
+	_, err := l.Generate(context.Background(), gollm.NewPrompt("Hi"))
+	assert.Error(t, err)
+	assert.Equal(t, 1, mock.CallCount(), "failed requests are not retried")
+}
@@ -0,0 +1,295 @@
+// Package guardrails validates prompts and responses with configurable checks
+// and blocks, redacts, rewrites or flags the content that fails them.
+package guardrails
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/teilomillet/gollm"
+	"github.com/teilomillet/gollm/llm"
+)
+
+// Stage identifies whether a check runs on the prompt or on the response.
+type Stage string
+
+// Stages a rule can apply to.
+const (
+	StageInput  Stage = "input"
+	StageOutput Stage = "output"
+)
+
+// Action is what the pipeline does when a check is triggered.
+type Action string
+
+// Available actions, from least to most intrusive.
+const (
+	// ActionFlag records the finding and lets the text through unchanged
+	ActionFlag Action = "flag"
+	// ActionRedact replaces the offending parts of the text
+	ActionRedact Action = "redact"
+	// ActionRewrite replaces the text with a rewritten version
+	ActionRewrite Action = "rewrite"
+	// ActionBlock stops the request with a *BlockedError
+	ActionBlock Action = "block"
+)
+
+// Result is the outcome of a check on a text.
+type Result struct {
+	Triggered bool
+	Message   string
+	// Matches lists the offending parts of the text, when the check can locate them
+	Matches []string
+	// Redacted is the text with the offending parts replaced, for checks that support redaction
+	Redacted string
+}
+
+// Check inspects a text.
+type Check interface {
+	Name() string
+	Check(ctx context.Context, text string) (Result, error)
+}
+
+// Rewriter produces an acceptable version of a text that failed a check.
+type Rewriter func(ctx context.Context, text string, finding Finding) (string, error)
+
+// Rule binds a check to the action taken when it is triggered.
+type Rule struct {
+	Check  Check
+	Action Action
+	// Stages the rule applies to; defaults to both
+	Stages []Stage
+	// Rewriter is required by ActionRewrite
+	Rewriter Rewriter
+}
+
+// appliesTo reports whether the rule runs at the stage.
+func (r Rule) appliesTo(stage Stage) bool {
+	if len(r.Stages) == 0 {
+		return true
+	}
+	for _, s := range r.Stages {
+		if s == stage {
+			return true
+		}
+	}
+	return false
+}
+
+// Finding records a triggered rule.
+type Finding struct {
+	Check   string   `json:"check"`
+	Stage   Stage    `json:"stage"`
+	Action  Action   `json:"action"`
+	Message string   `json:"message,omitempty"`
+	Matches []string `json:"matches,omitempty"`
+}
+
+// BlockedError is returned when a rule with ActionBlock is triggered.
+type BlockedError struct {
+	Finding Finding
+}
+
+func (e *BlockedError) Error() string {
+	if e.Finding.Message != "" {
+		return fmt.Sprintf("%s blocked by guardrail %q: %s", e.Finding.Stage, e.Finding.Check, e.Finding.Message)
+	}
+	return fmt.Sprintf("%s blocked by guardrail %q", e.Finding.Stage, e.Finding.Check)
+}
+
+// IsBlocked reports whether err was caused by a blocking guardrail.
+func IsBlocked(err error) bool {
+	var blocked *BlockedError
+	return errors.As(err, &blocked)
+}
+
+// Pipeline runs rules in order. Each rule sees the text as modified by the
+// previous rules, so a redaction rule placed first hides data from later checks.
+type Pipeline struct {
+	rules     []Rule
+	onFinding func(Finding)
+}
+
+// PipelineOption configures a Pipeline.
+type PipelineOption func(*Pipeline)
+
+// WithRule appends a rule to the pipeline.
+func WithRule(check Check, action Action, stages ...Stage) PipelineOption {
+	return func(p *Pipeline) {
+		p.rules = append(p.rules, Rule{Check: check, Action: action, Stages: stages})
+	}
+}
+
+// WithRewriteRule appends a rule that rewrites the text with the rewriter.
+func WithRewriteRule(check Check, rewriter Rewriter, stages ...Stage) PipelineOption {
+	return func(p *Pipeline) {
+		p.rules = append(p.rules, Rule{Check: check, Action: ActionRewrite, Stages: stages, Rewriter: rewriter})
+	}
+}
+
+// WithFindingHandler calls fn for every triggered rule, e.g. for audit logging.
+func WithFindingHandler(fn func(Finding)) PipelineOption {
+	return func(p *Pipeline) {
+		p.onFinding = fn
+	}
+}
+
+// NewPipeline creates a guardrails pipeline.
+//
+// Example usage:
+//
+//	pipeline, err := guardrails.NewPipeline(
+//	    guardrails.WithRule(guardrails.PIICheck(), guardrails.ActionRedact, guardrails.StageInput),
+//	    guardrails.WithRule(guardrails.RegexCheck("secrets", `sk-[A-Za-z0-9]{20,}`), guardrails.ActionBlock),
+//	    guardrails.WithRule(guardrails.TopicCheck(classifier, "medical advice"), guardrails.ActionFlag, guardrails.StageOutput),
+//	)
+//	safe := guardrails.Wrap(llm, pipeline)
+func NewPipeline(opts ...PipelineOption) (*Pipeline, error) {
+	p := &Pipeline{}
+	for _, opt := range opts {
+		opt(p)
+	}
+	for i, rule := range p.rules {
+		if rule.Check == nil {
+			return nil, fmt.Errorf("rule %d has no check", i+1)
+		}
+		switch rule.Action {
+		case ActionFlag, ActionRedact, ActionBlock:
+		case ActionRewrite:
+			if rule.Rewriter == nil {
+				return nil, fmt.Errorf("rule %q uses the rewrite action without a rewriter", rule.Check.Name())
+			}
+		default:
+			return nil, fmt.Errorf("rule %q has unknown action %q", rule.Check.Name(), rule.Action)
+		}
+	}
+	return p, nil
+}
+
+// Apply runs the rules of the stage on the text and returns the possibly
+// modified text with the findings. If a blocking rule is triggered, a
+// *BlockedError is returned.
+func (p *Pipeline) Apply(ctx context.Context, stage Stage, text string) (string, []Finding, error) {
+	var findings []Finding
+	for _, rule := range p.rules {
+		if !rule.appliesTo(stage) {
+			continue
+		}
+		result, err := rule.Check.Check(ctx, text)
+		if err != nil {
+			return text, findings, fmt.Errorf("guardrail %q failed: %w", rule.Check.Name(), err)
+		}
+		if !result.Triggered {
+			continue
+		}
+
+		finding := Finding{Check: rule.Check.Name(), Stage: stage, Action: rule.Action, Message: result.Message, Matches: result.Matches}
+		findings = append(findings, finding)
+		if p.onFinding != nil {
+			p.onFinding(finding)
+		}
+
+		switch rule.Action {
+		case ActionBlock:
+			return text, findings, &BlockedError{Finding: finding}
+		case ActionRedact:
+			if result.Redacted != "" {
+				text = result.Redacted
+			} else {
+				text = "[REDACTED]"
+			}
+		case ActionRewrite:
+			rewritten, err := rule.Rewriter(ctx, text, finding)
+			if err != nil {
+				return text, findings, fmt.Errorf("guardrail %q failed to rewrite: %w", rule.Check.Name(), err)
+			}
+			text = rewritten
+		}
+	}
+	return text, findings, nil
+}
+
+// guardedLLM applies a pipeline around Generate.
+type guardedLLM struct {
+	gollm.LLM
+	pipeline *Pipeline
+
+	mu       sync.Mutex
+	findings []Finding
+}
+
+// GuardedLLM is an LLM whose prompts and responses pass through a guardrails pipeline.
+type GuardedLLM interface {
+	gollm.LLM
+	// Findings returns the findings of the most recent Generate call
+	Findings() []Finding
+}
+
+// Wrap returns an LLM that applies the pipeline to the input, context and
+// messages of every prompt before sending it, and to every response before
+// returning it.
+func Wrap(l gollm.LLM, pipeline *Pipeline) GuardedLLM {
+	return &guardedLLM{LLM: l, pipeline: pipeline}
+}
+
+// Generate applies the input rules, generates a response and applies the
+// output rules. The caller's prompt is not modified.
+func (g *guardedLLM) Generate(ctx context.Context, prompt *llm.Prompt, opts ...llm.GenerateOption) (string, error) {
+	guarded := *prompt
+	var findings []Finding
+	defer func() {
+		g.mu.Lock()
+		g.findings = findings
+		g.mu.Unlock()
+	}()
+
+	// Prompts repeat the input as the first message, so identical texts are
+	// only checked once
+	checked := make(map[string]string)
+	apply := func(stage Stage, text string) (string, error) {
+		if text == "" {
+			return text, nil
+		}
+		if out, ok := checked[text]; ok && stage == StageInput {
+			return out, nil
+		}
+		out, stageFindings, err := g.pipeline.Apply(ctx, stage, text)
+		findings = append(findings, stageFindings...)
+		if err == nil && stage == StageInput {
+			checked[text] = out
+		}
+		return out, err
+	}
+
+	var err error
+	if guarded.Input, err = apply(StageInput, guarded.Input); err != nil {
+		return "", err
+	}
+	if guarded.Context, err = apply(StageInput, guarded.Context); err != nil {
+		return "", err
+	}
+	if len(prompt.Messages) > 0 {
+		guarded.Messages = make([]llm.PromptMessage, len(prompt.Messages))
+		for i, message := range prompt.Messages {
+			if message.Content, err = apply(StageInput, message.Content); err != nil {
+				return "", err
+			}
+			guarded.Messages[i] = message
+		}
+	}
+
+	response, err := g.LLM.Generate(ctx, &guarded, opts...)
+	if err != nil {
+		return "", err
+	}
+	return apply(StageOutput, response)
+}
+
+// Findings returns the findings of the most recent Generate call.
+func (g *guardedLLM) Findings() []Finding {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append([]Finding(nil), g.findings...)
+}
@@ -0,0 +1,99 @@
+package guardrails
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm"
+	"github.com/teilomillet/gollm/gollmtest"
+)
+
+func TestDetectPII(t *testing.T) {
+	text := "Mail jane.doe@example.com or call +1 (415) 555-0132. Card 4111 1111 1111 1111, not 1234 5678 9012 3456. SSN 123-45-6789 from 10.0.0.1 in 2024."
+	matches := DetectPII(text)
+	var found []string
+	for _, m := range matches {
+		found = append(found, string(m.Type)+"="+m.Value)
+	}
+	assert.Equal(t, []string{
+		"EMAIL=jane.doe@example.com",
+		"PHONE=+1 (415) 555-0132",
+		"CREDIT_CARD=4111 1111 1111 1111",
+		"SSN=123-45-6789",
+		"IP_ADDRESS=10.0.0.1",
+	}, found)
+
+	assert.Len(t, DetectPII(text, PIIEmail), 1)
+}
+
+func TestPipeline(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Actions", func(t *testing.T) {
+		var flagged []Finding
+		pipeline, err := NewPipeline(
+			WithRule(PIICheck(PIIEmail), ActionRedact, StageInput),
+			WithRule(RegexCheck("secrets", `sk-[A-Za-z0-9]{8,}`), ActionBlock),
+			WithRule(FuncCheck("shouting", func(ctx context.Context, text string) (Result, error) {
+				return Result{Triggered: text == strings.ToUpper(text)}, nil
+			}), ActionFlag),
+			WithFindingHandler(func(f Finding) { flagged = append(flagged, f) }),
+		)
+		require.NoError(t, err)
+
+		text, findings, err := pipeline.Apply(ctx, StageInput, "Contact bob@example.com")
+		require.NoError(t, err)
+		assert.Equal(t, "Contact [EMAIL]", text)
+		require.Len(t, findings, 1)
+		assert.Equal(t, ActionRedact, findings[0].Action)
+
+		_, _, err = pipeline.Apply(ctx, StageOutput, "key is sk-abcdefghij")
+		assert.True(t, IsBlocked(err))
+
+		text, findings, err = pipeline.Apply(ctx, StageOutput, "MAIL BOB@EXAMPLE.COM")
+		require.NoError(t, err)
+		assert.Equal(t, "MAIL BOB@EXAMPLE.COM", text, "redaction only applies to input")
+		require.Len(t, findings, 1)
+		assert.Equal(t, "shouting", findings[0].Check)
+		assert.Len(t, flagged, 3)
+	})
+
+	t.Run("InvalidRules", func(t *testing.T) {
+		_, err := NewPipeline(WithRule(PIICheck(), ActionRewrite))
+		assert.Error(t, err)
+		_, err = NewPipeline(WithRule(PIICheck(), Action("explode")))
+		assert.Error(t, err)
+	})
+
+	t.Run("LLMChecksAndWrap", func(t *testing.T) {
+		checker := &gollmtest.ScriptedLLM{Responses: []string{
+			`{"topics": ["Medical Advice"]}`,
+			`{"violation": true, "reason": "gives a dosage"}`,
+			"Please consult a doctor.",
+		}}
+		pipeline, err := NewPipeline(
+			WithRule(PIICheck(), ActionRedact, StageInput),
+			WithRule(TopicCheck(checker, "medical advice", "legal advice"), ActionFlag, StageOutput),
+			WithRewriteRule(LLMCheck(checker, "no-dosage", "Never recommend medication doses"), LLMRewriter(checker, "Refer to a professional instead."), StageOutput),
+		)
+		require.NoError(t, err)
+
+		model := &gollmtest.ScriptedLLM{Responses: []string{"Take 400mg of ibuprofen."}}
+		guarded := Wrap(model, pipeline)
+		prompt := gollm.NewPrompt("My email is amy@example.com, what should I take for a headache?")
+		response, err := guarded.Generate(ctx, prompt)
+		require.NoError(t, err)
+		assert.Equal(t, "Please consult a doctor.", response)
+		assert.Equal(t, "My email is [EMAIL], what should I take for a headache?", model.Prompts()[0].Input)
+		assert.Equal(t, model.Prompts()[0].Input, model.Prompts()[0].Messages[0].Content)
+		assert.Contains(t, prompt.Messages[0].Content, "amy@example.com", "the caller's prompt is not modified")
+
+		findings := guarded.Findings()
+		require.Len(t, findings, 3)
+		assert.Equal(t, []string{"medical advice"}, findings[1].Matches)
+		assert.Equal(t, "gives a dosage", findings[2].Message)
+	})
+}
@@ -0,0 +1,47 @@
+package guardrails
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm"
+	"github.com/teilomillet/gollm/gollmtest"
+)
+
+func TestRedactor(t *testing.T) {
+	ctx := context.Background()
+	vault := NewVault()
+	redactor := NewRedactor(WithNames("Jane Doe"))
+
+	redacted, err := redactor.Redact(ctx, "Jane Doe (jane@example.com, +1 415 555 0132) wrote to bob@example.com; cc jane@example.com.", vault)
+	require.NoError(t, err)
+	assert.Equal(t, "[NAME_1] ([EMAIL_1], [PHONE_1]) wrote to [EMAIL_2]; cc [EMAIL_1].", redacted)
+	assert.Equal(t, "Jane Doe", vault.Values()["[NAME_1]"])
+	assert.Equal(t, "Reply to Jane Doe at jane@example.com", vault.Restore("Reply to [NAME_1] at [EMAIL_1]"))
+
+	t.Run("NameDetector", func(t *testing.T) {
+		detector := LLMNameDetector(&gollmtest.ScriptedLLM{Responses: []string{`{"names": ["Ada Lovelace", "Nobody"]}`}})
+		redacted, err := NewRedactor(WithNameDetector(detector), WithPIITypes(PIIEmail)).Redact(ctx, "Ada Lovelace called 415 555 0132", NewVault())
+		require.NoError(t, err)
+		assert.Equal(t, "[NAME_1] called 415 555 0132", redacted)
+	})
+}
+
+func TestWrapRedactor(t *testing.T) {
+	base := &gollmtest.ScriptedLLM{Responses: []string{"Sure, I'll email [EMAIL_1] and thank [NAME_1]."}}
+	l := WrapRedactor(base, NewRedactor(WithNames("Grace")))
+
+	prompt := gollm.NewPrompt("Ask grace.h@example.com to thank Grace", gollm.WithContext("Grace works in billing"))
+	response, err := l.Generate(context.Background(), prompt)
+	require.NoError(t, err)
+	assert.Equal(t, "Sure, I'll email grace.h@example.com and thank Grace.", response)
+
+	require.Len(t, base.Prompts(), 1)
+	sent := base.Prompts()[0]
+	assert.Equal(t, "Ask [EMAIL_1] to thank [NAME_1]", sent.Input)
+	assert.Equal(t, "[NAME_1] works in billing", sent.Context)
+	assert.NotContains(t, sent.String(), "example.com")
+	assert.Equal(t, "Ask grace.h@example.com to thank Grace", prompt.Input, "the caller's prompt is unchanged")
+}
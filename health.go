@@ -0,0 +1,54 @@
+package gollm
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/teilomillet/gollm/llm"
+)
+
+// Healthy checks the LLMs concurrently and returns nil when they are all
+// healthy, or an error joining the failures.
+//
+// Example usage:
+//
+//	if err := gollm.Healthy(ctx, primary, fallback); err != nil {
+//	    log.Printf("degraded: %v", err)
+//	}
+func Healthy(ctx context.Context, llms ...LLM) error {
+	return llm.Healthy(ctx, baseLLMs(llms)...)
+}
+
+// ReadinessHandler returns an HTTP handler for readiness probes, answering
+// 200 when all LLMs are healthy and 503 with the failures otherwise.
+//
+// Example usage:
+//
+//	http.Handle("/readyz", gollm.ReadinessHandler(5*time.Second, client))
+func ReadinessHandler(timeout time.Duration, llms ...LLM) http.Handler {
+	return llm.ReadinessHandler(timeout, baseLLMs(llms)...)
+}
+
+// ConnectionStats are the counters of the connection pool of a provider.
+type ConnectionStats = llm.ConnectionStats
+
+// ConnectionPoolStats returns the counters of the connection pool of each
+// provider used so far, keyed by provider name.
+//
+// Example usage:
+//
+//	for provider, stats := range gollm.ConnectionPoolStats() {
+//	    openConnections.WithLabelValues(provider).Set(float64(stats.Open))
+//	}
+func ConnectionPoolStats() map[string]ConnectionStats {
+	return llm.ConnectionPoolStats()
+}
+
+func baseLLMs(llms []LLM) []llm.LLM {
+	base := make([]llm.LLM, len(llms))
+	for i, l := range llms {
+		base[i] = l
+	}
+	return base
+}
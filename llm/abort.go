@@ -0,0 +1,254 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+)
+
+// ErrAborted is returned by a Generation stopped with Abort.
+var ErrAborted = errors.New("generation aborted")
+
+// Generation is a Generate call running in the background, e.g. behind the
+// stop button of a chat. It is safe for concurrent use.
+type Generation struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+	input  int // Estimated prompt tokens
+
+	mu       sync.Mutex
+	aborted  bool
+	finished bool
+	response string
+	err      error
+	usage    Usage
+}
+
+// StartGeneration starts generating a response to the prompt and returns at
+// once. The response is collected with Wait, or the request canceled with
+// Abort.
+//
+// Example usage:
+//
+//	generation := llm.StartGeneration(ctx, l, prompt)
+//	go func() {
+//	    <-stopButton
+//	    generation.Abort()
+//	}()
+//	response, err := generation.Wait()
+//	if errors.Is(err, llm.ErrAborted) {
+//	    log.Println("stopped after", generation.Usage().InputTokens, "prompt tokens")
+//	}
+func StartGeneration(ctx context.Context, l LLM, prompt *Prompt, opts ...GenerateOption) *Generation {
+	ctx, cancel := context.WithCancel(ctx)
+	g := &Generation{cancel: cancel, done: make(chan struct{}), input: EstimateTokens(prompt.String())}
+
+	// The usage is recorded by the generation, then copied to the caller's
+	// WithUsage, if any
+	config := &GenerateConfig{}
+	for _, opt := range opts {
+		opt(config)
+	}
+	go func() {
+		defer close(g.done)
+		defer cancel()
+		var usage Usage
+		response, err := l.Generate(ctx, prompt, append(opts[:len(opts):len(opts)], WithUsage(&usage))...)
+
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		g.finished = true
+		if err != nil && g.aborted {
+			// The provider may bill the prompt of a canceled request
+			g.err = ErrAborted
+			g.usage = Usage{InputTokens: g.input, TotalTokens: g.input}
+		} else {
+			g.aborted = false
+			g.response, g.err, g.usage = response, err, usage
+		}
+		if config.Usage != nil {
+			*config.Usage = g.usage
+		}
+	}()
+	return g
+}
+
+// Abort cancels the request. Wait then returns ErrAborted, unless the
+// response was already complete.
+func (g *Generation) Abort() {
+	g.mu.Lock()
+	if !g.finished {
+		g.aborted = true
+	}
+	g.mu.Unlock()
+	g.cancel()
+}
+
+// Done returns a channel closed once the generation has ended.
+func (g *Generation) Done() <-chan struct{} {
+	return g.done
+}
+
+// Wait waits for the generation to end and returns its response.
+func (g *Generation) Wait() (string, error) {
+	<-g.done
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.response, g.err
+}
+
+// Aborted reports whether the generation ended because of Abort.
+func (g *Generation) Aborted() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.aborted && g.finished
+}
+
+// Usage returns the token usage of the generation once it has ended. The
+// usage of an aborted generation counts the prompt, estimated at four
+// characters per token, as providers may bill it.
+func (g *Generation) Usage() Usage {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.usage
+}
+
+// AbortableStream is a stream that can be stopped while it is read, e.g.
+// behind the stop button of a chat. Abort may be called from any goroutine.
+type AbortableStream struct {
+	stream TokenStream
+	cancel context.CancelFunc
+	input  int // Estimated prompt tokens
+
+	mu       sync.Mutex
+	aborted  bool
+	ended    bool            // Whether the terminal token or an error was returned
+	output   strings.Builder // Text received
+	reported *Usage          // Usage reported at the end of the stream, if any
+}
+
+// StartStream starts streaming a response to the prompt, returning a stream
+// that can be aborted.
+//
+// Example usage:
+//
+//	stream, err := llm.StartStream(ctx, l, prompt)
+//	if err != nil {
+//	    return err
+//	}
+//	go func() {
+//	    <-stopButton
+//	    stream.Abort()
+//	}()
+//	result, err := llm.CollectStream(ctx, stream)
+//	// result.FinishReason is llm.FinishReasonAborted if the stream was stopped
+func StartStream(ctx context.Context, l LLM, prompt *Prompt, opts ...StreamOption) (*AbortableStream, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	stream, err := l.Stream(ctx, prompt, opts...)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return &AbortableStream{stream: stream, cancel: cancel, input: EstimateTokens(prompt.String())}, nil
+}
+
+// Next returns the next token of the stream. Once the stream is aborted, it
+// returns a terminal token with FinishReasonAborted and the partial usage,
+// then io.EOF.
+func (s *AbortableStream) Next(ctx context.Context) (*StreamToken, error) {
+	s.mu.Lock()
+	aborted, ended := s.aborted, s.ended
+	s.mu.Unlock()
+	switch {
+	case ended:
+		return nil, io.EOF
+	case aborted:
+		return s.abortedToken()
+	}
+
+	token, err := s.stream.Next(ctx)
+
+	s.mu.Lock()
+	aborted = s.aborted
+	if err == nil && !aborted {
+		switch {
+		case token.Type == TokenTypeDone:
+			s.ended = true
+			if token.Done != nil {
+				s.reported = token.Done.Usage
+			}
+		case token.Type != TokenTypeToolCall:
+			s.output.WriteString(token.Text)
+		}
+	}
+	if err != nil && !aborted {
+		s.ended = true
+	}
+	s.mu.Unlock()
+
+	if aborted {
+		// The read was interrupted by Abort, or raced with it
+		return s.abortedToken()
+	}
+	return token, err
+}
+
+// abortedToken returns the terminal token of an aborted stream, or io.EOF
+// once it has been returned.
+func (s *AbortableStream) abortedToken() (*StreamToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		return nil, io.EOF
+	}
+	s.ended = true
+	usage := s.usage()
+	return &StreamToken{Type: TokenTypeDone, Done: &StreamDone{Usage: &usage, FinishReason: FinishReasonAborted}}, nil
+}
+
+// Abort cancels the request and closes the connection, so the provider
+// stops generating. A Next call blocked on the network returns at once.
+func (s *AbortableStream) Abort() {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.aborted = true
+	s.mu.Unlock()
+	s.cancel()
+	s.stream.Close()
+}
+
+// Aborted reports whether the stream was aborted before it ended.
+func (s *AbortableStream) Aborted() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.aborted
+}
+
+// Usage returns the token usage of the stream so far: the usage reported by
+// the provider at the end of the stream, or an estimate at four characters
+// per token of the prompt and of the text received.
+func (s *AbortableStream) Usage() Usage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.usage()
+}
+
+// usage returns the usage of the stream. The caller holds the lock.
+func (s *AbortableStream) usage() Usage {
+	if s.reported != nil && s.reported.TotalTokens > 0 {
+		return *s.reported
+	}
+	output := EstimateTokens(s.output.String())
+	return Usage{InputTokens: s.input, OutputTokens: output, TotalTokens: s.input + output}
+}
+
+// Close releases the stream and cancels the request if it is still running.
+func (s *AbortableStream) Close() error {
+	defer s.cancel()
+	return s.stream.Close()
+}
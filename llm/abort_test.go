@@ -0,0 +1,133 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm/providers"
+)
+
+// hangingStream is a stream whose reads block until it is closed, like a
+// connection waiting for the provider.
+type hangingStream struct {
+	closed chan struct{}
+}
+
+func (s *hangingStream) Next(ctx context.Context) (*StreamToken, error) {
+	<-s.closed
+	return nil, errors.New("read on closed body")
+}
+
+func (s *hangingStream) Close() error {
+	select {
+	case <-s.closed:
+	default:
+		close(s.closed)
+	}
+	return nil
+}
+
+func TestAbort(t *testing.T) {
+	ctx := context.Background()
+	l := newMockLLM(t)
+	mock := l.Provider.(*providers.MockProvider)
+
+	t.Run("Generation", func(t *testing.T) {
+		mock.Reset()
+		mock.SetLatency(time.Hour)
+		var usage Usage
+		prompt := NewPrompt("Write a long story")
+		generation := StartGeneration(ctx, l, prompt, WithUsage(&usage))
+		generation.Abort()
+
+		select {
+		case <-generation.Done():
+		case <-time.After(time.Second):
+			t.Fatal("the request was not canceled")
+		}
+		_, err := generation.Wait()
+		assert.ErrorIs(t, err, ErrAborted)
+		assert.True(t, generation.Aborted())
+		input := EstimateTokens(prompt.String())
+		assert.Equal(t, Usage{InputTokens: input, TotalTokens: input}, generation.Usage(), "the prompt is estimated")
+		assert.Equal(t, generation.Usage(), usage)
+	})
+
+	t.Run("GenerationCompleted", func(t *testing.T) {
+		mock.Reset()
+		mock.QueueResponse("Once upon a time")
+		generation := StartGeneration(ctx, l, NewPrompt("Write a story"))
+		_, err := generation.Wait()
+		require.NoError(t, err)
+		generation.Abort()
+
+		response, err := generation.Wait()
+		require.NoError(t, err, "aborting a finished generation has no effect")
+		assert.Equal(t, "Once upon a time", response)
+		assert.False(t, generation.Aborted())
+		assert.Equal(t, 4, generation.Usage().OutputTokens)
+	})
+
+	t.Run("Stream", func(t *testing.T) {
+		mock.Reset()
+		mock.QueueResponse("one two three four")
+		prompt := NewPrompt("Count")
+		stream, err := StartStream(ctx, l, prompt)
+		require.NoError(t, err)
+		defer stream.Close()
+		for _, want := range []string{"one ", "two "} {
+			token, err := stream.Next(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, want, token.Text)
+		}
+
+		stream.Abort()
+		result, err := CollectStream(ctx, stream)
+		require.NoError(t, err)
+		assert.Equal(t, FinishReasonAborted, result.FinishReason)
+		assert.Empty(t, result.Text, "no token is returned after the abort")
+		input := EstimateTokens(prompt.String())
+		assert.Equal(t, &Usage{InputTokens: input, OutputTokens: 2, TotalTokens: input + 2}, result.Usage, "the text received is estimated")
+		assert.True(t, stream.Aborted())
+	})
+
+	t.Run("StreamCompleted", func(t *testing.T) {
+		mock.Reset()
+		mock.QueueResponse("one two")
+		stream, err := StartStream(ctx, l, NewPrompt("Count"))
+		require.NoError(t, err)
+		result, err := CollectStream(ctx, stream)
+		require.NoError(t, err)
+		stream.Abort()
+		assert.Equal(t, FinishReasonStop, result.FinishReason)
+		assert.False(t, stream.Aborted())
+		assert.Equal(t, 2, stream.Usage().OutputTokens, "the reported usage is kept")
+	})
+
+	t.Run("BlockedRead", func(t *testing.T) {
+		stream := &AbortableStream{stream: &hangingStream{closed: make(chan struct{})}, cancel: func() {}}
+		tokens := make(chan *StreamToken)
+		go func() {
+			token, _ := stream.Next(ctx)
+			tokens <- token
+		}()
+		time.Sleep(10 * time.Millisecond)
+		stream.Abort()
+
+		select {
+		case token := <-tokens:
+			require.NotNil(t, token)
+			assert.Equal(t, TokenTypeDone, token.Type)
+			assert.Equal(t, FinishReasonAborted, token.Done.FinishReason)
+		case <-time.After(time.Second):
+			t.Fatal("the blocked read did not return")
+		}
+		_, err := stream.Next(ctx)
+		assert.Equal(t, io.EOF, err)
+	})
+}
@@ -0,0 +1,415 @@
+package llm
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/teilomillet/gollm/providers"
+	"github.com/teilomillet/gollm/types"
+	"github.com/teilomillet/gollm/utils"
+)
+
+// ToolExecutor runs the tools of an assistant. tools.Registry implements it.
+type ToolExecutor interface {
+	// Definitions returns the tool definitions sent to the model.
+	Definitions() []utils.Tool
+
+	// Call executes the named tool with the arguments of a tool call.
+	Call(ctx context.Context, name string, arguments interface{}) (string, error)
+}
+
+// AssistantConfig configures an assistant.
+type AssistantConfig struct {
+	// Name identifies the assistant
+	Name string
+
+	// Model is the model of a hosted assistant; defaults to the LLM's model
+	Model string
+
+	// Instructions are the persistent system instructions of every run
+	Instructions string
+
+	// Tools are the tools the assistant may call during runs, optional
+	Tools ToolExecutor
+
+	// MaxToolRounds limits the rounds of tool calls of a run; defaults to 10
+	MaxToolRounds int
+
+	// PollInterval is the delay between checks of a hosted run; defaults to
+	// 500ms
+	PollInterval time.Duration
+
+	// Emulate uses the emulated assistant even if the provider hosts
+	// assistants
+	Emulate bool
+}
+
+// Assistant is a model with persistent instructions and tools, holding
+// conversations in threads. With providers hosting assistants, currently
+// OpenAI, the assistant and its threads are stored by the provider. With
+// other providers they are emulated: threads are kept in process as memory
+// messages, and runs call the tools until the model answers.
+type Assistant struct {
+	// ID is the provider's ID of a hosted assistant, or a local ID
+	ID     string
+	config AssistantConfig
+	l      *LLMImpl
+	api    providers.AssistantAPI // nil when emulated
+}
+
+// Thread is a conversation with an assistant. Runs of a thread are
+// serialized; a Thread is safe for concurrent use.
+type Thread struct {
+	// ID is the provider's ID of a hosted thread, or a local ID
+	ID        string
+	assistant *Assistant
+
+	mu       sync.Mutex
+	messages []types.MemoryMessage // Conversation of an emulated thread
+}
+
+// ToolExecution records a tool call made during a run.
+type ToolExecution struct {
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments"`
+	Output    string          `json:"output"`
+}
+
+// Run is the outcome of running an assistant on a thread.
+type Run struct {
+	ID       string              `json:"id"`
+	ThreadID string              `json:"thread_id"`
+	Status   providers.RunStatus `json:"status"`
+	Answer   string              `json:"answer,omitempty"`
+	Tools    []ToolExecution     `json:"tools,omitempty"`
+}
+
+// NewAssistant creates an assistant. Hosted assistants are created with the
+// provider, and should be deleted with Delete when no longer needed.
+//
+// Example usage:
+//
+//	registry, _ := tools.NewRegistry(weatherTool)
+//	assistant, err := l.NewAssistant(ctx, llm.AssistantConfig{
+//	    Name:         "concierge",
+//	    Instructions: "You help hotel guests plan their day.",
+//	    Tools:        registry,
+//	})
+//	thread, err := assistant.NewThread(ctx)
+//	run, err := thread.Send(ctx, "What should I wear today?")
+//	fmt.Println(run.Answer)
+func (l *LLMImpl) NewAssistant(ctx context.Context, config AssistantConfig) (*Assistant, error) {
+	if config.MaxToolRounds <= 0 {
+		config.MaxToolRounds = 10
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = 500 * time.Millisecond
+	}
+	if config.Model == "" && l.config != nil {
+		config.Model = l.config.Model
+	}
+	a := &Assistant{config: config, l: l}
+
+	if host, ok := l.Provider.(providers.AssistantsHost); ok && !config.Emulate {
+		a.api = host.Assistants()
+	}
+	if a.api == nil {
+		a.ID = newAssistantID("asst_local_")
+		return a, nil
+	}
+
+	spec := providers.AssistantSpec{Name: config.Name, Model: config.Model, Instructions: config.Instructions}
+	if config.Tools != nil {
+		spec.Tools = config.Tools.Definitions()
+	}
+	id, err := a.api.CreateAssistant(ctx, l.client, spec)
+	if err != nil {
+		return nil, NewLLMError(ErrorTypeAPI, "failed to create assistant", err)
+	}
+	a.ID = id
+	l.logger.Debug("Assistant created", "provider", l.Provider.Name(), "id", id)
+	return a, nil
+}
+
+// NewAssistant creates an assistant with the underlying LLM. Threads are
+// kept apart from the LLM's memory.
+func (l *LLMWithMemory) NewAssistant(ctx context.Context, config AssistantConfig) (*Assistant, error) {
+	if a, ok := l.LLM.(interface {
+		NewAssistant(context.Context, AssistantConfig) (*Assistant, error)
+	}); ok {
+		return a.NewAssistant(ctx, config)
+	}
+	return nil, NewLLMError(ErrorTypeUnsupported, "assistants not supported by underlying LLM", nil)
+}
+
+// Hosted reports whether the assistant is stored by the provider.
+func (a *Assistant) Hosted() bool {
+	return a.api != nil
+}
+
+// Delete removes a hosted assistant from the provider. Its threads remain
+// until deleted. It does nothing for emulated assistants.
+func (a *Assistant) Delete(ctx context.Context) error {
+	if a.api == nil {
+		return nil
+	}
+	if err := a.api.DeleteAssistant(ctx, a.l.client, a.ID); err != nil {
+		return NewLLMError(ErrorTypeAPI, "failed to delete assistant", err)
+	}
+	return nil
+}
+
+// NewThread starts a conversation with the assistant.
+func (a *Assistant) NewThread(ctx context.Context) (*Thread, error) {
+	if a.api == nil {
+		return &Thread{ID: newAssistantID("thread_local_"), assistant: a}, nil
+	}
+	id, err := a.api.CreateThread(ctx, a.l.client)
+	if err != nil {
+		return nil, NewLLMError(ErrorTypeAPI, "failed to create thread", err)
+	}
+	return &Thread{ID: id, assistant: a}, nil
+}
+
+// Thread returns a previously created hosted thread by ID, e.g. to resume a
+// conversation after a restart. Emulated threads only live in process.
+func (a *Assistant) Thread(id string) (*Thread, error) {
+	if a.api == nil {
+		return nil, NewLLMError(ErrorTypeUnsupported, "emulated threads cannot be resumed by ID", nil)
+	}
+	return &Thread{ID: id, assistant: a}, nil
+}
+
+// AddMessage adds a user message to the thread without running the assistant.
+func (t *Thread) AddMessage(ctx context.Context, content string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.addMessage(ctx, content)
+}
+
+func (t *Thread) addMessage(ctx context.Context, content string) error {
+	if strings.TrimSpace(content) == "" {
+		return NewLLMError(ErrorTypeInvalidInput, "message cannot be empty", nil)
+	}
+	a := t.assistant
+	if a.api == nil {
+		t.messages = append(t.messages, a.threadMessage("user", content))
+		return nil
+	}
+	if err := a.api.AddThreadMessage(ctx, a.l.client, t.ID, providers.ThreadMessage{Role: "user", Content: content}); err != nil {
+		return NewLLMError(ErrorTypeAPI, "failed to add message", err)
+	}
+	return nil
+}
+
+// Send adds a user message to the thread and runs the assistant on it.
+func (t *Thread) Send(ctx context.Context, content string) (*Run, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := t.addMessage(ctx, content); err != nil {
+		return nil, err
+	}
+	return t.run(ctx)
+}
+
+// Run runs the assistant on the thread, calling its tools until it answers.
+// The answer is added to the thread.
+//
+// Returns:
+//   - ErrorTypeAPI if the provider fails the run
+//   - ErrorTypeResponse if the assistant does not answer within MaxToolRounds
+func (t *Thread) Run(ctx context.Context) (*Run, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.run(ctx)
+}
+
+func (t *Thread) run(ctx context.Context) (*Run, error) {
+	if t.assistant.api == nil {
+		return t.runEmulated(ctx)
+	}
+	return t.runHosted(ctx)
+}
+
+// Messages returns the messages of the thread, oldest first.
+func (t *Thread) Messages(ctx context.Context) ([]providers.ThreadMessage, error) {
+	a := t.assistant
+	if a.api != nil {
+		messages, err := a.api.ThreadMessages(ctx, a.l.client, t.ID)
+		if err != nil {
+			return nil, NewLLMError(ErrorTypeAPI, "failed to get thread messages", err)
+		}
+		return messages, nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	messages := make([]providers.ThreadMessage, len(t.messages))
+	for i, m := range t.messages {
+		messages[i] = providers.ThreadMessage{Role: m.Role, Content: m.Content}
+		if created, ok := m.Metadata["created_at"].(time.Time); ok {
+			messages[i].CreatedAt = created
+		}
+	}
+	return messages, nil
+}
+
+// Delete removes a hosted thread from the provider, or clears an emulated one.
+func (t *Thread) Delete(ctx context.Context) error {
+	a := t.assistant
+	if a.api == nil {
+		t.mu.Lock()
+		t.messages = nil
+		t.mu.Unlock()
+		return nil
+	}
+	if err := a.api.DeleteThread(ctx, a.l.client, t.ID); err != nil {
+		return NewLLMError(ErrorTypeAPI, "failed to delete thread", err)
+	}
+	return nil
+}
+
+// runHosted starts a run with the provider and answers its tool calls until
+// it stops.
+func (t *Thread) runHosted(ctx context.Context) (*Run, error) {
+	a := t.assistant
+	state, err := a.api.CreateRun(ctx, a.l.client, t.ID, a.ID)
+	if err != nil {
+		return nil, NewLLMError(ErrorTypeAPI, "failed to create run", err)
+	}
+	run := &Run{ID: state.ID, ThreadID: t.ID}
+	rounds := 0
+	for {
+		run.Status = state.Status
+		switch state.Status {
+		case providers.RunCompleted:
+			messages, err := a.api.ThreadMessages(ctx, a.l.client, t.ID)
+			if err != nil {
+				return run, NewLLMError(ErrorTypeAPI, "failed to get run answer", err)
+			}
+			if n := len(messages); n > 0 && messages[n-1].Role == "assistant" {
+				run.Answer = messages[n-1].Content
+			}
+			return run, nil
+		case providers.RunFailed, providers.RunCanceled, providers.RunExpired:
+			return run, NewLLMError(ErrorTypeAPI, fmt.Sprintf("run %s %s %s", run.ID, state.Status, state.Error), nil)
+		case providers.RunRequiresAction:
+			if rounds == a.config.MaxToolRounds {
+				if _, err := a.api.CancelRun(ctx, a.l.client, t.ID, run.ID); err != nil {
+					a.l.logger.Warn("Failed to cancel run", "id", run.ID, "error", err)
+				}
+				run.Status = providers.RunCanceled
+				return run, NewLLMError(ErrorTypeResponse, fmt.Sprintf("no answer after %d rounds of tool calls", rounds), nil)
+			}
+			rounds++
+			outputs := make([]providers.RunToolOutput, len(state.ToolCalls))
+			for i, call := range state.ToolCalls {
+				execution := a.callTool(ctx, call.Name, call.Arguments)
+				run.Tools = append(run.Tools, execution)
+				outputs[i] = providers.RunToolOutput{ToolCallID: call.ID, Output: execution.Output}
+			}
+			state, err = a.api.SubmitToolOutputs(ctx, a.l.client, t.ID, run.ID, outputs)
+			if err != nil {
+				return run, NewLLMError(ErrorTypeAPI, "failed to submit tool outputs", err)
+			}
+		default:
+			select {
+			case <-ctx.Done():
+				return run, ctx.Err()
+			case <-a.l.clock().After(a.config.PollInterval):
+			}
+			state, err = a.api.GetRun(ctx, a.l.client, t.ID, run.ID)
+			if err != nil {
+				return run, NewLLMError(ErrorTypeAPI, "failed to get run", err)
+			}
+		}
+	}
+}
+
+// runEmulated generates with the thread as context, calling the requested
+// tools and generating again with their outputs until the model answers.
+// Tool calls and outputs are only kept for the duration of the run.
+func (t *Thread) runEmulated(ctx context.Context) (*Run, error) {
+	a := t.assistant
+	run := &Run{ID: newAssistantID("run_local_"), ThreadID: t.ID, Status: providers.RunInProgress}
+	var transcript []types.MemoryMessage
+	var opts []PromptOption
+	if a.config.Instructions != "" {
+		opts = append(opts, WithSystemPrompt(a.config.Instructions, ""))
+	}
+	if a.config.Tools != nil {
+		opts = append(opts, WithTools(a.config.Tools.Definitions()))
+	}
+
+	for rounds := 0; ; rounds++ {
+		prompt := NewPrompt(renderMessages(append(t.messages[:len(t.messages):len(t.messages)], transcript...)), opts...)
+		response, err := a.l.Generate(ctx, prompt)
+		if err != nil {
+			run.Status = providers.RunFailed
+			return run, err
+		}
+		calls, _ := utils.ExtractFunctionCalls(response)
+		if len(calls) == 0 || a.config.Tools == nil {
+			run.Status, run.Answer = providers.RunCompleted, strings.TrimSpace(response)
+			t.messages = append(t.messages, a.threadMessage("assistant", run.Answer))
+			return run, nil
+		}
+		if rounds == a.config.MaxToolRounds {
+			run.Status = providers.RunCanceled
+			return run, NewLLMError(ErrorTypeResponse, fmt.Sprintf("no answer after %d rounds of tool calls", rounds), nil)
+		}
+
+		transcript = append(transcript, types.MemoryMessage{Role: "assistant", Content: response})
+		for _, call := range calls {
+			name, _ := call["name"].(string)
+			arguments, _ := json.Marshal(call["arguments"])
+			execution := a.callTool(ctx, name, arguments)
+			run.Tools = append(run.Tools, execution)
+			transcript = append(transcript, types.MemoryMessage{Role: "tool", Content: fmt.Sprintf("%s returned: %s", name, execution.Output)})
+		}
+	}
+}
+
+// callTool executes a tool call; errors are reported to the model as the
+// output.
+func (a *Assistant) callTool(ctx context.Context, name string, arguments json.RawMessage) ToolExecution {
+	execution := ToolExecution{Name: name, Arguments: arguments}
+	if a.config.Tools == nil {
+		execution.Output = fmt.Sprintf("Error: tool %q is not available", name)
+		return execution
+	}
+	output, err := a.config.Tools.Call(ctx, name, arguments)
+	if err != nil {
+		output = "Error: " + err.Error()
+	}
+	execution.Output = output
+	return execution
+}
+
+// threadMessage returns a message of an emulated thread, recording when it
+// was added.
+func (a *Assistant) threadMessage(role, content string) types.MemoryMessage {
+	return types.MemoryMessage{Role: role, Content: content, Metadata: map[string]interface{}{"created_at": a.l.clock().Now()}}
+}
+
+// renderMessages flattens a conversation as "role: content" lines, the
+// format of Memory.GetPrompt.
+func renderMessages(messages []types.MemoryMessage) string {
+	var b strings.Builder
+	for _, m := range messages {
+		fmt.Fprintf(&b, "%s: %s\n", m.Role, m.Content)
+	}
+	return b.String()
+}
+
+// newAssistantID returns a random ID for emulated assistants, threads and runs.
+func newAssistantID(prefix string) string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return prefix + hex.EncodeToString(b)
+}
@@ -0,0 +1,160 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm/providers"
+	"github.com/teilomillet/gollm/utils"
+)
+
+// weatherTools answers every call with the weather of the requested city.
+type weatherTools struct {
+	calls []string
+}
+
+func (w *weatherTools) Definitions() []utils.Tool {
+	return []utils.Tool{{Type: "function", Function: utils.Function{Name: "get_weather"}}}
+}
+
+func (w *weatherTools) Call(ctx context.Context, name string, arguments interface{}) (string, error) {
+	var args struct {
+		City string `json:"city"`
+	}
+	if err := json.Unmarshal(arguments.(json.RawMessage), &args); err != nil {
+		return "", err
+	}
+	w.calls = append(w.calls, args.City)
+	if args.City == "" {
+		return "", fmt.Errorf("missing city")
+	}
+	return "sunny in " + args.City, nil
+}
+
+func TestEmulatedAssistant(t *testing.T) {
+	ctx := context.Background()
+	l := newMockLLM(t)
+	mock := l.Provider.(*providers.MockProvider)
+	weather := &weatherTools{}
+	assistant, err := l.NewAssistant(ctx, AssistantConfig{Instructions: "You are a concierge.", Tools: weather})
+	require.NoError(t, err)
+	assert.False(t, assistant.Hosted())
+
+	thread, err := assistant.NewThread(ctx)
+	require.NoError(t, err)
+	mock.QueueToolCall("get_weather", map[string]interface{}{"city": "Paris"})
+	mock.QueueResponse("Wear sunglasses.")
+	run, err := thread.Send(ctx, "What should I wear in Paris?")
+	require.NoError(t, err)
+	assert.Equal(t, providers.RunCompleted, run.Status)
+	assert.Equal(t, "Wear sunglasses.", run.Answer)
+	require.Len(t, run.Tools, 1)
+	assert.Equal(t, "sunny in Paris", run.Tools[0].Output)
+	assert.Equal(t, []string{"Paris"}, weather.calls)
+
+	calls := mock.Calls()
+	require.Len(t, calls, 2)
+	assert.Contains(t, calls[0].Prompt, "You are a concierge.")
+	assert.Contains(t, calls[1].Prompt, "tool: get_weather returned: sunny in Paris")
+
+	// The thread keeps the conversation, without the tool calls
+	mock.QueueResponse("You're welcome.")
+	_, err = thread.Send(ctx, "Thanks!")
+	require.NoError(t, err)
+	last, _ := mock.LastCall()
+	assert.Contains(t, last.Prompt, "assistant: Wear sunglasses.")
+	assert.NotContains(t, last.Prompt, "returned")
+	messages, err := thread.Messages(ctx)
+	require.NoError(t, err)
+	require.Len(t, messages, 4)
+	assert.Equal(t, "user", messages[2].Role)
+	assert.Equal(t, "You're welcome.", messages[3].Content)
+	assert.False(t, messages[3].CreatedAt.IsZero())
+
+	t.Run("MaxToolRounds", func(t *testing.T) {
+		assistant, err := l.NewAssistant(ctx, AssistantConfig{Tools: weather, MaxToolRounds: 1})
+		require.NoError(t, err)
+		thread, _ := assistant.NewThread(ctx)
+		mock.QueueToolCall("get_weather", map[string]interface{}{})
+		mock.QueueToolCall("get_weather", map[string]interface{}{})
+		run, err := thread.Send(ctx, "Weather?")
+		var llmErr *LLMError
+		require.ErrorAs(t, err, &llmErr)
+		assert.Equal(t, ErrorTypeResponse, llmErr.Type)
+		assert.Equal(t, "Error: missing city", run.Tools[0].Output)
+	})
+}
+
+func TestHostedAssistant(t *testing.T) {
+	polls := 0
+	var outputs []interface{}
+	l := newBatchLLM(t, providers.NewOpenAIProvider("key", "gpt-4o", nil), func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "assistants=v2", r.Header.Get("OpenAI-Beta"))
+		var body map[string]interface{}
+		if r.Method == http.MethodPost {
+			json.NewDecoder(r.Body).Decode(&body)
+		}
+		switch r.Method + " " + r.URL.Path {
+		case "POST /v1/assistants":
+			assert.Equal(t, "gpt-4o-mini", body["model"])
+			assert.Equal(t, "Be brief.", body["instructions"])
+			assert.Len(t, body["tools"], 1)
+			fmt.Fprint(w, `{"id":"asst_1"}`)
+		case "POST /v1/threads":
+			fmt.Fprint(w, `{"id":"thread_1"}`)
+		case "POST /v1/threads/thread_1/messages":
+			assert.Equal(t, "Weather in Paris?", body["content"])
+			fmt.Fprint(w, `{"id":"msg_1"}`)
+		case "POST /v1/threads/thread_1/runs":
+			assert.Equal(t, "asst_1", body["assistant_id"])
+			fmt.Fprint(w, `{"id":"run_1","status":"queued"}`)
+		case "GET /v1/threads/thread_1/runs/run_1":
+			polls++
+			fmt.Fprint(w, `{"id":"run_1","status":"requires_action","required_action":{"type":"submit_tool_outputs","submit_tool_outputs":{"tool_calls":[
+				{"id":"call_1","type":"function","function":{"name":"get_weather","arguments":"{\"city\":\"Paris\"}"}}]}}}`)
+		case "POST /v1/threads/thread_1/runs/run_1/submit_tool_outputs":
+			outputs = body["tool_outputs"].([]interface{})
+			fmt.Fprint(w, `{"id":"run_1","status":"completed"}`)
+		case "GET /v1/threads/thread_1/messages":
+			fmt.Fprint(w, `{"data":[
+				{"role":"user","created_at":1700000000,"content":[{"type":"text","text":{"value":"Weather in Paris?"}}]},
+				{"role":"assistant","created_at":1700000005,"content":[{"type":"text","text":{"value":"Sunny."}}]}]}`)
+		case "DELETE /v1/assistants/asst_1":
+			fmt.Fprint(w, `{"id":"asst_1","deleted":true}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	})
+	ctx := context.Background()
+
+	assistant, err := l.NewAssistant(ctx, AssistantConfig{Model: "gpt-4o-mini", Instructions: "Be brief.", Tools: &weatherTools{}, PollInterval: time.Millisecond})
+	require.NoError(t, err)
+	assert.True(t, assistant.Hosted())
+	assert.Equal(t, "asst_1", assistant.ID)
+
+	thread, err := assistant.NewThread(ctx)
+	require.NoError(t, err)
+	run, err := thread.Send(ctx, "Weather in Paris?")
+	require.NoError(t, err)
+	assert.Equal(t, 1, polls)
+	assert.Equal(t, "Sunny.", run.Answer)
+	assert.Equal(t, []interface{}{map[string]interface{}{"tool_call_id": "call_1", "output": "sunny in Paris"}}, outputs)
+
+	messages, err := thread.Messages(ctx)
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	assert.Equal(t, time.Unix(1700000005, 0).UTC(), messages[1].CreatedAt)
+	require.NoError(t, assistant.Delete(ctx))
+
+	// DeepSeek has no hosted assistants and is emulated
+	l.Provider = providers.NewDeepSeekProvider("key", "deepseek-chat", nil)
+	emulated, err := l.NewAssistant(ctx, AssistantConfig{})
+	require.NoError(t, err)
+	assert.False(t, emulated.Hosted())
+}
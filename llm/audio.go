@@ -0,0 +1,213 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/teilomillet/gollm/providers"
+)
+
+// Transcription holds the result of a speech-to-text request.
+type Transcription = providers.Transcription
+
+// TranscriptionSegment is a timed portion of a transcription.
+type TranscriptionSegment = providers.TranscriptionSegment
+
+// TranscriptionOption is a function type for configuring transcription requests.
+type TranscriptionOption func(*providers.TranscriptionOptions)
+
+// WithTranscriptionModel overrides the provider's default transcription model.
+func WithTranscriptionModel(model string) TranscriptionOption {
+	return func(o *providers.TranscriptionOptions) {
+		o.Model = model
+	}
+}
+
+// WithTranscriptionLanguage sets the ISO-639-1 language of the audio.
+// Providing the language improves accuracy and latency.
+func WithTranscriptionLanguage(language string) TranscriptionOption {
+	return func(o *providers.TranscriptionOptions) {
+		o.Language = language
+	}
+}
+
+// WithTranscriptionPrompt provides text to guide the model's style or vocabulary.
+func WithTranscriptionPrompt(prompt string) TranscriptionOption {
+	return func(o *providers.TranscriptionOptions) {
+		o.Prompt = prompt
+	}
+}
+
+// WithTranscriptionTemperature sets the sampling temperature between 0 and 1.
+func WithTranscriptionTemperature(temperature float64) TranscriptionOption {
+	return func(o *providers.TranscriptionOptions) {
+		o.Temperature = temperature
+	}
+}
+
+// WithTranscriptionFilename sets the file name of the uploaded audio.
+// Multipart-based providers use its extension to detect the audio format.
+func WithTranscriptionFilename(filename string) TranscriptionOption {
+	return func(o *providers.TranscriptionOptions) {
+		o.Filename = filename
+	}
+}
+
+// WithTranscriptionMediaType sets the MIME type of the audio (e.g., "audio/wav").
+func WithTranscriptionMediaType(mediaType string) TranscriptionOption {
+	return func(o *providers.TranscriptionOptions) {
+		o.MediaType = mediaType
+	}
+}
+
+// Transcribe converts speech audio to text using the provider's transcription API.
+// Returns:
+//   - ErrorTypeUnsupported if the provider doesn't support transcription
+//   - ErrorTypeRequest for request preparation failures
+//   - ErrorTypeAPI for provider API errors
+//   - ErrorTypeResponse for response processing issues
+func (l *LLMImpl) Transcribe(ctx context.Context, audio io.Reader, opts ...TranscriptionOption) (*Transcription, error) {
+	transcriber, ok := l.Provider.(providers.Transcriber)
+	if !ok {
+		return nil, NewLLMError(ErrorTypeUnsupported, fmt.Sprintf("transcription not supported by provider %s", l.Provider.Name()), nil)
+	}
+
+	options := providers.TranscriptionOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	body, contentType, err := transcriber.PrepareTranscriptionRequest(audio, options)
+	if err != nil {
+		return nil, NewLLMError(ErrorTypeRequest, "failed to prepare transcription request", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", transcriber.TranscriptionEndpoint(options), bytes.NewReader(body))
+	if err != nil {
+		return nil, NewLLMError(ErrorTypeRequest, "failed to create transcription request", err)
+	}
+	for k, v := range l.Provider.Headers() {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	l.logger.Debug("Sending transcription request", "provider", l.Provider.Name(), "url", req.URL.String(), "size", len(body))
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return nil, NewLLMError(ErrorTypeRequest, "failed to send transcription request", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := providers.ReadResponseBody(resp.Body)
+	if err != nil {
+		return nil, NewLLMError(ErrorTypeResponse, "failed to read response body", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		l.logger.Error("API error", "provider", l.Provider.Name(), "status", resp.StatusCode, "body", string(respBody))
+		return nil, newAPIError(l.Provider.Name(), resp, respBody)
+	}
+
+	result, err := transcriber.ParseTranscriptionResponse(respBody)
+	if err != nil {
+		return nil, NewLLMError(ErrorTypeResponse, "failed to parse transcription response", err)
+	}
+	return result, nil
+}
+
+// Transcribe converts speech audio to text.
+// It delegates to the underlying LLM; transcriptions are not added to memory.
+func (l *LLMWithMemory) Transcribe(ctx context.Context, audio io.Reader, opts ...TranscriptionOption) (*Transcription, error) {
+	if t, ok := l.LLM.(interface {
+		Transcribe(context.Context, io.Reader, ...TranscriptionOption) (*Transcription, error)
+	}); ok {
+		return t.Transcribe(ctx, audio, opts...)
+	}
+	return nil, NewLLMError(ErrorTypeUnsupported, "transcription not supported by underlying LLM", nil)
+}
+
+// SpeechOption is a function type for configuring text-to-speech requests.
+type SpeechOption func(*providers.SpeechOptions)
+
+// WithSpeechModel overrides the provider's default speech model.
+func WithSpeechModel(model string) SpeechOption {
+	return func(o *providers.SpeechOptions) {
+		o.Model = model
+	}
+}
+
+// WithSpeechFormat sets the audio output format (e.g., "mp3", "wav", "opus").
+func WithSpeechFormat(format string) SpeechOption {
+	return func(o *providers.SpeechOptions) {
+		o.Format = format
+	}
+}
+
+// WithSpeechSpeed sets the playback speed multiplier, where supported.
+func WithSpeechSpeed(speed float64) SpeechOption {
+	return func(o *providers.SpeechOptions) {
+		o.Speed = speed
+	}
+}
+
+// Speak converts text to speech using the provider's text-to-speech API.
+// The returned stream yields the audio as it is produced; the caller must close it.
+// An empty voice selects the provider's default voice.
+// Returns:
+//   - ErrorTypeUnsupported if the provider doesn't support speech synthesis
+//   - ErrorTypeInvalidInput if text is empty
+//   - ErrorTypeRequest for request preparation failures
+//   - ErrorTypeAPI for provider API errors
+func (l *LLMImpl) Speak(ctx context.Context, text, voice string, opts ...SpeechOption) (io.ReadCloser, error) {
+	speaker, ok := l.Provider.(providers.Speaker)
+	if !ok {
+		return nil, NewLLMError(ErrorTypeUnsupported, fmt.Sprintf("speech synthesis not supported by provider %s", l.Provider.Name()), nil)
+	}
+	if text == "" {
+		return nil, NewLLMError(ErrorTypeInvalidInput, "text cannot be empty", nil)
+	}
+
+	options := providers.SpeechOptions{Voice: voice}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	body, err := speaker.PrepareSpeechRequest(text, options)
+	if err != nil {
+		return nil, NewLLMError(ErrorTypeRequest, "failed to prepare speech request", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", speaker.SpeechEndpoint(options), bytes.NewReader(body))
+	if err != nil {
+		return nil, NewLLMError(ErrorTypeRequest, "failed to create speech request", err)
+	}
+	for k, v := range l.Provider.Headers() {
+		req.Header.Set(k, v)
+	}
+
+	l.logger.Debug("Sending speech request", "provider", l.Provider.Name(), "url", req.URL.String(), "text_length", len(text))
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return nil, NewLLMError(ErrorTypeRequest, "failed to send speech request", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		respBody, _ := providers.ReadResponseBody(resp.Body)
+		l.logger.Error("API error", "provider", l.Provider.Name(), "status", resp.StatusCode, "body", string(respBody))
+		return nil, newAPIError(l.Provider.Name(), resp, respBody)
+	}
+	return resp.Body, nil
+}
+
+// Speak converts text to speech.
+// It delegates to the underlying LLM; spoken text is not added to memory.
+func (l *LLMWithMemory) Speak(ctx context.Context, text, voice string, opts ...SpeechOption) (io.ReadCloser, error) {
+	if s, ok := l.LLM.(interface {
+		Speak(context.Context, string, string, ...SpeechOption) (io.ReadCloser, error)
+	}); ok {
+		return s.Speak(ctx, text, voice, opts...)
+	}
+	return nil, NewLLMError(ErrorTypeUnsupported, "speech synthesis not supported by underlying LLM", nil)
+}
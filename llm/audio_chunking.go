@@ -0,0 +1,363 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// silenceWindow is the length of audio whose loudness is measured at once
+// when looking for silences.
+const silenceWindow = 20 * time.Millisecond
+
+// LongTranscriptionOption configures TranscribeLong.
+type LongTranscriptionOption func(*longTranscription)
+
+type longTranscription struct {
+	maxChunk    time.Duration
+	minSilence  time.Duration
+	threshold   float64
+	concurrency int
+	opts        []TranscriptionOption
+}
+
+// WithMaxChunkDuration sets the longest audio chunk sent in one request.
+// Defaults to 10 minutes, well within the 25 MB upload limit of Whisper for
+// 16 kHz mono audio.
+func WithMaxChunkDuration(d time.Duration) LongTranscriptionOption {
+	return func(c *longTranscription) {
+		c.maxChunk = d
+	}
+}
+
+// WithMinSilence sets the shortest pause the audio is split on.
+// Defaults to 300ms.
+func WithMinSilence(d time.Duration) LongTranscriptionOption {
+	return func(c *longTranscription) {
+		c.minSilence = d
+	}
+}
+
+// WithSilenceThreshold sets the loudness, as a fraction of full scale, below
+// which audio is silent. Defaults to 0.02.
+func WithSilenceThreshold(level float64) LongTranscriptionOption {
+	return func(c *longTranscription) {
+		c.threshold = level
+	}
+}
+
+// WithChunkConcurrency sets the number of chunks transcribed at once.
+// Defaults to 4.
+func WithChunkConcurrency(n int) LongTranscriptionOption {
+	return func(c *longTranscription) {
+		c.concurrency = n
+	}
+}
+
+// WithTranscriptionOptions sets the options of the transcription request of
+// every chunk.
+func WithTranscriptionOptions(opts ...TranscriptionOption) LongTranscriptionOption {
+	return func(c *longTranscription) {
+		c.opts = append(c.opts, opts...)
+	}
+}
+
+// TranscribeLong transcribes audio longer than the provider accepts in one
+// request. The audio is split into chunks at pauses, which are transcribed in
+// parallel; the transcription joins their text, and their segments with
+// timestamps relative to the start of the audio. A chunk without segments
+// becomes a single segment.
+//
+// The audio must be an uncompressed PCM WAV file. A pause falling in the
+// second half of the maximum chunk duration is preferred, the audio being
+// cut at the maximum duration when there is none.
+//
+// Returns:
+//   - ErrorTypeInvalidInput if the audio isn't a PCM WAV file
+//   - The first error of the chunk transcriptions, as returned by Transcribe
+//
+// Example usage:
+//
+//	f, _ := os.Open("meeting.wav")
+//	defer f.Close()
+//	transcription, err := l.TranscribeLong(ctx, f,
+//	    llm.WithMaxChunkDuration(5*time.Minute),
+//	    llm.WithTranscriptionOptions(llm.WithTranscriptionLanguage("en")),
+//	)
+func (l *LLMImpl) TranscribeLong(ctx context.Context, audio io.Reader, opts ...LongTranscriptionOption) (*Transcription, error) {
+	config := &longTranscription{
+		maxChunk:    10 * time.Minute,
+		minSilence:  300 * time.Millisecond,
+		threshold:   0.02,
+		concurrency: 4,
+	}
+	for _, opt := range opts {
+		opt(config)
+	}
+	if config.maxChunk < time.Second {
+		return nil, NewLLMError(ErrorTypeInvalidInput, "maximum chunk duration must be at least a second", nil)
+	}
+	if config.concurrency < 1 {
+		config.concurrency = 1
+	}
+
+	data, err := io.ReadAll(audio)
+	if err != nil {
+		return nil, NewLLMError(ErrorTypeRequest, "failed to read audio", err)
+	}
+	wav, err := parseWAV(data)
+	if err != nil {
+		return nil, NewLLMError(ErrorTypeInvalidInput, "long audio must be a PCM WAV file", err)
+	}
+	chunks := wav.split(config)
+	l.logger.Debug("Transcribing long audio", "duration", wav.duration(len(wav.data)), "chunks", len(chunks))
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	results := make([]*Transcription, len(chunks))
+	errs := make([]error, len(chunks))
+	slots := make(chan struct{}, config.concurrency)
+	var wg sync.WaitGroup
+	for i, chunk := range chunks {
+		wg.Add(1)
+		go func(i int, chunk wavChunk) {
+			defer wg.Done()
+			select {
+			case slots <- struct{}{}:
+				defer func() { <-slots }()
+			case <-ctx.Done():
+				errs[i] = ctx.Err()
+				return
+			}
+			chunkOpts := append([]TranscriptionOption{
+				WithTranscriptionFilename(fmt.Sprintf("chunk-%d.wav", i+1)),
+				WithTranscriptionMediaType("audio/wav"),
+			}, config.opts...)
+			results[i], errs[i] = l.Transcribe(ctx, bytes.NewReader(wav.encode(chunk)), chunkOpts...)
+			if errs[i] != nil {
+				cancel()
+			}
+		}(i, chunk)
+	}
+	wg.Wait()
+
+	// The chunk that failed is reported rather than the chunks it canceled
+	var canceled error
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		err = fmt.Errorf("failed to transcribe chunk %d of %d: %w", i+1, len(chunks), err)
+		if !errors.Is(err, context.Canceled) {
+			return nil, err
+		}
+		if canceled == nil {
+			canceled = err
+		}
+	}
+	if canceled != nil {
+		return nil, canceled
+	}
+	return stitchTranscriptions(wav, chunks, results), nil
+}
+
+// TranscribeLong transcribes long audio in chunks.
+// It delegates to the underlying LLM; transcriptions are not added to memory.
+func (l *LLMWithMemory) TranscribeLong(ctx context.Context, audio io.Reader, opts ...LongTranscriptionOption) (*Transcription, error) {
+	if t, ok := l.LLM.(interface {
+		TranscribeLong(context.Context, io.Reader, ...LongTranscriptionOption) (*Transcription, error)
+	}); ok {
+		return t.TranscribeLong(ctx, audio, opts...)
+	}
+	return nil, NewLLMError(ErrorTypeUnsupported, "transcription not supported by underlying LLM", nil)
+}
+
+// stitchTranscriptions joins the transcriptions of the chunks, shifting their
+// segments by the start of their chunk.
+func stitchTranscriptions(wav *wavAudio, chunks []wavChunk, results []*Transcription) *Transcription {
+	stitched := &Transcription{Duration: wav.duration(len(wav.data))}
+	texts := make([]string, 0, len(results))
+	for i, result := range results {
+		offset := wav.duration(chunks[i].start)
+		if text := strings.TrimSpace(result.Text); text != "" {
+			texts = append(texts, text)
+		}
+		if stitched.Language == "" {
+			stitched.Language = result.Language
+		}
+		if len(result.Segments) == 0 {
+			if text := strings.TrimSpace(result.Text); text != "" {
+				stitched.Segments = append(stitched.Segments, TranscriptionSegment{
+					Start: offset,
+					End:   wav.duration(chunks[i].end),
+					Text:  text,
+				})
+			}
+			continue
+		}
+		for _, segment := range result.Segments {
+			segment.Start += offset
+			segment.End += offset
+			stitched.Segments = append(stitched.Segments, segment)
+		}
+	}
+	stitched.Text = strings.Join(texts, " ")
+	return stitched
+}
+
+// wavAudio is the PCM audio of a WAV file.
+type wavAudio struct {
+	format        []byte // The fmt chunk, copied to every chunk
+	channels      int
+	sampleRate    int
+	bitsPerSample int
+	data          []byte
+}
+
+// wavChunk is a byte range of the audio data, aligned on frames.
+type wavChunk struct {
+	start, end int
+}
+
+// parseWAV reads the format and data chunks of a RIFF WAV file holding
+// integer PCM samples.
+func parseWAV(b []byte) (*wavAudio, error) {
+	if len(b) < 12 || string(b[0:4]) != "RIFF" || string(b[8:12]) != "WAVE" {
+		return nil, fmt.Errorf("not a RIFF WAVE file")
+	}
+	wav := &wavAudio{}
+	for rest := b[12:]; len(rest) >= 8; {
+		id, size := string(rest[0:4]), int(binary.LittleEndian.Uint32(rest[4:8]))
+		rest = rest[8:]
+		if size > len(rest) {
+			// Streams written without knowing their length declare more
+			size = len(rest)
+		}
+		body := rest[:size]
+		switch id {
+		case "fmt ":
+			if size < 16 {
+				return nil, fmt.Errorf("invalid fmt chunk")
+			}
+			if encoding := binary.LittleEndian.Uint16(body[0:2]); encoding != 1 && encoding != 0xFFFE {
+				return nil, fmt.Errorf("unsupported encoding %d, only PCM is supported", encoding)
+			}
+			wav.format = body
+			wav.channels = int(binary.LittleEndian.Uint16(body[2:4]))
+			wav.sampleRate = int(binary.LittleEndian.Uint32(body[4:8]))
+			wav.bitsPerSample = int(binary.LittleEndian.Uint16(body[14:16]))
+		case "data":
+			wav.data = body
+		}
+		// Chunks are padded to an even size
+		rest = rest[min(size+size%2, len(rest)):]
+	}
+	switch {
+	case wav.format == nil || wav.data == nil:
+		return nil, fmt.Errorf("missing fmt or data chunk")
+	case wav.channels < 1 || wav.sampleRate < 1:
+		return nil, fmt.Errorf("invalid format")
+	case wav.bitsPerSample != 8 && wav.bitsPerSample != 16 && wav.bitsPerSample != 24 && wav.bitsPerSample != 32:
+		return nil, fmt.Errorf("unsupported sample size of %d bits", wav.bitsPerSample)
+	}
+	wav.data = wav.data[:len(wav.data)/wav.frameSize()*wav.frameSize()]
+	return wav, nil
+}
+
+// frameSize returns the number of bytes of a sample of every channel.
+func (w *wavAudio) frameSize() int {
+	return w.channels * w.bitsPerSample / 8
+}
+
+// duration returns the time offset of a byte offset of the data, in seconds.
+func (w *wavAudio) duration(offset int) float64 {
+	return float64(offset/w.frameSize()) / float64(w.sampleRate)
+}
+
+// loudness returns the root mean square of the samples of the frames, as a
+// fraction of full scale.
+func (w *wavAudio) loudness(frames []byte) float64 {
+	size := w.bitsPerSample / 8
+	scale := math.Ldexp(1, w.bitsPerSample-1)
+	var sum float64
+	n := len(frames) / size
+	for i := 0; i < n; i++ {
+		s := frames[i*size : (i+1)*size]
+		var v float64
+		switch size {
+		case 1:
+			v = float64(int(s[0]) - 128) // 8-bit samples are unsigned
+		case 2:
+			v = float64(int16(binary.LittleEndian.Uint16(s)))
+		case 3:
+			v = float64(int32(uint32(s[0])<<8|uint32(s[1])<<16|uint32(s[2])<<24) >> 8)
+		case 4:
+			v = float64(int32(binary.LittleEndian.Uint32(s)))
+		}
+		v /= scale
+		sum += v * v
+	}
+	if n == 0 {
+		return 0
+	}
+	return math.Sqrt(sum / float64(n))
+}
+
+// split divides the audio into chunks no longer than the maximum duration,
+// cutting in the middle of the last long enough pause of the second half of
+// each chunk.
+func (w *wavAudio) split(config *longTranscription) []wavChunk {
+	window := max(int(float64(w.sampleRate)*silenceWindow.Seconds()), 1) * w.frameSize()
+	maxBytes := max(int(float64(w.sampleRate)*config.maxChunk.Seconds())/(window/w.frameSize()), 1) * window
+	minWindows := max(int(config.minSilence/silenceWindow), 1)
+
+	half := maxBytes / 2
+	half -= half % w.frameSize()
+
+	var chunks []wavChunk
+	start := 0
+	for len(w.data)-start > maxBytes {
+		end := start + maxBytes
+		cut, run, runStart := end, 0, 0
+		for offset := start + half; offset+window <= end; offset += window {
+			if w.loudness(w.data[offset:offset+window]) >= config.threshold {
+				run = 0
+				continue
+			}
+			if run == 0 {
+				runStart = offset
+			}
+			run++
+			if run >= minWindows {
+				cut = runStart + run*window/2
+			}
+		}
+		cut -= (cut - start) % w.frameSize()
+		chunks = append(chunks, wavChunk{start, cut})
+		start = cut
+	}
+	return append(chunks, wavChunk{start, len(w.data)})
+}
+
+// encode returns a WAV file of a chunk of the audio.
+func (w *wavAudio) encode(chunk wavChunk) []byte {
+	data := w.data[chunk.start:chunk.end]
+	var b bytes.Buffer
+	b.Grow(28 + len(w.format) + len(data))
+	b.WriteString("RIFF")
+	binary.Write(&b, binary.LittleEndian, uint32(4+8+len(w.format)+8+len(data)))
+	b.WriteString("WAVEfmt ")
+	binary.Write(&b, binary.LittleEndian, uint32(len(w.format)))
+	b.Write(w.format)
+	b.WriteString("data")
+	binary.Write(&b, binary.LittleEndian, uint32(len(data)))
+	b.Write(data)
+	return b.Bytes()
+}
@@ -0,0 +1,130 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm/providers"
+	"github.com/teilomillet/gollm/utils"
+)
+
+type localTranscriber struct {
+	*providers.OpenAIProvider
+	url string
+}
+
+func (p *localTranscriber) TranscriptionEndpoint(providers.TranscriptionOptions) string { return p.url }
+
+// testWAV returns 16-bit mono PCM audio at 8 kHz alternating tones and
+// silences of the given durations in seconds, starting with a tone.
+func testWAV(durations ...float64) []byte {
+	const rate = 8000
+	var samples []int16
+	for i, d := range durations {
+		for n := 0; n < int(d*rate); n++ {
+			var v int16
+			if i%2 == 0 {
+				v = int16(8000 * math.Sin(2*math.Pi*440*float64(n)/rate))
+			}
+			samples = append(samples, v)
+		}
+	}
+	format := make([]byte, 16)
+	binary.LittleEndian.PutUint16(format[0:], 1)      // PCM
+	binary.LittleEndian.PutUint16(format[2:], 1)      // Channels
+	binary.LittleEndian.PutUint32(format[4:], rate)   // Sample rate
+	binary.LittleEndian.PutUint32(format[8:], rate*2) // Byte rate
+	binary.LittleEndian.PutUint16(format[12:], 2)     // Frame size
+	binary.LittleEndian.PutUint16(format[14:], 16)    // Bits per sample
+	data := new(bytes.Buffer)
+	binary.Write(data, binary.LittleEndian, samples)
+	return (&wavAudio{format: format, channels: 1, sampleRate: rate, bitsPerSample: 16, data: data.Bytes()}).
+		encode(wavChunk{0, data.Len()})
+}
+
+func TestTranscribeLong(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		file, _, err := r.FormFile("file")
+		require.NoError(t, err)
+		body, _ := io.ReadAll(file)
+		wav, err := parseWAV(body)
+		require.NoError(t, err)
+		duration := wav.duration(len(wav.data))
+		if duration < 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":{"message":"audio too short"}}`))
+			return
+		}
+		text := fmt.Sprintf("%.1fs", duration)
+		json.NewEncoder(w).Encode(Transcription{
+			Text:     text,
+			Language: "english",
+			Segments: []TranscriptionSegment{{Start: 0.5, End: duration, Text: text}},
+		})
+	}))
+	defer server.Close()
+	l := &LLMImpl{
+		Provider: &localTranscriber{OpenAIProvider: providers.NewOpenAIProvider("fake-key", "gpt-4o", nil).(*providers.OpenAIProvider), url: server.URL},
+		Options:  make(map[string]interface{}),
+		client:   server.Client(),
+		logger:   utils.NewLogger(utils.LogLevelOff),
+	}
+	ctx := context.Background()
+
+	t.Run("SplitsAtPauses", func(t *testing.T) {
+		requests.Store(0)
+		result, err := l.TranscribeLong(ctx, bytes.NewReader(testWAV(3, 1, 3, 1, 2)), WithMaxChunkDuration(5*time.Second))
+		require.NoError(t, err)
+		assert.Equal(t, int32(3), requests.Load())
+		assert.Equal(t, "3.5s 4.0s 2.5s", result.Text)
+		assert.Equal(t, "english", result.Language)
+		assert.InDelta(t, 10, result.Duration, 0.01)
+		require.Len(t, result.Segments, 3)
+		for i, start := range []float64{0.5, 4, 8} {
+			assert.InDelta(t, start, result.Segments[i].Start, 0.01, "segment %d", i)
+		}
+		assert.InDelta(t, 10, result.Segments[2].End, 0.01)
+	})
+
+	t.Run("CutsWithoutPause", func(t *testing.T) {
+		result, err := l.TranscribeLong(ctx, bytes.NewReader(testWAV(7)), WithMaxChunkDuration(3*time.Second))
+		require.NoError(t, err)
+		assert.Equal(t, "3.0s 3.0s 1.0s", result.Text)
+	})
+
+	t.Run("ShortAudio", func(t *testing.T) {
+		requests.Store(0)
+		result, err := l.TranscribeLong(ctx, bytes.NewReader(testWAV(2)))
+		require.NoError(t, err)
+		assert.Equal(t, int32(1), requests.Load())
+		assert.Equal(t, "2.0s", result.Text)
+	})
+
+	t.Run("ChunkError", func(t *testing.T) {
+		_, err := l.TranscribeLong(ctx, bytes.NewReader(testWAV(6.5)), WithMaxChunkDuration(3*time.Second), WithChunkConcurrency(1))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "chunk 3 of 3")
+	})
+
+	t.Run("NotWAV", func(t *testing.T) {
+		_, err := l.TranscribeLong(ctx, strings.NewReader("ID3 not a wav file"))
+		var llmErr *LLMError
+		require.ErrorAs(t, err, &llmErr)
+		assert.Equal(t, ErrorTypeInvalidInput, llmErr.Type)
+	})
+}
@@ -0,0 +1,224 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/teilomillet/gollm/providers"
+)
+
+// BatchItem is a request of a batch, identified by an ID unique within the
+// batch.
+type BatchItem struct {
+	ID     string
+	Prompt *Prompt
+}
+
+// BatchResult is the outcome of a request of a batch.
+type BatchResult struct {
+	ID    string
+	Text  string
+	Usage Usage
+	Err   error // Set when the request failed
+}
+
+// BatchJob is a batch of requests run asynchronously by the provider,
+// usually at a discount and within a day. It works the same with every
+// provider supporting batches, currently OpenAI and Anthropic. A BatchJob is
+// not safe for concurrent use.
+type BatchJob struct {
+	providers.BatchInfo
+	l       *LLMImpl
+	batcher providers.Batcher
+}
+
+// SubmitBatch submits the items as a batch and returns the job tracking it.
+// The options apply to every request of the batch.
+//
+// Returns:
+//   - ErrorTypeUnsupported if the provider has no batch API
+//   - ErrorTypeInvalidInput if the items are empty or their IDs are not unique
+//   - ErrorTypeAPI if the provider rejects the batch
+//
+// Example usage:
+//
+//	job, err := l.SubmitBatch(ctx, []llm.BatchItem{
+//	    {ID: "review-1", Prompt: llm.NewPrompt("Summarize: " + review1)},
+//	    {ID: "review-2", Prompt: llm.NewPrompt("Summarize: " + review2)},
+//	})
+//	if err == nil {
+//	    err = job.Wait(ctx, time.Minute)
+//	}
+//	results, err := job.Results(ctx)
+func (l *LLMImpl) SubmitBatch(ctx context.Context, items []BatchItem, opts ...GenerateOption) (*BatchJob, error) {
+	batcher, err := l.batcher()
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, NewLLMError(ErrorTypeInvalidInput, "batch has no items", nil)
+	}
+	config := &GenerateConfig{}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	seen := make(map[string]bool, len(items))
+	requests := make([]providers.BatchRequest, len(items))
+	for i, item := range items {
+		if item.ID == "" || seen[item.ID] {
+			return nil, NewLLMError(ErrorTypeInvalidInput, fmt.Sprintf("batch item %d needs a unique ID", i), nil)
+		}
+		seen[item.ID] = true
+
+		options := l.requestOptions(config)
+		if item.Prompt.SystemPrompt != "" {
+			options["system_prompt"] = item.Prompt.SystemPrompt
+		}
+		body, err := l.Provider.PrepareRequest(item.Prompt.String(), options)
+		if err != nil {
+			return nil, NewLLMError(ErrorTypeRequest, fmt.Sprintf("failed to prepare batch item %s", item.ID), err)
+		}
+		requests[i] = providers.BatchRequest{CustomID: item.ID, Body: body}
+	}
+
+	info, err := batcher.CreateBatch(ctx, l.client, requests)
+	if err != nil {
+		return nil, NewLLMError(ErrorTypeAPI, "failed to create batch", err)
+	}
+	l.logger.Debug("Batch submitted", "provider", l.Provider.Name(), "id", info.ID, "requests", len(requests))
+	return &BatchJob{BatchInfo: *info, l: l, batcher: batcher}, nil
+}
+
+// GetBatch returns the job of a previously submitted batch, e.g. to collect
+// its results after a restart.
+func (l *LLMImpl) GetBatch(ctx context.Context, id string) (*BatchJob, error) {
+	batcher, err := l.batcher()
+	if err != nil {
+		return nil, err
+	}
+	job := &BatchJob{BatchInfo: providers.BatchInfo{ID: id}, l: l, batcher: batcher}
+	if err := job.Refresh(ctx); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+func (l *LLMImpl) batcher() (providers.Batcher, error) {
+	batcher, ok := l.Provider.(providers.Batcher)
+	if !ok {
+		return nil, NewLLMError(ErrorTypeUnsupported, fmt.Sprintf("batches not supported by provider %s", l.Provider.Name()), nil)
+	}
+	return batcher, nil
+}
+
+// requestOptions returns the LLM's options overridden by the request's.
+func (l *LLMImpl) requestOptions(config *GenerateConfig) map[string]interface{} {
+	l.optionsMutex.RLock()
+	options := make(map[string]interface{}, len(l.Options)+len(config.Options))
+	for k, v := range l.Options {
+		options[k] = v
+	}
+	l.optionsMutex.RUnlock()
+	for k, v := range config.Options {
+		options[k] = v
+	}
+	return options
+}
+
+// Done reports whether the batch has stopped running.
+func (j *BatchJob) Done() bool {
+	return j.Status != providers.BatchInProgress
+}
+
+// Refresh updates the job with the provider's current state of the batch.
+func (j *BatchJob) Refresh(ctx context.Context) error {
+	info, err := j.batcher.GetBatch(ctx, j.l.client, j.ID)
+	if err != nil {
+		return NewLLMError(ErrorTypeAPI, "failed to get batch", err)
+	}
+	j.BatchInfo = *info
+	return nil
+}
+
+// Wait polls the batch every interval until it stops running or the context
+// is cancelled.
+func (j *BatchJob) Wait(ctx context.Context, interval time.Duration) error {
+	for !j.Done() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-j.l.clock().After(interval):
+		}
+		if err := j.Refresh(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Cancel asks the provider to stop the batch. Requests already completed
+// keep their results.
+func (j *BatchJob) Cancel(ctx context.Context) error {
+	info, err := j.batcher.CancelBatch(ctx, j.l.client, j.ID)
+	if err != nil {
+		return NewLLMError(ErrorTypeAPI, "failed to cancel batch", err)
+	}
+	j.BatchInfo = *info
+	return nil
+}
+
+// Results downloads and parses the results of a batch that is done. Failed
+// requests have their Err set; the order of the results is the provider's.
+func (j *BatchJob) Results(ctx context.Context) ([]BatchResult, error) {
+	if !j.Done() {
+		return nil, NewLLMError(ErrorTypeInvalidInput, fmt.Sprintf("batch %s is still running", j.ID), nil)
+	}
+	raw, err := j.batcher.BatchResults(ctx, j.l.client, &j.BatchInfo)
+	if err != nil {
+		return nil, NewLLMError(ErrorTypeAPI, "failed to get batch results", err)
+	}
+
+	results := make([]BatchResult, len(raw))
+	for i, r := range raw {
+		results[i].ID = r.CustomID
+		if r.Error != "" {
+			results[i].Err = NewLLMError(ErrorTypeAPI, r.Error, nil)
+			continue
+		}
+		text, err := j.l.Provider.ParseResponse(r.Body)
+		if err != nil {
+			results[i].Err = NewLLMError(ErrorTypeResponse, "failed to parse response", err)
+			continue
+		}
+		results[i].Text = text
+		var response map[string]interface{}
+		if err := json.Unmarshal(r.Body, &response); err == nil {
+			results[i].Usage, _ = parseUsage(response)
+		}
+	}
+	return results, nil
+}
+
+// SubmitBatch submits a batch with the underlying LLM. Batched prompts are
+// not added to memory.
+func (l *LLMWithMemory) SubmitBatch(ctx context.Context, items []BatchItem, opts ...GenerateOption) (*BatchJob, error) {
+	if b, ok := l.LLM.(interface {
+		SubmitBatch(context.Context, []BatchItem, ...GenerateOption) (*BatchJob, error)
+	}); ok {
+		return b.SubmitBatch(ctx, items, opts...)
+	}
+	return nil, NewLLMError(ErrorTypeUnsupported, "batches not supported by underlying LLM", nil)
+}
+
+// GetBatch returns a batch job of the underlying LLM.
+func (l *LLMWithMemory) GetBatch(ctx context.Context, id string) (*BatchJob, error) {
+	if b, ok := l.LLM.(interface {
+		GetBatch(context.Context, string) (*BatchJob, error)
+	}); ok {
+		return b.GetBatch(ctx, id)
+	}
+	return nil, NewLLMError(ErrorTypeUnsupported, "batches not supported by underlying LLM", nil)
+}
@@ -0,0 +1,185 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm/config"
+	"github.com/teilomillet/gollm/providers"
+	"github.com/teilomillet/gollm/utils"
+)
+
+// rewriteTransport sends every request to a test server.
+type rewriteTransport struct {
+	target *url.URL
+}
+
+func (t rewriteTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.URL.Scheme = t.target.Scheme
+	r.URL.Host = t.target.Host
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func newBatchLLM(t *testing.T, provider providers.Provider, handler http.HandlerFunc) *LLMImpl {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	target, err := url.Parse(server.URL)
+	require.NoError(t, err)
+	return &LLMImpl{
+		Provider: provider,
+		Options:  make(map[string]interface{}),
+		client:   &http.Client{Transport: rewriteTransport{target: target}},
+		logger:   utils.NewLogger(utils.LogLevelOff),
+	}
+}
+
+var batchItems = []BatchItem{
+	{ID: "a", Prompt: NewPrompt("Summarize A")},
+	{ID: "b", Prompt: NewPrompt("Summarize B")},
+}
+
+func TestAnthropicBatch(t *testing.T) {
+	polls := 0
+	l := newBatchLLM(t, providers.NewAnthropicProvider("key", "claude-3-5-haiku-latest", nil), func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "key", r.Header.Get("x-api-key"))
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/messages/batches":
+			var body struct {
+				Requests []struct {
+					CustomID string                 `json:"custom_id"`
+					Params   map[string]interface{} `json:"params"`
+				} `json:"requests"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			require.Len(t, body.Requests, 2)
+			assert.Equal(t, "a", body.Requests[0].CustomID)
+			assert.Equal(t, "claude-3-5-haiku-latest", body.Requests[0].Params["model"])
+			fmt.Fprint(w, `{"id":"msgbatch_1","processing_status":"in_progress","request_counts":{"processing":2}}`)
+		case r.URL.Path == "/v1/messages/batches/msgbatch_1":
+			polls++
+			if polls == 1 {
+				fmt.Fprint(w, `{"id":"msgbatch_1","processing_status":"in_progress","request_counts":{"processing":1,"succeeded":1}}`)
+				return
+			}
+			fmt.Fprint(w, `{"id":"msgbatch_1","processing_status":"ended","request_counts":{"succeeded":1,"errored":1},
+				"results_url":"https://api.anthropic.com/v1/messages/batches/msgbatch_1/results"}`)
+		case r.URL.Path == "/v1/messages/batches/msgbatch_1/results":
+			fmt.Fprintln(w, `{"custom_id":"a","result":{"type":"succeeded","message":{"content":[{"type":"text","text":"Summary A"}],"usage":{"input_tokens":10,"output_tokens":2}}}}`)
+			fmt.Fprintln(w, `{"custom_id":"b","result":{"type":"errored","error":{"type":"error","error":{"type":"invalid_request_error","message":"prompt too long"}}}}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	})
+
+	ctx := context.Background()
+	job, err := l.SubmitBatch(ctx, batchItems)
+	require.NoError(t, err)
+	assert.Equal(t, "msgbatch_1", job.ID)
+	assert.False(t, job.Done())
+	_, err = job.Results(ctx)
+	assert.Error(t, err, "results are only available once the batch is done")
+
+	require.NoError(t, job.Wait(ctx, time.Millisecond))
+	assert.Equal(t, providers.BatchCompleted, job.Status)
+	assert.Equal(t, 2, job.Total)
+	assert.Equal(t, 1, job.Failed)
+
+	results, err := job.Results(ctx)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, "Summary A", results[0].Text)
+	assert.Equal(t, 12, results[0].Usage.TotalTokens)
+	assert.ErrorContains(t, results[1].Err, "prompt too long")
+}
+
+func TestOpenAIBatch(t *testing.T) {
+	l := newBatchLLM(t, providers.NewOpenAIProvider("key", "gpt-4o-mini", nil), func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer key", r.Header.Get("Authorization"))
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/files":
+			assert.Equal(t, "batch", r.FormValue("purpose"))
+			file, _, err := r.FormFile("file")
+			require.NoError(t, err)
+			input, _ := io.ReadAll(file)
+			lines := strings.Split(strings.TrimSpace(string(input)), "\n")
+			require.Len(t, lines, 2)
+			assert.Contains(t, lines[1], `"custom_id":"b"`)
+			assert.Contains(t, lines[1], `"url":"/v1/chat/completions"`)
+			fmt.Fprint(w, `{"id":"file-in"}`)
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/batches":
+			var body map[string]string
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.Equal(t, "file-in", body["input_file_id"])
+			fmt.Fprint(w, `{"id":"batch_1","status":"validating","request_counts":{"total":2}}`)
+		case r.URL.Path == "/v1/batches/batch_1":
+			fmt.Fprint(w, `{"id":"batch_1","status":"completed","request_counts":{"total":2,"completed":1,"failed":1},
+				"output_file_id":"file-out","error_file_id":"file-err"}`)
+		case r.URL.Path == "/v1/batches/batch_1/cancel":
+			fmt.Fprint(w, `{"id":"batch_1","status":"cancelling","request_counts":{"total":2}}`)
+		case r.URL.Path == "/v1/files/file-out/content":
+			fmt.Fprintln(w, `{"custom_id":"a","response":{"status_code":200,"body":{"choices":[{"message":{"content":"Summary A"}}],"usage":{"prompt_tokens":10,"completion_tokens":2}}}}`)
+		case r.URL.Path == "/v1/files/file-err/content":
+			fmt.Fprintln(w, `{"custom_id":"b","response":{"status_code":400,"body":{"error":{"message":"bad request"}}}}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	})
+
+	ctx := context.Background()
+	job, err := l.SubmitBatch(ctx, batchItems)
+	require.NoError(t, err)
+	assert.Equal(t, providers.BatchInProgress, job.Status)
+	require.NoError(t, job.Cancel(ctx))
+	assert.False(t, job.Done(), "cancelling batches keep running")
+
+	clock := utils.NewFakeClock(time.Now())
+	clock.SetAutoAdvance(true)
+	l.config = &config.Config{Clock: clock}
+	start := clock.Now()
+	require.NoError(t, job.Wait(ctx, time.Hour))
+	assert.True(t, job.Done())
+	assert.Equal(t, start.Add(time.Hour), clock.Now(), "polling waits on the clock")
+
+	resumed, err := l.GetBatch(ctx, "batch_1")
+	require.NoError(t, err)
+	assert.True(t, resumed.Done())
+	results, err := resumed.Results(ctx)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, "Summary A", results[0].Text)
+	assert.Equal(t, 10, results[0].Usage.InputTokens)
+	assert.ErrorContains(t, results[1].Err, "bad request")
+}
+
+func TestBatchUnsupported(t *testing.T) {
+	l := &LLMImpl{Provider: providers.NewMockProvider("", "mock", nil), logger: utils.NewLogger(utils.LogLevelOff)}
+	_, err := l.SubmitBatch(context.Background(), batchItems)
+	var llmErr *LLMError
+	require.True(t, errors.As(err, &llmErr))
+	assert.Equal(t, ErrorTypeUnsupported, llmErr.Type)
+
+	// DeepSeek has no batch API: the prompts must not be uploaded to OpenAI
+	// with the DeepSeek key
+	l = newBatchLLM(t, providers.NewDeepSeekProvider("key", "deepseek-chat", nil), func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+	})
+	_, err = l.SubmitBatch(context.Background(), batchItems)
+	require.True(t, errors.As(err, &llmErr))
+	assert.Equal(t, ErrorTypeUnsupported, llmErr.Type)
+	_, err = l.GetBatch(context.Background(), "batch_1")
+	require.True(t, errors.As(err, &llmErr))
+	assert.Equal(t, ErrorTypeUnsupported, llmErr.Type)
+}
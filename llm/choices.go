@@ -0,0 +1,136 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/teilomillet/gollm/providers"
+)
+
+// Selector picks the completion returned by Generate among the completions
+// requested with WithChoices, returning its index.
+type Selector func(ctx context.Context, prompt *Prompt, choices []string) (int, error)
+
+// WithChoices generates n completions of the prompt and records them into
+// out, if not nil. Generate returns the completion picked by the selector set
+// with WithSelector, the first one by default.
+//
+// Providers supporting it (OpenAI and OpenAI-compatible servers) generate the
+// completions in a single request; others are sent n requests.
+//
+// Example:
+//
+//	var drafts []string
+//	best, err := l.Generate(ctx, prompt, llm.WithChoices(4, &drafts), llm.WithSelector(llm.SelectByJudge(judge, "most concise")))
+func WithChoices(n int, out *[]string) GenerateOption {
+	return func(c *GenerateConfig) {
+		c.N = n
+		c.Choices = out
+	}
+}
+
+// WithSelector sets how the completion returned by Generate is picked among
+// those requested with WithChoices.
+func WithSelector(s Selector) GenerateOption {
+	return func(c *GenerateConfig) {
+		c.Selector = s
+	}
+}
+
+// SelectFirst picks the first completion.
+func SelectFirst(ctx context.Context, prompt *Prompt, choices []string) (int, error) {
+	return 0, nil
+}
+
+// SelectLongest picks the longest completion, the first one on ties.
+func SelectLongest(ctx context.Context, prompt *Prompt, choices []string) (int, error) {
+	best := 0
+	for i, choice := range choices {
+		if utf8.RuneCountInString(choice) > utf8.RuneCountInString(choices[best]) {
+			best = i
+		}
+	}
+	return best, nil
+}
+
+var choiceNumberPattern = regexp.MustCompile(`\d+`)
+
+// SelectByJudge asks the judge LLM which completion best answers the prompt,
+// optionally according to criteria such as "most accurate" or "most concise".
+func SelectByJudge(judge LLM, criteria string) Selector {
+	return func(ctx context.Context, prompt *Prompt, choices []string) (int, error) {
+		var b strings.Builder
+		fmt.Fprintf(&b, "Here is a prompt and %d candidate responses.\n\nPrompt:\n%s\n", len(choices), prompt.String())
+		for i, choice := range choices {
+			fmt.Fprintf(&b, "\nResponse %d:\n%s\n", i+1, choice)
+		}
+		b.WriteString("\nWhich response is the best")
+		if criteria != "" {
+			fmt.Fprintf(&b, " (%s)", criteria)
+		}
+		b.WriteString("? Answer with the number of the response only.")
+
+		verdict, err := judge.Generate(ctx, NewPrompt(b.String()))
+		if err != nil {
+			return 0, fmt.Errorf("failed to judge choices: %w", err)
+		}
+		n, err := strconv.Atoi(choiceNumberPattern.FindString(verdict))
+		if err != nil || n < 1 || n > len(choices) {
+			return 0, fmt.Errorf("judge gave no valid response number: %q", verdict)
+		}
+		return n - 1, nil
+	}
+}
+
+// generateChoices generates config.N completions and returns the selected one.
+func (l *LLMImpl) generateChoices(ctx context.Context, prompt *Prompt, config *GenerateConfig) (string, error) {
+	var choices []string
+	if parser, ok := l.Provider.(providers.ChoicesParser); ok && parser.SupportsChoices() {
+		if _, err := l.generate(ctx, prompt, config); err != nil {
+			return "", err
+		}
+		choices = config.choices
+	} else {
+		// Each request must generate a new completion, not the cached one
+		single := *config
+		single.N = 0
+		single.CacheControl = CacheBypass
+		var total Usage
+		for i := 0; i < config.N; i++ {
+			if config.Usage != nil {
+				single.Usage = &Usage{}
+			}
+			choice, err := l.generate(ctx, prompt, &single)
+			if err != nil {
+				return "", err
+			}
+			choices = append(choices, choice)
+			if single.Usage != nil {
+				total = total.add(*single.Usage)
+			}
+		}
+		if config.Usage != nil {
+			*config.Usage = total
+		}
+	}
+
+	if config.Choices != nil {
+		*config.Choices = choices
+	}
+	selector := config.Selector
+	if selector == nil {
+		selector = SelectFirst
+	}
+	best, err := selector(ctx, prompt, choices)
+	if err != nil {
+		return "", NewLLMError(ErrorTypeResponse, "failed to select choice", err)
+	}
+	if best < 0 || best >= len(choices) {
+		return "", NewLLMError(ErrorTypeResponse, fmt.Sprintf("selected choice %d out of %d", best, len(choices)), nil)
+	}
+	return choices[best], nil
+}
@@ -0,0 +1,97 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm/config"
+	"github.com/teilomillet/gollm/providers"
+	"github.com/teilomillet/gollm/utils"
+)
+
+func TestWithChoices(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("SingleRequest", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var request map[string]interface{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+			assert.Equal(t, float64(3), request["n"])
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"choices": [
+				{"index": 0, "message": {"content": "short"}},
+				{"index": 1, "message": {"content": "the longest one"}},
+				{"index": 2, "message": {"content": "medium one"}}
+			]}`))
+		}))
+		defer server.Close()
+
+		l := &LLMImpl{
+			Provider: &localOpenAIProvider{OpenAIProvider: providers.NewOpenAIProvider("fake-key", "gpt-4o", nil).(*providers.OpenAIProvider), url: server.URL},
+			Options:  make(map[string]interface{}),
+			client:   server.Client(),
+			logger:   utils.NewLogger(utils.LogLevelOff),
+		}
+		var choices []string
+		response, err := l.Generate(ctx, NewPrompt("Describe it"), WithChoices(3, &choices), WithSelector(SelectLongest))
+		require.NoError(t, err)
+		assert.Equal(t, "the longest one", response)
+		assert.Equal(t, []string{"short", "the longest one", "medium one"}, choices)
+	})
+
+	t.Run("RequestPerChoice", func(t *testing.T) {
+		l := newMockLLM(t)
+		mock := l.Provider.(*providers.MockProvider)
+		mock.QueueResponse("first", "second")
+
+		var choices []string
+		var usage Usage
+		response, err := l.Generate(ctx, NewPrompt("Hi"), WithChoices(2, &choices), WithUsage(&usage))
+		require.NoError(t, err)
+		assert.Equal(t, "first", response)
+		assert.Equal(t, []string{"first", "second"}, choices)
+		assert.Equal(t, 2, mock.CallCount())
+		_, hasN := mock.Calls()[0].Options["n"]
+		assert.False(t, hasN)
+		assert.Equal(t, 2, usage.OutputTokens)
+	})
+
+	t.Run("RequestPerChoiceCached", func(t *testing.T) {
+		l := newMockLLM(t)
+		l.UseResponseCache(NewMemoryCache(0), nil)
+		mock := l.Provider.(*providers.MockProvider)
+		mock.QueueResponse("first", "second", "third")
+
+		var choices []string
+		_, err := l.Generate(ctx, NewPrompt("Hi"), WithChoices(2, &choices))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"first", "second"}, choices, "choices are not served from the cache")
+
+		_, err = l.Generate(ctx, NewPrompt("Hi"), WithChoices(2, &choices))
+		require.NoError(t, err)
+		assert.Equal(t, 4, mock.CallCount())
+	})
+
+	t.Run("SelectByJudge", func(t *testing.T) {
+		judge := newMockLLM(t)
+		judge.Provider.(*providers.MockProvider).QueueResponse("Response 2 is the best.")
+		best, err := SelectByJudge(judge, "most accurate")(ctx, NewPrompt("2+2?"), []string{"5", "4"})
+		require.NoError(t, err)
+		assert.Equal(t, 1, best)
+		call, _ := judge.Provider.(*providers.MockProvider).LastCall()
+		assert.Contains(t, call.Prompt, "(most accurate)")
+	})
+}
+
+func newMockLLM(t *testing.T) *LLMImpl {
+	cfg := config.NewConfig()
+	config.ApplyOptions(cfg, config.SetProvider("mock"), config.SetModel("mock-model"), config.SetMaxRetries(0))
+	created, err := NewLLM(cfg, utils.NewLogger(utils.LogLevelOff), providers.NewProviderRegistry())
+	require.NoError(t, err)
+	return created.(*LLMImpl)
+}
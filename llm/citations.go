@@ -0,0 +1,36 @@
+package llm
+
+import "github.com/teilomillet/gollm/providers"
+
+// Citation is a source supporting part of a response.
+type Citation = providers.Citation
+
+// WithCitations records the citations of the response into out once the
+// request succeeds. Citations are returned by Anthropic and Cohere for the
+// documents sent with the request, and by OpenAI, OpenRouter and
+// Perplexity-compatible servers for web search results; with other
+// providers, or responses without sources, out is left empty.
+//
+// Example:
+//
+//	var citations []llm.Citation
+//	response, err := l.Generate(ctx, prompt, llm.WithCitations(&citations))
+//	for _, c := range citations {
+//	    fmt.Printf("%q is supported by %s\n", c.Claim, c.URL)
+//	}
+func WithCitations(out *[]Citation) GenerateOption {
+	return func(c *GenerateConfig) {
+		c.Citations = out
+	}
+}
+
+// recordCitations stores the citations of a response when requested.
+func (l *LLMImpl) recordCitations(body []byte, config *GenerateConfig) {
+	if config.Citations == nil {
+		return
+	}
+	*config.Citations = nil
+	if parser, ok := l.Provider.(providers.CitationParser); ok {
+		*config.Citations = parser.ParseCitations(body)
+	}
+}
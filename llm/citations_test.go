@@ -0,0 +1,57 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm/providers"
+)
+
+func TestCitations(t *testing.T) {
+	t.Run("Perplexity", func(t *testing.T) {
+		l := newBatchLLM(t, providers.NewOpenRouterProvider("key", "perplexity/sonar", nil), func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{
+				"choices": [{"message": {"role": "assistant", "content": "Paris is the capital of France [1]."}}],
+				"search_results": [{"title": "France", "url": "https://example.com/france", "snippet": "Its capital is Paris."}],
+				"citations": ["https://example.com/france", "https://example.com/paris"]
+			}`)
+		})
+		var citations []Citation
+		response, err := l.Generate(context.Background(), NewPrompt("Capital of France?"), WithCitations(&citations))
+		require.NoError(t, err)
+		assert.Equal(t, "Paris is the capital of France [1].", response)
+		assert.Equal(t, []Citation{
+			{Title: "France", URL: "https://example.com/france", Quote: "Its capital is Paris."},
+			{URL: "https://example.com/paris"},
+		}, citations)
+	})
+
+	t.Run("Cohere", func(t *testing.T) {
+		l := newBatchLLM(t, providers.NewCohereProvider("key", "command-r-plus", nil), func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"message": {
+				"role": "assistant",
+				"content": [{"type": "text", "text": "The tower was completed in 1889."}],
+				"citations": [{"start": 4, "end": 9, "text": "tower", "sources": [
+					{"type": "document", "id": "doc:0", "document": {"id": "history.txt", "snippet": "completed in 1889"}}]}]
+			}}`)
+		})
+		var citations []Citation
+		_, err := l.Generate(context.Background(), NewPrompt("When?"), WithCitations(&citations))
+		require.NoError(t, err)
+		assert.Equal(t, []Citation{{Claim: "tower", Quote: "completed in 1889", SourceID: "history.txt"}}, citations)
+	})
+
+	t.Run("None", func(t *testing.T) {
+		l := newBatchLLM(t, providers.NewOpenAIProvider("key", "gpt-4o", nil), func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"choices": [{"message": {"role": "assistant", "content": "Hi"}}]}`)
+		})
+		citations := []Citation{{URL: "stale"}}
+		_, err := l.Generate(context.Background(), NewPrompt("Hello"), WithCitations(&citations))
+		require.NoError(t, err)
+		assert.Empty(t, citations, "citations of a previous call are cleared")
+	})
+}
@@ -0,0 +1,99 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm/providers"
+	"github.com/teilomillet/gollm/utils"
+)
+
+func TestClock(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	l := newMockLLM(t)
+	l.MaxRetries = 3
+	l.RetryDelay = time.Hour
+	mock := l.Provider.(*providers.MockProvider)
+
+	t.Run("AutoAdvance", func(t *testing.T) {
+		mock.Reset()
+		mock.QueueError(http.StatusInternalServerError, "down")
+		mock.QueueError(http.StatusInternalServerError, "down")
+		mock.QueueResponse("Hello")
+		clock := utils.NewFakeClock(start)
+		clock.SetAutoAdvance(true)
+		l.config.Clock = clock
+
+		response, err := l.Generate(ctx, NewPrompt("Hi"))
+		require.NoError(t, err)
+		assert.Equal(t, "Hello", response)
+		assert.Equal(t, start.Add(2*time.Hour), clock.Now(), "each retry waits an hour of the fake clock")
+	})
+
+	t.Run("Advance", func(t *testing.T) {
+		mock.Reset()
+		mock.QueueError(http.StatusInternalServerError, "down")
+		mock.QueueResponse("Hello")
+		clock := utils.NewFakeClock(start)
+		l.config.Clock = clock
+
+		done := make(chan error, 1)
+		go func() {
+			_, err := l.Generate(ctx, NewPrompt("Hi"))
+			done <- err
+		}()
+		require.Eventually(t, func() bool { return clock.Waiters() == 1 }, time.Second, time.Millisecond)
+		clock.Advance(time.Minute)
+		select {
+		case <-done:
+			t.Fatal("retried before the delay")
+		case <-time.After(10 * time.Millisecond):
+		}
+		clock.Advance(time.Hour)
+		require.NoError(t, <-done)
+		assert.Equal(t, 2, mock.CallCount())
+	})
+
+	t.Run("RetryBudget", func(t *testing.T) {
+		mock.Reset()
+		for i := 0; i < 4; i++ {
+			mock.QueueError(http.StatusInternalServerError, "down")
+		}
+		clock := utils.NewFakeClock(start)
+		clock.SetAutoAdvance(true)
+		l.config.Clock = clock
+
+		// The second retry would start two hours in, past the budget
+		budget := &RetryBudget{MaxElapsed: 90 * time.Minute, Clock: clock}
+		_, err := l.Generate(ctx, NewPrompt("Hi"), WithRetryBudget(budget))
+		var llmErr *LLMError
+		require.ErrorAs(t, err, &llmErr)
+		assert.Equal(t, ErrorTypeRetryBudgetExhausted, llmErr.Type)
+		assert.Equal(t, 2, mock.CallCount())
+		assert.Equal(t, time.Hour, budget.Elapsed())
+	})
+
+	t.Run("CacheExpiry", func(t *testing.T) {
+		clock := utils.NewFakeClock(start)
+		cache := NewMemoryCache(time.Minute)
+		cache.Clock = clock
+		cache.Set(ctx, "k", "v")
+		clock.Advance(59 * time.Second)
+		_, ok := cache.Get(ctx, "k")
+		assert.True(t, ok)
+		clock.Advance(2 * time.Second)
+		_, ok = cache.Get(ctx, "k")
+		assert.False(t, ok)
+	})
+
+	t.Run("CurrentDate", func(t *testing.T) {
+		prompt := NewPrompt("Plan my day")
+		require.NoError(t, AddCurrentDateFrom(utils.NewFakeClock(start), time.DateOnly)(ctx, prompt))
+		assert.Equal(t, "Current date: 2025-01-01", prompt.Context)
+	})
+}
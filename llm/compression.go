@@ -0,0 +1,84 @@
+package llm
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// gzipWriters holds the writers of compressed request bodies for reuse.
+var gzipWriters = sync.Pool{
+	New: func() interface{} { return gzip.NewWriter(nil) },
+}
+
+// compressionTransport compresses request bodies of at least minSize bytes
+// with gzip, if minSize is positive, and decompresses gzip responses that the
+// base transport left compressed, e.g. because the request asked for them.
+type compressionTransport struct {
+	base    http.RoundTripper
+	minSize int
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *compressionTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.minSize > 0 && req.Body != nil && req.ContentLength >= int64(t.minSize) && req.Header.Get("Content-Encoding") == "" {
+		compressed, err := gzipBody(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(compressed))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(compressed)), nil
+		}
+		req.ContentLength = int64(len(compressed))
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || !strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		return resp, err
+	}
+	reader, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to decompress response: %w", err)
+	}
+	resp.Body = &gzipBodyReader{Reader: reader, body: resp.Body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return resp, nil
+}
+
+// gzipBody reads and compresses a request body.
+func gzipBody(body io.ReadCloser) ([]byte, error) {
+	defer body.Close()
+	var buf bytes.Buffer
+	w := gzipWriters.Get().(*gzip.Writer)
+	defer gzipWriters.Put(w)
+	w.Reset(&buf)
+	if _, err := io.Copy(w, body); err != nil {
+		return nil, fmt.Errorf("failed to compress request body: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress request body: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// gzipBodyReader decompresses a response body, closing it when closed.
+type gzipBodyReader struct {
+	*gzip.Reader
+	body io.ReadCloser
+}
+
+func (r *gzipBodyReader) Close() error {
+	r.Reader.Close()
+	return r.body.Close()
+}
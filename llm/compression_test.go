@@ -0,0 +1,57 @@
+package llm
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompressionTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := r.Body
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			reader, err := gzip.NewReader(r.Body)
+			require.NoError(t, err)
+			body = reader
+		}
+		data, _ := io.ReadAll(body)
+		w.Header().Set("X-Request-Encoding", r.Header.Get("Content-Encoding"))
+
+		// Answer compressed, as servers do when asked explicitly
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		gz.Write(data)
+		gz.Close()
+	}))
+	defer server.Close()
+
+	transport := &compressionTransport{base: http.DefaultTransport, minSize: 100}
+	for _, tc := range []struct {
+		name, body, encoding string
+	}{
+		{"Small", "short prompt", ""},
+		{"Large", strings.Repeat("a long context ", 100), "gzip"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, server.URL, bytes.NewBufferString(tc.body))
+			require.NoError(t, err)
+			req.Header.Set("Accept-Encoding", "gzip")
+			resp, err := transport.RoundTrip(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tc.encoding, resp.Header.Get("X-Request-Encoding"))
+			assert.Empty(t, resp.Header.Get("Content-Encoding"))
+			data, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			assert.Equal(t, tc.body, string(data), "the echoed body is decompressed")
+		})
+	}
+}
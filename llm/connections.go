@@ -0,0 +1,175 @@
+package llm
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/http2"
+)
+
+// ConnectionStats are the counters of the connection pool of a provider,
+// shared by the LLMs using the provider.
+type ConnectionStats struct {
+	Requests int64 // Requests sent, including warm-ups
+	Reused   int64 // Requests sent over a pooled connection
+	Dialed   int64 // Connections opened
+	Open     int64 // Connections currently open
+	WarmUps  int64 // Warm-up requests sent
+}
+
+// connectionPool is the HTTP transport of a provider, counting the use of
+// its connections.
+type connectionPool struct {
+	transport *http.Transport
+	requests  atomic.Int64
+	reused    atomic.Int64
+	dialed    atomic.Int64
+	open      atomic.Int64
+	warmUps   atomic.Int64
+}
+
+var (
+	connectionPools   = make(map[string]*connectionPool)
+	connectionPoolsMu sync.Mutex
+)
+
+// providerConnections returns the connection pool shared by the LLMs of a
+// provider, created with the keep-alive period of the first one.
+func providerConnections(provider string, keepAlive time.Duration) *connectionPool {
+	connectionPoolsMu.Lock()
+	defer connectionPoolsMu.Unlock()
+	pool, ok := connectionPools[provider]
+	if !ok {
+		pool = newConnectionPool(keepAlive)
+		connectionPools[provider] = pool
+	}
+	return pool
+}
+
+// newConnectionPool returns a pool with the settings of
+// http.DefaultTransport. With a keep-alive period, idle HTTP/2 connections
+// are pinged, so that broken ones are replaced before a request needs them.
+func newConnectionPool(keepAlive time.Duration) *connectionPool {
+	pool := &connectionPool{}
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	pool.transport = http.DefaultTransport.(*http.Transport).Clone()
+	pool.transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dialer.DialContext(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		pool.dialed.Add(1)
+		pool.open.Add(1)
+		return &countedConn{Conn: conn, open: &pool.open}, nil
+	}
+	if keepAlive > 0 {
+		if h2, err := http2.ConfigureTransports(pool.transport); err == nil {
+			h2.ReadIdleTimeout = keepAlive
+			h2.PingTimeout = min(keepAlive, 15*time.Second)
+		}
+	}
+	return pool
+}
+
+// RoundTrip implements http.RoundTripper, counting the request and whether
+// it reused a pooled connection.
+func (p *connectionPool) RoundTrip(req *http.Request) (*http.Response, error) {
+	p.requests.Add(1)
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if info.Reused {
+				p.reused.Add(1)
+			}
+		},
+	}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+	return p.transport.RoundTrip(req)
+}
+
+// stats returns a snapshot of the counters.
+func (p *connectionPool) stats() ConnectionStats {
+	return ConnectionStats{
+		Requests: p.requests.Load(),
+		Reused:   p.reused.Load(),
+		Dialed:   p.dialed.Load(),
+		Open:     p.open.Load(),
+		WarmUps:  p.warmUps.Load(),
+	}
+}
+
+// countedConn decrements the count of open connections once closed.
+type countedConn struct {
+	net.Conn
+	open   *atomic.Int64
+	closed sync.Once
+}
+
+func (c *countedConn) Close() error {
+	c.closed.Do(func() { c.open.Add(-1) })
+	return c.Conn.Close()
+}
+
+// ConnectionPoolStats returns the counters of the connection pool of each
+// provider used so far, keyed by provider name, e.g. to export them as
+// metrics.
+func ConnectionPoolStats() map[string]ConnectionStats {
+	connectionPoolsMu.Lock()
+	defer connectionPoolsMu.Unlock()
+	stats := make(map[string]ConnectionStats, len(connectionPools))
+	for provider, pool := range connectionPools {
+		stats[provider] = pool.stats()
+	}
+	return stats
+}
+
+// WarmUpper is implemented by LLMs that can open a connection to their
+// provider ahead of the first request.
+type WarmUpper interface {
+	// WarmUp opens a connection to the provider, completing the TCP and TLS
+	// handshakes, and leaves it in the pool for the next request.
+	WarmUp(ctx context.Context) error
+}
+
+// WarmUp opens a connection to the provider's endpoint with a HEAD request,
+// so that the first request doesn't pay for the handshakes. The response
+// status is ignored: any answer means the connection is established. It
+// does nothing for LLMs replaying fixtures or using in-process providers.
+//
+// Returns:
+//   - ErrorTypeRequest if the provider cannot be reached
+func (l *LLMImpl) WarmUp(ctx context.Context) error {
+	if l.connections == nil {
+		return nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, l.Provider.Endpoint(), nil)
+	if err != nil {
+		return NewLLMError(ErrorTypeRequest, "failed to create warm-up request", err)
+	}
+	l.connections.warmUps.Add(1)
+	resp, err := l.connections.RoundTrip(req)
+	if err != nil {
+		return NewLLMError(ErrorTypeRequest, "failed to warm up connection", err)
+	}
+	return resp.Body.Close()
+}
+
+// ConnectionStats returns the counters of the provider's connection pool.
+func (l *LLMImpl) ConnectionStats() ConnectionStats {
+	if l.connections == nil {
+		return ConnectionStats{}
+	}
+	return l.connections.stats()
+}
+
+// WarmUp warms up the connection of the underlying LLM.
+func (l *LLMWithMemory) WarmUp(ctx context.Context) error {
+	if w, ok := l.LLM.(WarmUpper); ok {
+		return w.WarmUp(ctx)
+	}
+	return nil
+}
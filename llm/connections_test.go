@@ -0,0 +1,47 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm/providers"
+	"github.com/teilomillet/gollm/utils"
+)
+
+func TestWarmUp(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.Write([]byte(`{"choices":[{"message":{"content":"warm"}}]}`))
+	}))
+	defer server.Close()
+
+	pool := newConnectionPool(0)
+	l := &LLMImpl{
+		Provider:    &localOpenAIProvider{OpenAIProvider: providers.NewOpenAIProvider("fake-key", "gpt-4o", nil).(*providers.OpenAIProvider), url: server.URL},
+		Options:     make(map[string]interface{}),
+		client:      &http.Client{Transport: pool},
+		connections: pool,
+		logger:      utils.NewLogger(utils.LogLevelOff),
+	}
+
+	require.NoError(t, l.WarmUp(context.Background()), "any answer warms up the connection")
+	assert.Equal(t, ConnectionStats{Requests: 1, Dialed: 1, Open: 1, WarmUps: 1}, l.ConnectionStats())
+
+	response, err := l.Generate(context.Background(), NewPrompt("Hello"))
+	require.NoError(t, err)
+	assert.Equal(t, "warm", response)
+	assert.Equal(t, ConnectionStats{Requests: 2, Reused: 1, Dialed: 1, Open: 1, WarmUps: 1}, l.ConnectionStats(),
+		"the request reuses the warm connection")
+
+	pool.transport.CloseIdleConnections()
+	assert.Zero(t, l.ConnectionStats().Open)
+
+	assert.NoError(t, (&LLMImpl{}).WarmUp(context.Background()), "LLMs without a pool don't warm up")
+}
@@ -0,0 +1,64 @@
+package llm
+
+import (
+	"errors"
+
+	"github.com/teilomillet/gollm/providers"
+)
+
+// ContentFilters is the content filter information of a response.
+type ContentFilters = providers.ContentFilters
+
+// ContentFilterResult is the verdict of a content filter on one category.
+type ContentFilterResult = providers.ContentFilterResult
+
+// WithContentFilters records the content filter annotations of the response
+// into out once the request succeeds. They are returned by Azure OpenAI, for
+// both the prompt and the response; with other providers out is left empty.
+//
+// Content that the filter blocks fails the request with an
+// ErrorTypeContentFiltered error instead. For a blocked response, its
+// underlying *providers.ContentFilterError lists the filtered categories.
+//
+// Example:
+//
+//	var filters llm.ContentFilters
+//	response, err := l.Generate(ctx, prompt, llm.WithContentFilters(&filters))
+//	for _, r := range filters.Response {
+//	    fmt.Printf("%s: %s\n", r.Category, r.Severity)
+//	}
+func WithContentFilters(out *ContentFilters) GenerateOption {
+	return func(c *GenerateConfig) {
+		c.ContentFilters = out
+	}
+}
+
+// recordContentFilters stores the content filter annotations of a response
+// when requested.
+func (l *LLMImpl) recordContentFilters(body []byte, config *GenerateConfig) {
+	if config.ContentFilters == nil {
+		return
+	}
+	*config.ContentFilters = ContentFilters{}
+	if parser, ok := l.Provider.(providers.ContentFilterParser); ok {
+		if filters := parser.ParseContentFilters(body); filters != nil {
+			*config.ContentFilters = *filters
+		}
+	}
+}
+
+// parseResponseError classifies an error parsing a response, which is a
+// content filter error when the provider blocked it.
+func parseResponseError(err error) *LLMError {
+	var filtered *providers.ContentFilterError
+	if errors.As(err, &filtered) {
+		return NewLLMError(ErrorTypeContentFiltered, "response blocked by content filter", err)
+	}
+	return NewLLMError(ErrorTypeResponse, "failed to parse response", err)
+}
+
+// isContentFiltered reports whether err is an ErrorTypeContentFiltered error.
+func isContentFiltered(err error) bool {
+	var llmErr *LLMError
+	return errors.As(err, &llmErr) && llmErr.Type == ErrorTypeContentFiltered
+}
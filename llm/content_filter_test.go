@@ -0,0 +1,82 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm/providers"
+)
+
+// newAzureLLM returns an LLM for the Azure OpenAI provider answering with
+// the given handler.
+func newAzureLLM(t *testing.T, handler http.HandlerFunc) *LLMImpl {
+	provider := providers.NewGenericProvider("key", "gpt-4o", "azure-openai", nil).(*providers.GenericProvider)
+	provider.SetEndpoint("https://example.openai.azure.com/openai/deployments/gpt-4o/chat/completions?api-version=2024-10-21")
+	l := newBatchLLM(t, provider, handler)
+	l.MaxRetries = 2
+	return l
+}
+
+func TestContentFilters(t *testing.T) {
+	t.Run("Annotations", func(t *testing.T) {
+		l := newAzureLLM(t, func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{
+				"prompt_filter_results": [{"prompt_index": 0, "content_filter_results": {
+					"hate": {"filtered": false, "severity": "safe"},
+					"jailbreak": {"filtered": false, "detected": false}}}],
+				"choices": [{"message": {"role": "assistant", "content": "Hello"}, "finish_reason": "stop",
+					"content_filter_results": {"violence": {"filtered": false, "severity": "low"}}}]
+			}`)
+		})
+		var filters ContentFilters
+		response, err := l.Generate(context.Background(), NewPrompt("Hi"), WithContentFilters(&filters))
+		require.NoError(t, err)
+		assert.Equal(t, "Hello", response)
+		assert.Equal(t, []ContentFilterResult{{Category: "hate", Severity: "safe"}, {Category: "jailbreak"}}, filters.Prompt)
+		assert.Equal(t, []ContentFilterResult{{Category: "violence", Severity: "low"}}, filters.Response)
+		assert.Empty(t, filters.Filtered())
+	})
+
+	t.Run("ResponseBlocked", func(t *testing.T) {
+		calls := 0
+		l := newAzureLLM(t, func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			fmt.Fprint(w, `{"choices": [{"message": {"role": "assistant", "content": ""}, "finish_reason": "content_filter",
+				"content_filter_results": {"hate": {"filtered": false, "severity": "safe"}, "violence": {"filtered": true, "severity": "high"}}}]}`)
+		})
+		_, err := l.Generate(context.Background(), NewPrompt("Hi"))
+		var llmErr *LLMError
+		require.ErrorAs(t, err, &llmErr)
+		assert.Equal(t, ErrorTypeContentFiltered, llmErr.Type)
+		var filtered *providers.ContentFilterError
+		require.ErrorAs(t, err, &filtered)
+		assert.Equal(t, []ContentFilterResult{{Category: "violence", Severity: "high", Filtered: true}}, filtered.Results)
+		assert.Equal(t, 1, calls, "blocked responses are not retried")
+	})
+
+	t.Run("PromptBlocked", func(t *testing.T) {
+		l := newAzureLLM(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error": {"message": "The response was filtered", "type": null, "param": "prompt", "code": "content_filter", "status": 400}}`)
+		})
+		_, err := l.Generate(context.Background(), NewPrompt("Hi"))
+		var llmErr *LLMError
+		require.ErrorAs(t, err, &llmErr)
+		assert.Equal(t, ErrorTypeContentFiltered, llmErr.Type)
+	})
+
+	t.Run("OpenAI", func(t *testing.T) {
+		l := newBatchLLM(t, providers.NewOpenAIProvider("key", "gpt-4o", nil), func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"choices": [{"message": {"role": "assistant", "content": null}, "finish_reason": "content_filter"}]}`)
+		})
+		_, err := l.GenerateWithSchema(context.Background(), NewPrompt("Hi"), map[string]interface{}{"type": "object"})
+		var llmErr *LLMError
+		require.ErrorAs(t, err, &llmErr)
+		assert.Equal(t, ErrorTypeContentFiltered, llmErr.Type)
+		assert.ErrorContains(t, err, "openai content filter blocked the response")
+	})
+}
@@ -0,0 +1,71 @@
+package llm
+
+import (
+	"reflect"
+
+	"github.com/teilomillet/gollm/config"
+	"github.com/teilomillet/gollm/providers"
+)
+
+// WithSystemFingerprint records the system fingerprint of a Generate call:
+// the backend configuration that served it, as reported by OpenAI and
+// compatible APIs, or an empty string. Responses generated with the same
+// seed are only reproducible while the fingerprint stays the same, so
+// storing it allows reproducibility audits.
+//
+// Example usage:
+//
+//	var fingerprint string
+//	response, err := l.Generate(ctx, prompt, llm.WithSystemFingerprint(&fingerprint))
+func WithSystemFingerprint(fingerprint *string) GenerateOption {
+	return func(c *GenerateConfig) {
+		c.SystemFingerprint = fingerprint
+	}
+}
+
+// applyDeterminism sets the provider's reproducible sampling options in
+// deterministic mode.
+func (l *LLMImpl) applyDeterminism() {
+	if l.config == nil || !l.config.Deterministic {
+		return
+	}
+	seed := config.DeterministicSeed
+	if l.config.Seed != nil {
+		seed = *l.config.Seed
+	}
+	options := map[string]interface{}{"temperature": 0.0}
+	seeded := false
+	if sampler, ok := l.Provider.(providers.DeterministicSampler); ok {
+		options, seeded = sampler.DeterministicOptions(seed)
+	}
+	if !seeded {
+		l.logger.Warn("Provider does not support seeded sampling; deterministic mode cannot guarantee reproducible responses", "provider", l.Provider.Name())
+	}
+	for k, v := range options {
+		l.Provider.SetOption(k, v)
+	}
+	l.deterministic = options
+}
+
+// enforceDeterminism restores the deterministic sampling options overridden
+// by per-request options.
+func (l *LLMImpl) enforceDeterminism(options map[string]interface{}) {
+	for k, v := range l.deterministic {
+		if requested, ok := options[k]; ok && !reflect.DeepEqual(requested, v) {
+			l.logger.Warn("Ignoring option overriding deterministic mode", "option", k, "value", requested)
+		}
+		options[k] = v
+	}
+}
+
+// recordFingerprint reports the system fingerprint of a response to the
+// caller and, in deterministic mode, logs it for audits.
+func (l *LLMImpl) recordFingerprint(response map[string]interface{}, config *GenerateConfig) {
+	fingerprint, _ := response["system_fingerprint"].(string)
+	if config.SystemFingerprint != nil {
+		*config.SystemFingerprint = fingerprint
+	}
+	if l.deterministic != nil {
+		l.logger.Info("Deterministic response", "provider", l.Provider.Name(), "model", response["model"], "seed", l.deterministic["seed"], "system_fingerprint", fingerprint)
+	}
+}
@@ -0,0 +1,63 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/teilomillet/gollm/config"
+	"github.com/teilomillet/gollm/providers"
+	"github.com/teilomillet/gollm/utils"
+)
+
+func TestDeterministicMode(t *testing.T) {
+	t.Run("EnforcesSampling", func(t *testing.T) {
+		var request map[string]interface{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+			w.Write([]byte(`{"system_fingerprint":"fp_44709d6fcb","choices":[{"message":{"content":"Hi"}}]}`))
+		}))
+		defer server.Close()
+
+		l := &LLMImpl{
+			Provider: &localOpenAIProvider{OpenAIProvider: providers.NewOpenAIProvider("fake-key", "gpt-4o", nil).(*providers.OpenAIProvider), url: server.URL},
+			Options:  make(map[string]interface{}),
+			client:   server.Client(),
+			logger:   utils.NewLogger(utils.LogLevelOff),
+			config:   &config.Config{Deterministic: true, Temperature: 0.7},
+		}
+		l.Provider.SetOption("temperature", 0.7)
+		l.applyDeterminism()
+
+		var fingerprint string
+		_, err := l.Generate(context.Background(), NewPrompt("Hello"),
+			WithRequestOption("temperature", 0.9),
+			WithSystemFingerprint(&fingerprint),
+		)
+		require.NoError(t, err)
+		assert.Equal(t, 0.0, request["temperature"], "per-request options cannot override deterministic mode")
+		assert.Equal(t, 1.0, request["top_p"])
+		assert.Equal(t, float64(config.DeterministicSeed), request["seed"])
+		assert.Equal(t, "fp_44709d6fcb", fingerprint)
+	})
+
+	t.Run("WarnsWithoutSeed", func(t *testing.T) {
+		logger := &utils.MockLogger{}
+		logger.On("Warn", mock.Anything, mock.Anything).Return()
+		seed := 7
+		l := &LLMImpl{
+			Provider: providers.NewAnthropicProvider("fake-key", "claude-3-5-sonnet-latest", nil),
+			logger:   logger,
+			config:   &config.Config{Deterministic: true, Seed: &seed},
+		}
+		l.applyDeterminism()
+		logger.AssertNumberOfCalls(t, "Warn", 1)
+		assert.Equal(t, map[string]interface{}{"temperature": 0.0}, l.deterministic)
+	})
+}
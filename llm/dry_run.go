@@ -0,0 +1,120 @@
+package llm
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/teilomillet/gollm/providers"
+)
+
+// DryRun is the request a Generate call would have sent, recorded by
+// WithDryRun, with estimates of its size and cost.
+type DryRun struct {
+	Provider string
+	Model    string      // Model named in the request body, if any
+	URL      string      // Endpoint, with credentials in the query redacted
+	Header   http.Header // Headers, with credentials redacted
+	Body     []byte      // Request body, after memory, templates, tools and schema
+
+	// InputTokens estimates the prompt tokens at four characters per token
+	// of the text of the body: messages, system prompt, tools and schema
+	InputTokens int
+
+	// MaxOutputTokens is the token limit of the response, or 0 when the
+	// request sets none
+	MaxOutputTokens int
+
+	// Cost is the estimated price in USD of the prompt and of a response
+	// reaching MaxOutputTokens, from the default model catalog
+	Cost float64
+
+	// Priced reports whether the catalog has the model's pricing
+	Priced bool
+}
+
+// WithDryRun builds the request of a Generate or GenerateWithSchema call and
+// records it into out instead of sending it, for debugging and pre-flight
+// budget checks. The call returns an empty response and no error. Nothing
+// reaches the network: moderation is skipped and documents are not uploaded.
+//
+// Example:
+//
+//	var dry llm.DryRun
+//	_, err := l.Generate(ctx, prompt, llm.WithDryRun(&dry))
+//	fmt.Printf("~%d tokens, up to $%.4f\n", dry.InputTokens, dry.Cost)
+func WithDryRun(out *DryRun) GenerateOption {
+	return func(c *GenerateConfig) {
+		c.DryRun = out
+	}
+}
+
+// isDryRun reports whether the options request a dry run.
+func isDryRun(opts []GenerateOption) bool {
+	config := &GenerateConfig{}
+	for _, opt := range opts {
+		opt(config)
+	}
+	return config.DryRun != nil
+}
+
+// outputLimitKeys are the request fields limiting the response tokens, by
+// API.
+var outputLimitKeys = []string{"max_tokens", "max_completion_tokens", "max_output_tokens", "num_predict"}
+
+// recordDryRun stores a request in place of sending it.
+func (l *LLMImpl) recordDryRun(req *http.Request, body []byte, config *GenerateConfig) {
+	dry := DryRun{
+		Provider: l.Provider.Name(),
+		URL:      redactQuery(req.URL),
+		Header:   redactHeader(req.Header),
+		Body:     body,
+	}
+	var request map[string]interface{}
+	if json.Unmarshal(body, &request) == nil {
+		dry.Model, _ = request["model"].(string)
+		delete(request, "model")
+		dry.InputTokens = EstimateTokens(bodyText(request))
+		dry.MaxOutputTokens = outputLimit(request)
+	}
+	if info, ok := providers.DefaultModelCatalog().Lookup(dry.Provider, dry.Model); ok {
+		dry.Cost = info.Cost(dry.InputTokens, dry.MaxOutputTokens)
+		dry.Priced = true
+	}
+	*config.DryRun = dry
+}
+
+// bodyText returns the string values of a decoded request body, each
+// followed by a space.
+func bodyText(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v + " "
+	case map[string]interface{}:
+		var text string
+		for _, value := range v {
+			text += bodyText(value)
+		}
+		return text
+	case []interface{}:
+		var text string
+		for _, value := range v {
+			text += bodyText(value)
+		}
+		return text
+	}
+	return ""
+}
+
+// outputLimit returns the response token limit of a decoded request body,
+// which Ollama and Cohere nest in an options object.
+func outputLimit(request map[string]interface{}) int {
+	for _, key := range outputLimitKeys {
+		if n, ok := request[key].(float64); ok {
+			return int(n)
+		}
+	}
+	if options, ok := request["options"].(map[string]interface{}); ok {
+		return outputLimit(options)
+	}
+	return 0
+}
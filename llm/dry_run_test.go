@@ -0,0 +1,65 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm/config"
+	"github.com/teilomillet/gollm/providers"
+	"github.com/teilomillet/gollm/utils"
+)
+
+func TestDryRun(t *testing.T) {
+	l := newBatchLLM(t, providers.NewOpenAIProvider("secret", "gpt-4o", nil), func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("dry runs must not reach the API")
+	})
+	l.SetOption("max_tokens", 1000)
+	prompt := NewPrompt("Summarize the report", WithSystemPrompt("Be brief.", CacheTypeEphemeral), WithTools([]utils.Tool{{Type: "function", Function: utils.Function{Name: "search", Description: "Searches the web"}}}))
+
+	var dry DryRun
+	response, err := l.Generate(context.Background(), prompt, WithDryRun(&dry))
+	require.NoError(t, err)
+	assert.Empty(t, response)
+	assert.Equal(t, "openai", dry.Provider)
+	assert.Equal(t, "gpt-4o", dry.Model)
+	assert.Equal(t, "https://api.openai.com/v1/chat/completions", dry.URL)
+	assert.Equal(t, redacted, dry.Header.Get("Authorization"))
+	assert.Contains(t, string(dry.Body), "Searches the web")
+	assert.Contains(t, string(dry.Body), "Be brief.")
+	assert.Greater(t, dry.InputTokens, 5)
+	assert.Equal(t, 1000, dry.MaxOutputTokens)
+	require.True(t, dry.Priced)
+	assert.InDelta(t, float64(dry.InputTokens)*2.5/1e6+0.01, dry.Cost, 1e-9)
+
+	t.Run("Schema", func(t *testing.T) {
+		var dry DryRun
+		_, err := l.GenerateWithSchema(context.Background(), NewPrompt("List colors"), map[string]interface{}{"type": "object"}, WithDryRun(&dry))
+		require.NoError(t, err)
+		assert.Contains(t, string(dry.Body), "json_schema")
+	})
+
+	t.Run("Thinking", func(t *testing.T) {
+		provider := providers.NewAnthropicProvider("secret", "claude-sonnet-4-0", nil)
+		budget := 2000
+		provider.SetDefaultOptions(&config.Config{ReasoningBudget: &budget})
+		l := newBatchLLM(t, provider, nil)
+		l.SetOption("temperature", 0.7)
+		var dry DryRun
+		_, err := l.Generate(context.Background(), NewPrompt("Hi"), WithDryRun(&dry))
+		require.NoError(t, err)
+		assert.Contains(t, string(dry.Body), `"budget_tokens":2000`)
+		assert.NotContains(t, string(dry.Body), "temperature", "the LLM's temperature is dropped when thinking")
+	})
+
+	t.Run("Unpriced", func(t *testing.T) {
+		l := newBatchLLM(t, providers.NewOllamaProvider("", "llama3", nil), nil)
+		var dry DryRun
+		_, err := l.Generate(context.Background(), NewPrompt("Hi"), WithDryRun(&dry))
+		require.NoError(t, err)
+		assert.False(t, dry.Priced)
+		assert.Equal(t, "llama3", dry.Model)
+	})
+}
@@ -1,8 +1,11 @@
 package llm
 
 import (
+	"errors"
 	"fmt"
+	"net/http"
 
+	"github.com/teilomillet/gollm/providers"
 	"github.com/teilomillet/gollm/utils"
 )
 
@@ -37,6 +40,20 @@ const (
 
 	// ErrorTypeUnsupported indicates a requested feature is not supported
 	ErrorTypeUnsupported
+
+	// ErrorTypeModeration indicates a prompt or response was flagged by moderation
+	ErrorTypeModeration
+
+	// ErrorTypeBudgetExceeded indicates a spend budget has been exhausted
+	ErrorTypeBudgetExceeded
+
+	// ErrorTypeContentFiltered indicates the provider's content filter blocked
+	// the prompt or the response
+	ErrorTypeContentFiltered
+
+	// ErrorTypeRetryBudgetExhausted indicates a request used up its retry
+	// budget before succeeding
+	ErrorTypeRetryBudgetExhausted
 )
 
 // LLMError represents a structured error in the LLM package.
@@ -94,6 +111,14 @@ func (e *LLMError) TypeString() string {
 		return "InvalidInputError"
 	case ErrorTypeUnsupported:
 		return "UnsupportedError"
+	case ErrorTypeModeration:
+		return "ModerationError"
+	case ErrorTypeBudgetExceeded:
+		return "BudgetExceededError"
+	case ErrorTypeContentFiltered:
+		return "ContentFilteredError"
+	case ErrorTypeRetryBudgetExhausted:
+		return "RetryBudgetExhaustedError"
 	default:
 		return "UnknownError"
 	}
@@ -117,6 +142,55 @@ func NewLLMError(errType ErrorType, message string, err error) *LLMError {
 	}
 }
 
+// newPrepareError wraps an error preparing a provider request. Attachments
+// the provider cannot send are reported as unsupported.
+func newPrepareError(message string, err error) *LLMError {
+	if errors.Is(err, providers.ErrUnsupportedAttachment) {
+		return NewLLMError(ErrorTypeUnsupported, message, err)
+	}
+	return NewLLMError(ErrorTypeRequest, message, err)
+}
+
+// newAPIError classifies an error response of the provider API. The parsed
+// *providers.APIError, with the provider's message and request ID, is the
+// underlying error.
+func newAPIError(provider string, resp *http.Response, body []byte) *LLMError {
+	apiErr := providers.ParseAPIError(provider, resp.StatusCode, resp.Header, body)
+	return NewLLMError(apiErrorType(apiErr), fmt.Sprintf("API error: status code %d", resp.StatusCode), apiErr)
+}
+
+// apiErrorType maps API errors to error types, from the error type or code
+// of the provider and then the HTTP status.
+func apiErrorType(err *providers.APIError) ErrorType {
+	if err.Code == "content_filter" {
+		// Azure OpenAI rejects filtered prompts as bad requests
+		return ErrorTypeContentFiltered
+	}
+	switch err.Type {
+	case "rate_limit_error", "RESOURCE_EXHAUSTED":
+		return ErrorTypeRateLimit
+	case "authentication_error", "permission_error", "UNAUTHENTICATED", "PERMISSION_DENIED":
+		return ErrorTypeAuthentication
+	case "invalid_request_error", "not_found_error", "request_too_large", "INVALID_ARGUMENT", "NOT_FOUND":
+		return ErrorTypeInvalidInput
+	}
+	switch err.Code {
+	case "rate_limit_exceeded", "insufficient_quota":
+		return ErrorTypeRateLimit
+	case "invalid_api_key":
+		return ErrorTypeAuthentication
+	}
+	switch err.StatusCode {
+	case http.StatusTooManyRequests:
+		return ErrorTypeRateLimit
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrorTypeAuthentication
+	case http.StatusBadRequest, http.StatusNotFound, http.StatusRequestEntityTooLarge, http.StatusUnprocessableEntity:
+		return ErrorTypeInvalidInput
+	}
+	return ErrorTypeAPI
+}
+
 // HandleError processes an error based on its severity.
 // It logs the error appropriately and can optionally terminate the program
 // if the error is considered fatal.
@@ -2,11 +2,14 @@ package llm
 
 import (
 	"errors"
+	"fmt"
+	"net/http"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm/providers"
 	"github.com/teilomillet/gollm/utils"
 )
 
@@ -89,3 +92,37 @@ func TestHandleError(t *testing.T) {
 		HandleError(fatalErr, true, mockLogger)
 	})
 }
+
+func TestNewAPIError(t *testing.T) {
+	tests := []struct {
+		status int
+		body   string
+		want   ErrorType
+	}{
+		{http.StatusTooManyRequests, `{"error": {"message": "Slow down", "type": "requests"}}`, ErrorTypeRateLimit},
+		{http.StatusForbidden, `{"error": {"message": "Quota", "code": "insufficient_quota"}}`, ErrorTypeRateLimit},
+		{http.StatusUnauthorized, `{"type": "error", "error": {"type": "authentication_error", "message": "invalid x-api-key"}}`, ErrorTypeAuthentication},
+		{http.StatusBadRequest, `{"error": {"message": "Unknown parameter", "type": "invalid_request_error"}}`, ErrorTypeInvalidInput},
+		{529, `{"type": "error", "error": {"type": "overloaded_error", "message": "Overloaded"}}`, ErrorTypeAPI},
+		{http.StatusBadGateway, `<html>Bad Gateway</html>`, ErrorTypeAPI},
+	}
+	for _, tt := range tests {
+		resp := &http.Response{StatusCode: tt.status, Header: http.Header{"Request-Id": []string{"req_1"}}}
+		err := newAPIError("test", resp, []byte(tt.body))
+		assert.Equal(t, tt.want, err.Type, tt.body)
+
+		var apiErr *providers.APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, "req_1", apiErr.RequestID)
+		assert.NotContains(t, err.Error(), "<html>", "raw bodies are not echoed")
+	}
+}
+
+func TestNewPrepareError(t *testing.T) {
+	err := newPrepareError("failed to prepare request", fmt.Errorf("%w: no images", providers.ErrUnsupportedAttachment))
+	assert.Equal(t, ErrorTypeUnsupported, err.Type)
+	assert.ErrorIs(t, err, providers.ErrUnsupportedAttachment)
+
+	err = newPrepareError("failed to prepare request", errors.New("bad schema"))
+	assert.Equal(t, ErrorTypeRequest, err.Type)
+}
@@ -0,0 +1,104 @@
+package llm
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/teilomillet/gollm/providers"
+	"github.com/teilomillet/gollm/types"
+)
+
+// Example is an input and the output expected for it, shown to the LLM as a
+// few-shot example.
+type Example struct {
+	Input  string `json:"input" jsonschema:"description=Example input"`
+	Output string `json:"output" jsonschema:"description=Output expected for the input"`
+}
+
+// WithExamplePairs adds few-shot examples pairing each input with the output
+// of the same index. Providers taking messages (see
+// providers.Conversational) receive them as user and assistant turns ahead of
+// the prompt; others receive them inline, as do schema and stream requests.
+//
+// Example usage:
+//
+//	prompt := llm.NewPrompt("I loved the ending",
+//	    llm.WithExamplePairs(
+//	        []string{"The plot made no sense", "Best film of the year"},
+//	        []string{"negative", "positive"},
+//	    ),
+//	    llm.WithExampleTokenBudget(200),
+//	)
+//
+// It panics if the slices have different lengths.
+func WithExamplePairs(inputs, outputs []string) PromptOption {
+	if len(inputs) != len(outputs) {
+		panic(fmt.Sprintf("WithExamplePairs: %d inputs but %d outputs", len(inputs), len(outputs)))
+	}
+	return func(p *Prompt) {
+		for i := range inputs {
+			p.FewShot = append(p.FewShot, Example{Input: inputs[i], Output: outputs[i]})
+		}
+	}
+}
+
+// WithExampleTokenBudget limits the estimated size of the few-shot examples.
+// Examples are kept in order until the next one would exceed the budget.
+func WithExampleTokenBudget(tokens int) PromptOption {
+	return func(p *Prompt) {
+		p.ExampleTokenBudget = tokens
+	}
+}
+
+// fewShotExamples returns the few-shot examples within the token budget.
+func (p *Prompt) fewShotExamples() []Example {
+	if p.ExampleTokenBudget <= 0 {
+		return p.FewShot
+	}
+	tokens := 0
+	for i, example := range p.FewShot {
+		tokens += EstimateTokens(example.Input) + EstimateTokens(example.Output)
+		if tokens > p.ExampleTokenBudget {
+			return p.FewShot[:i]
+		}
+	}
+	return p.FewShot
+}
+
+// writeFewShot writes the few-shot examples inline.
+func writeFewShot(builder *strings.Builder, examples []Example) {
+	for _, example := range examples {
+		builder.WriteString("Input: ")
+		builder.WriteString(example.Input)
+		builder.WriteString("\nOutput: ")
+		builder.WriteString(example.Output)
+		builder.WriteString("\n")
+	}
+}
+
+// fewShotMessages returns the few-shot examples of a prompt as alternating
+// user and assistant turns followed by the prompt itself, or false if the
+// prompt has no examples.
+func fewShotMessages(prompt *Prompt) ([]types.MemoryMessage, bool) {
+	examples := prompt.fewShotExamples()
+	if len(examples) == 0 {
+		return nil, false
+	}
+	messages := make([]types.MemoryMessage, 0, 2*len(examples)+1)
+	for _, example := range examples {
+		messages = append(messages,
+			types.MemoryMessage{Role: "user", Content: example.Input},
+			types.MemoryMessage{Role: "assistant", Content: example.Output},
+		)
+	}
+	final := *prompt
+	final.FewShot = nil
+	return append(messages, types.MemoryMessage{Role: "user", Content: final.String()}), true
+}
+
+// supportsMessages reports whether the provider sends messages as separate
+// turns.
+func supportsMessages(provider providers.Provider) bool {
+	c, ok := provider.(providers.Conversational)
+	return ok && c.SupportsMessages()
+}
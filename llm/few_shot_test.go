@@ -0,0 +1,80 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm/providers"
+	"github.com/teilomillet/gollm/utils"
+)
+
+func TestFewShotExamples(t *testing.T) {
+	prompt := NewPrompt("I loved the ending",
+		WithExamplePairs([]string{"The plot made no sense", "Best film of the year"}, []string{"negative", "positive"}))
+
+	t.Run("Inline", func(t *testing.T) {
+		assert.Contains(t, prompt.String(), "Examples:\nInput: The plot made no sense\nOutput: negative\nInput: Best film of the year\nOutput: positive\n")
+
+		budgeted := *prompt
+		budgeted.ExampleTokenBudget = 10
+		assert.Equal(t, []Example{{Input: "The plot made no sense", Output: "negative"}}, budgeted.fewShotExamples())
+		assert.NotContains(t, budgeted.String(), "Best film")
+	})
+
+	t.Run("Messages", func(t *testing.T) {
+		var body struct {
+			Messages []struct {
+				Role    string `json:"role"`
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			json.NewDecoder(r.Body).Decode(&body)
+			w.Write([]byte(`{"choices":[{"message":{"content":"positive"}}]}`))
+		}))
+		defer server.Close()
+
+		l := &LLMImpl{
+			Provider: &localOpenAIProvider{OpenAIProvider: providers.NewOpenAIProvider("fake-key", "gpt-4o", nil).(*providers.OpenAIProvider), url: server.URL},
+			Options:  make(map[string]interface{}),
+			client:   server.Client(),
+			logger:   utils.NewLogger(utils.LogLevelOff),
+		}
+		_, err := l.Generate(context.Background(), prompt)
+		require.NoError(t, err)
+		require.Len(t, body.Messages, 5)
+		for i, role := range []string{"user", "assistant", "user", "assistant", "user"} {
+			assert.Equal(t, role, body.Messages[i].Role)
+		}
+		assert.Equal(t, "negative", body.Messages[1].Content)
+		assert.Contains(t, body.Messages[4].Content, "I loved the ending")
+		assert.NotContains(t, body.Messages[4].Content, "negative", "examples aren't repeated inline")
+	})
+
+	t.Run("Memory", func(t *testing.T) {
+		l, mock := newOfflineMemoryLLM(t)
+		mock.QueueResponse("positive")
+		_, err := l.Generate(context.Background(), prompt)
+		require.NoError(t, err)
+
+		messages := mock.Calls()[0].Messages
+		require.Len(t, messages, 5)
+		assert.Equal(t, "negative", messages[1].Content)
+		assert.Contains(t, messages[4].Content, "I loved the ending")
+		assert.NotContains(t, messages[4].Content, "negative", "examples aren't repeated inline")
+		assert.Len(t, l.GetMemory(), 2, "examples aren't stored in memory")
+
+		l.SetUseStructuredMessages(false)
+		mock.QueueResponse("positive")
+		_, err = l.Generate(context.Background(), prompt)
+		require.NoError(t, err)
+		assert.Contains(t, mock.Calls()[1].Prompt, "Input: The plot made no sense\nOutput: negative\n")
+	})
+
+	assert.Panics(t, func() { WithExamplePairs([]string{"a"}, nil) })
+}
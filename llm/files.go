@@ -0,0 +1,365 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/teilomillet/gollm/providers"
+	"github.com/teilomillet/gollm/types"
+)
+
+// fileCache maps document content hashes to the provider file IDs they were
+// uploaded as, so each document is uploaded at most once per LLM instance.
+type fileCache struct {
+	mu  sync.Mutex
+	ids map[string]string
+}
+
+func (c *fileCache) get(hash string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	id, ok := c.ids[hash]
+	return id, ok
+}
+
+func (c *fileCache) put(hash, id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.ids == nil {
+		c.ids = make(map[string]string)
+	}
+	c.ids[hash] = id
+}
+
+// drain removes and returns all cached file IDs.
+func (c *fileCache) drain() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ids := make([]string, 0, len(c.ids))
+	for _, id := range c.ids {
+		ids = append(ids, id)
+	}
+	c.ids = nil
+	return ids
+}
+
+// resolveDocuments uploads inline documents when the provider references
+// documents by file ID and replaces them with file ID references. Uploads are
+// cached by content hash. The returned flag reports whether any document
+// references a file ID.
+func (l *LLMImpl) resolveDocuments(ctx context.Context, documents []types.Document) ([]types.Document, bool, error) {
+	uploader, ok := l.Provider.(providers.FileManager)
+	if !ok || !uploader.UploadsDocuments() {
+		return documents, false, nil
+	}
+
+	resolved := make([]types.Document, len(documents))
+	usesFiles := false
+	for i, doc := range documents {
+		if doc.IsInline() {
+			hash, err := doc.ContentHash()
+			if err != nil {
+				return nil, false, err
+			}
+			id, cached := l.files.get(hash)
+			if !cached {
+				id, err = l.uploadDocument(ctx, uploader, doc)
+				if err != nil {
+					return nil, false, err
+				}
+				l.files.put(hash, id)
+			}
+			l.logger.Debug("Using uploaded document", "file_id", id, "cached", cached)
+			doc = types.Document{FileID: id, Name: doc.Name}
+		}
+		usesFiles = usesFiles || doc.FileID != ""
+		resolved[i] = doc
+	}
+	return resolved, usesFiles, nil
+}
+
+// uploadDocument sends a single document to the provider's Files API.
+func (l *LLMImpl) uploadDocument(ctx context.Context, uploader providers.FileManager, doc types.Document) (string, error) {
+	data, mediaType, err := doc.Base64()
+	if err != nil {
+		return "", err
+	}
+	raw, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return "", fmt.Errorf("invalid base64 document data: %w", err)
+	}
+
+	body, contentType, err := uploader.PrepareFileUpload(bytes.NewReader(raw), providers.FileUploadOptions{Filename: doc.Filename(), MediaType: mediaType})
+	if err != nil {
+		return "", err
+	}
+	l.logger.Debug("Uploading document", "provider", l.Provider.Name(), "filename", doc.Filename(), "size", len(raw))
+	respBody, err := l.fileRequest(ctx, uploader, "POST", uploader.FilesEndpoint(), bytes.NewReader(body), contentType)
+	if err != nil {
+		return "", err
+	}
+	info, err := uploader.ParseFileInfo(respBody)
+	if err != nil {
+		return "", err
+	}
+	return info.ID, nil
+}
+
+// attachDocuments sets the documents of a request in its options, uploading
+// inline documents first when the provider references documents by file ID.
+// A dry run uploads nothing. It reports whether the request references
+// uploaded files.
+func (l *LLMImpl) attachDocuments(ctx context.Context, options map[string]interface{}, documents []types.Document, dryRun bool) (bool, error) {
+	if len(documents) == 0 {
+		return false, nil
+	}
+	if dryRun {
+		options["documents"] = documents
+		return false, nil
+	}
+	resolved, usesFiles, err := l.resolveDocuments(ctx, documents)
+	if err != nil {
+		return false, NewLLMError(ErrorTypeRequest, "failed to upload documents", err)
+	}
+	options["documents"] = resolved
+	return usesFiles, nil
+}
+
+// setFileHeaders adds the provider's file headers to a request referencing
+// uploaded files, which may require them (e.g., a beta flag).
+func (l *LLMImpl) setFileHeaders(req *http.Request, usesFiles bool) {
+	if uploader, ok := l.Provider.(providers.FileManager); ok && usesFiles {
+		for k, v := range uploader.FileHeaders() {
+			req.Header.Set(k, v)
+		}
+	}
+}
+
+// DeleteUploadedFiles removes all documents this instance uploaded to the
+// provider's Files API and clears the upload cache. It is a no-op for
+// providers without a Files API.
+func (l *LLMImpl) DeleteUploadedFiles(ctx context.Context) error {
+	uploader, ok := l.Provider.(providers.FileManager)
+	if !ok {
+		return nil
+	}
+
+	var errs []error
+	for _, id := range l.files.drain() {
+		if _, err := l.fileRequest(ctx, uploader, "DELETE", uploader.FilesEndpoint()+"/"+url.PathEscape(id), nil, ""); err != nil {
+			errs = append(errs, fmt.Errorf("failed to delete file %s: %w", id, err))
+		}
+	}
+	if len(errs) > 0 {
+		return NewLLMError(ErrorTypeAPI, "failed to delete uploaded files", errors.Join(errs...))
+	}
+	return nil
+}
+
+// DeleteUploadedFiles removes documents uploaded by the underlying LLM.
+func (l *LLMWithMemory) DeleteUploadedFiles(ctx context.Context) error {
+	if d, ok := l.LLM.(interface{ DeleteUploadedFiles(context.Context) error }); ok {
+		return d.DeleteUploadedFiles(ctx)
+	}
+	return nil
+}
+
+// FileInfo describes a file stored with a provider.
+type FileInfo = providers.FileInfo
+
+// FileOption is a function type for configuring file uploads.
+type FileOption func(*providers.FileUploadOptions)
+
+// WithFilePurpose sets the use of an uploaded file, e.g. "fine-tune" or
+// "batch". Defaults to the provider's general-purpose value.
+func WithFilePurpose(purpose string) FileOption {
+	return func(o *providers.FileUploadOptions) {
+		o.Purpose = purpose
+	}
+}
+
+// WithFileMediaType sets the MIME type of an uploaded file instead of
+// detecting it.
+func WithFileMediaType(mediaType string) FileOption {
+	return func(o *providers.FileUploadOptions) {
+		o.MediaType = mediaType
+	}
+}
+
+// UploadFile stores a file with the provider's Files API, e.g. the training
+// data of a fine-tuning job. The MIME type is detected from the file name
+// extension, or else from the content.
+//
+// Returns:
+//   - ErrorTypeUnsupported if the provider has no Files API
+//   - ErrorTypeInvalidInput if the file is empty or larger than the provider accepts
+//   - ErrorTypeAPI for provider API errors
+//
+// Example usage:
+//
+//	f, _ := os.Open("train.jsonl")
+//	defer f.Close()
+//	file, err := l.UploadFile(ctx, "train.jsonl", f, llm.WithFilePurpose("fine-tune"))
+func (l *LLMImpl) UploadFile(ctx context.Context, filename string, content io.Reader, opts ...FileOption) (*FileInfo, error) {
+	manager, err := l.fileManager()
+	if err != nil {
+		return nil, err
+	}
+	options := providers.FileUploadOptions{Filename: filepath.Base(filename)}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	// One byte more than the limit is enough to tell the file is too large
+	data, err := io.ReadAll(io.LimitReader(content, manager.MaxFileSize()+1))
+	if err != nil {
+		return nil, NewLLMError(ErrorTypeRequest, "failed to read file", err)
+	}
+	switch {
+	case len(data) == 0:
+		return nil, NewLLMError(ErrorTypeInvalidInput, "file is empty", nil)
+	case int64(len(data)) > manager.MaxFileSize():
+		return nil, NewLLMError(ErrorTypeInvalidInput, fmt.Sprintf("file is larger than the %d MB accepted by %s", manager.MaxFileSize()>>20, l.Provider.Name()), nil)
+	}
+	if options.MediaType == "" {
+		options.MediaType = detectMediaType(options.Filename, data)
+	}
+
+	body, contentType, err := manager.PrepareFileUpload(bytes.NewReader(data), options)
+	if err != nil {
+		return nil, NewLLMError(ErrorTypeRequest, "failed to prepare file upload", err)
+	}
+	l.logger.Debug("Uploading file", "provider", l.Provider.Name(), "filename", options.Filename, "media_type", options.MediaType, "size", len(data))
+	respBody, err := l.fileRequest(ctx, manager, "POST", manager.FilesEndpoint(), bytes.NewReader(body), contentType)
+	if err != nil {
+		return nil, err
+	}
+	info, err := manager.ParseFileInfo(respBody)
+	if err != nil {
+		return nil, NewLLMError(ErrorTypeResponse, "failed to parse file upload response", err)
+	}
+	return info, nil
+}
+
+// ListFiles returns the files stored with the provider's Files API.
+func (l *LLMImpl) ListFiles(ctx context.Context) ([]FileInfo, error) {
+	manager, err := l.fileManager()
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := l.fileRequest(ctx, manager, "GET", manager.FilesEndpoint(), nil, "")
+	if err != nil {
+		return nil, err
+	}
+	files, err := manager.ParseFileList(respBody)
+	if err != nil {
+		return nil, NewLLMError(ErrorTypeResponse, "failed to parse file list response", err)
+	}
+	return files, nil
+}
+
+// DeleteFile removes a file from the provider's Files API.
+func (l *LLMImpl) DeleteFile(ctx context.Context, fileID string) error {
+	manager, err := l.fileManager()
+	if err != nil {
+		return err
+	}
+	if fileID == "" {
+		return NewLLMError(ErrorTypeInvalidInput, "file ID cannot be empty", nil)
+	}
+	_, err = l.fileRequest(ctx, manager, "DELETE", manager.FilesEndpoint()+"/"+url.PathEscape(fileID), nil, "")
+	return err
+}
+
+// fileManager returns the provider as a FileManager.
+func (l *LLMImpl) fileManager() (providers.FileManager, error) {
+	manager, ok := l.Provider.(providers.FileManager)
+	if !ok {
+		return nil, NewLLMError(ErrorTypeUnsupported, fmt.Sprintf("file management not supported by provider %s", l.Provider.Name()), nil)
+	}
+	return manager, nil
+}
+
+// fileRequest sends a Files API request and returns the response body.
+func (l *LLMImpl) fileRequest(ctx context.Context, manager providers.FileManager, method, endpoint string, body io.Reader, contentType string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return nil, NewLLMError(ErrorTypeRequest, "failed to create file request", err)
+	}
+	for k, v := range manager.FileHeaders() {
+		req.Header.Set(k, v)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return nil, NewLLMError(ErrorTypeRequest, "failed to send file request", err)
+	}
+	defer resp.Body.Close()
+	respBody, err := providers.ReadResponseBody(resp.Body)
+	if err != nil {
+		return nil, NewLLMError(ErrorTypeResponse, "failed to read response body", err)
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		l.logger.Error("API error", "provider", l.Provider.Name(), "status", resp.StatusCode, "body", string(respBody))
+		return nil, newAPIError(l.Provider.Name(), resp, respBody)
+	}
+	return respBody, nil
+}
+
+// detectMediaType returns the MIME type of a file from its name extension,
+// or else from its content.
+func detectMediaType(filename string, data []byte) string {
+	switch ext := strings.ToLower(filepath.Ext(filename)); ext {
+	case ".jsonl":
+		// Not in the system MIME tables, and sniffed as plain text
+		return "application/jsonl"
+	case "":
+	default:
+		if mediaType := mime.TypeByExtension(ext); mediaType != "" {
+			return mediaType
+		}
+	}
+	return http.DetectContentType(data)
+}
+
+// UploadFile stores a file with the underlying LLM's provider.
+func (l *LLMWithMemory) UploadFile(ctx context.Context, filename string, content io.Reader, opts ...FileOption) (*FileInfo, error) {
+	if f, ok := l.LLM.(interface {
+		UploadFile(context.Context, string, io.Reader, ...FileOption) (*FileInfo, error)
+	}); ok {
+		return f.UploadFile(ctx, filename, content, opts...)
+	}
+	return nil, NewLLMError(ErrorTypeUnsupported, "file management not supported by underlying LLM", nil)
+}
+
+// ListFiles returns the files stored with the underlying LLM's provider.
+func (l *LLMWithMemory) ListFiles(ctx context.Context) ([]FileInfo, error) {
+	if f, ok := l.LLM.(interface {
+		ListFiles(context.Context) ([]FileInfo, error)
+	}); ok {
+		return f.ListFiles(ctx)
+	}
+	return nil, NewLLMError(ErrorTypeUnsupported, "file management not supported by underlying LLM", nil)
+}
+
+// DeleteFile removes a file from the underlying LLM's provider.
+func (l *LLMWithMemory) DeleteFile(ctx context.Context, fileID string) error {
+	if f, ok := l.LLM.(interface {
+		DeleteFile(context.Context, string) error
+	}); ok {
+		return f.DeleteFile(ctx, fileID)
+	}
+	return NewLLMError(ErrorTypeUnsupported, "file management not supported by underlying LLM", nil)
+}
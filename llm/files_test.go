@@ -0,0 +1,254 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm/providers"
+	"github.com/teilomillet/gollm/types"
+	"github.com/teilomillet/gollm/utils"
+)
+
+// uploadingProvider is a MockProvider with a Files API backed by a test server.
+type uploadingProvider struct {
+	*MockProvider
+	baseURL string
+}
+
+func (p *uploadingProvider) Endpoint() string      { return p.baseURL + "/messages" }
+func (p *uploadingProvider) FilesEndpoint() string { return p.baseURL + "/files" }
+func (p *uploadingProvider) FileHeaders() map[string]string {
+	return map[string]string{"x-files-beta": "on"}
+}
+func (p *uploadingProvider) MaxFileSize() int64 { return 1 << 20 }
+func (p *uploadingProvider) PrepareFileUpload(content io.Reader, opts providers.FileUploadOptions) ([]byte, string, error) {
+	data, err := io.ReadAll(content)
+	return data, opts.MediaType, err
+}
+func (p *uploadingProvider) ParseFileInfo(body []byte) (*FileInfo, error) {
+	return &FileInfo{ID: "file_123"}, nil
+}
+func (p *uploadingProvider) ParseFileList(body []byte) ([]FileInfo, error) { return nil, nil }
+func (p *uploadingProvider) UploadsDocuments() bool                        { return true }
+
+var _ providers.FileManager = (*uploadingProvider)(nil)
+
+func TestDocumentUploadCaching(t *testing.T) {
+	var uploads, deletes atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/files":
+			uploads.Add(1)
+			assert.Equal(t, "application/pdf", r.Header.Get("Content-Type"))
+			w.Write([]byte(`{"id":"file_123"}`))
+		case r.Method == http.MethodDelete && r.URL.Path == "/files/file_123":
+			deletes.Add(1)
+			w.WriteHeader(http.StatusOK)
+		case r.URL.Path == "/messages":
+			assert.Equal(t, "on", r.Header.Get("x-files-beta"))
+			w.Write([]byte(`{}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "report.pdf")
+	require.NoError(t, os.WriteFile(path, []byte("%PDF-1.4 fake"), 0o600))
+
+	l := &LLMImpl{
+		Provider: &uploadingProvider{MockProvider: NewMockProvider(), baseURL: server.URL},
+		Options:  make(map[string]interface{}),
+		client:   server.Client(),
+		logger:   utils.NewLogger(utils.LogLevelOff),
+	}
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		_, err := l.Generate(ctx, NewPrompt("Summarize", WithDocumentFile(path)))
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(1), uploads.Load(), "identical documents should be uploaded once")
+
+	require.NoError(t, l.DeleteUploadedFiles(ctx))
+	assert.Equal(t, int32(1), deletes.Load())
+
+	_, err := l.Generate(ctx, NewPrompt("Summarize", WithDocumentFile(path)))
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), uploads.Load(), "cache should be cleared after cleanup")
+}
+
+func TestDocumentUploadError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"error":{"type":"rate_limit_error","message":"slow down"}}`))
+	}))
+	defer server.Close()
+	l := &LLMImpl{
+		Provider: &uploadingProvider{MockProvider: NewMockProvider(), baseURL: server.URL},
+		Options:  make(map[string]interface{}),
+		client:   server.Client(),
+		logger:   utils.NewLogger(utils.LogLevelOff),
+	}
+
+	_, err := l.Generate(context.Background(), NewPrompt("Summarize", WithDocument(types.Document{Data: "JVBERi0xLjQ=", MediaType: "application/pdf"})))
+	require.Error(t, err)
+	var apiErr *providers.APIError
+	require.ErrorAs(t, err, &apiErr, "upload failures are API errors")
+	assert.Equal(t, http.StatusTooManyRequests, apiErr.StatusCode)
+}
+
+func TestStreamDocuments(t *testing.T) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if strings.Contains(string(body), `"stream":true`) {
+			w.Write([]byte("data: {\"choices\":[{\"delta\":{\"content\":\"A report\"}}]}\n\ndata: [DONE]\n\n"))
+			return
+		}
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"{\"kind\":\"report\"}"}}]}`))
+	}))
+	defer server.Close()
+	l := &LLMImpl{
+		Provider: &localOpenAIProvider{OpenAIProvider: providers.NewOpenAIProvider("fake-key", "gpt-4o", nil).(*providers.OpenAIProvider), url: server.URL},
+		Options:  make(map[string]interface{}),
+		client:   server.Client(),
+		logger:   utils.NewLogger(utils.LogLevelOff),
+	}
+	prompt := NewPrompt("What is this?", WithDocument(types.Document{Data: "JVBERi0xLjQ=", MediaType: "application/pdf"}))
+	const document = `"file_data":"data:application/pdf;base64,JVBERi0xLjQ="`
+
+	stream, err := l.Stream(context.Background(), prompt)
+	require.NoError(t, err)
+	_, err = CollectStream(context.Background(), stream)
+	require.NoError(t, err)
+	require.Len(t, bodies, 1)
+	assert.Contains(t, bodies[0], document, "the document is streamed with the prompt")
+
+	schema := map[string]interface{}{"type": "object", "properties": map[string]interface{}{"kind": map[string]interface{}{"type": "string"}}}
+	_, err = l.GenerateWithSchema(context.Background(), prompt, schema)
+	require.NoError(t, err)
+	require.Len(t, bodies, 2)
+	assert.Contains(t, bodies[1], document, "the document is sent with a schema")
+}
+
+func TestDocumentWithoutFilesAPI(t *testing.T) {
+	l := &LLMImpl{Provider: NewMockProvider(), logger: utils.NewLogger(utils.LogLevelOff)}
+	docs := NewPrompt("x", WithDocumentURL("https://example.com/a.pdf")).Documents
+
+	resolved, usesFiles, err := l.resolveDocuments(context.Background(), docs)
+	require.NoError(t, err)
+	assert.False(t, usesFiles)
+	assert.True(t, strings.HasSuffix(resolved[0].URL, "a.pdf"))
+	assert.NoError(t, l.DeleteUploadedFiles(context.Background()))
+}
+
+func TestDocumentUnsupportedProvider(t *testing.T) {
+	l := &LLMImpl{
+		Provider: providers.NewGroqProvider("fake-key", "llama-3.3-70b-versatile", nil),
+		Options:  make(map[string]interface{}),
+		logger:   utils.NewLogger(utils.LogLevelOff),
+	}
+	prompt := NewPrompt("Summarize", WithDocument(types.Document{Data: "JVBERi0xLjQ=", MediaType: "application/pdf"}))
+
+	var llmErr *LLMError
+	_, err := l.Generate(context.Background(), prompt)
+	require.True(t, errors.As(err, &llmErr), "documents are not dropped")
+	assert.Equal(t, ErrorTypeUnsupported, llmErr.Type)
+	assert.ErrorIs(t, err, providers.ErrUnsupportedAttachment)
+
+	_, err = l.Stream(context.Background(), prompt)
+	require.True(t, errors.As(err, &llmErr), "documents are not dropped when streaming")
+	assert.Equal(t, ErrorTypeUnsupported, llmErr.Type)
+}
+
+type localFileManager struct {
+	*providers.OpenAIProvider
+	url string
+}
+
+func (p *localFileManager) FilesEndpoint() string { return p.url + "/files" }
+
+func TestFileManagement(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer fake-key", r.Header.Get("Authorization"))
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/files":
+			file, header, err := r.FormFile("file")
+			require.NoError(t, err)
+			data, _ := io.ReadAll(file)
+			assert.Equal(t, "application/jsonl", header.Header.Get("Content-Type"))
+			assert.Equal(t, "fine-tune", r.FormValue("purpose"))
+			w.Write([]byte(`{"id":"file-1","object":"file","bytes":` + strconv.Itoa(len(data)) + `,"created_at":1700000000,"filename":"` + header.Filename + `","purpose":"fine-tune"}`))
+		case r.Method == http.MethodGet && r.URL.Path == "/files":
+			w.Write([]byte(`{"data":[{"id":"file-1","bytes":12,"created_at":1700000000,"filename":"train.jsonl"},` +
+				`{"id":"file_2","size_bytes":5,"created_at":"2025-01-02T03:04:05Z","filename":"notes.pdf","mime_type":"application/pdf"}]}`))
+		case r.Method == http.MethodDelete && r.URL.Path == "/files/file-1":
+			w.Write([]byte(`{"id":"file-1","deleted":true}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"message":"No such file"}}`))
+		}
+	}))
+	defer server.Close()
+
+	l := &LLMImpl{
+		Provider: &localFileManager{OpenAIProvider: providers.NewOpenAIProvider("fake-key", "gpt-4o", nil).(*providers.OpenAIProvider), url: server.URL},
+		Options:  make(map[string]interface{}),
+		client:   server.Client(),
+		logger:   utils.NewLogger(utils.LogLevelOff),
+	}
+	ctx := context.Background()
+
+	file, err := l.UploadFile(ctx, "data/train.jsonl", strings.NewReader(`{"messages":[]}`), WithFilePurpose("fine-tune"))
+	require.NoError(t, err)
+	assert.Equal(t, FileInfo{ID: "file-1", Filename: "train.jsonl", Bytes: 15, Purpose: "fine-tune", CreatedAt: time.Unix(1700000000, 0).UTC()}, *file)
+
+	files, err := l.ListFiles(ctx)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	assert.Equal(t, int64(5), files[1].Bytes)
+	assert.Equal(t, time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC), files[1].CreatedAt)
+	assert.Equal(t, "application/pdf", files[1].MediaType)
+
+	require.NoError(t, l.DeleteFile(ctx, "file-1"))
+	assert.ErrorContains(t, l.DeleteFile(ctx, "missing"), "404")
+
+	var llmErr *LLMError
+
+	require.ErrorAs(t, func() error { _, err := l.UploadFile(ctx, "empty.txt", strings.NewReader("")); return err }(), &llmErr)
+	assert.Equal(t, ErrorTypeInvalidInput, llmErr.Type)
+
+	l.Provider = NewMockProvider()
+	require.ErrorAs(t, func() error { _, err := l.ListFiles(ctx); return err }(), &llmErr)
+	assert.Equal(t, ErrorTypeUnsupported, llmErr.Type)
+	// DeepSeek has no Files API: OpenAI's must not be called with the DeepSeek key
+	deepseek := newBatchLLM(t, providers.NewDeepSeekProvider("key", "deepseek-chat", nil), func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+	})
+	require.ErrorAs(t, func() error { _, err := deepseek.UploadFile(ctx, "train.jsonl", strings.NewReader("{}")); return err }(), &llmErr)
+	assert.Equal(t, ErrorTypeUnsupported, llmErr.Type)
+	require.ErrorAs(t, func() error { _, err := deepseek.ListFiles(ctx); return err }(), &llmErr)
+	assert.Equal(t, ErrorTypeUnsupported, llmErr.Type)
+	require.ErrorAs(t, deepseek.DeleteFile(ctx, "file-1"), &llmErr)
+	assert.Equal(t, ErrorTypeUnsupported, llmErr.Type)
+}
+
+func TestDetectMediaType(t *testing.T) {
+	assert.Equal(t, "application/jsonl", detectMediaType("train.JSONL", []byte("{}")))
+	assert.Equal(t, "application/pdf", detectMediaType("report.pdf", nil))
+	assert.Equal(t, "image/png", detectMediaType("upload", []byte("\x89PNG\r\n\x1a\n")))
+}
@@ -0,0 +1,353 @@
+package llm
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/teilomillet/gollm/providers"
+)
+
+// FineTuneOption is a function type for configuring fine-tuning jobs.
+type FineTuneOption func(*providers.FineTuneRequest)
+
+// WithValidationFile sets the uploaded file used to evaluate the model
+// during training.
+func WithValidationFile(fileID string) FineTuneOption {
+	return func(r *providers.FineTuneRequest) {
+		r.ValidationFile = fileID
+	}
+}
+
+// WithFineTuneSuffix sets a suffix added to the name of the fine-tuned model.
+func WithFineTuneSuffix(suffix string) FineTuneOption {
+	return func(r *providers.FineTuneRequest) {
+		r.Suffix = suffix
+	}
+}
+
+// WithHyperparameter sets a training hyperparameter, such as "n_epochs" or
+// "learning_rate_multiplier". Names and values are the provider's.
+func WithHyperparameter(name string, value interface{}) FineTuneOption {
+	return func(r *providers.FineTuneRequest) {
+		if r.Hyperparameters == nil {
+			r.Hyperparameters = make(map[string]interface{})
+		}
+		r.Hyperparameters[name] = value
+	}
+}
+
+// FineTuneUpdate is received while watching a fine-tuning job: either a new
+// event of the job, or the error that stopped the watch.
+type FineTuneUpdate struct {
+	Event *providers.FineTuneEvent
+	Err   error
+}
+
+// FineTuneJob is a fine-tuning job run by the provider. It works the same
+// with every provider supporting fine-tuning, currently OpenAI and Mistral.
+// A FineTuneJob is not safe for concurrent use.
+type FineTuneJob struct {
+	providers.FineTuneInfo
+	l     *LLMImpl
+	tuner providers.FineTuner
+}
+
+// CreateFineTune starts fine-tuning the base model on a training file
+// uploaded with UploadFile. Check the data with ValidateTrainingData before
+// uploading it, as providers only report invalid data once the job fails.
+//
+// Returns:
+//   - ErrorTypeUnsupported if the provider has no fine-tuning API
+//   - ErrorTypeInvalidInput if the model or training file is missing
+//   - ErrorTypeAPI if the provider rejects the job
+//
+// Example usage:
+//
+//	file, err := l.UploadFile(ctx, "train.jsonl", data, llm.WithFilePurpose("fine-tune"))
+//	job, err := l.CreateFineTune(ctx, "gpt-4o-mini-2024-07-18", file.ID, llm.WithHyperparameter("n_epochs", 3))
+//	for update := range job.Watch(ctx, 30*time.Second) {
+//	    fmt.Println(update.Event.Message)
+//	}
+//	fmt.Println(job.Status, job.FineTunedModel)
+func (l *LLMImpl) CreateFineTune(ctx context.Context, model, trainingFile string, opts ...FineTuneOption) (*FineTuneJob, error) {
+	tuner, err := l.fineTuner()
+	if err != nil {
+		return nil, err
+	}
+	if model == "" || trainingFile == "" {
+		return nil, NewLLMError(ErrorTypeInvalidInput, "fine-tuning needs a model and a training file", nil)
+	}
+	request := providers.FineTuneRequest{Model: model, TrainingFile: trainingFile}
+	for _, opt := range opts {
+		opt(&request)
+	}
+
+	info, err := tuner.CreateFineTune(ctx, l.client, request)
+	if err != nil {
+		return nil, NewLLMError(ErrorTypeAPI, "failed to create fine-tuning job", err)
+	}
+	l.logger.Debug("Fine-tuning job created", "provider", l.Provider.Name(), "id", info.ID, "model", model)
+	return &FineTuneJob{FineTuneInfo: *info, l: l, tuner: tuner}, nil
+}
+
+// GetFineTune returns the job of a previously created fine-tune.
+func (l *LLMImpl) GetFineTune(ctx context.Context, id string) (*FineTuneJob, error) {
+	tuner, err := l.fineTuner()
+	if err != nil {
+		return nil, err
+	}
+	job := &FineTuneJob{FineTuneInfo: providers.FineTuneInfo{ID: id}, l: l, tuner: tuner}
+	if err := job.Refresh(ctx); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// ListFineTunes returns the most recent fine-tuning jobs of the account.
+func (l *LLMImpl) ListFineTunes(ctx context.Context) ([]*FineTuneJob, error) {
+	tuner, err := l.fineTuner()
+	if err != nil {
+		return nil, err
+	}
+	infos, err := tuner.ListFineTunes(ctx, l.client)
+	if err != nil {
+		return nil, NewLLMError(ErrorTypeAPI, "failed to list fine-tuning jobs", err)
+	}
+	jobs := make([]*FineTuneJob, len(infos))
+	for i, info := range infos {
+		jobs[i] = &FineTuneJob{FineTuneInfo: info, l: l, tuner: tuner}
+	}
+	return jobs, nil
+}
+
+func (l *LLMImpl) fineTuner() (providers.FineTuner, error) {
+	tuner, ok := l.Provider.(providers.FineTuner)
+	if !ok {
+		return nil, NewLLMError(ErrorTypeUnsupported, fmt.Sprintf("fine-tuning not supported by provider %s", l.Provider.Name()), nil)
+	}
+	return tuner, nil
+}
+
+// Done reports whether the job has stopped running.
+func (j *FineTuneJob) Done() bool {
+	switch j.Status {
+	case providers.FineTuneSucceeded, providers.FineTuneFailed, providers.FineTuneCanceled:
+		return true
+	}
+	return false
+}
+
+// Refresh updates the job with the provider's current state of the job.
+func (j *FineTuneJob) Refresh(ctx context.Context) error {
+	info, err := j.tuner.GetFineTune(ctx, j.l.client, j.ID)
+	if err != nil {
+		return NewLLMError(ErrorTypeAPI, "failed to get fine-tuning job", err)
+	}
+	j.FineTuneInfo = *info
+	return nil
+}
+
+// Wait polls the job every interval until it stops running or the context
+// is cancelled.
+func (j *FineTuneJob) Wait(ctx context.Context, interval time.Duration) error {
+	for !j.Done() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-j.l.clock().After(interval):
+		}
+		if err := j.Refresh(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Cancel asks the provider to stop the job.
+func (j *FineTuneJob) Cancel(ctx context.Context) error {
+	info, err := j.tuner.CancelFineTune(ctx, j.l.client, j.ID)
+	if err != nil {
+		return NewLLMError(ErrorTypeAPI, "failed to cancel fine-tuning job", err)
+	}
+	j.FineTuneInfo = *info
+	return nil
+}
+
+// Events returns the events of the job so far, oldest first.
+func (j *FineTuneJob) Events(ctx context.Context) ([]providers.FineTuneEvent, error) {
+	events, err := j.tuner.FineTuneEvents(ctx, j.l.client, j.ID)
+	if err != nil {
+		return nil, NewLLMError(ErrorTypeAPI, "failed to get fine-tuning events", err)
+	}
+	return events, nil
+}
+
+// Watch streams the events of the job, polling every interval, until the job
+// stops running or the context is cancelled. The job is refreshed along the
+// way, so its final state is known once the channel is closed. An error ends
+// the watch and is sent as the last update.
+func (j *FineTuneJob) Watch(ctx context.Context, interval time.Duration) <-chan FineTuneUpdate {
+	updates := make(chan FineTuneUpdate)
+	go func() {
+		defer close(updates)
+		send := func(update FineTuneUpdate) bool {
+			select {
+			case updates <- update:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		seen := make(map[string]bool)
+		for {
+			// Refreshed before the events so that none is missed when done
+			err := j.Refresh(ctx)
+			var events []providers.FineTuneEvent
+			if err == nil {
+				events, err = j.Events(ctx)
+			}
+			if err != nil {
+				send(FineTuneUpdate{Err: err})
+				return
+			}
+			for i := range events {
+				if seen[events[i].ID] {
+					continue
+				}
+				seen[events[i].ID] = true
+				if !send(FineTuneUpdate{Event: &events[i]}) {
+					return
+				}
+			}
+			if j.Done() {
+				return
+			}
+			select {
+			case <-ctx.Done():
+				send(FineTuneUpdate{Err: ctx.Err()})
+				return
+			case <-j.l.clock().After(interval):
+			}
+		}
+	}()
+	return updates
+}
+
+// CreateFineTune starts a fine-tuning job with the underlying LLM.
+func (l *LLMWithMemory) CreateFineTune(ctx context.Context, model, trainingFile string, opts ...FineTuneOption) (*FineTuneJob, error) {
+	if f, ok := l.LLM.(interface {
+		CreateFineTune(context.Context, string, string, ...FineTuneOption) (*FineTuneJob, error)
+	}); ok {
+		return f.CreateFineTune(ctx, model, trainingFile, opts...)
+	}
+	return nil, NewLLMError(ErrorTypeUnsupported, "fine-tuning not supported by underlying LLM", nil)
+}
+
+// GetFineTune returns a fine-tuning job of the underlying LLM.
+func (l *LLMWithMemory) GetFineTune(ctx context.Context, id string) (*FineTuneJob, error) {
+	if f, ok := l.LLM.(interface {
+		GetFineTune(context.Context, string) (*FineTuneJob, error)
+	}); ok {
+		return f.GetFineTune(ctx, id)
+	}
+	return nil, NewLLMError(ErrorTypeUnsupported, "fine-tuning not supported by underlying LLM", nil)
+}
+
+// ListFineTunes returns the fine-tuning jobs of the underlying LLM.
+func (l *LLMWithMemory) ListFineTunes(ctx context.Context) ([]*FineTuneJob, error) {
+	if f, ok := l.LLM.(interface {
+		ListFineTunes(context.Context) ([]*FineTuneJob, error)
+	}); ok {
+		return f.ListFineTunes(ctx)
+	}
+	return nil, NewLLMError(ErrorTypeUnsupported, "fine-tuning not supported by underlying LLM", nil)
+}
+
+// trainingExample is a line of fine-tuning data, in the chat format or the
+// legacy prompt/completion format.
+type trainingExample struct {
+	Messages []struct {
+		Role    string          `json:"role"`
+		Content json.RawMessage `json:"content"`
+	} `json:"messages"`
+	Prompt     *string `json:"prompt"`
+	Completion *string `json:"completion"`
+}
+
+// ValidateTrainingData checks JSONL fine-tuning data before it is uploaded.
+// Each line must be a chat example, {"messages": [...]} with known roles and
+// at least one assistant message, or a {"prompt": ..., "completion": ...}
+// pair. Blank lines are ignored.
+//
+// Returns:
+//   - ErrorTypeInvalidInput listing the invalid lines, or if there is no example
+func ValidateTrainingData(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	var problems []string
+	examples := 0
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		examples++
+		if problem := validateTrainingExample([]byte(text)); problem != "" {
+			problems = append(problems, fmt.Sprintf("line %d: %s", line, problem))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return NewLLMError(ErrorTypeInvalidInput, "failed to read training data", err)
+	}
+	if examples == 0 {
+		return NewLLMError(ErrorTypeInvalidInput, "training data has no examples", nil)
+	}
+	if len(problems) > 0 {
+		const maxProblems = 10
+		if len(problems) > maxProblems {
+			problems = append(problems[:maxProblems], fmt.Sprintf("and %d more", len(problems)-maxProblems))
+		}
+		return NewLLMError(ErrorTypeInvalidInput, "invalid training data: "+strings.Join(problems, "; "), nil)
+	}
+	return nil
+}
+
+func validateTrainingExample(data []byte) string {
+	var example trainingExample
+	if err := json.Unmarshal(data, &example); err != nil {
+		return "invalid JSON"
+	}
+	if example.Messages == nil {
+		if example.Prompt == nil || example.Completion == nil {
+			return `expected "messages" or "prompt" and "completion"`
+		}
+		return ""
+	}
+	if len(example.Messages) == 0 {
+		return "no messages"
+	}
+	hasAssistant := false
+	for i, m := range example.Messages {
+		switch m.Role {
+		case "assistant":
+			hasAssistant = true
+		case "system", "user", "tool":
+		default:
+			return fmt.Sprintf("message %d has unknown role %q", i+1, m.Role)
+		}
+		if len(m.Content) == 0 || string(m.Content) == "null" {
+			if m.Role != "assistant" {
+				return fmt.Sprintf("message %d has no content", i+1)
+			}
+		}
+	}
+	if !hasAssistant {
+		return "no assistant message"
+	}
+	return ""
+}
@@ -0,0 +1,159 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm/providers"
+)
+
+func TestOpenAIFineTune(t *testing.T) {
+	polls := 0
+	l := newBatchLLM(t, providers.NewOpenAIProvider("key", "gpt-4o-mini", nil), func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer key", r.Header.Get("Authorization"))
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/fine_tuning/jobs":
+			var body map[string]interface{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.Equal(t, "gpt-4o-mini-2024-07-18", body["model"])
+			assert.Equal(t, "file-1", body["training_file"])
+			assert.Equal(t, "support", body["suffix"])
+			assert.Equal(t, map[string]interface{}{"n_epochs": float64(3)}, body["hyperparameters"])
+			fmt.Fprint(w, `{"id":"ftjob-1","model":"gpt-4o-mini-2024-07-18","status":"validating_files","training_file":"file-1","created_at":1700000000}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/fine_tuning/jobs/ftjob-1":
+			polls++
+			status, model := "running", "null"
+			if polls > 1 {
+				status, model = "succeeded", `"ft:gpt-4o-mini:support"`
+			}
+			fmt.Fprintf(w, `{"id":"ftjob-1","status":%q,"fine_tuned_model":%s}`, status, model)
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/fine_tuning/jobs/ftjob-1/events":
+			// Newest first, growing with every poll
+			events := []string{
+				`{"id":"ev-2","created_at":1700000060,"level":"info","message":"Step 1/10: training loss=1.2","data":{"step":1}}`,
+				`{"id":"ev-1","created_at":1700000000,"level":"info","message":"Fine-tuning job started"}`,
+			}
+			if polls > 1 {
+				events = append([]string{`{"id":"ev-3","created_at":1700000120,"level":"info","message":"The job has successfully completed"}`}, events...)
+			}
+			fmt.Fprintf(w, `{"data":[%s]}`, strings.Join(events, ","))
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/fine_tuning/jobs/ftjob-1/cancel":
+			fmt.Fprint(w, `{"id":"ftjob-1","status":"cancelled"}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/fine_tuning/jobs":
+			fmt.Fprint(w, `{"data":[{"id":"ftjob-1","status":"succeeded"},{"id":"ftjob-0","status":"failed","error":{"message":"invalid file"}}]}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	})
+	ctx := context.Background()
+
+	job, err := l.CreateFineTune(ctx, "gpt-4o-mini-2024-07-18", "file-1", WithFineTuneSuffix("support"), WithHyperparameter("n_epochs", 3))
+	require.NoError(t, err)
+	assert.Equal(t, "ftjob-1", job.ID)
+	assert.Equal(t, providers.FineTuneQueued, job.Status)
+	assert.Equal(t, time.Unix(1700000000, 0).UTC(), job.CreatedAt)
+
+	var messages []string
+	for update := range job.Watch(ctx, time.Millisecond) {
+		require.NoError(t, update.Err)
+		messages = append(messages, update.Event.Message)
+	}
+	assert.Equal(t, []string{"Fine-tuning job started", "Step 1/10: training loss=1.2", "The job has successfully completed"}, messages)
+	assert.True(t, job.Done())
+	assert.Equal(t, "ft:gpt-4o-mini:support", job.FineTunedModel)
+
+	require.NoError(t, job.Cancel(ctx))
+	assert.Equal(t, providers.FineTuneCanceled, job.Status)
+
+	jobs, err := l.ListFineTunes(ctx)
+	require.NoError(t, err)
+	require.Len(t, jobs, 2)
+	assert.Equal(t, providers.FineTuneFailed, jobs[1].Status)
+	assert.Equal(t, "invalid file", jobs[1].Error)
+}
+
+func TestMistralFineTune(t *testing.T) {
+	l := newBatchLLM(t, providers.NewMistralProvider("key", "open-mistral-7b", nil), func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/fine_tuning/jobs":
+			var body map[string]interface{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.Equal(t, true, body["auto_start"])
+			assert.Equal(t, "file-1", body["training_files"].([]interface{})[0].(map[string]interface{})["file_id"])
+			fmt.Fprint(w, `{"id":"job-1","model":"open-mistral-7b","status":"QUEUED","training_files":["file-1"]}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/fine_tuning/jobs/job-1":
+			fmt.Fprint(w, `{"id":"job-1","status":"SUCCESS","fine_tuned_model":"ft:open-mistral-7b:1","events":[
+				{"name":"status-updated","data":{"status":"SUCCESS"},"created_at":1700000100},
+				{"name":"status-updated","data":{"status":"RUNNING"},"created_at":1700000000}]}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	})
+	ctx := context.Background()
+
+	job, err := l.CreateFineTune(ctx, "open-mistral-7b", "file-1")
+	require.NoError(t, err)
+	assert.Equal(t, providers.FineTuneQueued, job.Status)
+	assert.Equal(t, "file-1", job.TrainingFile)
+
+	require.NoError(t, job.Wait(ctx, time.Millisecond))
+	assert.Equal(t, providers.FineTuneSucceeded, job.Status)
+	events, err := job.Events(ctx)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, "RUNNING", events[0].Data["status"])
+}
+
+func TestFineTuneUnsupported(t *testing.T) {
+	l := newBatchLLM(t, providers.NewDeepSeekProvider("key", "deepseek-chat", nil), func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+	})
+	// DeepSeek has no fine-tuning API: OpenAI's must not be called with the
+	// DeepSeek key
+	ctx := context.Background()
+	var llmErr *LLMError
+	_, err := l.CreateFineTune(ctx, "deepseek-chat", "file-1")
+	require.ErrorAs(t, err, &llmErr)
+	assert.Equal(t, ErrorTypeUnsupported, llmErr.Type)
+	_, err = l.ListFineTunes(ctx)
+	require.ErrorAs(t, err, &llmErr)
+	assert.Equal(t, ErrorTypeUnsupported, llmErr.Type)
+	_, err = l.GetFineTune(ctx, "ftjob-1")
+	require.ErrorAs(t, err, &llmErr)
+	assert.Equal(t, ErrorTypeUnsupported, llmErr.Type)
+
+	l.Provider = providers.NewMockProvider("", "", nil)
+	_, err = l.ListFineTunes(ctx)
+	require.ErrorAs(t, err, &llmErr)
+	assert.Equal(t, ErrorTypeUnsupported, llmErr.Type)
+}
+
+func TestValidateTrainingData(t *testing.T) {
+	valid := `{"messages":[{"role":"system","content":"Be brief"},{"role":"user","content":"Hi"},{"role":"assistant","content":"Hello"}]}
+
+{"prompt":"2+2=","completion":"4"}
+`
+	assert.NoError(t, ValidateTrainingData(strings.NewReader(valid)))
+
+	invalid := `{"messages":[{"role":"user","content":"Hi"}]}
+not json
+{"messages":[{"role":"bot","content":"Hi"},{"role":"assistant","content":"Hello"}]}
+{"text":"Hi"}
+`
+	err := ValidateTrainingData(strings.NewReader(invalid))
+	var llmErr *LLMError
+	require.ErrorAs(t, err, &llmErr)
+	assert.Equal(t, ErrorTypeInvalidInput, llmErr.Type)
+	for _, want := range []string{"line 1: no assistant message", "line 2: invalid JSON", `line 3: message 1 has unknown role "bot"`, "line 4:"} {
+		assert.Contains(t, err.Error(), want)
+	}
+
+	assert.Error(t, ValidateTrainingData(strings.NewReader("\n\n")))
+}
@@ -0,0 +1,238 @@
+package llm
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/teilomillet/gollm/config"
+	"github.com/teilomillet/gollm/providers"
+)
+
+// DefaultFixtureDir is used when fixtures are enabled without a directory.
+const DefaultFixtureDir = "testdata/fixtures"
+
+// Fixture is a recorded provider request and its response.
+// Credentials are never recorded: request headers are omitted and secret
+// query parameters are removed from the URL.
+type Fixture struct {
+	Request  FixtureRequest  `json:"request"`
+	Response FixtureResponse `json:"response"`
+}
+
+// FixtureRequest is the recorded part of a provider request.
+type FixtureRequest struct {
+	Method string          `json:"method"`
+	URL    string          `json:"url"`
+	Body   json.RawMessage `json:"body,omitempty"`
+}
+
+// FixtureResponse is a recorded provider response.
+type FixtureResponse struct {
+	StatusCode  int    `json:"status_code"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        string `json:"body"`
+}
+
+// secretQueryParams are removed from recorded URLs and fixture keys.
+var secretQueryParams = []string{"key", "api_key", "apikey", "access_token"}
+
+// fixtureTransport records provider traffic to fixture files or replays it.
+type fixtureTransport struct {
+	dir    string
+	base   http.RoundTripper
+	replay bool
+	mu     sync.Mutex
+}
+
+// NewRecordingTransport returns a transport that sends requests through base
+// and saves every request with its response to a fixture file in dir.
+// Rate limit and server error responses are passed through without being
+// recorded, so a flaky recording run does not poison the fixtures.
+func NewRecordingTransport(dir string, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &fixtureTransport{dir: dir, base: base}
+}
+
+// NewReplayTransport returns a transport that answers requests from the
+// fixture files in dir without network access. Requests match a fixture when
+// their method, URL and body are identical, ignoring JSON key order.
+func NewReplayTransport(dir string) http.RoundTripper {
+	return &fixtureTransport{dir: dir, replay: true}
+}
+
+// newFixtureClient returns the HTTP client for the configured fixture mode,
+// sending requests with transport, or http.DefaultTransport if nil.
+func newFixtureClient(cfg *config.Config, transport http.RoundTripper) (*http.Client, error) {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	client := &http.Client{Timeout: cfg.Timeout, Transport: transport}
+	dir := cfg.FixtureDir
+	if dir == "" {
+		dir = DefaultFixtureDir
+	}
+	switch cfg.FixtureMode {
+	case "":
+	case config.FixtureModeRecord:
+		client.Transport = NewRecordingTransport(dir, transport)
+	case config.FixtureModeReplay:
+		client.Transport = NewReplayTransport(dir)
+	default:
+		return nil, NewLLMError(ErrorTypeInvalidInput, fmt.Sprintf("unknown fixture mode %q", cfg.FixtureMode), nil)
+	}
+	return client, nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *fixtureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+	}
+	// Multipart boundaries are random, so they are normalized before keying
+	keyBody := body
+	if _, params, err := mime.ParseMediaType(req.Header.Get("Content-Type")); err == nil && params["boundary"] != "" {
+		keyBody = bytes.ReplaceAll(body, []byte(params["boundary"]), []byte("BOUNDARY"))
+	}
+	recorded := FixtureRequest{
+		Method: req.Method,
+		URL:    redactURL(req.URL),
+		Body:   canonicalBody(keyBody),
+	}
+	path := filepath.Join(t.dir, fixtureKey(recorded)+".json")
+
+	if t.replay {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil, fmt.Errorf("no recorded fixture for %s %s (expected %s); record it with LLM_FIXTURE_MODE=record", recorded.Method, recorded.URL, path)
+			}
+			return nil, fmt.Errorf("failed to read fixture: %w", err)
+		}
+		var fixture Fixture
+		if err := json.Unmarshal(data, &fixture); err != nil {
+			return nil, fmt.Errorf("invalid fixture %s: %w", path, err)
+		}
+		return fixture.Response.httpResponse(req), nil
+	}
+
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := providers.ReadResponseBody(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return resp, nil
+	}
+
+	fixture := Fixture{
+		Request: recorded,
+		Response: FixtureResponse{
+			StatusCode:  resp.StatusCode,
+			ContentType: resp.Header.Get("Content-Type"),
+			Body:        string(respBody),
+		},
+	}
+	if err := t.save(path, fixture); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// save writes a fixture file, creating the fixture directory if needed.
+func (t *fixtureTransport) save(path string, fixture Fixture) error {
+	data, err := json.MarshalIndent(fixture, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode fixture: %w", err)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := os.MkdirAll(t.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create fixture directory: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write fixture: %w", err)
+	}
+	return nil
+}
+
+// httpResponse builds the HTTP response served for a replayed request.
+func (r FixtureResponse) httpResponse(req *http.Request) *http.Response {
+	header := make(http.Header)
+	if r.ContentType != "" {
+		header.Set("Content-Type", r.ContentType)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.StatusCode, http.StatusText(r.StatusCode)),
+		StatusCode:    r.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(r.Body)),
+		ContentLength: int64(len(r.Body)),
+		Request:       req,
+	}
+}
+
+// fixtureKey derives the fixture file name from the recorded request.
+func fixtureKey(r FixtureRequest) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s %s\n", r.Method, r.URL)
+	h.Write(r.Body)
+	return hex.EncodeToString(h.Sum(nil))[:24]
+}
+
+// redactURL returns the URL without secret query parameters.
+func redactURL(u *url.URL) string {
+	clean := *u
+	query := clean.Query()
+	for _, param := range secretQueryParams {
+		query.Del(param)
+	}
+	clean.RawQuery = query.Encode()
+	return clean.String()
+}
+
+// canonicalBody re-encodes JSON bodies with sorted keys so that map ordering
+// does not change fixture keys. Other bodies are stored as JSON strings.
+func canonicalBody(body []byte) json.RawMessage {
+	if len(body) == 0 {
+		return nil
+	}
+	var v interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&v); err == nil && !decoder.More() {
+		if canonical, err := json.Marshal(v); err == nil {
+			return canonical
+		}
+	}
+	encoded, _ := json.Marshal(string(body))
+	return encoded
+}
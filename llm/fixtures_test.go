@@ -0,0 +1,81 @@
+package llm
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm/config"
+	"github.com/teilomillet/gollm/providers"
+	"github.com/teilomillet/gollm/utils"
+)
+
+func TestFixtureRecordAndReplay(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if r.URL.Path == "/limited" {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices":[{"message":{"content":"recorded"}}]}`))
+	}))
+	dir := t.TempDir()
+
+	post := func(client *http.Client, path, body string) (*http.Response, error) {
+		req, err := http.NewRequest(http.MethodPost, server.URL+path+"?key=secret", strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer secret")
+		return client.Do(req)
+	}
+
+	recorder := &http.Client{Transport: NewRecordingTransport(dir, nil)}
+	resp, err := post(recorder, "/chat", `{"model":"gpt-4o","temperature":0.7}`)
+	require.NoError(t, err)
+	resp.Body.Close()
+	resp, err = post(recorder, "/limited", `{}`)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1, "rate limited responses are not recorded")
+	data, err := os.ReadFile(dir + "/" + files[0].Name())
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "secret")
+
+	server.Close()
+	replayer := &http.Client{Transport: NewReplayTransport(dir)}
+	resp, err = post(replayer, "/chat", `{"temperature":0.7, "model":"gpt-4o"}`)
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	assert.Contains(t, string(body), "recorded")
+	assert.Equal(t, int32(2), hits.Load())
+
+	_, err = post(replayer, "/chat", `{"model":"gpt-4o","temperature":0.2}`)
+	assert.ErrorContains(t, err, "no recorded fixture")
+}
+
+func TestNewLLMReplayWithoutAPIKey(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.FixtureMode = config.FixtureModeReplay
+	cfg.FixtureDir = t.TempDir()
+
+	_, err := NewLLM(cfg, utils.NewLogger(utils.LogLevelOff), providers.NewProviderRegistry())
+	require.NoError(t, err)
+	assert.NoError(t, Validate(cfg))
+
+	cfg.FixtureMode = "playback"
+	_, err = NewLLM(cfg, utils.NewLogger(utils.LogLevelOff), providers.NewProviderRegistry())
+	assert.Error(t, err)
+}
@@ -0,0 +1,119 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/teilomillet/gollm/providers"
+)
+
+// HealthChecker is implemented by LLMs that can check whether their provider
+// is able to serve requests.
+type HealthChecker interface {
+	// HealthCheck returns nil when the provider is reachable and accepts the
+	// credentials.
+	HealthCheck(ctx context.Context) error
+}
+
+// HealthCheck checks that the provider is reachable and accepts the
+// credentials. Providers implementing providers.HealthChecker are checked with
+// a request to their health endpoint, usually the free model listing; others
+// with a one-token generation. Checks are not retried, rate limited or queued.
+//
+// Returns:
+//   - ErrorTypeRequest if the provider cannot be reached
+//   - ErrorTypeAPI if the provider rejects the request
+func (l *LLMImpl) HealthCheck(ctx context.Context) error {
+	checker, ok := l.Provider.(providers.HealthChecker)
+	if !ok {
+		ping := &GenerateConfig{Options: map[string]interface{}{"max_tokens": 1}}
+		_, err := l.attemptGenerate(ctx, NewPrompt("ping"), ping)
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, checker.HealthEndpoint(), nil)
+	if err != nil {
+		return NewLLMError(ErrorTypeRequest, "failed to create health check request", err)
+	}
+	for k, v := range l.Provider.Headers() {
+		req.Header.Set(k, v)
+	}
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return NewLLMError(ErrorTypeRequest, "failed to send health check request", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return NewLLMError(ErrorTypeAPI, fmt.Sprintf("health check failed: status code %d", resp.StatusCode), nil)
+	}
+	return nil
+}
+
+// HealthCheck checks the underlying LLM's provider.
+func (l *LLMWithMemory) HealthCheck(ctx context.Context) error {
+	if checker, ok := l.LLM.(HealthChecker); ok {
+		return checker.HealthCheck(ctx)
+	}
+	return nil
+}
+
+// Healthy checks the LLMs concurrently and returns nil when they are all
+// healthy, or an error joining the failures. LLMs that do not implement
+// HealthChecker are assumed healthy.
+func Healthy(ctx context.Context, llms ...LLM) error {
+	errs := make([]error, len(llms))
+	var wg sync.WaitGroup
+	for i, l := range llms {
+		checker, ok := l.(HealthChecker)
+		if !ok {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, l LLM) {
+			defer wg.Done()
+			if err := checker.HealthCheck(ctx); err != nil {
+				errs[i] = fmt.Errorf("%s: %w", healthName(i, l), err)
+			}
+		}(i, l)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// healthName identifies an LLM in health check errors.
+func healthName(i int, l LLM) string {
+	if named, ok := l.(interface {
+		GetProvider() string
+		GetModel() string
+	}); ok {
+		return named.GetProvider() + "/" + named.GetModel()
+	}
+	return fmt.Sprintf("llm %d", i)
+}
+
+// ReadinessHandler returns an HTTP handler for readiness probes. It answers
+// 200 when all LLMs are healthy and 503 with the failures otherwise; each
+// probe checks the LLMs within timeout.
+//
+// Example usage:
+//
+//	http.Handle("/readyz", llm.ReadinessHandler(5*time.Second, client))
+func ReadinessHandler(timeout time.Duration, llms ...LLM) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if err := Healthy(ctx, llms...); err != nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprintln(w, err)
+			return
+		}
+		fmt.Fprintln(w, "ok")
+	})
+}
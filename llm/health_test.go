@@ -0,0 +1,64 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm/providers"
+	"github.com/teilomillet/gollm/utils"
+)
+
+// healthProvider is an OpenAI provider with a local health endpoint.
+type healthProvider struct {
+	*localOpenAIProvider
+}
+
+func (p *healthProvider) HealthEndpoint() string { return p.url + "/models" }
+
+func TestHealthCheck(t *testing.T) {
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		assert.Equal(t, "/models", r.URL.Path)
+		assert.Equal(t, "Bearer fake-key", r.Header.Get("Authorization"))
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	l := &LLMImpl{
+		Provider: &healthProvider{&localOpenAIProvider{OpenAIProvider: providers.NewOpenAIProvider("fake-key", "gpt-4o", nil).(*providers.OpenAIProvider), url: server.URL}},
+		Options:  make(map[string]interface{}),
+		client:   server.Client(),
+		logger:   utils.NewLogger(utils.LogLevelOff),
+	}
+	ctx := context.Background()
+	require.NoError(t, l.HealthCheck(ctx))
+	require.NoError(t, Healthy(ctx, l))
+
+	status = http.StatusUnauthorized
+	err := l.HealthCheck(ctx)
+	var llmErr *LLMError
+	require.True(t, errors.As(err, &llmErr))
+	assert.Equal(t, ErrorTypeAPI, llmErr.Type)
+	assert.Error(t, Healthy(ctx, l))
+
+	t.Run("ReadinessHandler", func(t *testing.T) {
+		handler := ReadinessHandler(time.Second, l)
+		status = http.StatusServiceUnavailable
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), "status code 503")
+
+		status = http.StatusOK
+		recorder = httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		assert.Equal(t, http.StatusOK, recorder.Code)
+	})
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/teilomillet/gollm/providers"
 	"github.com/teilomillet/gollm/types"
 	"github.com/teilomillet/gollm/utils"
+	"golang.org/x/time/rate"
 )
 
 // LLM interface defines the methods that our internal language model should implement.
@@ -64,14 +65,23 @@ type LLM interface {
 // LLMImpl implements the LLM interface and manages interactions with specific providers.
 // It handles provider communication, error management, and logging.
 type LLMImpl struct {
-	Provider     providers.Provider     // The underlying LLM provider
-	Options      map[string]interface{} // Provider-specific options
-	optionsMutex sync.RWMutex           // Mutex to protect concurrent access to Options map
-	client       *http.Client           // HTTP client for API requests
-	logger       utils.Logger           // Logger for debugging and monitoring
-	config       *config.Config         // Configuration settings
-	MaxRetries   int                    // Maximum number of retry attempts
-	RetryDelay   time.Duration          // Delay between retry attempts
+	Provider      providers.Provider          // The underlying LLM provider
+	Options       map[string]interface{}      // Provider-specific options
+	optionsMutex  sync.RWMutex                // Mutex to protect concurrent access to Options map
+	client        *http.Client                // HTTP client for API requests
+	logger        utils.Logger                // Logger for debugging and monitoring
+	config        *config.Config              // Configuration settings
+	MaxRetries    int                         // Maximum number of retry attempts
+	RetryDelay    time.Duration               // Delay between retry attempts
+	files         fileCache                   // Provider file IDs of uploaded documents
+	registry      *providers.ProviderRegistry // Registry used to create profile providers
+	profiles      profileCache                // LLMs created for config profiles
+	limiter       *rate.Limiter               // Limits requests per second, nil without a rate limit
+	queue         *RequestQueue               // Limits concurrent requests to the provider, nil without a limit
+	connections   *connectionPool             // Connection pool of the provider, nil when requests don't reach the network
+	deterministic map[string]interface{}      // Sampling options enforced in deterministic mode, nil otherwise
+	pipeline      *pipeline                   // Processors applied to every request, shared with profile LLMs
+	caching       *responseCaching            // Response cache, shared with profile LLMs
 }
 
 // GenerateOption is a function type for configuring generation behavior.
@@ -79,7 +89,32 @@ type GenerateOption func(*GenerateConfig)
 
 // GenerateConfig holds configuration options for text generation.
 type GenerateConfig struct {
-	UseJSONSchema bool // Whether to use JSON schema validation
+	UseJSONSchema     bool                   // Whether to use JSON schema validation
+	Options           map[string]interface{} // Per-request provider options that override the LLM's options
+	Usage             *Usage                 // Receives the token usage of the request, if set
+	Profile           string                 // Name of the config profile serving the request, if any
+	Model             string                 // Model or model alias serving the request, if not the LLM's own
+	Route             *RouteRequirements     // Routing constraints for routers, if set
+	Priority          Priority               // Priority of the request in the provider's queue
+	Logprobs          *[]TokenLogprob        // Receives the log probabilities of the generated tokens, if set
+	N                 int                    // Number of completions to generate
+	Choices           *[]string              // Receives the completions, if set
+	Selector          Selector               // Picks the completion returned, SelectFirst if nil
+	Metadata          map[string]string      // Key/value metadata of the request, e.g. user or feature
+	SystemFingerprint *string                // Receives the system fingerprint of the response, if set
+	FinishReason      *string                // Receives the reason the response finished, if set
+	Citations         *[]Citation            // Receives the citations of the response, if set
+	ContentFilters    *ContentFilters        // Receives the content filter annotations of the response, if set
+	DryRun            *DryRun                // Receives the request instead of sending it, if set
+	CacheControl      CacheControl           // How the request uses the response cache
+	CacheKey          CacheKeyFunc           // Computes the cache key of the request, if not the LLM's function
+	RetryBudget       *RetryBudget           // Limits the attempts of the request across retries and fallbacks, if set
+	Sanitize          *SanitizeReport        // Receives the repairs of the response, which is sanitized as JSON when set
+
+	PromptProcessors   []PromptProcessor   // Run on the prompt after those of the LLM
+	ResponseProcessors []ResponseProcessor // Run on the response after those of the LLM
+
+	choices []string // Completions of the last attempt, when the provider returns several
 }
 
 // NewLLM creates a new LLM instance with the specified configuration.
@@ -90,13 +125,21 @@ type GenerateConfig struct {
 //   - ErrorTypeProvider if provider initialization fails
 //   - ErrorTypeAuthentication if API key validation fails
 func NewLLM(cfg *config.Config, logger utils.Logger, registry *providers.ProviderRegistry) (LLM, error) {
+	cfg.ResolveModel()
+
 	extraHeaders := make(map[string]string)
 	if cfg.Provider == "anthropic" && cfg.EnableCaching {
 		extraHeaders["anthropic-beta"] = "prompt-caching-2024-07-31"
 	}
 
-	// Check if API key is empty
+	// Check if API key is empty; replayed fixtures never reach the provider
 	apiKey := cfg.APIKeys[cfg.Provider]
+	if apiKey == "" && cfg.FixtureMode == config.FixtureModeReplay {
+		apiKey = "replay"
+	}
+	if apiKey == "" && cfg.Provider == "mock" {
+		apiKey = "mock"
+	}
 	if apiKey == "" {
 		return nil, NewLLMError(ErrorTypeAuthentication, "empty API key", nil)
 	}
@@ -109,14 +152,55 @@ func NewLLM(cfg *config.Config, logger utils.Logger, registry *providers.Provide
 
 	provider.SetDefaultOptions(cfg)
 
+	// Requests share the connection pool of the provider, unless they are
+	// replayed or served in process
+	var transport http.RoundTripper
+	var connections *connectionPool
+	_, inProcess := provider.(providers.InProcessProvider)
+	if !inProcess && cfg.FixtureMode != config.FixtureModeReplay {
+		connections = providerConnections(cfg.Provider, cfg.KeepAlive)
+		transport = &compressionTransport{base: connections, minSize: cfg.CompressRequests}
+	}
+
+	client, err := newFixtureClient(cfg, transport)
+	if err != nil {
+		return nil, err
+	}
+
+	// In-process providers such as the mock never touch the network
+	if inProcess, ok := provider.(providers.InProcessProvider); ok {
+		client.Transport = inProcess.Transport()
+	}
+	client.Transport = newRawHookTransport(cfg, client.Transport)
+
 	llmClient := &LLMImpl{
-		Provider:   provider,
-		client:     &http.Client{Timeout: cfg.Timeout},
-		logger:     logger,
-		config:     cfg,
-		MaxRetries: cfg.MaxRetries,
-		RetryDelay: cfg.RetryDelay,
-		Options:    make(map[string]interface{}),
+		Provider:    provider,
+		client:      client,
+		connections: connections,
+		logger:      logger,
+		config:      cfg,
+		MaxRetries:  cfg.MaxRetries,
+		RetryDelay:  cfg.RetryDelay,
+		Options:     make(map[string]interface{}),
+		registry:    registry,
+		pipeline:    &pipeline{},
+		caching:     &responseCaching{},
+	}
+	if cfg.RateLimit > 0 {
+		llmClient.limiter = rate.NewLimiter(rate.Limit(cfg.RateLimit), 1)
+	}
+	if cfg.MaxConcurrency > 0 {
+		llmClient.queue = providerQueue(cfg.Provider, cfg.MaxConcurrency)
+	}
+	llmClient.applyDeterminism()
+	if cfg.WarmUp && connections != nil {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
+			defer cancel()
+			if err := llmClient.WarmUp(ctx); err != nil {
+				logger.Warn("Failed to warm up connection", "provider", cfg.Provider, "error", err)
+			}
+		}()
 	}
 
 	return llmClient, nil
@@ -132,6 +216,13 @@ func (l *LLMImpl) SetOption(key string, value interface{}) {
 	l.logger.Debug("Option set", key, value)
 }
 
+// SetToolChoice sets the tool choice of the requests whose prompt doesn't set
+// one: ToolChoiceAuto, ToolChoiceNone, ToolChoiceRequired or the name of the
+// tool the model must call. Providers translate it to their own tool_choice.
+func (l *LLMImpl) SetToolChoice(choice string) {
+	l.SetOption("tool_choice", choice)
+}
+
 // SetEndpoint updates the API endpoint for the provider.
 // This is primarily used for local models like Ollama.
 func (l *LLMImpl) SetEndpoint(endpoint string) {
@@ -174,26 +265,89 @@ func (l *LLMImpl) Generate(ctx context.Context, prompt *Prompt, opts ...Generate
 	for _, opt := range opts {
 		opt(config)
 	}
-	// Set the system prompt in the LLM's options
-	if prompt.SystemPrompt != "" {
+	if target, err := l.routed(config); err != nil {
+		return "", err
+	} else if target != nil {
+		return target.Generate(ctx, prompt, routedOptions(opts)...)
+	}
+	promptProcessors, responseProcessors := l.processors(config)
+	prompt, err := processPrompt(ctx, prompt, promptProcessors)
+	if err != nil {
+		return "", err
+	}
+	if prompt.OutputFormat != "" {
+		responseProcessors = append([]ResponseProcessor{outputFormatProcessor(prompt.OutputFormat)}, responseProcessors...)
+	}
+	// Set the system prompt in the LLM's options, or leave it in the
+	// prompt's text only for models without a system role
+	if prompt.SystemPlacement == SystemPlacementUser {
+		if config.Options == nil {
+			config.Options = make(map[string]interface{})
+		}
+		config.Options["system_prompt"] = ""
+	} else if prompt.SystemPrompt != "" {
 		l.SetOption("system_prompt", prompt.SystemPrompt)
 	}
+	if config.DryRun != nil {
+		_, err := l.attemptGenerate(ctx, prompt, config)
+		return "", err
+	}
+	if err := l.autoModerate(ctx, "prompt", prompt.String()); err != nil {
+		return "", err
+	}
+	var response string
+	if config.N > 1 {
+		response, err = l.generateChoices(ctx, prompt, config)
+	} else {
+		response, err = l.generate(ctx, prompt, config)
+	}
+	if err != nil {
+		return "", err
+	}
+	return processResponse(ctx, prompt, response, responseProcessors)
+}
+
+// generate sends the prompt, retrying failed attempts.
+func (l *LLMImpl) generate(ctx context.Context, prompt *Prompt, config *GenerateConfig) (string, error) {
+	var lastErr error
 	for attempt := 0; attempt <= l.MaxRetries; attempt++ {
-		l.logger.Debug("Generating text", "provider", l.Provider.Name(), "prompt", prompt.String(), "system_prompt", prompt.SystemPrompt, "attempt", attempt+1)
+		l.logger.Debug("Generating text", "provider", l.Provider.Name(), "prompt", prompt.String(), "system_prompt", prompt.SystemPrompt, "metadata", config.Metadata, "attempt", attempt+1)
+		if err := config.RetryBudget.take(lastErr); err != nil {
+			return "", err
+		}
+		if err := l.waitForRateLimit(ctx); err != nil {
+			return "", err
+		}
+		release, err := l.acquire(ctx, config.Priority)
+		if err != nil {
+			return "", err
+		}
 		// Pass the entire Prompt struct to attemptGenerate
-		result, err := l.attemptGenerate(ctx, prompt)
+		result, err := l.attemptGenerate(ctx, prompt, config)
+		release()
 		if err == nil {
+			if err := l.autoModerate(ctx, "response", result); err != nil {
+				return "", err
+			}
 			return result, nil
 		}
-		l.logger.Warn("Generation attempt failed", "error", err, "attempt", attempt+1)
+		l.logger.Warn("Generation attempt failed", "error", err, "metadata", config.Metadata, "attempt", attempt+1)
+		if isContentFiltered(err) {
+			// The same content would be blocked again
+			return "", err
+		}
+		lastErr = err
 		if attempt < l.MaxRetries {
+			if err := config.RetryBudget.check(l.RetryDelay, lastErr); err != nil {
+				return "", err
+			}
 			l.logger.Debug("Retrying", "delay", l.RetryDelay)
 			if err := l.wait(ctx); err != nil {
 				return "", err
 			}
 		}
 	}
-	return "", fmt.Errorf("failed to generate after %d attempts", l.MaxRetries+1)
+	return "", fmt.Errorf("failed to generate after %d attempts: %w", l.MaxRetries+1, lastErr)
 }
 
 // wait implements a cancellable delay between retry attempts.
@@ -202,9 +356,26 @@ func (l *LLMImpl) wait(ctx context.Context) error {
 	select {
 	case <-ctx.Done():
 		return ctx.Err()
-	case <-time.After(l.RetryDelay):
+	case <-l.clock().After(l.RetryDelay):
+		return nil
+	}
+}
+
+// clock returns the clock of the LLM's config, the system clock by default.
+func (l *LLMImpl) clock() utils.Clock {
+	if l.config != nil && l.config.Clock != nil {
+		return l.config.Clock
+	}
+	return utils.SystemClock()
+}
+
+// waitForRateLimit blocks until the rate limit allows another request.
+// Returns the context's error if it is cancelled first.
+func (l *LLMImpl) waitForRateLimit(ctx context.Context) error {
+	if l.limiter == nil {
 		return nil
 	}
+	return l.limiter.Wait(ctx)
 }
 
 // attemptGenerate makes a single attempt to generate text using the provider.
@@ -216,7 +387,7 @@ func (l *LLMImpl) wait(ctx context.Context) error {
 //   - ErrorTypeAPI for provider API errors
 //   - ErrorTypeResponse for response processing issues
 //   - ErrorTypeRateLimit if provider rate limit is exceeded
-func (l *LLMImpl) attemptGenerate(ctx context.Context, prompt *Prompt) (string, error) {
+func (l *LLMImpl) attemptGenerate(ctx context.Context, prompt *Prompt, config *GenerateConfig) (string, error) {
 	// Create a new options map that includes both l.Options and prompt-specific options
 	options := make(map[string]interface{})
 
@@ -227,6 +398,18 @@ func (l *LLMImpl) attemptGenerate(ctx context.Context, prompt *Prompt) (string,
 	}
 	l.optionsMutex.RUnlock()
 
+	// Per-request options take precedence over the LLM's options
+	for k, v := range config.Options {
+		options[k] = v
+	}
+	l.addMetadataOptions(options, config.Metadata)
+	l.translateParams(options)
+	l.enforceDeterminism(options)
+
+	if config.N > 1 {
+		options["n"] = config.N
+	}
+
 	// Add Tools and ToolChoice to options
 	if len(prompt.Tools) > 0 {
 		options["tools"] = prompt.Tools
@@ -234,16 +417,18 @@ func (l *LLMImpl) attemptGenerate(ctx context.Context, prompt *Prompt) (string,
 	if len(prompt.ToolChoice) > 0 {
 		options["tool_choice"] = prompt.ToolChoice
 	}
+	if len(prompt.Images) > 0 {
+		options["images"] = prompt.Images
+	}
+	usesFiles, err := l.attachDocuments(ctx, options, prompt.Documents, config.DryRun != nil)
+	if err != nil {
+		return "", err
+	}
 
 	var reqBody []byte
-	var err error
-
-	// Check if we have structured messages
-	l.optionsMutex.RLock()
-	structuredMessages, hasStructuredMessages := l.Options["structured_messages"]
-	l.optionsMutex.RUnlock()
 
-	// Check if we have structured messages
+	// Check if we have structured messages, set on the LLM or per request
+	structuredMessages, hasStructuredMessages := options["structured_messages"]
 	if hasStructuredMessages {
 		// Use the structured messages API if the provider supports it
 		if prepareWithMessages, ok := l.Provider.(interface {
@@ -264,13 +449,16 @@ func (l *LLMImpl) attemptGenerate(ctx context.Context, prompt *Prompt) (string,
 			// Provider doesn't support structured messages, fall back to normal request
 			reqBody, err = l.Provider.PrepareRequest(prompt.String(), options)
 		}
+	} else if messages, ok := fewShotMessages(prompt); ok && supportsMessages(l.Provider) {
+		// Few-shot examples are sent as prior turns of the conversation
+		reqBody, err = l.Provider.PrepareRequestWithMessages(messages, options)
 	} else {
 		// Standard request preparation
 		reqBody, err = l.Provider.PrepareRequest(prompt.String(), options)
 	}
 
 	if err != nil {
-		return "", NewLLMError(ErrorTypeRequest, "failed to prepare request", err)
+		return "", newPrepareError("failed to prepare request", err)
 	}
 
 	l.logger.Debug("Full request body", "body", string(reqBody))
@@ -284,12 +472,22 @@ func (l *LLMImpl) attemptGenerate(ctx context.Context, prompt *Prompt) (string,
 		req.Header.Set(k, v)
 		l.logger.Debug("Request header", "provider", l.Provider.Name(), "key", k, "value", v)
 	}
+	l.setFileHeaders(req, usesFiles)
+	if config.DryRun != nil {
+		l.recordDryRun(req, reqBody, config)
+		return "", nil
+	}
+	cached := l.responseCache(prompt, reqBody, config)
+	if response, ok := cached.get(ctx); ok {
+		l.logger.Debug("Serving cached response", "provider", l.Provider.Name())
+		return response, nil
+	}
 	resp, err := l.client.Do(req)
 	if err != nil {
 		return "", NewLLMError(ErrorTypeRequest, "failed to send request", err)
 	}
 	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
+	body, err := providers.ReadResponseBody(resp.Body)
 	if err != nil {
 		return "", NewLLMError(ErrorTypeResponse, "failed to read response body", err)
 	}
@@ -299,7 +497,7 @@ func (l *LLMImpl) attemptGenerate(ctx context.Context, prompt *Prompt) (string,
 
 	if resp.StatusCode != http.StatusOK {
 		l.logger.Error("API error", "provider", l.Provider.Name(), "status", resp.StatusCode, "body", string(body))
-		return "", NewLLMError(ErrorTypeAPI, fmt.Sprintf("API error: status code %d", resp.StatusCode), nil)
+		return "", newAPIError(l.Provider.Name(), resp, body)
 	}
 
 	// Extract and log caching information
@@ -329,8 +527,27 @@ func (l *LLMImpl) attemptGenerate(ctx context.Context, prompt *Prompt) (string,
 
 	result, err := l.Provider.ParseResponse(body)
 	if err != nil {
-		return "", NewLLMError(ErrorTypeResponse, "failed to parse response", err)
+		return "", parseResponseError(err)
+	}
+	result = l.sanitize(result, config)
+	if config.Usage != nil {
+		*config.Usage, _ = parseUsage(fullResponse)
+	}
+	if config.Logprobs != nil {
+		*config.Logprobs = parseLogprobs(fullResponse)
 	}
+	if config.FinishReason != nil {
+		*config.FinishReason = parseFinishReason(fullResponse)
+	}
+	l.recordFingerprint(fullResponse, config)
+	l.recordCitations(body, config)
+	l.recordContentFilters(body, config)
+	if config.N > 1 {
+		if config.choices, err = l.Provider.(providers.ChoicesParser).ParseChoices(body); err != nil {
+			return "", NewLLMError(ErrorTypeResponse, "failed to parse choices", err)
+		}
+	}
+	cached.set(ctx, result)
 	l.logger.Debug("Text generated successfully", "result", result)
 	return result, nil
 }
@@ -347,26 +564,66 @@ func (l *LLMImpl) GenerateWithSchema(ctx context.Context, prompt *Prompt, schema
 	for _, opt := range opts {
 		opt(config)
 	}
+	if target, err := l.routed(config); err != nil {
+		return "", err
+	} else if target != nil {
+		return target.GenerateWithSchema(ctx, prompt, schema, routedOptions(opts)...)
+	}
+	promptProcessors, responseProcessors := l.processors(config)
+	prompt, err := processPrompt(ctx, prompt, promptProcessors)
+	if err != nil {
+		return "", err
+	}
+	if prompt.OutputFormat != "" {
+		responseProcessors = append([]ResponseProcessor{outputFormatProcessor(prompt.OutputFormat)}, responseProcessors...)
+	}
 
 	var result string
 	var lastErr error
 
+	if config.DryRun != nil {
+		_, _, err := l.attemptGenerateWithSchema(ctx, prompt, schema, config)
+		return "", err
+	}
+	if err := l.autoModerate(ctx, "prompt", prompt.String()); err != nil {
+		return "", err
+	}
 	for attempt := 0; attempt <= l.MaxRetries; attempt++ {
-		l.logger.Debug("Generating text with schema", "provider", l.Provider.Name(), "prompt", prompt.String(), "attempt", attempt+1)
+		l.logger.Debug("Generating text with schema", "provider", l.Provider.Name(), "prompt", prompt.String(), "metadata", config.Metadata, "attempt", attempt+1)
 
-		result, _, lastErr = l.attemptGenerateWithSchema(ctx, prompt.String(), schema)
+		if err := config.RetryBudget.take(lastErr); err != nil {
+			return "", err
+		}
+		if err := l.waitForRateLimit(ctx); err != nil {
+			return "", err
+		}
+		release, err := l.acquire(ctx, config.Priority)
+		if err != nil {
+			return "", err
+		}
+		result, _, lastErr = l.attemptGenerateWithSchema(ctx, prompt, schema, config)
+		release()
 		if lastErr == nil {
-			return result, nil
+			if err := l.autoModerate(ctx, "response", result); err != nil {
+				return "", err
+			}
+			return processResponse(ctx, prompt, result, responseProcessors)
 		}
 
-		l.logger.Warn("Generation attempt with schema failed", "error", lastErr, "attempt", attempt+1)
+		l.logger.Warn("Generation attempt with schema failed", "error", lastErr, "metadata", config.Metadata, "attempt", attempt+1)
+		if isContentFiltered(lastErr) {
+			return "", lastErr
+		}
 
 		if attempt < l.MaxRetries {
+			if err := config.RetryBudget.check(l.RetryDelay, lastErr); err != nil {
+				return "", err
+			}
 			l.logger.Debug("Retrying", "delay", l.RetryDelay)
 			select {
 			case <-ctx.Done():
 				return "", ctx.Err()
-			case <-time.After(l.RetryDelay):
+			case <-l.clock().After(l.RetryDelay):
 				// Continue to next attempt
 			}
 		}
@@ -383,10 +640,11 @@ func (l *LLMImpl) GenerateWithSchema(ctx context.Context, prompt *Prompt, schema
 //   - Full prompt used for generation
 //   - ErrorTypeInvalidInput for schema validation failures
 //   - Other error types as per attemptGenerate
-func (l *LLMImpl) attemptGenerateWithSchema(ctx context.Context, prompt string, schema interface{}) (string, string, error) {
+func (l *LLMImpl) attemptGenerateWithSchema(ctx context.Context, prompt *Prompt, schema interface{}, config *GenerateConfig) (string, string, error) {
 	var reqBody []byte
 	var err error
 	var fullPrompt string
+	input := prompt.String()
 
 	l.optionsMutex.RLock()
 	options := make(map[string]interface{})
@@ -394,17 +652,30 @@ func (l *LLMImpl) attemptGenerateWithSchema(ctx context.Context, prompt string,
 		options[k] = v
 	}
 	l.optionsMutex.RUnlock()
+	for k, v := range config.Options {
+		options[k] = v
+	}
+	l.addMetadataOptions(options, config.Metadata)
+	l.translateParams(options)
+	l.enforceDeterminism(options)
+	if len(prompt.Images) > 0 {
+		options["images"] = prompt.Images
+	}
+	usesFiles, err := l.attachDocuments(ctx, options, prompt.Documents, config.DryRun != nil)
+	if err != nil {
+		return "", input, err
+	}
 
 	if l.SupportsJSONSchema() {
-		reqBody, err = l.Provider.PrepareRequestWithSchema(prompt, options, schema)
-		fullPrompt = prompt
+		reqBody, err = l.Provider.PrepareRequestWithSchema(input, options, schema)
+		fullPrompt = input
 	} else {
-		fullPrompt = l.preparePromptWithSchema(prompt, schema)
+		fullPrompt = l.preparePromptWithSchema(input, schema)
 		reqBody, err = l.Provider.PrepareRequest(fullPrompt, options)
 	}
 
 	if err != nil {
-		return "", fullPrompt, NewLLMError(ErrorTypeRequest, "failed to prepare request", err)
+		return "", fullPrompt, newPrepareError("failed to prepare request", err)
 	}
 
 	l.logger.Debug("Request body", "provider", l.Provider.Name(), "body", string(reqBody))
@@ -417,6 +688,16 @@ func (l *LLMImpl) attemptGenerateWithSchema(ctx context.Context, prompt string,
 	for k, v := range l.Provider.Headers() {
 		req.Header.Set(k, v)
 	}
+	l.setFileHeaders(req, usesFiles)
+	if config.DryRun != nil {
+		l.recordDryRun(req, reqBody, config)
+		return "", fullPrompt, nil
+	}
+	cached := l.responseCache(prompt, reqBody, config)
+	if response, ok := cached.get(ctx); ok {
+		l.logger.Debug("Serving cached response", "provider", l.Provider.Name())
+		return response, fullPrompt, nil
+	}
 
 	resp, err := l.client.Do(req)
 	if err != nil {
@@ -424,26 +705,43 @@ func (l *LLMImpl) attemptGenerateWithSchema(ctx context.Context, prompt string,
 	}
 	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	body, err := providers.ReadResponseBody(resp.Body)
 	if err != nil {
 		return "", fullPrompt, NewLLMError(ErrorTypeResponse, "failed to read response body", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
 		l.logger.Error("API error", "provider", l.Provider.Name(), "status", resp.StatusCode, "body", string(body))
-		return "", fullPrompt, NewLLMError(ErrorTypeAPI, fmt.Sprintf("API error: status code %d", resp.StatusCode), nil)
+		return "", fullPrompt, newAPIError(l.Provider.Name(), resp, body)
 	}
 
 	result, err := l.Provider.ParseResponse(body)
 	if err != nil {
-		return "", fullPrompt, NewLLMError(ErrorTypeResponse, "failed to parse response", err)
+		return "", fullPrompt, parseResponseError(err)
 	}
+	result = l.sanitize(result, config)
 
 	// Validate the result against the schema
 	if err := ValidateAgainstSchema(result, schema); err != nil {
 		return "", fullPrompt, NewLLMError(ErrorTypeResponse, "response does not match schema", err)
 	}
 
+	if config.Usage != nil || config.Logprobs != nil || config.SystemFingerprint != nil || l.deterministic != nil {
+		var fullResponse map[string]interface{}
+		if err := json.Unmarshal(body, &fullResponse); err == nil {
+			if config.Usage != nil {
+				*config.Usage, _ = parseUsage(fullResponse)
+			}
+			if config.Logprobs != nil {
+				*config.Logprobs = parseLogprobs(fullResponse)
+			}
+			l.recordFingerprint(fullResponse, config)
+		}
+	}
+	l.recordCitations(body, config)
+	l.recordContentFilters(body, config)
+	cached.set(ctx, result)
+
 	l.logger.Debug("Text generated successfully", "result", result)
 	return result, fullPrompt, nil
 }
@@ -469,16 +767,41 @@ func (l *LLMImpl) Stream(ctx context.Context, prompt *Prompt, opts ...StreamOpti
 	// Apply stream options
 	config := &StreamConfig{
 		BufferSize: 100,
-		RetryStrategy: &DefaultRetryStrategy{
-			MaxRetries:  l.MaxRetries,
-			InitialWait: l.RetryDelay,
-			MaxWait:     l.RetryDelay * 10,
-		},
 	}
 	for _, opt := range opts {
 		opt(config)
 	}
+	// Streams are sent through the prompt processors of the LLM; response
+	// processors apply to whole responses only
+	promptProcessors, _ := l.processors(&GenerateConfig{})
+	prompt, err := processPrompt(ctx, prompt, promptProcessors)
+	if err != nil {
+		return nil, err
+	}
+	if err := l.waitForRateLimit(ctx); err != nil {
+		return nil, err
+	}
+	release, err := l.acquire(ctx, config.Priority)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := l.stream(ctx, prompt, config)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	stream = &queuedStream{TokenStream: stream, release: release}
+	if config.Backpressure != nil {
+		stream = BufferStream(ctx, stream, config.BufferSize, *config.Backpressure)
+	}
+	if len(config.StopConditions) > 0 {
+		stream = StopStream(stream, config.StopConditions...)
+	}
+	return stream, nil
+}
 
+// stream sends a streaming request and returns the provider's stream.
+func (l *LLMImpl) stream(ctx context.Context, prompt *Prompt, config *StreamConfig) (TokenStream, error) {
 	// Prepare request with streaming enabled
 	options := make(map[string]interface{})
 	l.optionsMutex.RLock()
@@ -486,11 +809,36 @@ func (l *LLMImpl) Stream(ctx context.Context, prompt *Prompt, opts ...StreamOpti
 		options[k] = v
 	}
 	l.optionsMutex.RUnlock()
+	for k, v := range config.Options {
+		options[k] = v
+	}
 	options["stream"] = true
+	l.addMetadataOptions(options, config.Metadata)
+	l.translateParams(options)
+	l.enforceDeterminism(options)
+	if len(prompt.Tools) > 0 {
+		options["tools"] = prompt.Tools
+	}
+	if len(prompt.ToolChoice) > 0 {
+		options["tool_choice"] = prompt.ToolChoice
+	}
+	if len(prompt.Images) > 0 {
+		options["images"] = prompt.Images
+	}
+	usesFiles, err := l.attachDocuments(ctx, options, prompt.Documents, false)
+	if err != nil {
+		return nil, err
+	}
 
-	body, err := l.Provider.PrepareStreamRequest(prompt.String(), options)
+	var body []byte
+	if messages, ok := options["structured_messages"].([]types.MemoryMessage); ok && len(messages) > 0 && supportsMessages(l.Provider) {
+		// The stream option makes the provider request a stream
+		body, err = l.Provider.PrepareRequestWithMessages(messages, options)
+	} else {
+		body, err = l.Provider.PrepareStreamRequest(prompt.String(), options)
+	}
 	if err != nil {
-		return nil, NewLLMError(ErrorTypeRequest, "failed to prepare stream request", err)
+		return nil, newPrepareError("failed to prepare stream request", err)
 	}
 
 	// Create request
@@ -503,6 +851,7 @@ func (l *LLMImpl) Stream(ctx context.Context, prompt *Prompt, opts ...StreamOpti
 	for k, v := range l.Provider.Headers() {
 		req.Header.Set(k, v)
 	}
+	l.setFileHeaders(req, usesFiles)
 
 	// Make request
 	resp, err := l.client.Do(req)
@@ -511,8 +860,10 @@ func (l *LLMImpl) Stream(ctx context.Context, prompt *Prompt, opts ...StreamOpti
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		resp.Body.Close()
-		return nil, NewLLMError(ErrorTypeAPI, fmt.Sprintf("API error: status code %d", resp.StatusCode), nil)
+		defer resp.Body.Close()
+		body, _ := providers.ReadResponseBody(resp.Body)
+		l.logger.Error("API error", "provider", l.Provider.Name(), "status", resp.StatusCode, "body", string(body))
+		return nil, newAPIError(l.Provider.Name(), resp, body)
 	}
 
 	// Create and return stream
@@ -526,46 +877,83 @@ func (l *LLMImpl) SupportsStreaming() bool {
 
 // providerStream implements TokenStream for a specific provider
 type providerStream struct {
-	decoder       *SSEDecoder
-	provider      providers.Provider
-	config        *StreamConfig
-	buffer        []byte
-	currentIndex  int
-	retryStrategy RetryStrategy
+	body         io.ReadCloser
+	decoder      *eventDecoder
+	provider     providers.Provider
+	config       *StreamConfig
+	buffer       []byte
+	currentIndex int
+	pending      []*StreamToken // Tool call tokens parsed from the same chunk
+	metadata     streamMetadata
+	finished     bool       // Whether the terminal token was returned
+	watchMu      sync.Mutex // Guards watched and unwatch, as Close may be concurrent
+	watched      context.Context
+	unwatch      func() bool // Stops watching the context
 }
 
 func newProviderStream(reader io.ReadCloser, provider providers.Provider, config *StreamConfig) *providerStream {
 	return &providerStream{
-		decoder:       NewSSEDecoder(reader),
-		provider:      provider,
-		config:        config,
-		buffer:        make([]byte, 0, 4096),
-		currentIndex:  0,
-		retryStrategy: config.RetryStrategy,
+		body:         reader,
+		decoder:      newEventDecoder(reader),
+		provider:     provider,
+		config:       config,
+		buffer:       make([]byte, 0, 4096),
+		currentIndex: 0,
 	}
 }
 
 func (s *providerStream) Next(ctx context.Context) (*StreamToken, error) {
+	if len(s.pending) > 0 {
+		token := s.pending[0]
+		s.pending = s.pending[1:]
+		return token, nil
+	}
+	if s.finished {
+		return nil, io.EOF
+	}
+
+	s.watch(ctx)
+
 	for {
 		select {
 		case <-ctx.Done():
+			s.body.Close()
 			return nil, ctx.Err()
 		default:
 			if !s.decoder.Next() {
 				if err := s.decoder.Err(); err != nil {
-					if s.retryStrategy.ShouldRetry(err) {
-						time.Sleep(s.retryStrategy.NextDelay())
-						continue
+					// The decoder stops at its first error: the rest of an
+					// interrupted response cannot be read again
+					s.decoder.Release()
+					if ctx.Err() != nil {
+						return nil, ctx.Err()
 					}
 					return nil, err
 				}
-				return nil, io.EOF
+				return s.finish(), nil
 			}
 
 			event := s.decoder.Event()
 			if len(event.Data) == 0 {
 				continue
 			}
+			s.metadata.update(event.Data)
+
+			// Tool call deltas are surfaced as typed tokens before any text
+			if streamer, ok := s.provider.(providers.ToolCallStreamer); ok {
+				if deltas, err := streamer.ParseStreamToolCalls(event.Data); err == nil && len(deltas) > 0 {
+					for i := range deltas {
+						s.pending = append(s.pending, &StreamToken{
+							Type:     TokenTypeToolCall,
+							Index:    s.currentIndex,
+							ToolCall: &deltas[i],
+						})
+					}
+					token := s.pending[0]
+					s.pending = s.pending[1:]
+					return token, nil
+				}
+			}
 
 			// Process the event
 			token, err := s.provider.ParseStreamResponse(event.Data)
@@ -574,7 +962,8 @@ func (s *providerStream) Next(ctx context.Context) (*StreamToken, error) {
 					continue
 				}
 				if err == io.EOF {
-					return nil, io.EOF
+					s.drain(event.Data)
+					return s.finish(), nil
 				}
 				continue // Not enough data or malformed
 			}
@@ -589,6 +978,51 @@ func (s *providerStream) Next(ctx context.Context) (*StreamToken, error) {
 	}
 }
 
+// watch closes the body once ctx is cancelled, which aborts a read blocked
+// waiting for the provider. Streams are usually read with a single context,
+// watched once.
+func (s *providerStream) watch(ctx context.Context) {
+	s.watchMu.Lock()
+	defer s.watchMu.Unlock()
+	if ctx == s.watched || ctx.Done() == nil {
+		return
+	}
+	if s.unwatch != nil {
+		s.unwatch()
+	}
+	s.watched = ctx
+	s.unwatch = context.AfterFunc(ctx, func() { s.body.Close() })
+}
+
+// drain reads the events following the end of the response up to the
+// [DONE] marker, which carry the usage for OpenAI.
+func (s *providerStream) drain(last []byte) {
+	if bytes.Equal(bytes.TrimSpace(last), []byte("[DONE]")) {
+		return
+	}
+	for s.decoder.Next() {
+		data := s.decoder.Event().Data
+		if bytes.Equal(bytes.TrimSpace(data), []byte("[DONE]")) {
+			return
+		}
+		s.metadata.update(data)
+	}
+}
+
+// finish returns the terminal token; the next call to Next returns io.EOF.
+func (s *providerStream) finish() *StreamToken {
+	s.finished = true
+	s.decoder.Release()
+	return s.metadata.done(s.currentIndex)
+}
+
+// Close closes the response body, dropping the connection if the response
+// is still being generated.
 func (s *providerStream) Close() error {
-	return nil
+	s.watchMu.Lock()
+	if s.unwatch != nil {
+		s.unwatch()
+	}
+	s.watchMu.Unlock()
+	return s.body.Close()
 }
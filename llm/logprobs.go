@@ -0,0 +1,87 @@
+package llm
+
+import "math"
+
+// TokenLogprob is the log probability of a generated token and, when
+// requested with config.SetLogprobs(topN), of the most likely alternatives
+// at its position.
+type TokenLogprob struct {
+	Token       string       `json:"token"`
+	Logprob     float64      `json:"logprob"`
+	Bytes       []int        `json:"bytes,omitempty"` // UTF-8 bytes of the token, which may be a partial character
+	TopLogprobs []TopLogprob `json:"top_logprobs,omitempty"`
+}
+
+// TopLogprob is an alternative token at a position of the response.
+type TopLogprob struct {
+	Token   string  `json:"token"`
+	Logprob float64 `json:"logprob"`
+}
+
+// Probability returns the probability of the token, between 0 and 1.
+func (t TokenLogprob) Probability() float64 {
+	return math.Exp(t.Logprob)
+}
+
+// WithLogprobs records the log probabilities of the generated tokens into out
+// once the request succeeds. The LLM must be configured with
+// config.SetLogprobs and its provider support them (OpenAI and
+// OpenAI-compatible servers such as vLLM or Fireworks); otherwise out is left
+// empty.
+//
+// Example:
+//
+//	var logprobs []llm.TokenLogprob
+//	response, err := l.Generate(ctx, prompt, llm.WithLogprobs(&logprobs))
+//	for _, t := range logprobs {
+//	    fmt.Printf("%q %.2f\n", t.Token, t.Probability())
+//	}
+func WithLogprobs(out *[]TokenLogprob) GenerateOption {
+	return func(c *GenerateConfig) {
+		c.Logprobs = out
+	}
+}
+
+// parseLogprobs extracts the token log probabilities from a decoded response
+// in the OpenAI chat completions format.
+func parseLogprobs(response map[string]interface{}) []TokenLogprob {
+	choices, ok := response["choices"].([]interface{})
+	if !ok || len(choices) == 0 {
+		return nil
+	}
+	choice, _ := choices[0].(map[string]interface{})
+	logprobs, _ := choice["logprobs"].(map[string]interface{})
+	content, _ := logprobs["content"].([]interface{})
+
+	var tokens []TokenLogprob
+	for _, c := range content {
+		entry, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		token := TokenLogprob{}
+		token.Token, _ = entry["token"].(string)
+		token.Logprob, _ = entry["logprob"].(float64)
+		if bytes, ok := entry["bytes"].([]interface{}); ok {
+			for _, b := range bytes {
+				if n, ok := b.(float64); ok {
+					token.Bytes = append(token.Bytes, int(n))
+				}
+			}
+		}
+		if top, ok := entry["top_logprobs"].([]interface{}); ok {
+			for _, t := range top {
+				alt, ok := t.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				var tl TopLogprob
+				tl.Token, _ = alt["token"].(string)
+				tl.Logprob, _ = alt["logprob"].(float64)
+				token.TopLogprobs = append(token.TopLogprobs, tl)
+			}
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens
+}
@@ -0,0 +1,61 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm/config"
+	"github.com/teilomillet/gollm/providers"
+	"github.com/teilomillet/gollm/utils"
+)
+
+func TestLogprobs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		assert.Equal(t, true, request["logprobs"])
+		assert.Equal(t, float64(2), request["top_logprobs"])
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"choices": [{
+				"message": {"role": "assistant", "content": "Yes"},
+				"logprobs": {"content": [{
+					"token": "Yes",
+					"logprob": -0.1,
+					"bytes": [89, 101, 115],
+					"top_logprobs": [{"token": "Yes", "logprob": -0.1}, {"token": "No", "logprob": -2.4}]
+				}]}
+			}],
+			"usage": {"prompt_tokens": 5, "completion_tokens": 1, "total_tokens": 6}
+		}`))
+	}))
+	defer server.Close()
+
+	provider := &localOpenAIProvider{OpenAIProvider: providers.NewOpenAIProvider("fake-key", "gpt-4o", nil).(*providers.OpenAIProvider), url: server.URL}
+	cfg := config.NewConfig()
+	config.SetLogprobs(2)(cfg)
+	provider.SetDefaultOptions(cfg)
+
+	l := &LLMImpl{
+		Provider: provider,
+		Options:  make(map[string]interface{}),
+		client:   server.Client(),
+		logger:   utils.NewLogger(utils.LogLevelOff),
+	}
+
+	var logprobs []TokenLogprob
+	response, err := l.Generate(context.Background(), NewPrompt("Is the sky blue?"), WithLogprobs(&logprobs))
+	require.NoError(t, err)
+	assert.Equal(t, "Yes", response)
+	require.Len(t, logprobs, 1)
+	assert.Equal(t, "Yes", logprobs[0].Token)
+	assert.Equal(t, []int{89, 101, 115}, logprobs[0].Bytes)
+	assert.InDelta(t, 0.905, logprobs[0].Probability(), 0.001)
+	assert.Equal(t, []TopLogprob{{Token: "Yes", Logprob: -0.1}, {Token: "No", Logprob: -2.4}}, logprobs[0].TopLogprobs)
+}
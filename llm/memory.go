@@ -28,6 +28,8 @@ type Memory struct {
 	maxTokens   int                   // Maximum allowed tokens
 	encoding    *tiktoken.Tiktoken    // Token encoder for the model
 	logger      utils.Logger          // Logger for debugging and monitoring
+	strategy    MemoryStrategy        // Trims the messages before each request, nil to drop the oldest on Add
+	facts       []memoryFact          // Key/value facts sent in the system prompt, in the order set
 }
 
 // NewMemory creates a new Memory instance with the specified token limit and model.
@@ -123,16 +125,64 @@ func (m *Memory) truncate() {
 }
 
 // truncateIfNeeded truncates messages if the total token count exceeds the maxTokens.
-// This is called automatically by Add when necessary.
+// Pinned messages are kept. This is called automatically by Add when necessary.
+// Memories with a strategy are trimmed before each request instead.
 func (m *Memory) truncateIfNeeded() {
+	if m.strategy != nil {
+		return
+	}
 	for m.totalTokens > m.maxTokens && len(m.messages) > 1 {
-		removed := m.messages[0]
-		m.messages = m.messages[1:]
+		i := 0
+		for i < len(m.messages)-1 && m.messages[i].Pinned() {
+			i++
+		}
+		if i == len(m.messages)-1 {
+			break // Only pinned messages and the latest one are left
+		}
+		removed := m.messages[i]
+		m.messages = append(m.messages[:i:i], m.messages[i+1:]...)
 		m.totalTokens -= removed.Tokens
 		m.logger.Debug("Removed message from memory", "role", removed.Role, "tokens", removed.Tokens, "total_tokens", m.totalTokens)
 	}
 }
 
+// SetStrategy sets the strategy trimming the memory before each request; nil
+// restores dropping the oldest messages once the token limit is exceeded.
+func (m *Memory) SetStrategy(strategy MemoryStrategy) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.strategy = strategy
+	m.truncateIfNeeded()
+}
+
+// trim applies the strategy of the memory, if any. Should the strategy fail,
+// the memory is trimmed like TokenWindow.
+func (m *Memory) trim(ctx context.Context) {
+	m.mutex.Lock()
+	strategy := m.strategy
+	messages := append([]types.MemoryMessage(nil), m.messages...)
+	m.mutex.Unlock()
+	if strategy == nil {
+		return
+	}
+
+	// The strategy may call a model, so it runs without the lock
+	kept, err := strategy.Trim(ctx, messages, m.maxTokens)
+	if err != nil {
+		m.logger.Warn("Memory strategy failed, dropping the oldest messages", "error", err)
+		kept = dropOldest(messages, m.maxTokens)
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if len(m.messages) < len(messages) {
+		return // The memory was cleared or rewound meanwhile
+	}
+	m.messages = append(kept[:len(kept):len(kept)], m.messages[len(messages):]...)
+	m.totalTokens = countTokens(m.messages)
+	m.logger.Debug("Trimmed memory", "messages", len(m.messages), "total_tokens", m.totalTokens)
+}
+
 // GetPrompt returns the full conversation history as a formatted string.
 // Messages are formatted as "role: content" with newlines between them.
 // This operation is thread-safe.
@@ -167,6 +217,7 @@ func (m *Memory) GetMessages() []types.MemoryMessage {
 }
 
 // Clear removes all messages from memory and resets the token count.
+// Pinned messages are removed too; facts are kept.
 // This operation is thread-safe.
 func (m *Memory) Clear() {
 	m.mutex.Lock()
@@ -265,24 +316,27 @@ func NewLLMWithMemory(llm LLM, maxTokens int, model string) (LLM, error) {
 //   - Generated text response
 //   - Error types as per the base LLM's Generate method
 func (l *LLMWithMemory) Generate(ctx context.Context, prompt *Prompt, opts ...GenerateOption) (string, error) {
-	// Add user message to memory
-	l.memory.Add("user", prompt.Input)
+	// A dry run leaves the memory as is
+	dryRun := isDryRun(opts)
+	if !dryRun {
+		// Add user message to memory
+		l.memory.Add("user", prompt.Input)
+		l.memory.trim(ctx)
+	}
 
 	var response string
 	var err error
 
 	if l.useStructuredMessages {
 		// Get structured messages from memory
-		messages := l.memory.GetMessages()
+		messages := requestMessages(l.memory.GetMessages())
+		if dryRun {
+			messages = append(messages, types.MemoryMessage{Role: "user", Content: prompt.Input})
+		}
 
 		// Make a copy of the original prompt with empty input
 		// (since content will be in structured messages)
-		emptyPrompt := &Prompt{
-			SystemPrompt: prompt.SystemPrompt,
-			Tools:        prompt.Tools,
-			ToolChoice:   prompt.ToolChoice,
-			Input:        "", // Empty as content is in messages
-		}
+		emptyPrompt := l.requestPrompt(prompt, "")
 
 		// Add structured messages to the options
 		withMessages := func(config *GenerateConfig) {
@@ -291,7 +345,7 @@ func (l *LLMWithMemory) Generate(ctx context.Context, prompt *Prompt, opts ...Ge
 		}
 
 		// Set structured messages option
-		l.LLM.SetOption("structured_messages", messages)
+		l.LLM.SetOption("structured_messages", turnMessages(messages, emptyPrompt, prompt.Input))
 
 		// Generate with structured messages
 		response, err = l.LLM.Generate(ctx, emptyPrompt, append(opts, withMessages)...)
@@ -301,19 +355,15 @@ func (l *LLMWithMemory) Generate(ctx context.Context, prompt *Prompt, opts ...Ge
 	} else {
 		// Fallback to traditional flattened prompt approach
 		fullPrompt := l.memory.GetPrompt()
-
-		// Create a new Prompt with the full memory context
-		memoryPrompt := &Prompt{
-			SystemPrompt: prompt.SystemPrompt,
-			Tools:        prompt.Tools,
-			ToolChoice:   prompt.ToolChoice,
-			Input:        fullPrompt,
+		if dryRun {
+			fullPrompt += fmt.Sprintf("user: %s\n", prompt.Input)
 		}
 
-		response, err = l.LLM.Generate(ctx, memoryPrompt, opts...)
+		// Create a new Prompt with the full memory context
+		response, err = l.LLM.Generate(ctx, l.requestPrompt(prompt, fullPrompt), opts...)
 	}
 
-	if err != nil {
+	if err != nil || dryRun {
 		return "", err
 	}
 
@@ -322,6 +372,44 @@ func (l *LLMWithMemory) Generate(ctx context.Context, prompt *Prompt, opts ...Ge
 	return response, nil
 }
 
+// requestPrompt returns a copy of the caller's prompt sending input, with the
+// facts of the memory added to its system layers. The messages of the prompt
+// are dropped: the memory holds the conversation.
+func (l *LLMWithMemory) requestPrompt(prompt *Prompt, input string) *Prompt {
+	p := prompt.clone()
+	p.Input = input
+	p.Messages = nil
+	p.SystemLayers = l.memory.withFacts(prompt.SystemLayers)
+	return p
+}
+
+// turnMessages returns the conversation sent with a prompt: its few-shot
+// examples as prior turns, then the messages, the last user message carrying
+// the text of the prompt around input, such as its output format. The system
+// prompt is left to the system_prompt option unless placed in the user turn.
+func turnMessages(messages []types.MemoryMessage, prompt *Prompt, input string) []types.MemoryMessage {
+	turn := *prompt
+	turn.Input = input
+	turn.FewShot = nil
+	if turn.SystemPlacement != SystemPlacementUser {
+		turn.SystemPrompt, turn.SystemLayers = "", nil
+	}
+
+	examples := prompt.fewShotExamples()
+	result := make([]types.MemoryMessage, 0, 2*len(examples)+len(messages))
+	for _, example := range examples {
+		result = append(result,
+			types.MemoryMessage{Role: "user", Content: example.Input},
+			types.MemoryMessage{Role: "assistant", Content: example.Output},
+		)
+	}
+	result = append(result, messages...)
+	if last := len(result) - 1; last >= 0 && result[last].Role == "user" {
+		result[last].Content = turn.String()
+	}
+	return result
+}
+
 // SetUseStructuredMessages configures whether to use structured messages.
 // When enabled, messages are passed to the provider as structured objects.
 // When disabled, messages are flattened into a single text prompt.
@@ -332,6 +420,13 @@ func (l *LLMWithMemory) SetUseStructuredMessages(use bool) {
 	l.useStructuredMessages = use
 }
 
+// SetMemoryStrategy sets the strategy trimming the conversation before each
+// request, e.g. LastTurns(5); nil restores dropping the oldest messages once
+// the token limit is exceeded.
+func (l *LLMWithMemory) SetMemoryStrategy(strategy MemoryStrategy) {
+	l.memory.SetStrategy(strategy)
+}
+
 // ClearMemory removes all messages from the conversation history.
 func (l *LLMWithMemory) ClearMemory() {
 	l.memory.Clear()
@@ -358,16 +453,19 @@ func (l *LLMWithMemory) GetMemory() []types.MemoryMessage {
 //   - Generated text response
 //   - Error types as per the base LLM's GenerateWithSchema method
 func (l *LLMWithMemory) GenerateWithSchema(ctx context.Context, prompt *Prompt, schema interface{}, opts ...GenerateOption) (string, error) {
-	l.memory.Add("user", prompt.Input)
+	// A dry run leaves the memory as is
+	dryRun := isDryRun(opts)
+	if !dryRun {
+		l.memory.Add("user", prompt.Input)
+		l.memory.trim(ctx)
+	}
 	fullPrompt := l.memory.GetPrompt()
-
-	memoryPrompt := &Prompt{
-		Input: fullPrompt,
-		// Copy other fields from the original prompt if needed
+	if dryRun {
+		fullPrompt += fmt.Sprintf("user: %s\n", prompt.Input)
 	}
 
-	response, err := l.LLM.GenerateWithSchema(ctx, memoryPrompt, schema, opts...)
-	if err != nil {
+	response, err := l.LLM.GenerateWithSchema(ctx, l.requestPrompt(prompt, fullPrompt), schema, opts...)
+	if err != nil || dryRun {
 		return "", err
 	}
 
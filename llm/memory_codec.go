@@ -0,0 +1,192 @@
+package llm
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/teilomillet/gollm/types"
+)
+
+// MemoryCodec serializes conversation memory to persist it, e.g. as JSON,
+// protobuf or an encrypted blob.
+type MemoryCodec interface {
+	// Marshal encodes the messages of a conversation.
+	Marshal(messages []types.MemoryMessage) ([]byte, error)
+
+	// Unmarshal decodes the messages encoded by Marshal.
+	Unmarshal(data []byte) ([]types.MemoryMessage, error)
+}
+
+// PersistentMemory is implemented by LLMs whose conversation memory can be
+// saved and restored, e.g. to resume a chat in another process.
+type PersistentMemory interface {
+	// SaveMemory writes the conversation to w, encoded by codec.
+	SaveMemory(w io.Writer, codec MemoryCodec) error
+
+	// LoadMemory replaces the conversation with the one read from r.
+	LoadMemory(r io.Reader, codec MemoryCodec) error
+}
+
+// JSONMemoryCodec encodes memory as a JSON array of messages. It is the
+// codec used when none is given. Metadata values are decoded as JSON values,
+// so a time.Time becomes a string.
+type JSONMemoryCodec struct{}
+
+// memoryRecord is the JSON form of a message.
+type memoryRecord struct {
+	Role         string                 `json:"role"`
+	Content      string                 `json:"content"`
+	Tokens       int                    `json:"tokens,omitempty"`
+	CacheControl string                 `json:"cache_control,omitempty"`
+	Metadata     map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// Marshal encodes the messages as JSON.
+func (JSONMemoryCodec) Marshal(messages []types.MemoryMessage) ([]byte, error) {
+	records := make([]memoryRecord, len(messages))
+	for i, m := range messages {
+		records[i] = memoryRecord(m)
+	}
+	return json.Marshal(records)
+}
+
+// Unmarshal decodes messages encoded as JSON.
+func (JSONMemoryCodec) Unmarshal(data []byte) ([]types.MemoryMessage, error) {
+	var records []memoryRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("failed to decode memory: %w", err)
+	}
+	messages := make([]types.MemoryMessage, len(records))
+	for i, r := range records {
+		messages[i] = types.MemoryMessage(r)
+	}
+	return messages, nil
+}
+
+// encryptedMemoryCodec seals the output of another codec with AES-GCM.
+type encryptedMemoryCodec struct {
+	codec MemoryCodec
+	aead  cipher.AEAD
+}
+
+// NewEncryptedMemoryCodec returns a codec encrypting the output of codec, or
+// of JSONMemoryCodec when nil, with AES-GCM under key, so that sensitive
+// conversations are stored encrypted at rest. The key must be 16, 24 or 32
+// bytes long, selecting AES-128, AES-192 or AES-256. Loading memory saved
+// under another key fails.
+//
+// Example usage:
+//
+//	codec, err := llm.NewEncryptedMemoryCodec(nil, key) // key from a secrets manager
+//	err = memoryLLM.SaveMemory(file, codec)
+func NewEncryptedMemoryCodec(codec MemoryCodec, key []byte) (MemoryCodec, error) {
+	if codec == nil {
+		codec = JSONMemoryCodec{}
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid memory encryption key: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &encryptedMemoryCodec{codec: codec, aead: aead}, nil
+}
+
+// Marshal encodes the messages and encrypts them behind a random nonce.
+func (c *encryptedMemoryCodec) Marshal(messages []types.MemoryMessage) ([]byte, error) {
+	plaintext, err := c.codec.Marshal(messages)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, c.aead.NonceSize(), c.aead.NonceSize()+len(plaintext)+c.aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return c.aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// Unmarshal decrypts and decodes messages encoded by Marshal.
+func (c *encryptedMemoryCodec) Unmarshal(data []byte) ([]types.MemoryMessage, error) {
+	if len(data) < c.aead.NonceSize() {
+		return nil, fmt.Errorf("failed to decrypt memory: data too short")
+	}
+	nonce, ciphertext := data[:c.aead.NonceSize()], data[c.aead.NonceSize():]
+	plaintext, err := c.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt memory: %w", err)
+	}
+	return c.codec.Unmarshal(plaintext)
+}
+
+// Save writes the messages to w, encoded by codec, or as JSON when codec is
+// nil. Facts are saved first, as system messages with their key in the
+// metadata under MemoryFactKey.
+func (m *Memory) Save(w io.Writer, codec MemoryCodec) error {
+	if codec == nil {
+		codec = JSONMemoryCodec{}
+	}
+	data, err := codec.Marshal(append(m.factMessages(), m.GetMessages()...))
+	if err != nil {
+		return fmt.Errorf("failed to encode memory: %w", err)
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// Load replaces the messages with those read from r, decoded by codec, or
+// as JSON when codec is nil, and the facts with those saved. Messages without
+// a token count are counted, and the oldest are truncated if the conversation
+// exceeds the token limit.
+func (m *Memory) Load(r io.Reader, codec MemoryCodec) error {
+	if codec == nil {
+		codec = JSONMemoryCodec{}
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("failed to read memory: %w", err)
+	}
+	messages, err := codec.Unmarshal(data)
+	if err != nil {
+		return err
+	}
+	messages, facts := splitFacts(messages)
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.messages = messages
+	m.facts = facts
+	m.totalTokens = 0
+	for i := range m.messages {
+		if m.messages[i].Tokens == 0 && m.messages[i].Content != "" {
+			m.messages[i].Tokens = len(m.encoding.Encode(m.messages[i].Content, nil, nil))
+		}
+		m.totalTokens += m.messages[i].Tokens
+	}
+	m.truncateIfNeeded()
+	m.logger.Debug("Loaded memory", "messages", len(m.messages), "total_tokens", m.totalTokens)
+	return nil
+}
+
+// SaveMemory writes the conversation to w, encoded by codec, or as JSON when
+// codec is nil.
+//
+// Example usage:
+//
+//	f, _ := os.Create("chat.json")
+//	defer f.Close()
+//	err := memoryLLM.SaveMemory(f, nil)
+func (l *LLMWithMemory) SaveMemory(w io.Writer, codec MemoryCodec) error {
+	return l.memory.Save(w, codec)
+}
+
+// LoadMemory replaces the conversation with the one read from r, decoded by
+// codec, or as JSON when codec is nil.
+func (l *LLMWithMemory) LoadMemory(r io.Reader, codec MemoryCodec) error {
+	return l.memory.Load(r, codec)
+}
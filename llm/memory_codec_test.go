@@ -0,0 +1,98 @@
+package llm
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm/types"
+	"github.com/teilomillet/gollm/utils"
+)
+
+// lineCodec is a custom codec storing each message as a "role|content" line.
+type lineCodec struct{}
+
+func (lineCodec) Marshal(messages []types.MemoryMessage) ([]byte, error) {
+	var b strings.Builder
+	for _, m := range messages {
+		b.WriteString(m.Role + "|" + m.Content + "\n")
+	}
+	return []byte(b.String()), nil
+}
+
+func (lineCodec) Unmarshal(data []byte) ([]types.MemoryMessage, error) {
+	var messages []types.MemoryMessage
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		role, content, _ := strings.Cut(line, "|")
+		messages = append(messages, types.MemoryMessage{Role: role, Content: content, Tokens: 1})
+	}
+	return messages, nil
+}
+
+func TestMemoryCodec(t *testing.T) {
+	newMemory := func(maxTokens int, messages ...types.MemoryMessage) *Memory {
+		m := &Memory{maxTokens: maxTokens, logger: utils.NewLogger(utils.LogLevelOff)}
+		for _, message := range messages {
+			m.messages = append(m.messages, message)
+			m.totalTokens += message.Tokens
+		}
+		return m
+	}
+	conversation := []types.MemoryMessage{
+		{Role: "user", Content: "My card number is 4242", Tokens: 6},
+		{Role: "assistant", Content: "Noted.", Tokens: 2, CacheControl: "ephemeral", Metadata: map[string]interface{}{"turn": "1"}},
+	}
+
+	t.Run("JSON", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, newMemory(100, conversation...).Save(&buf, nil))
+		assert.Contains(t, buf.String(), `"cache_control":"ephemeral"`)
+
+		restored := newMemory(100)
+		require.NoError(t, restored.Load(&buf, nil))
+		assert.Equal(t, conversation, restored.GetMessages())
+		assert.Equal(t, 8, restored.totalTokens)
+	})
+
+	t.Run("Custom", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, newMemory(100, conversation...).Save(&buf, lineCodec{}))
+		assert.Equal(t, "user|My card number is 4242\nassistant|Noted.\n", buf.String())
+
+		restored := newMemory(100)
+		require.NoError(t, restored.Load(&buf, lineCodec{}))
+		assert.Equal(t, "Noted.", restored.GetMessages()[1].Content)
+	})
+
+	t.Run("LoadTruncates", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, newMemory(100, conversation...).Save(&buf, nil))
+		restored := newMemory(4)
+		require.NoError(t, restored.Load(&buf, nil))
+		assert.Equal(t, []types.MemoryMessage{conversation[1]}, restored.GetMessages())
+	})
+
+	t.Run("Encrypted", func(t *testing.T) {
+		key := bytes.Repeat([]byte{7}, 32)
+		codec, err := NewEncryptedMemoryCodec(nil, key)
+		require.NoError(t, err)
+		var buf bytes.Buffer
+		require.NoError(t, newMemory(100, conversation...).Save(&buf, codec))
+		assert.NotContains(t, buf.String(), "4242", "the conversation is encrypted at rest")
+		saved := buf.Bytes()
+
+		restored := newMemory(100)
+		require.NoError(t, restored.Load(bytes.NewReader(saved), codec))
+		assert.Equal(t, conversation, restored.GetMessages())
+
+		other, err := NewEncryptedMemoryCodec(nil, bytes.Repeat([]byte{8}, 32))
+		require.NoError(t, err)
+		assert.ErrorContains(t, newMemory(100).Load(bytes.NewReader(saved), other), "failed to decrypt memory")
+		assert.Error(t, newMemory(100).Load(strings.NewReader("x"), codec))
+
+		_, err = NewEncryptedMemoryCodec(nil, []byte("short"))
+		assert.ErrorContains(t, err, "invalid memory encryption key")
+	})
+}
@@ -0,0 +1,95 @@
+package llm
+
+import (
+	"context"
+
+	"github.com/teilomillet/gollm/types"
+)
+
+// ConversationEditor is implemented by LLMs with conversation memory that
+// can redo the last turn, e.g. for the regenerate and edit buttons of a chat.
+type ConversationEditor interface {
+	// RegenerateLast replaces the last response with a new one.
+	RegenerateLast(ctx context.Context, opts ...GenerateOption) (string, error)
+
+	// EditAndRegenerate replaces the last user message and its response.
+	EditAndRegenerate(ctx context.Context, newUserMessage string, opts ...GenerateOption) (string, error)
+}
+
+// memorySnapshot is the state of a Memory, to undo changes.
+type memorySnapshot struct {
+	messages    []types.MemoryMessage
+	totalTokens int
+}
+
+func (m *Memory) snapshot() memorySnapshot {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return memorySnapshot{messages: append([]types.MemoryMessage(nil), m.messages...), totalTokens: m.totalTokens}
+}
+
+func (m *Memory) restore(s memorySnapshot) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.messages, m.totalTokens = s.messages, s.totalTokens
+}
+
+// rewind removes the last user message and the messages after it, returning
+// the user message, or false if the conversation has none.
+func (m *Memory) rewind() (types.MemoryMessage, bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		if m.messages[i].Role != "user" {
+			continue
+		}
+		last := m.messages[i]
+		for _, removed := range m.messages[i:] {
+			m.totalTokens -= removed.Tokens
+		}
+		m.logger.Debug("Rewound memory", "removed", len(m.messages)-i, "total_tokens", m.totalTokens)
+		m.messages = m.messages[:i]
+		return last, true
+	}
+	return types.MemoryMessage{}, false
+}
+
+// RegenerateLast removes the last user message and the response to it from
+// the conversation and generates again from that message, so the memory
+// holds the new response in place of the old one and its token count only
+// includes the messages kept. The conversation is left unchanged if
+// generation fails or is a dry run.
+//
+// Example usage:
+//
+//	response, err := memoryLLM.RegenerateLast(ctx, llm.SetTemperature(1.0))
+func (l *LLMWithMemory) RegenerateLast(ctx context.Context, opts ...GenerateOption) (string, error) {
+	snapshot := l.memory.snapshot()
+	last, ok := l.memory.rewind()
+	if !ok {
+		return "", NewLLMError(ErrorTypeInvalidInput, "no user message to regenerate", nil)
+	}
+	return l.regenerate(ctx, snapshot, last.Content, opts)
+}
+
+// EditAndRegenerate replaces the last user message with newUserMessage,
+// dropping the response to it, and generates a response to the edited
+// message. The conversation is left unchanged if generation fails or is a
+// dry run.
+func (l *LLMWithMemory) EditAndRegenerate(ctx context.Context, newUserMessage string, opts ...GenerateOption) (string, error) {
+	snapshot := l.memory.snapshot()
+	if _, ok := l.memory.rewind(); !ok {
+		return "", NewLLMError(ErrorTypeInvalidInput, "no user message to edit", nil)
+	}
+	return l.regenerate(ctx, snapshot, newUserMessage, opts)
+}
+
+// regenerate generates a response to input on the rewound conversation,
+// restoring snapshot if the conversation should not change.
+func (l *LLMWithMemory) regenerate(ctx context.Context, snapshot memorySnapshot, input string, opts []GenerateOption) (string, error) {
+	response, err := l.Generate(ctx, l.NewPrompt(input), opts...)
+	if err != nil || isDryRun(opts) {
+		l.memory.restore(snapshot)
+	}
+	return response, err
+}
@@ -0,0 +1,112 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/pkoukk/tiktoken-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm/providers"
+	"github.com/teilomillet/gollm/types"
+	"github.com/teilomillet/gollm/utils"
+)
+
+// newOfflineMemoryLLM wraps the mock LLM with a memory counting a token per
+// byte, as tiktoken downloads its encodings on first use.
+func newOfflineMemoryLLM(t *testing.T) (*LLMWithMemory, *providers.MockProvider) {
+	ranks := make(map[string]int, 256)
+	for b := 0; b < 256; b++ {
+		ranks[string([]byte{byte(b)})] = b
+	}
+	special := map[string]int{"<|endoftext|>": 256}
+	bpe, err := tiktoken.NewCoreBPE(ranks, special, `\s+|\S+`)
+	require.NoError(t, err)
+	encoding := tiktoken.NewTiktoken(bpe, &tiktoken.Encoding{Name: "bytes", MergeableRanks: ranks, SpecialTokens: special}, map[string]any{})
+
+	base := newMockLLM(t)
+	memory := &Memory{maxTokens: 1000, encoding: encoding, logger: utils.NewLogger(utils.LogLevelOff)}
+	return &LLMWithMemory{LLM: base, memory: memory, useStructuredMessages: true}, base.Provider.(*providers.MockProvider)
+}
+
+func TestRegenerate(t *testing.T) {
+	ctx := context.Background()
+	roles := func(messages []types.MemoryMessage) []string {
+		var r []string
+		for _, m := range messages {
+			r = append(r, m.Role+": "+m.Content)
+		}
+		return r
+	}
+	totalTokens := func(m *Memory) int {
+		sum := 0
+		for _, message := range m.GetMessages() {
+			sum += message.Tokens
+		}
+		return sum
+	}
+
+	t.Run("RegenerateLast", func(t *testing.T) {
+		l, mock := newOfflineMemoryLLM(t)
+		mock.QueueResponse("Paris")
+		mock.QueueResponse("Lyon is the answer")
+		mock.QueueResponse("It is Paris")
+		_, err := l.Generate(ctx, NewPrompt("Capital of France?"))
+		require.NoError(t, err)
+		_, err = l.Generate(ctx, NewPrompt("Are you sure?"))
+		require.NoError(t, err)
+
+		response, err := l.RegenerateLast(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "It is Paris", response)
+		assert.Equal(t, []string{"user: Capital of France?", "assistant: Paris", "user: Are you sure?", "assistant: It is Paris"}, roles(l.GetMemory()))
+		assert.Equal(t, totalTokens(l.memory), l.memory.totalTokens, "the old response no longer counts")
+		assert.Equal(t, []types.MemoryMessage{
+			{Role: "user", Content: "Capital of France?", Tokens: 18},
+			{Role: "assistant", Content: "Paris", Tokens: 5},
+			{Role: "user", Content: "Are you sure?", Tokens: 13},
+		}, mock.Calls()[2].Messages, "the regenerated request omits the old response")
+	})
+
+	t.Run("EditAndRegenerate", func(t *testing.T) {
+		l, mock := newOfflineMemoryLLM(t)
+		mock.QueueResponse("Paris")
+		mock.QueueResponse("Rome")
+		_, err := l.Generate(ctx, NewPrompt("Capital of France?"))
+		require.NoError(t, err)
+
+		response, err := l.EditAndRegenerate(ctx, "Capital of Italy?")
+		require.NoError(t, err)
+		assert.Equal(t, "Rome", response)
+		assert.Equal(t, []string{"user: Capital of Italy?", "assistant: Rome"}, roles(l.GetMemory()))
+		assert.Equal(t, totalTokens(l.memory), l.memory.totalTokens)
+	})
+
+	t.Run("FailureKeepsConversation", func(t *testing.T) {
+		l, mock := newOfflineMemoryLLM(t)
+		mock.QueueResponse("Paris")
+		_, err := l.Generate(ctx, NewPrompt("Capital of France?"))
+		require.NoError(t, err)
+		before := l.GetMemory()
+		tokens := l.memory.totalTokens
+
+		mock.QueueError(http.StatusInternalServerError, "down")
+		_, err = l.EditAndRegenerate(ctx, "Capital of Italy?")
+		assert.Error(t, err)
+		assert.Equal(t, before, l.GetMemory())
+		assert.Equal(t, tokens, l.memory.totalTokens)
+
+		_, err = l.RegenerateLast(ctx, WithDryRun(&DryRun{}))
+		require.NoError(t, err)
+		assert.Equal(t, before, l.GetMemory(), "a dry run leaves the memory as is")
+	})
+
+	t.Run("NoUserMessage", func(t *testing.T) {
+		l, _ := newOfflineMemoryLLM(t)
+		_, err := l.RegenerateLast(ctx)
+		var llmErr *LLMError
+		require.ErrorAs(t, err, &llmErr)
+		assert.Equal(t, ErrorTypeInvalidInput, llmErr.Type)
+	})
+}
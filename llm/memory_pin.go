@@ -0,0 +1,236 @@
+package llm
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/teilomillet/gollm/types"
+)
+
+// PinnedMemory is implemented by LLMs with conversation memory that can keep
+// messages and facts however the conversation is trimmed, e.g. the user's
+// name or the rules of a support chat.
+type PinnedMemory interface {
+	// AddPinnedMessage adds a message that is never trimmed.
+	AddPinnedMessage(role, content string) error
+
+	// PinMessage pins or unpins the message at index of the conversation.
+	PinMessage(index int, pinned bool) error
+
+	// SetFact sets a fact always included in the context.
+	SetFact(key, value string) error
+
+	// RemoveFact removes a fact set by SetFact.
+	RemoveFact(key string) error
+
+	// Facts returns the facts of the memory.
+	Facts() map[string]string
+}
+
+const (
+	// MemoryFactKey is the metadata key holding the key of a fact, on the
+	// messages facts are saved as by Memory.Save.
+	MemoryFactKey = "fact"
+
+	// memoryFactsLayer is the name of the system prompt layer of the facts.
+	memoryFactsLayer = "facts"
+)
+
+// memoryFact is a key/value fact of a Memory.
+type memoryFact struct {
+	key   string
+	value string
+}
+
+// SetFact sets the value of a fact, replacing the previous value of the key.
+// Facts are sent with every request in a required layer of the system prompt,
+// so they are never trimmed; they do not count towards the token limit and
+// are kept by Clear.
+func (m *Memory) SetFact(key, value string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for i := range m.facts {
+		if m.facts[i].key == key {
+			m.facts[i].value = value
+			return
+		}
+	}
+	m.facts = append(m.facts, memoryFact{key: key, value: value})
+}
+
+// RemoveFact removes a fact, reporting whether the memory had it.
+func (m *Memory) RemoveFact(key string) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for i := range m.facts {
+		if m.facts[i].key == key {
+			m.facts = append(m.facts[:i:i], m.facts[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Facts returns a copy of the facts.
+func (m *Memory) Facts() map[string]string {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	facts := make(map[string]string, len(m.facts))
+	for _, f := range m.facts {
+		facts[f.key] = f.value
+	}
+	return facts
+}
+
+// Pin pins or unpins the message at index, in the order of GetMessages.
+func (m *Memory) Pin(index int, pinned bool) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if index < 0 || index >= len(m.messages) {
+		return NewLLMError(ErrorTypeInvalidInput, fmt.Sprintf("no message at index %d", index), nil)
+	}
+
+	// Update a copy of the metadata, which snapshots may share
+	message := &m.messages[index]
+	metadata := make(map[string]interface{}, len(message.Metadata)+1)
+	for k, v := range message.Metadata {
+		metadata[k] = v
+	}
+	if pinned {
+		metadata[types.MemoryPinnedKey] = true
+	} else {
+		delete(metadata, types.MemoryPinnedKey)
+	}
+	message.Metadata = metadata
+	if !pinned {
+		m.truncateIfNeeded()
+	}
+	return nil
+}
+
+// factMessages returns the facts as the messages they are saved as.
+func (m *Memory) factMessages() []types.MemoryMessage {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	messages := make([]types.MemoryMessage, len(m.facts))
+	for i, f := range m.facts {
+		messages[i] = types.MemoryMessage{
+			Role:     "system",
+			Content:  f.value,
+			Metadata: map[string]interface{}{types.MemoryPinnedKey: true, MemoryFactKey: f.key},
+		}
+	}
+	return messages
+}
+
+// splitFacts separates the messages facts were saved as from the
+// conversation.
+func splitFacts(messages []types.MemoryMessage) ([]types.MemoryMessage, []memoryFact) {
+	var facts []memoryFact
+	conversation := make([]types.MemoryMessage, 0, len(messages))
+	for _, m := range messages {
+		if key, ok := m.Metadata[MemoryFactKey].(string); ok {
+			facts = append(facts, memoryFact{key: key, value: m.Content})
+			continue
+		}
+		conversation = append(conversation, m)
+	}
+	return conversation, facts
+}
+
+// requestMessages returns the messages without the metadata the memory keeps
+// for itself. Providers send metadata as fields of the messages, and APIs
+// reject messages with unknown fields.
+func requestMessages(messages []types.MemoryMessage) []types.MemoryMessage {
+	stripped := make([]types.MemoryMessage, len(messages))
+	for i, m := range messages {
+		stripped[i] = m
+		_, pinned := m.Metadata[types.MemoryPinnedKey]
+		_, fact := m.Metadata[MemoryFactKey]
+		if !pinned && !fact {
+			continue
+		}
+		metadata := make(map[string]interface{}, len(m.Metadata))
+		for k, v := range m.Metadata {
+			if k != types.MemoryPinnedKey && k != MemoryFactKey {
+				metadata[k] = v
+			}
+		}
+		if len(metadata) == 0 {
+			metadata = nil
+		}
+		stripped[i].Metadata = metadata
+	}
+	return stripped
+}
+
+// withFacts returns the system prompt layers with the facts layer, if the
+// memory has facts.
+func (m *Memory) withFacts(layers []SystemLayer) []SystemLayer {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if len(m.facts) == 0 {
+		return layers
+	}
+	var b strings.Builder
+	b.WriteString("Facts to keep in mind:")
+	for _, f := range m.facts {
+		fmt.Fprintf(&b, "\n- %s: %s", f.key, f.value)
+	}
+	p := &Prompt{SystemLayers: append([]SystemLayer(nil), layers...)}
+	p.setSystemLayer(SystemLayer{Name: memoryFactsLayer, Content: b.String(), Required: true})
+	return p.SystemLayers
+}
+
+// AddPinnedMessage adds a message that memory strategies and truncation
+// always keep, e.g. instructions given at the start of the conversation.
+func (l *LLMWithMemory) AddPinnedMessage(role, content string) error {
+	if role == "" {
+		return NewLLMError(ErrorTypeInvalidInput, "message role is required", nil)
+	}
+	l.memory.AddStructured(types.MemoryMessage{
+		Role:     role,
+		Content:  content,
+		Metadata: map[string]interface{}{types.MemoryPinnedKey: true},
+	})
+	return nil
+}
+
+// PinMessage pins or unpins the message at index of the conversation, in the
+// order of GetMemory.
+//
+// Example usage:
+//
+//	messages := memoryLLM.GetMemory()
+//	err := memoryLLM.PinMessage(len(messages)-1, true) // Keep the last answer
+func (l *LLMWithMemory) PinMessage(index int, pinned bool) error {
+	return l.memory.Pin(index, pinned)
+}
+
+// SetFact sets a fact sent with every request, in a required layer of the
+// system prompt, so it survives any trimming of the conversation. Setting a
+// key again replaces its value.
+//
+// Example usage:
+//
+//	err := memoryLLM.SetFact("user name", "Ada")
+func (l *LLMWithMemory) SetFact(key, value string) error {
+	if key == "" {
+		return NewLLMError(ErrorTypeInvalidInput, "fact key is required", nil)
+	}
+	l.memory.SetFact(key, value)
+	return nil
+}
+
+// RemoveFact removes a fact set by SetFact.
+func (l *LLMWithMemory) RemoveFact(key string) error {
+	if !l.memory.RemoveFact(key) {
+		return NewLLMError(ErrorTypeInvalidInput, fmt.Sprintf("no fact %q", key), nil)
+	}
+	return nil
+}
+
+// Facts returns a copy of the facts set by SetFact.
+func (l *LLMWithMemory) Facts() map[string]string {
+	return l.memory.Facts()
+}
@@ -0,0 +1,101 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm/providers"
+	"github.com/teilomillet/gollm/types"
+)
+
+func TestPinnedMemory(t *testing.T) {
+	ctx := context.Background()
+	contents := func(messages []types.MemoryMessage) []string {
+		var c []string
+		for _, m := range messages {
+			c = append(c, m.Content)
+		}
+		return c
+	}
+
+	t.Run("Truncation", func(t *testing.T) {
+		l, mock := newOfflineMemoryLLM(t)
+		l.memory.maxTokens = 23 // One token per byte
+		require.NoError(t, l.AddPinnedMessage("user", "I am Ada"))
+		for _, input := range []string{"one", "two", "three"} {
+			mock.QueueResponse(input + "!")
+			_, err := l.Generate(ctx, NewPrompt(input))
+			require.NoError(t, err)
+		}
+		assert.Equal(t, []string{"I am Ada", "two!", "three", "three!"}, contents(l.GetMemory()))
+
+		require.NoError(t, l.PinMessage(0, false))
+		l.AddToMemory("user", "four")
+		assert.Equal(t, []string{"two!", "three", "three!", "four"}, contents(l.GetMemory()), "an unpinned message is truncated")
+
+		require.NoError(t, l.PinMessage(3, true))
+		assert.True(t, l.GetMemory()[3].Pinned())
+		assert.Error(t, l.PinMessage(4, true))
+		assert.Error(t, l.AddPinnedMessage("", "no role"))
+	})
+
+	t.Run("Facts", func(t *testing.T) {
+		l, mock := newOfflineMemoryLLM(t)
+		require.NoError(t, l.SetFact("user name", "Ada"))
+		require.NoError(t, l.SetFact("plan", "free"))
+		require.NoError(t, l.SetFact("plan", "pro"))
+		assert.Equal(t, map[string]string{"user name": "Ada", "plan": "pro"}, l.Facts())
+		assert.Error(t, l.SetFact("", "no key"))
+
+		mock.QueueResponse("Hi Ada")
+		_, err := l.Generate(ctx, NewPrompt("Hello", WithSystemPrompt("Be brief", ""), WithSystemTokenBudget(20)))
+		require.NoError(t, err)
+		call := mock.Calls()[0]
+		assert.Equal(t, "Be brief\n\nFacts to keep in mind:\n- user name: Ada\n- plan: pro", call.Options["system_prompt"], "facts are required in the system prompt")
+		assert.Equal(t, []string{"Hello"}, contents(call.Messages), "facts are not part of the conversation")
+
+		l.ClearMemory()
+		require.NoError(t, l.RemoveFact("plan"))
+		assert.Error(t, l.RemoveFact("plan"))
+		assert.Equal(t, map[string]string{"user name": "Ada"}, l.Facts(), "facts are kept by ClearMemory")
+	})
+
+	t.Run("RequestBody", func(t *testing.T) {
+		l, _ := newOfflineMemoryLLM(t)
+		l.LLM = newBatchLLM(t, providers.NewOpenAIProvider("key", "gpt-4o", nil), nil)
+		require.NoError(t, l.AddPinnedMessage("system", "Answer in French"))
+		require.NoError(t, l.SetFact("user name", "Ada"))
+
+		var dry DryRun
+		_, err := l.Generate(ctx, NewPrompt("Hello"), WithDryRun(&dry))
+		require.NoError(t, err)
+		var body struct {
+			Messages []map[string]interface{} `json:"messages"`
+		}
+		require.NoError(t, json.Unmarshal(dry.Body, &body))
+		require.Len(t, body.Messages, 3)
+		assert.Equal(t, map[string]interface{}{"role": "system", "content": "Answer in French"}, body.Messages[1], "the pin is not sent")
+		assert.NotContains(t, string(dry.Body), `"pinned"`)
+		assert.NotContains(t, string(dry.Body), `"fact"`)
+		assert.True(t, l.GetMemory()[0].Pinned(), "the memory keeps the pin")
+	})
+
+	t.Run("Persistence", func(t *testing.T) {
+		l, _ := newOfflineMemoryLLM(t)
+		require.NoError(t, l.SetFact("user name", "Ada"))
+		require.NoError(t, l.AddPinnedMessage("system", "Answer in French"))
+		l.AddToMemory("user", "Hello")
+
+		var saved bytes.Buffer
+		require.NoError(t, l.SaveMemory(&saved, nil))
+		restored, _ := newOfflineMemoryLLM(t)
+		require.NoError(t, restored.LoadMemory(&saved, nil))
+		assert.Equal(t, l.Facts(), restored.Facts())
+		assert.Equal(t, []string{"Answer in French", "Hello"}, contents(restored.GetMemory()))
+		assert.True(t, restored.GetMemory()[0].Pinned())
+	})
+}
@@ -0,0 +1,219 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/teilomillet/gollm/types"
+)
+
+// MemoryStrategy selects the messages a conversation memory keeps before
+// each request. Every built-in strategy keeps pinned messages.
+type MemoryStrategy = types.MemoryStrategy
+
+// MemoryImportanceKey is the metadata key under which ImportanceWeighted
+// records the score of a turn, on its first message.
+const MemoryImportanceKey = "importance"
+
+// TokenWindow keeps the most recent messages within the token limit, and the
+// pinned messages. It is how the memory is trimmed without a strategy.
+func TokenWindow() MemoryStrategy {
+	return tokenWindow{}
+}
+
+type tokenWindow struct{}
+
+func (tokenWindow) Trim(_ context.Context, messages []types.MemoryMessage, maxTokens int) ([]types.MemoryMessage, error) {
+	return dropOldest(messages, maxTokens), nil
+}
+
+// LastTurns keeps the last k turns of the conversation, a turn being a user
+// message and the messages that answer it, then trims them to the token
+// limit like TokenWindow.
+//
+// Example usage:
+//
+//	l, err := gollm.NewLLM(gollm.SetMemory(8000), gollm.SetMemoryStrategy(llm.LastTurns(5)))
+func LastTurns(k int) MemoryStrategy {
+	return lastTurns(k)
+}
+
+type lastTurns int
+
+func (k lastTurns) Trim(_ context.Context, messages []types.MemoryMessage, maxTokens int) ([]types.MemoryMessage, error) {
+	turns := splitTurns(messages)
+	kept := make([]types.MemoryMessage, 0, len(messages))
+	for i, turn := range turns {
+		recent := i >= len(turns)-int(k)
+		for _, m := range turn {
+			if recent || m.Pinned() {
+				kept = append(kept, m)
+			}
+		}
+	}
+	return dropOldest(kept, maxTokens), nil
+}
+
+// ImportanceWeighted drops the least important turns first once the
+// conversation exceeds the token limit, instead of the oldest. The scorer,
+// typically a small and cheap model, rates each turn from 0 to 10 the first
+// time it is considered; the score is kept in the metadata of the turn's
+// first message under MemoryImportanceKey. The latest turn is never dropped.
+// If scoring fails, the memory falls back to TokenWindow.
+//
+// Example usage:
+//
+//	scorer, _ := gollm.NewLLM(gollm.SetModel("gpt-4o-mini"))
+//	l, err := gollm.NewLLM(gollm.SetMemory(8000), gollm.SetMemoryStrategy(llm.ImportanceWeighted(scorer)))
+func ImportanceWeighted(scorer LLM) MemoryStrategy {
+	return importanceWeighted{scorer: scorer}
+}
+
+type importanceWeighted struct {
+	scorer LLM
+}
+
+var scorePattern = regexp.MustCompile(`\d+(\.\d+)?`)
+
+func (s importanceWeighted) Trim(ctx context.Context, messages []types.MemoryMessage, maxTokens int) ([]types.MemoryMessage, error) {
+	total := countTokens(messages)
+	if maxTokens <= 0 || total <= maxTokens {
+		return messages, nil
+	}
+
+	// Score the turns before the latest one
+	turns := splitTurns(append([]types.MemoryMessage(nil), messages...))
+	scores := make([]float64, len(turns)-1)
+	for i, turn := range turns[:len(turns)-1] {
+		score, err := s.score(ctx, turn)
+		if err != nil {
+			return nil, err
+		}
+		scores[i] = score
+	}
+
+	// Drop the least important turns, the oldest first among equals
+	order := make([]int, len(scores))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return scores[order[a]] < scores[order[b]] })
+	dropped := make(map[int]bool)
+	for _, i := range order {
+		if total <= maxTokens {
+			break
+		}
+		dropped[i] = true
+		for _, m := range turns[i] {
+			if !m.Pinned() {
+				total -= m.Tokens
+			}
+		}
+	}
+
+	kept := make([]types.MemoryMessage, 0, len(messages))
+	for i, turn := range turns {
+		for _, m := range turn {
+			if !dropped[i] || m.Pinned() {
+				kept = append(kept, m)
+			}
+		}
+	}
+	return dropOldest(kept, maxTokens), nil
+}
+
+// score returns the importance of a turn, asking the scorer if the turn has
+// no score yet. Turns of pinned messages only are never dropped, so they are
+// not scored.
+func (s importanceWeighted) score(ctx context.Context, turn []types.MemoryMessage) (float64, error) {
+	if allPinned(turn) {
+		return 0, nil // Nothing to drop
+	}
+	if score, ok := turn[0].Metadata[MemoryImportanceKey].(float64); ok {
+		return score, nil
+	}
+	var b strings.Builder
+	for _, m := range turn {
+		fmt.Fprintf(&b, "%s: %s\n", m.Role, m.Content)
+	}
+	prompt := NewPrompt(b.String(),
+		WithDirectives("Rate how important this part of a conversation is to continue the conversation, from 0 for small talk to 10 for essential facts, instructions or decisions"),
+		WithOutput("Answer with the number only"),
+	)
+	response, err := s.scorer.Generate(ctx, prompt)
+	if err != nil {
+		return 0, fmt.Errorf("failed to score turn: %w", err)
+	}
+	match := scorePattern.FindString(response)
+	if match == "" {
+		return 0, fmt.Errorf("invalid importance score %q", response)
+	}
+	score, _ := strconv.ParseFloat(match, 64)
+	score = min(score, 10)
+
+	// Record the score on a copy of the metadata, shared with the caller
+	metadata := make(map[string]interface{}, len(turn[0].Metadata)+1)
+	for k, v := range turn[0].Metadata {
+		metadata[k] = v
+	}
+	metadata[MemoryImportanceKey] = score
+	turn[0].Metadata = metadata
+	return score, nil
+}
+
+// splitTurns splits a conversation into turns, each starting with a user
+// message. Messages before the first user message form a turn of their own.
+func splitTurns(messages []types.MemoryMessage) [][]types.MemoryMessage {
+	var turns [][]types.MemoryMessage
+	start := 0
+	for i, m := range messages {
+		if m.Role == "user" && i > start {
+			turns = append(turns, messages[start:i])
+			start = i
+		}
+	}
+	if start < len(messages) {
+		turns = append(turns, messages[start:])
+	}
+	return turns
+}
+
+// dropOldest drops the oldest messages that are not pinned until the
+// messages fit in maxTokens, always keeping the last one. Zero maxTokens
+// keeps every message.
+func dropOldest(messages []types.MemoryMessage, maxTokens int) []types.MemoryMessage {
+	total := countTokens(messages)
+	if maxTokens <= 0 || total <= maxTokens {
+		return messages
+	}
+	kept := make([]types.MemoryMessage, 0, len(messages))
+	for i, m := range messages {
+		if total > maxTokens && !m.Pinned() && i < len(messages)-1 {
+			total -= m.Tokens
+			continue
+		}
+		kept = append(kept, m)
+	}
+	return kept
+}
+
+func allPinned(messages []types.MemoryMessage) bool {
+	for _, m := range messages {
+		if !m.Pinned() {
+			return false
+		}
+	}
+	return true
+}
+
+func countTokens(messages []types.MemoryMessage) int {
+	total := 0
+	for _, m := range messages {
+		total += m.Tokens
+	}
+	return total
+}
@@ -0,0 +1,142 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm/providers"
+	"github.com/teilomillet/gollm/types"
+)
+
+func TestMemoryStrategy(t *testing.T) {
+	ctx := context.Background()
+	message := func(role, content string, pinned bool) types.MemoryMessage {
+		m := types.MemoryMessage{Role: role, Content: content, Tokens: 10}
+		if pinned {
+			m.Metadata = map[string]interface{}{types.MemoryPinnedKey: true}
+		}
+		return m
+	}
+	contents := func(messages []types.MemoryMessage) string {
+		var c []string
+		for _, m := range messages {
+			c = append(c, m.Content)
+		}
+		return strings.Join(c, " ")
+	}
+	conversation := []types.MemoryMessage{
+		message("system", "rules", true),
+		message("user", "hi", false),
+		message("assistant", "hello", false),
+		message("user", "my name is Ada", true),
+		message("assistant", "noted", false),
+		message("user", "weather?", false),
+		message("assistant", "sunny", false),
+		message("user", "and tomorrow?", false),
+	}
+
+	t.Run("TokenWindow", func(t *testing.T) {
+		kept, err := TokenWindow().Trim(ctx, conversation, 50)
+		require.NoError(t, err)
+		assert.Equal(t, "rules my name is Ada weather? sunny and tomorrow?", contents(kept))
+
+		kept, err = TokenWindow().Trim(ctx, conversation, 0)
+		require.NoError(t, err)
+		assert.Len(t, kept, len(conversation), "zero means no limit")
+	})
+
+	t.Run("LastTurns", func(t *testing.T) {
+		kept, err := LastTurns(2).Trim(ctx, conversation, 0)
+		require.NoError(t, err)
+		assert.Equal(t, "rules my name is Ada weather? sunny and tomorrow?", contents(kept))
+
+		kept, err = LastTurns(3).Trim(ctx, conversation, 40)
+		require.NoError(t, err)
+		assert.Equal(t, "rules my name is Ada sunny and tomorrow?", contents(kept), "the turns are trimmed to the token limit")
+	})
+
+	t.Run("ImportanceWeighted", func(t *testing.T) {
+		scorer := newMockLLM(t)
+		mock := scorer.Provider.(*providers.MockProvider)
+		mock.SetResponder(func(call providers.MockCall) (string, error) {
+			if strings.Contains(call.Prompt, "user: hi\n") {
+				return "Score: 1", nil
+			}
+			return "8", nil
+		})
+		strategy := ImportanceWeighted(scorer)
+
+		kept, err := strategy.Trim(ctx, conversation, 60)
+		require.NoError(t, err)
+		assert.Equal(t, "rules my name is Ada noted weather? sunny and tomorrow?", contents(kept), "small talk goes first")
+		assert.Equal(t, 3, mock.CallCount(), "every turn but the latest and the pinned ones is scored")
+		assert.Equal(t, 8.0, kept[1].Metadata[MemoryImportanceKey])
+		assert.True(t, kept[1].Pinned(), "the metadata is kept")
+		assert.Nil(t, conversation[3].Metadata[MemoryImportanceKey], "the caller's messages are not modified")
+
+		// Scores are recorded on the messages kept, so they are asked once
+		_, err = strategy.Trim(ctx, append(kept, message("user", "rain?", false)), 60)
+		require.NoError(t, err)
+		assert.Equal(t, 4, mock.CallCount(), "only the turn before the latest is new")
+
+		kept, err = strategy.Trim(ctx, conversation, 1000)
+		require.NoError(t, err)
+		assert.Len(t, kept, len(conversation), "nothing is scored below the limit")
+
+		mock.SetResponder(func(providers.MockCall) (string, error) { return "", errors.New("down") })
+		_, err = strategy.Trim(ctx, conversation, 60)
+		assert.ErrorContains(t, err, "failed to score turn")
+	})
+
+	t.Run("Memory", func(t *testing.T) {
+		l, mock := newOfflineMemoryLLM(t)
+		l.SetMemoryStrategy(LastTurns(2))
+		for _, input := range []string{"one", "two", "three"} {
+			mock.QueueResponse(input + "!")
+			_, err := l.Generate(ctx, NewPrompt(input))
+			require.NoError(t, err)
+		}
+		calls := mock.Calls()
+		assert.Equal(t, "two two! three", contents(calls[len(calls)-1].Messages), "the first turn is dropped before the request")
+		assert.Equal(t, countTokens(l.GetMemory()), l.memory.totalTokens)
+	})
+
+	t.Run("MemoryFallback", func(t *testing.T) {
+		l, mock := newOfflineMemoryLLM(t)
+		l.memory.maxTokens = 8
+		scorer := newMockLLM(t)
+		scorer.Provider.(*providers.MockProvider).SetResponder(func(providers.MockCall) (string, error) { return "", errors.New("down") })
+		l.SetMemoryStrategy(ImportanceWeighted(scorer))
+		for _, input := range []string{"one", "two"} {
+			mock.QueueResponse(input + "!")
+			_, err := l.Generate(ctx, NewPrompt(input))
+			require.NoError(t, err, "a failing strategy doesn't fail the request")
+		}
+		calls := mock.Calls()
+		assert.Equal(t, "one! two", contents(calls[len(calls)-1].Messages), "the oldest messages are dropped instead")
+	})
+	t.Run("MemorySchema", func(t *testing.T) {
+		l, mock := newOfflineMemoryLLM(t)
+		l.SetMemoryStrategy(LastTurns(1))
+		schema := map[string]interface{}{"type": "object", "properties": map[string]interface{}{"n": map[string]interface{}{"type": "string"}}}
+		for _, input := range []string{"one", "two"} {
+			mock.QueueResponse(`{"n":"` + input + `"}`)
+			_, err := l.GenerateWithSchema(ctx, NewPrompt(input, WithSystemPrompt("Answer in JSON", "")), schema)
+			require.NoError(t, err)
+		}
+		call, _ := mock.LastCall()
+		assert.Contains(t, call.Prompt, "Answer in JSON", "the system prompt is kept")
+		assert.NotContains(t, call.Prompt, "one", "the strategy trims the conversation")
+
+		var dry DryRun
+		_, err := l.GenerateWithSchema(ctx, NewPrompt("three"), schema, WithDryRun(&dry))
+		require.NoError(t, err)
+		assert.Contains(t, string(dry.Body), "three")
+		assert.Len(t, l.GetMemory(), 2, "a dry run leaves the memory as is")
+		assert.Equal(t, 2, mock.CallCount())
+	})
+}
@@ -0,0 +1,67 @@
+package llm
+
+import (
+	"github.com/teilomillet/gollm/providers"
+)
+
+// MetadataUserKey is the metadata key of the end-user ID, which providers
+// such as OpenAI and Anthropic accept for abuse monitoring.
+const MetadataUserKey = providers.MetadataUserKey
+
+// WithMetadata attaches key/value metadata to a request, such as the user,
+// feature or experiment arm it serves. The metadata is logged with the
+// request, recorded by wrappers such as cost trackers and tracers, and sent
+// to providers that accept it: OpenAI receives it as "metadata" and the
+// MetadataUserKey value as "user", Anthropic as metadata.user_id. Repeated
+// options are merged.
+//
+// Example usage:
+//
+//	response, err := l.Generate(ctx, prompt, llm.WithMetadata(map[string]string{
+//	    llm.MetadataUserKey: userID,
+//	    "feature":           "summary",
+//	    "experiment":        "prompt-v2",
+//	}))
+func WithMetadata(metadata map[string]string) GenerateOption {
+	return func(c *GenerateConfig) {
+		c.Metadata = mergeMetadata(c.Metadata, metadata)
+	}
+}
+
+// WithStreamMetadata attaches key/value metadata to a streaming request, like
+// WithMetadata.
+func WithStreamMetadata(metadata map[string]string) StreamOption {
+	return func(c *StreamConfig) {
+		c.Metadata = mergeMetadata(c.Metadata, metadata)
+	}
+}
+
+// mergeMetadata returns a copy of base with the pairs of extra, leaving the
+// caller's maps untouched.
+func mergeMetadata(base, extra map[string]string) map[string]string {
+	merged := make(map[string]string, len(base)+len(extra))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range extra {
+		merged[k] = v
+	}
+	return merged
+}
+
+// addMetadataOptions adds the provider options carrying the metadata, without
+// overriding options set explicitly.
+func (l *LLMImpl) addMetadataOptions(options map[string]interface{}, metadata map[string]string) {
+	if len(metadata) == 0 {
+		return
+	}
+	mapper, ok := l.Provider.(providers.MetadataMapper)
+	if !ok {
+		return
+	}
+	for k, v := range mapper.MetadataOptions(metadata) {
+		if _, set := options[k]; !set {
+			options[k] = v
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/teilomillet/gollm/providers"
+	"github.com/teilomillet/gollm/utils"
+)
+
+func TestWithMetadata(t *testing.T) {
+	var request map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		w.Write([]byte(`{"choices":[{"message":{"content":"Hi"}}]}`))
+	}))
+	defer server.Close()
+
+	l := &LLMImpl{
+		Provider: &localOpenAIProvider{OpenAIProvider: providers.NewOpenAIProvider("fake-key", "gpt-4o", nil).(*providers.OpenAIProvider), url: server.URL},
+		Options:  make(map[string]interface{}),
+		client:   server.Client(),
+		logger:   utils.NewLogger(utils.LogLevelOff),
+	}
+
+	_, err := l.Generate(context.Background(), NewPrompt("Hello"),
+		WithMetadata(map[string]string{MetadataUserKey: "user-42"}),
+		WithMetadata(map[string]string{"feature": "greeting"}),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "user-42", request["user"])
+	assert.Equal(t, map[string]interface{}{"user_id": "user-42", "feature": "greeting"}, request["metadata"])
+
+	_, err = l.Generate(context.Background(), NewPrompt("Hello"),
+		WithMetadata(map[string]string{MetadataUserKey: "user-42"}),
+		WithRequestOption("user", "explicit"),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, "explicit", request["user"], "explicit options take precedence")
+}
+
+func TestMetadataOptions(t *testing.T) {
+	metadata := map[string]string{MetadataUserKey: "user-42", "feature": "greeting"}
+
+	anthropic := providers.NewAnthropicProvider("fake-key", "claude-3-5-sonnet-latest", nil).(providers.MetadataMapper)
+	assert.Equal(t, map[string]interface{}{"metadata": map[string]string{"user_id": "user-42"}}, anthropic.MetadataOptions(metadata))
+	assert.Nil(t, anthropic.MetadataOptions(map[string]string{"feature": "greeting"}))
+
+	_, ok := providers.NewMistralProvider("fake-key", "mistral-large-latest", nil).(providers.MetadataMapper)
+	assert.False(t, ok, "metadata stays on the client side for other providers")
+}
@@ -0,0 +1,75 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/teilomillet/gollm/providers"
+)
+
+// ListModels returns the models available with the provider, with their
+// context window, capabilities, input modalities and deprecation when the
+// provider reports them. The models are also merged into
+// providers.DefaultModelCatalog(), so that cost tracking and routing know
+// about them; prices and capabilities already in the catalog are kept.
+//
+// Returns:
+//   - ErrorTypeUnsupported if the provider cannot list its models
+//   - ErrorTypeRequest if the provider cannot be reached
+//   - ErrorTypeAPI if the provider rejects the request
+//   - ErrorTypeResponse if the listing cannot be parsed
+//
+// Example usage:
+//
+//	models, err := l.ListModels(ctx)
+//	for _, m := range models {
+//	    if !m.Deprecated && m.Has(providers.CapabilityTools) {
+//	        fmt.Println(m.Model, m.ContextWindow)
+//	    }
+//	}
+func (l *LLMImpl) ListModels(ctx context.Context) ([]providers.ModelInfo, error) {
+	lister, ok := l.Provider.(providers.ModelLister)
+	if !ok {
+		return nil, NewLLMError(ErrorTypeUnsupported, fmt.Sprintf("model listing not supported by provider %s", l.Provider.Name()), nil)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, lister.ModelsEndpoint(), nil)
+	if err != nil {
+		return nil, NewLLMError(ErrorTypeRequest, "failed to create model listing request", err)
+	}
+	for k, v := range l.Provider.Headers() {
+		req.Header.Set(k, v)
+	}
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return nil, NewLLMError(ErrorTypeRequest, "failed to send model listing request", err)
+	}
+	defer resp.Body.Close()
+	body, err := providers.ReadResponseBody(resp.Body)
+	if err != nil {
+		return nil, NewLLMError(ErrorTypeResponse, "failed to read model listing", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		l.logger.Error("API error", "provider", l.Provider.Name(), "status", resp.StatusCode, "body", string(body))
+		return nil, newAPIError(l.Provider.Name(), resp, body)
+	}
+
+	models, err := lister.ParseModels(body)
+	if err != nil {
+		return nil, NewLLMError(ErrorTypeResponse, "failed to parse model listing", err)
+	}
+	providers.DefaultModelCatalog().Merge(models...)
+	l.logger.Debug("Models listed", "provider", l.Provider.Name(), "count", len(models))
+	return models, nil
+}
+
+// ListModels lists the models of the underlying LLM's provider.
+func (l *LLMWithMemory) ListModels(ctx context.Context) ([]providers.ModelInfo, error) {
+	if m, ok := l.LLM.(interface {
+		ListModels(context.Context) ([]providers.ModelInfo, error)
+	}); ok {
+		return m.ListModels(ctx)
+	}
+	return nil, NewLLMError(ErrorTypeUnsupported, "model listing not supported by underlying LLM", nil)
+}
@@ -0,0 +1,79 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm/providers"
+)
+
+func TestListModels(t *testing.T) {
+	t.Run("Mistral", func(t *testing.T) {
+		l := newBatchLLM(t, providers.NewMistralProvider("key", "mistral-small-latest", nil), func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/v1/models", r.URL.Path)
+			assert.Equal(t, "Bearer key", r.Header.Get("Authorization"))
+			fmt.Fprint(w, `{"data":[
+				{"id":"mistral-small-latest","max_context_length":131072,"capabilities":{"completion_chat":true,"function_calling":true,"vision":true}},
+				{"id":"mistral-tiny-2312","max_context_length":32768,"deprecation":"2024-11-30T12:00:00Z","capabilities":{"completion_chat":true}}]}`)
+		})
+		models, err := l.ListModels(context.Background())
+		require.NoError(t, err)
+		require.Len(t, models, 2)
+		assert.Equal(t, "mistral", models[0].Provider)
+		assert.Equal(t, 131072, models[0].ContextWindow)
+		assert.True(t, models[0].Has(providers.CapabilityTools, providers.CapabilityVision))
+		assert.Equal(t, []string{"text", "image"}, models[0].Modalities)
+		assert.True(t, models[1].Deprecated)
+		assert.Equal(t, "2024-11-30T12:00:00Z", models[1].Deprecation)
+
+		// Listed models feed the default catalog without losing its prices
+		known, ok := providers.DefaultModelCatalog().Lookup("mistral", "mistral-small-latest")
+		require.True(t, ok)
+		assert.Equal(t, 0.2, known.InputPerMillion)
+		assert.Equal(t, 32000, known.ContextWindow)
+		assert.True(t, known.Has(providers.CapabilityVision))
+		_, ok = providers.DefaultModelCatalog().Lookup("mistral", "mistral-tiny-2312")
+		assert.True(t, ok)
+	})
+
+	t.Run("OpenRouter", func(t *testing.T) {
+		l := newBatchLLM(t, providers.NewOpenRouterProvider("key", "openai/gpt-4o", nil), func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/api/v1/models", r.URL.Path)
+			fmt.Fprint(w, `{"data":[{"id":"example/listed-model","context_length":200000,
+				"architecture":{"input_modalities":["text","image","file"]},
+				"pricing":{"prompt":"0.000003","completion":"0.000015"},
+				"supported_parameters":["tools","temperature"]}]}`)
+		})
+		models, err := l.ListModels(context.Background())
+		require.NoError(t, err)
+		require.Len(t, models, 1)
+		assert.InDelta(t, 3, models[0].InputPerMillion, 1e-9)
+		assert.InDelta(t, 15, models[0].OutputPerMillion, 1e-9)
+		assert.True(t, models[0].Has(providers.CapabilityTools, providers.CapabilityVision, providers.CapabilityDocuments, providers.CapabilityStreaming))
+		assert.False(t, models[0].Has(providers.CapabilityJSONSchema))
+	})
+
+	t.Run("APIError", func(t *testing.T) {
+		l := newBatchLLM(t, providers.NewAnthropicProvider("bad", "claude-3-5-haiku-latest", nil), func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "1000", r.URL.Query().Get("limit"))
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"type":"error","error":{"type":"authentication_error","message":"invalid x-api-key"}}`)
+		})
+		_, err := l.ListModels(context.Background())
+		var llmErr *LLMError
+		require.ErrorAs(t, err, &llmErr)
+		assert.Equal(t, ErrorTypeAuthentication, llmErr.Type)
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		l := newBatchLLM(t, providers.NewMockProvider("", "", nil), nil)
+		_, err := l.ListModels(context.Background())
+		var llmErr *LLMError
+		require.ErrorAs(t, err, &llmErr)
+		assert.Equal(t, ErrorTypeUnsupported, llmErr.Type)
+	})
+}
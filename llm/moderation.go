@@ -0,0 +1,114 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/teilomillet/gollm/providers"
+)
+
+// ModerationResult holds the flagged categories and category scores of a moderated text.
+type ModerationResult = providers.ModerationResult
+
+// ModerationOption is a function type for configuring moderation requests.
+type ModerationOption func(*providers.ModerationOptions)
+
+// WithModerationModel overrides the provider's default moderation model.
+func WithModerationModel(model string) ModerationOption {
+	return func(o *providers.ModerationOptions) {
+		o.Model = model
+	}
+}
+
+// Moderate classifies text with the provider's moderation API.
+// Returns:
+//   - ErrorTypeUnsupported if the provider doesn't offer moderation
+//   - ErrorTypeInvalidInput if text is empty
+//   - ErrorTypeRequest for request preparation failures
+//   - ErrorTypeAPI for provider API errors
+//   - ErrorTypeResponse for response processing issues
+func (l *LLMImpl) Moderate(ctx context.Context, text string, opts ...ModerationOption) (*ModerationResult, error) {
+	moderator, ok := l.Provider.(providers.Moderator)
+	if !ok {
+		return nil, NewLLMError(ErrorTypeUnsupported, fmt.Sprintf("moderation not supported by provider %s", l.Provider.Name()), nil)
+	}
+	if text == "" {
+		return nil, NewLLMError(ErrorTypeInvalidInput, "text cannot be empty", nil)
+	}
+
+	options := providers.ModerationOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	body, err := moderator.PrepareModerationRequest(text, options)
+	if err != nil {
+		return nil, NewLLMError(ErrorTypeRequest, "failed to prepare moderation request", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", moderator.ModerationEndpoint(), bytes.NewReader(body))
+	if err != nil {
+		return nil, NewLLMError(ErrorTypeRequest, "failed to create moderation request", err)
+	}
+	for k, v := range l.Provider.Headers() {
+		req.Header.Set(k, v)
+	}
+
+	l.logger.Debug("Sending moderation request", "provider", l.Provider.Name(), "url", req.URL.String(), "text_length", len(text))
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return nil, NewLLMError(ErrorTypeRequest, "failed to send moderation request", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := providers.ReadResponseBody(resp.Body)
+	if err != nil {
+		return nil, NewLLMError(ErrorTypeResponse, "failed to read response body", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		l.logger.Error("API error", "provider", l.Provider.Name(), "status", resp.StatusCode, "body", string(respBody))
+		return nil, newAPIError(l.Provider.Name(), resp, respBody)
+	}
+
+	result, err := moderator.ParseModerationResponse(respBody)
+	if err != nil {
+		return nil, NewLLMError(ErrorTypeResponse, "failed to parse moderation response", err)
+	}
+	return result, nil
+}
+
+// autoModerate checks text with Moderate when automatic moderation is enabled
+// in the configuration, and returns an ErrorTypeModeration error if it is flagged.
+// The subject ("prompt" or "response") is used in the error message.
+func (l *LLMImpl) autoModerate(ctx context.Context, subject, text string) error {
+	if l.config == nil || !l.config.AutoModerate || text == "" {
+		return nil
+	}
+	result, err := l.Moderate(ctx, text)
+	if err != nil {
+		return err
+	}
+	if !result.Flagged {
+		return nil
+	}
+	categories := result.FlaggedCategories()
+	l.logger.Warn("Moderation flagged text", "subject", subject, "categories", categories)
+	if len(categories) == 0 {
+		return NewLLMError(ErrorTypeModeration, subject+" flagged by moderation", nil)
+	}
+	return NewLLMError(ErrorTypeModeration, fmt.Sprintf("%s flagged by moderation: %s", subject, strings.Join(categories, ", ")), nil)
+}
+
+// Moderate classifies text with the provider's moderation API.
+// It delegates to the underlying LLM; moderated text is not added to memory.
+func (l *LLMWithMemory) Moderate(ctx context.Context, text string, opts ...ModerationOption) (*ModerationResult, error) {
+	if m, ok := l.LLM.(interface {
+		Moderate(context.Context, string, ...ModerationOption) (*ModerationResult, error)
+	}); ok {
+		return m.Moderate(ctx, text, opts...)
+	}
+	return nil, NewLLMError(ErrorTypeUnsupported, "moderation not supported by underlying LLM", nil)
+}
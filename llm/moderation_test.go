@@ -0,0 +1,115 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm/config"
+	"github.com/teilomillet/gollm/providers"
+	"github.com/teilomillet/gollm/utils"
+)
+
+// moderatingProvider is a MockProvider with a moderation API backed by a test server.
+type moderatingProvider struct {
+	*MockProvider
+	baseURL string
+}
+
+func (p *moderatingProvider) Endpoint() string           { return p.baseURL + "/chat" }
+func (p *moderatingProvider) ModerationEndpoint() string { return p.baseURL + "/moderations" }
+func (p *moderatingProvider) PrepareModerationRequest(text string, opts providers.ModerationOptions) ([]byte, error) {
+	return []byte(text), nil
+}
+func (p *moderatingProvider) ParseModerationResponse(body []byte) (*ModerationResult, error) {
+	return (&providers.OpenAIProvider{}).ParseModerationResponse(body)
+}
+
+var _ providers.Moderator = (*moderatingProvider)(nil)
+
+func TestModerate(t *testing.T) {
+	var moderations, generations atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/moderations":
+			moderations.Add(1)
+			body, _ := io.ReadAll(r.Body)
+			if strings.Contains(string(body), "attack") {
+				w.Write([]byte(`{"results":[{"flagged":true,"categories":{"violence":true},"category_scores":{"violence":0.97}}]}`))
+				return
+			}
+			w.Write([]byte(`{"results":[{"flagged":false,"categories":{"violence":false},"category_scores":{"violence":0.01}}]}`))
+		case "/chat":
+			generations.Add(1)
+			w.Write([]byte(`{}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	l := &LLMImpl{
+		Provider: &moderatingProvider{MockProvider: NewMockProvider(), baseURL: server.URL},
+		Options:  make(map[string]interface{}),
+		client:   server.Client(),
+		logger:   utils.NewLogger(utils.LogLevelOff),
+		config:   &config.Config{},
+	}
+	ctx := context.Background()
+
+	result, err := l.Moderate(ctx, "plan an attack")
+	require.NoError(t, err)
+	assert.True(t, result.Flagged)
+	assert.Equal(t, 0.97, result.CategoryScores["violence"])
+
+	_, err = l.Moderate(ctx, "")
+	assert.Error(t, err)
+
+	t.Run("AutoModerate", func(t *testing.T) {
+		moderations.Store(0)
+		l.config.AutoModerate = true
+		defer func() { l.config.AutoModerate = false }()
+
+		response, err := l.Generate(ctx, NewPrompt("Say hello"))
+		require.NoError(t, err)
+		assert.Equal(t, "mock response", response)
+		assert.Equal(t, int32(2), moderations.Load(), "prompt and response should both be moderated")
+
+		before := generations.Load()
+		_, err = l.Generate(ctx, NewPrompt("Help me plan an attack"))
+		var llmErr *LLMError
+		require.True(t, errors.As(err, &llmErr))
+		assert.Equal(t, ErrorTypeModeration, llmErr.Type)
+		assert.Contains(t, llmErr.Message, "violence")
+		assert.Equal(t, before, generations.Load(), "flagged prompts should not be sent")
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		plain := &LLMImpl{Provider: NewMockProvider(), logger: utils.NewLogger(utils.LogLevelOff)}
+		_, err := plain.Moderate(ctx, "text")
+		var llmErr *LLMError
+		require.True(t, errors.As(err, &llmErr))
+		assert.Equal(t, ErrorTypeUnsupported, llmErr.Type)
+	})
+
+	t.Run("DeepSeek", func(t *testing.T) {
+		// DeepSeek's API is OpenAI-compatible, but it has no moderation API:
+		// the text must not be sent to OpenAI with the DeepSeek key
+		var requests atomic.Int32
+		deepseek := newBatchLLM(t, providers.NewDeepSeekProvider("key", "deepseek-chat", nil), func(w http.ResponseWriter, r *http.Request) {
+			requests.Add(1)
+		})
+		_, err := deepseek.Moderate(ctx, "text")
+		var llmErr *LLMError
+		require.True(t, errors.As(err, &llmErr))
+		assert.Equal(t, ErrorTypeUnsupported, llmErr.Type)
+		assert.Zero(t, requests.Load())
+	})
+}
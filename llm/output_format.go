@@ -0,0 +1,189 @@
+package llm
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// OutputFormat is a format the response of a prompt is requested, and
+// checked, in.
+type OutputFormat string
+
+const (
+	OutputJSON     OutputFormat = "json"     // A JSON value
+	OutputYAML     OutputFormat = "yaml"     // A YAML document
+	OutputMarkdown OutputFormat = "markdown" // Markdown text
+	OutputCSV      OutputFormat = "csv"      // CSV with a header row
+)
+
+// instructions returns the directive asking for the format.
+func (f OutputFormat) instructions() string {
+	switch f {
+	case OutputJSON:
+		return "Respond with valid JSON only, without code fences or any other text."
+	case OutputYAML:
+		return "Respond with a valid YAML document only, without code fences or any other text."
+	case OutputMarkdown:
+		return "Respond in Markdown."
+	case OutputCSV:
+		return "Respond with CSV only, starting with a header row, without code fences or any other text."
+	default:
+		return ""
+	}
+}
+
+// WithOutputFormat requests the response in a format. The prompt asks for the
+// format, and Generate returns the response cleaned of code fences and
+// surrounding text, with common issues such as trailing commas in JSON fixed.
+// A response that still isn't valid in the format is an ErrorTypeResponse.
+//
+// Example usage:
+//
+//	prompt := llm.NewPrompt("List three primary colors with their hex codes",
+//	    llm.WithOutput("An array of objects with name and hex fields"),
+//	    llm.WithOutputFormat(llm.OutputJSON),
+//	)
+//	response, err := l.Generate(ctx, prompt)
+//	var colors []Color
+//	err = llm.DecodeOutput(response, llm.OutputJSON, &colors)
+func WithOutputFormat(format OutputFormat) PromptOption {
+	return func(p *Prompt) {
+		p.OutputFormat = format
+	}
+}
+
+// outputSpec returns the output specification of the prompt followed by the
+// instructions of its format.
+func (p *Prompt) outputSpec() string {
+	instructions := p.OutputFormat.instructions()
+	switch {
+	case instructions == "":
+		return p.Output
+	case p.Output == "":
+		return instructions
+	default:
+		return p.Output + "\n" + instructions
+	}
+}
+
+// ParseOutput cleans a response and checks that it is valid in the format:
+// enclosing code fences are removed, and JSON is repaired by SanitizeJSON,
+// except a truncated response, which is rejected. Markdown is only unfenced.
+//
+// Returns:
+//   - The cleaned response
+//   - ErrorTypeResponse if the response isn't valid in the format
+func ParseOutput(response string, format OutputFormat) (string, error) {
+	body, lang, fenced := cutCodeFence(response)
+	if !fenced {
+		body = strings.TrimSpace(response)
+	}
+	switch format {
+	case OutputJSON:
+		var report SanitizeReport
+		body, report = SanitizeJSON(body)
+		if report.Has(RepairTruncated) || !json.Valid([]byte(body)) {
+			return "", NewLLMError(ErrorTypeResponse, "response is not valid JSON", nil)
+		}
+	case OutputYAML:
+		var doc interface{}
+		if err := yaml.Unmarshal([]byte(body), &doc); err != nil {
+			return "", NewLLMError(ErrorTypeResponse, "response is not valid YAML", err)
+		}
+	case OutputCSV:
+		records, err := csv.NewReader(strings.NewReader(body)).ReadAll()
+		if err != nil {
+			return "", NewLLMError(ErrorTypeResponse, "response is not valid CSV", err)
+		}
+		if len(records) == 0 {
+			return "", NewLLMError(ErrorTypeResponse, "response has no CSV records", nil)
+		}
+	case OutputMarkdown:
+		// Code blocks are valid Markdown: only a fence around the whole
+		// document is removed
+		if fenced && lang != "markdown" && lang != "md" {
+			body = strings.TrimSpace(response)
+		}
+	default:
+		return "", NewLLMError(ErrorTypeInvalidInput, fmt.Sprintf("unknown output format %q", format), nil)
+	}
+	return body, nil
+}
+
+// DecodeOutput parses a response in the format into v: any value for JSON and
+// YAML, a *[][]string of records for CSV and a *string for Markdown.
+//
+// Returns:
+//   - ErrorTypeResponse if the response isn't valid in the format
+//   - ErrorTypeInvalidInput if v doesn't suit the format
+func DecodeOutput(response string, format OutputFormat, v interface{}) error {
+	body, err := ParseOutput(response, format)
+	if err != nil {
+		return err
+	}
+	switch format {
+	case OutputJSON:
+		err = json.Unmarshal([]byte(body), v)
+	case OutputYAML:
+		err = yaml.Unmarshal([]byte(body), v)
+	case OutputCSV:
+		records, ok := v.(*[][]string)
+		if !ok {
+			return NewLLMError(ErrorTypeInvalidInput, fmt.Sprintf("CSV decodes into *[][]string, not %T", v), nil)
+		}
+		*records, err = csv.NewReader(strings.NewReader(body)).ReadAll()
+	case OutputMarkdown:
+		text, ok := v.(*string)
+		if !ok {
+			return NewLLMError(ErrorTypeInvalidInput, fmt.Sprintf("Markdown decodes into *string, not %T", v), nil)
+		}
+		*text = body
+	}
+	if err != nil {
+		return NewLLMError(ErrorTypeResponse, fmt.Sprintf("failed to decode %s response", format), err)
+	}
+	return nil
+}
+
+// outputFormatProcessor returns the response processor applying ParseOutput.
+func outputFormatProcessor(format OutputFormat) ResponseProcessor {
+	return func(_ context.Context, _ *Prompt, response string) (string, error) {
+		return ParseOutput(response, format)
+	}
+}
+
+// dropTrailingCommas removes the commas preceding a closing brace or
+// bracket outside of JSON strings.
+func dropTrailingCommas(text string) string {
+	var b strings.Builder
+	b.Grow(len(text))
+	inString, escaped := false, false
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case inString:
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case c == ',':
+			next := strings.TrimLeft(text[i+1:], " \t\r\n")
+			if next != "" && (next[0] == '}' || next[0] == ']') {
+				continue
+			}
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
@@ -0,0 +1,100 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm/providers"
+	"github.com/teilomillet/gollm/utils"
+)
+
+func TestParseOutput(t *testing.T) {
+	for _, tc := range []struct {
+		name, response string
+		format         OutputFormat
+		want           string
+		valid          bool
+	}{
+		{"JSONFenced", "```json\n{\"a\": [1, 2,],}\n```", OutputJSON, `{"a": [1, 2]}`, true},
+		{"JSONInProse", "Here it is: [{\"s\": \"a, }\"}] Hope it helps!", OutputJSON, `[{"s": "a, }"}]`, true},
+		{"JSONInvalid", "{not json}", OutputJSON, "", false},
+		{"JSONTruncated", "{\"a\": [1, 2", OutputJSON, "", false},
+		{"YAML", "```yaml\nname: Ada\nlanguages: [go]\n```", OutputYAML, "name: Ada\nlanguages: [go]", true},
+		{"YAMLInvalid", "name: [unclosed", OutputYAML, "", false},
+		{"CSV", "name,age\nAda,36\n", OutputCSV, "name,age\nAda,36", true},
+		{"CSVInvalid", "name,age\nAda", OutputCSV, "", false},
+		{"MarkdownFenced", "```markdown\n# Title\n```", OutputMarkdown, "# Title", true},
+		{"MarkdownCodeBlock", "```go\nfmt.Println()\n```", OutputMarkdown, "```go\nfmt.Println()\n```", true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseOutput(tc.response, tc.format)
+			if !tc.valid {
+				var llmErr *LLMError
+				require.ErrorAs(t, err, &llmErr)
+				assert.Equal(t, ErrorTypeResponse, llmErr.Type)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestDecodeOutput(t *testing.T) {
+	var value struct {
+		Name string `json:"name" yaml:"name"`
+	}
+	require.NoError(t, DecodeOutput("```json\n{\"name\": \"Ada\",}\n```", OutputJSON, &value))
+	assert.Equal(t, "Ada", value.Name)
+
+	var records [][]string
+	require.NoError(t, DecodeOutput("name\nAda", OutputCSV, &records))
+	assert.Equal(t, [][]string{{"name"}, {"Ada"}}, records)
+	assert.Error(t, DecodeOutput("name\nAda", OutputCSV, &value))
+}
+
+func TestOutputFormat(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("{\"choices\":[{\"message\":{\"content\":\"```json\\n{\\\"ok\\\": true,}\\n```\"}}]}"))
+	}))
+	defer server.Close()
+
+	l := &LLMImpl{
+		Provider: &localOpenAIProvider{OpenAIProvider: providers.NewOpenAIProvider("fake-key", "gpt-4o", nil).(*providers.OpenAIProvider), url: server.URL},
+		Options:  make(map[string]interface{}),
+		client:   server.Client(),
+		logger:   utils.NewLogger(utils.LogLevelOff),
+	}
+	prompt := NewPrompt("Is it ok?", WithOutput("An object with an ok field"), WithOutputFormat(OutputJSON))
+	assert.Contains(t, prompt.String(), "Expected Output Format:\nAn object with an ok field\nRespond with valid JSON only")
+
+	response, err := l.Generate(context.Background(), prompt)
+	require.NoError(t, err)
+	assert.Equal(t, `{"ok": true}`, response)
+}
+
+func TestOutputFormatWithMemory(t *testing.T) {
+	ctx := context.Background()
+	l, mock := newOfflineMemoryLLM(t)
+	prompt := NewPrompt("Is it ok?", WithOutputFormat(OutputJSON))
+	const fenced = "```json\n{\"ok\": true,}\n```"
+
+	mock.QueueResponse(fenced)
+	response, err := l.Generate(ctx, prompt)
+	require.NoError(t, err)
+	assert.Equal(t, `{"ok": true}`, response, "the response is parsed in the format")
+	messages := mock.Calls()[0].Messages
+	assert.Contains(t, messages[len(messages)-1].Content, "Respond with valid JSON only")
+	assert.Equal(t, "Is it ok?", l.GetMemory()[0].Content, "the memory keeps the input")
+
+	l.SetUseStructuredMessages(false)
+	mock.QueueResponse(fenced)
+	response, err = l.Generate(ctx, prompt)
+	require.NoError(t, err)
+	assert.Equal(t, `{"ok": true}`, response)
+	assert.Contains(t, mock.Calls()[1].Prompt, "Respond with valid JSON only")
+}
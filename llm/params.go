@@ -0,0 +1,11 @@
+package llm
+
+import "github.com/teilomillet/gollm/providers"
+
+// translateParams translates the generic sampling options of a request to
+// the provider's naming and ranges, logging the options clamped or dropped.
+func (l *LLMImpl) translateParams(options map[string]interface{}) {
+	for _, warning := range providers.TranslateParams(l.Provider, options) {
+		l.logger.Warn("Adjusted request option", "provider", l.Provider.Name(), "change", warning)
+	}
+}
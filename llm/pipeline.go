@@ -0,0 +1,263 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/teilomillet/gollm/utils"
+)
+
+// PromptProcessor modifies a prompt before it is sent, e.g. to inject a
+// system prompt or add context. It receives a copy of the caller's prompt.
+type PromptProcessor func(ctx context.Context, prompt *Prompt) error
+
+// ResponseProcessor modifies a response after it is parsed, e.g. to strip
+// markdown fences. It receives the prompt as sent.
+type ResponseProcessor func(ctx context.Context, prompt *Prompt, response string) (string, error)
+
+// pipeline holds the processors applied to every request of an LLM and of
+// the LLMs derived from it for profiles.
+type pipeline struct {
+	mu       sync.RWMutex
+	prompt   []PromptProcessor
+	response []ResponseProcessor
+}
+
+// processors returns the processors of the pipeline followed by those of the
+// request.
+func (p *pipeline) processors(config *GenerateConfig) ([]PromptProcessor, []ResponseProcessor) {
+	if p == nil {
+		return config.PromptProcessors, config.ResponseProcessors
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	prompt := append(p.prompt[:len(p.prompt):len(p.prompt)], config.PromptProcessors...)
+	response := append(p.response[:len(p.response):len(p.response)], config.ResponseProcessors...)
+	return prompt, response
+}
+
+// ProcessorUser is implemented by LLMs that apply processors to all their
+// requests.
+type ProcessorUser interface {
+	// UsePromptProcessors appends processors run on every prompt before it is sent.
+	UsePromptProcessors(processors ...PromptProcessor)
+	// UseResponseProcessors appends processors run on every response after it is parsed.
+	UseResponseProcessors(processors ...ResponseProcessor)
+}
+
+// UsePromptProcessors appends processors run, in order, on every prompt
+// before it is sent, ahead of the processors of the request.
+//
+// Example usage:
+//
+//	l.UsePromptProcessors(llm.InjectSystemPrompt("You are a support agent for Acme."), llm.AddCurrentDate(time.DateOnly))
+func (l *LLMImpl) UsePromptProcessors(processors ...PromptProcessor) {
+	l.ensurePipeline()
+	l.pipeline.mu.Lock()
+	defer l.pipeline.mu.Unlock()
+	l.pipeline.prompt = append(l.pipeline.prompt, processors...)
+}
+
+// UseResponseProcessors appends processors run, in order, on every response
+// after it is parsed, ahead of the processors of the request.
+func (l *LLMImpl) UseResponseProcessors(processors ...ResponseProcessor) {
+	l.ensurePipeline()
+	l.pipeline.mu.Lock()
+	defer l.pipeline.mu.Unlock()
+	l.pipeline.response = append(l.pipeline.response, processors...)
+}
+
+// processors returns the processors of the LLM followed by those of the
+// request.
+func (l *LLMImpl) processors(config *GenerateConfig) ([]PromptProcessor, []ResponseProcessor) {
+	l.optionsMutex.RLock()
+	p := l.pipeline
+	l.optionsMutex.RUnlock()
+	return p.processors(config)
+}
+
+// ensurePipeline creates the pipeline of LLMs not created by NewLLM.
+func (l *LLMImpl) ensurePipeline() {
+	l.optionsMutex.Lock()
+	defer l.optionsMutex.Unlock()
+	if l.pipeline == nil {
+		l.pipeline = &pipeline{}
+	}
+}
+
+// UsePromptProcessors appends prompt processors to the underlying LLM.
+func (l *LLMWithMemory) UsePromptProcessors(processors ...PromptProcessor) {
+	if u, ok := l.LLM.(ProcessorUser); ok {
+		u.UsePromptProcessors(processors...)
+	}
+}
+
+// UseResponseProcessors appends response processors to the underlying LLM.
+func (l *LLMWithMemory) UseResponseProcessors(processors ...ResponseProcessor) {
+	if u, ok := l.LLM.(ProcessorUser); ok {
+		u.UseResponseProcessors(processors...)
+	}
+}
+
+// WithPromptProcessors appends processors run on the prompt of a single
+// Generate call, after those of the LLM.
+func WithPromptProcessors(processors ...PromptProcessor) GenerateOption {
+	return func(c *GenerateConfig) {
+		c.PromptProcessors = append(c.PromptProcessors, processors...)
+	}
+}
+
+// WithResponseProcessors appends processors run on the response of a single
+// Generate call, after those of the LLM.
+func WithResponseProcessors(processors ...ResponseProcessor) GenerateOption {
+	return func(c *GenerateConfig) {
+		c.ResponseProcessors = append(c.ResponseProcessors, processors...)
+	}
+}
+
+// processPrompt runs the prompt processors on a copy of the prompt, leaving
+// the caller's prompt unchanged, then composes its system prompt layers.
+func processPrompt(ctx context.Context, prompt *Prompt, processors []PromptProcessor) (*Prompt, error) {
+	if len(processors) > 0 {
+		prompt = prompt.clone()
+		for i, process := range processors {
+			if err := process(ctx, prompt); err != nil {
+				return nil, NewLLMError(ErrorTypeInvalidInput, fmt.Sprintf("prompt processor %d failed", i+1), err)
+			}
+		}
+	}
+	return composeSystemLayers(prompt)
+}
+
+// processResponse runs the response processors on the response.
+func processResponse(ctx context.Context, prompt *Prompt, response string, processors []ResponseProcessor) (string, error) {
+	for i, process := range processors {
+		var err error
+		if response, err = process(ctx, prompt, response); err != nil {
+			return "", NewLLMError(ErrorTypeResponse, fmt.Sprintf("response processor %d failed", i+1), err)
+		}
+	}
+	return response, nil
+}
+
+// clone returns a copy of the prompt whose slices and maps can be modified
+// without affecting p.
+func (p *Prompt) clone() *Prompt {
+	c := *p
+	c.Directives = append([]string(nil), p.Directives...)
+	c.Examples = append([]string(nil), p.Examples...)
+	c.SystemLayers = append([]SystemLayer(nil), p.SystemLayers...)
+	c.FewShot = append([]Example(nil), p.FewShot...)
+	c.Messages = append([]PromptMessage(nil), p.Messages...)
+	c.Tools = append(p.Tools[:0:0], p.Tools...)
+	c.Images = append(p.Images[:0:0], p.Images...)
+	c.Documents = append(p.Documents[:0:0], p.Documents...)
+	if p.ToolChoice != nil {
+		c.ToolChoice = make(map[string]interface{}, len(p.ToolChoice))
+		for k, v := range p.ToolChoice {
+			c.ToolChoice[k] = v
+		}
+	}
+	return &c
+}
+
+// InjectSystemPrompt returns a prompt processor that places systemPrompt
+// ahead of the prompt's own system prompt, if any.
+func InjectSystemPrompt(systemPrompt string) PromptProcessor {
+	return func(_ context.Context, prompt *Prompt) error {
+		if prompt.SystemPrompt == "" {
+			prompt.SystemPrompt = systemPrompt
+		} else {
+			prompt.SystemPrompt = systemPrompt + "\n\n" + prompt.SystemPrompt
+		}
+		return nil
+	}
+}
+
+// AddContext returns a prompt processor that appends the text returned by fn,
+// e.g. the profile of the current user, to the prompt's context. Empty texts
+// are skipped.
+func AddContext(fn func(ctx context.Context) (string, error)) PromptProcessor {
+	return func(ctx context.Context, prompt *Prompt) error {
+		text, err := fn(ctx)
+		if err != nil || text == "" {
+			return err
+		}
+		if prompt.Context == "" {
+			prompt.Context = text
+		} else {
+			prompt.Context += "\n" + text
+		}
+		return nil
+	}
+}
+
+// AddCurrentDate returns a prompt processor that appends the current date,
+// formatted with layout (e.g. time.DateOnly), to the prompt's context.
+func AddCurrentDate(layout string) PromptProcessor {
+	return AddCurrentDateFrom(utils.SystemClock(), layout)
+}
+
+// AddCurrentDateFrom is AddCurrentDate with the date read from clock, so
+// tests get a fixed date.
+func AddCurrentDateFrom(clock utils.Clock, layout string) PromptProcessor {
+	return AddContext(func(context.Context) (string, error) {
+		return "Current date: " + clock.Now().Format(layout), nil
+	})
+}
+
+// ApplyTemplate returns a prompt processor that replaces the prompt's input
+// with the text/template tmpl executed on the prompt, e.g.
+// "Answer in French: {{.Input}}".
+func ApplyTemplate(tmpl string) PromptProcessor {
+	parsed, parseErr := template.New("input").Option("missingkey=error").Parse(tmpl)
+	return func(_ context.Context, prompt *Prompt) error {
+		if parseErr != nil {
+			return fmt.Errorf("failed to parse template: %w", parseErr)
+		}
+		var input strings.Builder
+		if err := parsed.Execute(&input, prompt); err != nil {
+			return fmt.Errorf("failed to execute template: %w", err)
+		}
+		prompt.Input = input.String()
+		return nil
+	}
+}
+
+// StripCodeFences returns a response processor that removes the markdown
+// code fence, e.g. ```json, enclosing a response. Responses that aren't
+// fenced are returned unchanged.
+func StripCodeFences() ResponseProcessor {
+	return func(_ context.Context, _ *Prompt, response string) (string, error) {
+		if body, _, ok := cutCodeFence(response); ok {
+			return body, nil
+		}
+		return response, nil
+	}
+}
+
+// cutCodeFence returns the trimmed body and language of the markdown code
+// fence enclosing text, or false if text isn't fenced.
+func cutCodeFence(text string) (body, lang string, ok bool) {
+	trimmed := strings.TrimSpace(text)
+	if !strings.HasPrefix(trimmed, "```") || !strings.HasSuffix(trimmed, "```") || len(trimmed) < 6 {
+		return text, "", false
+	}
+	body = strings.TrimSuffix(trimmed, "```")
+	newline := strings.IndexByte(body, '\n')
+	if newline == -1 {
+		return strings.TrimSpace(strings.TrimPrefix(body, "```")), "", true
+	}
+	return strings.TrimSpace(body[newline+1:]), strings.TrimSpace(body[3:newline]), true
+}
+
+// TrimSpace returns a response processor that removes leading and trailing
+// white space.
+func TrimSpace() ResponseProcessor {
+	return func(_ context.Context, _ *Prompt, response string) (string, error) {
+		return strings.TrimSpace(response), nil
+	}
+}
@@ -0,0 +1,76 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm/providers"
+	"github.com/teilomillet/gollm/utils"
+)
+
+func TestPipeline(t *testing.T) {
+	var sent []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		sent = append(sent, body)
+		w.Write([]byte("{\"choices\":[{\"message\":{\"content\":\"```json\\n{\\\"ok\\\": true}\\n```\\n\"}}]}"))
+	}))
+	defer server.Close()
+
+	l := &LLMImpl{
+		Provider: &localOpenAIProvider{OpenAIProvider: providers.NewOpenAIProvider("fake-key", "gpt-4o", nil).(*providers.OpenAIProvider), url: server.URL},
+		Options:  make(map[string]interface{}),
+		client:   server.Client(),
+		logger:   utils.NewLogger(utils.LogLevelOff),
+	}
+	l.UsePromptProcessors(InjectSystemPrompt("You are terse."))
+	l.UseResponseProcessors(StripCodeFences())
+
+	ctx := context.Background()
+	prompt := NewPrompt("Is it ok?")
+	response, err := l.Generate(ctx, prompt,
+		WithPromptProcessors(ApplyTemplate("Answer in JSON: {{.Input}}"), AddContext(func(context.Context) (string, error) {
+			return "User: Ada", nil
+		})),
+		WithResponseProcessors(func(_ context.Context, p *Prompt, response string) (string, error) {
+			assert.Equal(t, "Answer in JSON: Is it ok?", p.Input, "response processors see the prompt as sent")
+			return response, nil
+		}))
+	require.NoError(t, err)
+	assert.Equal(t, `{"ok": true}`, response)
+	assert.Equal(t, "Is it ok?", prompt.Input, "the caller's prompt is unchanged")
+	assert.Empty(t, prompt.SystemPrompt)
+
+	messages := sent[0]["messages"].([]interface{})
+	assert.Equal(t, "You are terse.", messages[0].(map[string]interface{})["content"])
+	assert.Contains(t, messages[1].(map[string]interface{})["content"], "Answer in JSON: Is it ok?")
+	assert.Contains(t, messages[1].(map[string]interface{})["content"], "User: Ada")
+
+	_, err = l.Generate(ctx, prompt, WithPromptProcessors(func(context.Context, *Prompt) error {
+		return errors.New("no profile")
+	}))
+	assert.ErrorContains(t, err, "prompt processor 2 failed")
+	assert.Len(t, sent, 1, "failed prompts aren't sent")
+}
+
+func TestStripCodeFences(t *testing.T) {
+	strip := StripCodeFences()
+	for input, want := range map[string]string{
+		"```json\n{\"a\": 1}\n```": `{"a": 1}`,
+		"```\nplain\n```":          "plain",
+		"```inline```":             "inline",
+		"no fences":                "no fences",
+		"text then ```code```":     "text then ```code```",
+	} {
+		got, err := strip(context.Background(), nil, input)
+		require.NoError(t, err)
+		assert.Equal(t, want, got, input)
+	}
+}
@@ -0,0 +1,115 @@
+package llm
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/teilomillet/gollm/config"
+	"github.com/teilomillet/gollm/providers"
+)
+
+// WithProfile sends the request with the named profile's provider, model and
+// options instead of the LLM's own. The profile must have been added with
+// config.SetProfile. Options set with WithRequestOption still take precedence
+// over the profile's options.
+//
+// Example:
+//
+//	response, err := l.Generate(ctx, prompt, llm.WithProfile("fast"))
+func WithProfile(name string) GenerateOption {
+	return func(c *GenerateConfig) {
+		c.Profile = name
+	}
+}
+
+// WithModel sends the request to another model, given by name or by a model
+// alias added with config.SetModelAlias. An alias can also switch the
+// provider; a plain model name is served by the LLM's provider.
+//
+// Example:
+//
+//	summary, err := l.Generate(ctx, prompt, llm.WithModel("cheap-summarizer"))
+func WithModel(model string) GenerateOption {
+	return func(c *GenerateConfig) {
+		c.Model = model
+	}
+}
+
+// profileCache holds the LLMs created for profiles and per-request models, so
+// each one's provider is created once per LLM instance.
+type profileCache struct {
+	mu   sync.Mutex
+	llms map[string]*LLMImpl
+}
+
+// routed returns the LLM serving a request that selects a profile or a model,
+// or nil when the request is served by l itself.
+func (l *LLMImpl) routed(gen *GenerateConfig) (*LLMImpl, error) {
+	switch {
+	case gen.Profile != "":
+		profile, ok := l.config.Profiles[gen.Profile]
+		if !ok {
+			return nil, NewLLMError(ErrorTypeInvalidInput, fmt.Sprintf("unknown profile %q", gen.Profile), nil)
+		}
+		if gen.Model != "" {
+			profile.Model = gen.Model
+		}
+		return l.derived("profile:"+gen.Profile+"/"+gen.Model, profile)
+	case gen.Model != "" && gen.Model != l.config.Model:
+		return l.derived("model:"+gen.Model, config.Profile{Model: gen.Model})
+	default:
+		return nil, nil
+	}
+}
+
+// derived returns the LLM serving a profile, creating it on first use. It
+// shares the logger, HTTP settings and retry policy of l.
+func (l *LLMImpl) derived(key string, profile config.Profile) (*LLMImpl, error) {
+	l.profiles.mu.Lock()
+	defer l.profiles.mu.Unlock()
+	if target, ok := l.profiles.llms[key]; ok {
+		return target, nil
+	}
+
+	cfg := *l.config
+	if profile.Provider != "" {
+		cfg.Provider = profile.Provider
+	}
+	if profile.Model != "" {
+		cfg.Model = profile.Model
+	}
+	// Profiles do not nest
+	cfg.Profiles = nil
+
+	registry := l.registry
+	if registry == nil {
+		registry = providers.GetDefaultRegistry()
+	}
+	created, err := NewLLM(&cfg, l.logger, registry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create LLM for %s: %w", key, err)
+	}
+	target := created.(*LLMImpl)
+	target.pipeline = l.pipeline
+	target.caching = l.caching
+	for k, v := range profile.Options {
+		target.Options[k] = v
+	}
+
+	if l.profiles.llms == nil {
+		l.profiles.llms = make(map[string]*LLMImpl)
+	}
+	l.profiles.llms[key] = target
+	return target, nil
+}
+
+// routedOptions returns the options that forward a request to a routed LLM:
+// the original options followed by one that clears the routing.
+func routedOptions(opts []GenerateOption) []GenerateOption {
+	forwarded := make([]GenerateOption, len(opts), len(opts)+1)
+	copy(forwarded, opts)
+	return append(forwarded, func(c *GenerateConfig) {
+		c.Profile = ""
+		c.Model = ""
+	})
+}
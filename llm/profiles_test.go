@@ -0,0 +1,79 @@
+package llm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm/config"
+	"github.com/teilomillet/gollm/providers"
+	"github.com/teilomillet/gollm/utils"
+)
+
+func TestWithProfile(t *testing.T) {
+	cfg := config.NewConfig()
+	config.ApplyOptions(cfg,
+		config.SetProvider("mock"),
+		config.SetModel("default-model"),
+		config.SetMaxRetries(0),
+		config.SetProfile("fast", config.Profile{Model: "fast-model", Options: map[string]interface{}{"temperature": 0.1, "top_p": 0.5}}),
+	)
+	created, err := NewLLM(cfg, utils.NewLogger(utils.LogLevelOff), providers.NewProviderRegistry())
+	require.NoError(t, err)
+	l := created.(*LLMImpl)
+	ctx := context.Background()
+
+	_, err = l.Generate(ctx, NewPrompt("Hi"), WithProfile("fast"))
+	require.NoError(t, err)
+	_, err = l.Generate(ctx, NewPrompt("Again"), WithProfile("fast"), WithRequestOption("top_p", 0.9))
+	require.NoError(t, err)
+
+	assert.Equal(t, 0, l.Provider.(*providers.MockProvider).CallCount())
+	fast, err := l.routed(&GenerateConfig{Profile: "fast"})
+	require.NoError(t, err)
+	mock := fast.Provider.(*providers.MockProvider)
+	assert.Equal(t, 2, mock.CallCount(), "the profile LLM is reused")
+	call, ok := mock.LastCall()
+	require.True(t, ok)
+	assert.Contains(t, call.Prompt, "Again")
+	assert.Equal(t, 0.1, call.Options["temperature"])
+	assert.Equal(t, 0.9, call.Options["top_p"], "request options override the profile's")
+	assert.Equal(t, "fast-model", fast.config.Model)
+
+	_, err = l.Generate(ctx, NewPrompt("Hi"), WithProfile("missing"))
+	var llmErr *LLMError
+	require.ErrorAs(t, err, &llmErr)
+	assert.Equal(t, ErrorTypeInvalidInput, llmErr.Type)
+}
+
+func TestModelAliases(t *testing.T) {
+	cfg := config.NewConfig()
+	config.ApplyOptions(cfg,
+		config.SetProvider("openai"),
+		config.SetAPIKey("sk-test"),
+		config.SetModel("default-chat"),
+		config.SetMaxRetries(0),
+		config.SetModelAlias("default-chat", "mock", "chat-model"),
+		config.SetModelAlias("cheap-summarizer", "mock", "summary-model"),
+	)
+	created, err := NewLLM(cfg, utils.NewLogger(utils.LogLevelOff), providers.NewProviderRegistry())
+	require.NoError(t, err)
+	l := created.(*LLMImpl)
+	assert.Equal(t, "mock", l.Provider.Name(), "the alias selects the provider")
+	assert.Equal(t, "chat-model", l.config.Model)
+
+	_, err = l.Generate(context.Background(), NewPrompt("Summarize"), WithModel("cheap-summarizer"))
+	require.NoError(t, err)
+	assert.Equal(t, 0, l.Provider.(*providers.MockProvider).CallCount())
+
+	summarizer, err := l.routed(&GenerateConfig{Model: "cheap-summarizer"})
+	require.NoError(t, err)
+	call, ok := summarizer.Provider.(*providers.MockProvider).LastCall()
+	require.True(t, ok)
+	assert.Equal(t, "summary-model", call.Options["model"])
+
+	same, err := l.routed(&GenerateConfig{Model: "chat-model"})
+	require.NoError(t, err)
+	assert.Nil(t, same, "the LLM's own model is not routed")
+}
@@ -1,11 +1,14 @@
 package llm
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"strings"
 
 	"github.com/invopop/jsonschema"
+	"github.com/teilomillet/gollm/providers"
+	"github.com/teilomillet/gollm/types"
 	"github.com/teilomillet/gollm/utils"
 )
 
@@ -56,6 +59,17 @@ type Prompt struct {
 	Messages        []PromptMessage        `json:"messages,omitempty" jsonschema:"description=List of messages for the conversation"`
 	Tools           []utils.Tool           `json:"tools,omitempty" jsonschema:"description=Available tools for the LLM to use"`
 	ToolChoice      map[string]interface{} `json:"tool_choice,omitempty" jsonschema:"description=Configuration for tool selection behavior"`
+	Images          []types.Image          `json:"images,omitempty" jsonschema:"description=Images sent alongside the input for vision-capable models"`
+	Documents       []types.Document       `json:"documents,omitempty" jsonschema:"description=Documents such as PDFs sent alongside the input for models with document understanding"`
+
+	SystemLayers      []SystemLayer   `json:"systemLayers,omitempty" jsonschema:"description=Layers composed after the system prompt, such as persona, task instructions and safety preamble"`
+	SystemTokenBudget int             `json:"systemTokenBudget,omitempty" jsonschema:"minimum=0,description=Maximum estimated tokens of the composed system prompt"`
+	SystemPlacement   SystemPlacement `json:"systemPlacement,omitempty" jsonschema:"enum=,enum=user,description=Where the system prompt is sent"`
+
+	FewShot            []Example `json:"fewShot,omitempty" jsonschema:"description=Input and output pairs shown to the LLM as examples"`
+	ExampleTokenBudget int       `json:"exampleTokenBudget,omitempty" jsonschema:"minimum=0,description=Maximum estimated tokens of the few-shot examples"`
+
+	OutputFormat OutputFormat `json:"outputFormat,omitempty" jsonschema:"enum=json,enum=yaml,enum=markdown,enum=csv,description=Format the response is requested and checked in"`
 }
 
 // PromptOption is a function type that modifies a Prompt.
@@ -134,10 +148,19 @@ func WithTools(tools []utils.Tool) PromptOption {
 	}
 }
 
-// WithToolChoice specifies how tools should be selected by the LLM.
+// Tool choice modes. Any other tool choice names the tool to call.
+const (
+	ToolChoiceAuto     = providers.ToolChoiceAuto     // The model decides whether to call tools
+	ToolChoiceNone     = providers.ToolChoiceNone     // The model must not call tools
+	ToolChoiceRequired = providers.ToolChoiceRequired // The model must call at least one tool
+)
+
+// WithToolChoice specifies how tools should be selected by the LLM for this
+// request, overriding the LLM's tool choice.
 //
 // Parameters:
-//   - choice: Tool selection strategy
+//   - choice: ToolChoiceAuto, ToolChoiceNone, ToolChoiceRequired, or the name
+//     of the tool the model must call
 func WithToolChoice(choice string) PromptOption {
 	return func(p *Prompt) {
 		p.ToolChoice = map[string]interface{}{
@@ -146,6 +169,79 @@ func WithToolChoice(choice string) PromptOption {
 	}
 }
 
+// WithImage attaches one or more images to the prompt.
+// The images are sent alongside the input to vision-capable models.
+//
+// Parameters:
+//   - images: Images to attach
+func WithImage(images ...types.Image) PromptOption {
+	return func(p *Prompt) {
+		p.Images = append(p.Images, images...)
+	}
+}
+
+// WithImageURL attaches a remotely hosted image to the prompt.
+//
+// Parameters:
+//   - url: URL of the image (http(s) or data: URL)
+func WithImageURL(url string) PromptOption {
+	return WithImage(types.Image{URL: url})
+}
+
+// WithImageBase64 attaches base64-encoded image data to the prompt.
+//
+// Parameters:
+//   - data: Base64-encoded image bytes
+//   - mediaType: MIME type of the image (e.g., "image/png")
+func WithImageBase64(data, mediaType string) PromptOption {
+	return WithImage(types.Image{Data: data, MediaType: mediaType})
+}
+
+// WithImageFile attaches a local image file to the prompt.
+// The file is read when the request is prepared, so errors surface from Generate.
+//
+// Parameters:
+//   - path: Path to the image file
+func WithImageFile(path string) PromptOption {
+	return WithImage(types.Image{Path: path})
+}
+
+// WithDocument attaches one or more documents, such as PDFs, to the prompt.
+// Documents are sent natively to providers with document understanding.
+//
+// Parameters:
+//   - documents: Documents to attach
+func WithDocument(documents ...types.Document) PromptOption {
+	return func(p *Prompt) {
+		p.Documents = append(p.Documents, documents...)
+	}
+}
+
+// WithDocumentURL attaches a remotely hosted document to the prompt.
+//
+// Parameters:
+//   - url: URL of the document
+func WithDocumentURL(url string) PromptOption {
+	return WithDocument(types.Document{URL: url})
+}
+
+// WithDocumentFile attaches a local document to the prompt.
+// The file is read when the request is prepared, so errors surface from Generate.
+//
+// Parameters:
+//   - path: Path to the document file
+func WithDocumentFile(path string) PromptOption {
+	return WithDocument(types.Document{Path: path})
+}
+
+// WithDocumentFileID attaches a document previously uploaded to the provider.
+//
+// Parameters:
+//   - fileID: Provider file ID returned by an upload
+func WithDocumentFileID(fileID string) PromptOption {
+	return WithDocument(types.Document{FileID: fileID})
+}
+
 // WithMessages sets the complete list of conversation messages.
 //
 // Parameters:
@@ -202,6 +298,45 @@ func WithJSONSchemaValidation() GenerateOption {
 	}
 }
 
+// WithRequestOption sets a provider option (e.g., "temperature", "max_tokens")
+// for a single Generate call without changing the LLM's options. This makes it
+// safe to vary parameters across concurrent requests on the same LLM.
+//
+// Parameters:
+//   - key: Option name as understood by the provider
+//   - value: Option value
+func WithRequestOption(key string, value interface{}) GenerateOption {
+	return func(c *GenerateConfig) {
+		if c.Options == nil {
+			c.Options = make(map[string]interface{})
+		}
+		c.Options[key] = value
+	}
+}
+
+// WithStreamRequestOption sets a provider option for a single Stream call,
+// like WithRequestOption.
+func WithStreamRequestOption(key string, value interface{}) StreamOption {
+	return func(c *StreamConfig) {
+		if c.Options == nil {
+			c.Options = make(map[string]interface{})
+		}
+		c.Options[key] = value
+	}
+}
+
+// WithGrammar constrains the response of a single Generate call to a GBNF
+// grammar, for providers accepting one such as llama.cpp servers. Grammars
+// for JSON schemas can be generated with providers.SchemaToGBNF;
+// GenerateWithSchema does so automatically.
+//
+// Example:
+//
+//	answer, err := l.Generate(ctx, prompt, llm.WithGrammar(`root ::= "yes" | "no"`))
+func WithGrammar(grammar string) GenerateOption {
+	return WithRequestOption("grammar", grammar)
+}
+
 // WithExamples adds example conversations or outputs to guide the LLM.
 // If a single example ends with .txt or .jsonl, it's treated as a file path.
 //
@@ -240,9 +375,10 @@ func (p *Prompt) Apply(opts ...PromptOption) {
 func (p *Prompt) String() string {
 	var builder strings.Builder
 
-	if p.SystemPrompt != "" {
+	// Without a budget, composing never fails
+	if system, _ := p.composeSystemPrompt(0); system != "" {
 		builder.WriteString("System: ")
-		builder.WriteString(p.SystemPrompt)
+		builder.WriteString(system)
 		if p.SystemCacheType != "" {
 			builder.WriteString(fmt.Sprintf(" (Cache: %s)", p.SystemCacheType))
 		}
@@ -267,18 +403,19 @@ func (p *Prompt) String() string {
 
 	builder.WriteString(p.Input)
 
-	if p.Output != "" {
+	if output := p.outputSpec(); output != "" {
 		builder.WriteString("\n\nExpected Output Format:\n")
-		builder.WriteString(p.Output)
+		builder.WriteString(output)
 	}
 
-	if len(p.Examples) > 0 {
+	if fewShot := p.fewShotExamples(); len(p.Examples) > 0 || len(fewShot) > 0 {
 		builder.WriteString("\n\nExamples:\n")
 		for _, example := range p.Examples {
 			builder.WriteString("- ")
 			builder.WriteString(example)
 			builder.WriteString("\n")
 		}
+		writeFewShot(&builder, fewShot)
 	}
 
 	if p.MaxLength > 0 {
@@ -317,6 +454,13 @@ func (p *Prompt) Validate() error {
 //   - JSON schema as bytes
 //   - Error if schema generation fails
 func (p *Prompt) GenerateJSONSchema(opts ...SchemaOption) ([]byte, error) {
+	// The default schema is the same for every prompt
+	if len(opts) == 0 {
+		promptSchema.once.Do(func() {
+			promptSchema.schema, promptSchema.err = jsonschema.Reflect(&Prompt{}).MarshalJSON()
+		})
+		return bytes.Clone(promptSchema.schema), promptSchema.err
+	}
 	reflector := &jsonschema.Reflector{}
 	for _, opt := range opts {
 		opt(reflector)
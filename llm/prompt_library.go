@@ -0,0 +1,465 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// PromptLibrary is a thread-safe registry of named, versioned prompt templates.
+// Templates can be registered in code or loaded from a directory of prompt
+// files, so prompts can be managed outside Go code and reloaded at runtime.
+//
+// Prompt files use YAML (or JSON) front-matter followed by the template body:
+//
+//	---
+//	name: summarize
+//	version: 2
+//	description: Summarizes text
+//	model: gpt-4o-mini
+//	variables: [text]
+//	defaults:
+//	  words: 100
+//	system_prompt: You are a concise summarizer.
+//	---
+//	Summarize the following in {{.words}} words:
+//	{{.text}}
+//
+// Files with a .json extension may instead hold the whole definition as a JSON
+// object, with the body in the "template" field. The name defaults to the file
+// name without extension and the version defaults to "1".
+type PromptLibrary struct {
+	mu      sync.RWMutex
+	prompts map[string]map[string]*PromptTemplate // name -> version -> template
+	dir     string                                // Directory loaded by LoadDir, used by Reload
+	modTime map[string]time.Time                  // Modification times of loaded files
+}
+
+// promptFileSpec is the front-matter (or JSON document) of a prompt file.
+type promptFileSpec struct {
+	Name         string                 `yaml:"name" json:"name"`
+	Version      promptVersion          `yaml:"version" json:"version"`
+	Description  string                 `yaml:"description" json:"description"`
+	Model        string                 `yaml:"model" json:"model"`
+	Provider     string                 `yaml:"provider" json:"provider"`
+	Variables    []string               `yaml:"variables" json:"variables"`
+	Defaults     map[string]interface{} `yaml:"defaults" json:"defaults"`
+	Partials     map[string]string      `yaml:"partials" json:"partials"`
+	Strict       bool                   `yaml:"strict" json:"strict"`
+	SystemPrompt string                 `yaml:"system_prompt" json:"system_prompt"`
+	Directives   []string               `yaml:"directives" json:"directives"`
+	Output       string                 `yaml:"output" json:"output"`
+	MaxLength    int                    `yaml:"max_length" json:"max_length"`
+	Template     string                 `yaml:"template" json:"template"`
+}
+
+// promptVersion accepts versions written as either strings or numbers.
+type promptVersion string
+
+func (v *promptVersion) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*v = promptVersion(s)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("version must be a string or number: %w", err)
+	}
+	*v = promptVersion(n.String())
+	return nil
+}
+
+// promptFileExtensions lists the file types loaded from a prompt directory.
+var promptFileExtensions = map[string]bool{
+	".prompt": true,
+	".md":     true,
+	".txt":    true,
+	".tmpl":   true,
+	".yaml":   true,
+	".yml":    true,
+	".json":   true,
+}
+
+// NewPromptLibrary creates an empty prompt library.
+func NewPromptLibrary() *PromptLibrary {
+	return &PromptLibrary{
+		prompts: make(map[string]map[string]*PromptTemplate),
+		modTime: make(map[string]time.Time),
+	}
+}
+
+// LoadPromptLibrary creates a prompt library from the prompt files in dir.
+func LoadPromptLibrary(dir string) (*PromptLibrary, error) {
+	lib := NewPromptLibrary()
+	if err := lib.LoadDir(dir); err != nil {
+		return nil, err
+	}
+	return lib, nil
+}
+
+// Register adds templates to the library. A template with the same name and
+// version as an existing one replaces it. Templates without a version are
+// registered as version "1".
+func (lib *PromptLibrary) Register(templates ...*PromptTemplate) {
+	lib.mu.Lock()
+	defer lib.mu.Unlock()
+	for _, pt := range templates {
+		lib.add(lib.prompts, pt)
+	}
+}
+
+func (lib *PromptLibrary) add(prompts map[string]map[string]*PromptTemplate, pt *PromptTemplate) {
+	if pt.Version == "" {
+		pt.Version = "1"
+	}
+	if prompts[pt.Name] == nil {
+		prompts[pt.Name] = make(map[string]*PromptTemplate)
+	}
+	prompts[pt.Name][pt.Version] = pt
+}
+
+// Get returns the latest version of the named template.
+func (lib *PromptLibrary) Get(name string) (*PromptTemplate, error) {
+	lib.mu.RLock()
+	defer lib.mu.RUnlock()
+	versions, ok := lib.prompts[name]
+	if !ok || len(versions) == 0 {
+		return nil, NewLLMError(ErrorTypeInvalidInput, fmt.Sprintf("prompt %q not found", name), nil)
+	}
+	return versions[latestVersion(versions)], nil
+}
+
+// GetVersion returns a specific version of the named template.
+func (lib *PromptLibrary) GetVersion(name, version string) (*PromptTemplate, error) {
+	lib.mu.RLock()
+	defer lib.mu.RUnlock()
+	pt, ok := lib.prompts[name][version]
+	if !ok {
+		return nil, NewLLMError(ErrorTypeInvalidInput, fmt.Sprintf("prompt %q version %q not found", name, version), nil)
+	}
+	return pt, nil
+}
+
+// Names returns the names of all registered templates, sorted alphabetically.
+func (lib *PromptLibrary) Names() []string {
+	lib.mu.RLock()
+	defer lib.mu.RUnlock()
+	names := make([]string, 0, len(lib.prompts))
+	for name := range lib.prompts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Versions returns the available versions of the named template, oldest first.
+func (lib *PromptLibrary) Versions(name string) []string {
+	lib.mu.RLock()
+	defer lib.mu.RUnlock()
+	versions := make([]string, 0, len(lib.prompts[name]))
+	for v := range lib.prompts[name] {
+		versions = append(versions, v)
+	}
+	sort.Slice(versions, func(i, j int) bool { return compareVersions(versions[i], versions[j]) < 0 })
+	return versions
+}
+
+// LoadDir loads every prompt file in dir (recursively) into the library.
+// Templates already registered in code are kept; loaded files replace entries
+// with the same name and version. The directory is remembered for Reload.
+func (lib *PromptLibrary) LoadDir(dir string) error {
+	loaded, modTime, err := loadPromptDir(dir)
+	if err != nil {
+		return err
+	}
+
+	lib.mu.Lock()
+	defer lib.mu.Unlock()
+	for _, pt := range loaded {
+		lib.add(lib.prompts, pt)
+	}
+	lib.dir = dir
+	lib.modTime = modTime
+	return nil
+}
+
+// Reload re-reads the directory given to LoadDir and atomically replaces the
+// file-based templates. If any file fails to load, the library is left unchanged.
+func (lib *PromptLibrary) Reload() error {
+	lib.mu.RLock()
+	dir := lib.dir
+	lib.mu.RUnlock()
+	if dir == "" {
+		return fmt.Errorf("prompt library was not loaded from a directory")
+	}
+
+	loaded, modTime, err := loadPromptDir(dir)
+	if err != nil {
+		return err
+	}
+
+	lib.mu.Lock()
+	defer lib.mu.Unlock()
+	prompts := make(map[string]map[string]*PromptTemplate)
+	for _, versions := range lib.prompts {
+		for _, pt := range versions {
+			if _, fromFile := lib.modTime[pt.source]; !fromFile {
+				lib.add(prompts, pt)
+			}
+		}
+	}
+	for _, pt := range loaded {
+		lib.add(prompts, pt)
+	}
+	lib.prompts = prompts
+	lib.modTime = modTime
+	return nil
+}
+
+// Watch polls the loaded directory every interval and reloads the library when
+// prompt files are added, removed or modified. It blocks until ctx is done.
+// Reload errors are passed to onError (if non-nil) and the previous templates stay active.
+func (lib *PromptLibrary) Watch(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			changed, err := lib.changed()
+			if err == nil && changed {
+				err = lib.Reload()
+			}
+			if err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// changed reports whether the set or modification times of prompt files differ
+// from the last load.
+func (lib *PromptLibrary) changed() (bool, error) {
+	lib.mu.RLock()
+	dir, known := lib.dir, lib.modTime
+	lib.mu.RUnlock()
+
+	current, err := scanPromptDir(dir)
+	if err != nil {
+		return false, err
+	}
+	if len(current) != len(known) {
+		return true, nil
+	}
+	for path, mod := range current {
+		if prev, ok := known[path]; !ok || !prev.Equal(mod) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// scanPromptDir returns the modification times of the prompt files in dir.
+func scanPromptDir(dir string) (map[string]time.Time, error) {
+	files := make(map[string]time.Time)
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !promptFileExtensions[strings.ToLower(filepath.Ext(path))] {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files[path] = info.ModTime()
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan prompt directory %s: %w", dir, err)
+	}
+	return files, nil
+}
+
+func loadPromptDir(dir string) ([]*PromptTemplate, map[string]time.Time, error) {
+	files, err := scanPromptDir(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	templates := make([]*PromptTemplate, 0, len(paths))
+	for _, path := range paths {
+		pt, err := LoadPromptFile(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		templates = append(templates, pt)
+	}
+	return templates, files, nil
+}
+
+// LoadPromptFile parses a single prompt file into a PromptTemplate.
+// See PromptLibrary for the file format.
+func LoadPromptFile(path string) (*PromptTemplate, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read prompt file %s: %w", path, err)
+	}
+
+	var spec promptFileSpec
+	ext := strings.ToLower(filepath.Ext(path))
+	trimmed := bytes.TrimLeft(content, "\ufeff \t\r\n")
+	switch {
+	case ext == ".json":
+		if err := json.Unmarshal(content, &spec); err != nil {
+			return nil, fmt.Errorf("failed to parse prompt file %s: %w", path, err)
+		}
+	case bytes.HasPrefix(trimmed, []byte("---")):
+		frontMatter, body, err := splitFrontMatter(trimmed)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse prompt file %s: %w", path, err)
+		}
+		if err := yaml.Unmarshal(frontMatter, &spec); err != nil {
+			return nil, fmt.Errorf("failed to parse front-matter of %s: %w", path, err)
+		}
+		if spec.Template == "" {
+			spec.Template = body
+		}
+	case ext == ".yaml" || ext == ".yml":
+		if err := yaml.Unmarshal(content, &spec); err != nil {
+			return nil, fmt.Errorf("failed to parse prompt file %s: %w", path, err)
+		}
+	default:
+		spec.Template = string(content)
+	}
+
+	if spec.Name == "" {
+		spec.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if spec.Template == "" {
+		return nil, fmt.Errorf("prompt file %s has an empty template", path)
+	}
+
+	pt := spec.toTemplate()
+	pt.source = path
+	if _, err := pt.parse(); err != nil {
+		return nil, fmt.Errorf("invalid template in %s: %w", path, err)
+	}
+	return pt, nil
+}
+
+// splitFrontMatter separates a "---" delimited header from the body that follows it.
+func splitFrontMatter(content []byte) ([]byte, string, error) {
+	rest := bytes.TrimPrefix(content, []byte("---"))
+	rest = bytes.TrimLeft(rest, " \t")
+	rest = bytes.TrimPrefix(bytes.TrimPrefix(rest, []byte("\r")), []byte("\n"))
+
+	for offset := 0; offset <= len(rest); {
+		lineEnd := bytes.IndexByte(rest[offset:], '\n')
+		var line []byte
+		if lineEnd < 0 {
+			line = rest[offset:]
+		} else {
+			line = rest[offset : offset+lineEnd]
+		}
+		if strings.TrimSpace(string(line)) == "---" {
+			body := ""
+			if lineEnd >= 0 {
+				body = string(rest[offset+lineEnd+1:])
+			}
+			return rest[:offset], strings.TrimRight(body, "\r\n"), nil
+		}
+		if lineEnd < 0 {
+			break
+		}
+		offset += lineEnd + 1
+	}
+	return nil, "", fmt.Errorf("unterminated front-matter")
+}
+
+func (spec promptFileSpec) toTemplate() *PromptTemplate {
+	pt := &PromptTemplate{
+		Name:        spec.Name,
+		Version:     string(spec.Version),
+		Description: spec.Description,
+		Model:       spec.Model,
+		Provider:    spec.Provider,
+		Template:    spec.Template,
+		Required:    spec.Variables,
+		Defaults:    spec.Defaults,
+		Partials:    spec.Partials,
+		Strict:      spec.Strict,
+	}
+	if spec.SystemPrompt != "" {
+		pt.Options = append(pt.Options, WithSystemPrompt(spec.SystemPrompt, ""))
+	}
+	if len(spec.Directives) > 0 {
+		pt.Options = append(pt.Options, WithDirectives(spec.Directives...))
+	}
+	if spec.Output != "" {
+		pt.Options = append(pt.Options, WithOutput(spec.Output))
+	}
+	if spec.MaxLength > 0 {
+		pt.Options = append(pt.Options, WithMaxLength(spec.MaxLength))
+	}
+	return pt
+}
+
+// latestVersion returns the highest version key in versions.
+func latestVersion(versions map[string]*PromptTemplate) string {
+	latest := ""
+	for v := range versions {
+		if latest == "" || compareVersions(v, latest) > 0 {
+			latest = v
+		}
+	}
+	return latest
+}
+
+// compareVersions compares dotted version strings such as "1", "2.1" or "v1.10.0".
+// Numeric components are compared numerically, others lexically.
+func compareVersions(a, b string) int {
+	as := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	bs := strings.Split(strings.TrimPrefix(b, "v"), ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y string
+		if i < len(as) {
+			x = as[i]
+		}
+		if i < len(bs) {
+			y = bs[i]
+		}
+		xn, xerr := strconv.Atoi(x)
+		yn, yerr := strconv.Atoi(y)
+		switch {
+		case xerr == nil && yerr == nil:
+			if xn != yn {
+				if xn < yn {
+					return -1
+				}
+				return 1
+			}
+		case x != y:
+			return strings.Compare(x, y)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,111 @@
+package llm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writePromptFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+}
+
+func TestPromptLibraryLoadDir(t *testing.T) {
+	dir := t.TempDir()
+	writePromptFile(t, filepath.Join(dir, "summarize_v1.prompt"), `---
+name: summarize
+version: 1
+---
+Summarize: {{.text}}`)
+	writePromptFile(t, filepath.Join(dir, "nested", "summarize_v2.md"), `---
+name: summarize
+version: "2"
+model: gpt-4o-mini
+variables: [text]
+defaults:
+  words: 50
+system_prompt: You are concise.
+---
+Summarize in {{.words}} words:
+{{.text}}
+`)
+	writePromptFile(t, filepath.Join(dir, "translate.json"), `{
+  "version": 3,
+  "template": "Translate to {{.lang}}: {{.text}}",
+  "variables": ["lang", "text"]
+}`)
+	writePromptFile(t, filepath.Join(dir, "greet.txt"), `Hello {{.name}}`)
+	writePromptFile(t, filepath.Join(dir, "README"), `not a prompt`)
+
+	lib, err := LoadPromptLibrary(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"greet", "summarize", "translate"}, lib.Names())
+	assert.Equal(t, []string{"1", "2"}, lib.Versions("summarize"))
+
+	latest, err := lib.Get("summarize")
+	require.NoError(t, err)
+	assert.Equal(t, "2", latest.Version)
+	assert.Equal(t, "gpt-4o-mini", latest.Model)
+
+	prompt, err := latest.Execute(map[string]interface{}{"text": "Go is fun."})
+	require.NoError(t, err)
+	assert.Equal(t, "Summarize in 50 words:\nGo is fun.", prompt.Input)
+	assert.Equal(t, "You are concise.", prompt.SystemPrompt)
+
+	_, err = latest.Execute(map[string]interface{}{})
+	assert.Error(t, err, "declared variables are required")
+
+	v1, err := lib.GetVersion("summarize", "1")
+	require.NoError(t, err)
+	assert.Equal(t, "Summarize: {{.text}}", v1.Template)
+
+	translate, err := lib.Get("translate")
+	require.NoError(t, err)
+	assert.Equal(t, "3", translate.Version)
+
+	_, err = lib.Get("missing")
+	assert.Error(t, err)
+}
+
+func TestPromptLibraryInvalidFile(t *testing.T) {
+	dir := t.TempDir()
+	writePromptFile(t, filepath.Join(dir, "bad.prompt"), "---\nname: bad\nHello")
+	_, err := LoadPromptLibrary(dir)
+	assert.ErrorContains(t, err, "front-matter")
+
+	writePromptFile(t, filepath.Join(dir, "bad.prompt"), "{{.broken")
+	_, err = LoadPromptLibrary(dir)
+	assert.ErrorContains(t, err, "invalid template")
+}
+
+func TestPromptLibraryWatch(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "greet.prompt")
+	writePromptFile(t, path, "Hello {{.name}}")
+
+	lib, err := LoadPromptLibrary(dir)
+	require.NoError(t, err)
+	lib.Register(NewPromptTemplate("inline", "", "registered in code"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go lib.Watch(ctx, 10*time.Millisecond, func(err error) { t.Error(err) })
+
+	writePromptFile(t, path, "Hi {{.name}}")
+	require.NoError(t, os.Chtimes(path, time.Now().Add(time.Second), time.Now().Add(time.Second)))
+
+	assert.Eventually(t, func() bool {
+		pt, err := lib.Get("greet")
+		return err == nil && pt.Template == "Hi {{.name}}"
+	}, time.Second, 10*time.Millisecond)
+
+	_, err = lib.Get("inline")
+	assert.NoError(t, err, "code-registered templates survive reloads")
+}
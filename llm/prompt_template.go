@@ -3,7 +3,15 @@ package llm
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"sort"
+	"strconv"
+	"strings"
 	"text/template"
+	"text/template/parse"
 )
 
 // PromptTemplate represents a reusable template for generating prompts dynamically.
@@ -18,16 +26,27 @@ import (
 //	    "Translate the following text to {{.language}}:\n{{.text}}",
 //	    WithPromptOptions(WithMaxLength(100)),
 //	)
-//	
+//
 //	prompt, err := template.Execute(map[string]interface{}{
 //	    "language": "French",
 //	    "text": "Hello, world!",
 //	})
 type PromptTemplate struct {
-	Name        string         // Unique identifier for the template
-	Description string         // Human-readable description of the template's purpose
-	Template    string         // Go template string for generating prompts
-	Options     []PromptOption // Configuration options for generated prompts
+	Name        string                 // Unique identifier for the template
+	Version     string                 // Template version, used by PromptLibrary
+	Description string                 // Human-readable description of the template's purpose
+	Model       string                 // Optional hint for the model the template was written for
+	Provider    string                 // Optional hint for the provider the template was written for
+	Template    string                 // Go template string for generating prompts
+	Options     []PromptOption         // Configuration options for generated prompts
+	Required    []string               // Variables that must be provided at execution time
+	Defaults    map[string]interface{} // Fallback values for variables not provided
+	Partials    map[string]string      // Named sub-templates, invoked with {{template "name" .}}
+	Funcs       template.FuncMap       // Additional template functions
+	Escaper     func(string) string    // Optional escaping applied to every string variable
+	Strict      bool                   // Fail on variables referenced but not provided
+
+	source string // File the template was loaded from, if any
 }
 
 // PromptTemplateOption is a function type that modifies a PromptTemplate.
@@ -99,9 +118,78 @@ func WithPromptOptions(options ...PromptOption) PromptTemplateOption {
 	}
 }
 
+// WithRequiredVariables declares variables that must be provided to Execute.
+// Missing variables cause Execute to fail with ErrorTypeInvalidInput.
+//
+// Example:
+//
+//	template := NewPromptTemplate("qa", "Answers questions",
+//	    "Answer: {{.question}}",
+//	    WithRequiredVariables("question"),
+//	)
+func WithRequiredVariables(names ...string) PromptTemplateOption {
+	return func(pt *PromptTemplate) {
+		pt.Required = append(pt.Required, names...)
+	}
+}
+
+// WithDefaults sets fallback values for variables not provided to Execute.
+func WithDefaults(defaults map[string]interface{}) PromptTemplateOption {
+	return func(pt *PromptTemplate) {
+		if pt.Defaults == nil {
+			pt.Defaults = make(map[string]interface{}, len(defaults))
+		}
+		for k, v := range defaults {
+			pt.Defaults[k] = v
+		}
+	}
+}
+
+// WithPartial registers a named sub-template that can be included with
+// {{template "name" .}}. Partials allow common fragments such as output
+// instructions to be shared between templates.
+func WithPartial(name, text string) PromptTemplateOption {
+	return func(pt *PromptTemplate) {
+		if pt.Partials == nil {
+			pt.Partials = make(map[string]string)
+		}
+		pt.Partials[name] = text
+	}
+}
+
+// WithTemplateFuncs adds functions that can be called from the template.
+func WithTemplateFuncs(funcs template.FuncMap) PromptTemplateOption {
+	return func(pt *PromptTemplate) {
+		if pt.Funcs == nil {
+			pt.Funcs = make(template.FuncMap, len(funcs))
+		}
+		for k, v := range funcs {
+			pt.Funcs[k] = v
+		}
+	}
+}
+
+// WithEscaper applies an escaping function to every string variable before
+// substitution, e.g. WithEscaper(EscapeXML) to keep user input from breaking
+// out of XML-style delimiters in the prompt.
+func WithEscaper(escaper func(string) string) PromptTemplateOption {
+	return func(pt *PromptTemplate) {
+		pt.Escaper = escaper
+	}
+}
+
+// WithStrictVariables makes Execute fail when the template references a
+// variable that was neither provided nor given a default.
+func WithStrictVariables() PromptTemplateOption {
+	return func(pt *PromptTemplate) {
+		pt.Strict = true
+	}
+}
+
 // Execute generates a Prompt from the PromptTemplate with the given data.
-// It applies the template's options to the generated prompt and validates
-// the result.
+// Defaults are merged with the data, required (and, in strict mode, all
+// referenced) variables are checked, the escaper is applied to string values,
+// and the template's options are applied to the generated prompt.
 //
 // Parameters:
 //   - data: Map of key-value pairs to substitute in the template
@@ -120,13 +208,48 @@ func WithPromptOptions(options ...PromptOption) PromptTemplateOption {
 //	    log.Fatal(err)
 //	}
 func (pt *PromptTemplate) Execute(data map[string]interface{}) (*Prompt, error) {
-	tmpl, err := template.New(pt.Name).Parse(pt.Template)
+	vars := make(map[string]interface{}, len(pt.Defaults)+len(data))
+	for k, v := range pt.Defaults {
+		vars[k] = v
+	}
+	for k, v := range data {
+		vars[k] = v
+	}
+
+	var missing []string
+	for _, name := range pt.Required {
+		if _, ok := vars[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+
+	tmpl, err := pt.parse()
 	if err != nil {
 		return nil, err
 	}
 
+	if pt.Strict {
+		for _, name := range templateVariables(tmpl) {
+			if _, ok := vars[name]; !ok && !containsString(missing, name) {
+				missing = append(missing, name)
+			}
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, NewLLMError(ErrorTypeInvalidInput, fmt.Sprintf("template %q is missing variables: %s", pt.Name, strings.Join(missing, ", ")), nil)
+	}
+
+	if pt.Escaper != nil {
+		for k, v := range vars {
+			if str, ok := v.(string); ok {
+				vars[k] = pt.Escaper(str)
+			}
+		}
+	}
+
 	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, data); err != nil {
+	if err := tmpl.Execute(&buf, vars); err != nil {
 		return nil, err
 	}
 
@@ -135,3 +258,160 @@ func (pt *PromptTemplate) Execute(data map[string]interface{}) (*Prompt, error)
 
 	return prompt, nil
 }
+
+// Variables returns the names of the top-level variables referenced by the
+// template and its partials (e.g., "text" for {{.text}}), sorted alphabetically.
+//
+// Returns:
+//   - Sorted list of variable names
+//   - Error if the template or a partial fails to parse
+func (pt *PromptTemplate) Variables() ([]string, error) {
+	tmpl, err := pt.parse()
+	if err != nil {
+		return nil, err
+	}
+	return templateVariables(tmpl), nil
+}
+
+// parse compiles the template together with its partials and functions.
+func (pt *PromptTemplate) parse() (*template.Template, error) {
+	tmpl := template.New(pt.Name).Funcs(defaultTemplateFuncs).Funcs(pt.Funcs)
+	if pt.Strict {
+		tmpl = tmpl.Option("missingkey=error")
+	}
+	for name, partial := range pt.Partials {
+		if _, err := tmpl.New(name).Parse(partial); err != nil {
+			return nil, fmt.Errorf("failed to parse partial %q: %w", name, err)
+		}
+	}
+	return tmpl.Parse(pt.Template)
+}
+
+// templateVariables collects the top-level field names referenced by every
+// template associated with tmpl. Fields accessed inside range or with blocks
+// are relative to a different dot and are not included.
+func templateVariables(tmpl *template.Template) []string {
+	seen := make(map[string]bool)
+	for _, t := range tmpl.Templates() {
+		if t.Tree != nil {
+			collectFields(t.Tree.Root, seen)
+		}
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func collectFields(node parse.Node, seen map[string]bool) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			collectFields(child, seen)
+		}
+	case *parse.ActionNode:
+		collectFields(n.Pipe, seen)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, cmd := range n.Cmds {
+			collectFields(cmd, seen)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			collectFields(arg, seen)
+		}
+	case *parse.FieldNode:
+		seen[n.Ident[0]] = true
+	case *parse.IfNode:
+		collectFields(n.Pipe, seen)
+		collectFields(n.List, seen)
+		collectFields(n.ElseList, seen)
+	case *parse.RangeNode:
+		collectFields(n.Pipe, seen)
+		collectFields(n.ElseList, seen)
+	case *parse.WithNode:
+		collectFields(n.Pipe, seen)
+		collectFields(n.ElseList, seen)
+	case *parse.TemplateNode:
+		collectFields(n.Pipe, seen)
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultTemplateFuncs are available in every PromptTemplate.
+//   - json: encodes a value as JSON
+//   - xml: escapes &, <, >, ' and " for embedding in XML-style tags
+//   - quote: renders a string as a double-quoted Go string literal
+//   - upper, lower, trim: string case and whitespace helpers
+//   - join: joins a string slice with a separator
+//   - indent: prefixes every line with n spaces
+//   - default: returns the fallback when the value is empty
+var defaultTemplateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"xml":   EscapeXML,
+	"quote": strconv.Quote,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"trim":  strings.TrimSpace,
+	"join": func(sep string, items []string) string {
+		return strings.Join(items, sep)
+	},
+	"indent": func(n int, s string) string {
+		pad := strings.Repeat(" ", n)
+		return pad + strings.ReplaceAll(s, "\n", "\n"+pad)
+	},
+	"default": func(fallback, v interface{}) interface{} {
+		if v == nil || v == "" {
+			return fallback
+		}
+		return v
+	},
+}
+
+// EscapeXML escapes text so it can be safely embedded between XML-style tags
+// in a prompt (e.g., <document>...</document>) without closing them early.
+// It can be used as a PromptTemplate escaper via WithEscaper(EscapeXML).
+func EscapeXML(s string) string {
+	return html.EscapeString(s)
+}
+
+// GenerateFromTemplate executes the template with the given variables and
+// generates a response from the resulting prompt.
+//
+// Returns:
+//   - ErrorTypeInvalidInput if required variables are missing
+//   - Any error from template execution or Generate
+func (l *LLMImpl) GenerateFromTemplate(ctx context.Context, tmpl *PromptTemplate, vars map[string]interface{}, opts ...GenerateOption) (string, error) {
+	prompt, err := tmpl.Execute(vars)
+	if err != nil {
+		return "", err
+	}
+	return l.Generate(ctx, prompt, opts...)
+}
+
+// GenerateFromTemplate executes the template and generates a response with memory.
+func (l *LLMWithMemory) GenerateFromTemplate(ctx context.Context, tmpl *PromptTemplate, vars map[string]interface{}, opts ...GenerateOption) (string, error) {
+	prompt, err := tmpl.Execute(vars)
+	if err != nil {
+		return "", err
+	}
+	return l.Generate(ctx, prompt, opts...)
+}
@@ -0,0 +1,209 @@
+package llm
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+)
+
+// Priority orders requests waiting for a provider's concurrency limit.
+// Requests with a higher priority are sent first; requests with the same
+// priority are sent in arrival order.
+type Priority int
+
+const (
+	// PriorityInteractive is the default priority, for user-facing requests
+	PriorityInteractive Priority = 0
+
+	// PriorityBatch is for background jobs that may wait behind interactive requests
+	PriorityBatch Priority = -1
+)
+
+// WithPriority sets the priority of a request waiting for the provider's
+// concurrency limit (see config.SetMaxConcurrency).
+//
+// Example usage:
+//
+//	summary, err := l.Generate(ctx, prompt, llm.WithPriority(llm.PriorityBatch))
+func WithPriority(p Priority) GenerateOption {
+	return func(c *GenerateConfig) {
+		c.Priority = p
+	}
+}
+
+// WithStreamPriority sets the priority of a stream waiting for the provider's
+// concurrency limit.
+func WithStreamPriority(p Priority) StreamOption {
+	return func(c *StreamConfig) {
+		c.Priority = p
+	}
+}
+
+// RequestQueue limits the number of concurrent requests, serving waiting
+// requests by priority. It is safe for concurrent use.
+type RequestQueue struct {
+	mu      sync.Mutex
+	limit   int
+	active  int
+	waiting waiters
+	seq     uint64
+}
+
+// NewRequestQueue creates a queue allowing limit concurrent requests.
+func NewRequestQueue(limit int) *RequestQueue {
+	return &RequestQueue{limit: limit}
+}
+
+// SetLimit changes the number of concurrent requests allowed. Raising it
+// starts waiting requests; lowering it lets active requests complete.
+func (q *RequestQueue) SetLimit(limit int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.limit = limit
+	q.grant()
+}
+
+// Acquire blocks until a request with the given priority may be sent and
+// returns the function releasing its slot, which must be called once the
+// request completes. It returns the context's error if it is cancelled first.
+func (q *RequestQueue) Acquire(ctx context.Context, p Priority) (func(), error) {
+	q.mu.Lock()
+	if q.active < q.limit && len(q.waiting) == 0 {
+		q.active++
+		q.mu.Unlock()
+		return q.releaser(), nil
+	}
+	w := &waiter{priority: p, seq: q.seq, ready: make(chan struct{})}
+	q.seq++
+	heap.Push(&q.waiting, w)
+	q.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return q.releaser(), nil
+	case <-ctx.Done():
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		select {
+		case <-w.ready:
+			// Granted while giving up: pass the slot on
+			q.active--
+			q.grant()
+		default:
+			heap.Remove(&q.waiting, w.index)
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// Waiting returns the number of requests waiting for a slot.
+func (q *RequestQueue) Waiting() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.waiting)
+}
+
+func (q *RequestQueue) releaser() func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			q.mu.Lock()
+			defer q.mu.Unlock()
+			q.active--
+			q.grant()
+		})
+	}
+}
+
+// grant starts waiting requests while slots are free. The caller must hold
+// the lock.
+func (q *RequestQueue) grant() {
+	for q.active < q.limit && len(q.waiting) > 0 {
+		w := heap.Pop(&q.waiting).(*waiter)
+		q.active++
+		close(w.ready)
+	}
+}
+
+// waiter is a request waiting for a slot.
+type waiter struct {
+	priority Priority
+	seq      uint64
+	ready    chan struct{}
+	index    int
+}
+
+// waiters is a heap of waiting requests, highest priority first.
+type waiters []*waiter
+
+func (w waiters) Len() int { return len(w) }
+func (w waiters) Less(i, j int) bool {
+	if w[i].priority != w[j].priority {
+		return w[i].priority > w[j].priority
+	}
+	return w[i].seq < w[j].seq
+}
+func (w waiters) Swap(i, j int) {
+	w[i], w[j] = w[j], w[i]
+	w[i].index = i
+	w[j].index = j
+}
+func (w *waiters) Push(x interface{}) {
+	item := x.(*waiter)
+	item.index = len(*w)
+	*w = append(*w, item)
+}
+func (w *waiters) Pop() interface{} {
+	old := *w
+	item := old[len(old)-1]
+	*w = old[:len(old)-1]
+	return item
+}
+
+var (
+	providerQueues   = make(map[string]*RequestQueue)
+	providerQueuesMu sync.Mutex
+)
+
+// providerQueue returns the queue shared by the LLMs of a provider, setting
+// its limit to the latest configured one.
+func providerQueue(provider string, limit int) *RequestQueue {
+	providerQueuesMu.Lock()
+	defer providerQueuesMu.Unlock()
+	q, ok := providerQueues[provider]
+	if !ok {
+		q = NewRequestQueue(limit)
+		providerQueues[provider] = q
+	} else {
+		q.SetLimit(limit)
+	}
+	return q
+}
+
+// acquire waits for a slot in the provider's queue, if the LLM has a
+// concurrency limit, and returns the function releasing it.
+func (l *LLMImpl) acquire(ctx context.Context, p Priority) (func(), error) {
+	if l.queue == nil {
+		return func() {}, nil
+	}
+	return l.queue.Acquire(ctx, p)
+}
+
+// queuedStream releases its slot in the provider's queue once it ends.
+type queuedStream struct {
+	TokenStream
+	release func()
+}
+
+func (s *queuedStream) Next(ctx context.Context) (*StreamToken, error) {
+	token, err := s.TokenStream.Next(ctx)
+	if err != nil {
+		s.release()
+	}
+	return token, err
+}
+
+func (s *queuedStream) Close() error {
+	s.release()
+	return s.TokenStream.Close()
+}
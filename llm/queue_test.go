@@ -0,0 +1,91 @@
+package llm
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestQueue(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Priority", func(t *testing.T) {
+		q := NewRequestQueue(1)
+		release, err := q.Acquire(ctx, PriorityBatch)
+		require.NoError(t, err)
+
+		var mu sync.Mutex
+		var order []string
+		var wg sync.WaitGroup
+		enqueue := func(name string, p Priority) {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				release, err := q.Acquire(ctx, p)
+				if !assert.NoError(t, err) {
+					return
+				}
+				mu.Lock()
+				order = append(order, name)
+				mu.Unlock()
+				release()
+			}()
+		}
+		waitFor := func(n int) {
+			require.Eventually(t, func() bool { return q.Waiting() == n }, time.Second, time.Millisecond)
+		}
+
+		enqueue("batch1", PriorityBatch)
+		waitFor(1)
+		enqueue("batch2", PriorityBatch)
+		waitFor(2)
+		enqueue("interactive", PriorityInteractive)
+		waitFor(3)
+
+		release()
+		wg.Wait()
+		assert.Equal(t, []string{"interactive", "batch1", "batch2"}, order)
+	})
+
+	t.Run("Cancel", func(t *testing.T) {
+		q := NewRequestQueue(1)
+		release, err := q.Acquire(ctx, PriorityInteractive)
+		require.NoError(t, err)
+
+		waitCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		_, err = q.Acquire(waitCtx, PriorityInteractive)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Equal(t, 0, q.Waiting())
+
+		release()
+		release() // Releasing twice is harmless
+		release, err = q.Acquire(ctx, PriorityInteractive)
+		require.NoError(t, err)
+		release()
+	})
+
+	t.Run("SetLimit", func(t *testing.T) {
+		q := NewRequestQueue(1)
+		_, err := q.Acquire(ctx, PriorityInteractive)
+		require.NoError(t, err)
+
+		acquired := make(chan struct{})
+		go func() {
+			if _, err := q.Acquire(ctx, PriorityInteractive); assert.NoError(t, err) {
+				close(acquired)
+			}
+		}()
+		require.Eventually(t, func() bool { return q.Waiting() == 1 }, time.Second, time.Millisecond)
+		q.SetLimit(2)
+		select {
+		case <-acquired:
+		case <-time.After(time.Second):
+			t.Fatal("raising the limit did not start the waiting request")
+		}
+	})
+}
@@ -0,0 +1,141 @@
+package llm
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/teilomillet/gollm/config"
+)
+
+// redacted replaces credentials in the payloads passed to raw hooks.
+const redacted = "REDACTED"
+
+// rawHookTransport passes the HTTP traffic of an LLM to the raw request and
+// response hooks of its configuration.
+type rawHookTransport struct {
+	base       http.RoundTripper
+	provider   string
+	onRequest  config.RawRequestHook
+	onResponse config.RawResponseHook
+}
+
+// newRawHookTransport wraps base with the configured raw hooks, or returns
+// base if there are none.
+func newRawHookTransport(cfg *config.Config, base http.RoundTripper) http.RoundTripper {
+	if cfg.OnRawRequest == nil && cfg.OnRawResponse == nil {
+		return base
+	}
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &rawHookTransport{base: base, provider: cfg.Provider, onRequest: cfg.OnRawRequest, onResponse: cfg.OnRawResponse}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *rawHookTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	raw := config.RawRequest{
+		Provider: t.provider,
+		Method:   req.Method,
+		URL:      redactQuery(req.URL),
+		Header:   redactHeader(req.Header),
+		Body:     body,
+	}
+	if t.onRequest != nil {
+		t.onRequest(raw)
+	}
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	if t.onResponse == nil {
+		return resp, err
+	}
+	if err != nil {
+		t.onResponse(raw, config.RawResponse{Duration: time.Since(start), Err: err})
+		return nil, err
+	}
+	resp.Body = &rawResponseBody{
+		ReadCloser: resp.Body,
+		report: func(body []byte) {
+			t.onResponse(raw, config.RawResponse{
+				StatusCode: resp.StatusCode,
+				Header:     resp.Header.Clone(),
+				Body:       body,
+				Duration:   time.Since(start),
+			})
+		},
+	}
+	return resp, nil
+}
+
+// rawResponseBody copies a response body as it is read and reports it once
+// fully read or closed, so streams reach the caller without delay.
+type rawResponseBody struct {
+	io.ReadCloser
+	buf    bytes.Buffer
+	report func([]byte)
+	once   sync.Once
+}
+
+func (b *rawResponseBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.buf.Write(p[:n])
+	if err == io.EOF {
+		b.finish()
+	}
+	return n, err
+}
+
+func (b *rawResponseBody) Close() error {
+	b.finish()
+	return b.ReadCloser.Close()
+}
+
+func (b *rawResponseBody) finish() {
+	b.once.Do(func() {
+		b.report(append([]byte(nil), b.buf.Bytes()...))
+	})
+}
+
+// redactHeader returns a copy of the header with credentials replaced.
+func redactHeader(header http.Header) http.Header {
+	clean := header.Clone()
+	for name := range clean {
+		lower := strings.ToLower(name)
+		switch {
+		case lower == "authorization", lower == "proxy-authorization", lower == "cookie",
+			strings.Contains(lower, "key"), strings.Contains(lower, "token"), strings.Contains(lower, "secret"):
+			clean[name] = []string{redacted}
+		}
+	}
+	return clean
+}
+
+// redactQuery returns the URL with the values of secret query parameters
+// replaced.
+func redactQuery(u *url.URL) string {
+	clean := *u
+	query := clean.Query()
+	for _, param := range secretQueryParams {
+		if query.Has(param) {
+			query.Set(param, redacted)
+		}
+	}
+	clean.RawQuery = query.Encode()
+	return clean.String()
+}
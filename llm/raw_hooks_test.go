@@ -0,0 +1,79 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm/config"
+	"github.com/teilomillet/gollm/providers"
+	"github.com/teilomillet/gollm/utils"
+)
+
+func TestRawHooks(t *testing.T) {
+	var mu sync.Mutex
+	var requests []config.RawRequest
+	var responses []config.RawResponse
+	cfg := config.NewConfig()
+	config.ApplyOptions(cfg,
+		config.SetProvider("mock"),
+		config.SetModel("mock-model"),
+		config.SetMaxRetries(0),
+		config.SetOnRawRequest(func(r config.RawRequest) {
+			mu.Lock()
+			defer mu.Unlock()
+			requests = append(requests, r)
+		}),
+		config.SetOnRawResponse(func(req config.RawRequest, r config.RawResponse) {
+			mu.Lock()
+			defer mu.Unlock()
+			responses = append(responses, r)
+		}),
+	)
+	created, err := NewLLM(cfg, utils.NewLogger(utils.LogLevelOff), providers.NewProviderRegistry())
+	require.NoError(t, err)
+	l := created.(*LLMImpl)
+	mock := l.Provider.(*providers.MockProvider)
+	mock.QueueResponse("Hello there")
+	mock.QueueResponse("streamed reply")
+
+	_, err = l.Generate(context.Background(), NewPrompt("Hi"))
+	require.NoError(t, err)
+	require.Len(t, requests, 1)
+	assert.Equal(t, "mock", requests[0].Provider)
+	assert.Equal(t, http.MethodPost, requests[0].Method)
+	assert.Contains(t, string(requests[0].Body), "user: Hi")
+	require.Len(t, responses, 1)
+	assert.Equal(t, http.StatusOK, responses[0].StatusCode)
+	assert.Contains(t, string(responses[0].Body), "Hello there")
+
+	stream, err := l.Stream(context.Background(), NewPrompt("Hi"))
+	require.NoError(t, err)
+	require.NoError(t, ConsumeStream(context.Background(), stream, func(*StreamToken) error { return nil }))
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, responses, 2, "streams are reported once read")
+	assert.Contains(t, string(responses[1].Body), "[DONE]")
+}
+
+func TestRawHookRedaction(t *testing.T) {
+	header := http.Header{}
+	header.Set("Authorization", "Bearer sk-secret")
+	header.Set("X-Api-Key", "sk-ant-secret")
+	header.Set("Content-Type", "application/json")
+	clean := redactHeader(header)
+	assert.Equal(t, redacted, clean.Get("Authorization"))
+	assert.Equal(t, redacted, clean.Get("X-Api-Key"))
+	assert.Equal(t, "application/json", clean.Get("Content-Type"))
+	assert.Equal(t, "Bearer sk-secret", header.Get("Authorization"), "the request keeps its headers")
+
+	u, _ := url.Parse("https://example.com/v1/models/gemini:generate?key=secret&alt=sse")
+	redactedURL := redactQuery(u)
+	assert.NotContains(t, redactedURL, "secret")
+	assert.True(t, strings.Contains(redactedURL, "alt=sse"))
+}
@@ -0,0 +1,347 @@
+package llm
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/teilomillet/gollm/providers"
+	"github.com/teilomillet/gollm/utils"
+	"golang.org/x/net/websocket"
+)
+
+// RealtimeOption is a function type for configuring realtime sessions.
+type RealtimeOption func(*providers.RealtimeOptions)
+
+// WithRealtimeModel overrides the provider's default realtime model.
+func WithRealtimeModel(model string) RealtimeOption {
+	return func(o *providers.RealtimeOptions) {
+		o.Model = model
+	}
+}
+
+// WithRealtimeInstructions sets the system instructions of the session.
+func WithRealtimeInstructions(instructions string) RealtimeOption {
+	return func(o *providers.RealtimeOptions) {
+		o.Instructions = instructions
+	}
+}
+
+// WithRealtimeVoice sets the voice of the audio responses (e.g., "alloy").
+func WithRealtimeVoice(voice string) RealtimeOption {
+	return func(o *providers.RealtimeOptions) {
+		o.Voice = voice
+	}
+}
+
+// WithRealtimeModalities sets the response modalities, "text" and/or "audio".
+func WithRealtimeModalities(modalities ...string) RealtimeOption {
+	return func(o *providers.RealtimeOptions) {
+		o.Modalities = modalities
+	}
+}
+
+// WithRealtimeTools sets the functions the model may call during the session.
+func WithRealtimeTools(tools []utils.Tool) RealtimeOption {
+	return func(o *providers.RealtimeOptions) {
+		o.Tools = tools
+	}
+}
+
+// WithRealtimeTemperature sets the sampling temperature of the session.
+func WithRealtimeTemperature(temperature float64) RealtimeOption {
+	return func(o *providers.RealtimeOptions) {
+		o.Temperature = temperature
+	}
+}
+
+// RealtimeEventType is the kind of a RealtimeEvent.
+type RealtimeEventType string
+
+const (
+	RealtimeAudio        RealtimeEventType = "audio"         // A chunk of response audio
+	RealtimeText         RealtimeEventType = "text"          // A delta of response text
+	RealtimeTranscript   RealtimeEventType = "transcript"    // A delta of the transcript of response audio
+	RealtimeToolCall     RealtimeEventType = "tool_call"     // A complete function call
+	RealtimeResponseDone RealtimeEventType = "response_done" // The end of a response
+	RealtimeError        RealtimeEventType = "error"         // An error reported by the server or the connection
+	RealtimeServerEvent  RealtimeEventType = "server_event"  // Any other server event, such as speech detection
+)
+
+// RealtimeEvent is received on the channel of a RealtimeSession.
+type RealtimeEvent struct {
+	Type RealtimeEventType
+
+	// ServerType is the type of the server event, e.g. "response.audio.delta"
+	ServerType string
+
+	// Text is the delta of a RealtimeText or RealtimeTranscript event
+	Text string
+
+	// Audio is the audio of a RealtimeAudio event, 24 kHz 16-bit mono PCM by default
+	Audio []byte
+
+	// ToolCall is the call of a RealtimeToolCall event, answered with SendToolResult
+	ToolCall *ToolCall
+
+	// Err is the error of a RealtimeError event
+	Err error
+
+	// Raw is the server event
+	Raw json.RawMessage
+}
+
+// RealtimeSession is a realtime voice and text conversation over a
+// WebSocket. Audio is streamed in with SendAudio, and text with SendText;
+// the response audio, text deltas and tool calls arrive in order on Events.
+// It is safe for concurrent use.
+type RealtimeSession struct {
+	conn      *websocket.Conn
+	events    chan RealtimeEvent
+	done      chan struct{}
+	sendMu    sync.Mutex
+	closeOnce sync.Once
+}
+
+// Realtime opens a realtime session with the provider.
+//
+// Returns:
+//   - ErrorTypeUnsupported if the provider has no realtime API
+//   - ErrorTypeRequest if the connection fails
+//
+// Example usage:
+//
+//	session, err := l.Realtime(ctx, llm.WithRealtimeModalities("text", "audio"))
+//	defer session.Close()
+//	go streamMicrophone(session) // calls session.SendAudio with PCM chunks
+//	for event := range session.Events() {
+//	    switch event.Type {
+//	    case llm.RealtimeAudio:
+//	        speaker.Write(event.Audio)
+//	    case llm.RealtimeToolCall:
+//	        session.SendToolResult(event.ToolCall.ID, run(event.ToolCall))
+//	    }
+//	}
+func (l *LLMImpl) Realtime(ctx context.Context, opts ...RealtimeOption) (*RealtimeSession, error) {
+	realtimer, ok := l.Provider.(providers.Realtimer)
+	if !ok {
+		return nil, NewLLMError(ErrorTypeUnsupported, fmt.Sprintf("realtime sessions not supported by provider %s", l.Provider.Name()), nil)
+	}
+
+	options := providers.RealtimeOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	update, err := realtimer.PrepareRealtimeSession(options)
+	if err != nil {
+		return nil, NewLLMError(ErrorTypeRequest, "failed to prepare realtime session", err)
+	}
+
+	endpoint := realtimer.RealtimeEndpoint(options)
+	location, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, NewLLMError(ErrorTypeRequest, "invalid realtime endpoint", err)
+	}
+	origin := &url.URL{Scheme: "https", Host: location.Host}
+	config := &websocket.Config{Location: location, Origin: origin, Version: websocket.ProtocolVersionHybi13, Header: http.Header{}}
+	for k, v := range realtimer.RealtimeHeaders() {
+		config.Header.Set(k, v)
+	}
+
+	l.logger.Debug("Opening realtime session", "provider", l.Provider.Name(), "url", endpoint)
+	conn, err := config.DialContext(ctx)
+	if err != nil {
+		return nil, NewLLMError(ErrorTypeRequest, "failed to open realtime session", err)
+	}
+	session := &RealtimeSession{conn: conn, events: make(chan RealtimeEvent), done: make(chan struct{})}
+	if err := session.send(update); err != nil {
+		session.Close()
+		return nil, err
+	}
+	go session.receive()
+	return session, nil
+}
+
+// Realtime opens a realtime session.
+// It delegates to the underlying LLM; the conversation is not added to memory.
+func (l *LLMWithMemory) Realtime(ctx context.Context, opts ...RealtimeOption) (*RealtimeSession, error) {
+	if r, ok := l.LLM.(interface {
+		Realtime(context.Context, ...RealtimeOption) (*RealtimeSession, error)
+	}); ok {
+		return r.Realtime(ctx, opts...)
+	}
+	return nil, NewLLMError(ErrorTypeUnsupported, "realtime sessions not supported by underlying LLM", nil)
+}
+
+// Events returns the channel of the server events, closed when the session
+// ends. It must be read for the session to progress.
+func (s *RealtimeSession) Events() <-chan RealtimeEvent {
+	return s.events
+}
+
+// SendAudio appends audio to the input buffer, 24 kHz 16-bit mono PCM by
+// default. With server voice detection, the default, the model responds
+// when the user stops speaking.
+func (s *RealtimeSession) SendAudio(pcm []byte) error {
+	return s.Send(map[string]interface{}{
+		"type":  "input_audio_buffer.append",
+		"audio": base64.StdEncoding.EncodeToString(pcm),
+	})
+}
+
+// CommitAudio ends the user turn of the input audio, when voice detection
+// is disabled.
+func (s *RealtimeSession) CommitAudio() error {
+	return s.Send(map[string]interface{}{"type": "input_audio_buffer.commit"})
+}
+
+// SendText adds a user message to the conversation and requests a response.
+func (s *RealtimeSession) SendText(text string) error {
+	err := s.Send(map[string]interface{}{
+		"type": "conversation.item.create",
+		"item": map[string]interface{}{
+			"type":    "message",
+			"role":    "user",
+			"content": []map[string]interface{}{{"type": "input_text", "text": text}},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return s.CreateResponse()
+}
+
+// SendToolResult returns the output of a tool call to the model and requests
+// the response continuing from it.
+func (s *RealtimeSession) SendToolResult(callID, output string) error {
+	err := s.Send(map[string]interface{}{
+		"type": "conversation.item.create",
+		"item": map[string]interface{}{
+			"type":    "function_call_output",
+			"call_id": callID,
+			"output":  output,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return s.CreateResponse()
+}
+
+// CreateResponse requests a response to the conversation so far.
+func (s *RealtimeSession) CreateResponse() error {
+	return s.Send(map[string]interface{}{"type": "response.create"})
+}
+
+// Send sends a client event as is, for events without a dedicated method.
+func (s *RealtimeSession) Send(event interface{}) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return NewLLMError(ErrorTypeInvalidInput, "failed to encode realtime event", err)
+	}
+	return s.send(data)
+}
+
+func (s *RealtimeSession) send(data []byte) error {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+	if err := websocket.Message.Send(s.conn, string(data)); err != nil {
+		return NewLLMError(ErrorTypeRequest, "failed to send realtime event", err)
+	}
+	return nil
+}
+
+// Close ends the session and closes the event channel.
+func (s *RealtimeSession) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		close(s.done)
+		err = s.conn.Close()
+	})
+	return err
+}
+
+// receive reads the server events until the connection closes.
+func (s *RealtimeSession) receive() {
+	defer close(s.events)
+	for {
+		var data []byte
+		if err := websocket.Message.Receive(s.conn, &data); err != nil {
+			select {
+			case <-s.done:
+			default:
+				s.emit(RealtimeEvent{Type: RealtimeError, Err: NewLLMError(ErrorTypeResponse, "realtime session ended", err)})
+				s.Close()
+			}
+			return
+		}
+		if !s.emit(parseRealtimeEvent(data)) {
+			return
+		}
+	}
+}
+
+// emit sends an event, returning false once the session is closed.
+func (s *RealtimeSession) emit(event RealtimeEvent) bool {
+	select {
+	case s.events <- event:
+		return true
+	case <-s.done:
+		return false
+	}
+}
+
+// parseRealtimeEvent reads a server event of the OpenAI Realtime protocol,
+// under its beta and current names.
+func parseRealtimeEvent(data []byte) RealtimeEvent {
+	var payload struct {
+		Type      string `json:"type"`
+		Delta     string `json:"delta"`
+		CallID    string `json:"call_id"`
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+		Error     *struct {
+			Type    string `json:"type"`
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return RealtimeEvent{Type: RealtimeError, Err: NewLLMError(ErrorTypeResponse, "invalid realtime event", err), Raw: data}
+	}
+	event := RealtimeEvent{Type: RealtimeServerEvent, ServerType: payload.Type, Raw: data}
+	switch payload.Type {
+	case "response.audio.delta", "response.output_audio.delta":
+		audio, err := base64.StdEncoding.DecodeString(payload.Delta)
+		if err != nil {
+			event.Type, event.Err = RealtimeError, NewLLMError(ErrorTypeResponse, "invalid realtime audio", err)
+			break
+		}
+		event.Type, event.Audio = RealtimeAudio, audio
+	case "response.text.delta", "response.output_text.delta":
+		event.Type, event.Text = RealtimeText, payload.Delta
+	case "response.audio_transcript.delta", "response.output_audio_transcript.delta":
+		event.Type, event.Text = RealtimeTranscript, payload.Delta
+	case "response.function_call_arguments.done":
+		call := &ToolCall{ID: payload.CallID, Type: "function"}
+		call.Function.Name = payload.Name
+		call.Function.Arguments = json.RawMessage("{}")
+		if payload.Arguments != "" {
+			call.Function.Arguments = json.RawMessage(payload.Arguments)
+		}
+		event.Type, event.ToolCall = RealtimeToolCall, call
+	case "response.done":
+		event.Type = RealtimeResponseDone
+	case "error":
+		message := "unknown realtime error"
+		if payload.Error != nil {
+			message = fmt.Sprintf("realtime error %s: %s", payload.Error.Type, payload.Error.Message)
+		}
+		event.Type, event.Err = RealtimeError, NewLLMError(ErrorTypeAPI, message, nil)
+	}
+	return event
+}
@@ -0,0 +1,115 @@
+package llm
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm/providers"
+	"github.com/teilomillet/gollm/utils"
+	"golang.org/x/net/websocket"
+)
+
+type localRealtimer struct {
+	*providers.OpenAIProvider
+	url string
+}
+
+func (p *localRealtimer) RealtimeEndpoint(providers.RealtimeOptions) string { return p.url }
+
+func TestRealtime(t *testing.T) {
+	received := make(chan map[string]interface{}, 10)
+	server := httptest.NewServer(websocket.Handler(func(conn *websocket.Conn) {
+		assert.Equal(t, "Bearer fake-key", conn.Request().Header.Get("Authorization"))
+		assert.Equal(t, "realtime=v1", conn.Request().Header.Get("OpenAI-Beta"))
+		for {
+			var event map[string]interface{}
+			if err := websocket.JSON.Receive(conn, &event); err != nil {
+				return
+			}
+			received <- event
+			if event["type"] != "response.create" {
+				continue
+			}
+			for _, reply := range []string{
+				`{"type":"response.created"}`,
+				`{"type":"response.audio.delta","delta":"` + base64.StdEncoding.EncodeToString([]byte{1, 2, 3}) + `"}`,
+				`{"type":"response.audio_transcript.delta","delta":"It is"}`,
+				`{"type":"response.text.delta","delta":"sunny"}`,
+				`{"type":"response.function_call_arguments.done","call_id":"call_1","name":"get_weather","arguments":"{\"city\":\"Paris\"}"}`,
+				`{"type":"response.done"}`,
+				`{"type":"error","error":{"type":"invalid_request_error","message":"bad event"}}`,
+			} {
+				websocket.Message.Send(conn, reply)
+			}
+		}
+	}))
+	defer server.Close()
+
+	l := &LLMImpl{
+		Provider: &localRealtimer{OpenAIProvider: providers.NewOpenAIProvider("fake-key", "gpt-4o", nil).(*providers.OpenAIProvider), url: "ws" + strings.TrimPrefix(server.URL, "http")},
+		Options:  make(map[string]interface{}),
+		logger:   utils.NewLogger(utils.LogLevelOff),
+	}
+	session, err := l.Realtime(context.Background(),
+		WithRealtimeInstructions("Be brief"),
+		WithRealtimeTools([]utils.Tool{{Type: "function", Function: utils.Function{Name: "get_weather"}}}),
+	)
+	require.NoError(t, err)
+	defer session.Close()
+
+	update := <-received
+	assert.Equal(t, "session.update", update["type"])
+	settings := update["session"].(map[string]interface{})
+	assert.Equal(t, "Be brief", settings["instructions"])
+	assert.Equal(t, "get_weather", settings["tools"].([]interface{})[0].(map[string]interface{})["name"])
+
+	require.NoError(t, session.SendAudio([]byte{9}))
+	require.NoError(t, session.SendText("Weather in Paris?"))
+	for _, want := range []string{"input_audio_buffer.append", "conversation.item.create", "response.create"} {
+		assert.Equal(t, want, (<-received)["type"])
+	}
+
+	var events []RealtimeEvent
+	for event := range session.Events() {
+		events = append(events, event)
+		if event.Type == RealtimeError {
+			break
+		}
+	}
+	require.Len(t, events, 7)
+	assert.Equal(t, RealtimeServerEvent, events[0].Type)
+	assert.Equal(t, "response.created", events[0].ServerType)
+	assert.Equal(t, []byte{1, 2, 3}, events[1].Audio)
+	assert.Equal(t, RealtimeTranscript, events[2].Type)
+	assert.Equal(t, "It is", events[2].Text)
+	assert.Equal(t, RealtimeText, events[3].Type)
+	assert.Equal(t, "sunny", events[3].Text)
+	require.Equal(t, RealtimeToolCall, events[4].Type)
+	assert.Equal(t, "call_1", events[4].ToolCall.ID)
+	assert.JSONEq(t, `{"city":"Paris"}`, string(events[4].ToolCall.Function.Arguments))
+	assert.Equal(t, RealtimeResponseDone, events[5].Type)
+	assert.ErrorContains(t, events[6].Err, "bad event")
+
+	require.NoError(t, session.SendToolResult("call_1", "sunny"))
+	output := <-received
+	item, _ := json.Marshal(output["item"])
+	assert.JSONEq(t, `{"type":"function_call_output","call_id":"call_1","output":"sunny"}`, string(item))
+
+	require.NoError(t, session.Close())
+	for range session.Events() {
+	}
+}
+
+func TestRealtimeUnsupported(t *testing.T) {
+	l := &LLMImpl{Provider: providers.NewMockProvider("", "", nil), logger: utils.NewLogger(utils.LogLevelOff)}
+	_, err := l.Realtime(context.Background())
+	var llmErr *LLMError
+	require.ErrorAs(t, err, &llmErr)
+	assert.Equal(t, ErrorTypeUnsupported, llmErr.Type)
+}
@@ -0,0 +1,226 @@
+package llm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/teilomillet/gollm/utils"
+)
+
+// ResponseCache stores generated responses by cache key. Implementations
+// must be safe for concurrent use.
+type ResponseCache interface {
+	// Get returns the response cached under key, if any.
+	Get(ctx context.Context, key string) (string, bool)
+	// Set caches a response under key.
+	Set(ctx context.Context, key, response string)
+}
+
+// CacheKeyRequest is a request about to be sent, from which its cache key is
+// computed.
+type CacheKeyRequest struct {
+	Provider string
+	Prompt   *Prompt           // Prompt after the prompt processors
+	Body     []byte            // Request body, which includes the model and options
+	Metadata map[string]string // Metadata of the request, e.g. user or tier
+}
+
+// CacheKeyFunc computes the cache key of a request. Requests with the same
+// key share a cached response; an empty key skips the cache.
+type CacheKeyFunc func(CacheKeyRequest) string
+
+// DefaultCacheKey keys requests by provider and request body, so only
+// identical requests share a response.
+func DefaultCacheKey(r CacheKeyRequest) string {
+	h := sha256.New()
+	h.Write([]byte(r.Provider))
+	h.Write([]byte{0})
+	h.Write(r.Body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// CacheControl sets how a request uses the response cache.
+type CacheControl int
+
+const (
+	CacheDefault  CacheControl = iota // Serve cached responses and cache new ones
+	CacheBypass                       // Neither read nor write the cache
+	CacheRefresh                      // Skip cached responses but cache the new one
+	CacheReadOnly                     // Serve cached responses without caching new ones
+)
+
+// WithCacheControl sets how a Generate call uses the response cache of the
+// LLM, e.g. CacheRefresh to replace a stale response.
+func WithCacheControl(control CacheControl) GenerateOption {
+	return func(c *GenerateConfig) {
+		c.CacheControl = control
+	}
+}
+
+// WithCacheKey computes the cache key of a Generate call with key instead of
+// the key function of the LLM.
+func WithCacheKey(key CacheKeyFunc) GenerateOption {
+	return func(c *GenerateConfig) {
+		c.CacheKey = key
+	}
+}
+
+// ResponseCacher is implemented by LLMs that can cache their responses.
+type ResponseCacher interface {
+	// UseResponseCache caches responses in cache, keyed by key.
+	UseResponseCache(cache ResponseCache, key CacheKeyFunc)
+}
+
+// responseCaching is the response cache of an LLM, shared with its profile
+// LLMs.
+type responseCaching struct {
+	mu    sync.RWMutex
+	cache ResponseCache
+	key   CacheKeyFunc
+}
+
+// UseResponseCache caches the responses of Generate and GenerateWithSchema
+// in cache, keyed by key, or DefaultCacheKey when nil. A nil cache turns
+// caching off. Cached responses are served without a request, so they report
+// no usage, logprobs or citations; requests for several choices and dry runs
+// are never cached.
+//
+// Example usage:
+//
+//	// Share responses across users of the same tier, whatever the date in the prompt
+//	l.UseResponseCache(llm.NewMemoryCache(time.Hour), func(r llm.CacheKeyRequest) string {
+//	    body := datePattern.ReplaceAll(r.Body, nil)
+//	    return r.Metadata["tier"] + ":" + llm.DefaultCacheKey(llm.CacheKeyRequest{Provider: r.Provider, Body: body})
+//	})
+func (l *LLMImpl) UseResponseCache(cache ResponseCache, key CacheKeyFunc) {
+	if key == nil {
+		key = DefaultCacheKey
+	}
+	l.optionsMutex.Lock()
+	if l.caching == nil {
+		l.caching = &responseCaching{}
+	}
+	caching := l.caching
+	l.optionsMutex.Unlock()
+
+	caching.mu.Lock()
+	defer caching.mu.Unlock()
+	caching.cache, caching.key = cache, key
+}
+
+// UseResponseCache sets the response cache of the underlying LLM.
+func (l *LLMWithMemory) UseResponseCache(cache ResponseCache, key CacheKeyFunc) {
+	if c, ok := l.LLM.(ResponseCacher); ok {
+		c.UseResponseCache(cache, key)
+	}
+}
+
+// cachedRequest is a request's use of the response cache.
+type cachedRequest struct {
+	cache   ResponseCache
+	key     string
+	control CacheControl
+}
+
+// responseCache returns how a request uses the response cache, or nil when
+// it does not.
+func (l *LLMImpl) responseCache(prompt *Prompt, body []byte, config *GenerateConfig) *cachedRequest {
+	if config.CacheControl == CacheBypass || config.N > 1 || config.DryRun != nil {
+		return nil
+	}
+	l.optionsMutex.RLock()
+	caching := l.caching
+	l.optionsMutex.RUnlock()
+	if caching == nil {
+		return nil
+	}
+	caching.mu.RLock()
+	cache, key := caching.cache, caching.key
+	caching.mu.RUnlock()
+	if cache == nil {
+		return nil
+	}
+	if config.CacheKey != nil {
+		key = config.CacheKey
+	}
+	k := key(CacheKeyRequest{Provider: l.Provider.Name(), Prompt: prompt, Body: body, Metadata: config.Metadata})
+	if k == "" {
+		return nil
+	}
+	return &cachedRequest{cache: cache, key: k, control: config.CacheControl}
+}
+
+// get returns the cached response of the request, unless it refreshes it.
+func (r *cachedRequest) get(ctx context.Context) (string, bool) {
+	if r == nil || r.control == CacheRefresh {
+		return "", false
+	}
+	return r.cache.Get(ctx, r.key)
+}
+
+// set caches the response of the request, unless it is read-only.
+func (r *cachedRequest) set(ctx context.Context, response string) {
+	if r == nil || r.control == CacheReadOnly {
+		return
+	}
+	r.cache.Set(ctx, r.key, response)
+}
+
+// MemoryCache is a ResponseCache in memory whose entries expire after a
+// time to live.
+type MemoryCache struct {
+	// Clock expires the entries; defaults to the system clock
+	Clock utils.Clock
+
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]memoryCacheEntry
+}
+
+type memoryCacheEntry struct {
+	response string
+	expires  time.Time // Zero for entries that do not expire
+}
+
+// NewMemoryCache creates an in-memory response cache whose entries expire
+// after ttl, or never when ttl is 0.
+func NewMemoryCache(ttl time.Duration) *MemoryCache {
+	return &MemoryCache{ttl: ttl, entries: make(map[string]memoryCacheEntry)}
+}
+
+// Get returns the response cached under key, if it has not expired.
+func (c *MemoryCache) Get(ctx context.Context, key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		return "", false
+	}
+	if !entry.expires.IsZero() && c.now().After(entry.expires) {
+		delete(c.entries, key)
+		return "", false
+	}
+	return entry.response, true
+}
+
+// Set caches a response under key.
+func (c *MemoryCache) Set(ctx context.Context, key, response string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry := memoryCacheEntry{response: response}
+	if c.ttl > 0 {
+		entry.expires = c.now().Add(c.ttl)
+	}
+	c.entries[key] = entry
+}
+
+// now returns the time of the cache's clock.
+func (c *MemoryCache) now() time.Time {
+	if c.Clock != nil {
+		return c.Clock.Now()
+	}
+	return time.Now()
+}
@@ -0,0 +1,94 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm/providers"
+)
+
+func TestResponseCache(t *testing.T) {
+	ctx := context.Background()
+	calls := 0
+	l := newBatchLLM(t, providers.NewOpenAIProvider("key", "gpt-4o", nil), func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		fmt.Fprintf(w, `{"choices": [{"message": {"role": "assistant", "content": "answer %d"}}]}`, calls)
+	})
+	l.caching = &responseCaching{}
+	generate := func(input string, opts ...GenerateOption) string {
+		t.Helper()
+		response, err := l.Generate(ctx, NewPrompt(input), opts...)
+		require.NoError(t, err)
+		return response
+	}
+
+	// Without a cache every request is sent
+	generate("Hi")
+	generate("Hi")
+	require.Equal(t, 2, calls)
+
+	l.UseResponseCache(NewMemoryCache(0), nil)
+	assert.Equal(t, "answer 3", generate("Hi"))
+	assert.Equal(t, "answer 3", generate("Hi"))
+	assert.Equal(t, "answer 4", generate("Hello"), "other requests have other keys")
+
+	assert.Equal(t, "answer 5", generate("Hi", WithCacheControl(CacheBypass)))
+	assert.Equal(t, "answer 3", generate("Hi"), "bypassed responses are not cached")
+	assert.Equal(t, "answer 6", generate("Hi", WithCacheControl(CacheRefresh)))
+	assert.Equal(t, "answer 6", generate("Hi"), "refreshed responses replace the cached one")
+	assert.Equal(t, "answer 7", generate("New", WithCacheControl(CacheReadOnly)))
+	assert.Equal(t, "answer 8", generate("New"), "read-only requests cache nothing")
+
+	t.Run("CustomKey", func(t *testing.T) {
+		date := regexp.MustCompile(`\d{4}-\d{2}-\d{2}`)
+		l.UseResponseCache(NewMemoryCache(time.Hour), func(r CacheKeyRequest) string {
+			return r.Metadata["tier"] + ":" + date.ReplaceAllString(r.Prompt.Input, "")
+		})
+		first := generate("Today is 2026-01-01. Plan my day.", WithMetadata(map[string]string{"tier": "pro"}))
+		assert.Equal(t, first, generate("Today is 2026-01-02. Plan my day.", WithMetadata(map[string]string{"tier": "pro"})))
+		assert.NotEqual(t, first, generate("Today is 2026-01-02. Plan my day.", WithMetadata(map[string]string{"tier": "free"})))
+
+		// Per-request key functions take precedence; empty keys skip the cache
+		before := calls
+		noCache := WithCacheKey(func(CacheKeyRequest) string { return "" })
+		generate("Today is 2026-01-01. Plan my day.", WithMetadata(map[string]string{"tier": "pro"}), noCache)
+		assert.Equal(t, before+1, calls)
+	})
+
+	t.Run("Schema", func(t *testing.T) {
+		l := newBatchLLM(t, providers.NewOpenAIProvider("key", "gpt-4o", nil), func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			fmt.Fprint(w, `{"choices": [{"message": {"role": "assistant", "content": "{\"n\": \"1\"}"}}]}`)
+		})
+		var keyed []*Prompt
+		l.UseResponseCache(NewMemoryCache(time.Hour), func(r CacheKeyRequest) string {
+			keyed = append(keyed, r.Prompt)
+			return r.Prompt.SystemPrompt + ":" + r.Prompt.Input
+		})
+		schema := map[string]interface{}{"type": "object", "properties": map[string]interface{}{"n": map[string]interface{}{"type": "string"}}}
+		before := calls
+		for _, system := range []string{"Be brief", "Be brief", "Be verbose"} {
+			_, err := l.GenerateWithSchema(ctx, NewPrompt("Count", WithSystemPrompt(system, "")), schema)
+			require.NoError(t, err)
+		}
+		require.Len(t, keyed, 3)
+		assert.Equal(t, "Count", keyed[0].Input, "the key function gets the caller's prompt")
+		assert.Equal(t, before+2, calls, "prompts differing by their system prompt have other keys")
+	})
+
+	t.Run("Expiry", func(t *testing.T) {
+		cache := NewMemoryCache(time.Millisecond)
+		cache.Set(ctx, "k", "v")
+		_, ok := cache.Get(ctx, "k")
+		assert.True(t, ok)
+		time.Sleep(5 * time.Millisecond)
+		_, ok = cache.Get(ctx, "k")
+		assert.False(t, ok)
+	})
+}
@@ -0,0 +1,130 @@
+package llm
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/teilomillet/gollm/utils"
+)
+
+// RetryBudget limits the attempts and the time of a single request across
+// its retries and, behind a router, the models it falls back to. Create one
+// per request: every attempt made with it draws from the same budget.
+type RetryBudget struct {
+	MaxAttempts int           // Attempts allowed in total; zero means no limit
+	MaxElapsed  time.Duration // Time after the first attempt when no new attempt starts; zero means no limit
+	Clock       utils.Clock   // Measures the elapsed time; defaults to the system clock
+
+	mu       sync.Mutex
+	attempts int
+	start    time.Time
+}
+
+// NewRetryBudget creates a budget of maxAttempts attempts within maxElapsed.
+// Zero leaves either unlimited.
+func NewRetryBudget(maxAttempts int, maxElapsed time.Duration) *RetryBudget {
+	return &RetryBudget{MaxAttempts: maxAttempts, MaxElapsed: maxElapsed}
+}
+
+// WithRetryBudget bounds the request by budget, shared with every other
+// request given the same budget. Once it is spent, no retry or fallback is
+// attempted and the call fails with an ErrorTypeRetryBudgetExhausted error
+// wrapping the last failure.
+//
+// Example:
+//
+//	// At most 4 attempts in 20 seconds, across the router's fallbacks
+//	response, err := router.Generate(ctx, prompt, llm.WithRetryBudget(llm.NewRetryBudget(4, 20*time.Second)))
+func WithRetryBudget(budget *RetryBudget) GenerateOption {
+	return func(c *GenerateConfig) {
+		c.RetryBudget = budget
+	}
+}
+
+// Attempts returns the number of attempts made so far.
+func (b *RetryBudget) Attempts() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.attempts
+}
+
+// Elapsed returns the time since the first attempt.
+func (b *RetryBudget) Elapsed() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.start.IsZero() {
+		return 0
+	}
+	return b.since()
+}
+
+// Exhausted reports whether the budget allows no further attempt.
+func (b *RetryBudget) Exhausted() bool {
+	if b == nil {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.exhausted(0)
+}
+
+// exhausted reports whether no attempt may start after delay. The caller
+// holds the lock.
+func (b *RetryBudget) exhausted(delay time.Duration) bool {
+	if b.MaxAttempts > 0 && b.attempts >= b.MaxAttempts {
+		return true
+	}
+	return b.MaxElapsed > 0 && !b.start.IsZero() && b.since()+delay >= b.MaxElapsed
+}
+
+// take records an attempt, or returns an error wrapping lastErr if the
+// budget is spent. A nil budget allows every attempt.
+func (b *RetryBudget) take(lastErr error) error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.exhausted(0) {
+		return b.exhaustedError(lastErr)
+	}
+	if b.start.IsZero() {
+		b.start = b.now()
+	}
+	b.attempts++
+	return nil
+}
+
+// check returns an error wrapping lastErr if no attempt may start after
+// waiting delay, so that a retry is not waited for in vain.
+func (b *RetryBudget) check(delay time.Duration, lastErr error) error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.exhausted(delay) {
+		return b.exhaustedError(lastErr)
+	}
+	return nil
+}
+
+// now returns the time of the budget's clock.
+func (b *RetryBudget) now() time.Time {
+	if b.Clock != nil {
+		return b.Clock.Now()
+	}
+	return time.Now()
+}
+
+// since returns the time since the first attempt.
+func (b *RetryBudget) since() time.Duration {
+	return b.now().Sub(b.start)
+}
+
+// exhaustedError reports the spent budget. The caller holds the lock.
+func (b *RetryBudget) exhaustedError(lastErr error) error {
+	message := fmt.Sprintf("retry budget exhausted after %d attempts in %s", b.attempts, b.since().Round(time.Millisecond))
+	return NewLLMError(ErrorTypeRetryBudgetExhausted, message, lastErr)
+}
@@ -0,0 +1,57 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm/providers"
+)
+
+func TestRetryBudget(t *testing.T) {
+	ctx := context.Background()
+	l := newMockLLM(t)
+	l.MaxRetries = 3
+	mock := l.Provider.(*providers.MockProvider)
+
+	t.Run("MaxAttempts", func(t *testing.T) {
+		mock.Reset()
+		for i := 0; i < 4; i++ {
+			mock.QueueError(http.StatusInternalServerError, "down")
+		}
+		budget := NewRetryBudget(2, 0)
+		_, err := l.Generate(ctx, NewPrompt("Hi"), WithRetryBudget(budget))
+		var llmErr *LLMError
+		require.ErrorAs(t, err, &llmErr)
+		assert.Equal(t, ErrorTypeRetryBudgetExhausted, llmErr.Type)
+		assert.ErrorContains(t, err, "down")
+		assert.Equal(t, 2, mock.CallCount())
+		assert.Equal(t, 2, budget.Attempts())
+		assert.True(t, budget.Exhausted())
+
+		// The spent budget is shared with later requests
+		mock.QueueResponse("Hello")
+		_, err = l.Generate(ctx, NewPrompt("Hi"), WithRetryBudget(budget))
+		require.ErrorAs(t, err, &llmErr)
+		assert.Equal(t, 2, mock.CallCount())
+	})
+
+	t.Run("MaxElapsed", func(t *testing.T) {
+		mock.Reset()
+		mock.QueueError(http.StatusInternalServerError, "down")
+		l.RetryDelay = time.Hour
+		defer func() { l.RetryDelay = 0 }()
+
+		// The retry would start after the budget's time, so it is not waited for
+		start := time.Now()
+		_, err := l.Generate(ctx, NewPrompt("Hi"), WithRetryBudget(NewRetryBudget(0, time.Minute)))
+		var llmErr *LLMError
+		require.ErrorAs(t, err, &llmErr)
+		assert.Equal(t, ErrorTypeRetryBudgetExhausted, llmErr.Type)
+		assert.Less(t, time.Since(start), time.Minute)
+		assert.Equal(t, 1, mock.CallCount())
+	})
+}
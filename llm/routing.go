@@ -0,0 +1,50 @@
+package llm
+
+import (
+	"time"
+
+	"github.com/teilomillet/gollm/providers"
+)
+
+// RouteRequirements are the constraints a router must satisfy when choosing
+// the model serving a request. Zero values impose no constraint.
+type RouteRequirements struct {
+	MaxLatency       time.Duration          // Maximum expected latency of the model
+	MinContextWindow int                    // Minimum context window in tokens
+	Capabilities     []providers.Capability // Capabilities the model must support
+	OutputTokens     int                    // Expected response length, used to estimate cost
+	Provider         string                 // Restricts routing to one provider
+}
+
+// Merge returns the requirements with the non-zero fields of override
+// replacing those of r. Capabilities are combined.
+func (r RouteRequirements) Merge(override RouteRequirements) RouteRequirements {
+	if override.MaxLatency > 0 {
+		r.MaxLatency = override.MaxLatency
+	}
+	if override.MinContextWindow > 0 {
+		r.MinContextWindow = override.MinContextWindow
+	}
+	if override.OutputTokens > 0 {
+		r.OutputTokens = override.OutputTokens
+	}
+	if override.Provider != "" {
+		r.Provider = override.Provider
+	}
+	r.Capabilities = append(append([]providers.Capability(nil), r.Capabilities...), override.Capabilities...)
+	return r
+}
+
+// WithRouteRequirements overrides the routing constraints of a router for a
+// single request. LLMs that do not route ignore it.
+//
+// Example:
+//
+//	router.Generate(ctx, prompt, llm.WithRouteRequirements(llm.RouteRequirements{
+//	    MaxLatency: 2 * time.Second,
+//	}))
+func WithRouteRequirements(requirements RouteRequirements) GenerateOption {
+	return func(c *GenerateConfig) {
+		c.Route = &requirements
+	}
+}
@@ -0,0 +1,293 @@
+package llm
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Repair is a fix applied to a JSON response by SanitizeJSON.
+type Repair string
+
+const (
+	RepairCodeFence       Repair = "code_fence"       // Removed the markdown code fence around the JSON
+	RepairSurroundingText Repair = "surrounding_text" // Cut the JSON out of the text around it
+	RepairTrailingComma   Repair = "trailing_comma"   // Dropped commas before a closing brace or bracket
+	RepairSingleQuotes    Repair = "single_quotes"    // Turned single-quoted strings into double-quoted ones
+	RepairTruncated       Repair = "truncated"        // Closed the strings, arrays and objects of a cut-off response
+)
+
+// SanitizeReport lists the repairs SanitizeJSON applied to a response.
+type SanitizeReport struct {
+	Repairs []Repair
+}
+
+// Repaired reports whether the response needed any repair.
+func (r SanitizeReport) Repaired() bool {
+	return len(r.Repairs) > 0
+}
+
+// Has reports whether the repair was applied.
+func (r SanitizeReport) Has(repair Repair) bool {
+	for _, applied := range r.Repairs {
+		if applied == repair {
+			return true
+		}
+	}
+	return false
+}
+
+// SanitizeJSON cleans a response expected to hold JSON: it removes code
+// fences and the text around the first JSON object or array, turns single
+// quotes into double quotes, drops trailing commas, and closes what a
+// response cut off by the token limit left open. Valid JSON is returned
+// unchanged. The result may still be invalid when the response is too
+// damaged, so it should be unmarshaled as usual.
+//
+// Example usage:
+//
+//	cleaned, report := llm.SanitizeJSON(response)
+//	if report.Has(llm.RepairTruncated) {
+//	    log.Println("response was cut off, raise the token limit")
+//	}
+//	err := json.Unmarshal([]byte(cleaned), &result)
+func SanitizeJSON(response string) (string, SanitizeReport) {
+	var report SanitizeReport
+	text := strings.TrimSpace(response)
+	if json.Valid([]byte(text)) {
+		return text, report
+	}
+
+	if body, _, ok := cutCodeFence(text); ok {
+		text = body
+		report.Repairs = append(report.Repairs, RepairCodeFence)
+	} else if strings.HasPrefix(text, "```") {
+		// A truncated response loses its closing fence
+		text = strings.TrimPrefix(text, "```")
+		if newline := strings.IndexByte(text, '\n'); newline != -1 {
+			text = text[newline+1:]
+		}
+		report.Repairs = append(report.Repairs, RepairCodeFence)
+	}
+
+	start := strings.IndexAny(text, "{[")
+	if start == -1 {
+		return text, report
+	}
+	end, complete := scanJSON(text[start:])
+	value := text[start : start+end]
+	if strings.TrimSpace(text[:start]) != "" || strings.TrimSpace(text[start+end:]) != "" {
+		report.Repairs = append(report.Repairs, RepairSurroundingText)
+	}
+
+	if quoted := doubleQuote(value); quoted != value {
+		value = quoted
+		report.Repairs = append(report.Repairs, RepairSingleQuotes)
+	}
+	if !complete {
+		value = closeJSON(value)
+		report.Repairs = append(report.Repairs, RepairTruncated)
+	}
+	if dropped := dropTrailingCommas(value); dropped != value {
+		value = dropped
+		report.Repairs = append(report.Repairs, RepairTrailingComma)
+	}
+	return value, report
+}
+
+// WithSanitizer runs SanitizeJSON on the response of a Generate call before
+// any response processor, and on the response of GenerateWithSchema before it
+// is validated against the schema. The repairs of the last attempt are
+// recorded into report, which may be nil.
+//
+// Example usage:
+//
+//	var report llm.SanitizeReport
+//	response, err := l.GenerateWithSchema(ctx, prompt, schema, llm.WithSanitizer(&report))
+func WithSanitizer(report *SanitizeReport) GenerateOption {
+	if report == nil {
+		report = &SanitizeReport{}
+	}
+	return func(c *GenerateConfig) {
+		c.Sanitize = report
+	}
+}
+
+// sanitize applies SanitizeJSON to a response if the request asks for it.
+func (l *LLMImpl) sanitize(response string, config *GenerateConfig) string {
+	if config.Sanitize == nil {
+		return response
+	}
+	sanitized, report := SanitizeJSON(response)
+	*config.Sanitize = report
+	if report.Repaired() {
+		l.logger.Debug("Sanitized response", "repairs", report.Repairs)
+	}
+	return sanitized
+}
+
+// scanJSON returns the length of the JSON object or array at the start of
+// text, and false if text ends before the value is closed. Strings may be
+// single- or double-quoted.
+func scanJSON(text string) (int, bool) {
+	var stack []byte
+	var quote byte
+	escaped := false
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == quote:
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '{' || c == '[':
+			stack = append(stack, c)
+		case c == '}' || c == ']':
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+			if len(stack) == 0 {
+				return i + 1, true
+			}
+		}
+	}
+	return len(text), false
+}
+
+// doubleQuote turns the single-quoted strings of text into double-quoted
+// ones, escaping the double quotes they contain.
+func doubleQuote(text string) string {
+	if !strings.Contains(text, "'") {
+		return text
+	}
+	var b strings.Builder
+	b.Grow(len(text))
+	var quote byte
+	escaped := false
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote == 0:
+			if c == '\'' {
+				quote = c
+				c = '"'
+			} else if c == '"' {
+				quote = c
+			}
+		case escaped:
+			escaped = false
+			if quote == '\'' && c == '\'' {
+				// \' needs no escape in a double-quoted string
+				b.WriteByte(c)
+				continue
+			}
+		case c == '\\':
+			escaped = true
+			if quote == '\'' && i+1 < len(text) && text[i+1] == '\'' {
+				continue
+			}
+		case c == quote:
+			if quote == '\'' {
+				c = '"'
+			}
+			quote = 0
+		case quote == '\'' && c == '"':
+			b.WriteString(`\"`)
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// closeJSON completes a double-quoted JSON value cut off at its end: it
+// closes an open string, completes a cut-off literal, gives a dangling key a
+// null value and closes the open arrays and objects.
+func closeJSON(text string) string {
+	var stack []byte
+	inString, escaped := false, false
+	key := false         // Whether the last string is an object key
+	lastToken := byte(0) // Last structural character outside strings
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case inString:
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+		case c == '"':
+			inString = true
+			key = len(stack) > 0 && stack[len(stack)-1] == '{' && (lastToken == '{' || lastToken == ',')
+		case c == '{' || c == '[':
+			stack = append(stack, c)
+			lastToken = c
+		case c == '}' || c == ']':
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+			lastToken = c
+		case c == ',' || c == ':':
+			lastToken = c
+		}
+	}
+
+	var b strings.Builder
+	if inString {
+		if escaped {
+			// Drop the backslash of a cut-off escape sequence
+			text = text[:len(text)-1]
+		}
+		b.WriteString(text + `"`)
+		if key {
+			b.WriteString(":null")
+		}
+	} else {
+		trimmed := strings.TrimSuffix(strings.TrimRight(text, " \t\r\n"), ",")
+		switch {
+		case strings.HasSuffix(trimmed, ":"):
+			trimmed += "null"
+		case strings.HasSuffix(trimmed, `"`) && key:
+			// A complete key without its value
+			trimmed += ":null"
+		default:
+			trimmed = completeLiteral(trimmed)
+		}
+		b.WriteString(trimmed)
+	}
+	for i := len(stack) - 1; i >= 0; i-- {
+		if stack[i] == '{' {
+			b.WriteByte('}')
+		} else {
+			b.WriteByte(']')
+		}
+	}
+	return b.String()
+}
+
+// completeLiteral completes a true, false or null literal cut off at the end
+// of text, and drops the dangling sign, point or exponent of a number.
+func completeLiteral(text string) string {
+	word := len(text)
+	for word > 0 && text[word-1] >= 'a' && text[word-1] <= 'z' {
+		word--
+	}
+	if partial := text[word:]; partial != "" {
+		for _, literal := range []string{"true", "false", "null"} {
+			if strings.HasPrefix(literal, partial) {
+				return text[:word] + literal
+			}
+		}
+	}
+	return strings.TrimRight(text, "-+.eE")
+}
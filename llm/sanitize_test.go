@@ -0,0 +1,68 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm/providers"
+)
+
+func TestSanitizeJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		want     string
+		repairs  []Repair
+	}{
+		{"Valid", ` {"a": 1} `, `{"a": 1}`, nil},
+		{"CodeFence", "```json\n{\"a\": 1}\n```", `{"a": 1}`, []Repair{RepairCodeFence}},
+		{"SurroundingText", "Here's the result: {\"a\": \"it's\"} Let me know if you'd like more.", `{"a": "it's"}`, []Repair{RepairSurroundingText}},
+		{"TrailingComma", `{"a": [1, 2,], "b": 3,}`, `{"a": [1, 2], "b": 3}`, []Repair{RepairTrailingComma}},
+		{"SingleQuotes", `{'name': 'Ada "the Countess"', 'it\'s': true}`, `{"name": "Ada \"the Countess\"", "it's": true}`, []Repair{RepairSingleQuotes}},
+		{"TruncatedString", `{"items": [{"name": "Ada"}, {"name": "Gra`, `{"items": [{"name": "Ada"}, {"name": "Gra"}]}`, []Repair{RepairTruncated}},
+		{"TruncatedKey", `{"a": 1, "b`, `{"a": 1, "b":null}`, []Repair{RepairTruncated}},
+		{"TruncatedValue", `{"a": "x", "b":`, `{"a": "x", "b":null}`, []Repair{RepairTruncated}},
+		{"TruncatedLiteral", `{"ok": tr`, `{"ok": true}`, []Repair{RepairTruncated}},
+		{"TruncatedNumber", `[1, 2.`, `[1, 2]`, []Repair{RepairTruncated}},
+		{"TruncatedAfterComma", `{"a": "x",`, `{"a": "x"}`, []Repair{RepairTruncated}},
+		{"TruncatedEscape", `{"a": "line\`, `{"a": "line"}`, []Repair{RepairTruncated}},
+		{"TruncatedFence", "```json\n{\"a\": [1, 2", `{"a": [1, 2]}`, []Repair{RepairCodeFence, RepairTruncated}},
+		{"NoJSON", "I cannot help with that.", "I cannot help with that.", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, report := SanitizeJSON(tt.response)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.repairs, report.Repairs)
+			if tt.name != "NoJSON" {
+				assert.True(t, json.Valid([]byte(got)), got)
+			}
+		})
+	}
+}
+
+func TestWithSanitizer(t *testing.T) {
+	ctx := context.Background()
+	l := newMockLLM(t)
+	mock := l.Provider.(*providers.MockProvider)
+
+	mock.QueueResponse("```json\n{'city': 'Paris', 'population': 2100000,}\n```")
+	var report SanitizeReport
+	response, err := l.GenerateWithSchema(ctx, NewPrompt("Largest city of France?"), map[string]interface{}{
+		"type":       "object",
+		"properties": map[string]interface{}{"city": map[string]interface{}{"type": "string"}},
+		"required":   []string{"city"},
+	}, WithSanitizer(&report))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"city": "Paris", "population": 2100000}`, response)
+	assert.Equal(t, []Repair{RepairCodeFence, RepairSingleQuotes, RepairTrailingComma}, report.Repairs)
+
+	// Without the option, the response is left alone
+	mock.QueueResponse("```json\n{\"city\": \"Paris\"}\n```")
+	response, err = l.Generate(ctx, NewPrompt("Largest city of France?"))
+	require.NoError(t, err)
+	assert.Contains(t, response, "```")
+}
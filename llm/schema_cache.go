@@ -0,0 +1,68 @@
+package llm
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sync"
+)
+
+// maxParsedSchemas bounds the number of schema texts whose parsed form is
+// kept, as they may come from callers rather than a fixed set of types.
+const maxParsedSchemas = 1024
+
+var (
+	// typeSchemas holds the result of GenerateJSONSchema per Go type.
+	typeSchemas sync.Map // reflect.Type -> typeSchema
+
+	// promptSchema holds the default JSON schema of Prompt.
+	promptSchema struct {
+		once   sync.Once
+		schema []byte
+		err    error
+	}
+
+	// parsedSchemas holds the schemas given as JSON text to
+	// ValidateAgainstSchema, parsed, keyed by their text.
+	parsedSchemas   = make(map[string]map[string]interface{})
+	parsedSchemasMu sync.RWMutex
+)
+
+// typeSchema is the memoized schema of a type, or the error generating it.
+type typeSchema struct {
+	schema []byte
+	err    error
+}
+
+// cachedTypeSchema returns the schema of a type, generating it on first use.
+// Callers receive their own copy of the schema.
+func cachedTypeSchema(t reflect.Type, generate func() ([]byte, error)) ([]byte, error) {
+	cached, ok := typeSchemas.Load(t)
+	if !ok {
+		var entry typeSchema
+		entry.schema, entry.err = generate()
+		cached, _ = typeSchemas.LoadOrStore(t, entry)
+	}
+	entry := cached.(typeSchema)
+	return bytes.Clone(entry.schema), entry.err
+}
+
+// parsedSchema returns a schema given as JSON text, parsed. The result is
+// shared and must not be modified.
+func parsedSchema(text string) (map[string]interface{}, error) {
+	parsedSchemasMu.RLock()
+	schema, ok := parsedSchemas[text]
+	parsedSchemasMu.RUnlock()
+	if ok {
+		return schema, nil
+	}
+	if err := json.Unmarshal([]byte(text), &schema); err != nil {
+		return nil, err
+	}
+	parsedSchemasMu.Lock()
+	if len(parsedSchemas) < maxParsedSchemas {
+		parsedSchemas[text] = schema
+	}
+	parsedSchemasMu.Unlock()
+	return schema, nil
+}
@@ -0,0 +1,82 @@
+package llm
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/invopop/jsonschema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type schemaCacheOrder struct {
+	ID    string   `json:"id" validate:"required"`
+	Items []string `json:"items" validate:"min=1"`
+	Total float64  `json:"total"`
+}
+
+func TestSchemaCache(t *testing.T) {
+	first, err := GenerateJSONSchema(schemaCacheOrder{})
+	require.NoError(t, err)
+	first[0] = 'x'
+	second, err := GenerateJSONSchema(schemaCacheOrder{ID: "other value, same type"})
+	require.NoError(t, err)
+	assert.Equal(t, byte('{'), second[0], "callers get their own copy")
+	assert.Contains(t, string(second), `"required": [`)
+
+	_, err = GenerateJSONSchema(struct{ C chan int }{})
+	assert.Error(t, err)
+	_, err = GenerateJSONSchema(struct{ C chan int }{})
+	assert.Error(t, err, "errors are memoized too")
+
+	expected, err := (&jsonschema.Reflector{}).Reflect(&Prompt{}).MarshalJSON()
+	require.NoError(t, err)
+	schema, err := NewPrompt("any").GenerateJSONSchema()
+	require.NoError(t, err)
+	assert.JSONEq(t, string(expected), string(schema))
+
+	require.NoError(t, ValidateAgainstSchema(`{"id":"1","items":["a"],"total":2}`, second))
+	assert.Error(t, ValidateAgainstSchema(`{"items":["a"]}`, string(second)), "cached parsed schemas still validate")
+}
+
+func BenchmarkGenerateJSONSchema(b *testing.B) {
+	b.Run("Cached", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := GenerateJSONSchema(schemaCacheOrder{}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("Reflected", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := generateJSONSchema(reflect.TypeOf(schemaCacheOrder{})); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkPromptJSONSchema(b *testing.B) {
+	prompt := NewPrompt("any")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := prompt.GenerateJSONSchema(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkValidateAgainstSchema(b *testing.B) {
+	schema, err := GenerateJSONSchema(schemaCacheOrder{})
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := ValidateAgainstSchema(`{"id":"1","items":["a"],"total":2}`, schema); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
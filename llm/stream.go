@@ -5,7 +5,10 @@ import (
 	"bytes"
 	"context"
 	"io"
+	"sync"
 	"time"
+
+	"github.com/teilomillet/gollm/providers"
 )
 
 // StreamToken represents a single token from the streaming response.
@@ -21,6 +24,72 @@ type StreamToken struct {
 
 	// Metadata contains provider-specific metadata
 	Metadata map[string]interface{}
+
+	// ToolCall is the tool call delta carried by tokens of type TokenTypeToolCall
+	ToolCall *ToolCallDelta
+
+	// Done is the final metadata carried by the token of type TokenTypeDone
+	Done *StreamDone
+}
+
+// TokenTypeToolCall is the type of stream tokens that carry a tool call delta
+// instead of text.
+const TokenTypeToolCall = "function_call"
+
+// ToolCallDelta is an incremental piece of a streamed tool call.
+type ToolCallDelta = providers.ToolCallDelta
+
+// ToolCallAccumulator assembles streamed tool call deltas into complete calls.
+//
+// Example usage:
+//
+//	var calls llm.ToolCallAccumulator
+//	for {
+//	    token, err := stream.Next(ctx)
+//	    if err != nil {
+//	        break
+//	    }
+//	    if token.Type == llm.TokenTypeToolCall {
+//	        call := calls.Add(*token.ToolCall)
+//	        fmt.Printf("\rcalling %s(%s…)", call.Function.Name, call.Function.Arguments)
+//	        continue
+//	    }
+//	    fmt.Print(token.Text)
+//	}
+type ToolCallAccumulator struct {
+	calls []*ToolCall
+	index map[int]*ToolCall
+}
+
+// Add appends a delta to its call and returns the call as assembled so far.
+func (a *ToolCallAccumulator) Add(delta ToolCallDelta) ToolCall {
+	if a.index == nil {
+		a.index = make(map[int]*ToolCall)
+	}
+	call, ok := a.index[delta.Index]
+	if !ok {
+		call = &ToolCall{Type: "function"}
+		a.index[delta.Index] = call
+		a.calls = append(a.calls, call)
+	}
+	if delta.ID != "" {
+		call.ID = delta.ID
+	}
+	if delta.Name != "" {
+		call.Function.Name = delta.Name
+	}
+	call.Function.Arguments = append(call.Function.Arguments, delta.Arguments...)
+	return *call
+}
+
+// Calls returns the calls in the order they started.
+func (a *ToolCallAccumulator) Calls() []ToolCall {
+	calls := make([]ToolCall, len(a.calls))
+	for i, call := range a.calls {
+		calls[i] = *call
+		calls[i].Function.Arguments = append([]byte(nil), call.Function.Arguments...)
+	}
+	return calls
 }
 
 // TokenStream represents a stream of tokens from the LLM.
@@ -39,11 +108,30 @@ type StreamOption func(*StreamConfig)
 
 // StreamConfig holds configuration options for streaming.
 type StreamConfig struct {
-	// BufferSize is the size of the token buffer
+	// BufferSize is the number of tokens buffered ahead of the consumer
+	// when Backpressure is set
 	BufferSize int
 
-	// RetryStrategy defines how to handle stream interruptions
+	// Backpressure is the policy of a stream read in the background, nil to
+	// read the response only as the consumer asks for tokens
+	Backpressure *BackpressurePolicy
+
+	// Deprecated: RetryStrategy is ignored. A response interrupted mid-stream
+	// cannot be resumed, so the read error is returned by Next.
 	RetryStrategy RetryStrategy
+
+	// Priority is the priority of the stream in the provider's queue
+	Priority Priority
+
+	// StopConditions end the stream on the client side once met
+	StopConditions []StopCondition
+
+	// Metadata is the key/value metadata of the request, e.g. user or feature
+	Metadata map[string]string
+
+	// Options are provider options for this stream only, taking precedence
+	// over the LLM's options
+	Options map[string]interface{}
 }
 
 // RetryStrategy defines how to handle stream interruptions.
@@ -83,11 +171,28 @@ func (s *DefaultRetryStrategy) Reset() {
 	s.attempts = 0
 }
 
-// SSEDecoder handles Server-Sent Events (SSE) streaming
+// maxEventLine is the longest line of a stream the SSEDecoder accepts.
+const maxEventLine = providers.MaxResponseSize
+
+// decoderBuffers holds the line and data buffers of released decoders, so
+// that streams don't allocate them anew.
+var decoderBuffers = sync.Pool{
+	New: func() interface{} {
+		return &decoderBuffer{line: make([]byte, 4096), data: make([]byte, 0, 4096)}
+	},
+}
+
+type decoderBuffer struct {
+	line []byte
+	data []byte
+}
+
+// SSEDecoder handles Server-Sent Events (SSE) streaming. It also decodes
+// newline-delimited JSON streams, such as Ollama's: a line holding a JSON
+// object is an event by itself.
 type SSEDecoder struct {
-	reader  *bufio.Scanner
+	decoder *eventDecoder
 	current Event
-	err     error
 }
 
 type Event struct {
@@ -96,28 +201,75 @@ type Event struct {
 }
 
 func NewSSEDecoder(reader io.Reader) *SSEDecoder {
-	return &SSEDecoder{
-		reader: bufio.NewScanner(reader),
-	}
+	return &SSEDecoder{decoder: newEventDecoder(reader)}
 }
 
+// Next decodes the next event. The buffers of the decoder are released once
+// the stream ends.
 func (d *SSEDecoder) Next() bool {
-	if d.err != nil {
+	if !d.decoder.Next() {
+		d.decoder.Release()
 		return false
 	}
+	event := d.decoder.Event()
+	d.current = Event{Type: event.Type, Data: append([]byte(nil), event.Data...)}
+	return true
+}
+
+// Event returns the current event, whose data remains valid after the next
+// call to Next.
+func (d *SSEDecoder) Event() Event {
+	return d.current
+}
+
+func (d *SSEDecoder) Err() error {
+	return d.decoder.Err()
+}
+
+// eventDecoder decodes events like SSEDecoder, reusing its buffers: the data
+// of an event is only valid until the next call to Next. Once the stream is
+// consumed, Release returns the buffers to a pool shared by decoders.
+type eventDecoder struct {
+	reader    *bufio.Scanner
+	buffer    *decoderBuffer
+	eventType []byte
+	current   Event
+	err       error
+}
+
+func newEventDecoder(reader io.Reader) *eventDecoder {
+	buffer := decoderBuffers.Get().(*decoderBuffer)
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(buffer.line, maxEventLine)
+	return &eventDecoder{
+		reader: scanner,
+		buffer: buffer,
+	}
+}
 
-	event := ""
-	data := bytes.NewBuffer(nil)
+func (d *eventDecoder) Next() bool {
+	if d.err != nil || d.buffer == nil {
+		return false
+	}
 
+	d.eventType = d.eventType[:0]
+	data := d.buffer.data[:0]
 	for d.reader.Scan() {
 		line := d.reader.Bytes()
 
+		// A JSON line outside of an event is an event of its own
+		if len(line) > 0 && line[0] == '{' && len(data) == 0 && len(d.eventType) == 0 {
+			data = append(data, line...)
+			d.dispatch(data)
+			return true
+		}
+
 		// Dispatch event on empty line
 		if len(line) == 0 {
-			d.current = Event{
-				Type: event,
-				Data: data.Bytes(),
+			if len(data) > 0 {
+				data = data[:len(data)-1] // The newline of the last data line
 			}
+			d.dispatch(data)
 			return true
 		}
 
@@ -133,20 +285,44 @@ func (d *SSEDecoder) Next() bool {
 		case "":
 			continue // Skip comments
 		case "event":
-			event = string(value)
+			d.eventType = append(d.eventType[:0], value...)
 		case "data":
-			data.Write(value)
-			data.WriteRune('\n')
+			data = append(data, value...)
+			data = append(data, '\n')
 		}
 	}
 
+	d.buffer.data = data
+	d.err = d.reader.Err()
 	return false
 }
 
-func (d *SSEDecoder) Event() Event {
+// dispatch makes the data the current event, keeping the grown buffer for
+// the next events.
+func (d *eventDecoder) dispatch(data []byte) {
+	d.buffer.data = data
+	d.current.Data = data
+	if string(d.eventType) != d.current.Type {
+		// Streams repeat few event types; only a new one is allocated
+		d.current.Type = string(d.eventType)
+	}
+}
+
+func (d *eventDecoder) Event() Event {
 	return d.current
 }
 
-func (d *SSEDecoder) Err() error {
+func (d *eventDecoder) Err() error {
 	return d.err
 }
+
+// Release returns the buffers of the decoder to the pool. The decoder and
+// the data of its events must not be used afterwards.
+func (d *eventDecoder) Release() {
+	if d.buffer == nil {
+		return
+	}
+	d.current = Event{}
+	decoderBuffers.Put(d.buffer)
+	d.buffer = nil
+}
@@ -0,0 +1,208 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+)
+
+// ConsumeStream calls fn for every token of the stream until it ends, fn
+// returns an error or the context is cancelled. The stream is closed before
+// returning; the end of the stream is not reported as an error.
+func ConsumeStream(ctx context.Context, stream TokenStream, fn func(*StreamToken) error) error {
+	defer stream.Close()
+	for {
+		token, err := stream.Next(ctx)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(token); err != nil {
+			return err
+		}
+	}
+}
+
+// StreamWithCallback streams a response and calls fn for every token,
+// including tool call tokens. Returning an error from fn stops the stream.
+func StreamWithCallback(ctx context.Context, l LLM, prompt *Prompt, fn func(*StreamToken) error, opts ...StreamOption) error {
+	stream, err := l.Stream(ctx, prompt, opts...)
+	if err != nil {
+		return err
+	}
+	return ConsumeStream(ctx, stream, fn)
+}
+
+// StreamToWriter streams a response into w as it is generated and returns the
+// complete text. Only text tokens are written.
+//
+// Example usage:
+//
+//	_, err := llm.StreamToWriter(ctx, client, prompt, os.Stdout)
+func StreamToWriter(ctx context.Context, l LLM, prompt *Prompt, w io.Writer, opts ...StreamOption) (string, error) {
+	var text strings.Builder
+	err := StreamWithCallback(ctx, l, prompt, func(token *StreamToken) error {
+		if token.Type == TokenTypeToolCall || token.Text == "" {
+			return nil
+		}
+		text.WriteString(token.Text)
+		_, err := io.WriteString(w, token.Text)
+		return err
+	}, opts...)
+	return text.String(), err
+}
+
+// StreamToChannel delivers the tokens of a stream on a channel, which is
+// closed when the stream ends. A failure other than the end of the stream is
+// sent on the error channel, which is closed afterwards. The stream is closed
+// when it ends or the context is cancelled.
+func StreamToChannel(ctx context.Context, stream TokenStream, buffer int) (<-chan *StreamToken, <-chan error) {
+	tokens := make(chan *StreamToken, buffer)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(errc)
+		defer close(tokens)
+		err := ConsumeStream(ctx, stream, func(token *StreamToken) error {
+			select {
+			case tokens <- token:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		if err != nil {
+			errc <- err
+		}
+	}()
+	return tokens, errc
+}
+
+// TeeStream splits a stream into n streams that each receive every token,
+// e.g. to render a response while logging it. Each consumer buffers the
+// tokens it has not read yet, so a slow consumer does not block the others.
+// The source is read in the background and closed when it ends or when every
+// consumer has been closed.
+func TeeStream(ctx context.Context, stream TokenStream, n int) []TokenStream {
+	ctx, cancel := context.WithCancel(ctx)
+	tee := &streamTee{cancel: cancel, open: n}
+	consumers := make([]TokenStream, n)
+	for i := range consumers {
+		c := &teeConsumer{tee: tee, notify: make(chan struct{}, 1)}
+		tee.consumers = append(tee.consumers, c)
+		consumers[i] = c
+	}
+	go tee.pump(ctx, stream)
+	return consumers
+}
+
+// streamTee fans the tokens of a source stream out to its consumers.
+type streamTee struct {
+	consumers []*teeConsumer
+	cancel    context.CancelFunc
+
+	mu   sync.Mutex
+	open int
+}
+
+func (t *streamTee) pump(ctx context.Context, stream TokenStream) {
+	err := ConsumeStream(ctx, stream, func(token *StreamToken) error {
+		for _, c := range t.consumers {
+			c.push(token, nil)
+		}
+		return nil
+	})
+	if err == nil {
+		err = io.EOF
+	}
+	for _, c := range t.consumers {
+		c.push(nil, err)
+	}
+	t.cancel()
+}
+
+// teeConsumer is one of the streams returned by TeeStream.
+type teeConsumer struct {
+	tee    *streamTee
+	notify chan struct{}
+
+	mu     sync.Mutex
+	tokens []*StreamToken
+	err    error
+	closed bool
+}
+
+func (c *teeConsumer) push(token *StreamToken, err error) {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return
+	}
+	if token != nil {
+		copied := *token
+		c.tokens = append(c.tokens, &copied)
+	}
+	if err != nil {
+		c.err = err
+	}
+	c.mu.Unlock()
+	select {
+	case c.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Next returns the next token, or the error that ended the source stream once
+// every token has been read.
+func (c *teeConsumer) Next(ctx context.Context) (*StreamToken, error) {
+	for {
+		c.mu.Lock()
+		if len(c.tokens) > 0 {
+			token := c.tokens[0]
+			c.tokens = c.tokens[1:]
+			c.mu.Unlock()
+			return token, nil
+		}
+		if c.closed {
+			c.mu.Unlock()
+			return nil, io.EOF
+		}
+		if c.err != nil {
+			err := c.err
+			c.mu.Unlock()
+			return nil, err
+		}
+		c.mu.Unlock()
+
+		select {
+		case <-c.notify:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Close stops delivery to this consumer. The source is closed once every
+// consumer is closed.
+func (c *teeConsumer) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	c.tokens = nil
+	c.mu.Unlock()
+
+	c.tee.mu.Lock()
+	c.tee.open--
+	last := c.tee.open == 0
+	c.tee.mu.Unlock()
+	if last {
+		c.tee.cancel()
+	}
+	return nil
+}
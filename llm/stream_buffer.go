@@ -0,0 +1,212 @@
+package llm
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+// BackpressurePolicy is what a buffered stream does when its consumer falls
+// behind and the buffer is full.
+type BackpressurePolicy int
+
+const (
+	// BackpressureBlock stops reading the response until the consumer
+	// catches up, as unbuffered streams do
+	BackpressureBlock BackpressurePolicy = iota
+
+	// BackpressureDropOldest discards the oldest buffered text token to make
+	// room for new text, for consumers that only need the latest output
+	BackpressureDropOldest
+
+	// BackpressureCoalesce appends new text to the newest buffered text
+	// token, so no text is lost but the consumer receives fewer, larger
+	// tokens
+	BackpressureCoalesce
+)
+
+// WithBufferSize sets how many tokens a stream read with WithBackpressure
+// buffers ahead of its consumer. Defaults to 100.
+func WithBufferSize(size int) StreamOption {
+	return func(c *StreamConfig) {
+		c.BufferSize = size
+	}
+}
+
+// WithBackpressure reads the response in the background, buffering tokens
+// ahead of a slow consumer so the provider's connection keeps draining, and
+// applies policy once the buffer is full. Tool call and terminal tokens are
+// never dropped or merged: when only they could make room, the stream blocks.
+//
+// Example:
+//
+//	// Render the latest text without stalling the response
+//	stream, err := l.Stream(ctx, prompt, llm.WithBufferSize(32), llm.WithBackpressure(llm.BackpressureCoalesce))
+func WithBackpressure(policy BackpressurePolicy) StreamOption {
+	return func(c *StreamConfig) {
+		c.Backpressure = &policy
+	}
+}
+
+// BufferStream reads a stream in the background into a buffer of size
+// tokens, applying policy when the consumer falls behind. The source is read
+// with ctx, and closed when the returned stream is.
+func BufferStream(ctx context.Context, stream TokenStream, size int, policy BackpressurePolicy) TokenStream {
+	if size < 1 {
+		size = 1
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	b := &bufferedStream{
+		source: stream,
+		size:   size,
+		policy: policy,
+		cancel: cancel,
+		notify: make(chan struct{}, 1),
+		space:  make(chan struct{}, 1),
+	}
+	go b.pump(ctx)
+	return b
+}
+
+// bufferedStream is a stream read ahead of its consumer by BufferStream.
+type bufferedStream struct {
+	source TokenStream
+	size   int
+	policy BackpressurePolicy
+	cancel context.CancelFunc
+	notify chan struct{} // Signaled when a token is buffered or the source ends
+	space  chan struct{} // Signaled when the consumer takes a token
+
+	mu     sync.Mutex
+	tokens []*StreamToken
+	err    error // Error that ended the source, io.EOF at its end
+	closed bool
+}
+
+func (b *bufferedStream) pump(ctx context.Context) {
+	for {
+		token, err := b.source.Next(ctx)
+		if err != nil {
+			b.mu.Lock()
+			b.err = err
+			b.mu.Unlock()
+			wake(b.notify)
+			return
+		}
+		if !b.push(ctx, token) {
+			return
+		}
+	}
+}
+
+// push buffers a token, waiting for room when the policy cannot make any.
+// It returns false if the stream was closed or ctx cancelled first.
+func (b *bufferedStream) push(ctx context.Context, token *StreamToken) bool {
+	for {
+		b.mu.Lock()
+		if b.closed {
+			b.mu.Unlock()
+			return false
+		}
+		if b.makeRoom(token) {
+			b.mu.Unlock()
+			wake(b.notify)
+			return true
+		}
+		b.mu.Unlock()
+
+		select {
+		case <-b.space:
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+// makeRoom buffers the token if there is room, or if the policy makes some.
+func (b *bufferedStream) makeRoom(token *StreamToken) bool {
+	if len(b.tokens) < b.size {
+		b.tokens = append(b.tokens, token)
+		return true
+	}
+	if !isTextToken(token) {
+		return false
+	}
+	switch b.policy {
+	case BackpressureDropOldest:
+		for i, t := range b.tokens {
+			if isTextToken(t) {
+				b.tokens = append(b.tokens[:i], b.tokens[i+1:]...)
+				b.tokens = append(b.tokens, token)
+				return true
+			}
+		}
+	case BackpressureCoalesce:
+		if last := b.tokens[len(b.tokens)-1]; isTextToken(last) {
+			merged := *last
+			merged.Text += token.Text
+			b.tokens[len(b.tokens)-1] = &merged
+			return true
+		}
+	}
+	return false
+}
+
+// Next returns the next buffered token, or the error that ended the source
+// once every token has been read.
+func (b *bufferedStream) Next(ctx context.Context) (*StreamToken, error) {
+	for {
+		b.mu.Lock()
+		if b.closed {
+			b.mu.Unlock()
+			return nil, io.EOF
+		}
+		if len(b.tokens) > 0 {
+			token := b.tokens[0]
+			b.tokens = b.tokens[1:]
+			b.mu.Unlock()
+			wake(b.space)
+			return token, nil
+		}
+		if b.err != nil {
+			err := b.err
+			b.mu.Unlock()
+			return nil, err
+		}
+		b.mu.Unlock()
+
+		select {
+		case <-b.notify:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Close stops the background read and closes the source.
+func (b *bufferedStream) Close() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	b.tokens = nil
+	b.mu.Unlock()
+	b.cancel()
+	return b.source.Close()
+}
+
+// isTextToken reports whether a token carries text, rather than a tool call
+// or the end of the stream.
+func isTextToken(token *StreamToken) bool {
+	return token.Type != TokenTypeToolCall && token.Type != TokenTypeDone && token.ToolCall == nil && token.Done == nil
+}
+
+// wake wakes the waiter of a channel of capacity 1 without blocking.
+func wake(c chan struct{}) {
+	select {
+	case c <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,95 @@
+package llm
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// drainedStream is a TokenStream over fixed tokens that reports when it has
+// been read to the end.
+type drainedStream struct {
+	tokens  []*StreamToken
+	drained chan struct{}
+	closed  bool
+}
+
+func newDrainedStream(tokens ...*StreamToken) *drainedStream {
+	return &drainedStream{tokens: tokens, drained: make(chan struct{})}
+}
+
+func (s *drainedStream) Next(ctx context.Context) (*StreamToken, error) {
+	if len(s.tokens) == 0 {
+		close(s.drained)
+		return nil, io.EOF
+	}
+	token := s.tokens[0]
+	s.tokens = s.tokens[1:]
+	return token, nil
+}
+
+func (s *drainedStream) Close() error {
+	s.closed = true
+	return nil
+}
+
+func TestBufferStream(t *testing.T) {
+	ctx := context.Background()
+	text := func(s string) *StreamToken { return &StreamToken{Text: s, Type: "text"} }
+	done := &StreamToken{Type: TokenTypeDone, Done: &StreamDone{FinishReason: "stop"}}
+	toolCall := &StreamToken{Type: TokenTypeToolCall, ToolCall: &ToolCallDelta{Name: "get_weather"}}
+	read := func(stream TokenStream) []string {
+		var texts []string
+		for {
+			token, err := stream.Next(ctx)
+			if err == io.EOF {
+				return texts
+			}
+			require.NoError(t, err)
+			texts = append(texts, token.Type+":"+token.Text)
+		}
+	}
+
+	t.Run("Coalesce", func(t *testing.T) {
+		source := newDrainedStream(text("a"), toolCall, text("b"), text("c"), text("d"))
+		stream := BufferStream(ctx, source, 3, BackpressureCoalesce)
+		<-source.drained
+		assert.Equal(t, []string{"text:a", "function_call:", "text:bcd"}, read(stream))
+	})
+
+	t.Run("DropOldest", func(t *testing.T) {
+		source := newDrainedStream(text("a"), toolCall, text("b"), text("c"), text("d"))
+		stream := BufferStream(ctx, source, 3, BackpressureDropOldest)
+		<-source.drained
+		assert.Equal(t, []string{"function_call:", "text:c", "text:d"}, read(stream))
+	})
+
+	t.Run("Block", func(t *testing.T) {
+		source := newDrainedStream(text("a"), text("b"), text("c"), text("d"), done)
+		stream := BufferStream(ctx, source, 1, BackpressureBlock)
+		assert.Equal(t, []string{"text:a", "text:b", "text:c", "text:d", "done:"}, read(stream))
+	})
+
+	t.Run("TerminalTokensBlock", func(t *testing.T) {
+		// Done cannot be merged nor make room, so it waits for the consumer
+		source := newDrainedStream(text("a"), text("b"), done)
+		stream := BufferStream(ctx, source, 1, BackpressureDropOldest)
+		texts := read(stream)
+		require.GreaterOrEqual(t, len(texts), 2)
+		assert.Equal(t, []string{"text:b", "done:"}, texts[len(texts)-2:])
+	})
+
+	t.Run("Close", func(t *testing.T) {
+		source := &blockingStream{texts: []string{"partial"}}
+		stream := BufferStream(ctx, source, 4, BackpressureBlock)
+		token, err := stream.Next(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "partial", token.Text)
+		require.NoError(t, stream.Close())
+		_, err = stream.Next(ctx)
+		assert.Equal(t, io.EOF, err)
+	})
+}
@@ -0,0 +1,155 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/teilomillet/gollm/utils"
+)
+
+// Reasons a collected stream finished.
+const (
+	FinishReasonStop     = "stop"     // The response is complete
+	FinishReasonDeadline = "deadline" // The context deadline cut the response short
+)
+
+// StreamResult is the text and tool calls of a collected stream.
+type StreamResult struct {
+	Text         string
+	ToolCalls    []ToolCall
+	FinishReason string
+	Usage        *Usage // Usage reported at the end of the stream, if any
+	Model        string // Model version reported by the provider, if any
+}
+
+// CollectOption configures CollectStream.
+type CollectOption func(*collectConfig)
+
+type collectConfig struct {
+	margin time.Duration
+	cancel bool
+	clock  utils.Clock
+}
+
+// WithDeadlineMargin stops reading the stream d before the context deadline,
+// leaving time to use the partial response.
+func WithDeadlineMargin(d time.Duration) CollectOption {
+	return func(c *collectConfig) {
+		c.margin = d
+	}
+}
+
+// WithCancelOnDeadline cancels the read of the stream as soon as it is cut
+// short, dropping the connection so the provider stops generating the rest
+// of the response. Otherwise the request runs until the context deadline.
+func WithCancelOnDeadline() CollectOption {
+	return func(c *collectConfig) {
+		c.cancel = true
+	}
+}
+
+// WithCollectClock sets the clock timing the deadline margin; defaults to
+// the system clock.
+func WithCollectClock(clock utils.Clock) CollectOption {
+	return func(c *collectConfig) {
+		c.clock = clock
+	}
+}
+
+// CollectStream reads a stream until it ends and returns its text and tool
+// calls. The stream is closed once its last read returns, which may be after
+// CollectStream returns when the response is cut short.
+//
+// When the context deadline approaches, the text received so far is returned
+// with FinishReasonDeadline instead of an error, so a slow response is
+// truncated rather than lost. Other failures return the partial result along
+// with the error.
+//
+// Example usage:
+//
+//	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+//	defer cancel()
+//	stream, err := client.Stream(ctx, prompt)
+//	if err != nil {
+//	    return err
+//	}
+//	result, err := llm.CollectStream(ctx, stream, llm.WithDeadlineMargin(500*time.Millisecond), llm.WithCancelOnDeadline())
+//	if result.FinishReason == llm.FinishReasonDeadline {
+//	    log.Print("response truncated")
+//	}
+func CollectStream(ctx context.Context, stream TokenStream, opts ...CollectOption) (*StreamResult, error) {
+	cfg := &collectConfig{clock: utils.SystemClock()}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	// Tokens are read in the background so a read blocked on the network
+	// does not hold the result past the deadline. Only the reader closes the
+	// stream, after its last read: streams need not support concurrent calls,
+	// so the read is cancelled through its context instead.
+	readCtx, stopReading := context.WithCancel(ctx)
+	type next struct {
+		token *StreamToken
+		err   error
+	}
+	results := make(chan next)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		defer stopReading()
+		defer stream.Close()
+		for {
+			token, err := stream.Next(readCtx)
+			select {
+			case results <- next{token, err}:
+			case <-done:
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	var expired <-chan time.Time
+	if deadline, ok := ctx.Deadline(); ok {
+		expired = cfg.clock.After(deadline.Sub(cfg.clock.Now()) - cfg.margin)
+	}
+
+	var text strings.Builder
+	var calls ToolCallAccumulator
+	var metadata StreamDone
+	result := func(reason string) *StreamResult {
+		if reason == FinishReasonStop && metadata.FinishReason != "" {
+			reason = metadata.FinishReason
+		}
+		return &StreamResult{Text: text.String(), ToolCalls: calls.Calls(), FinishReason: reason, Usage: metadata.Usage, Model: metadata.Model}
+	}
+	for {
+		select {
+		case <-expired:
+			if cfg.cancel {
+				stopReading()
+			}
+			return result(FinishReasonDeadline), nil
+		case n := <-results:
+			switch {
+			case errors.Is(n.err, io.EOF):
+				return result(FinishReasonStop), nil
+			case errors.Is(n.err, context.DeadlineExceeded):
+				return result(FinishReasonDeadline), nil
+			case n.err != nil:
+				return result(""), n.err
+			case n.token.Type == TokenTypeToolCall && n.token.ToolCall != nil:
+				calls.Add(*n.token.ToolCall)
+			case n.token.Type == TokenTypeDone && n.token.Done != nil:
+				metadata = *n.token.Done
+			default:
+				text.WriteString(n.token.Text)
+			}
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package llm
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// TokenTypeDone is the type of the last token of a stream that completed
+// normally. It carries no text; its Done field holds the usage, finish
+// reason and model of the response.
+const TokenTypeDone = "done"
+
+// Normalized reasons a response finished, in addition to FinishReasonStop.
+const (
+	FinishReasonLength    = "length"     // The response reached the token limit
+	FinishReasonToolCalls = "tool_calls" // The model called tools
+	FinishReasonAborted   = "aborted"    // The stream was stopped with AbortableStream.Abort
+)
+
+// StreamDone is the final metadata of a streamed response. Providers report
+// different parts of it; missing values are left empty.
+type StreamDone struct {
+	// Usage is the token usage of the request, nil if the provider did not
+	// report it. OpenAI reports it when the request sets
+	// stream_options.include_usage, which gollm does by default.
+	Usage *Usage
+
+	// FinishReason is why the response ended: FinishReasonStop,
+	// FinishReasonLength, FinishReasonToolCalls, or the provider's own
+	// value for other reasons such as content filtering
+	FinishReason string
+
+	// Model is the model version that served the response, e.g.
+	// "gpt-4o-2024-08-06", which may differ from the requested alias
+	Model string
+
+	// SystemFingerprint identifies the backend configuration that served the
+	// response, as reported by OpenAI and compatible APIs
+	SystemFingerprint string
+}
+
+// streamMetadata accumulates the metadata spread over the events of a
+// stream.
+type streamMetadata struct {
+	usage        Usage
+	hasUsage     bool
+	finishReason string
+	model        string
+	fingerprint  string
+}
+
+// update extracts the usage, finish reason and model of a stream event in
+// the OpenAI, Anthropic, Groq, Cohere or Ollama format. Events that are not
+// JSON objects are ignored.
+func (m *streamMetadata) update(data []byte) {
+	if m.model != "" && !bytes.Contains(data, []byte("usage")) && !bytes.Contains(data, []byte("eval_count")) &&
+		!bytes.Contains(data, []byte(`_reason":"`)) && !bytes.Contains(data, []byte(`_reason": "`)) {
+		return // Most events carry only text
+	}
+	var event map[string]interface{}
+	if err := json.Unmarshal(data, &event); err != nil {
+		return
+	}
+
+	// Anthropic sends the model and input usage in message_start, and the
+	// stop reason and output usage in message_delta
+	if message, ok := event["message"].(map[string]interface{}); ok {
+		m.updateFrom(message)
+	}
+	if delta, ok := event["delta"].(map[string]interface{}); ok {
+		if reason, ok := delta["stop_reason"].(string); ok && reason != "" {
+			m.finishReason = reason
+		}
+	}
+	// Groq reports the usage in an x_groq object of the last chunk
+	if groq, ok := event["x_groq"].(map[string]interface{}); ok {
+		m.updateFrom(groq)
+	}
+	m.updateFrom(event)
+
+	if choices, ok := event["choices"].([]interface{}); ok && len(choices) > 0 {
+		if choice, ok := choices[0].(map[string]interface{}); ok {
+			if reason, ok := choice["finish_reason"].(string); ok && reason != "" {
+				m.finishReason = reason
+			}
+		}
+	}
+}
+
+// updateFrom extracts the model, usage and finish reason found at the top
+// level of an object.
+func (m *streamMetadata) updateFrom(object map[string]interface{}) {
+	if model, ok := object["model"].(string); ok && model != "" {
+		m.model = model
+	}
+	if fingerprint, ok := object["system_fingerprint"].(string); ok && fingerprint != "" {
+		m.fingerprint = fingerprint
+	}
+	for _, key := range []string{"finish_reason", "done_reason"} {
+		if reason, ok := object[key].(string); ok && reason != "" {
+			m.finishReason = reason
+		}
+	}
+	if u, ok := parseUsage(object); ok {
+		m.hasUsage = true
+		// Later events report cumulative counts; keep the latest of each
+		for _, f := range []struct{ dst, src *int }{
+			{&m.usage.InputTokens, &u.InputTokens},
+			{&m.usage.OutputTokens, &u.OutputTokens},
+			{&m.usage.CacheReadTokens, &u.CacheReadTokens},
+			{&m.usage.CacheCreationTokens, &u.CacheCreationTokens},
+		} {
+			if *f.src > 0 {
+				*f.dst = *f.src
+			}
+		}
+	}
+}
+
+// done returns the terminal token of the stream.
+func (m *streamMetadata) done(index int) *StreamToken {
+	done := &StreamDone{FinishReason: normalizeFinishReason(m.finishReason), Model: m.model, SystemFingerprint: m.fingerprint}
+	if m.hasUsage {
+		usage := m.usage
+		usage.TotalTokens = usage.InputTokens + usage.OutputTokens
+		done.Usage = &usage
+	}
+	return &StreamToken{Type: TokenTypeDone, Index: index, Done: done}
+}
+
+// normalizeFinishReason maps the providers' finish reasons to the common
+// ones, leaving the others unchanged.
+func normalizeFinishReason(reason string) string {
+	switch reason {
+	case "stop", "end_turn", "stop_sequence", "STOP", "COMPLETE", "complete":
+		return FinishReasonStop
+	case "length", "max_tokens", "MAX_TOKENS":
+		return FinishReasonLength
+	case "tool_calls", "tool_use", "function_call", "TOOL_CALL":
+		return FinishReasonToolCalls
+	}
+	return reason
+}
+
+// WithFinishReason records why the response of a Generate call finished:
+// FinishReasonStop, FinishReasonLength, FinishReasonToolCalls, the provider's
+// own value for other reasons, or an empty string when the provider does not
+// report it, as for cached responses.
+//
+// Example usage:
+//
+//	var reason string
+//	response, err := l.Generate(ctx, prompt, llm.WithFinishReason(&reason))
+//	if reason == llm.FinishReasonLength {
+//	    // The response was truncated
+//	}
+func WithFinishReason(reason *string) GenerateOption {
+	return func(c *GenerateConfig) {
+		c.FinishReason = reason
+	}
+}
+
+// parseFinishReason extracts the finish reason of a decoded response in the
+// OpenAI, Anthropic, Gemini, Cohere or Ollama format.
+func parseFinishReason(response map[string]interface{}) string {
+	for _, key := range []string{"choices", "candidates"} {
+		if list, ok := response[key].([]interface{}); ok && len(list) > 0 {
+			if first, ok := list[0].(map[string]interface{}); ok {
+				for _, field := range []string{"finish_reason", "finishReason"} {
+					if reason, ok := first[field].(string); ok && reason != "" {
+						return normalizeFinishReason(reason)
+					}
+				}
+			}
+		}
+	}
+	for _, key := range []string{"stop_reason", "finish_reason", "done_reason"} {
+		if reason, ok := response[key].(string); ok && reason != "" {
+			return normalizeFinishReason(reason)
+		}
+	}
+	return ""
+}
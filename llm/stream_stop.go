@@ -0,0 +1,199 @@
+package llm
+
+import (
+	"context"
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// StopCondition ends a stream on the client side as soon as the text
+// received matches it, for providers whose server-side stop sequences are
+// limited or missing.
+type StopCondition struct {
+	// match returns the index at which the text ends, or -1 without a match
+	match func(text string) int
+
+	// holdback is the number of trailing bytes withheld from the caller as
+	// they may start a match completed by the next tokens
+	holdback int
+}
+
+// StopOnSequence stops the stream before the first occurrence of any of the
+// sequences, which are not returned. Text that may begin a sequence is held
+// back until the next tokens tell whether it does.
+func StopOnSequence(sequences ...string) StopCondition {
+	holdback := 0
+	for _, s := range sequences {
+		if len(s)-1 > holdback {
+			holdback = len(s) - 1
+		}
+	}
+	return StopCondition{
+		match: func(text string) int {
+			end := -1
+			for _, s := range sequences {
+				if s == "" {
+					continue
+				}
+				if i := strings.Index(text, s); i >= 0 && (end < 0 || i < end) {
+					end = i
+				}
+			}
+			return end
+		},
+		holdback: holdback,
+	}
+}
+
+// StopOnRegexp stops the stream before the first match of the expression,
+// which is not returned. The match is tested as text arrives, so an
+// expression matching a growing text, such as `\n\n+`, stops at its shortest
+// match.
+func StopOnRegexp(re *regexp.Regexp) StopCondition {
+	return StopCondition{
+		match: func(text string) int {
+			if loc := re.FindStringIndex(text); loc != nil {
+				return loc[0]
+			}
+			return -1
+		},
+	}
+}
+
+// StopWhen stops the stream once fn returns true for the text received so
+// far, which is returned in full.
+func StopWhen(fn func(text string) bool) StopCondition {
+	return StopCondition{
+		match: func(text string) int {
+			if fn(text) {
+				return len(text)
+			}
+			return -1
+		},
+	}
+}
+
+// WithStopConditions ends the stream as soon as any of the conditions is
+// met, closing the connection so the provider stops generating.
+//
+// Example usage:
+//
+//	stream, err := l.Stream(ctx, prompt, llm.WithStopConditions(
+//	    llm.StopOnSequence("\nUser:"),
+//	    llm.StopOnRegexp(regexp.MustCompile(`(?m)^END$`)),
+//	))
+func WithStopConditions(conditions ...StopCondition) StreamOption {
+	return func(c *StreamConfig) {
+		c.StopConditions = append(c.StopConditions, conditions...)
+	}
+}
+
+// StopStream wraps a stream so it ends as soon as any of the conditions is
+// met. The wrapped stream is closed then, and Next returns io.EOF.
+func StopStream(stream TokenStream, conditions ...StopCondition) TokenStream {
+	s := &stoppingStream{TokenStream: stream, conditions: conditions}
+	for _, c := range conditions {
+		if c.holdback > s.holdback {
+			s.holdback = c.holdback
+		}
+	}
+	return s
+}
+
+// stoppingStream applies stop conditions to the text of a stream.
+type stoppingStream struct {
+	TokenStream
+	conditions []StopCondition
+	holdback   int
+
+	text    strings.Builder
+	emitted int // Bytes of text returned to the caller
+	index   int
+	stopped bool
+	pending error        // Error of the wrapped stream, returned once held text is flushed
+	last    *StreamToken // Last text token received, whose fields the returned tokens copy
+	done    *StreamToken // Terminal token, returned after the held text
+}
+
+func (s *stoppingStream) Next(ctx context.Context) (*StreamToken, error) {
+	for {
+		if s.stopped {
+			if done := s.done; done != nil {
+				s.done = nil
+				return done, nil
+			}
+			return nil, io.EOF
+		}
+		if s.pending != nil {
+			// Flush the text held back before ending the stream
+			s.stopped = s.pending == io.EOF
+			if token := s.flush(s.text.Len()); token != nil {
+				return token, nil
+			}
+			if s.stopped {
+				continue
+			}
+			return nil, s.pending
+		}
+
+		token, err := s.TokenStream.Next(ctx)
+		if err != nil {
+			s.pending = err
+			continue
+		}
+		if token.Type == TokenTypeToolCall {
+			return token, nil
+		}
+		if token.Type == TokenTypeDone {
+			s.done = token
+			continue
+		}
+		s.text.WriteString(token.Text)
+		s.last = token
+
+		text := s.text.String()
+		end := -1
+		for _, c := range s.conditions {
+			if i := c.match(text); i >= 0 && (end < 0 || i < end) {
+				end = i
+			}
+		}
+		if end >= 0 {
+			s.stopped = true
+			s.TokenStream.Close()
+			if flushed := s.flush(end); flushed != nil {
+				return flushed, nil
+			}
+			return nil, io.EOF
+		}
+		if flushed := s.flush(s.safeEnd(text)); flushed != nil {
+			return flushed, nil
+		}
+	}
+}
+
+// safeEnd returns the end of the text that can be returned without giving
+// away the start of a stop sequence, on a UTF-8 character boundary.
+func (s *stoppingStream) safeEnd(text string) int {
+	end := len(text) - s.holdback
+	for end > s.emitted && end < len(text) && !utf8.RuneStart(text[end]) {
+		end--
+	}
+	return end
+}
+
+// flush returns a token with the text received up to end and not yet
+// returned, or nil if there is none.
+func (s *stoppingStream) flush(end int) *StreamToken {
+	if end <= s.emitted {
+		return nil
+	}
+	token := *s.last
+	token.Text = s.text.String()[s.emitted:end]
+	token.Index = s.index
+	s.emitted = end
+	s.index++
+	return &token
+}
@@ -0,0 +1,596 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm/providers"
+	"github.com/teilomillet/gollm/types"
+	"github.com/teilomillet/gollm/utils"
+)
+
+// localOpenAIProvider sends OpenAI requests to a test server.
+type localOpenAIProvider struct {
+	*providers.OpenAIProvider
+	url string
+}
+
+func (p *localOpenAIProvider) Endpoint() string { return p.url }
+
+func TestStreamToolCalls(t *testing.T) {
+	chunks := []string{
+		`{"choices":[{"delta":{"role":"assistant","content":"Checking"}}]}`,
+		`{"choices":[{"delta":{"tool_calls":[{"index":0,"id":"call_1","function":{"name":"get_weather","arguments":""}},{"index":1,"id":"call_2","function":{"name":"get_time","arguments":"{}"}}]}}]}`,
+		`{"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"city\":"}}]}}]}`,
+		`{"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"Paris\"}"}}]}}]}`,
+		`{"choices":[{"delta":{},"finish_reason":"tool_calls"}]}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.Contains(t, string(body), `"get_weather"`, "tools should be sent with the stream request")
+		for _, chunk := range chunks {
+			fmt.Fprintf(w, "data: %s\n\n", chunk)
+		}
+	}))
+	defer server.Close()
+
+	l := &LLMImpl{
+		Provider: &localOpenAIProvider{OpenAIProvider: providers.NewOpenAIProvider("fake-key", "gpt-4o", nil).(*providers.OpenAIProvider), url: server.URL},
+		Options:  make(map[string]interface{}),
+		client:   server.Client(),
+		logger:   utils.NewLogger(utils.LogLevelOff),
+	}
+	prompt := NewPrompt("Weather in Paris?", WithTools([]utils.Tool{{Type: "function", Function: utils.Function{Name: "get_weather"}}}))
+	stream, err := l.Stream(context.Background(), prompt)
+	require.NoError(t, err)
+	defer stream.Close()
+
+	var text string
+	var calls ToolCallAccumulator
+	var names []string
+	for {
+		token, err := stream.Next(context.Background())
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		if token.Type == TokenTypeToolCall {
+			call := calls.Add(*token.ToolCall)
+			if token.ToolCall.Name != "" {
+				names = append(names, call.Function.Name)
+			}
+			continue
+		}
+		text += token.Text
+	}
+
+	assert.Equal(t, "Checking", text)
+	assert.Equal(t, []string{"get_weather", "get_time"}, names)
+	result := calls.Calls()
+	require.Len(t, result, 2)
+	assert.Equal(t, "call_1", result[0].ID)
+	assert.JSONEq(t, `{"city":"Paris"}`, string(result[0].Function.Arguments))
+	assert.Equal(t, "{}", string(result[1].Function.Arguments))
+}
+
+// sliceStream is a TokenStream over fixed texts, ending with a terminal
+// token if done is set.
+type sliceStream struct {
+	texts  []string
+	done   *StreamDone
+	closed bool
+}
+
+func (s *sliceStream) Next(ctx context.Context) (*StreamToken, error) {
+	if len(s.texts) == 0 && s.done != nil {
+		token := &StreamToken{Type: TokenTypeDone, Done: s.done}
+		s.done = nil
+		return token, nil
+	}
+	if len(s.texts) == 0 {
+		return nil, io.EOF
+	}
+	token := &StreamToken{Text: s.texts[0]}
+	s.texts = s.texts[1:]
+	return token, nil
+}
+
+func (s *sliceStream) Close() error {
+	s.closed = true
+	return nil
+}
+
+func TestStreamAdapters(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("ConsumeStream", func(t *testing.T) {
+		stream := &sliceStream{texts: []string{"a", "b", "c"}}
+		var seen []string
+		err := ConsumeStream(ctx, stream, func(token *StreamToken) error {
+			seen = append(seen, token.Text)
+			if token.Text == "b" {
+				return assert.AnError
+			}
+			return nil
+		})
+		assert.ErrorIs(t, err, assert.AnError)
+		assert.Equal(t, []string{"a", "b"}, seen)
+		assert.True(t, stream.closed)
+	})
+
+	t.Run("StreamToChannel", func(t *testing.T) {
+		tokens, errc := StreamToChannel(ctx, &sliceStream{texts: []string{"Hello", ", ", "world"}}, 0)
+		var text string
+		for token := range tokens {
+			text += token.Text
+		}
+		assert.NoError(t, <-errc)
+		assert.Equal(t, "Hello, world", text)
+	})
+
+	t.Run("TeeStream", func(t *testing.T) {
+		source := &sliceStream{texts: []string{"one ", "two ", "three"}}
+		streams := TeeStream(ctx, source, 2)
+		require.Len(t, streams, 2)
+
+		// Reading the consumers one after the other must not deadlock
+		for _, stream := range streams {
+			var text string
+			require.NoError(t, ConsumeStream(ctx, stream, func(token *StreamToken) error {
+				text += token.Text
+				return nil
+			}))
+			assert.Equal(t, "one two three", text)
+		}
+	})
+}
+
+func TestCollectStream(t *testing.T) {
+	t.Run("Complete", func(t *testing.T) {
+		stream := &sliceStream{texts: []string{"Hello", ", ", "world"}}
+		result, err := CollectStream(context.Background(), stream)
+		require.NoError(t, err)
+		assert.Equal(t, "Hello, world", result.Text)
+		assert.Equal(t, FinishReasonStop, result.FinishReason)
+	})
+
+	t.Run("Deadline", func(t *testing.T) {
+		disconnected := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, "data: %s\n\n", `{"choices":[{"delta":{"content":"Once upon"}}]}`)
+			fmt.Fprintf(w, "data: %s\n\n", `{"choices":[{"delta":{"content":" a time"}}]}`)
+			w.(http.Flusher).Flush()
+			// Stall until the client goes away
+			<-r.Context().Done()
+			close(disconnected)
+		}))
+		defer server.Close()
+
+		l := &LLMImpl{
+			Provider:   &localOpenAIProvider{OpenAIProvider: providers.NewOpenAIProvider("fake-key", "gpt-4o", nil).(*providers.OpenAIProvider), url: server.URL},
+			Options:    make(map[string]interface{}),
+			client:     server.Client(),
+			logger:     utils.NewLogger(utils.LogLevelOff),
+			MaxRetries: 2,
+			RetryDelay: time.Second,
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		stream, err := l.Stream(ctx, NewPrompt("Tell me a story"))
+		require.NoError(t, err)
+
+		start := time.Now()
+		result, err := CollectStream(ctx, stream, WithDeadlineMargin(4700*time.Millisecond), WithCancelOnDeadline())
+		require.NoError(t, err)
+		assert.Less(t, time.Since(start), 4*time.Second, "returns before the deadline")
+		assert.Equal(t, "Once upon a time", result.Text)
+		assert.Equal(t, FinishReasonDeadline, result.FinishReason)
+
+		select {
+		case <-disconnected:
+		case <-time.After(3 * time.Second):
+			t.Fatal("the provider connection was not cancelled")
+		}
+	})
+
+	t.Run("Done", func(t *testing.T) {
+		usage := &Usage{InputTokens: 3, OutputTokens: 2, TotalTokens: 5}
+		stream := &sliceStream{texts: []string{"Hello", "!"}, done: &StreamDone{FinishReason: FinishReasonLength, Usage: usage}}
+		result, err := CollectStream(context.Background(), stream)
+		require.NoError(t, err)
+		assert.Equal(t, "Hello!", result.Text)
+		assert.Equal(t, FinishReasonLength, result.FinishReason, "the finish reason of the terminal token is kept")
+		assert.Equal(t, usage, result.Usage)
+	})
+
+	t.Run("CancelWhileReading", func(t *testing.T) {
+		// Run with -race: closing the stream during a read is a data race
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		stream := &unsafeStream{texts: []string{"partial"}, closed: make(chan struct{})}
+		result, err := CollectStream(ctx, stream, WithDeadlineMargin(950*time.Millisecond), WithCancelOnDeadline())
+		require.NoError(t, err)
+		assert.Equal(t, "partial", result.Text)
+		assert.Equal(t, FinishReasonDeadline, result.FinishReason)
+		select {
+		case <-stream.closed:
+		case <-time.After(time.Second):
+			t.Fatal("the stream was not closed after the cancelled read")
+		}
+		assert.False(t, stream.closedWhileReading)
+	})
+
+	t.Run("Clock", func(t *testing.T) {
+		clock := utils.NewFakeClock(time.Now())
+		ctx, cancel := context.WithDeadline(context.Background(), clock.Now().Add(time.Hour))
+		defer cancel()
+		done := make(chan *StreamResult, 1)
+		go func() {
+			result, err := CollectStream(ctx, &blockingStream{texts: []string{"partial"}}, WithDeadlineMargin(10*time.Minute), WithCollectClock(clock))
+			assert.NoError(t, err)
+			done <- result
+		}()
+		require.Eventually(t, func() bool { return clock.Waiters() == 1 }, time.Second, time.Millisecond)
+		clock.Advance(50 * time.Minute)
+		result := <-done
+		assert.Equal(t, "partial", result.Text)
+		assert.Equal(t, FinishReasonDeadline, result.FinishReason, "the margin is timed by the clock")
+	})
+
+	t.Run("ContextDeadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		stream := &blockingStream{texts: []string{"partial"}}
+		result, err := CollectStream(ctx, stream)
+		require.NoError(t, err)
+		assert.Equal(t, "partial", result.Text)
+		assert.Equal(t, FinishReasonDeadline, result.FinishReason)
+	})
+}
+
+// unsafeStream is a blockingStream that doesn't support a Close concurrent
+// with Next, and records whether one happened.
+type unsafeStream struct {
+	texts              []string
+	reading            bool
+	closedWhileReading bool
+	closed             chan struct{}
+}
+
+func (s *unsafeStream) Next(ctx context.Context) (*StreamToken, error) {
+	if len(s.texts) > 0 {
+		token := &StreamToken{Text: s.texts[0]}
+		s.texts = s.texts[1:]
+		return token, nil
+	}
+	s.reading = true
+	defer func() { s.reading = false }()
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (s *unsafeStream) Close() error {
+	s.closedWhileReading = s.reading
+	close(s.closed)
+	return nil
+}
+
+// blockingStream returns its texts, then blocks until the context is done.
+type blockingStream struct {
+	texts []string
+}
+
+func (s *blockingStream) Next(ctx context.Context) (*StreamToken, error) {
+	if len(s.texts) > 0 {
+		token := &StreamToken{Text: s.texts[0]}
+		s.texts = s.texts[1:]
+		return token, nil
+	}
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (s *blockingStream) Close() error {
+	return nil
+}
+
+func TestStopStream(t *testing.T) {
+	ctx := context.Background()
+	collect := func(stream TokenStream) []string {
+		var texts []string
+		require.NoError(t, ConsumeStream(ctx, stream, func(token *StreamToken) error {
+			texts = append(texts, token.Text)
+			return nil
+		}))
+		return texts
+	}
+
+	t.Run("SequenceAcrossTokens", func(t *testing.T) {
+		source := &sliceStream{texts: []string{"Hello", " wor", "ld\nUs", "er: next", " turn"}}
+		texts := collect(StopStream(source, StopOnSequence("\nUser:")))
+		assert.Equal(t, "Hello world", strings.Join(texts, ""))
+		assert.True(t, source.closed)
+		assert.Len(t, source.texts, 1, "the stream stops once the sequence is complete")
+	})
+
+	t.Run("HeldTextFlushedAtEnd", func(t *testing.T) {
+		texts := collect(StopStream(&sliceStream{texts: []string{"no stop", " here\n"}}, StopOnSequence("\nUser:")))
+		assert.Equal(t, "no stop here\n", strings.Join(texts, ""))
+	})
+
+	t.Run("RegexpAndCallback", func(t *testing.T) {
+		texts := collect(StopStream(&sliceStream{texts: []string{"a1", "b2", "c3"}}, StopOnRegexp(regexp.MustCompile(`b\d`))))
+		assert.Equal(t, []string{"a1"}, texts)
+
+		texts = collect(StopStream(&sliceStream{texts: []string{"one", "two", "three"}}, StopWhen(func(text string) bool {
+			return strings.Contains(text, "two")
+		})))
+		assert.Equal(t, []string{"one", "two"}, texts)
+	})
+}
+
+func TestStreamDone(t *testing.T) {
+	t.Run("OpenAI", func(t *testing.T) {
+		chunks := []string{
+			`{"model":"gpt-4o-2024-08-06","choices":[{"delta":{"role":"assistant","content":"Hi"},"finish_reason":null}]}`,
+			`{"model":"gpt-4o-2024-08-06","choices":[{"delta":{"content":" there"},"finish_reason":null}]}`,
+			`{"model":"gpt-4o-2024-08-06","choices":[{"delta":{},"finish_reason":"length"}]}`,
+			`{"model":"gpt-4o-2024-08-06","choices":[],"usage":{"prompt_tokens":5,"completion_tokens":2,"total_tokens":7}}`,
+			`[DONE]`,
+		}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			assert.Contains(t, string(body), `"include_usage":true`)
+			for _, chunk := range chunks {
+				fmt.Fprintf(w, "data: %s\n\n", chunk)
+			}
+		}))
+		defer server.Close()
+		l := &LLMImpl{
+			Provider: &localOpenAIProvider{OpenAIProvider: providers.NewOpenAIProvider("fake-key", "gpt-4o", nil).(*providers.OpenAIProvider), url: server.URL},
+			Options:  make(map[string]interface{}),
+			client:   server.Client(),
+			logger:   utils.NewLogger(utils.LogLevelOff),
+		}
+
+		stream, err := l.Stream(context.Background(), NewPrompt("Hello"))
+		require.NoError(t, err)
+		var tokens []*StreamToken
+		require.NoError(t, ConsumeStream(context.Background(), stream, func(token *StreamToken) error {
+			tokens = append(tokens, token)
+			return nil
+		}))
+		require.Len(t, tokens, 3)
+		assert.Equal(t, "Hi there", tokens[0].Text+tokens[1].Text)
+		done := tokens[2]
+		assert.Equal(t, TokenTypeDone, done.Type)
+		assert.Empty(t, done.Text)
+		require.NotNil(t, done.Done)
+		assert.Equal(t, &Usage{InputTokens: 5, OutputTokens: 2, TotalTokens: 7}, done.Done.Usage)
+		assert.Equal(t, FinishReasonLength, done.Done.FinishReason)
+		assert.Equal(t, "gpt-4o-2024-08-06", done.Done.Model)
+
+		stream, err = l.Stream(context.Background(), NewPrompt("Hello"), WithStopConditions(StopOnSequence("never")))
+		require.NoError(t, err)
+		result, err := CollectStream(context.Background(), stream)
+		require.NoError(t, err)
+		assert.Equal(t, "Hi there", result.Text)
+		assert.Equal(t, FinishReasonLength, result.FinishReason)
+		assert.Equal(t, 7, result.Usage.TotalTokens, "the terminal token passes through stop conditions")
+
+		l.SetOption("structured_messages", []types.MemoryMessage{{Role: "user", Content: "Hello"}})
+		stream, err = l.Stream(context.Background(), NewPrompt(""))
+		require.NoError(t, err)
+		result, err = CollectStream(context.Background(), stream)
+		require.NoError(t, err)
+		assert.Equal(t, "Hi there", result.Text)
+		assert.Equal(t, 7, result.Usage.TotalTokens, "streamed conversations report the usage")
+	})
+
+	t.Run("Anthropic", func(t *testing.T) {
+		var metadata streamMetadata
+		for _, event := range []string{
+			`{"type":"message_start","message":{"model":"claude-3-5-sonnet-20241022","stop_reason":null,"usage":{"input_tokens":10,"output_tokens":1,"cache_read_input_tokens":4}}}`,
+			`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hello"}}`,
+			`{"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":15}}`,
+			`{"type":"message_stop"}`,
+		} {
+			metadata.update([]byte(event))
+		}
+		done := metadata.done(3).Done
+		assert.Equal(t, &Usage{InputTokens: 10, OutputTokens: 15, TotalTokens: 25, CacheReadTokens: 4}, done.Usage)
+		assert.Equal(t, FinishReasonStop, done.FinishReason)
+		assert.Equal(t, "claude-3-5-sonnet-20241022", done.Model)
+	})
+
+	t.Run("Generate", func(t *testing.T) {
+		for body, expected := range map[string]string{
+			`{"choices":[{"message":{"content":"Hi"},"finish_reason":"length"}]}`:         FinishReasonLength,
+			`{"content":[{"type":"text","text":"Hi"}],"stop_reason":"tool_use"}`:          FinishReasonToolCalls,
+			`{"candidates":[{"content":{},"finishReason":"STOP"}]}`:                       FinishReasonStop,
+			`{"response":"Hi","done_reason":"stop"}`:                                      FinishReasonStop,
+			`{"choices":[{"message":{"content":"Hi"},"finish_reason":"content_filter"}]}`: "content_filter",
+			`{"content":"Hi"}`: "",
+		} {
+			var response map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(body), &response))
+			assert.Equal(t, expected, parseFinishReason(response), body)
+		}
+	})
+}
+
+func TestSSEDecoder(t *testing.T) {
+	stream := ": keep-alive\n\n" +
+		"event: message_start\ndata: {\"a\":1}\n\n" +
+		"data: first\ndata: second\n\n" +
+		"{\"response\":\"ndjson\"}\n" +
+		"data: truncated"
+	expected := []Event{
+		{}, // The comment ends an empty event
+		{Type: "message_start", Data: []byte(`{"a":1}`)},
+		{Data: []byte("first\nsecond")},
+		{Data: []byte(`{"response":"ndjson"}`)},
+	}
+
+	t.Run("Exported", func(t *testing.T) {
+		decoder := NewSSEDecoder(strings.NewReader(stream))
+		var events []Event
+		for decoder.Next() {
+			events = append(events, decoder.Event()) // Kept without copying
+		}
+		require.NoError(t, decoder.Err())
+		assert.Equal(t, expected, events)
+	})
+
+	t.Run("Pooled", func(t *testing.T) {
+		decoder := newEventDecoder(strings.NewReader(stream))
+		var events []Event
+		for decoder.Next() {
+			event := decoder.Event()
+			events = append(events, Event{Type: event.Type, Data: append([]byte(nil), event.Data...)})
+		}
+		require.NoError(t, decoder.Err())
+		decoder.Release()
+		assert.False(t, decoder.Next(), "a released decoder is done")
+		assert.Equal(t, expected, events)
+	})
+}
+
+func BenchmarkSSEDecoder(b *testing.B) {
+	var sb strings.Builder
+	for i := 0; i < 100; i++ {
+		sb.WriteString(`data: {"model":"gpt-4o","choices":[{"delta":{"content":" token"},"finish_reason":null}]}` + "\n\n")
+	}
+	stream := sb.String()
+	reader := strings.NewReader(stream)
+	b.ReportAllocs()
+	b.SetBytes(int64(len(stream)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		reader.Reset(stream)
+		decoder := newEventDecoder(reader)
+		for decoder.Next() {
+		}
+		decoder.Release()
+	}
+}
+
+func TestStreamCancellation(t *testing.T) {
+	aborted := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"Hi\"},\"finish_reason\":null}]}\n\n")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done() // Stall until the client goes away
+		close(aborted)
+	}))
+	defer server.Close()
+	l := &LLMImpl{
+		Provider: &localOpenAIProvider{OpenAIProvider: providers.NewOpenAIProvider("fake-key", "gpt-4o", nil).(*providers.OpenAIProvider), url: server.URL},
+		Options:  make(map[string]interface{}),
+		client:   server.Client(),
+		logger:   utils.NewLogger(utils.LogLevelOff),
+	}
+
+	stream, err := l.Stream(context.Background(), NewPrompt("Hello"))
+	require.NoError(t, err)
+	defer stream.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	token, err := stream.Next(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "Hi", token.Text)
+
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start := time.Now()
+	_, err = stream.Next(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, time.Since(start), 5*time.Second, "the blocked read is aborted")
+	select {
+	case <-aborted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the request was not aborted")
+	}
+}
+
+// failingReader returns err from every read, counting them.
+type failingReader struct {
+	err   error
+	reads int
+}
+
+func (r *failingReader) Read([]byte) (int, error) {
+	r.reads++
+	return 0, r.err
+}
+
+func TestStreamInterrupted(t *testing.T) {
+	reset := errors.New("connection reset by peer")
+	failing := &failingReader{err: reset}
+	body := io.MultiReader(strings.NewReader("data: {\"choices\":[{\"delta\":{\"content\":\"Hi\"}}]}\n\n"), failing)
+	stream := newProviderStream(io.NopCloser(body), providers.NewOpenAIProvider("fake-key", "gpt-4o", nil), &StreamConfig{})
+	ctx := context.Background()
+
+	token, err := stream.Next(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "Hi", token.Text)
+
+	errs := make(chan error, 1)
+	go func() {
+		_, err := stream.Next(ctx)
+		errs <- err
+	}()
+	select {
+	case err := <-errs:
+		assert.ErrorIs(t, err, reset, "the read error is returned")
+	case <-time.After(time.Second):
+		t.Fatal("the failed read was retried")
+	}
+	_, err = stream.Next(ctx)
+	assert.ErrorIs(t, err, reset)
+	assert.Equal(t, 1, failing.reads, "the failed body is not read again")
+}
+
+func TestStreamImages(t *testing.T) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if strings.Contains(string(body), `"stream":true`) {
+			fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"A cat\"}}]}\n\ndata: [DONE]\n\n")
+			return
+		}
+		fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"{\"animal\":\"cat\"}"}}]}`)
+	}))
+	defer server.Close()
+	l := &LLMImpl{
+		Provider: &localOpenAIProvider{OpenAIProvider: providers.NewOpenAIProvider("fake-key", "gpt-4o", nil).(*providers.OpenAIProvider), url: server.URL},
+		Options:  make(map[string]interface{}),
+		client:   server.Client(),
+		logger:   utils.NewLogger(utils.LogLevelOff),
+	}
+	prompt := NewPrompt("What is in this picture?", WithImageBase64("aGVsbG8=", "image/png"))
+
+	stream, err := l.Stream(context.Background(), prompt)
+	require.NoError(t, err)
+	result, err := CollectStream(context.Background(), stream)
+	require.NoError(t, err)
+	assert.Equal(t, "A cat", result.Text)
+	require.Len(t, bodies, 1)
+	assert.Contains(t, bodies[0], `"image_url":{"url":"data:image/png;base64,aGVsbG8="}`, "the image is streamed with the prompt")
+
+	schema := map[string]interface{}{"type": "object", "properties": map[string]interface{}{"animal": map[string]interface{}{"type": "string"}}}
+	_, err = l.GenerateWithSchema(context.Background(), prompt, schema)
+	require.NoError(t, err)
+	require.Len(t, bodies, 2)
+	assert.Contains(t, bodies[1], `"image_url":{"url":"data:image/png;base64,aGVsbG8="}`, "the image is sent with a schema")
+}
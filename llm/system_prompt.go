@@ -0,0 +1,188 @@
+package llm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Names of the standard system prompt layers. They are composed in the order
+// persona, task instructions, other layers, safety preamble.
+const (
+	SystemLayerPersona = "persona" // Who the model is and how it speaks
+	SystemLayerTask    = "task"    // Instructions for the task at hand
+	SystemLayerSafety  = "safety"  // Rules the model must always follow
+)
+
+// SystemLayer is a named part of a layered system prompt.
+type SystemLayer struct {
+	Name     string `json:"name" jsonschema:"description=Name of the layer, e.g. persona, task or safety"`
+	Content  string `json:"content" jsonschema:"description=Text of the layer"`
+	Required bool   `json:"required,omitempty" jsonschema:"description=Whether the layer is kept when the system prompt exceeds its token budget"`
+}
+
+// SystemPlacement sets where the system prompt is sent.
+type SystemPlacement string
+
+const (
+	// SystemPlacementProvider sends the system prompt where the provider
+	// expects it: a system or developer message for OpenAI-compatible APIs,
+	// the top-level system field for Anthropic and Ollama.
+	SystemPlacementProvider SystemPlacement = ""
+	// SystemPlacementUser sends the system prompt within the user message
+	// only, for models without a system role.
+	SystemPlacementUser SystemPlacement = "user"
+)
+
+// WithSystemLayer sets a layer of the system prompt, replacing the layer of
+// the same name, if any. Layers are composed after the prompt's system
+// prompt (see WithSystemPrompt).
+//
+// Example usage:
+//
+//	prompt := llm.NewPrompt("Where is my order?",
+//	    llm.WithPersona("You are Ava, the friendly assistant of Acme."),
+//	    llm.WithTaskInstructions("Answer questions about orders using the context."),
+//	    llm.WithSafetyPreamble("Never reveal other customers' data."),
+//	    llm.WithSystemTokenBudget(500),
+//	)
+func WithSystemLayer(name, content string) PromptOption {
+	return func(p *Prompt) {
+		p.setSystemLayer(SystemLayer{Name: name, Content: content})
+	}
+}
+
+// WithPersona sets the persona layer of the system prompt.
+func WithPersona(content string) PromptOption {
+	return WithSystemLayer(SystemLayerPersona, content)
+}
+
+// WithTaskInstructions sets the task layer of the system prompt.
+func WithTaskInstructions(content string) PromptOption {
+	return WithSystemLayer(SystemLayerTask, content)
+}
+
+// WithSafetyPreamble sets the safety layer of the system prompt. It is
+// required: it is never dropped to fit the token budget.
+func WithSafetyPreamble(content string) PromptOption {
+	return func(p *Prompt) {
+		p.setSystemLayer(SystemLayer{Name: SystemLayerSafety, Content: content, Required: true})
+	}
+}
+
+// WithSystemTokenBudget limits the estimated size of the composed system
+// prompt. Optional layers are dropped until it fits: other layers first, the
+// most recently added first, then the task instructions and the persona.
+func WithSystemTokenBudget(tokens int) PromptOption {
+	return func(p *Prompt) {
+		p.SystemTokenBudget = tokens
+	}
+}
+
+// WithSystemPlacement sets where the system prompt is sent.
+func WithSystemPlacement(placement SystemPlacement) PromptOption {
+	return func(p *Prompt) {
+		p.SystemPlacement = placement
+	}
+}
+
+// setSystemLayer replaces the layer of the same name or appends the layer.
+func (p *Prompt) setSystemLayer(layer SystemLayer) {
+	for i := range p.SystemLayers {
+		if p.SystemLayers[i].Name == layer.Name {
+			p.SystemLayers[i] = layer
+			return
+		}
+	}
+	p.SystemLayers = append(p.SystemLayers, layer)
+}
+
+// ComposeSystemPrompt returns the system prompt followed by the layers,
+// within the prompt's token budget.
+//
+// Returns:
+//   - The composed system prompt
+//   - ErrorTypeInvalidInput if the system prompt and the required layers exceed the budget
+func (p *Prompt) ComposeSystemPrompt() (string, error) {
+	return p.composeSystemPrompt(p.SystemTokenBudget)
+}
+
+// composeSystemPrompt composes the system prompt within budget tokens, if
+// positive.
+func (p *Prompt) composeSystemPrompt(budget int) (string, error) {
+	layers := make([]SystemLayer, 0, len(p.SystemLayers))
+	for _, layer := range p.SystemLayers {
+		if layer.Content != "" {
+			layers = append(layers, layer)
+		}
+	}
+	if budget > 0 {
+		for EstimateTokens(joinSystemPrompt(p.SystemPrompt, layers)) > budget {
+			drop := -1
+			for i := len(layers) - 1; i >= 0; i-- {
+				if !layers[i].Required && (drop == -1 || systemLayerRank(layers[i].Name) > systemLayerRank(layers[drop].Name)) {
+					drop = i
+				}
+			}
+			if drop == -1 {
+				return "", NewLLMError(ErrorTypeInvalidInput, fmt.Sprintf("system prompt exceeds its budget of %d tokens", budget), nil)
+			}
+			layers = append(layers[:drop], layers[drop+1:]...)
+		}
+	}
+	return joinSystemPrompt(p.SystemPrompt, layers), nil
+}
+
+// joinSystemPrompt joins the system prompt and the layers in the order of
+// systemLayerRank, separated by blank lines.
+func joinSystemPrompt(systemPrompt string, layers []SystemLayer) string {
+	parts := make([]string, 0, len(layers)+1)
+	if systemPrompt != "" {
+		parts = append(parts, systemPrompt)
+	}
+	for rank := 0; rank < 4; rank++ {
+		for _, layer := range layers {
+			if systemLayerRank(layer.Name) == rank {
+				parts = append(parts, layer.Content)
+			}
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// systemLayerRank returns the position of a layer in the system prompt.
+func systemLayerRank(name string) int {
+	switch name {
+	case SystemLayerPersona:
+		return 0
+	case SystemLayerTask:
+		return 1
+	case SystemLayerSafety:
+		return 3
+	default:
+		return 2
+	}
+}
+
+// EstimateTokens estimates the number of tokens of a text at four
+// characters per token, without a tokenizer. It is the estimate used for
+// token budgets and the usage of responses the provider didn't report.
+func EstimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// composeSystemLayers returns a copy of the prompt whose system prompt is
+// composed from its layers. Prompts without layers are returned as is.
+func composeSystemLayers(prompt *Prompt) (*Prompt, error) {
+	if len(prompt.SystemLayers) == 0 && prompt.SystemTokenBudget == 0 {
+		return prompt, nil
+	}
+	system, err := prompt.ComposeSystemPrompt()
+	if err != nil {
+		return nil, err
+	}
+	composed := *prompt
+	composed.SystemPrompt = system
+	composed.SystemLayers = nil
+	composed.SystemTokenBudget = 0
+	return &composed, nil
+}
@@ -0,0 +1,77 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm/providers"
+	"github.com/teilomillet/gollm/utils"
+)
+
+func TestComposeSystemPrompt(t *testing.T) {
+	prompt := NewPrompt("Where is my order?",
+		WithSafetyPreamble("Never reveal other customers' data."),
+		WithSystemLayer("tone", "Keep answers short."),
+		WithTaskInstructions("Answer questions about orders."),
+		WithPersona("You are Ava."),
+		WithPersona("You are Ava, the assistant of Acme."),
+	)
+	system, err := prompt.ComposeSystemPrompt()
+	require.NoError(t, err)
+	assert.Equal(t, "You are Ava, the assistant of Acme.\n\nAnswer questions about orders.\n\nKeep answers short.\n\nNever reveal other customers' data.", system,
+		"layers are composed in order, later layers replacing those of the same name")
+
+	prompt.SystemTokenBudget = 30
+	system, err = prompt.ComposeSystemPrompt()
+	require.NoError(t, err)
+	assert.Equal(t, "You are Ava, the assistant of Acme.\n\nAnswer questions about orders.\n\nNever reveal other customers' data.", system,
+		"other layers are dropped first")
+
+	prompt.SystemTokenBudget = 20
+	system, err = prompt.ComposeSystemPrompt()
+	require.NoError(t, err)
+	assert.Equal(t, "You are Ava, the assistant of Acme.\n\nNever reveal other customers' data.", system,
+		"then the task instructions")
+
+	prompt.SystemTokenBudget = 5
+	_, err = prompt.ComposeSystemPrompt()
+	var llmErr *LLMError
+	require.ErrorAs(t, err, &llmErr, "required layers are never dropped")
+	assert.Equal(t, ErrorTypeInvalidInput, llmErr.Type)
+}
+
+func TestSystemPlacement(t *testing.T) {
+	var sent []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		sent = append(sent, body)
+		w.Write([]byte(`{"choices":[{"message":{"content":"ok"}}]}`))
+	}))
+	defer server.Close()
+
+	l := &LLMImpl{
+		Provider: &localOpenAIProvider{OpenAIProvider: providers.NewOpenAIProvider("fake-key", "gpt-4o", nil).(*providers.OpenAIProvider), url: server.URL},
+		Options:  make(map[string]interface{}),
+		client:   server.Client(),
+		logger:   utils.NewLogger(utils.LogLevelOff),
+	}
+	ctx := context.Background()
+	_, err := l.Generate(ctx, NewPrompt("Hi", WithPersona("You are Ava."), WithTaskInstructions("Greet.")))
+	require.NoError(t, err)
+	messages := sent[0]["messages"].([]interface{})
+	require.Len(t, messages, 2)
+	assert.Equal(t, "You are Ava.\n\nGreet.", messages[0].(map[string]interface{})["content"])
+
+	_, err = l.Generate(ctx, NewPrompt("Hi", WithPersona("You are Ava."), WithSystemPlacement(SystemPlacementUser)))
+	require.NoError(t, err)
+	messages = sent[1]["messages"].([]interface{})
+	require.Len(t, messages, 1, "no system message is sent")
+	assert.True(t, strings.HasPrefix(messages[0].(map[string]interface{})["content"].(string), "System: You are Ava."))
+}
@@ -0,0 +1,77 @@
+package llm
+
+// Usage reports the tokens consumed by a request, as returned by the provider.
+// Fields the provider does not report are left at zero.
+type Usage struct {
+	InputTokens         int `json:"input_tokens"`          // Tokens in the prompt
+	OutputTokens        int `json:"output_tokens"`         // Tokens in the generated response
+	TotalTokens         int `json:"total_tokens"`          // Input plus output tokens
+	CacheReadTokens     int `json:"cache_read_tokens"`     // Prompt tokens served from the provider's cache
+	CacheCreationTokens int `json:"cache_creation_tokens"` // Prompt tokens written to the provider's cache
+}
+
+// WithUsage records the token usage of the request into u once it succeeds.
+// The usage of failed attempts is not included.
+//
+// Example:
+//
+//	var usage llm.Usage
+//	response, err := l.Generate(ctx, prompt, llm.WithUsage(&usage))
+//	fmt.Println(usage.InputTokens, usage.OutputTokens)
+func WithUsage(u *Usage) GenerateOption {
+	return func(c *GenerateConfig) {
+		c.Usage = u
+	}
+}
+
+// parseUsage extracts token usage from a decoded provider response.
+// It understands the OpenAI, Anthropic, Cohere and Ollama response formats.
+func parseUsage(response map[string]interface{}) (Usage, bool) {
+	var u Usage
+	found := false
+	set := func(dst *int, m map[string]interface{}, key string) {
+		if v, ok := m[key].(float64); ok {
+			*dst = int(v)
+			found = true
+		}
+	}
+
+	if usage, ok := response["usage"].(map[string]interface{}); ok {
+		// OpenAI-compatible providers
+		set(&u.InputTokens, usage, "prompt_tokens")
+		set(&u.OutputTokens, usage, "completion_tokens")
+		set(&u.TotalTokens, usage, "total_tokens")
+		if details, ok := usage["prompt_tokens_details"].(map[string]interface{}); ok {
+			set(&u.CacheReadTokens, details, "cached_tokens")
+		}
+		// Anthropic
+		set(&u.InputTokens, usage, "input_tokens")
+		set(&u.OutputTokens, usage, "output_tokens")
+		set(&u.CacheReadTokens, usage, "cache_read_input_tokens")
+		set(&u.CacheCreationTokens, usage, "cache_creation_input_tokens")
+		// Cohere
+		if billed, ok := usage["billed_units"].(map[string]interface{}); ok {
+			set(&u.InputTokens, billed, "input_tokens")
+			set(&u.OutputTokens, billed, "output_tokens")
+		}
+	}
+	// Ollama
+	set(&u.InputTokens, response, "prompt_eval_count")
+	set(&u.OutputTokens, response, "eval_count")
+
+	if found && u.TotalTokens == 0 {
+		u.TotalTokens = u.InputTokens + u.OutputTokens
+	}
+	return u, found
+}
+
+// add returns the sum of two usages.
+func (u Usage) add(o Usage) Usage {
+	return Usage{
+		InputTokens:         u.InputTokens + o.InputTokens,
+		OutputTokens:        u.OutputTokens + o.OutputTokens,
+		TotalTokens:         u.TotalTokens + o.TotalTokens,
+		CacheReadTokens:     u.CacheReadTokens + o.CacheReadTokens,
+		CacheCreationTokens: u.CacheCreationTokens + o.CacheCreationTokens,
+	}
+}
@@ -0,0 +1,492 @@
+// Package llmproxy serves gollm LLMs over an OpenAI-compatible HTTP API, so
+// that clients in any language can use gollm's providers, routing, caching and
+// fallback logic through an OpenAI SDK.
+package llmproxy
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/teilomillet/gollm"
+	"github.com/teilomillet/gollm/llm"
+)
+
+// Server is an http.Handler that exposes /v1/chat/completions, with SSE
+// streaming, and /v1/models.
+type Server struct {
+	fallback gollm.LLM
+	models   map[string]gollm.LLM
+	apiKeys  []string
+	maxBody  int64
+	mux      *http.ServeMux
+}
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithModel serves l for requests whose "model" field is name. Requests for
+// models that are not registered use the default LLM given to New.
+func WithModel(name string, l gollm.LLM) Option {
+	return func(s *Server) {
+		s.models[name] = l
+	}
+}
+
+// WithAPIKeys requires requests to send one of the keys as a bearer token.
+// Without keys, the server accepts every request.
+func WithAPIKeys(keys ...string) Option {
+	return func(s *Server) {
+		s.apiKeys = append(s.apiKeys, keys...)
+	}
+}
+
+// WithMaxBodySize limits the size of request bodies (default 4 MiB).
+func WithMaxBodySize(n int64) Option {
+	return func(s *Server) {
+		s.maxBody = n
+	}
+}
+
+// New creates a proxy server. The default LLM may be nil when every model is
+// registered with WithModel.
+//
+// Example usage:
+//
+//	client, _ := gollm.NewLLM(gollm.SetProvider("anthropic"), gollm.SetModel("claude-3-5-sonnet-latest"))
+//	proxy := llmproxy.New(client, llmproxy.WithAPIKeys(os.Getenv("PROXY_KEY")))
+//	log.Fatal(http.ListenAndServe(":8080", proxy))
+func New(l gollm.LLM, opts ...Option) *Server {
+	s := &Server{
+		fallback: l,
+		models:   make(map[string]gollm.LLM),
+		maxBody:  4 << 20,
+		mux:      http.NewServeMux(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.mux.HandleFunc("/v1/chat/completions", s.handleChatCompletions)
+	s.mux.HandleFunc("/v1/models", s.handleModels)
+	return s
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		writeError(w, http.StatusUnauthorized, "invalid_request_error", "invalid API key")
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// authorized checks the bearer token against the configured keys.
+func (s *Server) authorized(r *http.Request) bool {
+	if len(s.apiKeys) == 0 {
+		return true
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return false
+	}
+	for _, key := range s.apiKeys {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(key)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve returns the LLM serving a model and the model name to report.
+func (s *Server) resolve(model string) (gollm.LLM, string, bool) {
+	if l, ok := s.models[model]; ok {
+		return l, model, true
+	}
+	if s.fallback == nil {
+		return nil, "", false
+	}
+	if model == "" {
+		model = s.fallback.GetModel()
+	}
+	return s.fallback, model, true
+}
+
+// chatRequest is the subset of the OpenAI chat completion request that is supported.
+type chatRequest struct {
+	Model       string        `json:"model"`
+	Messages    []chatMessage `json:"messages"`
+	Stream      bool          `json:"stream"`
+	Temperature *float64      `json:"temperature,omitempty"`
+	TopP        *float64      `json:"top_p,omitempty"`
+	MaxTokens   *int          `json:"max_tokens,omitempty"`
+	Seed        *int          `json:"seed,omitempty"`
+	Stop        interface{}   `json:"stop,omitempty"`
+
+	// The system prompt and conversation are sent with each request rather
+	// than set on the LLM, which is shared by all clients
+	system       string
+	conversation []gollm.MemoryMessage
+}
+
+// chatMessage accepts both string content and arrays of text parts, along
+// with the tool calls of assistant messages and the call answered by tool
+// messages.
+type chatMessage struct {
+	Role       string          `json:"role"`
+	Content    json.RawMessage `json:"content"`
+	Name       string          `json:"name,omitempty"`
+	ToolCalls  json.RawMessage `json:"tool_calls,omitempty"`
+	ToolCallID string          `json:"tool_call_id,omitempty"`
+}
+
+// text returns the message content, joining text parts.
+func (m chatMessage) text() (string, error) {
+	if len(m.Content) == 0 || string(m.Content) == "null" {
+		return "", nil
+	}
+	var s string
+	if err := json.Unmarshal(m.Content, &s); err == nil {
+		return s, nil
+	}
+	var parts []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(m.Content, &parts); err != nil {
+		return "", fmt.Errorf("unsupported content for %s message", m.Role)
+	}
+	var texts []string
+	for _, part := range parts {
+		if part.Type != "text" {
+			return "", fmt.Errorf("unsupported content part %q", part.Type)
+		}
+		texts = append(texts, part.Text)
+	}
+	return strings.Join(texts, "\n"), nil
+}
+
+// metadata returns the fields of the message sent along with its role and
+// content.
+func (m chatMessage) metadata() map[string]interface{} {
+	metadata := make(map[string]interface{})
+	if m.Name != "" {
+		metadata["name"] = m.Name
+	}
+	if len(m.ToolCalls) > 0 && string(m.ToolCalls) != "null" {
+		metadata["tool_calls"] = m.ToolCalls
+	}
+	if m.ToolCallID != "" {
+		metadata["tool_call_id"] = m.ToolCallID
+	}
+	if len(metadata) == 0 {
+		return nil
+	}
+	return metadata
+}
+
+// buildPrompt converts the messages to the conversation sent to the LLM, in
+// order, and its system prompt. The returned prompt holds the last user
+// message, which the LLM moderates and logs.
+func buildPrompt(messages []chatMessage) (*gollm.Prompt, []gollm.MemoryMessage, string, error) {
+	var system []string
+	var conversation []gollm.MemoryMessage
+	input := ""
+	for _, m := range messages {
+		text, err := m.text()
+		if err != nil {
+			return nil, nil, "", err
+		}
+		switch m.Role {
+		case "system", "developer":
+			system = append(system, text)
+			continue
+		case "user":
+			input = text
+		case "assistant", "tool":
+		default:
+			return nil, nil, "", fmt.Errorf("unsupported message role %q", m.Role)
+		}
+		conversation = append(conversation, gollm.MemoryMessage{Role: m.Role, Content: text, Metadata: m.metadata()})
+	}
+	if input == "" {
+		return nil, nil, "", errors.New("messages must include a user message")
+	}
+	return gollm.NewPrompt(input), conversation, strings.Join(system, "\n\n"), nil
+}
+
+// options returns the conversation, system prompt and sampling parameters
+// of the request as provider options.
+func (r chatRequest) options() map[string]interface{} {
+	options := map[string]interface{}{"structured_messages": r.conversation}
+	if r.system != "" {
+		options["system_prompt"] = r.system
+	}
+	if r.Temperature != nil {
+		options["temperature"] = *r.Temperature
+	}
+	if r.TopP != nil {
+		options["top_p"] = *r.TopP
+	}
+	if r.MaxTokens != nil {
+		options["max_tokens"] = *r.MaxTokens
+	}
+	if r.Seed != nil {
+		options["seed"] = *r.Seed
+	}
+	if r.Stop != nil {
+		options["stop"] = r.Stop
+	}
+	return options
+}
+
+// generateOptions converts sampling parameters to per-request options.
+func (r chatRequest) generateOptions() []llm.GenerateOption {
+	var opts []llm.GenerateOption
+	for k, v := range r.options() {
+		opts = append(opts, gollm.WithRequestOption(k, v))
+	}
+	return opts
+}
+
+// streamOptions converts sampling parameters to per-stream options.
+func (r chatRequest) streamOptions() []llm.StreamOption {
+	var opts []llm.StreamOption
+	for k, v := range r.options() {
+		opts = append(opts, gollm.WithStreamRequestOption(k, v))
+	}
+	return opts
+}
+
+func (s *Server) handleChatCompletions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "invalid_request_error", "method not allowed")
+		return
+	}
+	var req chatRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, s.maxBody)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "invalid request body: "+err.Error())
+		return
+	}
+	l, model, ok := s.resolve(req.Model)
+	if !ok {
+		writeError(w, http.StatusNotFound, "invalid_request_error", fmt.Sprintf("model %q is not served by this proxy", req.Model))
+		return
+	}
+	prompt, conversation, system, err := buildPrompt(req.Messages)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+	req.conversation, req.system = conversation, system
+
+	if req.Stream {
+		s.stream(w, r, l, model, prompt, req)
+		return
+	}
+
+	var usage gollm.Usage
+	var finishReason string
+	response, err := l.Generate(r.Context(), prompt, append(req.generateOptions(), gollm.WithUsage(&usage), gollm.WithFinishReason(&finishReason))...)
+	if err != nil {
+		writeLLMError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"id":      newID(),
+		"object":  "chat.completion",
+		"created": time.Now().Unix(),
+		"model":   model,
+		"choices": []map[string]interface{}{{
+			"index":         0,
+			"message":       map[string]interface{}{"role": "assistant", "content": response},
+			"finish_reason": finishReasonOrStop(finishReason),
+		}},
+		"usage": map[string]int{
+			"prompt_tokens":     usage.InputTokens,
+			"completion_tokens": usage.OutputTokens,
+			"total_tokens":      usage.InputTokens + usage.OutputTokens,
+		},
+	})
+}
+
+// stream answers with server-sent events in the OpenAI chunk format. LLMs
+// that cannot stream send the whole response as a single chunk.
+func (s *Server) stream(w http.ResponseWriter, r *http.Request, l gollm.LLM, model string, prompt *gollm.Prompt, req chatRequest) {
+	ctx := r.Context()
+	var stream gollm.TokenStream
+	if l.SupportsStreaming() {
+		var err error
+		if stream, err = l.Stream(ctx, prompt, req.streamOptions()...); err != nil {
+			writeLLMError(w, err)
+			return
+		}
+		defer stream.Close()
+	}
+
+	// Errors after this point can only be reported in the event stream
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+
+	id, created := newID(), time.Now().Unix()
+	send := func(delta map[string]interface{}, finishReason interface{}) error {
+		data, err := json.Marshal(map[string]interface{}{
+			"id":      id,
+			"object":  "chat.completion.chunk",
+			"created": created,
+			"model":   model,
+			"choices": []map[string]interface{}{{"index": 0, "delta": delta, "finish_reason": finishReason}},
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	}
+	fail := func(err error) {
+		data, _ := json.Marshal(errorBody(err.Error(), "server_error"))
+		fmt.Fprintf(w, "data: %s\n\n", data)
+	}
+
+	if err := send(map[string]interface{}{"role": "assistant", "content": ""}, nil); err != nil {
+		return
+	}
+
+	var finishReason string
+	if stream == nil {
+		response, err := l.Generate(ctx, prompt, append(req.generateOptions(), gollm.WithFinishReason(&finishReason))...)
+		if err != nil {
+			fail(err)
+			return
+		}
+		if err := send(map[string]interface{}{"content": response}, nil); err != nil {
+			return
+		}
+	} else {
+		calledTools := false
+		err := gollm.ConsumeStream(ctx, stream, func(token *gollm.StreamToken) error {
+			if token.Type == gollm.TokenTypeDone && token.Done != nil {
+				finishReason = token.Done.FinishReason
+				return nil
+			}
+			if token.Type == gollm.TokenTypeToolCall && token.ToolCall != nil {
+				calledTools = true
+				call := map[string]interface{}{"index": token.ToolCall.Index, "function": map[string]interface{}{"arguments": token.ToolCall.Arguments}}
+				if token.ToolCall.ID != "" {
+					call["id"] = token.ToolCall.ID
+					call["type"] = "function"
+				}
+				if token.ToolCall.Name != "" {
+					call["function"].(map[string]interface{})["name"] = token.ToolCall.Name
+				}
+				return send(map[string]interface{}{"tool_calls": []interface{}{call}}, nil)
+			}
+			if token.Text == "" {
+				return nil
+			}
+			return send(map[string]interface{}{"content": token.Text}, nil)
+		})
+		if err != nil {
+			fail(err)
+			return
+		}
+		if finishReason == "" && calledTools {
+			finishReason = gollm.FinishReasonToolCalls
+		}
+	}
+
+	if err := send(map[string]interface{}{}, finishReasonOrStop(finishReason)); err != nil {
+		return
+	}
+	fmt.Fprint(w, "data: [DONE]\n\n")
+	if flusher != nil {
+		flusher.Flush()
+	}
+}
+
+// finishReasonOrStop returns the finish reason reported by the provider, or
+// "stop" when it reported none.
+func finishReasonOrStop(reason string) string {
+	if reason == "" {
+		return gollm.FinishReasonStop
+	}
+	return reason
+}
+
+func (s *Server) handleModels(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "invalid_request_error", "method not allowed")
+		return
+	}
+	names := make([]string, 0, len(s.models)+1)
+	for name := range s.models {
+		names = append(names, name)
+	}
+	if s.fallback != nil {
+		if _, ok := s.models[s.fallback.GetModel()]; !ok {
+			names = append(names, s.fallback.GetModel())
+		}
+	}
+	sort.Strings(names)
+
+	data := make([]map[string]interface{}, len(names))
+	for i, name := range names {
+		owner := "gollm"
+		if l, _, ok := s.resolve(name); ok {
+			owner = l.GetProvider()
+		}
+		data[i] = map[string]interface{}{"id": name, "object": "model", "owned_by": owner}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"object": "list", "data": data})
+}
+
+// writeLLMError maps gollm errors to HTTP statuses.
+func writeLLMError(w http.ResponseWriter, err error) {
+	var llmErr *llm.LLMError
+	if errors.As(err, &llmErr) {
+		switch llmErr.Type {
+		case llm.ErrorTypeInvalidInput, llm.ErrorTypeUnsupported, llm.ErrorTypeModeration, llm.ErrorTypeContentFiltered:
+			writeError(w, http.StatusBadRequest, "invalid_request_error", err.Error())
+			return
+		case llm.ErrorTypeRateLimit:
+			writeError(w, http.StatusTooManyRequests, "rate_limit_error", err.Error())
+			return
+		}
+	}
+	writeError(w, http.StatusBadGateway, "server_error", err.Error())
+}
+
+func errorBody(message, kind string) map[string]interface{} {
+	return map[string]interface{}{"error": map[string]interface{}{"message": message, "type": kind}}
+}
+
+func writeError(w http.ResponseWriter, status int, kind, message string) {
+	writeJSON(w, status, errorBody(message, kind))
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// newID returns a random completion ID.
+func newID() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return "chatcmpl-" + hex.EncodeToString(b)
+}
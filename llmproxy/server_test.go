@@ -0,0 +1,219 @@
+package llmproxy
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm"
+	"github.com/teilomillet/gollm/gollmtest"
+)
+
+func post(t *testing.T, url, key, body string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, url+"/v1/chat/completions", strings.NewReader(body))
+	require.NoError(t, err)
+	if key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	return resp
+}
+
+func TestChatCompletions(t *testing.T) {
+	l, mock := gollmtest.NewMockLLM(t, gollm.SetModel("default-model"))
+	other, otherMock := gollmtest.NewMockLLM(t, gollm.SetModel("other-model"))
+	server := httptest.NewServer(New(l, WithModel("fast", other), WithAPIKeys("secret")))
+	defer server.Close()
+
+	t.Run("Unauthorized", func(t *testing.T) {
+		resp := post(t, server.URL, "wrong", `{"messages":[{"role":"user","content":"Hi"}]}`)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("Completion", func(t *testing.T) {
+		mock.QueueResponse("Bonjour le monde")
+		resp := post(t, server.URL, "secret", `{
+			"model": "default-model",
+			"messages": [
+				{"role": "system", "content": "Answer in French."},
+				{"role": "user", "content": "Say hello"},
+				{"role": "assistant", "content": "Bonjour"},
+				{"role": "user", "content": [{"type": "text", "text": "Say hello world"}]}
+			],
+			"temperature": 0.1
+		}`)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var body struct {
+			Model   string `json:"model"`
+			Choices []struct {
+				Message struct {
+					Content string `json:"content"`
+				} `json:"message"`
+				FinishReason string `json:"finish_reason"`
+			} `json:"choices"`
+			Usage struct {
+				CompletionTokens int `json:"completion_tokens"`
+			} `json:"usage"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, "default-model", body.Model)
+		require.Len(t, body.Choices, 1)
+		assert.Equal(t, "Bonjour le monde", body.Choices[0].Message.Content)
+		assert.Equal(t, "stop", body.Choices[0].FinishReason)
+		assert.Equal(t, 3, body.Usage.CompletionTokens)
+
+		call, ok := mock.LastCall()
+		require.True(t, ok)
+		assert.Equal(t, "Answer in French.", call.Options["system_prompt"])
+		assert.Equal(t, []gollm.MemoryMessage{
+			{Role: "user", Content: "Say hello"},
+			{Role: "assistant", Content: "Bonjour"},
+			{Role: "user", Content: "Say hello world"},
+		}, call.Messages, "the conversation is sent in order")
+		assert.Equal(t, 0.1, call.Options["temperature"])
+	})
+
+	t.Run("Streaming", func(t *testing.T) {
+		otherMock.QueueResponse("Streaming works fine")
+		resp := post(t, server.URL, "secret", `{"model":"fast","stream":true,"temperature":0.3,"max_tokens":50,"messages":[{"role":"user","content":"Go"}]}`)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+		var text, finish string
+		done := false
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+			if !ok {
+				continue
+			}
+			if data == "[DONE]" {
+				done = true
+				break
+			}
+			var chunk struct {
+				Model   string `json:"model"`
+				Choices []struct {
+					Delta struct {
+						Content string `json:"content"`
+					} `json:"delta"`
+					FinishReason *string `json:"finish_reason"`
+				} `json:"choices"`
+			}
+			require.NoError(t, json.Unmarshal([]byte(data), &chunk))
+			assert.Equal(t, "fast", chunk.Model)
+			text += chunk.Choices[0].Delta.Content
+			if chunk.Choices[0].FinishReason != nil {
+				finish = *chunk.Choices[0].FinishReason
+			}
+		}
+		assert.True(t, done)
+		assert.Equal(t, "Streaming works fine", strings.TrimSpace(text))
+		assert.Equal(t, "stop", finish)
+		assert.Equal(t, 1, otherMock.CallCount())
+
+		call, ok := otherMock.LastCall()
+		require.True(t, ok)
+		assert.True(t, call.Stream)
+		assert.Equal(t, []gollm.MemoryMessage{{Role: "user", Content: "Go"}}, call.Messages)
+		assert.Equal(t, 0.3, call.Options["temperature"])
+		assert.EqualValues(t, 50, call.Options["max_tokens"])
+	})
+
+	t.Run("ToolCalls", func(t *testing.T) {
+		mock.QueueToolCall("get_weather", map[string]string{"city": "Lyon"})
+		resp := post(t, server.URL, "secret", `{"messages":[
+			{"role": "user", "content": "Weather in Paris?"},
+			{"role": "assistant", "content": null, "tool_calls": [{"id": "call_1", "type": "function", "function": {"name": "get_weather", "arguments": "{\"city\":\"Paris\"}"}}]},
+			{"role": "tool", "tool_call_id": "call_1", "content": "Sunny"},
+			{"role": "user", "content": "And in Lyon?"}
+		]}`)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var body struct {
+			Choices []struct {
+				FinishReason string `json:"finish_reason"`
+			} `json:"choices"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		require.Len(t, body.Choices, 1)
+		assert.Equal(t, "tool_calls", body.Choices[0].FinishReason)
+
+		call, ok := mock.LastCall()
+		require.True(t, ok)
+		require.Len(t, call.Messages, 4)
+		assert.Equal(t, "assistant", call.Messages[1].Role)
+		assert.Contains(t, call.Messages[1].Metadata, "tool_calls")
+		assert.Equal(t, map[string]interface{}{"tool_call_id": "call_1"}, call.Messages[2].Metadata)
+		assert.Equal(t, "And in Lyon?", call.Messages[3].Content)
+	})
+
+	t.Run("BadRequest", func(t *testing.T) {
+		resp := post(t, server.URL, "secret", `{"messages":[{"role":"system","content":"Only a system prompt"}]}`)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Models", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"/v1/models", nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		var body struct {
+			Data []struct {
+				ID      string `json:"id"`
+				OwnedBy string `json:"owned_by"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		require.Len(t, body.Data, 2)
+		assert.Equal(t, "default-model", body.Data[0].ID)
+		assert.Equal(t, "fast", body.Data[1].ID)
+		assert.Equal(t, "mock", body.Data[1].OwnedBy)
+	})
+}
+
+func TestSystemPromptPerRequest(t *testing.T) {
+	l, mock := gollmtest.NewMockLLM(t, gollm.SetModel("shared"))
+	server := httptest.NewServer(New(l))
+	defer server.Close()
+
+	resp := post(t, server.URL, "", `{"messages":[{"role":"system","content":"SECRET tenant A"},{"role":"user","content":"Hi"}]}`)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	call, ok := mock.LastCall()
+	require.True(t, ok)
+	assert.Equal(t, "SECRET tenant A", call.Options["system_prompt"])
+
+	// The system prompt of one client is not applied to the next requests
+	resp = post(t, server.URL, "", `{"messages":[{"role":"user","content":"Hello"}]}`)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	call, ok = mock.LastCall()
+	require.True(t, ok)
+	assert.NotContains(t, call.Options, "system_prompt")
+	assert.NotContains(t, call.Prompt, "SECRET")
+}
+
+func TestUnknownModel(t *testing.T) {
+	l, _ := gollmtest.NewMockLLM(t, gollm.SetModel("only"))
+	server := httptest.NewServer(New(nil, WithModel("only", l)))
+	defer server.Close()
+
+	resp := post(t, server.URL, "", `{"model":"missing","messages":[{"role":"user","content":"Hi"}]}`)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
@@ -0,0 +1,178 @@
+// Package gollm provides persistence, editing, trimming and pinning of conversation memory.
+// This file contains type definitions and re-exports for saving, loading,
+// rewinding, trimming and pinning the memory of LLMs created with SetMemory.
+package gollm
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/teilomillet/gollm/llm"
+	"github.com/teilomillet/gollm/types"
+)
+
+// Re-export conversation memory types from the llm package
+type (
+	// MemoryCodec serializes conversation memory, e.g. as JSON, protobuf or an encrypted blob.
+	MemoryCodec = llm.MemoryCodec
+
+	// JSONMemoryCodec encodes memory as JSON; it is the default codec.
+	JSONMemoryCodec = llm.JSONMemoryCodec
+
+	// PersistentMemory is implemented by LLMs whose conversation memory can be saved and restored.
+	//
+	// Example usage:
+	//   l, _ := NewLLM(SetMemory(4000))
+	//   err := l.(PersistentMemory).SaveMemory(file, nil)
+	PersistentMemory = llm.PersistentMemory
+
+	// ConversationEditor is implemented by LLMs with conversation memory that can redo the last turn.
+	//
+	// Example usage:
+	//   l, _ := NewLLM(SetMemory(4000))
+	//   response, err := l.(ConversationEditor).RegenerateLast(ctx)
+	ConversationEditor = llm.ConversationEditor
+
+	// MemoryStrategy selects the messages a conversation memory keeps before each request.
+	//
+	// Example usage:
+	//   l, _ := NewLLM(SetMemory(8000), SetMemoryStrategy(LastTurns(5)))
+	MemoryStrategy = llm.MemoryStrategy
+
+	// PinnedMemory is implemented by LLMs with conversation memory that can pin messages and facts.
+	//
+	// Example usage:
+	//   l, _ := NewLLM(SetMemory(4000))
+	//   err := l.(PinnedMemory).SetFact("user name", "Ada")
+	PinnedMemory = llm.PinnedMemory
+)
+
+// Re-export conversation memory metadata keys
+const (
+	// MemoryImportanceKey is the message metadata key of the scores recorded by ImportanceWeighted.
+	MemoryImportanceKey = llm.MemoryImportanceKey
+
+	// MemoryPinnedKey is the message metadata key marking a message as pinned.
+	MemoryPinnedKey = types.MemoryPinnedKey
+
+	// MemoryFactKey is the message metadata key holding the key of a saved fact.
+	MemoryFactKey = llm.MemoryFactKey
+)
+
+// Re-export conversation memory functions from the llm package
+var (
+	// NewEncryptedMemoryCodec encrypts the output of a codec with AES-GCM under a user-provided key.
+	NewEncryptedMemoryCodec = llm.NewEncryptedMemoryCodec
+
+	// TokenWindow keeps the most recent messages within the token limit.
+	TokenWindow = llm.TokenWindow
+
+	// LastTurns keeps the last turns of the conversation.
+	LastTurns = llm.LastTurns
+
+	// ImportanceWeighted drops the turns a cheap model scores least important first.
+	ImportanceWeighted = llm.ImportanceWeighted
+)
+
+// SaveMemory writes the conversation memory of the LLM to w, encoded by
+// codec, or as JSON when codec is nil. It fails if the LLM was created
+// without SetMemory.
+func (l *llmImpl) SaveMemory(w io.Writer, codec MemoryCodec) error {
+	memory, err := l.conversation()
+	if err != nil {
+		return err
+	}
+	return memory.SaveMemory(w, codec)
+}
+
+// LoadMemory replaces the conversation memory of the LLM with the one read
+// from r, decoded by codec, or as JSON when codec is nil. It fails if the LLM
+// was created without SetMemory.
+func (l *llmImpl) LoadMemory(r io.Reader, codec MemoryCodec) error {
+	memory, err := l.conversation()
+	if err != nil {
+		return err
+	}
+	return memory.LoadMemory(r, codec)
+}
+
+// RegenerateLast replaces the last response of the conversation with a new
+// one. It fails if the LLM was created without SetMemory.
+func (l *llmImpl) RegenerateLast(ctx context.Context, opts ...llm.GenerateOption) (string, error) {
+	memory, err := l.conversation()
+	if err != nil {
+		return "", err
+	}
+	return memory.RegenerateLast(ctx, opts...)
+}
+
+// EditAndRegenerate replaces the last user message of the conversation and
+// generates a response to it. It fails if the LLM was created without
+// SetMemory.
+func (l *llmImpl) EditAndRegenerate(ctx context.Context, newUserMessage string, opts ...llm.GenerateOption) (string, error) {
+	memory, err := l.conversation()
+	if err != nil {
+		return "", err
+	}
+	return memory.EditAndRegenerate(ctx, newUserMessage, opts...)
+}
+
+// AddPinnedMessage adds a message that is never trimmed from the
+// conversation. It fails if the LLM was created without SetMemory.
+func (l *llmImpl) AddPinnedMessage(role, content string) error {
+	memory, err := l.conversation()
+	if err != nil {
+		return err
+	}
+	return memory.AddPinnedMessage(role, content)
+}
+
+// PinMessage pins or unpins the message at index of the conversation. It
+// fails if the LLM was created without SetMemory.
+func (l *llmImpl) PinMessage(index int, pinned bool) error {
+	memory, err := l.conversation()
+	if err != nil {
+		return err
+	}
+	return memory.PinMessage(index, pinned)
+}
+
+// SetFact sets a fact sent with every request. It fails if the LLM was
+// created without SetMemory.
+func (l *llmImpl) SetFact(key, value string) error {
+	memory, err := l.conversation()
+	if err != nil {
+		return err
+	}
+	return memory.SetFact(key, value)
+}
+
+// RemoveFact removes a fact set by SetFact. It fails if the LLM was created
+// without SetMemory.
+func (l *llmImpl) RemoveFact(key string) error {
+	memory, err := l.conversation()
+	if err != nil {
+		return err
+	}
+	return memory.RemoveFact(key)
+}
+
+// Facts returns the facts set by SetFact, or nil if the LLM was created
+// without SetMemory.
+func (l *llmImpl) Facts() map[string]string {
+	memory, err := l.conversation()
+	if err != nil {
+		return nil
+	}
+	return memory.Facts()
+}
+
+// conversation returns the memory of an LLM created with SetMemory.
+func (l *llmImpl) conversation() (*llm.LLMWithMemory, error) {
+	memory, ok := l.LLM.(*llm.LLMWithMemory)
+	if !ok {
+		return nil, fmt.Errorf("LLM has no conversation memory, create it with SetMemory")
+	}
+	return memory, nil
+}
@@ -3,7 +3,9 @@ package gollm
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -11,6 +13,7 @@ import (
 	"github.com/teilomillet/gollm/llm"
 	"github.com/teilomillet/gollm/providers"
 	"github.com/teilomillet/gollm/utils"
+	"golang.org/x/sync/errgroup"
 )
 
 // MOAConfig represents the configuration for the Mixture of Agents (MOA) system.
@@ -31,6 +34,49 @@ type MOAConfig struct {
 	// AgentTimeout specifies the maximum duration for each agent's execution
 	// If set to 0, no timeout is applied
 	AgentTimeout time.Duration
+
+	// Layers defines proposer layers explicitly, each with its own agents,
+	// timeout and failure tolerance. When set, they are created after the
+	// single-model layers defined by Models, which may then be empty.
+	Layers []MOALayerConfig
+
+	// MinSuccessful is the number of agents that must succeed in each layer
+	// built from Models. If set to 0, every agent must succeed
+	MinSuccessful int
+}
+
+// MOALayerConfig configures a single proposer layer of the Mixture of Agents.
+type MOALayerConfig struct {
+	// Agents lists the proposers in this layer. Each agent is configured by its
+	// own options, so agents can use different providers, models and API keys
+	Agents [][]ConfigOption
+
+	// Timeout bounds the execution of the whole layer. Agents that have not
+	// answered when it expires are counted as failed
+	// If set to 0, only AgentTimeout applies
+	Timeout time.Duration
+
+	// MinSuccessful is the number of agents that must succeed for the layer to
+	// produce output; the outputs of successful agents are passed on and the
+	// failures are dropped. If set to 0, every agent must succeed
+	MinSuccessful int
+}
+
+// MOAAgent bundles the options configuring a single agent, for use in
+// MOALayerConfig.Agents.
+//
+// Example:
+//
+//	layer := MOALayerConfig{
+//	    Agents: [][]ConfigOption{
+//	        MOAAgent(SetProvider("openai"), SetModel("gpt-4o-mini")),
+//	        MOAAgent(SetProvider("anthropic"), SetModel("claude-3-5-haiku-latest")),
+//	    },
+//	    Timeout:       30 * time.Second,
+//	    MinSuccessful: 1,
+//	}
+func MOAAgent(opts ...ConfigOption) []ConfigOption {
+	return opts
 }
 
 // MOALayer represents a single layer in the Mixture of Agents architecture.
@@ -38,6 +84,14 @@ type MOAConfig struct {
 type MOALayer struct {
 	// Models contains the LLM instances that operate within this layer
 	Models []llm.LLM
+
+	// Timeout bounds the execution of the whole layer
+	// If set to 0, only the agent timeout applies
+	Timeout time.Duration
+
+	// MinSuccessful is the number of models that must succeed for the layer
+	// to produce output. If set to 0, every model must succeed
+	MinSuccessful int
 }
 
 // MOA (Mixture of Agents) implements an ensemble learning system that combines
@@ -74,7 +128,7 @@ type MOA struct {
 //	    MaxParallel: 2,
 //	})
 func NewMOA(moaConfig MOAConfig, aggregatorOpts ...ConfigOption) (*MOA, error) {
-	if len(moaConfig.Models) == 0 {
+	if len(moaConfig.Models) == 0 && len(moaConfig.Layers) == 0 {
 		return nil, fmt.Errorf("invalid model configuration: at least one model must be specified")
 	}
 
@@ -83,7 +137,7 @@ func NewMOA(moaConfig MOAConfig, aggregatorOpts ...ConfigOption) (*MOA, error) {
 
 	moa := &MOA{
 		Config: moaConfig,
-		Layers: make([]MOALayer, len(moaConfig.Models)),
+		Layers: make([]MOALayer, 0, len(moaConfig.Models)+len(moaConfig.Layers)),
 	}
 
 	// Initialize each layer with its corresponding model
@@ -94,9 +148,37 @@ func NewMOA(moaConfig MOAConfig, aggregatorOpts ...ConfigOption) (*MOA, error) {
 		if err != nil {
 			return nil, fmt.Errorf("failed to create LLM for model %d: %w", i, err)
 		}
-		moa.Layers[i] = MOALayer{
-			Models: []llm.LLM{llmInstance},
+		moa.Layers = append(moa.Layers, MOALayer{
+			Models:        []llm.LLM{llmInstance},
+			MinSuccessful: moaConfig.MinSuccessful,
+		})
+	}
+
+	// Initialize the explicitly configured layers, one LLM per agent
+	for i, layerConfig := range moaConfig.Layers {
+		if len(layerConfig.Agents) == 0 {
+			return nil, fmt.Errorf("invalid layer configuration: layer %d has no agents", i)
+		}
+		if layerConfig.MinSuccessful > len(layerConfig.Agents) {
+			return nil, fmt.Errorf("invalid layer configuration: layer %d requires %d successful agents but has %d", i, layerConfig.MinSuccessful, len(layerConfig.Agents))
+		}
+		layer := MOALayer{
+			Models:        make([]llm.LLM, len(layerConfig.Agents)),
+			Timeout:       layerConfig.Timeout,
+			MinSuccessful: layerConfig.MinSuccessful,
+		}
+		for j, agentOpts := range layerConfig.Agents {
+			cfg := &config.Config{}
+			for _, opt := range agentOpts {
+				opt(cfg)
+			}
+			llmInstance, err := llm.NewLLM(cfg, logger, registry)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create LLM for agent %d in layer %d: %w", j, i, err)
+			}
+			layer.Models[j] = llmInstance
 		}
+		moa.Layers = append(moa.Layers, layer)
 	}
 
 	// Create the aggregator LLM
@@ -160,59 +242,88 @@ func (moa *MOA) Generate(ctx context.Context, input string) (string, error) {
 //   - input: The text input to process
 //
 // Returns:
-//   - Combined output from all models in the layer
-//   - Any error encountered during processing
+//   - Combined output from the models in the layer that succeeded
+//   - An error if fewer models than required succeeded
 //
 // Features:
 //   - Supports parallel processing with configurable concurrency limits
-//   - Implements per-agent timeouts when configured
-//   - Combines outputs from all models in the layer
+//   - Implements per-agent and per-layer timeouts when configured
+//   - Drops failed models when the layer tolerates partial results
+//   - Cancels the remaining models once too many have failed for the layer
+//     to succeed, so a hung provider does not hold up a lost cause
 func (moa *MOA) processLayer(ctx context.Context, layer MOALayer, input string) (string, error) {
 	results := make([]string, len(layer.Models))
-	errors := make([]error, len(layer.Models))
+	errs := make([]error, len(layer.Models))
 
-	// Create a worker pool if parallel processing is enabled
-	var wg sync.WaitGroup
-	var workerPool chan struct{}
-	if moa.Config.MaxParallel > 0 {
-		workerPool = make(chan struct{}, moa.Config.MaxParallel)
+	required := layer.MinSuccessful
+	if required <= 0 || required > len(layer.Models) {
+		required = len(layer.Models)
 	}
 
+	if layer.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, layer.Timeout)
+		defer cancel()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Branches record their own errors so that the others keep running
+	// while the layer can still succeed
+	var g errgroup.Group
+	if moa.Config.MaxParallel > 0 {
+		g.SetLimit(moa.Config.MaxParallel)
+	}
+	var mu sync.Mutex
+	failed := 0
 	for i, model := range layer.Models {
-		wg.Add(1)
-		go func(index int, llmInstance llm.LLM) {
-			defer wg.Done()
-			if workerPool != nil {
-				workerPool <- struct{}{}        // Acquire a worker
-				defer func() { <-workerPool }() // Release the worker
+		g.Go(func() error {
+			if err := ctx.Err(); err != nil {
+				errs[i] = err
+				return nil
 			}
 
 			// Create a context with timeout if AgentTimeout is set
-			var cancel context.CancelFunc
+			agentCtx := ctx
 			if moa.Config.AgentTimeout > 0 {
-				ctx, cancel = context.WithTimeout(ctx, moa.Config.AgentTimeout)
-				defer cancel()
+				var cancelAgent context.CancelFunc
+				agentCtx, cancelAgent = context.WithTimeout(ctx, moa.Config.AgentTimeout)
+				defer cancelAgent()
 			}
 
-			output, err := llmInstance.Generate(ctx, llm.NewPrompt(input))
+			output, err := model.Generate(agentCtx, llm.NewPrompt(input))
 			if err != nil {
-				errors[index] = err
-				return
+				errs[i] = err
+				mu.Lock()
+				failed++
+				if failed > len(layer.Models)-required {
+					cancel()
+				}
+				mu.Unlock()
+				return nil
 			}
-			results[index] = output
-		}(i, model)
+			results[i] = output
+			return nil
+		})
 	}
+	_ = g.Wait()
 
-	wg.Wait()
-
-	// Check for errors
-	for _, err := range errors {
+	// Keep the outputs of successful models, in model order
+	var succeeded []string
+	var failures []error
+	for i, err := range errs {
 		if err != nil {
-			return "", fmt.Errorf("error in layer processing: %w", err)
+			failures = append(failures, fmt.Errorf("model %d: %w", i, err))
+			continue
 		}
+		succeeded = append(succeeded, results[i])
+	}
+
+	if len(succeeded) < required {
+		return "", fmt.Errorf("error in layer processing: %d of %d models succeeded, %d required: %w", len(succeeded), len(layer.Models), required, errors.Join(failures...))
 	}
 
-	return moa.combineResults(results), nil
+	return moa.combineResults(succeeded), nil
 }
 
 // combineResults merges the results from multiple models in a layer into a single string.
@@ -224,11 +335,11 @@ func (moa *MOA) processLayer(ctx context.Context, layer MOALayer, input string)
 // Returns:
 //   - A combined string containing all model outputs
 func (moa *MOA) combineResults(results []string) string {
-	combined := ""
+	var combined strings.Builder
 	for _, result := range results {
-		combined += result + "\n---\n"
+		combined.WriteString(result + "\n---\n")
 	}
-	return combined
+	return combined.String()
 }
 
 // aggregate uses the aggregator LLM to synthesize the final output from multiple iterations.
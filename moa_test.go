@@ -0,0 +1,93 @@
+package gollm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm/llm"
+)
+
+// stubAgent answers with a fixed output or error after an optional delay.
+type stubAgent struct {
+	llm.LLM
+	output string
+	err    error
+	delay  time.Duration
+}
+
+func (s *stubAgent) Generate(ctx context.Context, prompt *llm.Prompt, opts ...llm.GenerateOption) (string, error) {
+	select {
+	case <-time.After(s.delay):
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+	if s.err != nil {
+		return "", s.err
+	}
+	return s.output + ": " + prompt.Input, nil
+}
+
+func TestMOAProcessLayer(t *testing.T) {
+	ctx := context.Background()
+	agents := []llm.LLM{
+		&stubAgent{output: "a"},
+		&stubAgent{err: errors.New("provider down")},
+		&stubAgent{output: "c", delay: time.Second},
+	}
+
+	t.Run("AllRequired", func(t *testing.T) {
+		moa := &MOA{}
+		_, err := moa.processLayer(ctx, MOALayer{Models: agents[:2]}, "q")
+		assert.ErrorContains(t, err, "provider down")
+	})
+
+	t.Run("PartialWithLayerTimeout", func(t *testing.T) {
+		moa := &MOA{}
+		output, err := moa.processLayer(ctx, MOALayer{Models: agents, Timeout: 50 * time.Millisecond, MinSuccessful: 1}, "q")
+		require.NoError(t, err)
+		assert.Equal(t, "a: q\n---\n", output)
+	})
+
+	t.Run("BelowMinimum", func(t *testing.T) {
+		moa := &MOA{Config: MOAConfig{AgentTimeout: 50 * time.Millisecond}}
+		_, err := moa.processLayer(ctx, MOALayer{Models: agents, MinSuccessful: 2}, "q")
+		assert.ErrorContains(t, err, "1 of 3 models succeeded")
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("CancelsLostCause", func(t *testing.T) {
+		// The failure makes the layer fail, so the hung agent is not waited for
+		moa := &MOA{Config: MOAConfig{MaxParallel: 2}}
+		hung := &stubAgent{output: "d", delay: time.Hour}
+		start := time.Now()
+		_, err := moa.processLayer(ctx, MOALayer{Models: []llm.LLM{hung, agents[1], agents[0]}}, "q")
+		assert.ErrorContains(t, err, "provider down")
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Less(t, time.Since(start), time.Second)
+	})
+}
+
+func TestNewMOAHeterogeneousLayers(t *testing.T) {
+	moa, err := NewMOA(MOAConfig{
+		Iterations: 1,
+		Layers: []MOALayerConfig{{
+			Agents: [][]ConfigOption{
+				MOAAgent(SetProvider("openai"), SetModel("gpt-4o-mini"), SetAPIKey("test")),
+				MOAAgent(SetProvider("anthropic"), SetModel("claude-3-5-haiku-latest"), SetAPIKey("test")),
+			},
+			Timeout:       time.Minute,
+			MinSuccessful: 1,
+		}},
+	}, SetProvider("openai"), SetModel("gpt-4o"), SetAPIKey("test"))
+	require.NoError(t, err)
+	require.Len(t, moa.Layers, 1)
+	assert.Len(t, moa.Layers[0].Models, 2)
+	assert.Equal(t, 1, moa.Layers[0].MinSuccessful)
+
+	_, err = NewMOA(MOAConfig{Layers: []MOALayerConfig{{Agents: [][]ConfigOption{MOAAgent(SetProvider("openai"))}, MinSuccessful: 2}}})
+	assert.Error(t, err)
+}
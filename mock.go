@@ -0,0 +1,55 @@
+// Package gollm provides testing support for Language Learning Models.
+// This file re-exports the mock provider, which lets applications unit test
+// their LLM code without network access or API keys.
+package gollm
+
+import (
+	"fmt"
+
+	"github.com/teilomillet/gollm/llm"
+	"github.com/teilomillet/gollm/providers"
+)
+
+// Re-export mock provider types from the providers package
+type (
+	// MockProvider answers requests in process with scripted replies and records every call.
+	MockProvider = providers.MockProvider
+
+	// MockCall records a request received by a MockProvider.
+	MockCall = providers.MockCall
+
+	// MockToolCall is a scripted tool call returned by a MockProvider.
+	MockToolCall = providers.MockToolCall
+
+	// MockResponder computes mock replies dynamically.
+	MockResponder = providers.MockResponder
+)
+
+// GetMockProvider returns the MockProvider serving an LLM created with
+// SetProvider("mock"), so tests can script replies and inspect calls.
+//
+// Example usage:
+//
+//	llm, _ := NewLLM(SetProvider("mock"), SetMaxRetries(0))
+//	mock, _ := GetMockProvider(llm)
+//	mock.QueueToolCall("get_weather", map[string]interface{}{"location": "Paris"})
+//	mock.QueueError(http.StatusTooManyRequests, "rate limited")
+func GetMockProvider(l LLM) (*MockProvider, error) {
+	impl, ok := l.(*llmImpl)
+	if !ok {
+		return nil, fmt.Errorf("LLM was not created by NewLLM")
+	}
+	base := impl.LLM
+	if withMemory, ok := base.(*llm.LLMWithMemory); ok {
+		base = withMemory.LLM
+	}
+	core, ok := base.(*llm.LLMImpl)
+	if !ok {
+		return nil, fmt.Errorf("unexpected LLM implementation %T", base)
+	}
+	mock, ok := core.Provider.(*providers.MockProvider)
+	if !ok {
+		return nil, fmt.Errorf("provider %q is not the mock provider", core.Provider.Name())
+	}
+	return mock, nil
+}
@@ -0,0 +1,91 @@
+package gollm
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMockProvider(t *testing.T) {
+	ctx := context.Background()
+	l, err := NewLLM(SetProvider("mock"), SetModel("test-model"), SetMaxRetries(0), SetTemperature(0.2))
+	require.NoError(t, err)
+	mock, err := GetMockProvider(l)
+	require.NoError(t, err)
+
+	mock.QueueResponse("Paris").
+		QueueToolCall("get_weather", map[string]interface{}{"location": "Paris"}).
+		QueueError(http.StatusTooManyRequests, "rate limited")
+
+	answer, err := l.Generate(ctx, NewPrompt("Capital of France?"))
+	require.NoError(t, err)
+	assert.Equal(t, "Paris", answer)
+
+	call, ok := mock.LastCall()
+	require.True(t, ok)
+	assert.Contains(t, call.Prompt, "Capital of France?")
+	assert.Equal(t, "test-model", call.Options["model"])
+	assert.Equal(t, 0.2, call.Options["temperature"])
+
+	toolCall, err := l.Generate(ctx, NewPrompt("Weather?"))
+	require.NoError(t, err)
+	assert.Contains(t, toolCall, `"name":"get_weather"`)
+
+	_, err = l.Generate(ctx, NewPrompt("Again"))
+	assert.Error(t, err)
+	assert.Equal(t, 0, mock.Pending())
+	assert.Equal(t, 3, mock.CallCount())
+
+	answer, err = l.Generate(ctx, NewPrompt("Unscripted"))
+	require.NoError(t, err)
+	assert.Equal(t, "mock response", answer)
+
+	mock.SetResponder(func(call MockCall) (string, error) {
+		return strings.ToUpper(call.Prompt), nil
+	})
+	answer, err = l.Generate(ctx, NewPrompt("echo"))
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(answer, "ECHO"))
+
+	mock.Reset()
+	mock.SetLatency(time.Second)
+	timeoutCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	_, err = l.Generate(timeoutCtx, NewPrompt("slow"))
+	assert.Error(t, err)
+	assert.Equal(t, 1, mock.CallCount())
+}
+
+func TestMockProviderStream(t *testing.T) {
+	l, err := NewLLM(SetProvider("mock"), SetMaxRetries(0))
+	require.NoError(t, err)
+	mock, err := GetMockProvider(l)
+	require.NoError(t, err)
+	mock.QueueResponse("streamed mock reply")
+
+	stream, err := l.Stream(context.Background(), NewPrompt("Stream please"))
+	require.NoError(t, err)
+	defer stream.Close()
+
+	var text strings.Builder
+	for {
+		token, err := stream.Next(context.Background())
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		text.WriteString(token.Text)
+	}
+	assert.Equal(t, "streamed mock reply", text.String())
+	call, _ := mock.LastCall()
+	assert.True(t, call.Stream)
+
+	_, err = GetMockProvider(&llmImpl{LLM: nil})
+	assert.Error(t, err)
+}
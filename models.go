@@ -0,0 +1,36 @@
+// Package gollm provides model discovery for Language Learning Models.
+// This file contains type definitions and re-exports for listing the models
+// of a provider and their capabilities.
+package gollm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/teilomillet/gollm/providers"
+)
+
+// Re-export model types from the providers package
+type (
+	// ModelInfo describes the pricing, capabilities and lifecycle of a model.
+	ModelInfo = providers.ModelInfo
+
+	// ModelCatalog is a table of models used for cost tracking and routing.
+	ModelCatalog = providers.ModelCatalog
+)
+
+type modelLister interface {
+	ListModels(context.Context) ([]providers.ModelInfo, error)
+}
+
+// ListModels returns the models available with the configured provider and
+// merges them into providers.DefaultModelCatalog().
+// Supported providers are "openai", "anthropic", "mistral", "groq",
+// "deepseek", "cohere", "openrouter" and "ollama".
+func (l *llmImpl) ListModels(ctx context.Context) ([]ModelInfo, error) {
+	m, ok := l.LLM.(modelLister)
+	if !ok {
+		return nil, fmt.Errorf("model listing not supported by provider %s", l.provider.Name())
+	}
+	return m.ListModels(ctx)
+}
@@ -0,0 +1,35 @@
+// Package gollm provides moderation functionality for Language Learning Models.
+// This file contains type definitions and re-exports for classifying text with
+// a provider's moderation API.
+package gollm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/teilomillet/gollm/llm"
+)
+
+// Re-export moderation types from the llm package
+type (
+	// ModerationResult holds the flagged categories and category scores of a moderated text.
+	ModerationResult = llm.ModerationResult
+
+	// ModerationOption configures a moderation request.
+	ModerationOption = llm.ModerationOption
+)
+
+// WithModerationModel overrides the provider's default moderation model.
+var WithModerationModel = llm.WithModerationModel
+
+// Moderate classifies text using the configured provider's moderation API.
+// Supported providers are "openai" and "mistral".
+func (l *llmImpl) Moderate(ctx context.Context, text string, opts ...ModerationOption) (*ModerationResult, error) {
+	m, ok := l.LLM.(interface {
+		Moderate(context.Context, string, ...llm.ModerationOption) (*llm.ModerationResult, error)
+	})
+	if !ok {
+		return nil, fmt.Errorf("moderation not supported by provider %s", l.provider.Name())
+	}
+	return m.Moderate(ctx, text, opts...)
+}
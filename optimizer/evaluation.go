@@ -0,0 +1,330 @@
+// Package optimizer provides prompt optimization capabilities for Language Learning Models.
+package optimizer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/teilomillet/gollm/llm"
+)
+
+// InputPlaceholder marks where a test case input is inserted into a candidate prompt.
+// Prompts without the placeholder get the input appended after their text.
+const InputPlaceholder = "{{input}}"
+
+// TestCase is an example the optimized prompt is expected to handle.
+type TestCase struct {
+	// Name identifies the test case in results
+	Name string
+
+	// Input is inserted into the candidate prompt at InputPlaceholder
+	Input string
+
+	// Expected is the reference output passed to the ScoreFunc
+	Expected string
+}
+
+// TestCaseResult records how a candidate prompt performed on a single test case.
+type TestCaseResult struct {
+	TestCase TestCase
+	Output   string  // Output generated by the candidate prompt
+	Score    float64 // Score between 0 and 1
+	Err      error   // Generation or scoring error, if any
+}
+
+// ScoreFunc scores the output produced for a test case between 0 (wrong) and 1 (perfect).
+type ScoreFunc func(ctx context.Context, tc TestCase, output string) (float64, error)
+
+// EvaluationFunc scores a candidate prompt directly between 0 (worst) and 1 (best).
+// Use it when the quality of a prompt cannot be expressed as test cases.
+type EvaluationFunc func(ctx context.Context, prompt *llm.Prompt) (float64, error)
+
+// ExactMatch is a ScoreFunc that scores 1 when the output equals the expected
+// value, ignoring case and surrounding whitespace, and 0 otherwise.
+func ExactMatch(ctx context.Context, tc TestCase, output string) (float64, error) {
+	if strings.EqualFold(strings.TrimSpace(output), strings.TrimSpace(tc.Expected)) {
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// ContainsExpected is a ScoreFunc that scores 1 when the output contains the
+// expected value, ignoring case, and 0 otherwise.
+func ContainsExpected(ctx context.Context, tc TestCase, output string) (float64, error) {
+	if strings.Contains(strings.ToLower(output), strings.ToLower(strings.TrimSpace(tc.Expected))) {
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// ScoredCandidate is a prompt evaluated during feedback optimization.
+type ScoredCandidate struct {
+	// Iteration is the optimization step that produced the candidate; 0 is the initial prompt
+	Iteration int
+
+	// Prompt is the evaluated candidate
+	Prompt *llm.Prompt
+
+	// Score is the average test case score, or the EvaluationFunc result
+	Score float64
+
+	// Results holds per test case results when test cases are used
+	Results []TestCaseResult
+
+	// Reasoning is the rewriter's explanation of the changes, if any
+	Reasoning string
+}
+
+// FeedbackResult is the outcome of OptimizeWithFeedback.
+type FeedbackResult struct {
+	// BestPrompt is the highest scoring candidate
+	BestPrompt *llm.Prompt
+
+	// BestScore is the score of BestPrompt
+	BestScore float64
+
+	// History lists every evaluated candidate in evaluation order
+	History []ScoredCandidate
+}
+
+// WithEvaluator sets a scoring function that evaluates candidate prompts
+// directly. It takes precedence over test cases.
+func WithEvaluator(evaluate EvaluationFunc) OptimizerOption {
+	return func(po *PromptOptimizer) {
+		po.evaluator = evaluate
+	}
+}
+
+// WithTestCases sets the test cases candidate prompts are run against and the
+// function scoring their outputs. A nil score function defaults to ContainsExpected.
+func WithTestCases(score ScoreFunc, cases ...TestCase) OptimizerOption {
+	return func(po *PromptOptimizer) {
+		if score == nil {
+			score = ContainsExpected
+		}
+		po.scoreFunc = score
+		po.testCases = cases
+	}
+}
+
+// WithCandidatesPerIteration sets how many rewrites are generated and
+// evaluated in each iteration of OptimizeWithFeedback. Defaults to 1.
+func WithCandidatesPerIteration(n int) OptimizerOption {
+	return func(po *PromptOptimizer) {
+		po.candidates = n
+	}
+}
+
+// WithTargetLLM sets the model that runs candidate prompts against test cases.
+// By default the optimizer's own model is used, which also rewrites prompts.
+func WithTargetLLM(target llm.LLM) OptimizerOption {
+	return func(po *PromptOptimizer) {
+		po.targetLLM = target
+	}
+}
+
+// OptimizeWithFeedback iteratively rewrites the initial prompt and keeps the
+// candidate that scores best against the configured evaluator or test cases.
+//
+// The optimization process:
+// 1. Scores the initial prompt
+// 2. Asks the LLM to rewrite the best prompt so far, showing it the score and the failing test cases
+// 3. Scores each rewrite and keeps the best one
+// 4. Repeats until the threshold is reached or max iterations are done
+//
+// Unlike OptimizePrompt, which relies on the LLM's own assessment, candidates
+// are judged only by measured performance. Rewrites that fail to generate or
+// parse are logged and skipped.
+//
+// Parameters:
+//   - ctx: Context for cancellation and timeout
+//
+// Returns:
+//   - Best prompt with its score and the full score history
+//   - Error if no evaluator or test cases are configured, or evaluation fails
+//
+// Example usage:
+//
+//	optimizer := NewPromptOptimizer(llmInstance, debugManager, llm.NewPrompt("Classify the sentiment: {{input}}"), "Sentiment classification",
+//	    WithTestCases(ExactMatch,
+//	        TestCase{Input: "I loved it", Expected: "positive"},
+//	        TestCase{Input: "Terrible service", Expected: "negative"},
+//	    ),
+//	    WithThreshold(1.0),
+//	)
+//	result, err := optimizer.OptimizeWithFeedback(ctx)
+func (po *PromptOptimizer) OptimizeWithFeedback(ctx context.Context) (*FeedbackResult, error) {
+	if po.evaluator == nil && len(po.testCases) == 0 {
+		return nil, fmt.Errorf("feedback optimization requires an evaluator or test cases")
+	}
+
+	best, err := po.evaluateCandidate(ctx, po.initialPrompt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate initial prompt: %w", err)
+	}
+	result := &FeedbackResult{History: []ScoredCandidate{best}}
+	po.debugManager.LogResponse(fmt.Sprintf("Initial prompt score: %.3f", best.Score))
+
+	candidates := po.candidates
+	if candidates < 1 {
+		candidates = 1
+	}
+
+	for i := 1; i <= po.iterations && best.Score < po.threshold; i++ {
+		for c := 0; c < candidates; c++ {
+			if err := ctx.Err(); err != nil {
+				return po.feedbackResult(result, best), err
+			}
+
+			rewrite, reasoning, err := po.rewritePrompt(ctx, best, result.History)
+			if err != nil {
+				po.debugManager.LogResponse(fmt.Sprintf("Failed to rewrite prompt at iteration %d: %v", i, err))
+				continue
+			}
+
+			candidate, err := po.evaluateCandidate(ctx, rewrite)
+			if err != nil {
+				return po.feedbackResult(result, best), fmt.Errorf("failed to evaluate candidate at iteration %d: %w", i, err)
+			}
+			candidate.Iteration = i
+			candidate.Reasoning = reasoning
+			result.History = append(result.History, candidate)
+
+			if candidate.Score > best.Score {
+				best = candidate
+			}
+		}
+		po.debugManager.LogResponse(fmt.Sprintf("Iteration %d complete. Best score: %.3f", i, best.Score))
+	}
+
+	return po.feedbackResult(result, best), nil
+}
+
+// feedbackResult fills in the best candidate of an optimization result.
+func (po *PromptOptimizer) feedbackResult(result *FeedbackResult, best ScoredCandidate) *FeedbackResult {
+	result.BestPrompt = best.Prompt
+	result.BestScore = best.Score
+	return result
+}
+
+// evaluateCandidate scores a prompt with the evaluator, or by running it
+// against every test case and averaging the scores.
+func (po *PromptOptimizer) evaluateCandidate(ctx context.Context, prompt *llm.Prompt) (ScoredCandidate, error) {
+	candidate := ScoredCandidate{Prompt: prompt}
+	if po.evaluator != nil {
+		score, err := po.evaluator(ctx, prompt)
+		if err != nil {
+			return candidate, err
+		}
+		candidate.Score = score
+		return candidate, nil
+	}
+
+	target := po.targetLLM
+	if target == nil {
+		target = po.llm
+	}
+
+	var total float64
+	for _, tc := range po.testCases {
+		res := TestCaseResult{TestCase: tc}
+		res.Output, res.Err = target.Generate(ctx, applyTestInput(prompt, tc.Input))
+		if res.Err == nil {
+			res.Score, res.Err = po.scoreFunc(ctx, tc, res.Output)
+		}
+		if ctx.Err() != nil {
+			return candidate, ctx.Err()
+		}
+		total += res.Score
+		candidate.Results = append(candidate.Results, res)
+	}
+	candidate.Score = total / float64(len(po.testCases))
+	return candidate, nil
+}
+
+// applyTestInput returns a copy of the prompt with the test case input inserted.
+func applyTestInput(prompt *llm.Prompt, input string) *llm.Prompt {
+	p := *prompt
+	if strings.Contains(p.Input, InputPlaceholder) {
+		p.Input = strings.ReplaceAll(p.Input, InputPlaceholder, input)
+	} else if input != "" {
+		p.Input = p.Input + "\n\n" + input
+	}
+	return &p
+}
+
+// rewritePrompt asks the LLM for an improved version of the best prompt,
+// using its score, its failing test cases and previous attempts as feedback.
+func (po *PromptOptimizer) rewritePrompt(ctx context.Context, best ScoredCandidate, history []ScoredCandidate) (*llm.Prompt, string, error) {
+	var failures strings.Builder
+	for _, res := range best.Results {
+		if res.Score >= 1 {
+			continue
+		}
+		if res.Err != nil {
+			fmt.Fprintf(&failures, "- Input: %q\n  Expected: %q\n  Error: %v\n", res.TestCase.Input, res.TestCase.Expected, res.Err)
+			continue
+		}
+		fmt.Fprintf(&failures, "- Input: %q\n  Expected: %q\n  Got: %q (score %.2f)\n", res.TestCase.Input, res.TestCase.Expected, res.Output, res.Score)
+	}
+	if failures.Len() == 0 {
+		failures.WriteString("No individual failures available.\n")
+	}
+
+	var attempts strings.Builder
+	recent := history
+	if po.memorySize > 0 && len(recent) > po.memorySize {
+		recent = recent[len(recent)-po.memorySize:]
+	}
+	for _, h := range recent {
+		fmt.Fprintf(&attempts, "- Score %.3f: %q\n", h.Score, h.Prompt.Input)
+	}
+
+	rewritePrompt := llm.NewPrompt(fmt.Sprintf(`
+		Rewrite the following prompt so that it performs better on its task.
+
+		Task Description: %s
+		Optimization Goal: %s
+
+		Current best prompt (score %.3f out of 1):
+		%+v
+
+		Failing cases:
+		%s
+		Recent attempts:
+		%s
+		Keep the placeholder %s wherever the input should be inserted, if the prompt uses it.
+		Address the failing cases without breaking the cases that already pass.
+
+		IMPORTANT: Respond ONLY with a raw JSON object. Do not use any markdown formatting, code blocks, or backticks.
+		The JSON object should have this structure:
+		{
+			"input": "rewritten prompt text",
+			"directives": ["directive1", "directive2", ...],
+			"examples": ["example1", "example2", ...],
+			"reasoning": "explanation of the changes and the failures they address"
+		}
+	`, po.taskDesc, po.optimizationGoal, best.Score, best.Prompt, failures.String(), attempts.String(), InputPlaceholder))
+
+	po.debugManager.LogPrompt(rewritePrompt.String())
+
+	response, err := po.llm.Generate(ctx, rewritePrompt)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate rewritten prompt: %w", err)
+	}
+	po.debugManager.LogResponse(response)
+
+	var rewritten struct {
+		llm.Prompt
+		Reasoning string `json:"reasoning"`
+	}
+	if err := json.Unmarshal([]byte(cleanJSONResponse(response)), &rewritten); err != nil {
+		return nil, "", fmt.Errorf("failed to parse rewritten prompt: %w", err)
+	}
+	if strings.TrimSpace(rewritten.Input) == "" {
+		return nil, "", fmt.Errorf("rewritten prompt has no input")
+	}
+	return &rewritten.Prompt, rewritten.Reasoning, nil
+}
@@ -0,0 +1,79 @@
+package optimizer
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm/llm"
+	"github.com/teilomillet/gollm/utils"
+)
+
+// rewriterLLM rewrites prompts with canned responses and answers test inputs
+// correctly only when the prompt asks for a single word.
+type rewriterLLM struct {
+	llm.LLM
+	mu       sync.Mutex
+	rewrites []string
+}
+
+func (r *rewriterLLM) Generate(ctx context.Context, prompt *llm.Prompt, opts ...llm.GenerateOption) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if strings.Contains(prompt.Input, "Rewrite the following prompt") {
+		rewrite := r.rewrites[0]
+		r.rewrites = r.rewrites[1:]
+		return rewrite, nil
+	}
+	if strings.Contains(prompt.Input, "one word") {
+		if strings.Contains(prompt.Input, "loved") {
+			return "positive", nil
+		}
+		return "negative", nil
+	}
+	return "The sentiment of this text is hard to say.", nil
+}
+
+func TestOptimizeWithFeedback(t *testing.T) {
+	debug := utils.NewDebugManager(utils.NewLogger(utils.LogLevelOff), utils.DebugOptions{})
+	model := &rewriterLLM{rewrites: []string{
+		"not json",
+		`{"input": "Classify the sentiment: {{input}}. Be brief.", "reasoning": "shorter"}`,
+		"```json\n{\"input\": \"Answer with one word, positive or negative: {{input}}\", \"reasoning\": \"constrain output\"}\n```",
+	}}
+
+	po := NewPromptOptimizer(model, debug, llm.NewPrompt("Classify the sentiment: {{input}}"), "Sentiment classification",
+		WithTestCases(ExactMatch,
+			TestCase{Input: "I loved it", Expected: "positive"},
+			TestCase{Input: "Terrible service", Expected: "negative"},
+		),
+		WithThreshold(1.0),
+		WithIterations(3),
+	)
+
+	result, err := po.OptimizeWithFeedback(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, result.BestScore)
+	assert.Equal(t, "Answer with one word, positive or negative: {{input}}", result.BestPrompt.Input)
+	require.Len(t, result.History, 3, "the unparsable rewrite is skipped")
+	assert.Equal(t, 0.0, result.History[0].Score)
+	assert.Equal(t, "constrain output", result.History[2].Reasoning)
+	assert.Equal(t, 3, result.History[2].Iteration)
+}
+
+func TestOptimizeWithFeedbackEvaluator(t *testing.T) {
+	debug := utils.NewDebugManager(utils.NewLogger(utils.LogLevelOff), utils.DebugOptions{})
+	po := NewPromptOptimizer(&rewriterLLM{}, debug, llm.NewPrompt("Summarize"), "Summaries",
+		WithEvaluator(func(ctx context.Context, p *llm.Prompt) (float64, error) { return 0.9, nil }),
+	)
+	result, err := po.OptimizeWithFeedback(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 0.9, result.BestScore)
+	assert.Len(t, result.History, 1, "no rewrite is needed once the threshold is met")
+
+	_, err = NewPromptOptimizer(&rewriterLLM{}, debug, llm.NewPrompt("x"), "x").OptimizeWithFeedback(context.Background())
+	assert.Error(t, err)
+}
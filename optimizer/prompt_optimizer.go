@@ -153,6 +153,7 @@ func NewPromptOptimizer(llm llm.LLM, debugManager *utils.DebugManager, initialPr
 		threshold:     0.8,
 		maxRetries:    3,
 		retryDelay:    time.Second * 2,
+		clock:         utils.SystemClock(),
 		memorySize:    2,
 		iterations:    5,
 	}
@@ -198,7 +199,11 @@ func (po *PromptOptimizer) OptimizePrompt(ctx context.Context) (*llm.Prompt, err
 			po.debugManager.LogResponse(fmt.Sprintf("Error in iteration %d, attempt %d: %v", i+1, attempt+1, err))
 			if attempt < po.maxRetries-1 {
 				po.debugManager.LogResponse(fmt.Sprintf("Retrying in %v...", po.retryDelay))
-				time.Sleep(po.retryDelay)
+				select {
+				case <-ctx.Done():
+					return bestPrompt, ctx.Err()
+				case <-po.clock.After(po.retryDelay):
+				}
 			}
 		}
 
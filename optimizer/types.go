@@ -142,9 +142,27 @@ type PromptOptimizer struct {
 	// retryDelay sets the wait time between retries
 	retryDelay time.Duration
 
+	// clock times the waits between retries
+	clock utils.Clock
+
 	// memorySize limits the optimization history length
 	memorySize int
 
 	// iterations counts the optimization steps performed
 	iterations int
+
+	// evaluator scores candidate prompts in feedback optimization
+	evaluator EvaluationFunc
+
+	// testCases are run against candidate prompts in feedback optimization
+	testCases []TestCase
+
+	// scoreFunc scores test case outputs
+	scoreFunc ScoreFunc
+
+	// candidates is the number of rewrites evaluated per iteration
+	candidates int
+
+	// targetLLM runs candidate prompts against test cases
+	targetLLM llm.LLM
 }
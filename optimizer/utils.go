@@ -5,6 +5,8 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/teilomillet/gollm/utils"
 )
 
 // DefaultRetryDelay is the standard duration to wait between retry attempts.
@@ -135,6 +137,17 @@ func WithRetryDelay(delay time.Duration) OptimizerOption {
 	}
 }
 
+// WithClock sets the clock timing the waits between retries, e.g. a
+// utils.FakeClock in tests.
+//
+// Parameters:
+//   - clock: Clock to wait with
+func WithClock(clock utils.Clock) OptimizerOption {
+	return func(po *PromptOptimizer) {
+		po.clock = clock
+	}
+}
+
 // WithMemorySize sets the number of optimization entries to retain in history.
 // This affects the context available for subsequent optimization iterations.
 //
@@ -5,7 +5,9 @@ package presets
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"strings"
 	"unicode/utf8"
 
 	"github.com/teilomillet/gollm"
@@ -143,3 +145,181 @@ func ChainOfThought(ctx context.Context, l gollm.LLM, question string, opts ...g
 	}
 	return response, nil
 }
+
+// defaultAnswerMarker introduces the final answer in a chain of thought response.
+const defaultAnswerMarker = "Final Answer:"
+
+// ChainOfThoughtResult separates the reasoning trace of a chain of thought
+// response from its final answer.
+type ChainOfThoughtResult struct {
+	Reasoning string `json:"reasoning" validate:"required"` // Step-by-step reasoning
+	Answer    string `json:"answer" validate:"required"`    // Final answer only, without reasoning
+}
+
+// chainOfThoughtConfig holds the settings of ChainOfThoughtWithAnswer.
+type chainOfThoughtConfig struct {
+	marker        string
+	structured    bool
+	alwaysExtract bool
+	promptOptions []gollm.PromptOption
+}
+
+// ChainOfThoughtOption configures ChainOfThoughtWithAnswer.
+type ChainOfThoughtOption func(*chainOfThoughtConfig)
+
+// WithAnswerMarker changes the label the model is asked to put before its
+// final answer. Defaults to "Final Answer:".
+func WithAnswerMarker(marker string) ChainOfThoughtOption {
+	return func(c *chainOfThoughtConfig) {
+		c.marker = marker
+	}
+}
+
+// WithStructuredReasoning asks the model for a JSON object with separate
+// "reasoning" and "answer" fields instead of a labelled final line.
+func WithStructuredReasoning() ChainOfThoughtOption {
+	return func(c *chainOfThoughtConfig) {
+		c.structured = true
+	}
+}
+
+// WithExtractionPass always runs a second, separate request that extracts
+// the final answer from the reasoning. By default the extraction pass only
+// runs when the answer cannot be found in the first response.
+func WithExtractionPass() ChainOfThoughtOption {
+	return func(c *chainOfThoughtConfig) {
+		c.alwaysExtract = true
+	}
+}
+
+// WithReasoningPromptOptions applies prompt options (context, examples,
+// max length, ...) to the reasoning request.
+func WithReasoningPromptOptions(opts ...gollm.PromptOption) ChainOfThoughtOption {
+	return func(c *chainOfThoughtConfig) {
+		c.promptOptions = append(c.promptOptions, opts...)
+	}
+}
+
+// ChainOfThoughtWithAnswer performs chain of thought reasoning and returns the
+// reasoning trace and the final answer as distinct fields.
+//
+// The model is asked to think step by step and finish with a labelled final
+// answer (or, with WithStructuredReasoning, to return JSON). If no answer can
+// be found in the response, a separate extraction request derives it from the
+// reasoning, so callers always receive a short answer they can compare or parse.
+//
+// Example usage:
+//
+//	result, err := ChainOfThoughtWithAnswer(ctx, llm,
+//	    "What is the result of (17 * 6) + (23 * 4)?",
+//	    WithReasoningPromptOptions(gollm.WithMaxLength(200)),
+//	)
+//	fmt.Println(result.Answer) // 194
+func ChainOfThoughtWithAnswer(ctx context.Context, l gollm.LLM, question string, opts ...ChainOfThoughtOption) (*ChainOfThoughtResult, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context cannot be nil")
+	}
+	if l == nil {
+		return nil, fmt.Errorf("LLM instance cannot be nil")
+	}
+	if strings.TrimSpace(question) == "" {
+		return nil, fmt.Errorf("question cannot be empty")
+	}
+	if !utf8.ValidString(question) {
+		return nil, fmt.Errorf("question contains invalid UTF-8 characters")
+	}
+
+	cfg := &chainOfThoughtConfig{marker: defaultAnswerMarker}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	prompt, err := chainOfThoughtTemplate.Execute(map[string]interface{}{
+		"Question": question,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute chain of thought template: %w", err)
+	}
+	if cfg.structured {
+		schema, err := gollm.GenerateJSONSchema(ChainOfThoughtResult{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate JSON schema: %w", err)
+		}
+		prompt.Apply(
+			gollm.WithDirectives(
+				"Think step by step and put the full reasoning in the \"reasoning\" field",
+				"Put only the final answer, without explanation, in the \"answer\" field",
+			),
+			gollm.WithOutput("JSON object matching this schema:\n"+string(schema)),
+		)
+	} else {
+		prompt.Apply(
+			gollm.WithDirectives(
+				"Think step by step before answering",
+				fmt.Sprintf("End with a final line of the form %q followed by only the answer", cfg.marker+" <answer>"),
+			),
+		)
+	}
+	prompt.Apply(cfg.promptOptions...)
+
+	response, err := l.Generate(ctx, prompt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate response: %w", err)
+	}
+
+	var result *ChainOfThoughtResult
+	if cfg.structured {
+		var parsed ChainOfThoughtResult
+		if err := json.Unmarshal([]byte(gollm.CleanResponse(response)), &parsed); err == nil && parsed.Answer != "" {
+			result = &parsed
+		} else {
+			result = &ChainOfThoughtResult{Reasoning: strings.TrimSpace(response)}
+		}
+	} else {
+		reasoning, answer, _ := splitFinalAnswer(response, cfg.marker)
+		result = &ChainOfThoughtResult{Reasoning: reasoning, Answer: answer}
+	}
+
+	if result.Answer == "" || cfg.alwaysExtract {
+		answer, err := extractFinalAnswer(ctx, l, question, result.Reasoning)
+		if err != nil {
+			return nil, err
+		}
+		result.Answer = answer
+	}
+	return result, nil
+}
+
+// splitFinalAnswer splits a response at the last occurrence of marker
+// (case-insensitive). It returns the whole response as reasoning and ok=false
+// when the marker is absent.
+func splitFinalAnswer(response, marker string) (reasoning, answer string, ok bool) {
+	idx := strings.LastIndex(strings.ToLower(response), strings.ToLower(marker))
+	if idx < 0 {
+		return strings.TrimSpace(response), "", false
+	}
+	answer = strings.TrimSpace(response[idx+len(marker):])
+	answer = strings.Trim(answer, "*_` ")
+	return strings.TrimSpace(response[:idx]), answer, answer != ""
+}
+
+// extractFinalAnswer runs a separate request that distills the final answer
+// from a reasoning trace.
+func extractFinalAnswer(ctx context.Context, l gollm.LLM, question, reasoning string) (string, error) {
+	prompt := gollm.NewPrompt(fmt.Sprintf("Question:\n%s\n\nReasoning:\n%s\n\nState the final answer to the question based on the reasoning above.", question, reasoning))
+	prompt.Apply(
+		gollm.WithDirectives(
+			"Respond with only the final answer",
+			"Do not repeat the reasoning or add explanations",
+		),
+	)
+	answer, err := l.Generate(ctx, prompt)
+	if err != nil {
+		return "", fmt.Errorf("failed to extract final answer: %w", err)
+	}
+	_, stripped, ok := splitFinalAnswer(answer, defaultAnswerMarker)
+	if ok {
+		return stripped, nil
+	}
+	return strings.TrimSpace(answer), nil
+}
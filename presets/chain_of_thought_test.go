@@ -0,0 +1,45 @@
+package presets
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm/gollmtest"
+)
+
+func TestChainOfThoughtWithAnswer(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Marker", func(t *testing.T) {
+		l := &gollmtest.ScriptedLLM{Responses: []string{"1. 17*6 = 102\n2. 23*4 = 92\n3. 102+92 = 194\n\n**Final Answer:** 194"}}
+		result, err := ChainOfThoughtWithAnswer(ctx, l, "What is (17 * 6) + (23 * 4)?")
+		require.NoError(t, err)
+		assert.Equal(t, "194", result.Answer)
+		assert.Contains(t, result.Reasoning, "102+92")
+		assert.NotContains(t, result.Reasoning, "Final Answer")
+		assert.Len(t, l.Prompts(), 1)
+	})
+
+	t.Run("ExtractionFallback", func(t *testing.T) {
+		l := &gollmtest.ScriptedLLM{Responses: []string{"Step 1: add things. The total is 194.", "194"}}
+		result, err := ChainOfThoughtWithAnswer(ctx, l, "What is (17 * 6) + (23 * 4)?")
+		require.NoError(t, err)
+		assert.Equal(t, "194", result.Answer)
+		require.Len(t, l.Prompts(), 2)
+		assert.Contains(t, l.Prompts()[1].Input, "Step 1: add things")
+	})
+
+	t.Run("Structured", func(t *testing.T) {
+		l := &gollmtest.ScriptedLLM{Responses: []string{"```json\n{\"reasoning\": \"102 + 92\", \"answer\": \"194\"}\n```"}}
+		result, err := ChainOfThoughtWithAnswer(ctx, l, "What is (17 * 6) + (23 * 4)?", WithStructuredReasoning())
+		require.NoError(t, err)
+		assert.Equal(t, &ChainOfThoughtResult{Reasoning: "102 + 92", Answer: "194"}, result)
+	})
+
+	t.Run("EmptyQuestion", func(t *testing.T) {
+		_, err := ChainOfThoughtWithAnswer(ctx, &gollmtest.ScriptedLLM{}, "  ")
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,236 @@
+// Package presets provides utilities for enhancing Language Learning Model interactions
+// with specific reasoning patterns and question-answering capabilities.
+package presets
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/teilomillet/gollm"
+)
+
+// Source is a document that QuestionAnswerWithSources answers from.
+type Source struct {
+	ID   string // Name, URL or identifier shown to the model, optional
+	Text string
+}
+
+// Citation is a passage of a source quoted to support an answer.
+type Citation struct {
+	Source int    `json:"source" validate:"gte=1" jsonschema:"description=Number of the cited source"`
+	Quote  string `json:"quote" validate:"required" jsonschema:"description=Passage copied verbatim from the source"`
+
+	// Verified reports whether the quote was found in the source
+	Verified bool `json:"-"`
+}
+
+// Claim is a sentence of a cited answer with the sources it cites.
+type Claim struct {
+	Text    string
+	Sources []int // Numbers of the cited sources, from 1
+
+	// Supported reports whether the claim cites at least one source and
+	// every source it cites has a verified quote
+	Supported bool
+}
+
+// CitedAnswer is the answer of QuestionAnswerWithSources.
+type CitedAnswer struct {
+	// Answer is the answer with inline citation markers such as [1]
+	Answer    string
+	Citations []Citation
+	Claims    []Claim
+
+	// References are the verified citations in the form of the citations of
+	// provider responses, for handling both alike
+	References []gollm.Citation
+}
+
+// Unsupported returns the claims without verified citations, which should be
+// checked or removed before the answer is shown.
+func (a *CitedAnswer) Unsupported() []Claim {
+	var unsupported []Claim
+	for _, c := range a.Claims {
+		if !c.Supported {
+			unsupported = append(unsupported, c)
+		}
+	}
+	return unsupported
+}
+
+// citedAnswerResponse is the JSON object requested from the model.
+type citedAnswerResponse struct {
+	Answer    string     `json:"answer" validate:"required" jsonschema:"description=The answer with a marker such as [1] after each statement, naming the sources supporting it"`
+	Citations []Citation `json:"citations" validate:"dive"`
+}
+
+// QuestionAnswerWithSourcesTemplate asks for an answer citing numbered
+// sources.
+var QuestionAnswerWithSourcesTemplate = gollm.NewPromptTemplate(
+	"QuestionAnswerWithSources",
+	"Answer the given question from the given sources, citing them",
+	"Answer the following question using only the sources below.\n\nQuestion: {{.Question}}\n\nSources:\n{{.Sources}}",
+	gollm.WithPromptOptions(
+		gollm.WithDirectives(
+			"Support every statement with an inline marker naming its sources, e.g. [1] or [1][3]",
+			"For every cited source, quote verbatim the passage supporting the answer",
+			"If the sources do not answer the question, say so instead of using other knowledge",
+		),
+	),
+)
+
+// citationMarker matches the inline citation markers of an answer.
+var citationMarker = regexp.MustCompile(`\[(\d+)\]`)
+
+// QuestionAnswerWithSources answers a question from the given sources and
+// enforces citations: the answer must cite the sources with inline markers
+// such as [1], and quote the passages it relies on. A response without
+// markers or citing unknown sources is sent back to the model for repair.
+// The quotes are then looked up in the sources, and each sentence of the
+// answer is reported as a Claim, supported only if its citations are
+// verified.
+//
+// Example usage:
+//
+//	answer, err := QuestionAnswerWithSources(ctx, llm, "When was the tower completed?", []Source{
+//	    {ID: "history.txt", Text: history},
+//	    {ID: "guide.txt", Text: guide},
+//	})
+//	fmt.Println(answer.Answer) // The tower was completed in 1889 [1].
+//	for _, claim := range answer.Unsupported() {
+//	    fmt.Println("unsupported:", claim.Text)
+//	}
+func QuestionAnswerWithSources(ctx context.Context, l gollm.LLM, question string, sources []Source, opts ...gollm.PromptOption) (*CitedAnswer, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context cannot be nil")
+	}
+	if l == nil {
+		return nil, fmt.Errorf("LLM instance cannot be nil")
+	}
+	if strings.TrimSpace(question) == "" {
+		return nil, fmt.Errorf("question cannot be empty")
+	}
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("at least one source is required")
+	}
+
+	var list strings.Builder
+	for i, s := range sources {
+		fmt.Fprintf(&list, "[%d]", i+1)
+		if s.ID != "" {
+			list.WriteString(" " + s.ID)
+		}
+		list.WriteString("\n" + strings.TrimSpace(s.Text) + "\n\n")
+	}
+	prompt, err := QuestionAnswerWithSourcesTemplate.Execute(map[string]interface{}{
+		"Question": question,
+		"Sources":  strings.TrimSpace(list.String()),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute question answer template: %w", err)
+	}
+	prompt.Apply(opts...)
+
+	response, err := GenerateStructured[citedAnswerResponse](ctx, l, prompt,
+		WithValidator(func(r *citedAnswerResponse) error {
+			return checkCitations(r, len(sources))
+		}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate cited answer: %w", err)
+	}
+	return verifyCitations(response, sources), nil
+}
+
+// checkCitations demands citation markers and only known sources.
+func checkCitations(r *citedAnswerResponse, sources int) error {
+	markers := citationMarker.FindAllStringSubmatch(r.Answer, -1)
+	if len(markers) == 0 {
+		return Violation{Field: "answer", Message: "must cite the sources with inline markers such as [1]"}
+	}
+	for _, m := range markers {
+		if n, _ := strconv.Atoi(m[1]); n < 1 || n > sources {
+			return Violation{Field: "answer", Message: fmt.Sprintf("cites unknown source [%d]; sources are numbered 1 to %d", n, sources)}
+		}
+	}
+	for i, c := range r.Citations {
+		if c.Source > sources {
+			return Violation{Field: fmt.Sprintf("citations[%d].source", i), Message: fmt.Sprintf("unknown source %d; sources are numbered 1 to %d", c.Source, sources)}
+		}
+	}
+	return nil
+}
+
+// verifyCitations looks up the quotes in the sources and flags the claims
+// without verified citations.
+func verifyCitations(r *citedAnswerResponse, sources []Source) *CitedAnswer {
+	answer := &CitedAnswer{Answer: strings.TrimSpace(r.Answer), Citations: r.Citations}
+	verified := make(map[int]bool)
+	for i, c := range answer.Citations {
+		quote := normalizeQuote(c.Quote)
+		if quote != "" && strings.Contains(normalizeQuote(sources[c.Source-1].Text), quote) {
+			answer.Citations[i].Verified = true
+			verified[c.Source] = true
+		}
+	}
+
+	for _, s := range splitSentences(answer.Answer) {
+		var cited []int
+		for _, m := range citationMarker.FindAllStringSubmatch(s.text, -1) {
+			n, _ := strconv.Atoi(m[1])
+			cited = append(cited, n)
+		}
+		if strings.TrimSpace(citationMarker.ReplaceAllString(s.text, "")) == "" && len(answer.Claims) > 0 {
+			// Markers placed after the period belong to the previous sentence
+			last := &answer.Claims[len(answer.Claims)-1]
+			last.Text += " " + s.text
+			last.Sources = append(last.Sources, cited...)
+			continue
+		}
+		answer.Claims = append(answer.Claims, Claim{Text: s.text, Sources: cited})
+	}
+	for i, c := range answer.Claims {
+		supported := len(c.Sources) > 0
+		for _, n := range c.Sources {
+			supported = supported && verified[n]
+		}
+		answer.Claims[i].Supported = supported
+	}
+
+	for _, c := range answer.Citations {
+		if !c.Verified {
+			continue
+		}
+		source := sources[c.Source-1]
+		reference := gollm.Citation{Quote: c.Quote, SourceID: source.ID}
+		if source.ID == "" {
+			reference.SourceID = strconv.Itoa(c.Source)
+		}
+		if strings.HasPrefix(source.ID, "http://") || strings.HasPrefix(source.ID, "https://") {
+			reference.URL = source.ID
+		}
+		var claims []string
+		for _, claim := range answer.Claims {
+			for _, n := range claim.Sources {
+				if n == c.Source {
+					claims = append(claims, claim.Text)
+					break
+				}
+			}
+		}
+		reference.Claim = strings.Join(claims, " ")
+		answer.References = append(answer.References, reference)
+	}
+	return answer
+}
+
+// normalizeQuote lowercases a quote and collapses its spaces, so that line
+// breaks and capitalization do not fail the lookup.
+func normalizeQuote(s string) string {
+	s = strings.Trim(strings.TrimSpace(s), `"'“”…`)
+	s = strings.TrimSuffix(strings.TrimPrefix(s, "..."), "...")
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
@@ -0,0 +1,67 @@
+package presets
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm/gollmtest"
+)
+
+func TestQuestionAnswerWithSources(t *testing.T) {
+	ctx := context.Background()
+	sources := []Source{
+		{ID: "history.txt", Text: "The Eiffel Tower was completed\nin 1889 for the World's Fair."},
+		{ID: "guide.txt", Text: "Visitors can climb to the second floor by stairs."},
+	}
+
+	t.Run("Verified", func(t *testing.T) {
+		l := &gollmtest.ScriptedLLM{Responses: []string{`{
+			"answer": "The tower was completed in 1889 [1]. It was built by aliens. Visitors can take the stairs. [2]",
+			"citations": [
+				{"source": 1, "quote": "completed in 1889"},
+				{"source": 2, "quote": "climb to the top by elevator"}
+			]
+		}`}}
+		answer, err := QuestionAnswerWithSources(ctx, l, "When was the tower completed?", sources)
+		require.NoError(t, err)
+		require.Len(t, l.Prompts(), 1)
+		assert.Contains(t, l.Prompts()[0].Input, "[2] guide.txt\nVisitors can climb")
+
+		assert.True(t, answer.Citations[0].Verified, "quotes match across line breaks")
+		assert.False(t, answer.Citations[1].Verified)
+
+		require.Len(t, answer.Claims, 3)
+		assert.True(t, answer.Claims[0].Supported)
+		assert.Equal(t, []int{1}, answer.Claims[0].Sources)
+		assert.False(t, answer.Claims[1].Supported, "claims without markers are unsupported")
+		assert.Equal(t, []int{2}, answer.Claims[2].Sources, "trailing markers belong to the previous sentence")
+		assert.False(t, answer.Claims[2].Supported, "claims citing unverified quotes are unsupported")
+		assert.Len(t, answer.Unsupported(), 2)
+
+		require.Len(t, answer.References, 1, "only verified citations are references")
+		assert.Equal(t, "history.txt", answer.References[0].SourceID)
+		assert.Equal(t, "completed in 1889", answer.References[0].Quote)
+		assert.Equal(t, "The tower was completed in 1889 [1].", answer.References[0].Claim)
+	})
+
+	t.Run("RepairsMissingMarkers", func(t *testing.T) {
+		l := &gollmtest.ScriptedLLM{Responses: []string{
+			`{"answer": "It was completed in 1889.", "citations": []}`,
+			`{"answer": "It was completed in 1889 [3].", "citations": []}`,
+			`{"answer": "It was completed in 1889 [1].", "citations": [{"source": 1, "quote": "in 1889"}]}`,
+		}}
+		answer, err := QuestionAnswerWithSources(ctx, l, "When was the tower completed?", sources)
+		require.NoError(t, err)
+		require.Len(t, l.Prompts(), 3)
+		assert.Contains(t, l.Prompts()[1].Input, "inline markers")
+		assert.Contains(t, l.Prompts()[2].Input, "unknown source [3]")
+		assert.Empty(t, answer.Unsupported())
+	})
+
+	t.Run("NoSources", func(t *testing.T) {
+		_, err := QuestionAnswerWithSources(ctx, &gollmtest.ScriptedLLM{}, "Why?", nil)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,210 @@
+// Package presets provides utilities for enhancing Language Learning Model interactions
+// with specific reasoning patterns and text processing capabilities.
+package presets
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/teilomillet/gollm"
+	"github.com/teilomillet/gollm/llm"
+)
+
+// Classification is the label given to a text by Classify.
+type Classification struct {
+	Label     string `json:"label" validate:"required" jsonschema:"description=One of the allowed labels, exactly as written"`
+	Rationale string `json:"rationale" jsonschema:"description=One or two sentences explaining the choice"`
+
+	// Confidence is the probability of the label, or 0 unless the LLM
+	// returns log probabilities (see config.SetLogprobs)
+	Confidence float64 `json:"-"`
+}
+
+// classifyConfig holds the settings of Classify and ClassifyBatch.
+type classifyConfig struct {
+	descriptions  map[string]string
+	concurrency   int
+	promptOptions []gollm.PromptOption
+}
+
+// ClassifyOption configures Classify and ClassifyBatch.
+type ClassifyOption func(*classifyConfig)
+
+// WithLabelDescriptions explains the labels to the model, e.g.
+// {"billing": "payments, invoices and refunds"}.
+func WithLabelDescriptions(descriptions map[string]string) ClassifyOption {
+	return func(c *classifyConfig) {
+		c.descriptions = descriptions
+	}
+}
+
+// WithClassifyConcurrency limits how many texts ClassifyBatch classifies in
+// parallel. Defaults to 4.
+func WithClassifyConcurrency(n int) ClassifyOption {
+	return func(c *classifyConfig) {
+		c.concurrency = n
+	}
+}
+
+// WithClassifyPromptOptions applies prompt options to every classification
+// request.
+func WithClassifyPromptOptions(opts ...gollm.PromptOption) ClassifyOption {
+	return func(c *classifyConfig) {
+		c.promptOptions = append(c.promptOptions, opts...)
+	}
+}
+
+// classifyTemplate asks for the label of a text.
+var classifyTemplate = gollm.NewPromptTemplate(
+	"Classify",
+	"Classify the given text",
+	"Classify the following text with exactly one of these labels:\n{{.Labels}}\n\nText:\n{{.Text}}",
+	gollm.WithPromptOptions(
+		gollm.WithDirectives(
+			"Choose the single label that fits best, even if none fits perfectly",
+			"Never invent a label outside the list",
+		),
+	),
+)
+
+// Classify assigns one of the labels to a text and explains the choice. The
+// label is constrained to the set: a response with another label is sent
+// back to the model for repair, and the label returned is always one of
+// labels, in its original case.
+//
+// Example usage:
+//
+//	result, err := Classify(ctx, llm, ticket, []string{"billing", "bug", "feature request"},
+//	    WithLabelDescriptions(map[string]string{"billing": "payments, invoices and refunds"}),
+//	)
+//	fmt.Println(result.Label, "-", result.Rationale)
+func Classify(ctx context.Context, l gollm.LLM, text string, labels []string, opts ...ClassifyOption) (*Classification, error) {
+	if err := validateClassifyInput(ctx, l, labels); err != nil {
+		return nil, err
+	}
+	cfg := newClassifyConfig(opts)
+	return classify(ctx, l, text, labels, cfg)
+}
+
+// ClassifyBatch classifies each text like Classify, concurrently, and returns
+// the classifications in the order of the texts.
+//
+// Example usage:
+//
+//	results, err := ClassifyBatch(ctx, llm, reviews, []string{"positive", "negative", "neutral"},
+//	    WithClassifyConcurrency(8),
+//	)
+func ClassifyBatch(ctx context.Context, l gollm.LLM, texts []string, labels []string, opts ...ClassifyOption) ([]*Classification, error) {
+	if err := validateClassifyInput(ctx, l, labels); err != nil {
+		return nil, err
+	}
+	cfg := newClassifyConfig(opts)
+
+	results := make([]*Classification, len(texts))
+	errs := make([]error, len(texts))
+	sem := make(chan struct{}, cfg.concurrency)
+	var wg sync.WaitGroup
+	for i, text := range texts {
+		wg.Add(1)
+		go func(i int, text string) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				errs[i] = ctx.Err()
+				return
+			}
+			results[i], errs[i] = classify(ctx, l, text, labels, cfg)
+		}(i, text)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("failed to classify text %d of %d: %w", i+1, len(texts), err)
+		}
+	}
+	return results, nil
+}
+
+func validateClassifyInput(ctx context.Context, l gollm.LLM, labels []string) error {
+	if ctx == nil {
+		return fmt.Errorf("context cannot be nil")
+	}
+	if l == nil {
+		return fmt.Errorf("LLM instance cannot be nil")
+	}
+	if len(labels) < 2 {
+		return fmt.Errorf("at least two labels are required")
+	}
+	return nil
+}
+
+func newClassifyConfig(opts []ClassifyOption) *classifyConfig {
+	cfg := &classifyConfig{concurrency: 4}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if cfg.concurrency < 1 {
+		cfg.concurrency = 1
+	}
+	return cfg
+}
+
+// classify classifies a single text.
+func classify(ctx context.Context, l gollm.LLM, text string, labels []string, cfg *classifyConfig) (*Classification, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, fmt.Errorf("text cannot be empty")
+	}
+	var list strings.Builder
+	for _, label := range labels {
+		list.WriteString("- " + label)
+		if description := cfg.descriptions[label]; description != "" {
+			list.WriteString(": " + description)
+		}
+		list.WriteString("\n")
+	}
+	prompt, err := classifyTemplate.Execute(map[string]interface{}{
+		"Labels": strings.TrimSuffix(list.String(), "\n"),
+		"Text":   text,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute classify template: %w", err)
+	}
+	prompt.Apply(cfg.promptOptions...)
+
+	var logprobs []llm.TokenLogprob
+	result, err := GenerateStructured[Classification](ctx, l, prompt,
+		WithStructuredGenerateOptions(llm.WithLogprobs(&logprobs)),
+		WithFieldValidator("label", func(value interface{}) error {
+			s, _ := value.(string)
+			if matchLabel(s, labels) == "" {
+				return fmt.Errorf("must be one of: %s", strings.Join(labels, ", "))
+			}
+			return nil
+		}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to classify text: %w", err)
+	}
+	result.Label = matchLabel(result.Label, labels)
+	result.Rationale = strings.TrimSpace(result.Rationale)
+	if confidence, ok := valueConfidence(logprobs)["label"]; ok {
+		result.Confidence = confidence
+	}
+	return result, nil
+}
+
+// matchLabel returns the label matching s regardless of case and surrounding
+// spaces, or an empty string.
+func matchLabel(s string, labels []string) string {
+	s = strings.TrimSpace(s)
+	for _, label := range labels {
+		if strings.EqualFold(s, label) {
+			return label
+		}
+	}
+	return ""
+}
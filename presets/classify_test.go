@@ -0,0 +1,78 @@
+package presets
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm"
+	"github.com/teilomillet/gollm/gollmtest"
+	"github.com/teilomillet/gollm/llm"
+)
+
+// keywordLLM labels texts mentioning "crash" as bugs and the others as
+// billing, whatever order the requests arrive in.
+type keywordLLM struct {
+	gollm.LLM
+}
+
+func (keywordLLM) Generate(ctx context.Context, prompt *llm.Prompt, opts ...llm.GenerateOption) (string, error) {
+	if strings.Contains(prompt.Input, "crash") {
+		return `{"label": "Bug", "rationale": "Crash."}`, nil
+	}
+	return `{"label": "Billing", "rationale": "Invoice."}`, nil
+}
+
+func TestClassify(t *testing.T) {
+	ctx := context.Background()
+	labels := []string{"Billing", "Bug", "Feature request"}
+
+	t.Run("Label", func(t *testing.T) {
+		l := &gollmtest.ScriptedLLM{Responses: []string{`{"label": "billing", "rationale": " Asks about a refund. "}`}}
+		result, err := Classify(ctx, l, "I was charged twice, please refund me.", labels,
+			WithLabelDescriptions(map[string]string{"Billing": "payments and refunds"}),
+		)
+		require.NoError(t, err)
+		assert.Equal(t, "Billing", result.Label)
+		assert.Equal(t, "Asks about a refund.", result.Rationale)
+		assert.Zero(t, result.Confidence)
+		require.Len(t, l.Prompts(), 1)
+		assert.Contains(t, l.Prompts()[0].Input, "- Billing: payments and refunds\n- Bug\n- Feature request")
+	})
+
+	t.Run("RepairsUnknownLabel", func(t *testing.T) {
+		l := &gollmtest.ScriptedLLM{Responses: []string{
+			`{"label": "Question", "rationale": "It is a question."}`,
+			`{"label": "Feature request", "rationale": "Asks for dark mode."}`,
+		}}
+		result, err := Classify(ctx, l, "Could you add a dark mode?", labels)
+		require.NoError(t, err)
+		assert.Equal(t, "Feature request", result.Label)
+		require.Len(t, l.Prompts(), 2)
+		assert.Contains(t, l.Prompts()[1].Input, "must be one of: Billing, Bug, Feature request")
+	})
+
+	t.Run("Batch", func(t *testing.T) {
+		texts := []string{"The app crashes.", "Where is my invoice?", "It crashed again.", "Refund please."}
+		results, err := ClassifyBatch(ctx, keywordLLM{}, texts, labels, WithClassifyConcurrency(2))
+		require.NoError(t, err)
+		require.Len(t, results, 4)
+		assert.Equal(t, "Bug", results[0].Label)
+		assert.Equal(t, "Billing", results[1].Label)
+		assert.Equal(t, "Bug", results[2].Label)
+		assert.Equal(t, "Billing", results[3].Label)
+	})
+
+	t.Run("BatchError", func(t *testing.T) {
+		l := &gollmtest.ScriptedLLM{Responses: []string{`{"label": "Bug", "rationale": "Crash."}`}}
+		_, err := ClassifyBatch(ctx, l, []string{"The app crashes.", "Where is my invoice?"}, labels)
+		assert.Error(t, err)
+	})
+
+	t.Run("TooFewLabels", func(t *testing.T) {
+		_, err := Classify(ctx, &gollmtest.ScriptedLLM{}, "text", []string{"only"})
+		assert.Error(t, err)
+	})
+}
@@ -5,6 +5,7 @@ package presets
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 
@@ -12,6 +13,7 @@ import (
 	"github.com/teilomillet/gollm/llm"
 	"github.com/teilomillet/gollm/providers"
 	"github.com/teilomillet/gollm/utils"
+	"golang.org/x/sync/errgroup"
 )
 
 // ComparisonResult represents the outcome of a model comparison for a specific provider.
@@ -59,11 +61,16 @@ type ValidateFunc[T any] func(T) error
 // and validation of responses.
 //
 // The function:
-// 1. Attempts to generate responses from all models
+// 1. Attempts to generate responses from all models concurrently
 // 2. Cleans and parses JSON responses
 // 3. Validates responses using the provided validation function
 // 4. Retries failed attempts up to 3 times
 //
+// Models that still fail are reported in the Error of their result, and the
+// results of the others are kept. An error is returned when every model
+// failed, or when a model reports an API error such as an invalid key, which
+// cancels the models still running.
+//
 // Type parameter T represents the expected response structure.
 //
 // Parameters:
@@ -132,6 +139,20 @@ type ValidateFunc[T any] func(T) error
 //	analysis := AnalyzeComparisonResults(results)
 //	fmt.Println(analysis)
 func CompareModels[T any](ctx context.Context, prompt string, validateFunc ValidateFunc[T], configs ...*config.Config) ([]ComparisonResult[T], error) {
+	return CompareModelsWithOptions(ctx, prompt, validateFunc, configs)
+}
+
+// CompareModelsWithOptions is CompareModels configured by harness options.
+// WithHarnessConcurrency limits how many models run at once, all by default;
+// WithHarnessTimeout bounds each model across its attempts; and
+// WithHarnessGenerateOptions adds options to every request.
+//
+// Example usage:
+//
+//	// A model that hangs fails on its own after a minute
+//	results, err := presets.CompareModelsWithOptions(ctx, prompt, validatePerson, configs,
+//	    presets.WithHarnessTimeout(time.Minute))
+func CompareModelsWithOptions[T any](ctx context.Context, prompt string, validateFunc ValidateFunc[T], configs []*config.Config, opts ...HarnessOption) ([]ComparisonResult[T], error) {
 	// Validate inputs
 	if prompt == "" {
 		return nil, fmt.Errorf("prompt cannot be empty")
@@ -143,97 +164,108 @@ func CompareModels[T any](ctx context.Context, prompt string, validateFunc Valid
 		return nil, fmt.Errorf("at least one config must be provided")
 	}
 
-	results := make([]ComparisonResult[T], len(configs))
-	remainingConfigs := make([]*config.Config, len(configs))
-	copy(remainingConfigs, configs)
+	cfg := &harnessConfig{concurrency: len(configs)}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if cfg.concurrency < 1 {
+		cfg.concurrency = 1
+	}
 
+	results := make([]ComparisonResult[T], len(configs))
 	logger := utils.NewLogger(utils.LogLevelDebug)
 
-	for attempt := 1; attempt <= 3; attempt++ {
-		if len(remainingConfigs) == 0 {
-			break
+	// An API error fails the comparison and cancels the other models; other
+	// failures are only recorded in their result
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(cfg.concurrency)
+	for i, config := range configs {
+		result := &results[i]
+		result.Provider = config.Provider
+		result.Model = config.Model
+		g.Go(func() error {
+			branchCtx := gctx
+			if cfg.timeout > 0 {
+				var cancel context.CancelFunc
+				branchCtx, cancel = context.WithTimeout(gctx, cfg.timeout)
+				defer cancel()
+			}
+			return compareModel(branchCtx, prompt, validateFunc, config, logger, cfg.opts, result)
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return results, err
+	}
+
+	var failures []error
+	for _, result := range results {
+		if result.Error != nil {
+			failures = append(failures, fmt.Errorf("%s %s: %w", result.Provider, result.Model, result.Error))
 		}
+	}
+	if len(failures) == len(results) {
+		return results, fmt.Errorf("all models failed: %w", errors.Join(failures...))
+	}
+	return results, nil
+}
 
-		newRemainingConfigs := []*config.Config{}
+// compareModel generates and validates the response of a single model,
+// retrying up to 3 times, and records the outcome in result. It only returns
+// the API errors that should stop the whole comparison.
+func compareModel[T any](ctx context.Context, prompt string, validateFunc ValidateFunc[T], config *config.Config, logger utils.Logger, opts []llm.GenerateOption, result *ComparisonResult[T]) error {
+	registry := providers.NewProviderRegistry()
+	llmInstance, err := llm.NewLLM(config, logger, registry)
+	if err != nil {
+		return fmt.Errorf("failed to create LLM for %s: %w", config.Provider, err)
+	}
 
-		for _, config := range remainingConfigs {
-			debugLog(config, "Attempting generation for %s %s (Attempt %d)", config.Provider, config.Model, attempt)
+	for attempt := 1; attempt <= 3; attempt++ {
+		debugLog(config, "Attempting generation for %s %s (Attempt %d)", config.Provider, config.Model, attempt)
+		result.Attempts = attempt
 
-			registry := providers.NewProviderRegistry()
-			llmInstance, err := llm.NewLLM(config, logger, registry)
-			if err != nil {
-				return nil, fmt.Errorf("failed to create LLM for %s: %w", config.Provider, err)
+		response, err := llmInstance.Generate(ctx, llm.NewPrompt(prompt), opts...)
+		result.Response = response
+		result.Error = err
+		if err != nil {
+			debugLog(config, "Error generating response: %v", err)
+			// Immediately propagate API errors (like invalid keys)
+			if strings.Contains(err.Error(), "API error") {
+				return fmt.Errorf("API error for %s %s: %w", config.Provider, config.Model, err)
 			}
-
-			response, err := llmInstance.Generate(ctx, llm.NewPrompt(prompt))
-
-			index := findConfigIndex(configs, config)
-			results[index].Provider = config.Provider
-			results[index].Model = config.Model
-			results[index].Response = response
-			results[index].Error = err
-			results[index].Attempts = attempt
-
-			if err != nil {
-				debugLog(config, "Error generating response: %v", err)
-				// Immediately propagate API errors (like invalid keys)
-				if strings.Contains(err.Error(), "API error") {
-					return nil, fmt.Errorf("API error for %s %s: %w", config.Provider, config.Model, err)
-				}
-				if attempt == 3 {
-					return nil, fmt.Errorf("failed to generate response after all attempts: %w", err)
-				}
-				newRemainingConfigs = append(newRemainingConfigs, config)
-				continue
+			if ctx.Err() != nil {
+				return nil
 			}
-
-			debugLog(config, "Raw response received: %s", response)
-
-			cleanedResponse := cleanResponse(response)
-			debugLog(config, "Cleaned response: %s", cleanedResponse)
-
-			results[index].Response = cleanedResponse
-
-			var data T
-			if err := json.Unmarshal([]byte(cleanedResponse), &data); err != nil {
-				debugLog(config, "Invalid JSON: %v", err)
-				results[index].Error = fmt.Errorf("invalid JSON: %w", err)
-				if attempt == 3 {
-					return nil, fmt.Errorf("failed to parse JSON after all attempts: %w", err)
-				}
-				newRemainingConfigs = append(newRemainingConfigs, config)
-				continue
+			if attempt == 3 {
+				result.Error = fmt.Errorf("failed to generate response after all attempts: %w", err)
 			}
+			continue
+		}
 
-			if err := validateFunc(data); err != nil {
-				debugLog(config, "Validation failed: %v", err)
-				results[index].Error = fmt.Errorf("validation failed: %w", err)
-				if attempt == 3 {
-					return nil, fmt.Errorf("validation failed after all attempts: %w", err)
-				}
-				newRemainingConfigs = append(newRemainingConfigs, config)
-				continue
-			}
+		debugLog(config, "Raw response received: %s", response)
 
-			results[index].Data = data
-			debugLog(config, "Valid response received for %s %s", config.Provider, config.Model)
-		}
+		cleanedResponse := cleanResponse(response)
+		debugLog(config, "Cleaned response: %s", cleanedResponse)
 
-		remainingConfigs = newRemainingConfigs
-	}
+		result.Response = cleanedResponse
 
-	return results, nil
-}
+		var data T
+		if err := json.Unmarshal([]byte(cleanedResponse), &data); err != nil {
+			debugLog(config, "Invalid JSON: %v", err)
+			result.Error = fmt.Errorf("invalid JSON: %w", err)
+			continue
+		}
 
-// findConfigIndex finds the index of a config in the original config slice.
-// This helps maintain result ordering consistent with input configs.
-func findConfigIndex(configs []*config.Config, target *config.Config) int {
-	for i, config := range configs {
-		if config.Provider == target.Provider && config.Model == target.Model {
-			return i
+		if err := validateFunc(data); err != nil {
+			debugLog(config, "Validation failed: %v", err)
+			result.Error = fmt.Errorf("validation failed: %w", err)
+			continue
 		}
+
+		result.Data = data
+		debugLog(config, "Valid response received for %s %s", config.Provider, config.Model)
+		return nil
 	}
-	return -1
+	return nil
 }
 
 // AnalyzeComparisonResults generates a formatted analysis of comparison results.
@@ -0,0 +1,346 @@
+package presets
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/teilomillet/gollm/config"
+	"github.com/teilomillet/gollm/eval"
+	"github.com/teilomillet/gollm/llm"
+	"github.com/teilomillet/gollm/providers"
+	"github.com/teilomillet/gollm/utils"
+	"golang.org/x/sync/errgroup"
+)
+
+// ComparisonCase is a prompt of the comparison prompt set.
+type ComparisonCase struct {
+	// ID identifies the case in reports; defaults to its position in the set
+	ID string `json:"id,omitempty"`
+
+	// Prompt is sent unchanged to every target
+	Prompt string `json:"prompt"`
+
+	// Reference is an optional known-good answer passed to the judge
+	Reference string `json:"reference,omitempty"`
+}
+
+// ComparisonTarget is a provider and model taking part in a comparison.
+type ComparisonTarget struct {
+	Provider string
+	Model    string
+	LLM      llm.LLM
+}
+
+// ModelPricing is the price of a model in USD per million tokens.
+type ModelPricing struct {
+	InputPerMillion  float64 `json:"input_per_million"`
+	OutputPerMillion float64 `json:"output_per_million"`
+}
+
+// ComparisonRun is the outcome of one case on one target.
+type ComparisonRun struct {
+	Provider   string        `json:"provider"`
+	Model      string        `json:"model"`
+	CaseID     string        `json:"case_id"`
+	Response   string        `json:"response,omitempty"`
+	Latency    time.Duration `json:"latency"`
+	Usage      llm.Usage     `json:"usage"`
+	Cost       float64       `json:"cost"`            // USD, zero without pricing for the model
+	Score      *float64      `json:"score,omitempty"` // Judge's overall score from 0 to 1, nil when not judged
+	Error      string        `json:"error,omitempty"` // Generation error
+	JudgeError string        `json:"judge_error,omitempty"`
+}
+
+// ModelSummary aggregates the runs of one target.
+type ModelSummary struct {
+	Provider     string        `json:"provider"`
+	Model        string        `json:"model"`
+	Runs         int           `json:"runs"`
+	Failures     int           `json:"failures"`
+	MeanLatency  time.Duration `json:"mean_latency"`
+	P95Latency   time.Duration `json:"p95_latency"`
+	InputTokens  int           `json:"input_tokens"`
+	OutputTokens int           `json:"output_tokens"`
+	TotalCost    float64       `json:"total_cost"`
+	MeanScore    *float64      `json:"mean_score,omitempty"` // nil when no run was judged
+}
+
+// ComparisonReport is the structured result of RunComparison.
+type ComparisonReport struct {
+	// Runs holds one entry per case and target, ordered by case then target
+	Runs []ComparisonRun `json:"runs"`
+
+	// Summaries holds one entry per target, in target order
+	Summaries []ModelSummary `json:"summaries"`
+}
+
+// harnessConfig holds the settings of a comparison run.
+type harnessConfig struct {
+	concurrency int
+	timeout     time.Duration
+	pricing     map[string]ModelPricing
+	judge       *eval.Judge
+	opts        []llm.GenerateOption
+}
+
+// HarnessOption configures RunComparison and CompareProviders.
+type HarnessOption func(*harnessConfig)
+
+// WithHarnessConcurrency limits how many requests run in parallel across all
+// targets. Defaults to 4.
+func WithHarnessConcurrency(n int) HarnessOption {
+	return func(c *harnessConfig) {
+		c.concurrency = n
+	}
+}
+
+// WithHarnessTimeout bounds each request, and the judging of its response,
+// so a hung provider only fails its own runs. Defaults to no limit.
+func WithHarnessTimeout(timeout time.Duration) HarnessOption {
+	return func(c *harnessConfig) {
+		c.timeout = timeout
+	}
+}
+
+// WithPricing sets the prices used to compute the cost of each run. Keys are
+// either "provider/model" or a bare model name; the former takes precedence.
+func WithPricing(pricing map[string]ModelPricing) HarnessOption {
+	return func(c *harnessConfig) {
+		c.pricing = pricing
+	}
+}
+
+// WithHarnessJudge scores every successful response with the judge.
+func WithHarnessJudge(judge *eval.Judge) HarnessOption {
+	return func(c *harnessConfig) {
+		c.judge = judge
+	}
+}
+
+// WithHarnessGenerateOptions adds options to every generation request.
+func WithHarnessGenerateOptions(opts ...llm.GenerateOption) HarnessOption {
+	return func(c *harnessConfig) {
+		c.opts = append(c.opts, opts...)
+	}
+}
+
+// RunComparison sends every case to every target concurrently and collects
+// the responses with their latency, token usage, cost and optional judge
+// score. Failed requests are recorded in the report instead of aborting the
+// comparison; an error is only returned for invalid input or when the context
+// is cancelled.
+//
+// Example usage:
+//
+//	judge, _ := eval.NewJudge(judgeLLM, eval.WithRubric(eval.Correctness))
+//	report, err := presets.RunComparison(ctx, cases, targets,
+//	    presets.WithHarnessJudge(judge),
+//	    presets.WithPricing(map[string]presets.ModelPricing{
+//	        "gpt-4o-mini": {InputPerMillion: 0.15, OutputPerMillion: 0.6},
+//	    }),
+//	)
+//	report.WriteCSV(os.Stdout)
+func RunComparison(ctx context.Context, cases []ComparisonCase, targets []ComparisonTarget, opts ...HarnessOption) (*ComparisonReport, error) {
+	if len(cases) == 0 {
+		return nil, fmt.Errorf("at least one case must be provided")
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("at least one target must be provided")
+	}
+	for i, target := range targets {
+		if target.LLM == nil {
+			return nil, fmt.Errorf("target %d has no LLM", i)
+		}
+	}
+
+	cfg := &harnessConfig{concurrency: 4}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if cfg.concurrency < 1 {
+		cfg.concurrency = 1
+	}
+
+	report := &ComparisonReport{Runs: make([]ComparisonRun, len(cases)*len(targets))}
+	// Runs record their own errors, so a failed run never cancels the others
+	var g errgroup.Group
+	g.SetLimit(cfg.concurrency)
+	for i, c := range cases {
+		if c.ID == "" {
+			c.ID = strconv.Itoa(i + 1)
+		}
+		for j, target := range targets {
+			run := &report.Runs[i*len(targets)+j]
+			*run = ComparisonRun{Provider: target.Provider, Model: target.Model, CaseID: c.ID}
+			g.Go(func() error {
+				if err := ctx.Err(); err != nil {
+					run.Error = err.Error()
+					return nil
+				}
+				cfg.execute(ctx, run, c, target)
+				return nil
+			})
+		}
+	}
+	_ = g.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return report, err
+	}
+	report.Summaries = summarizeRuns(report.Runs, targets)
+	return report, nil
+}
+
+// execute runs a single case on a target and fills in the run.
+func (cfg *harnessConfig) execute(ctx context.Context, run *ComparisonRun, c ComparisonCase, target ComparisonTarget) {
+	if cfg.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.timeout)
+		defer cancel()
+	}
+	var usage llm.Usage
+	opts := append([]llm.GenerateOption{llm.WithUsage(&usage)}, cfg.opts...)
+
+	start := time.Now()
+	response, err := target.LLM.Generate(ctx, llm.NewPrompt(c.Prompt), opts...)
+	run.Latency = time.Since(start)
+	run.Usage = usage
+	if err != nil {
+		run.Error = err.Error()
+		return
+	}
+	run.Response = response
+	if pricing, ok := cfg.priceFor(target); ok {
+		run.Cost = (float64(usage.InputTokens)*pricing.InputPerMillion + float64(usage.OutputTokens)*pricing.OutputPerMillion) / 1e6
+	}
+
+	if cfg.judge == nil {
+		return
+	}
+	judgement, err := cfg.judge.Score(ctx, eval.Sample{ID: c.ID, Input: c.Prompt, Output: response, Reference: c.Reference})
+	if err != nil {
+		run.JudgeError = err.Error()
+		return
+	}
+	score := judgement.Overall
+	run.Score = &score
+}
+
+// priceFor looks up the pricing of a target.
+func (cfg *harnessConfig) priceFor(target ComparisonTarget) (ModelPricing, bool) {
+	if pricing, ok := cfg.pricing[target.Provider+"/"+target.Model]; ok {
+		return pricing, true
+	}
+	pricing, ok := cfg.pricing[target.Model]
+	return pricing, ok
+}
+
+// summarizeRuns aggregates the runs of each target. Latencies only include
+// successful runs.
+func summarizeRuns(runs []ComparisonRun, targets []ComparisonTarget) []ModelSummary {
+	summaries := make([]ModelSummary, len(targets))
+	for j, target := range targets {
+		summary := ModelSummary{Provider: target.Provider, Model: target.Model}
+		var latencies []time.Duration
+		var scoreTotal float64
+		scored := 0
+		for i := j; i < len(runs); i += len(targets) {
+			run := runs[i]
+			summary.Runs++
+			summary.InputTokens += run.Usage.InputTokens
+			summary.OutputTokens += run.Usage.OutputTokens
+			summary.TotalCost += run.Cost
+			if run.Error != "" {
+				summary.Failures++
+				continue
+			}
+			latencies = append(latencies, run.Latency)
+			if run.Score != nil {
+				scoreTotal += *run.Score
+				scored++
+			}
+		}
+		if len(latencies) > 0 {
+			var total time.Duration
+			for _, latency := range latencies {
+				total += latency
+			}
+			summary.MeanLatency = total / time.Duration(len(latencies))
+			sort.Slice(latencies, func(a, b int) bool { return latencies[a] < latencies[b] })
+			summary.P95Latency = latencies[(len(latencies)*95+99)/100-1]
+		}
+		if scored > 0 {
+			mean := scoreTotal / float64(scored)
+			summary.MeanScore = &mean
+		}
+		summaries[j] = summary
+	}
+	return summaries
+}
+
+// WriteJSON writes the report as indented JSON. Latencies are in nanoseconds.
+func (r *ComparisonReport) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// WriteCSV writes one row per run, with latency in milliseconds.
+func (r *ComparisonReport) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	header := []string{"provider", "model", "case_id", "latency_ms", "input_tokens", "output_tokens", "cost", "score", "error", "response"}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for _, run := range r.Runs {
+		score := ""
+		if run.Score != nil {
+			score = strconv.FormatFloat(*run.Score, 'f', 4, 64)
+		}
+		errMsg := run.Error
+		if errMsg == "" {
+			errMsg = run.JudgeError
+		}
+		record := []string{
+			run.Provider,
+			run.Model,
+			run.CaseID,
+			strconv.FormatInt(run.Latency.Milliseconds(), 10),
+			strconv.Itoa(run.Usage.InputTokens),
+			strconv.Itoa(run.Usage.OutputTokens),
+			strconv.FormatFloat(run.Cost, 'f', 6, 64),
+			score,
+			errMsg,
+			run.Response,
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// CompareProviders builds a target for each configuration and runs the
+// comparison. See RunComparison.
+func CompareProviders(ctx context.Context, cases []ComparisonCase, configs []*config.Config, opts ...HarnessOption) (*ComparisonReport, error) {
+	if len(configs) == 0 {
+		return nil, fmt.Errorf("at least one config must be provided")
+	}
+	registry := providers.NewProviderRegistry()
+	targets := make([]ComparisonTarget, len(configs))
+	for i, cfg := range configs {
+		instance, err := llm.NewLLM(cfg, utils.NewLogger(cfg.LogLevel), registry)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create LLM for %s %s: %w", cfg.Provider, cfg.Model, err)
+		}
+		targets[i] = ComparisonTarget{Provider: cfg.Provider, Model: cfg.Model, LLM: instance}
+	}
+	return RunComparison(ctx, cases, targets, opts...)
+}
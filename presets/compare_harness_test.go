@@ -0,0 +1,116 @@
+package presets
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm"
+	"github.com/teilomillet/gollm/eval"
+	"github.com/teilomillet/gollm/gollmtest"
+)
+
+func mockTarget(t *testing.T, model string) (ComparisonTarget, *gollm.MockProvider) {
+	t.Helper()
+	l, mock := gollmtest.NewMockLLM(t, gollm.SetModel(model))
+	return ComparisonTarget{Provider: "mock", Model: model, LLM: l}, mock
+}
+
+func TestRunComparison(t *testing.T) {
+	ctx := context.Background()
+	fast, fastMock := mockTarget(t, "fast")
+	slow, slowMock := mockTarget(t, "slow")
+	fastMock.SetDefaultResponse("Paris is the capital")
+	slowMock.SetResponder(func(call gollm.MockCall) (string, error) {
+		if strings.Contains(call.Prompt, "Italy") {
+			return "", assert.AnError
+		}
+		return "Paris", nil
+	})
+
+	judgeResponse := `{"scores": [{"criterion": "correctness", "reasoning": "ok", "score": 8}]}`
+	judge, err := eval.NewJudge(&gollmtest.ScriptedLLM{Responses: []string{judgeResponse, judgeResponse, judgeResponse}}, eval.WithRubric(eval.Correctness))
+	require.NoError(t, err)
+
+	cases := []ComparisonCase{
+		{ID: "capital", Prompt: "Capital of France?", Reference: "Paris"},
+		{Prompt: "Capital of Italy?"},
+	}
+	report, err := RunComparison(ctx, cases, []ComparisonTarget{fast, slow},
+		WithHarnessConcurrency(1),
+		WithHarnessJudge(judge),
+		WithPricing(map[string]ModelPricing{
+			"fast":      {InputPerMillion: 1e6, OutputPerMillion: 2e6},
+			"mock/slow": {InputPerMillion: 1e6},
+		}),
+	)
+	require.NoError(t, err)
+	require.Len(t, report.Runs, 4)
+
+	first := report.Runs[0]
+	assert.Equal(t, "fast", first.Model)
+	assert.Equal(t, "capital", first.CaseID)
+	assert.Equal(t, "Paris is the capital", first.Response)
+	assert.Positive(t, first.Usage.InputTokens)
+	assert.Equal(t, 4, first.Usage.OutputTokens)
+	assert.InDelta(t, float64(first.Usage.InputTokens)+8, first.Cost, 1e-9)
+	require.NotNil(t, first.Score)
+	assert.InDelta(t, 0.8, *first.Score, 1e-9)
+
+	assert.Equal(t, "2", report.Runs[2].CaseID)
+	assert.NotEmpty(t, report.Runs[3].Error)
+	assert.Nil(t, report.Runs[3].Score)
+
+	require.Len(t, report.Summaries, 2)
+	assert.Equal(t, 2, report.Summaries[0].Runs)
+	assert.Equal(t, 0, report.Summaries[0].Failures)
+	assert.Equal(t, 8, report.Summaries[0].OutputTokens)
+	assert.Equal(t, 1, report.Summaries[1].Failures)
+	require.NotNil(t, report.Summaries[1].MeanScore)
+
+	var buf bytes.Buffer
+	require.NoError(t, report.WriteJSON(&buf))
+	var decoded ComparisonReport
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, report.Runs[0].Response, decoded.Runs[0].Response)
+
+	buf.Reset()
+	require.NoError(t, report.WriteCSV(&buf))
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 5)
+	assert.Equal(t, "provider", records[0][0])
+	assert.Equal(t, "0.8000", records[1][7])
+}
+
+func TestRunComparisonInvalidInput(t *testing.T) {
+	ctx := context.Background()
+	target, _ := mockTarget(t, "m")
+
+	_, err := RunComparison(ctx, nil, []ComparisonTarget{target})
+	assert.Error(t, err)
+	_, err = RunComparison(ctx, []ComparisonCase{{Prompt: "hi"}}, nil)
+	assert.Error(t, err)
+	_, err = RunComparison(ctx, []ComparisonCase{{Prompt: "hi"}}, []ComparisonTarget{{Model: "nil"}})
+	assert.Error(t, err)
+}
+
+func TestRunComparisonTimeout(t *testing.T) {
+	fast, fastMock := mockTarget(t, "fast")
+	hung, hungMock := mockTarget(t, "hung")
+	fastMock.SetDefaultResponse("Paris")
+	hungMock.SetLatency(time.Hour)
+
+	report, err := RunComparison(context.Background(), []ComparisonCase{{Prompt: "Capital of France?"}}, []ComparisonTarget{fast, hung},
+		WithHarnessTimeout(50*time.Millisecond))
+	require.NoError(t, err)
+	assert.Equal(t, "Paris", report.Runs[0].Response)
+	assert.Contains(t, report.Runs[1].Error, "deadline exceeded")
+	assert.Equal(t, 1, report.Summaries[1].Failures)
+}
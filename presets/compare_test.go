@@ -0,0 +1,61 @@
+package presets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm"
+	"github.com/teilomillet/gollm/config"
+)
+
+func TestCompareModelsPartialResults(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Model string `json:"model"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.Model == "hung" {
+			<-r.Context().Done()
+			return
+		}
+		fmt.Fprint(w, `{"response":"{\"name\":\"Ada\"}","done":true}`)
+	}))
+	defer server.Close()
+
+	newConfig := func(model string) *config.Config {
+		cfg := gollm.NewConfig()
+		for _, opt := range []gollm.ConfigOption{
+			gollm.SetProvider("ollama"), gollm.SetModel(model), gollm.SetOllamaEndpoint(server.URL), gollm.SetAPIKey("unused"),
+			gollm.SetMaxRetries(0), gollm.SetLogLevel(gollm.LogLevelOff),
+		} {
+			opt(cfg)
+		}
+		return cfg
+	}
+	type person struct {
+		Name string `json:"name"`
+	}
+	validate := func(p person) error { return nil }
+
+	start := time.Now()
+	results, err := CompareModelsWithOptions(context.Background(), "Name a scientist", validate,
+		[]*config.Config{newConfig("llama3.2"), newConfig("hung")}, WithHarnessTimeout(100*time.Millisecond))
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second, "the hung model fails on its own")
+	require.Len(t, results, 2)
+	assert.NoError(t, results[0].Error)
+	assert.Equal(t, "Ada", results[0].Data.Name)
+	assert.Equal(t, "hung", results[1].Model)
+	assert.ErrorIs(t, results[1].Error, context.DeadlineExceeded)
+
+	_, err = CompareModelsWithOptions(context.Background(), "Name a scientist", validate,
+		[]*config.Config{newConfig("hung")}, WithHarnessTimeout(50*time.Millisecond))
+	assert.ErrorContains(t, err, "all models failed")
+}
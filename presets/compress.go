@@ -0,0 +1,309 @@
+// Package presets provides utilities for enhancing Language Learning Model interactions
+// with specific reasoning patterns and text processing capabilities.
+package presets
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/teilomillet/gollm"
+	"github.com/teilomillet/gollm/llm"
+)
+
+// CompressResult reports the outcome of a context compression.
+type CompressResult struct {
+	Text           string  // The compressed text
+	OriginalTokens int     // Estimated tokens of the original text
+	Tokens         int     // Estimated tokens of the compressed text
+	Ratio          float64 // Tokens over OriginalTokens
+	Summarized     bool    // Whether the summarizer LLM was used
+}
+
+// compressConfig holds the settings of CompressContext.
+type compressConfig struct {
+	ratio      float64
+	query      string
+	summarizer gollm.LLM
+}
+
+// CompressOption configures CompressContext.
+type CompressOption func(*compressConfig)
+
+// WithTargetRatio sets the fraction of the original tokens to keep, between
+// 0 and 1. Defaults to 0.5.
+func WithTargetRatio(ratio float64) CompressOption {
+	return func(c *compressConfig) {
+		c.ratio = ratio
+	}
+}
+
+// WithQuery favors the sentences sharing words with the question the context
+// will answer.
+func WithQuery(query string) CompressOption {
+	return func(c *compressConfig) {
+		c.query = query
+	}
+}
+
+// WithSummarizer summarizes the text with an LLM, usually a cheap one, once
+// the heuristic trimming has removed the least informative half of the
+// excess. Without a summarizer, compression is purely heuristic and free.
+func WithSummarizer(l gollm.LLM) CompressOption {
+	return func(c *compressConfig) {
+		c.summarizer = l
+	}
+}
+
+// CompressContext reduces the token count of a long context, such as
+// retrieved documents, before it is sent with the final request.
+//
+// Compression first applies heuristics in the spirit of LLMLingua: filler
+// phrases are shortened, repeated sentences removed, and the sentences with
+// the least information (frequent words, no overlap with the query) dropped
+// until the target ratio is met. The remaining sentences keep their order.
+// With WithSummarizer, the heuristics stop halfway and the LLM condenses the
+// rest, which preserves more meaning at the cost of a request.
+//
+// Tokens are estimated as four characters per token.
+//
+// Example usage:
+//
+//	result, err := CompressContext(ctx, retrieved,
+//	    WithTargetRatio(0.3),
+//	    WithQuery(question),
+//	)
+//	answer, err := QuestionAnswer(ctx, llm, question, gollm.WithContext(result.Text))
+func CompressContext(ctx context.Context, text string, opts ...CompressOption) (*CompressResult, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context cannot be nil")
+	}
+	cfg := &compressConfig{ratio: 0.5}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if cfg.ratio <= 0 || cfg.ratio > 1 {
+		return nil, fmt.Errorf("target ratio must be between 0 and 1, got %v", cfg.ratio)
+	}
+
+	original := llm.EstimateTokens(text)
+	result := &CompressResult{OriginalTokens: original}
+	target := int(math.Ceil(float64(original) * cfg.ratio))
+
+	compressed := dropRepeatedSentences(shortenFillers(text))
+	heuristicTarget := target
+	if cfg.summarizer != nil {
+		// Leave the summarizer half of the work, so it sees most of the content
+		heuristicTarget = (original + target) / 2
+	}
+	compressed = selectSentences(compressed, cfg.query, heuristicTarget)
+
+	if cfg.summarizer != nil && llm.EstimateTokens(compressed) > target {
+		prompt := gollm.NewPrompt(fmt.Sprintf("Condense the following text to about %d words:\n\n%s", target*3/4, compressed))
+		prompt.Apply(gollm.WithDirectives(
+			"Keep facts, names, numbers and dates exactly",
+			"Drop repetition, examples and rhetoric first",
+			"Respond with the condensed text only",
+		))
+		if cfg.query != "" {
+			prompt.Apply(gollm.WithDirectives("Keep everything relevant to the question: " + cfg.query))
+		}
+		summary, err := cfg.summarizer.Generate(ctx, prompt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to summarize context: %w", err)
+		}
+		compressed = strings.TrimSpace(summary)
+		result.Summarized = true
+	}
+
+	result.Text = compressed
+	result.Tokens = llm.EstimateTokens(compressed)
+	if original > 0 {
+		result.Ratio = float64(result.Tokens) / float64(original)
+	}
+	return result, nil
+}
+
+// CompressPrompt compresses the context of a prompt in place, leaving its
+// instructions untouched. The prompt input is used as the query unless one
+// is given.
+func CompressPrompt(ctx context.Context, prompt *gollm.Prompt, opts ...CompressOption) (*CompressResult, error) {
+	if prompt == nil {
+		return nil, fmt.Errorf("prompt cannot be nil")
+	}
+	result, err := CompressContext(ctx, prompt.Context, append([]CompressOption{WithQuery(prompt.Input)}, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	prompt.Context = result.Text
+	return result, nil
+}
+
+// fillerReplacements shorten phrases carrying little information.
+var fillerReplacements = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`(?i)\b(in order to)\b`), "to"},
+	{regexp.MustCompile(`(?i)\b(due to the fact that)\b`), "because"},
+	{regexp.MustCompile(`(?i)\b(at this point in time)\b`), "now"},
+	{regexp.MustCompile(`(?i)\b(in the event that)\b`), "if"},
+	{regexp.MustCompile(`(?i)\b(for the purpose of)\b`), "for"},
+	{regexp.MustCompile(`(?i)\b(basically|actually|really|very|just|quite|simply|literally|totally|definitely)\s+`), ""},
+	{regexp.MustCompile(`[ \t]+`), " "},
+}
+
+func shortenFillers(text string) string {
+	for _, f := range fillerReplacements {
+		text = f.pattern.ReplaceAllString(text, f.replacement)
+	}
+	return text
+}
+
+// sentence is a sentence of a text and the paragraph it belongs to.
+type sentence struct {
+	text      string
+	paragraph int
+}
+
+// splitSentences splits text into sentences, ending them at terminal
+// punctuation followed by a space and at line breaks.
+func splitSentences(text string) []sentence {
+	var sentences []sentence
+	for p, line := range strings.Split(text, "\n") {
+		start := 0
+		runes := []rune(line)
+		for i, r := range runes {
+			if (r == '.' || r == '!' || r == '?') && (i+1 == len(runes) || unicode.IsSpace(runes[i+1])) {
+				if s := strings.TrimSpace(string(runes[start : i+1])); s != "" {
+					sentences = append(sentences, sentence{text: s, paragraph: p})
+				}
+				start = i + 1
+			}
+		}
+		if s := strings.TrimSpace(string(runes[start:])); s != "" {
+			sentences = append(sentences, sentence{text: s, paragraph: p})
+		}
+	}
+	return sentences
+}
+
+func joinSentences(sentences []sentence) string {
+	var b strings.Builder
+	for i, s := range sentences {
+		if i > 0 {
+			if s.paragraph != sentences[i-1].paragraph {
+				b.WriteString("\n")
+			} else {
+				b.WriteString(" ")
+			}
+		}
+		b.WriteString(s.text)
+	}
+	return b.String()
+}
+
+func dropRepeatedSentences(text string) string {
+	seen := make(map[string]bool)
+	var kept []sentence
+	for _, s := range splitSentences(text) {
+		key := strings.ToLower(strings.Join(words(s.text), " "))
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		kept = append(kept, s)
+	}
+	return joinSentences(kept)
+}
+
+// selectSentences keeps the most informative sentences, in their original
+// order, within the token target.
+func selectSentences(text, query string, target int) string {
+	if llm.EstimateTokens(text) <= target {
+		return text
+	}
+	sentences := splitSentences(text)
+
+	// Words found in few sentences carry more information
+	frequency := make(map[string]int)
+	for _, s := range sentences {
+		for w := range wordSet(s.text) {
+			frequency[w]++
+		}
+	}
+	queryWords := wordSet(query)
+
+	scores := make([]float64, len(sentences))
+	for i, s := range sentences {
+		ws := words(s.text)
+		if len(ws) == 0 {
+			continue
+		}
+		score := 0.0
+		for _, w := range ws {
+			if stopWords[w] {
+				continue
+			}
+			score += math.Log(1 + float64(len(sentences))/float64(frequency[w]))
+			if queryWords[w] {
+				score += 2
+			}
+		}
+		scores[i] = score / math.Sqrt(float64(len(ws)))
+		if i == 0 || sentences[i-1].paragraph != s.paragraph {
+			scores[i] *= 1.2 // Opening sentences usually state the topic
+		}
+	}
+
+	order := make([]int, len(sentences))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return scores[order[a]] > scores[order[b]] })
+
+	keep := make([]bool, len(sentences))
+	tokens := 0
+	for _, i := range order {
+		cost := llm.EstimateTokens(sentences[i].text) + 1
+		if tokens+cost > target && tokens > 0 {
+			continue
+		}
+		keep[i] = true
+		tokens += cost
+	}
+	var kept []sentence
+	for i, s := range sentences {
+		if keep[i] {
+			kept = append(kept, s)
+		}
+	}
+	return joinSentences(kept)
+}
+
+var wordPattern = regexp.MustCompile(`[\p{L}\p{N}]+`)
+
+func words(text string) []string {
+	return wordPattern.FindAllString(strings.ToLower(text), -1)
+}
+
+func wordSet(text string) map[string]bool {
+	set := make(map[string]bool)
+	for _, w := range words(text) {
+		if !stopWords[w] {
+			set[w] = true
+		}
+	}
+	return set
+}
+
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true, "but": true,
+	"by": true, "for": true, "from": true, "has": true, "have": true, "in": true, "is": true, "it": true,
+	"its": true, "of": true, "on": true, "or": true, "that": true, "the": true, "this": true, "to": true,
+	"was": true, "were": true, "which": true, "will": true, "with": true, "what": true, "how": true,
+}
@@ -0,0 +1,58 @@
+package presets
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm"
+	"github.com/teilomillet/gollm/gollmtest"
+	"github.com/teilomillet/gollm/llm"
+)
+
+const compressSample = `The Eiffel Tower was completed in 1889 for the World's Fair in Paris.
+It is really very tall, and people basically love it. It is really very tall, and people basically love it.
+The weather in Paris was pleasant that day. Many visitors took photographs of the river.
+Gustave Eiffel's company designed and built the tower in order to showcase iron engineering.
+Some people said it was nice. Others said it was nice too.`
+
+func TestCompressContext(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Heuristic", func(t *testing.T) {
+		result, err := CompressContext(ctx, compressSample, WithTargetRatio(0.5), WithQuery("Who built the Eiffel Tower?"))
+		require.NoError(t, err)
+		assert.False(t, result.Summarized)
+		assert.LessOrEqual(t, result.Ratio, 0.5)
+		assert.Equal(t, llm.EstimateTokens(result.Text), result.Tokens)
+		assert.Contains(t, result.Text, "Gustave Eiffel's company designed and built the tower to showcase iron engineering.")
+		assert.LessOrEqual(t, strings.Count(result.Text, "tall"), 1, "repeated sentences are dropped")
+		assert.NotContains(t, result.Text, "basically")
+		assert.Less(t, strings.Index(result.Text, "1889"), strings.Index(result.Text, "Gustave"), "sentences keep their order")
+	})
+
+	t.Run("Summarizer", func(t *testing.T) {
+		l := &gollmtest.ScriptedLLM{Responses: []string{" Eiffel's company built the tower in 1889. "}}
+		result, err := CompressContext(ctx, compressSample, WithTargetRatio(0.2), WithSummarizer(l))
+		require.NoError(t, err)
+		assert.True(t, result.Summarized)
+		assert.Equal(t, "Eiffel's company built the tower in 1889.", result.Text)
+		require.Len(t, l.Prompts(), 1)
+		assert.Contains(t, l.Prompts()[0].Input, "Condense the following text")
+	})
+
+	t.Run("CompressPrompt", func(t *testing.T) {
+		prompt := gollm.NewPrompt("When was the Eiffel Tower completed?", gollm.WithContext(compressSample))
+		result, err := CompressPrompt(ctx, prompt, WithTargetRatio(0.4))
+		require.NoError(t, err)
+		assert.Equal(t, result.Text, prompt.Context)
+		assert.Contains(t, prompt.Context, "completed in 1889")
+	})
+
+	t.Run("InvalidRatio", func(t *testing.T) {
+		_, err := CompressContext(ctx, compressSample, WithTargetRatio(1.5))
+		assert.Error(t, err)
+	})
+}
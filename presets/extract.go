@@ -0,0 +1,310 @@
+// Package presets provides utilities for enhancing Language Learning Model interactions
+// with specific reasoning patterns and structured data extraction capabilities.
+package presets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/teilomillet/gollm"
+	"github.com/teilomillet/gollm/llm"
+)
+
+// Person is a person mentioned in a text, for use with Extract.
+type Person struct {
+	Name         string `json:"name" validate:"required" jsonschema:"description=Full name as written in the text"`
+	Role         string `json:"role" jsonschema:"description=Job title or role, if stated"`
+	Organization string `json:"organization" jsonschema:"description=Organization the person belongs to, if stated"`
+}
+
+// EntityDescription implements EntityDescriber.
+func (Person) EntityDescription() string { return "person" }
+
+// DateMention is a date or time mentioned in a text, for use with Extract.
+type DateMention struct {
+	Text  string `json:"text" validate:"required" jsonschema:"description=The date as written in the text"`
+	Date  string `json:"date" jsonschema:"description=The date in ISO 8601 format (YYYY-MM-DD), empty if it cannot be determined"`
+	Event string `json:"event" jsonschema:"description=What happens on that date, if stated"`
+}
+
+// EntityDescription implements EntityDescriber.
+func (DateMention) EntityDescription() string { return "date" }
+
+// Amount is a monetary amount mentioned in a text, for use with Extract.
+type Amount struct {
+	Text     string  `json:"text" validate:"required" jsonschema:"description=The amount as written in the text"`
+	Value    float64 `json:"value" jsonschema:"description=The numeric value"`
+	Currency string  `json:"currency" jsonschema:"description=ISO 4217 currency code, e.g. USD or EUR"`
+	Purpose  string  `json:"purpose" jsonschema:"description=What the amount is for, if stated"`
+}
+
+// EntityDescription implements EntityDescriber.
+func (Amount) EntityDescription() string { return "monetary amount" }
+
+// EntityDescriber is implemented by entity types to tell Extract what they
+// are, e.g. "product" or "medication with its dosage".
+type EntityDescriber interface {
+	EntityDescription() string
+}
+
+// Extraction holds the entities found by Extract.
+type Extraction[T any] struct {
+	Entities []T
+
+	// Confidence holds, for each entity, the probability of its values keyed
+	// by JSON path, e.g. "name", "address.city" or "tags[0]". The probability
+	// of a value is that of its least likely token. Confidence is nil unless
+	// the LLM returns log probabilities (see config.SetLogprobs).
+	Confidence []map[string]float64
+}
+
+// extractConfig holds the settings of Extract.
+type extractConfig struct {
+	description       string
+	promptOptions     []gollm.PromptOption
+	structuredOptions []StructuredOption
+}
+
+// ExtractOption configures Extract.
+type ExtractOption func(*extractConfig)
+
+// WithEntityDescription tells the model what to extract, e.g. "company that
+// is a customer". It overrides the description of an EntityDescriber.
+func WithEntityDescription(description string) ExtractOption {
+	return func(c *extractConfig) {
+		c.description = description
+	}
+}
+
+// WithExtractPromptOptions applies prompt options to the extraction request.
+func WithExtractPromptOptions(opts ...gollm.PromptOption) ExtractOption {
+	return func(c *extractConfig) {
+		c.promptOptions = append(c.promptOptions, opts...)
+	}
+}
+
+// WithExtractStructuredOptions passes options such as validators to
+// GenerateStructured.
+func WithExtractStructuredOptions(opts ...StructuredOption) ExtractOption {
+	return func(c *extractConfig) {
+		c.structuredOptions = append(c.structuredOptions, opts...)
+	}
+}
+
+// extractTemplate asks for the entities of a text.
+var extractTemplate = gollm.NewPromptTemplate(
+	"Extract",
+	"Extract entities from the given text",
+	"Extract every {{.Description}} mentioned in the following text:\n\n{{.Text}}",
+	gollm.WithPromptOptions(
+		gollm.WithDirectives(
+			"Include only what the text states; do not infer missing values",
+			"List each entity once, in order of first mention",
+			"Return an empty list if there is none",
+		),
+	),
+)
+
+// extractedEntities is the JSON object requested from the model.
+type extractedEntities[T any] struct {
+	Entities []T `json:"entities" validate:"dive"`
+}
+
+// Extract pulls every entity of type T from unstructured text, using
+// GenerateStructured to validate the response and have the model repair it.
+// T is any struct usable with GenerateStructured; Person, DateMention and
+// Amount cover common cases.
+//
+// When the LLM is configured with config.SetLogprobs, the result reports the
+// confidence of each extracted value, so uncertain values can be sent for
+// review.
+//
+// Example usage:
+//
+//	people, err := Extract[Person](ctx, llm, article)
+//	for i, p := range people.Entities {
+//	    if people.Confidence != nil && people.Confidence[i]["name"] < 0.8 {
+//	        fmt.Println("check:", p.Name)
+//	    }
+//	}
+//
+//	type Product struct {
+//	    Name  string  `json:"name" validate:"required"`
+//	    Price float64 `json:"price"`
+//	}
+//	products, err := Extract[Product](ctx, llm, email,
+//	    WithEntityDescription("product ordered by the customer"),
+//	)
+func Extract[T any](ctx context.Context, l gollm.LLM, text string, opts ...ExtractOption) (*Extraction[T], error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context cannot be nil")
+	}
+	if l == nil {
+		return nil, fmt.Errorf("LLM instance cannot be nil")
+	}
+	if strings.TrimSpace(text) == "" {
+		return nil, fmt.Errorf("text cannot be empty")
+	}
+	cfg := &extractConfig{description: "entity"}
+	var zero T
+	if d, ok := interface{}(zero).(EntityDescriber); ok {
+		cfg.description = d.EntityDescription()
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	prompt, err := extractTemplate.Execute(map[string]interface{}{
+		"Description": cfg.description,
+		"Text":        text,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute extract template: %w", err)
+	}
+	prompt.Apply(cfg.promptOptions...)
+
+	var logprobs []llm.TokenLogprob
+	structuredOptions := append([]StructuredOption{WithStructuredGenerateOptions(llm.WithLogprobs(&logprobs))}, cfg.structuredOptions...)
+	extracted, err := GenerateStructured[extractedEntities[T]](ctx, l, prompt, structuredOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract entities: %w", err)
+	}
+
+	result := &Extraction[T]{Entities: extracted.Entities}
+	if len(logprobs) > 0 {
+		result.Confidence = entityConfidence(valueConfidence(logprobs), len(extracted.Entities))
+	}
+	return result, nil
+}
+
+// entityConfidence splits the confidence of the "entities[i]..." paths by
+// entity, dropping the prefix.
+func entityConfidence(values map[string]float64, n int) []map[string]float64 {
+	if values == nil {
+		return nil
+	}
+	confidence := make([]map[string]float64, n)
+	for i := range confidence {
+		confidence[i] = make(map[string]float64)
+		prefix := fmt.Sprintf("entities[%d]", i)
+		for path, p := range values {
+			if rest := strings.TrimPrefix(path, prefix); rest != path && (rest == "" || rest[0] == '.' || rest[0] == '[') {
+				confidence[i][strings.TrimPrefix(rest, ".")] = p
+			}
+		}
+	}
+	return confidence
+}
+
+// valueConfidence maps the JSON path of every scalar value of the JSON object
+// spelled by the tokens to the probability of its least likely token. It
+// returns nil if the tokens do not spell a JSON object.
+func valueConfidence(tokens []llm.TokenLogprob) map[string]float64 {
+	var text strings.Builder
+	offsets := make([]int, len(tokens)+1)
+	for i, t := range tokens {
+		if len(t.Bytes) > 0 {
+			// The token may be part of a character; the bytes are exact
+			for _, b := range t.Bytes {
+				text.WriteByte(byte(b))
+			}
+		} else {
+			text.WriteString(t.Token)
+		}
+		offsets[i+1] = text.Len()
+	}
+	response := text.String()
+	cleaned := gollm.CleanResponse(response)
+	base := strings.Index(response, cleaned)
+	if cleaned == "" || base < 0 {
+		return nil
+	}
+
+	// probability returns the probability of the least likely token
+	// overlapping the response bytes [start, end)
+	probability := func(start, end int) float64 {
+		minimum := 1.0
+		for i, t := range tokens {
+			if offsets[i] < end && offsets[i+1] > start {
+				minimum = math.Min(minimum, math.Exp(t.Logprob))
+			}
+		}
+		return minimum
+	}
+
+	type frame struct {
+		array   bool
+		index   int
+		key     string
+		wantKey bool
+	}
+	var stack []*frame
+	path := func() string {
+		var b strings.Builder
+		for _, f := range stack {
+			if f.array {
+				fmt.Fprintf(&b, "[%d]", f.index)
+			} else {
+				if b.Len() > 0 {
+					b.WriteString(".")
+				}
+				b.WriteString(f.key)
+			}
+		}
+		return b.String()
+	}
+	afterValue := func() {
+		if len(stack) == 0 {
+			return
+		}
+		if top := stack[len(stack)-1]; top.array {
+			top.index++
+		} else {
+			top.wantKey = true
+		}
+	}
+
+	values := make(map[string]float64)
+	decoder := json.NewDecoder(strings.NewReader(cleaned))
+	decoder.UseNumber()
+	for {
+		start := int(decoder.InputOffset())
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil
+		}
+		end := int(decoder.InputOffset())
+
+		if delim, ok := token.(json.Delim); ok {
+			switch delim {
+			case '{', '[':
+				stack = append(stack, &frame{array: delim == '[', wantKey: delim == '{'})
+			case '}', ']':
+				stack = stack[:len(stack)-1]
+				afterValue()
+			}
+			continue
+		}
+		if len(stack) > 0 {
+			if top := stack[len(stack)-1]; !top.array && top.wantKey {
+				top.key, _ = token.(string)
+				top.wantKey = false
+				continue
+			}
+		}
+		// The offset before the token includes the separators
+		for start < end && strings.ContainsRune(" \t\r\n:,", rune(cleaned[start])) {
+			start++
+		}
+		values[path()] = probability(base+start, base+end)
+		afterValue()
+	}
+	return values
+}
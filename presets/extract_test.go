@@ -0,0 +1,105 @@
+package presets
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm/gollmtest"
+	"github.com/teilomillet/gollm/llm"
+)
+
+// logprobLLM is a ScriptedLLM whose responses come with log probabilities.
+type logprobLLM struct {
+	gollmtest.ScriptedLLM
+	tokens []llm.TokenLogprob
+}
+
+func (l *logprobLLM) Generate(ctx context.Context, prompt *llm.Prompt, opts ...llm.GenerateOption) (string, error) {
+	response, err := l.ScriptedLLM.Generate(ctx, prompt, opts...)
+	config := &llm.GenerateConfig{}
+	for _, opt := range opts {
+		opt(config)
+	}
+	if err == nil && config.Logprobs != nil {
+		*config.Logprobs = l.tokens
+	}
+	return response, err
+}
+
+func TestExtract(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("People", func(t *testing.T) {
+		l := &gollmtest.ScriptedLLM{Responses: []string{`{"entities": [{"name": "Ada Lovelace", "role": "mathematician"}, {"name": "Charles Babbage"}]}`}}
+		people, err := Extract[Person](ctx, l, "Ada Lovelace, a mathematician, worked with Charles Babbage.")
+		require.NoError(t, err)
+		require.Len(t, people.Entities, 2)
+		assert.Equal(t, "Ada Lovelace", people.Entities[0].Name)
+		assert.Equal(t, "mathematician", people.Entities[0].Role)
+		assert.Equal(t, "Charles Babbage", people.Entities[1].Name)
+		assert.Nil(t, people.Confidence)
+		require.Len(t, l.Prompts(), 1)
+		assert.Contains(t, l.Prompts()[0].Input, "Extract every person mentioned")
+	})
+
+	t.Run("RepairsInvalidEntities", func(t *testing.T) {
+		l := &gollmtest.ScriptedLLM{Responses: []string{
+			`{"entities": [{"text": "", "value": 12}]}`,
+			`{"entities": [{"text": "$12", "value": 12, "currency": "USD"}]}`,
+		}}
+		amounts, err := Extract[Amount](ctx, l, "The ticket costs $12.", WithEntityDescription("price"))
+		require.NoError(t, err)
+		require.Len(t, amounts.Entities, 1)
+		assert.Equal(t, "USD", amounts.Entities[0].Currency)
+		require.Len(t, l.Prompts(), 2)
+		assert.Contains(t, l.Prompts()[0].Input, "Extract every price mentioned")
+	})
+
+	t.Run("Confidence", func(t *testing.T) {
+		tokens := []llm.TokenLogprob{
+			{Token: `{"entities": [{"`, Logprob: 0},
+			{Token: `text`, Logprob: 0},
+			{Token: `": "`, Logprob: 0},
+			{Token: `May`, Logprob: math.Log(0.9)},
+			{Token: ` 3`, Logprob: math.Log(0.6)},
+			{Token: `", "date": "`, Logprob: 0},
+			{Token: `2024-05-03`, Logprob: math.Log(0.5)},
+			{Token: `"}]}`, Logprob: 0},
+		}
+		response := ""
+		for _, token := range tokens {
+			response += token.Token
+		}
+		l := &logprobLLM{ScriptedLLM: gollmtest.ScriptedLLM{Responses: []string{response}}, tokens: tokens}
+		dates, err := Extract[DateMention](ctx, l, "We meet on May 3.")
+		require.NoError(t, err)
+		require.Len(t, dates.Entities, 1)
+		require.Len(t, dates.Confidence, 1)
+		assert.InDelta(t, 0.6, dates.Confidence[0]["text"], 1e-9)
+		assert.InDelta(t, 0.5, dates.Confidence[0]["date"], 1e-9)
+	})
+
+	t.Run("EmptyText", func(t *testing.T) {
+		_, err := Extract[Person](ctx, &gollmtest.ScriptedLLM{}, " ")
+		assert.Error(t, err)
+	})
+}
+
+func TestValueConfidence(t *testing.T) {
+	tokens := []llm.TokenLogprob{
+		{Token: "```json\n{\"a\": [", Logprob: 0},
+		{Token: "1", Logprob: math.Log(0.7)},
+		{Token: ", tr", Logprob: math.Log(0.4)},
+		{Token: "ue], \"b\": {\"c\": null}}\n```", Logprob: 0},
+	}
+	values := valueConfidence(tokens)
+	require.NotNil(t, values)
+	assert.InDelta(t, 0.7, values["a[0]"], 1e-9)
+	assert.InDelta(t, 0.4, values["a[1]"], 1e-9)
+	assert.InDelta(t, 1.0, values["b.c"], 1e-9)
+
+	assert.Nil(t, valueConfidence([]llm.TokenLogprob{{Token: "not json"}}))
+}
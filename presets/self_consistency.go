@@ -0,0 +1,210 @@
+// Package presets provides utilities for enhancing Language Learning Model interactions
+// with specific reasoning patterns and problem-solving strategies.
+package presets
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/teilomillet/gollm"
+)
+
+// SelfConsistencyResult holds the outcome of self-consistency sampling.
+type SelfConsistencyResult struct {
+	Answer     string                 // Majority answer, as written in the first sample that gave it
+	Confidence float64                // Fraction of valid samples that agree with the majority answer
+	Votes      map[string]int         // Number of samples per normalized answer
+	Samples    []ChainOfThoughtResult // Samples with an extractable answer, in completion order
+	Failed     int                    // Samples that errored or had no final answer
+}
+
+// selfConsistencyConfig holds the settings of SelfConsistency.
+type selfConsistencyConfig struct {
+	samples       int
+	temperature   float64
+	concurrency   int
+	normalize     func(string) string
+	promptOptions []gollm.PromptOption
+}
+
+// SelfConsistencyOption configures SelfConsistency.
+type SelfConsistencyOption func(*selfConsistencyConfig)
+
+// WithSampleCount sets how many reasoning samples are generated. Defaults to 5.
+func WithSampleCount(n int) SelfConsistencyOption {
+	return func(c *selfConsistencyConfig) {
+		c.samples = n
+	}
+}
+
+// WithSamplingTemperature sets the temperature used for each sample.
+// A higher temperature yields more diverse reasoning paths. Defaults to 0.8.
+func WithSamplingTemperature(temperature float64) SelfConsistencyOption {
+	return func(c *selfConsistencyConfig) {
+		c.temperature = temperature
+	}
+}
+
+// WithMaxConcurrency limits how many samples are requested in parallel.
+// Defaults to the sample count.
+func WithMaxConcurrency(n int) SelfConsistencyOption {
+	return func(c *selfConsistencyConfig) {
+		c.concurrency = n
+	}
+}
+
+// WithAnswerNormalizer sets the function that maps answers to voting keys.
+// The default lowercases, collapses whitespace, strips surrounding punctuation
+// and canonicalizes numbers, so "194", "194.0" and "194." vote together.
+func WithAnswerNormalizer(normalize func(string) string) SelfConsistencyOption {
+	return func(c *selfConsistencyConfig) {
+		c.normalize = normalize
+	}
+}
+
+// WithSamplePromptOptions applies prompt options to every sample.
+func WithSamplePromptOptions(opts ...gollm.PromptOption) SelfConsistencyOption {
+	return func(c *selfConsistencyConfig) {
+		c.promptOptions = append(c.promptOptions, opts...)
+	}
+}
+
+// SelfConsistency generates several chain of thought samples at a higher
+// temperature and returns the answer most samples agree on, together with
+// a confidence score. It improves reliability on tasks with a single correct
+// answer, such as arithmetic or classification.
+//
+// Samples are requested concurrently. Samples that fail or have no final
+// answer are counted in Failed and excluded from the vote; an error is only
+// returned when no sample produced an answer.
+//
+// Example usage:
+//
+//	result, err := SelfConsistency(ctx, llm,
+//	    "A bat and a ball cost $1.10 in total. The bat costs $1.00 more than the ball. How much does the ball cost?",
+//	    WithSampleCount(7),
+//	)
+//	fmt.Printf("%s (confidence %.0f%%)\n", result.Answer, result.Confidence*100)
+func SelfConsistency(ctx context.Context, l gollm.LLM, question string, opts ...SelfConsistencyOption) (*SelfConsistencyResult, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context cannot be nil")
+	}
+	if l == nil {
+		return nil, fmt.Errorf("LLM instance cannot be nil")
+	}
+	if strings.TrimSpace(question) == "" {
+		return nil, fmt.Errorf("question cannot be empty")
+	}
+	if !utf8.ValidString(question) {
+		return nil, fmt.Errorf("question contains invalid UTF-8 characters")
+	}
+
+	cfg := &selfConsistencyConfig{
+		samples:     5,
+		temperature: 0.8,
+		normalize:   normalizeAnswer,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if cfg.samples < 1 {
+		return nil, fmt.Errorf("sample count must be at least 1")
+	}
+	if cfg.concurrency < 1 || cfg.concurrency > cfg.samples {
+		cfg.concurrency = cfg.samples
+	}
+
+	prompt, err := chainOfThoughtTemplate.Execute(map[string]interface{}{
+		"Question": question,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute chain of thought template: %w", err)
+	}
+	prompt.Apply(
+		gollm.WithDirectives(
+			"Think step by step before answering",
+			fmt.Sprintf("End with a final line of the form %q followed by only the answer", defaultAnswerMarker+" <answer>"),
+		),
+	)
+	prompt.Apply(cfg.promptOptions...)
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		lastErr error
+		sem     = make(chan struct{}, cfg.concurrency)
+		result  = &SelfConsistencyResult{Votes: make(map[string]int)}
+	)
+	for i := 0; i < cfg.samples; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				mu.Lock()
+				result.Failed++
+				lastErr = ctx.Err()
+				mu.Unlock()
+				return
+			}
+
+			response, err := l.Generate(ctx, prompt, gollm.WithRequestOption("temperature", cfg.temperature))
+			reasoning, answer, ok := splitFinalAnswer(response, defaultAnswerMarker)
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err != nil:
+				result.Failed++
+				lastErr = err
+			case !ok:
+				result.Failed++
+			default:
+				result.Samples = append(result.Samples, ChainOfThoughtResult{Reasoning: reasoning, Answer: answer})
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(result.Samples) == 0 {
+		if lastErr != nil {
+			return nil, fmt.Errorf("no sample produced an answer: %w", lastErr)
+		}
+		return nil, fmt.Errorf("no sample produced an answer")
+	}
+
+	// Vote on normalized answers; ties go to the answer seen first
+	var winner string
+	for _, sample := range result.Samples {
+		key := cfg.normalize(sample.Answer)
+		result.Votes[key]++
+		if winner == "" || result.Votes[key] > result.Votes[winner] {
+			winner = key
+		}
+	}
+	for _, sample := range result.Samples {
+		if cfg.normalize(sample.Answer) == winner {
+			result.Answer = sample.Answer
+			break
+		}
+	}
+	result.Confidence = float64(result.Votes[winner]) / float64(len(result.Samples))
+	return result, nil
+}
+
+// normalizeAnswer is the default voting key for SelfConsistency.
+func normalizeAnswer(answer string) string {
+	key := strings.ToLower(strings.Join(strings.Fields(answer), " "))
+	key = strings.Trim(key, " .,;:!?\"'`*_()[]")
+	numeric := strings.TrimPrefix(strings.ReplaceAll(key, ",", ""), "$")
+	if f, err := strconv.ParseFloat(numeric, 64); err == nil {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return key
+}
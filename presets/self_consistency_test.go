@@ -0,0 +1,43 @@
+package presets
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm/gollmtest"
+)
+
+func TestSelfConsistency(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("MajorityVote", func(t *testing.T) {
+		l := &gollmtest.ScriptedLLM{Responses: []string{
+			"102 + 92\nFinal Answer: 194",
+			"102 + 90\nFinal Answer: 192",
+			"Final Answer: 194.0",
+			"I am not sure.",
+			"Final Answer: $194.",
+		}}
+		result, err := SelfConsistency(ctx, l, "What is (17 * 6) + (23 * 4)?", WithMaxConcurrency(2))
+		require.NoError(t, err)
+		assert.Equal(t, "194", normalizeAnswer(result.Answer))
+		assert.Equal(t, 3, result.Votes["194"])
+		assert.Equal(t, 1, result.Votes["192"])
+		assert.InDelta(t, 0.75, result.Confidence, 1e-9)
+		assert.Equal(t, 1, result.Failed)
+		assert.Len(t, result.Samples, 4)
+		assert.Len(t, l.Prompts(), 5)
+	})
+
+	t.Run("NoAnswers", func(t *testing.T) {
+		_, err := SelfConsistency(ctx, &gollmtest.ScriptedLLM{}, "What is 2 + 2?", WithSampleCount(3))
+		assert.ErrorIs(t, err, gollmtest.ErrNoResponse)
+	})
+
+	t.Run("InvalidSampleCount", func(t *testing.T) {
+		_, err := SelfConsistency(ctx, &gollmtest.ScriptedLLM{}, "What is 2 + 2?", WithSampleCount(0))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,291 @@
+package presets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/teilomillet/gollm"
+	"github.com/teilomillet/gollm/llm"
+)
+
+// Violation is a validation failure in a structured response.
+type Violation struct {
+	// Field is the JSON path of the offending value, e.g. "items[1].price";
+	// empty for failures that concern the whole object
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+func (v Violation) Error() string {
+	if v.Field == "" {
+		return v.Message
+	}
+	return v.Field + ": " + v.Message
+}
+
+// ValidationError is returned by GenerateStructured when the response still
+// fails validation after the last repair attempt.
+type ValidationError struct {
+	Violations []Violation
+	// Response is the last response of the model
+	Response string
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		messages[i] = v.Error()
+	}
+	return "structured output validation failed: " + strings.Join(messages, "; ")
+}
+
+// FieldValidator checks the decoded JSON value of a field: a string, float64,
+// bool, nil, []interface{} or map[string]interface{}.
+type FieldValidator func(value interface{}) error
+
+// StructuredOption configures GenerateStructured.
+type StructuredOption func(*structuredConfig)
+
+type structuredConfig struct {
+	fields     []fieldValidator
+	validators []func(interface{}) []Violation
+	maxRepairs int
+	generate   []llm.GenerateOption
+}
+
+type fieldValidator struct {
+	path     string
+	validate FieldValidator
+}
+
+// WithFieldValidator checks the field at a dot-separated JSON path, e.g.
+// "address.zip". When the path crosses an array, the validator runs on the
+// field of every element. Missing fields are skipped; use a "required" tag
+// to demand them.
+func WithFieldValidator(path string, validate FieldValidator) StructuredOption {
+	return func(c *structuredConfig) {
+		c.fields = append(c.fields, fieldValidator{path: path, validate: validate})
+	}
+}
+
+// WithValidator checks the decoded value as a whole, for rules that span
+// several fields such as "end must be after start". The validator may return
+// a Violation to name the offending field.
+func WithValidator[T any](validate func(*T) error) StructuredOption {
+	return func(c *structuredConfig) {
+		c.validators = append(c.validators, func(value interface{}) []Violation {
+			typed, ok := value.(*T)
+			if !ok {
+				return nil
+			}
+			return toViolations(validate(typed))
+		})
+	}
+}
+
+// WithMaxRepairs sets how many times the model is asked to fix a response
+// that fails validation (default 2).
+func WithMaxRepairs(n int) StructuredOption {
+	return func(c *structuredConfig) {
+		c.maxRepairs = n
+	}
+}
+
+// WithStructuredGenerateOptions passes options to every Generate call.
+func WithStructuredGenerateOptions(opts ...llm.GenerateOption) StructuredOption {
+	return func(c *structuredConfig) {
+		c.generate = append(c.generate, opts...)
+	}
+}
+
+// GenerateStructured asks the model for a JSON object matching the schema of
+// T, decodes it and validates it with the struct's validate tags, the field
+// validators and the whole-value validators. When validation fails, the
+// violations are sent back to the model with its previous response so it can
+// repair it, up to the configured number of repairs; a *ValidationError is
+// returned if the last response is still invalid.
+//
+// Example usage:
+//
+//	type Booking struct {
+//	    Guest    string    `json:"guest" validate:"required"`
+//	    CheckIn  time.Time `json:"check_in"`
+//	    CheckOut time.Time `json:"check_out"`
+//	    Room     string    `json:"room"`
+//	}
+//
+//	booking, err := presets.GenerateStructured[Booking](ctx, llm, gollm.NewPrompt(email),
+//	    presets.WithFieldValidator("room", func(v interface{}) error {
+//	        if s, _ := v.(string); !strings.HasPrefix(s, "R") {
+//	            return errors.New("room numbers start with R")
+//	        }
+//	        return nil
+//	    }),
+//	    presets.WithValidator(func(b *Booking) error {
+//	        if !b.CheckOut.After(b.CheckIn) {
+//	            return presets.Violation{Field: "check_out", Message: "must be after check_in"}
+//	        }
+//	        return nil
+//	    }),
+//	)
+func GenerateStructured[T any](ctx context.Context, l gollm.LLM, prompt *gollm.Prompt, opts ...StructuredOption) (*T, error) {
+	cfg := &structuredConfig{maxRepairs: 2}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	var zero T
+	schema, err := gollm.GenerateJSONSchema(zero)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate JSON schema: %w", err)
+	}
+
+	request := prompt.String() + "\n\nRespond ONLY with a JSON object matching this schema:\n" + string(schema)
+	current := gollm.NewPrompt(request)
+	for attempt := 0; ; attempt++ {
+		response, err := l.Generate(ctx, current, cfg.generate...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate structured output: %w", err)
+		}
+
+		result, violations := validateStructured[T](response, cfg)
+		if len(violations) == 0 {
+			return result, nil
+		}
+		if attempt >= cfg.maxRepairs {
+			return nil, &ValidationError{Violations: violations, Response: response}
+		}
+
+		var feedback strings.Builder
+		for _, v := range violations {
+			feedback.WriteString("- " + v.Error() + "\n")
+		}
+		current = gollm.NewPrompt(fmt.Sprintf("%s\n\nYour previous response was:\n%s\n\nIt failed validation:\n%s\nRespond again with the corrected JSON object only.", request, response, feedback.String()))
+	}
+}
+
+// validateStructured decodes a response and collects its violations.
+func validateStructured[T any](response string, cfg *structuredConfig) (*T, []Violation) {
+	cleaned := gollm.CleanResponse(response)
+	var result T
+	if err := json.Unmarshal([]byte(cleaned), &result); err != nil {
+		return nil, []Violation{{Message: "invalid JSON: " + err.Error()}}
+	}
+
+	var violations []Violation
+	if err := gollm.Validate(&result); err != nil {
+		for _, v := range toViolations(err) {
+			v.Field = jsonPath(reflect.TypeOf(result), v.Field)
+			violations = append(violations, v)
+		}
+	}
+	if len(cfg.fields) > 0 {
+		var raw interface{}
+		if err := json.Unmarshal([]byte(cleaned), &raw); err == nil {
+			for _, fv := range cfg.fields {
+				visitPath(raw, strings.Split(fv.path, "."), "", func(field string, value interface{}) {
+					if err := fv.validate(value); err != nil {
+						violations = append(violations, Violation{Field: field, Message: err.Error()})
+					}
+				})
+			}
+		}
+	}
+	for _, validate := range cfg.validators {
+		violations = append(violations, validate(&result)...)
+	}
+	return &result, violations
+}
+
+// visitPath calls fn for every value at the path, fanning out over arrays.
+func visitPath(value interface{}, path []string, prefix string, fn func(field string, value interface{})) {
+	if items, ok := value.([]interface{}); ok && len(path) > 0 {
+		for i, item := range items {
+			visitPath(item, path, fmt.Sprintf("%s[%d]", prefix, i), fn)
+		}
+		return
+	}
+	if len(path) == 0 {
+		fn(prefix, value)
+		return
+	}
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return
+	}
+	child, ok := object[path[0]]
+	if !ok {
+		return
+	}
+	field := path[0]
+	if prefix != "" {
+		field = prefix + "." + path[0]
+	}
+	visitPath(child, path[1:], field, fn)
+}
+
+// toViolations converts a validator error to violations, keeping the field
+// names of Violation and struct tag errors. Tag errors are named by their Go
+// namespace, e.g. "Order.Items[0].Price"; see jsonPath.
+func toViolations(err error) []Violation {
+	if err == nil {
+		return nil
+	}
+	var violation Violation
+	if errors.As(err, &violation) {
+		return []Violation{violation}
+	}
+	var tagErrors validator.ValidationErrors
+	if errors.As(err, &tagErrors) {
+		violations := make([]Violation, len(tagErrors))
+		for i, fe := range tagErrors {
+			message := "failed the " + fe.Tag() + " rule"
+			if fe.Param() != "" {
+				message += " (" + fe.Param() + ")"
+			}
+			violations[i] = Violation{Field: fe.StructNamespace(), Message: message}
+		}
+		return violations
+	}
+	return []Violation{{Message: err.Error()}}
+}
+
+// jsonPath converts the Go namespace of a struct tag error to the JSON path
+// the model sees, e.g. "Order.Items[0].Price" to "items[0].price".
+func jsonPath(t reflect.Type, namespace string) string {
+	segments := strings.Split(namespace, ".")
+	if len(segments) < 2 {
+		return namespace
+	}
+	path := make([]string, 0, len(segments)-1)
+	for _, segment := range segments[1:] {
+		name, index := segment, ""
+		if i := strings.Index(segment, "["); i != -1 {
+			name, index = segment[:i], segment[i:]
+		}
+		for t != nil && (t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map) {
+			t = t.Elem()
+		}
+		if t == nil || t.Kind() != reflect.Struct {
+			path = append(path, segment)
+			continue
+		}
+		field, ok := t.FieldByName(name)
+		if !ok {
+			path = append(path, segment)
+			t = nil
+			continue
+		}
+		if tag := strings.Split(field.Tag.Get("json"), ",")[0]; tag != "" && tag != "-" {
+			name = tag
+		}
+		path = append(path, name+index)
+		t = field.Type
+	}
+	return strings.Join(path, ".")
+}
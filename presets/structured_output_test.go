@@ -0,0 +1,68 @@
+package presets
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm"
+	"github.com/teilomillet/gollm/gollmtest"
+)
+
+type testOrder struct {
+	Customer string          `json:"customer" validate:"required"`
+	Items    []testOrderItem `json:"items" validate:"dive"`
+	Subtotal float64         `json:"subtotal"`
+	Total    float64         `json:"total"`
+}
+
+type testOrderItem struct {
+	SKU      string  `json:"sku"`
+	Quantity int     `json:"quantity" validate:"gte=1"`
+	Price    float64 `json:"price"`
+}
+
+func TestGenerateStructured(t *testing.T) {
+	ctx := context.Background()
+	skuValidator := WithFieldValidator("items.sku", func(v interface{}) error {
+		if s, _ := v.(string); len(s) != 6 {
+			return errors.New("must be 6 characters")
+		}
+		return nil
+	})
+	totalValidator := WithValidator(func(o *testOrder) error {
+		if o.Total < o.Subtotal {
+			return Violation{Field: "total", Message: "must not be less than subtotal"}
+		}
+		return nil
+	})
+
+	t.Run("Repair", func(t *testing.T) {
+		l := &gollmtest.ScriptedLLM{Responses: []string{
+			`{"customer": "Ada", "items": [{"sku": "ABC123", "quantity": 0}, {"sku": "XY", "quantity": 1}], "subtotal": 10, "total": 8}`,
+			"```json\n{\"customer\": \"Ada\", \"items\": [{\"sku\": \"ABC123\", \"quantity\": 1}, {\"sku\": \"XYZ789\", \"quantity\": 1}], \"subtotal\": 10, \"total\": 12}\n```",
+		}}
+		order, err := GenerateStructured[testOrder](ctx, l, gollm.NewPrompt("Extract the order"), skuValidator, totalValidator)
+		require.NoError(t, err)
+		assert.Equal(t, "XYZ789", order.Items[1].SKU)
+		assert.Equal(t, 12.0, order.Total)
+
+		require.Len(t, l.Prompts(), 2)
+		repair := l.Prompts()[1].Input
+		assert.Contains(t, repair, "items[0].quantity: failed the gte rule (1)")
+		assert.Contains(t, repair, "items[1].sku: must be 6 characters")
+		assert.Contains(t, repair, "total: must not be less than subtotal")
+		assert.Contains(t, repair, `"total": 8`)
+	})
+
+	t.Run("GiveUp", func(t *testing.T) {
+		l := &gollmtest.ScriptedLLM{Responses: []string{"not json", `{"customer": ""}`}}
+		_, err := GenerateStructured[testOrder](ctx, l, gollm.NewPrompt("Extract the order"), WithMaxRepairs(1))
+		var validationErr *ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, []Violation{{Field: "customer", Message: "failed the required rule"}}, validationErr.Violations)
+		assert.Contains(t, l.Prompts()[1].Input, "invalid JSON")
+	})
+}
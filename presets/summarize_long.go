@@ -0,0 +1,232 @@
+// Package presets provides utilities for enhancing Language Learning Model interactions
+// with specific reasoning patterns and text processing capabilities.
+package presets
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/teilomillet/gollm"
+	"github.com/teilomillet/gollm/llm"
+)
+
+// summarizeLongConfig holds the settings of SummarizeLong.
+type summarizeLongConfig struct {
+	chunkTokens   int
+	style         string
+	length        int
+	concurrency   int
+	promptOptions []gollm.PromptOption
+}
+
+// SummarizeOption configures SummarizeLong.
+type SummarizeOption func(*summarizeLongConfig)
+
+// WithChunkTokens sets the size in tokens of the chunks summarized
+// separately; documents within it are summarized in a single request.
+// Defaults to 3000, which leaves room for the instructions and the summary in
+// small context windows.
+func WithChunkTokens(n int) SummarizeOption {
+	return func(c *summarizeLongConfig) {
+		c.chunkTokens = n
+	}
+}
+
+// WithSummaryStyle sets the style of the final summary, e.g. "bullet points"
+// or "an executive brief".
+func WithSummaryStyle(style string) SummarizeOption {
+	return func(c *summarizeLongConfig) {
+		c.style = style
+	}
+}
+
+// WithSummaryLength sets the approximate length of the final summary in
+// words.
+func WithSummaryLength(words int) SummarizeOption {
+	return func(c *summarizeLongConfig) {
+		c.length = words
+	}
+}
+
+// WithSummaryConcurrency limits how many chunks are summarized in parallel.
+// Defaults to 4.
+func WithSummaryConcurrency(n int) SummarizeOption {
+	return func(c *summarizeLongConfig) {
+		c.concurrency = n
+	}
+}
+
+// WithSummaryPromptOptions applies prompt options to every summarization
+// request.
+func WithSummaryPromptOptions(opts ...gollm.PromptOption) SummarizeOption {
+	return func(c *summarizeLongConfig) {
+		c.promptOptions = append(c.promptOptions, opts...)
+	}
+}
+
+// SummarizeLong summarizes documents of any length with map-reduce: a text
+// exceeding the chunk size is split on paragraph and sentence boundaries,
+// the chunks are summarized in parallel, and their summaries are combined
+// into the final summary, in several rounds if they are still too long.
+// Shorter texts are summarized with a single request, like Summarize.
+//
+// Example usage:
+//
+//	summary, err := SummarizeLong(ctx, llm, report,
+//	    WithSummaryStyle("bullet points"),
+//	    WithSummaryLength(200),
+//	)
+func SummarizeLong(ctx context.Context, l gollm.LLM, text string, opts ...SummarizeOption) (string, error) {
+	if ctx == nil {
+		return "", fmt.Errorf("context cannot be nil")
+	}
+	if l == nil {
+		return "", fmt.Errorf("LLM instance cannot be nil")
+	}
+	if strings.TrimSpace(text) == "" {
+		return "", fmt.Errorf("text cannot be empty")
+	}
+	cfg := &summarizeLongConfig{chunkTokens: 3000, concurrency: 4}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if cfg.chunkTokens < 1 {
+		return "", fmt.Errorf("chunk size must be at least 1 token")
+	}
+	if cfg.concurrency < 1 {
+		cfg.concurrency = 1
+	}
+
+	if llm.EstimateTokens(text) <= cfg.chunkTokens {
+		return summarizeChunk(ctx, l, text, cfg, true)
+	}
+
+	// Map: summarize each chunk. Reduce: combine the summaries until they fit
+	// in a single request.
+	parts := chunkText(text, cfg.chunkTokens)
+	for {
+		summaries, err := summarizeChunks(ctx, l, parts, cfg)
+		if err != nil {
+			return "", err
+		}
+		combined := strings.Join(summaries, "\n\n")
+		if llm.EstimateTokens(combined) <= cfg.chunkTokens || len(summaries) == 1 {
+			return summarizeChunk(ctx, l, combined, cfg, true)
+		}
+		parts = chunkText(combined, cfg.chunkTokens)
+		if len(parts) >= len(summaries) {
+			// Summaries as long as their chunks: reduce what fits
+			return summarizeChunk(ctx, l, combined, cfg, true)
+		}
+	}
+}
+
+// summarizeChunks summarizes the chunks concurrently, keeping their order.
+func summarizeChunks(ctx context.Context, l gollm.LLM, chunks []string, cfg *summarizeLongConfig) ([]string, error) {
+	summaries := make([]string, len(chunks))
+	errs := make([]error, len(chunks))
+	sem := make(chan struct{}, cfg.concurrency)
+	var wg sync.WaitGroup
+	for i, chunk := range chunks {
+		wg.Add(1)
+		go func(i int, chunk string) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				errs[i] = ctx.Err()
+				return
+			}
+			summaries[i], errs[i] = summarizeChunk(ctx, l, chunk, cfg, false)
+		}(i, chunk)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("failed to summarize chunk %d of %d: %w", i+1, len(chunks), err)
+		}
+	}
+	return summaries, nil
+}
+
+// summarizeChunk summarizes a chunk, or produces the final summary with the
+// configured style and length.
+func summarizeChunk(ctx context.Context, l gollm.LLM, text string, cfg *summarizeLongConfig, final bool) (string, error) {
+	prompt, err := summarizeTemplate.Execute(map[string]interface{}{
+		"Text": text,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to execute summarize template: %w", err)
+	}
+	if final {
+		if cfg.style != "" {
+			prompt.Apply(gollm.WithDirectives("Write the summary as " + cfg.style))
+		}
+		if cfg.length > 0 {
+			prompt.Apply(gollm.WithDirectives(fmt.Sprintf("Keep the summary to about %d words", cfg.length)))
+		}
+	} else {
+		prompt.Apply(gollm.WithDirectives(
+			"This is one part of a longer document; summarize only this part",
+			"Keep facts, names and figures needed for an overall summary",
+		))
+	}
+	prompt.Apply(cfg.promptOptions...)
+	response, err := l.Generate(ctx, prompt)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate response: %w", err)
+	}
+	return strings.TrimSpace(response), nil
+}
+
+// chunkText splits text into chunks of at most maxTokens, on paragraph and
+// sentence boundaries. Sentences longer than a chunk are split on words.
+func chunkText(text string, maxTokens int) []string {
+	var chunks []string
+	var current []sentence
+	tokens := 0
+	flush := func() {
+		if len(current) > 0 {
+			chunks = append(chunks, joinSentences(current))
+			current, tokens = nil, 0
+		}
+	}
+	for _, s := range splitSentences(text) {
+		for _, piece := range splitLong(s, maxTokens) {
+			cost := llm.EstimateTokens(piece.text) + 1
+			if tokens+cost > maxTokens {
+				flush()
+			}
+			current = append(current, piece)
+			tokens += cost
+		}
+	}
+	flush()
+	return chunks
+}
+
+// splitLong splits a sentence exceeding maxTokens on words.
+func splitLong(s sentence, maxTokens int) []sentence {
+	if llm.EstimateTokens(s.text) < maxTokens {
+		return []sentence{s}
+	}
+	var pieces []sentence
+	var b strings.Builder
+	for _, word := range strings.Fields(s.text) {
+		if b.Len() > 0 && llm.EstimateTokens(b.String()+" "+word) >= maxTokens {
+			pieces = append(pieces, sentence{text: b.String(), paragraph: s.paragraph})
+			b.Reset()
+		}
+		if b.Len() > 0 {
+			b.WriteString(" ")
+		}
+		b.WriteString(word)
+	}
+	if b.Len() > 0 {
+		pieces = append(pieces, sentence{text: b.String(), paragraph: s.paragraph})
+	}
+	return pieces
+}
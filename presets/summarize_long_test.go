@@ -0,0 +1,63 @@
+package presets
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm"
+	"github.com/teilomillet/gollm/llm"
+)
+
+// summaryLLM answers summarization requests with the numbers of the sections
+// found in the text, so summaries shrink while keeping track of their input.
+type summaryLLM struct {
+	gollm.LLM
+	mu      sync.Mutex
+	prompts []*gollm.Prompt
+}
+
+func (s *summaryLLM) Generate(ctx context.Context, prompt *llm.Prompt, opts ...llm.GenerateOption) (string, error) {
+	s.mu.Lock()
+	s.prompts = append(s.prompts, prompt)
+	s.mu.Unlock()
+	var sections []string
+	for _, word := range strings.Fields(prompt.Input) {
+		if strings.HasPrefix(word, "S") && strings.Trim(word, "S0123456789.") == "" {
+			sections = append(sections, strings.TrimSuffix(word, "."))
+		}
+	}
+	return strings.Join(sections, " ") + ".", nil
+}
+
+func TestSummarizeLong(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("MapReduce", func(t *testing.T) {
+		var doc strings.Builder
+		for i := 1; i <= 12; i++ {
+			fmt.Fprintf(&doc, "Section S%d covers one topic of the report in some detail. It is followed by more text.\n", i)
+		}
+		l := &summaryLLM{}
+		summary, err := SummarizeLong(ctx, l, doc.String(), WithChunkTokens(60), WithSummaryStyle("bullet points"), WithSummaryLength(50), WithSummaryConcurrency(2))
+		require.NoError(t, err)
+		assert.Equal(t, "S1 S2 S3 S4 S5 S6 S7 S8 S9 S10 S11 S12.", summary, "every section reaches the final summary, in order")
+		assert.Greater(t, len(l.prompts), 2)
+
+		final := l.prompts[len(l.prompts)-1]
+		assert.Contains(t, final.Directives, "Write the summary as bullet points")
+		assert.Contains(t, final.Directives, "Keep the summary to about 50 words")
+		assert.Contains(t, l.prompts[0].Directives, "This is one part of a longer document; summarize only this part")
+	})
+
+	t.Run("ShortText", func(t *testing.T) {
+		l := &summaryLLM{}
+		_, err := SummarizeLong(ctx, l, "A short note about S1.")
+		require.NoError(t, err)
+		assert.Len(t, l.prompts, 1)
+	})
+}
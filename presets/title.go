@@ -0,0 +1,204 @@
+// Package presets provides utilities for enhancing Language Learning Model interactions
+// with specific reasoning patterns and text processing capabilities.
+package presets
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/teilomillet/gollm"
+	"github.com/teilomillet/gollm/llm"
+)
+
+// ConversationTitle is a short title and topical tags for a conversation.
+type ConversationTitle struct {
+	Title string   `json:"title" validate:"required,max=80" jsonschema:"description=Short title of at most 6 words, without quotes or final period"`
+	Tags  []string `json:"tags" validate:"max=5" jsonschema:"description=Up to 5 lowercase topical tags"`
+}
+
+// titleTranscriptTokens caps the transcript sent for titling; the start of
+// a conversation and its latest turns carry its topic.
+const titleTranscriptTokens = 2000
+
+// titleTemplate asks for the title and tags of a conversation.
+var titleTemplate = gollm.NewPromptTemplate(
+	"TitleConversation",
+	"Title and tag a conversation",
+	"Give a short title and topical tags to the following conversation:\n\n{{.Transcript}}",
+	gollm.WithPromptOptions(
+		gollm.WithDirectives(
+			"Describe the subject of the conversation, not the fact that it is a conversation",
+			"Write the title in the language of the conversation",
+			"Use tags of one or two words, such as the technologies, products or domains discussed",
+		),
+	),
+)
+
+// TitleConversation generates a title and tags for a conversation from its
+// messages, e.g. the history of an LLM with memory. Long conversations are
+// shortened to their first message and latest turns.
+//
+// To title a conversation as it goes on without a request per turn, use a
+// ConversationTitler.
+//
+// Example usage:
+//
+//	title, err := TitleConversation(ctx, cheapLLM, chat.GetMemory())
+//	fmt.Println(title.Title, title.Tags)
+func TitleConversation(ctx context.Context, l gollm.LLM, messages []gollm.MemoryMessage, opts ...gollm.PromptOption) (*ConversationTitle, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context cannot be nil")
+	}
+	if l == nil {
+		return nil, fmt.Errorf("LLM instance cannot be nil")
+	}
+	transcript := titleTranscript(messages)
+	if transcript == "" {
+		return nil, fmt.Errorf("conversation cannot be empty")
+	}
+
+	prompt, err := titleTemplate.Execute(map[string]interface{}{
+		"Transcript": transcript,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute title template: %w", err)
+	}
+	prompt.Apply(opts...)
+	title, err := GenerateStructured[ConversationTitle](ctx, l, prompt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to title conversation: %w", err)
+	}
+
+	title.Title = strings.TrimRight(strings.Trim(strings.TrimSpace(title.Title), `"'`), ".")
+	seen := make(map[string]bool)
+	tags := title.Tags[:0]
+	for _, tag := range title.Tags {
+		tag = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(tag, "#")))
+		if tag != "" && !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	title.Tags = tags
+	return title, nil
+}
+
+// titleTranscript renders the messages as a transcript within
+// titleTranscriptTokens, keeping the first message and the latest ones.
+func titleTranscript(messages []gollm.MemoryMessage) string {
+	var lines []string
+	for _, m := range messages {
+		if m.Role == "system" || strings.TrimSpace(m.Content) == "" {
+			continue
+		}
+		lines = append(lines, m.Role+": "+strings.TrimSpace(m.Content))
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+
+	first := truncateTokens(lines[0], titleTranscriptTokens/2)
+	budget := titleTranscriptTokens - llm.EstimateTokens(first)
+	var recent []string
+	for i := len(lines) - 1; i > 0 && budget > 0; i-- {
+		line := truncateTokens(lines[i], budget)
+		recent = append([]string{line}, recent...)
+		budget -= llm.EstimateTokens(line) + 1
+	}
+	if len(recent) < len(lines)-1 {
+		recent = append([]string{"[...]"}, recent...)
+	}
+	return strings.Join(append([]string{first}, recent...), "\n")
+}
+
+// truncateTokens cuts text to about maxTokens, on a word boundary.
+func truncateTokens(text string, maxTokens int) string {
+	if llm.EstimateTokens(text) <= maxTokens {
+		return text
+	}
+	end := maxTokens * 4
+	for end > 0 && !utf8.RuneStart(text[end]) {
+		end--
+	}
+	cut := text[:end]
+	if i := strings.LastIndexByte(cut, ' '); i > 0 {
+		cut = cut[:i]
+	}
+	return cut + " [...]"
+}
+
+// ConversationTitler keeps the title of a growing conversation up to date
+// while throttling requests: the conversation is titled once it has
+// MinMessages messages and retitled only after Every new messages, so most
+// turns reuse the current title at no cost. It is safe for concurrent use.
+type ConversationTitler struct {
+	// LLM generates the titles; a small, cheap model is usually enough
+	LLM gollm.LLM
+
+	// MinMessages is the number of messages before the first title,
+	// 2 (one exchange) if zero
+	MinMessages int
+
+	// Every is the number of new messages before the title is refreshed,
+	// 10 if zero; a negative value keeps the first title
+	Every int
+
+	mu       sync.Mutex
+	title    *ConversationTitle
+	messages int
+}
+
+// Update returns the title of the conversation, generating it if the
+// conversation has grown enough since the last title. The boolean reports
+// whether a new title was generated; the title is nil while the conversation
+// is shorter than MinMessages.
+//
+// Example usage:
+//
+//	titler := &presets.ConversationTitler{LLM: cheapLLM}
+//	// after each turn
+//	if title, changed, err := titler.Update(ctx, chat.GetMemory()); err == nil && changed {
+//	    ui.SetTitle(title.Title)
+//	}
+func (t *ConversationTitler) Update(ctx context.Context, messages []gollm.MemoryMessage, opts ...gollm.PromptOption) (*ConversationTitle, bool, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	minMessages, every := t.MinMessages, t.Every
+	if minMessages <= 0 {
+		minMessages = 2
+	}
+	if every == 0 {
+		every = 10
+	}
+	switch {
+	case len(messages) < minMessages:
+		return t.title, false, nil
+	case t.title != nil && (every < 0 || len(messages)-t.messages < every):
+		return t.title, false, nil
+	}
+
+	title, err := TitleConversation(ctx, t.LLM, messages, opts...)
+	if err != nil {
+		return t.title, false, err
+	}
+	t.title, t.messages = title, len(messages)
+	return title, true, nil
+}
+
+// Title returns the current title, or nil if none was generated yet.
+func (t *ConversationTitler) Title() *ConversationTitle {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.title
+}
+
+// Reset forgets the title, for a new conversation.
+func (t *ConversationTitler) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.title, t.messages = nil, 0
+}
@@ -0,0 +1,86 @@
+package presets
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm"
+	"github.com/teilomillet/gollm/gollmtest"
+	"github.com/teilomillet/gollm/llm"
+)
+
+func TestTitleConversation(t *testing.T) {
+	ctx := context.Background()
+	messages := []gollm.MemoryMessage{
+		{Role: "system", Content: "You are helpful."},
+		{Role: "user", Content: "How do I read a file in Go?"},
+		{Role: "assistant", Content: "Use os.ReadFile."},
+	}
+
+	t.Run("Title", func(t *testing.T) {
+		l := &gollmtest.ScriptedLLM{Responses: []string{`{"title": "\"Reading files in Go.\"", "tags": ["Go", "#files", "go"]}`}}
+		title, err := TitleConversation(ctx, l, messages)
+		require.NoError(t, err)
+		assert.Equal(t, "Reading files in Go", title.Title)
+		assert.Equal(t, []string{"go", "files"}, title.Tags)
+		require.Len(t, l.Prompts(), 1)
+		assert.Contains(t, l.Prompts()[0].Input, "user: How do I read a file in Go?\nassistant: Use os.ReadFile.")
+		assert.NotContains(t, l.Prompts()[0].Input, "You are helpful")
+	})
+
+	t.Run("LongTranscript", func(t *testing.T) {
+		long := []gollm.MemoryMessage{{Role: "user", Content: "First question about Kubernetes."}}
+		for i := 0; i < 100; i++ {
+			long = append(long, gollm.MemoryMessage{Role: "assistant", Content: strings.Repeat("word ", 100)})
+		}
+		long = append(long, gollm.MemoryMessage{Role: "user", Content: "Last question."})
+		transcript := titleTranscript(long)
+		assert.LessOrEqual(t, llm.EstimateTokens(transcript), titleTranscriptTokens+10)
+		assert.True(t, strings.HasPrefix(transcript, "user: First question about Kubernetes.\n[...]"))
+		assert.True(t, strings.HasSuffix(transcript, "user: Last question."))
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		_, err := TitleConversation(ctx, &gollmtest.ScriptedLLM{}, messages[:1])
+		assert.Error(t, err)
+	})
+}
+
+func TestConversationTitler(t *testing.T) {
+	ctx := context.Background()
+	l := &gollmtest.ScriptedLLM{Responses: []string{
+		`{"title": "Go files", "tags": ["go"]}`,
+		`{"title": "Go files and errors", "tags": ["go", "errors"]}`,
+	}}
+	titler := &ConversationTitler{LLM: l, Every: 4}
+
+	var messages []gollm.MemoryMessage
+	turn := func() (*ConversationTitle, bool) {
+		messages = append(messages,
+			gollm.MemoryMessage{Role: "user", Content: "question"},
+			gollm.MemoryMessage{Role: "assistant", Content: "answer"},
+		)
+		title, changed, err := titler.Update(ctx, messages)
+		require.NoError(t, err)
+		return title, changed
+	}
+
+	title, changed := turn()
+	assert.True(t, changed)
+	assert.Equal(t, "Go files", title.Title)
+
+	title, changed = turn()
+	assert.False(t, changed, "two new messages do not refresh the title")
+	assert.Equal(t, "Go files", title.Title)
+
+	title, changed = turn()
+	assert.True(t, changed)
+	assert.Equal(t, "Go files and errors", title.Title)
+	assert.Len(t, l.Prompts(), 2)
+
+	titler.Reset()
+	assert.Nil(t, titler.Title())
+}
@@ -0,0 +1,124 @@
+// Package presets provides utilities for enhancing Language Learning Model interactions
+// with specific reasoning patterns and text processing capabilities.
+package presets
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/teilomillet/gollm"
+)
+
+// LanguageDetection is the language of a text as detected by DetectLanguage.
+type LanguageDetection struct {
+	Language   string  `json:"language" validate:"required" jsonschema:"description=ISO 639-1 code of the main language of the text, e.g. en or fr"`
+	Name       string  `json:"name" validate:"required" jsonschema:"description=English name of the language"`
+	Confidence float64 `json:"confidence" validate:"gte=0,lte=1" jsonschema:"description=Confidence from 0 to 1"`
+}
+
+// Translation is a text translated by Translate.
+type Translation struct {
+	Text           string `json:"text" validate:"required" jsonschema:"description=The translated text"`
+	SourceLanguage string `json:"source_language" validate:"required" jsonschema:"description=ISO 639-1 code of the original language"`
+	TargetLanguage string `json:"target_language" validate:"required" jsonschema:"description=ISO 639-1 code of the target language"`
+}
+
+// detectLanguageTemplate asks for the language of a text.
+var detectLanguageTemplate = gollm.NewPromptTemplate(
+	"DetectLanguage",
+	"Detect the language of the given text",
+	"Identify the language of the following text:\n\n{{.Text}}",
+	gollm.WithPromptOptions(
+		gollm.WithDirectives(
+			"Report the main language if several are mixed",
+			"Lower the confidence for short or ambiguous texts",
+		),
+	),
+)
+
+// translateTemplate asks for the translation of a text.
+var translateTemplate = gollm.NewPromptTemplate(
+	"Translate",
+	"Translate the given text",
+	"Translate the following text into {{.Target}}:\n\n{{.Text}}",
+	gollm.WithPromptOptions(
+		gollm.WithDirectives(
+			"Preserve the meaning, tone and formatting of the original",
+			"Keep names, code and URLs unchanged",
+		),
+	),
+)
+
+// DetectLanguage identifies the language of a text, returning its ISO 639-1
+// code, English name and a confidence score.
+//
+// Example usage:
+//
+//	detected, err := DetectLanguage(ctx, llm, "¿Dónde está la biblioteca?")
+//	fmt.Println(detected.Language) // es
+func DetectLanguage(ctx context.Context, l gollm.LLM, text string, opts ...gollm.PromptOption) (*LanguageDetection, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context cannot be nil")
+	}
+	if l == nil {
+		return nil, fmt.Errorf("LLM instance cannot be nil")
+	}
+	if strings.TrimSpace(text) == "" {
+		return nil, fmt.Errorf("text cannot be empty")
+	}
+
+	prompt, err := detectLanguageTemplate.Execute(map[string]interface{}{
+		"Text": text,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute detect language template: %w", err)
+	}
+	prompt.Apply(opts...)
+	detected, err := GenerateStructured[LanguageDetection](ctx, l, prompt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect language: %w", err)
+	}
+	detected.Language = strings.ToLower(detected.Language)
+	return detected, nil
+}
+
+// Translate translates a text into the target language, given by name or
+// code (e.g. "German" or "de"), and reports the detected source language.
+//
+// Example usage:
+//
+//	translation, err := Translate(ctx, llm, "The meeting is postponed.", "French",
+//	    gollm.WithDirectives("Use a formal register"),
+//	)
+//	fmt.Println(translation.Text)
+func Translate(ctx context.Context, l gollm.LLM, text, targetLang string, opts ...gollm.PromptOption) (*Translation, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("context cannot be nil")
+	}
+	if l == nil {
+		return nil, fmt.Errorf("LLM instance cannot be nil")
+	}
+	if strings.TrimSpace(text) == "" {
+		return nil, fmt.Errorf("text cannot be empty")
+	}
+	if strings.TrimSpace(targetLang) == "" {
+		return nil, fmt.Errorf("target language cannot be empty")
+	}
+
+	prompt, err := translateTemplate.Execute(map[string]interface{}{
+		"Text":   text,
+		"Target": targetLang,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute translate template: %w", err)
+	}
+	prompt.Apply(opts...)
+	translation, err := GenerateStructured[Translation](ctx, l, prompt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to translate text: %w", err)
+	}
+	translation.SourceLanguage = strings.ToLower(translation.SourceLanguage)
+	translation.TargetLanguage = strings.ToLower(translation.TargetLanguage)
+	return translation, nil
+}
@@ -0,0 +1,47 @@
+package presets
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm/gollmtest"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	l := &gollmtest.ScriptedLLM{Responses: []string{`{"language": "ES", "name": "Spanish", "confidence": 0.97}`}}
+	detected, err := DetectLanguage(context.Background(), l, "¿Dónde está la biblioteca?")
+	require.NoError(t, err)
+	assert.Equal(t, &LanguageDetection{Language: "es", Name: "Spanish", Confidence: 0.97}, detected)
+	assert.Contains(t, l.Prompts()[0].Input, "¿Dónde está la biblioteca?")
+}
+
+func TestTranslate(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Translate", func(t *testing.T) {
+		l := &gollmtest.ScriptedLLM{Responses: []string{`{"text": "La réunion est reportée.", "source_language": "en", "target_language": "fr"}`}}
+		translation, err := Translate(ctx, l, "The meeting is postponed.", "French")
+		require.NoError(t, err)
+		assert.Equal(t, "La réunion est reportée.", translation.Text)
+		assert.Equal(t, "en", translation.SourceLanguage)
+		assert.Contains(t, l.Prompts()[0].Input, "into French")
+	})
+
+	t.Run("RepairsInvalidOutput", func(t *testing.T) {
+		l := &gollmtest.ScriptedLLM{Responses: []string{
+			`{"text": "", "source_language": "en", "target_language": "de"}`,
+			`{"text": "Hallo", "source_language": "en", "target_language": "de"}`,
+		}}
+		translation, err := Translate(ctx, l, "Hello", "de")
+		require.NoError(t, err)
+		assert.Equal(t, "Hallo", translation.Text)
+		assert.Len(t, l.Prompts(), 2)
+	})
+
+	t.Run("EmptyTarget", func(t *testing.T) {
+		_, err := Translate(ctx, &gollmtest.ScriptedLLM{}, "Hello", " ")
+		assert.Error(t, err)
+	})
+}
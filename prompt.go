@@ -8,6 +8,7 @@ import (
 
 	"github.com/teilomillet/gollm/config"
 	"github.com/teilomillet/gollm/llm"
+	"github.com/teilomillet/gollm/types"
 	"github.com/teilomillet/gollm/utils"
 )
 
@@ -27,6 +28,42 @@ type (
 	// It can be a system message, user message, or assistant message.
 	PromptMessage = llm.PromptMessage
 
+	// Usage reports the tokens consumed by a request.
+	Usage = llm.Usage
+
+	// TokenLogprob is the log probability of a generated token and its top alternatives.
+	TokenLogprob = llm.TokenLogprob
+
+	// TopLogprob is an alternative token at a position of the response.
+	TopLogprob = llm.TopLogprob
+
+	// Citation is a source supporting part of a response.
+	Citation = llm.Citation
+
+	// ContentFilters is the content filter information of a response.
+	ContentFilters = llm.ContentFilters
+
+	// ContentFilterResult is the verdict of a content filter on one category.
+	ContentFilterResult = llm.ContentFilterResult
+
+	// DryRun is the request a Generate call would have sent, with its estimated tokens and cost.
+	DryRun = llm.DryRun
+
+	// RetryBudget limits the attempts and time of a request across retries and fallbacks.
+	RetryBudget = llm.RetryBudget
+
+	// Generation is a Generate call running in the background that can be aborted.
+	Generation = llm.Generation
+
+	// Selector picks the completion returned among those requested with WithChoices.
+	Selector = llm.Selector
+
+	// RouteRequirements are the constraints a Router satisfies when choosing a model.
+	RouteRequirements = llm.RouteRequirements
+
+	// Priority orders requests waiting for a provider's concurrency limit.
+	Priority = llm.Priority
+
 	// Function defines a callable function that can be used by the LLM.
 	// It includes metadata like name, description, and parameter schemas.
 	Function = utils.Function
@@ -54,6 +91,45 @@ type (
 	// PromptTemplate defines a reusable template for generating prompts.
 	// Templates can include variables that are filled in at runtime.
 	PromptTemplate = llm.PromptTemplate
+
+	// PromptLibrary is a registry of named, versioned prompt templates that
+	// can be loaded from a directory of prompt files and hot-reloaded.
+	PromptLibrary = llm.PromptLibrary
+
+	// Image represents an image attachment for vision-capable models.
+	// It can reference a remote URL, inline base64 data, or a local file.
+	Image = types.Image
+
+	// Document represents a file attachment, such as a PDF, for models with document understanding.
+	// It can reference a remote URL, inline base64 data, a local file, or an uploaded file ID.
+	Document = types.Document
+
+	// Example is an input and its expected output, shown as a few-shot example.
+	Example = llm.Example
+
+	// OutputFormat is a format the response of a prompt is requested and checked in.
+	OutputFormat = llm.OutputFormat
+
+	// SystemLayer is a named part of a layered system prompt.
+	SystemLayer = llm.SystemLayer
+
+	// SystemPlacement sets where the system prompt is sent.
+	SystemPlacement = llm.SystemPlacement
+
+	// PromptProcessor modifies a prompt before it is sent.
+	PromptProcessor = llm.PromptProcessor
+
+	// ResponseProcessor modifies a response after it is parsed.
+	ResponseProcessor = llm.ResponseProcessor
+
+	// ProcessorUser is implemented by LLMs that apply processors to all their requests.
+	ProcessorUser = llm.ProcessorUser
+
+	// Repair is a fix applied to a JSON response by SanitizeJSON.
+	Repair = llm.Repair
+
+	// SanitizeReport lists the repairs applied to a JSON response.
+	SanitizeReport = llm.SanitizeReport
 )
 
 // Cache type constants define the available caching strategies.
@@ -63,6 +139,48 @@ const (
 	CacheTypeEphemeral = llm.CacheTypeEphemeral
 )
 
+// Output formats of responses.
+const (
+	OutputJSON     = llm.OutputJSON     // A JSON value
+	OutputYAML     = llm.OutputYAML     // A YAML document
+	OutputMarkdown = llm.OutputMarkdown // Markdown text
+	OutputCSV      = llm.OutputCSV      // CSV with a header row
+)
+
+// Repairs applied by SanitizeJSON.
+const (
+	RepairCodeFence       = llm.RepairCodeFence       // Removed the markdown code fence around the JSON
+	RepairSurroundingText = llm.RepairSurroundingText // Cut the JSON out of the text around it
+	RepairTrailingComma   = llm.RepairTrailingComma   // Dropped commas before a closing brace or bracket
+	RepairSingleQuotes    = llm.RepairSingleQuotes    // Turned single-quoted strings into double-quoted ones
+	RepairTruncated       = llm.RepairTruncated       // Closed what a cut-off response left open
+)
+
+// Tool choice modes. Any other tool choice names the tool to call.
+const (
+	ToolChoiceAuto     = llm.ToolChoiceAuto     // The model decides whether to call tools
+	ToolChoiceNone     = llm.ToolChoiceNone     // The model must not call tools
+	ToolChoiceRequired = llm.ToolChoiceRequired // The model must call at least one tool
+)
+
+// Standard system prompt layers and placements.
+const (
+	SystemLayerPersona      = llm.SystemLayerPersona      // Who the model is and how it speaks
+	SystemLayerTask         = llm.SystemLayerTask         // Instructions for the task at hand
+	SystemLayerSafety       = llm.SystemLayerSafety       // Rules the model must always follow
+	SystemPlacementProvider = llm.SystemPlacementProvider // The provider's system role or field
+	SystemPlacementUser     = llm.SystemPlacementUser     // Within the user message
+)
+
+// Request priorities in a provider's queue.
+const (
+	PriorityInteractive = llm.PriorityInteractive // User-facing requests, the default
+	PriorityBatch       = llm.PriorityBatch       // Background jobs
+)
+
+// MetadataUserKey is the request metadata key of the end-user ID.
+const MetadataUserKey = llm.MetadataUserKey
+
 // The following variables are re-exported functions from the llm package.
 // They provide the primary means of constructing and customizing prompts.
 var (
@@ -84,6 +202,48 @@ var (
 	// WithToolChoice specifies how tools should be selected.
 	WithToolChoice = llm.WithToolChoice
 
+	// WithImage attaches images to the prompt.
+	WithImage = llm.WithImage
+
+	// WithImageURL attaches a remotely hosted image to the prompt.
+	WithImageURL = llm.WithImageURL
+
+	// WithImageBase64 attaches base64-encoded image data to the prompt.
+	WithImageBase64 = llm.WithImageBase64
+
+	// WithImageFile attaches a local image file to the prompt.
+	WithImageFile = llm.WithImageFile
+
+	// WithDocument attaches documents such as PDFs to the prompt.
+	WithDocument = llm.WithDocument
+
+	// WithDocumentURL attaches a remotely hosted document to the prompt.
+	WithDocumentURL = llm.WithDocumentURL
+
+	// WithDocumentFile attaches a local document to the prompt.
+	WithDocumentFile = llm.WithDocumentFile
+
+	// WithDocumentFileID attaches a document previously uploaded to the provider.
+	WithDocumentFileID = llm.WithDocumentFileID
+
+	// WithSystemLayer sets a named layer of the system prompt.
+	WithSystemLayer = llm.WithSystemLayer
+
+	// WithPersona sets the persona layer of the system prompt.
+	WithPersona = llm.WithPersona
+
+	// WithTaskInstructions sets the task layer of the system prompt.
+	WithTaskInstructions = llm.WithTaskInstructions
+
+	// WithSafetyPreamble sets the required safety layer of the system prompt.
+	WithSafetyPreamble = llm.WithSafetyPreamble
+
+	// WithSystemTokenBudget limits the estimated size of the composed system prompt.
+	WithSystemTokenBudget = llm.WithSystemTokenBudget
+
+	// WithSystemPlacement sets where the system prompt is sent.
+	WithSystemPlacement = llm.WithSystemPlacement
+
 	// WithMessages adds multiple messages to the prompt.
 	WithMessages = llm.WithMessages
 
@@ -93,6 +253,21 @@ var (
 	// WithOutput configures the expected output format.
 	WithOutput = llm.WithOutput
 
+	// WithOutputFormat requests the response in JSON, YAML, Markdown or CSV and checks it.
+	WithOutputFormat = llm.WithOutputFormat
+
+	// ParseOutput cleans a response and checks that it is valid in a format.
+	ParseOutput = llm.ParseOutput
+
+	// DecodeOutput parses a response in a format into a value.
+	DecodeOutput = llm.DecodeOutput
+
+	// SanitizeJSON repairs a JSON response and reports the repairs applied.
+	SanitizeJSON = llm.SanitizeJSON
+
+	// WithSanitizer repairs the JSON response of a Generate call before it is processed or validated.
+	WithSanitizer = llm.WithSanitizer
+
 	// WithContext adds contextual information to the prompt.
 	WithContext = llm.WithContext
 
@@ -102,6 +277,12 @@ var (
 	// WithExamples adds example conversations or outputs.
 	WithExamples = llm.WithExamples
 
+	// WithExamplePairs adds few-shot examples from inputs and their outputs.
+	WithExamplePairs = llm.WithExamplePairs
+
+	// WithExampleTokenBudget limits the estimated size of the few-shot examples.
+	WithExampleTokenBudget = llm.WithExampleTokenBudget
+
 	// WithExpandedStruct enables detailed structure expansion.
 	WithExpandedStruct = llm.WithExpandedStruct
 
@@ -111,9 +292,144 @@ var (
 	// WithPromptOptions adds multiple prompt options at once.
 	WithPromptOptions = llm.WithPromptOptions
 
+	// NewPromptLibrary creates an empty prompt library.
+	NewPromptLibrary = llm.NewPromptLibrary
+
+	// LoadPromptLibrary creates a prompt library from a directory of prompt files.
+	LoadPromptLibrary = llm.LoadPromptLibrary
+
+	// LoadPromptFile parses a single prompt file into a template.
+	LoadPromptFile = llm.LoadPromptFile
+
+	// WithRequiredVariables declares variables a template must be given.
+	WithRequiredVariables = llm.WithRequiredVariables
+
+	// WithDefaults sets fallback values for template variables.
+	WithDefaults = llm.WithDefaults
+
+	// WithPartial registers a named sub-template usable with {{template "name" .}}.
+	WithPartial = llm.WithPartial
+
+	// WithTemplateFuncs adds custom functions to a template.
+	WithTemplateFuncs = llm.WithTemplateFuncs
+
+	// WithEscaper applies an escaping function to string template variables.
+	WithEscaper = llm.WithEscaper
+
+	// WithStrictVariables fails template execution on any missing variable.
+	WithStrictVariables = llm.WithStrictVariables
+
+	// EscapeXML escapes text for embedding between XML-style prompt delimiters.
+	EscapeXML = llm.EscapeXML
+
 	// WithJSONSchemaValidation enables JSON schema validation.
 	WithJSONSchemaValidation = llm.WithJSONSchemaValidation
 
+	// WithRequestOption overrides a provider option for a single Generate call.
+	WithRequestOption = llm.WithRequestOption
+
+	// WithGrammar constrains a Generate call to a GBNF grammar.
+	WithGrammar = llm.WithGrammar
+
+	// WithUsage records the token usage of a Generate call.
+	WithUsage = llm.WithUsage
+
+	// WithLogprobs records the log probabilities of the tokens of a Generate call.
+	WithLogprobs = llm.WithLogprobs
+
+	// WithSystemFingerprint records the system fingerprint of a Generate call.
+	WithSystemFingerprint = llm.WithSystemFingerprint
+
+	// WithFinishReason records why the response of a Generate call finished.
+	WithFinishReason = llm.WithFinishReason
+
+	// WithCitations records the citations of the response of a Generate call.
+	WithCitations = llm.WithCitations
+
+	// WithContentFilters records the content filter annotations of the response of a Generate call.
+	WithContentFilters = llm.WithContentFilters
+
+	// WithDryRun records the request of a Generate call instead of sending it.
+	WithDryRun = llm.WithDryRun
+
+	// WithRetryBudget bounds a Generate call by a retry budget shared across fallbacks.
+	WithRetryBudget = llm.WithRetryBudget
+
+	// NewRetryBudget creates a budget of attempts and elapsed time for a request.
+	NewRetryBudget = llm.NewRetryBudget
+
+	// StartGeneration starts a Generate call in the background, returning a handle to wait for or abort it.
+	StartGeneration = llm.StartGeneration
+
+	// ErrAborted is returned by a Generation stopped with Abort.
+	ErrAborted = llm.ErrAborted
+
+	// WithChoices generates several completions in a Generate call.
+	WithChoices = llm.WithChoices
+
+	// WithSelector sets how the completion returned is picked among those generated.
+	WithSelector = llm.WithSelector
+
+	// SelectFirst picks the first completion.
+	SelectFirst = llm.SelectFirst
+
+	// SelectLongest picks the longest completion.
+	SelectLongest = llm.SelectLongest
+
+	// SelectByJudge picks the completion an LLM judges best.
+	SelectByJudge = llm.SelectByJudge
+
+	// WithProfile sends a Generate call with a named config profile.
+	WithProfile = llm.WithProfile
+
+	// WithModel sends a Generate call to another model or model alias.
+	WithModel = llm.WithModel
+
+	// WithRouteRequirements overrides a Router's constraints for a single request.
+	WithRouteRequirements = llm.WithRouteRequirements
+
+	// WithPriority sets the priority of a Generate call in the provider's queue.
+	WithPriority = llm.WithPriority
+
+	// WithStreamPriority sets the priority of a stream in the provider's queue.
+	WithStreamPriority = llm.WithStreamPriority
+
+	// WithMetadata attaches key/value metadata such as the user or feature to a Generate call.
+	WithMetadata = llm.WithMetadata
+
+	// WithStreamMetadata attaches key/value metadata to a stream.
+	WithStreamMetadata = llm.WithStreamMetadata
+
+	// WithStreamRequestOption overrides a provider option for a single Stream call.
+	WithStreamRequestOption = llm.WithStreamRequestOption
+
+	// WithPromptProcessors runs processors on the prompt of a Generate call.
+	WithPromptProcessors = llm.WithPromptProcessors
+
+	// WithResponseProcessors runs processors on the response of a Generate call.
+	WithResponseProcessors = llm.WithResponseProcessors
+
+	// InjectSystemPrompt places a system prompt ahead of the prompt's own.
+	InjectSystemPrompt = llm.InjectSystemPrompt
+
+	// AddContext appends text computed per request to the prompt's context.
+	AddContext = llm.AddContext
+
+	// AddCurrentDate appends the current date to the prompt's context.
+	AddCurrentDate = llm.AddCurrentDate
+
+	// AddCurrentDateFrom appends the date of a clock to the prompt's context.
+	AddCurrentDateFrom = llm.AddCurrentDateFrom
+
+	// ApplyTemplate rewrites the prompt's input with a text/template.
+	ApplyTemplate = llm.ApplyTemplate
+
+	// StripCodeFences removes the markdown code fence enclosing a response.
+	StripCodeFences = llm.StripCodeFences
+
+	// TrimSpace removes white space around a response.
+	TrimSpace = llm.TrimSpace
+
 	// WithStream enables or disables streaming responses.
 	WithStream = config.WithStream
 )
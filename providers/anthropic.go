@@ -76,10 +76,13 @@ func (p *AnthropicProvider) SetOption(key string, value interface{}) {
 // SetDefaultOptions configures standard options from the global configuration.
 // This includes temperature, max tokens, and sampling parameters.
 func (p *AnthropicProvider) SetDefaultOptions(config *config.Config) {
-	p.SetOption("temperature", config.Temperature)
-	p.SetOption("max_tokens", config.MaxTokens)
-	if config.Seed != nil {
-		p.SetOption("seed", *config.Seed)
+	setSamplingDefaults(p, config)
+	if config.ReasoningBudget != nil {
+		if supportsReasoning(p.Name(), p.model) {
+			setThinking(p.options, *config.ReasoningBudget)
+		} else {
+			p.logger.Warn("Reasoning budget ignored: the model does not reason", "model", p.model)
+		}
 	}
 }
 
@@ -113,9 +116,67 @@ func (p *AnthropicProvider) Headers() map[string]string {
 		"anthropic-version": "2023-06-01",
 		"anthropic-beta":    "prompt-caching-2024-07-31",
 	}
+	for k, v := range p.extraHeaders {
+		headers[k] = v
+	}
 	return headers
 }
 
+// anthropicRequest is the body of an Anthropic messages request. Other
+// parameters, such as temperature, are passed through as options.
+type anthropicRequest struct {
+	Model      string             `json:"model"`
+	MaxTokens  interface{}        `json:"max_tokens"`
+	System     interface{}        `json:"system,omitempty"`
+	Messages   []anthropicMessage `json:"messages"`
+	Tools      interface{}        `json:"tools,omitempty"`
+	ToolChoice interface{}        `json:"tool_choice,omitempty"`
+	Stream     bool               `json:"stream,omitempty"`
+}
+
+// anthropicMessage is a message of an Anthropic messages request.
+type anthropicMessage struct {
+	Role    string      `json:"role"`
+	Content interface{} `json:"content"`
+}
+
+// newAnthropicRequest starts a request with the maximum number of tokens of
+// the options, else of the defaults, which the API requires.
+func newAnthropicRequest(model string, defaults, options map[string]interface{}) anthropicRequest {
+	request := anthropicRequest{Model: model, MaxTokens: options["max_tokens"]}
+	if request.MaxTokens == nil {
+		request.MaxTokens = defaults["max_tokens"]
+	}
+	if request.MaxTokens == nil {
+		request.MaxTokens = 1024
+	}
+	return request
+}
+
+// setTools converts the tools of the options, choosing tools automatically
+// unless the options tell otherwise. It returns the number of tools.
+func (r *anthropicRequest) setTools(options map[string]interface{}) int {
+	tools, ok := options["tools"].([]utils.Tool)
+	if !ok || len(tools) == 0 {
+		return 0
+	}
+	r.Tools = anthropicTools(tools)
+	r.ToolChoice = anthropicToolChoice(options["tool_choice"])
+	return len(tools)
+}
+
+// defaults returns the options set on the provider, which are defaults for
+// the request options; the API has no seed parameter.
+func (p *AnthropicProvider) defaults() map[string]interface{} {
+	defaults := make(map[string]interface{}, len(p.options))
+	for k, v := range p.options {
+		if k != "seed" {
+			defaults[k] = v
+		}
+	}
+	return defaults
+}
+
 // PrepareRequest creates the request body for an Anthropic API call.
 // It handles:
 //   - Message formatting
@@ -131,12 +192,7 @@ func (p *AnthropicProvider) Headers() map[string]string {
 //   - Serialized JSON request body
 //   - Any error encountered during preparation
 func (p *AnthropicProvider) PrepareRequest(prompt string, options map[string]interface{}) ([]byte, error) {
-	requestBody := map[string]interface{}{
-		"model":      p.model,
-		"max_tokens": p.options["max_tokens"],
-		"system":     []map[string]interface{}{},
-		"messages":   []map[string]interface{}{},
-	}
+	request := newAnthropicRequest(p.model, p.options, options)
 
 	// Handle system prompt
 	systemPrompt := ""
@@ -145,81 +201,61 @@ func (p *AnthropicProvider) PrepareRequest(prompt string, options map[string]int
 	}
 
 	// If we have tools, add tool usage instructions to the system prompt
-	if tools, ok := options["tools"].([]utils.Tool); ok && len(tools) > 0 {
-		anthropicTools := make([]map[string]interface{}, len(tools))
-		for i, tool := range tools {
-			anthropicTools[i] = map[string]interface{}{
-				"name":         tool.Function.Name,
-				"description":  tool.Function.Description,
-				"input_schema": tool.Function.Parameters,
-			}
-		}
-		requestBody["tools"] = anthropicTools
-
-		// Add tool usage instructions to system prompt
-		if len(tools) > 1 {
-			toolUsagePrompt := "When multiple tools are needed to answer a question, you should identify all required tools upfront and use them all at once in your response, rather than using them sequentially. Do not wait for tool results before calling other tools."
-			if systemPrompt != "" {
-				systemPrompt = toolUsagePrompt + "\n\n" + systemPrompt
-			} else {
-				systemPrompt = toolUsagePrompt
-			}
-		}
-
-		// Only set tool_choice when tools are provided
-		if toolChoice, ok := options["tool_choice"].(string); ok {
-			requestBody["tool_choice"] = map[string]interface{}{
-				"type": toolChoice,
-			}
+	if request.setTools(options) > 1 {
+		toolUsagePrompt := "When multiple tools are needed to answer a question, you should identify all required tools upfront and use them all at once in your response, rather than using them sequentially. Do not wait for tool results before calling other tools."
+		if systemPrompt != "" {
+			systemPrompt = toolUsagePrompt + "\n\n" + systemPrompt
 		} else {
-			// Default to auto for tool choice when tools are provided
-			requestBody["tool_choice"] = map[string]interface{}{
-				"type": "auto",
-			}
+			systemPrompt = toolUsagePrompt
 		}
 	}
 
 	// Add system prompt if we have one
 	if systemPrompt != "" {
-		parts := splitSystemPrompt(systemPrompt, 3)
-		for i, part := range parts {
-			systemMessage := map[string]interface{}{
-				"type": "text",
-				"text": part,
-			}
-			if i > 0 {
-				systemMessage["cache_control"] = map[string]string{"type": "ephemeral"}
-			}
-			requestBody["system"] = append(requestBody["system"].([]map[string]interface{}), systemMessage)
-		}
+		request.System = anthropicSystemBlocks(systemPrompt)
 	}
 
 	// Handle user message with potential caching
-	userMessage := map[string]interface{}{
-		"role": "user",
-		"content": []map[string]interface{}{
-			{
-				"type": "text",
-				"text": prompt,
-			},
+	content := []map[string]interface{}{
+		{
+			"type": "text",
+			"text": prompt,
 		},
 	}
 
 	// Add cache_control only if caching is enabled
 	if caching, ok := options["enable_caching"].(bool); ok && caching {
-		userMessage["content"].([]map[string]interface{})[0]["cache_control"] = map[string]string{"type": "ephemeral"}
+		content[0]["cache_control"] = map[string]string{"type": "ephemeral"}
 	}
 
-	requestBody["messages"] = append(requestBody["messages"].([]map[string]interface{}), userMessage)
-
-	// Add other options
-	for k, v := range options {
-		if k != "system_prompt" && k != "max_tokens" && k != "tools" && k != "tool_choice" && k != "enable_caching" {
-			requestBody[k] = v
+	// Documents and images precede the text block, as recommended by Anthropic
+	if hasAttachments(options) {
+		attachments, err := anthropicAttachmentBlocks(options)
+		if err != nil {
+			return nil, err
 		}
+		content = append(attachments, content...)
 	}
 
-	return json.Marshal(requestBody)
+	request.Messages = []anthropicMessage{{Role: "user", Content: content}}
+	return marshalRequest(request, p.defaults(), options)
+}
+
+// anthropicSystemBlocks splits the system prompt into up to three text
+// blocks, caching all but the first.
+func anthropicSystemBlocks(systemPrompt string) []map[string]interface{} {
+	var blocks []map[string]interface{}
+	for i, part := range splitSystemPrompt(systemPrompt, 3) {
+		block := map[string]interface{}{
+			"type": "text",
+			"text": part,
+		}
+		if i > 0 {
+			block["cache_control"] = map[string]string{"type": "ephemeral"}
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks
 }
 
 // Helper function to split the system prompt into a maximum of n parts
@@ -273,22 +309,14 @@ func (p *AnthropicProvider) PrepareRequestWithSchema(prompt string, options map[
 	// Create a system message that enforces the JSON schema
 	systemMsg := fmt.Sprintf("You must respond with a JSON object that strictly adheres to this schema:\n%s\nDo not include any explanatory text, only output valid JSON.", string(schemaJSON))
 
-	requestBody := map[string]interface{}{
-		"model":  p.model,
-		"system": systemMsg,
-		"messages": []map[string]string{
-			{"role": "user", "content": prompt},
-		},
-	}
-
-	// Add any additional options
-	for k, v := range options {
-		if k != "system_prompt" { // Skip system_prompt as we're using it for schema
-			requestBody[k] = v
-		}
+	content, err := anthropicUserContent(prompt, options)
+	if err != nil {
+		return nil, err
 	}
-
-	return json.Marshal(requestBody)
+	request := newAnthropicRequest(p.model, p.options, options)
+	request.System = systemMsg // Replaces the system prompt
+	request.Messages = []anthropicMessage{{Role: "user", Content: content}}
+	return marshalRequest(request, p.defaults(), options)
 }
 
 // ParseResponse extracts the generated text from the Anthropic API response.
@@ -301,6 +329,9 @@ func (p *AnthropicProvider) PrepareRequestWithSchema(prompt string, options map[
 //   - Generated text content
 //   - Any error encountered during parsing
 func (p *AnthropicProvider) ParseResponse(body []byte) (string, error) {
+	if err := CheckResponseBody(body); err != nil {
+		return "", err
+	}
 	p.logger.Debug("Raw API response: %s", string(body))
 
 	var response struct {
@@ -439,44 +470,22 @@ func (p *AnthropicProvider) SupportsStreaming() bool {
 
 // PrepareStreamRequest creates a request body for streaming API calls
 func (p *AnthropicProvider) PrepareStreamRequest(prompt string, options map[string]interface{}) ([]byte, error) {
-	requestBody := map[string]interface{}{
-		"model":  p.model,
-		"stream": true,
-		"messages": []map[string]interface{}{
-			{
-				"role":    "user",
-				"content": prompt,
-			},
-		},
-		"max_tokens": 1024, // Default max tokens
+	content, err := anthropicUserContent(prompt, options)
+	if err != nil {
+		return nil, err
 	}
+	request := newAnthropicRequest(p.model, p.options, options)
+	request.Stream = true
+	request.Messages = []anthropicMessage{{Role: "user", Content: content}}
 
 	// Add system prompt if present
 	if systemPrompt, ok := options["system_prompt"].(string); ok && systemPrompt != "" {
-		requestBody["system"] = systemPrompt
-		delete(options, "system_prompt")
-	}
-
-	// Add max tokens if present
-	if maxTokens, ok := options["max_tokens"].(int); ok {
-		requestBody["max_tokens"] = maxTokens
-		delete(options, "max_tokens")
-	}
-
-	// Add temperature if present
-	if temperature, ok := options["temperature"].(float64); ok {
-		requestBody["temperature"] = temperature
-		delete(options, "temperature")
+		request.System = systemPrompt
 	}
 
-	// Add other options
-	for k, v := range options {
-		if k != "stream" { // Don't override stream setting
-			requestBody[k] = v
-		}
-	}
-
-	return json.Marshal(requestBody)
+	// Convert tools so that tool calls can be streamed
+	request.setTools(options)
+	return marshalRequest(request, p.defaults(), options)
 }
 
 // ParseStreamResponse processes a single chunk from a streaming response
@@ -534,72 +543,38 @@ func (p *AnthropicProvider) ParseStreamResponse(chunk []byte) (string, error) {
 //   - Serialized JSON request body
 //   - Any error encountered during preparation
 func (p *AnthropicProvider) PrepareRequestWithMessages(messages []types.MemoryMessage, options map[string]interface{}) ([]byte, error) {
-	requestBody := map[string]interface{}{
-		"model":      p.model,
-		"max_tokens": p.options["max_tokens"],
-		"system":     []map[string]interface{}{},
-		"messages":   []map[string]interface{}{},
-	}
-
-	// Extract system prompt if present in options
-	systemPrompt := ""
-	if sp, ok := options["system_prompt"].(string); ok && sp != "" {
-		systemPrompt = sp
-	}
+	request := newAnthropicRequest(p.model, p.options, options)
 
 	// Handle system prompt
-	if systemPrompt != "" {
-		parts := splitSystemPrompt(systemPrompt, 3)
-		for i, part := range parts {
-			systemMessage := map[string]interface{}{
-				"type": "text",
-				"text": part,
-			}
-			if i > 0 {
-				systemMessage["cache_control"] = map[string]string{"type": "ephemeral"}
-			}
-			requestBody["system"] = append(requestBody["system"].([]map[string]interface{}), systemMessage)
-		}
+	var system []map[string]interface{}
+	if systemPrompt, ok := options["system_prompt"].(string); ok && systemPrompt != "" {
+		system = anthropicSystemBlocks(systemPrompt)
 	}
 
-	// Process tools if present
-	if tools, ok := options["tools"].([]utils.Tool); ok && len(tools) > 0 {
-		anthropicTools := make([]map[string]interface{}, len(tools))
-		for i, tool := range tools {
-			anthropicTools[i] = map[string]interface{}{
-				"name":         tool.Function.Name,
-				"description":  tool.Function.Description,
-				"input_schema": tool.Function.Parameters,
-			}
-		}
-		requestBody["tools"] = anthropicTools
-
-		// Add tool usage instructions to system prompt if needed
-		if len(tools) > 1 {
-			toolUsagePrompt := "When multiple tools are needed to answer a question, you should identify all required tools upfront and use them all at once in your response, rather than using them sequentially. Do not wait for tool results before calling other tools."
-			// This is separate from the existing system messages
-			systemMessage := map[string]interface{}{
-				"type": "text",
-				"text": toolUsagePrompt,
-			}
-			requestBody["system"] = append(requestBody["system"].([]map[string]interface{}), systemMessage)
-		}
+	// Process tools if present, with tool usage instructions if needed
+	if request.setTools(options) > 1 {
+		toolUsagePrompt := "When multiple tools are needed to answer a question, you should identify all required tools upfront and use them all at once in your response, rather than using them sequentially. Do not wait for tool results before calling other tools."
+		// This is separate from the existing system messages
+		system = append(system, map[string]interface{}{
+			"type": "text",
+			"text": toolUsagePrompt,
+		})
+	}
+	if len(system) > 0 {
+		request.System = system
+	}
 
-		// Only set tool_choice when tools are provided
-		if toolChoice, ok := options["tool_choice"].(string); ok {
-			requestBody["tool_choice"] = map[string]interface{}{
-				"type": toolChoice,
-			}
-		} else {
-			// Default to auto for tool choice when tools are provided
-			requestBody["tool_choice"] = map[string]interface{}{
-				"type": "auto",
-			}
+	// Images and documents are attached to the most recent user message
+	attach := hasAttachments(options)
+	lastUser := -1
+	for i, msg := range messages {
+		if msg.Role == "user" {
+			lastUser = i
 		}
 	}
 
 	// Convert MemoryMessage objects to Anthropic messages
-	for _, msg := range messages {
+	for i, msg := range messages {
 		content := []map[string]interface{}{
 			{
 				"type": "text",
@@ -615,20 +590,16 @@ func (p *AnthropicProvider) PrepareRequestWithMessages(messages []types.MemoryMe
 			content[0]["cache_control"] = map[string]string{"type": "ephemeral"}
 		}
 
-		message := map[string]interface{}{
-			"role":    msg.Role,
-			"content": content,
+		if i == lastUser && attach {
+			attachments, err := anthropicAttachmentBlocks(options)
+			if err != nil {
+				return nil, err
+			}
+			content = append(attachments, content...)
 		}
 
-		requestBody["messages"] = append(requestBody["messages"].([]map[string]interface{}), message)
-	}
-
-	// Add other options
-	for k, v := range options {
-		if k != "system_prompt" && k != "max_tokens" && k != "tools" && k != "tool_choice" && k != "enable_caching" && k != "structured_messages" {
-			requestBody[k] = v
-		}
+		request.Messages = append(request.Messages, anthropicMessage{Role: msg.Role, Content: content})
 	}
 
-	return json.Marshal(requestBody)
+	return marshalRequest(request, p.defaults(), options)
 }
@@ -0,0 +1,268 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/teilomillet/gollm/utils"
+)
+
+// RunStatus is the state of an assistant run.
+type RunStatus string
+
+const (
+	RunQueued         RunStatus = "queued"          // Waiting to start
+	RunInProgress     RunStatus = "in_progress"     // Generating
+	RunRequiresAction RunStatus = "requires_action" // Waiting for tool outputs
+	RunCompleted      RunStatus = "completed"       // The answer was added to the thread
+	RunFailed         RunStatus = "failed"          // The run stopped on an error
+	RunCanceled       RunStatus = "canceled"        // The run was canceled
+	RunExpired        RunStatus = "expired"         // Tool outputs were not submitted in time
+)
+
+// AssistantSpec describes an assistant to create.
+type AssistantSpec struct {
+	Name         string
+	Model        string
+	Instructions string       // Persistent system instructions
+	Tools        []utils.Tool // Functions the assistant may call
+}
+
+// ThreadMessage is a message of an assistant thread.
+type ThreadMessage struct {
+	Role      string    `json:"role"` // "user" or "assistant"
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RunToolCall is a tool call awaiting its output in a run.
+type RunToolCall struct {
+	ID        string
+	Name      string
+	Arguments json.RawMessage
+}
+
+// RunToolOutput is the output of a tool call submitted to a run.
+type RunToolOutput struct {
+	ToolCallID string `json:"tool_call_id"`
+	Output     string `json:"output"`
+}
+
+// RunState is the state of an assistant run as reported by the provider.
+type RunState struct {
+	ID        string
+	Status    RunStatus
+	ToolCalls []RunToolCall // Calls to answer when the status is RunRequiresAction
+	Error     string        // Reason of a failure
+}
+
+// AssistantAPI is a provider's hosted assistants API, which stores the
+// assistants and their threads. Its methods make their calls with the given
+// client.
+type AssistantAPI interface {
+	CreateAssistant(ctx context.Context, client *http.Client, spec AssistantSpec) (string, error)
+	DeleteAssistant(ctx context.Context, client *http.Client, assistantID string) error
+	CreateThread(ctx context.Context, client *http.Client) (string, error)
+	DeleteThread(ctx context.Context, client *http.Client, threadID string) error
+	AddThreadMessage(ctx context.Context, client *http.Client, threadID string, message ThreadMessage) error
+	// ThreadMessages returns the messages of a thread, oldest first.
+	ThreadMessages(ctx context.Context, client *http.Client, threadID string) ([]ThreadMessage, error)
+	CreateRun(ctx context.Context, client *http.Client, threadID, assistantID string) (*RunState, error)
+	GetRun(ctx context.Context, client *http.Client, threadID, runID string) (*RunState, error)
+	SubmitToolOutputs(ctx context.Context, client *http.Client, threadID, runID string, outputs []RunToolOutput) (*RunState, error)
+	CancelRun(ctx context.Context, client *http.Client, threadID, runID string) (*RunState, error)
+}
+
+// AssistantsHost is implemented by providers with a hosted assistants API.
+// Like Batcher, it is an optional capability discovered through a type
+// assertion; providers without it have their assistants emulated.
+type AssistantsHost interface {
+	// Assistants returns the assistants API, or nil when the provider has none.
+	Assistants() AssistantAPI
+}
+
+// Assistants returns the OpenAI Assistants API.
+func (p *OpenAIProvider) Assistants() AssistantAPI {
+	return &openAIAssistants{p: p}
+}
+
+// openAIAssistants implements AssistantAPI with the OpenAI Assistants API, v2.
+type openAIAssistants struct {
+	p *OpenAIProvider
+}
+
+const openAIAssistantsURL = "https://api.openai.com/v1"
+
+func (a *openAIAssistants) headers() map[string]string {
+	headers := a.p.Headers()
+	headers["OpenAI-Beta"] = "assistants=v2"
+	return headers
+}
+
+func (a *openAIAssistants) call(ctx context.Context, client *http.Client, method, path string, body interface{}, out interface{}) error {
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+	_, err := apiCall(ctx, client, method, openAIAssistantsURL+path, a.headers(), data, out)
+	return err
+}
+
+func (a *openAIAssistants) CreateAssistant(ctx context.Context, client *http.Client, spec AssistantSpec) (string, error) {
+	body := map[string]interface{}{"model": spec.Model}
+	if spec.Name != "" {
+		body["name"] = spec.Name
+	}
+	if spec.Instructions != "" {
+		body["instructions"] = spec.Instructions
+	}
+	if len(spec.Tools) > 0 {
+		body["tools"] = spec.Tools
+	}
+	var created struct {
+		ID string `json:"id"`
+	}
+	if err := a.call(ctx, client, http.MethodPost, "/assistants", body, &created); err != nil {
+		return "", err
+	}
+	return created.ID, nil
+}
+
+func (a *openAIAssistants) DeleteAssistant(ctx context.Context, client *http.Client, assistantID string) error {
+	return a.call(ctx, client, http.MethodDelete, "/assistants/"+assistantID, nil, nil)
+}
+
+func (a *openAIAssistants) CreateThread(ctx context.Context, client *http.Client) (string, error) {
+	var created struct {
+		ID string `json:"id"`
+	}
+	if err := a.call(ctx, client, http.MethodPost, "/threads", map[string]interface{}{}, &created); err != nil {
+		return "", err
+	}
+	return created.ID, nil
+}
+
+func (a *openAIAssistants) DeleteThread(ctx context.Context, client *http.Client, threadID string) error {
+	return a.call(ctx, client, http.MethodDelete, "/threads/"+threadID, nil, nil)
+}
+
+func (a *openAIAssistants) AddThreadMessage(ctx context.Context, client *http.Client, threadID string, message ThreadMessage) error {
+	body := map[string]interface{}{"role": message.Role, "content": message.Content}
+	return a.call(ctx, client, http.MethodPost, "/threads/"+threadID+"/messages", body, nil)
+}
+
+func (a *openAIAssistants) ThreadMessages(ctx context.Context, client *http.Client, threadID string) ([]ThreadMessage, error) {
+	var list struct {
+		Data []struct {
+			Role      string `json:"role"`
+			CreatedAt int64  `json:"created_at"`
+			Content   []struct {
+				Type string `json:"type"`
+				Text struct {
+					Value string `json:"value"`
+				} `json:"text"`
+			} `json:"content"`
+		} `json:"data"`
+	}
+	if err := a.call(ctx, client, http.MethodGet, "/threads/"+threadID+"/messages?order=asc&limit=100", nil, &list); err != nil {
+		return nil, err
+	}
+	messages := make([]ThreadMessage, len(list.Data))
+	for i, m := range list.Data {
+		messages[i] = ThreadMessage{Role: m.Role, CreatedAt: time.Unix(m.CreatedAt, 0).UTC()}
+		for _, part := range m.Content {
+			if part.Type == "text" {
+				messages[i].Content += part.Text.Value
+			}
+		}
+	}
+	return messages, nil
+}
+
+func (a *openAIAssistants) CreateRun(ctx context.Context, client *http.Client, threadID, assistantID string) (*RunState, error) {
+	return a.run(ctx, client, http.MethodPost, "/threads/"+threadID+"/runs", map[string]interface{}{"assistant_id": assistantID})
+}
+
+func (a *openAIAssistants) GetRun(ctx context.Context, client *http.Client, threadID, runID string) (*RunState, error) {
+	return a.run(ctx, client, http.MethodGet, "/threads/"+threadID+"/runs/"+runID, nil)
+}
+
+func (a *openAIAssistants) SubmitToolOutputs(ctx context.Context, client *http.Client, threadID, runID string, outputs []RunToolOutput) (*RunState, error) {
+	body := map[string]interface{}{"tool_outputs": outputs}
+	return a.run(ctx, client, http.MethodPost, "/threads/"+threadID+"/runs/"+runID+"/submit_tool_outputs", body)
+}
+
+func (a *openAIAssistants) CancelRun(ctx context.Context, client *http.Client, threadID, runID string) (*RunState, error) {
+	return a.run(ctx, client, http.MethodPost, "/threads/"+threadID+"/runs/"+runID+"/cancel", map[string]interface{}{})
+}
+
+// run makes a call returning a run object.
+func (a *openAIAssistants) run(ctx context.Context, client *http.Client, method, path string, body interface{}) (*RunState, error) {
+	var run struct {
+		ID             string `json:"id"`
+		Status         string `json:"status"`
+		RequiredAction *struct {
+			SubmitToolOutputs struct {
+				ToolCalls []struct {
+					ID       string `json:"id"`
+					Function struct {
+						Name      string `json:"name"`
+						Arguments string `json:"arguments"`
+					} `json:"function"`
+				} `json:"tool_calls"`
+			} `json:"submit_tool_outputs"`
+		} `json:"required_action"`
+		LastError *struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"last_error"`
+		IncompleteDetails *struct {
+			Reason string `json:"reason"`
+		} `json:"incomplete_details"`
+	}
+	if err := a.call(ctx, client, method, path, body, &run); err != nil {
+		return nil, err
+	}
+
+	state := &RunState{ID: run.ID}
+	switch run.Status {
+	case "queued":
+		state.Status = RunQueued
+	case "in_progress", "cancelling":
+		state.Status = RunInProgress
+	case "requires_action":
+		state.Status = RunRequiresAction
+	case "completed":
+		state.Status = RunCompleted
+	case "cancelled":
+		state.Status = RunCanceled
+	case "expired":
+		state.Status = RunExpired
+	case "incomplete":
+		state.Status = RunFailed
+		if run.IncompleteDetails != nil {
+			state.Error = fmt.Sprintf("incomplete: %s", run.IncompleteDetails.Reason)
+		}
+	default:
+		state.Status = RunFailed
+	}
+	if run.LastError != nil {
+		state.Error = fmt.Sprintf("%s: %s", run.LastError.Code, run.LastError.Message)
+	}
+	if run.RequiredAction != nil {
+		for _, call := range run.RequiredAction.SubmitToolOutputs.ToolCalls {
+			arguments := json.RawMessage(call.Function.Arguments)
+			if len(arguments) == 0 {
+				arguments = json.RawMessage("{}")
+			}
+			state.ToolCalls = append(state.ToolCalls, RunToolCall{ID: call.ID, Name: call.Function.Name, Arguments: arguments})
+		}
+	}
+	return state, nil
+}
@@ -0,0 +1,142 @@
+// Package providers implements LLM provider interfaces and implementations.
+package providers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"strconv"
+)
+
+// TranscriptionOptions configures a speech-to-text request.
+// Zero values are omitted from the request so provider defaults apply.
+type TranscriptionOptions struct {
+	Model       string  // Transcription model (e.g., "whisper-1"); provider default if empty
+	Language    string  // ISO-639-1 language of the audio (e.g., "en")
+	Prompt      string  // Optional text to guide the model's style or vocabulary
+	Temperature float64 // Sampling temperature between 0 and 1
+	Filename    string  // File name sent with multipart uploads; determines the audio format
+	MediaType   string  // MIME type of the audio for providers that take raw uploads
+}
+
+// TranscriptionSegment is a timed portion of a transcription.
+type TranscriptionSegment struct {
+	Start float64 `json:"start"` // Segment start time in seconds
+	End   float64 `json:"end"`   // Segment end time in seconds
+	Text  string  `json:"text"`  // Transcribed text of the segment
+}
+
+// Transcription holds the result of a speech-to-text request.
+type Transcription struct {
+	Text     string                 `json:"text"`               // Full transcribed text
+	Language string                 `json:"language,omitempty"` // Detected or requested language
+	Duration float64                `json:"duration,omitempty"` // Audio duration in seconds
+	Segments []TranscriptionSegment `json:"segments,omitempty"` // Timed segments, when available
+}
+
+// Transcriber is implemented by providers that offer speech-to-text.
+// It is an optional capability discovered through a type assertion, so
+// providers that only support text generation do not need to implement it.
+type Transcriber interface {
+	// TranscriptionEndpoint returns the URL for transcription requests.
+	TranscriptionEndpoint(opts TranscriptionOptions) string
+
+	// PrepareTranscriptionRequest builds the request body for the audio upload
+	// and returns it together with the Content-Type header to send.
+	PrepareTranscriptionRequest(audio io.Reader, opts TranscriptionOptions) ([]byte, string, error)
+
+	// ParseTranscriptionResponse extracts the transcription from the API response.
+	ParseTranscriptionResponse(body []byte) (*Transcription, error)
+}
+
+// prepareWhisperRequest builds the multipart form used by OpenAI-compatible
+// /audio/transcriptions endpoints (OpenAI Whisper, Groq).
+func prepareWhisperRequest(audio io.Reader, opts TranscriptionOptions, defaultModel string) ([]byte, string, error) {
+	model := opts.Model
+	if model == "" {
+		model = defaultModel
+	}
+	filename := opts.Filename
+	if filename == "" {
+		filename = "audio.mp3"
+	}
+
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+
+	part, err := writer.CreateFormFile("file", filename)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create form file: %w", err)
+	}
+	if _, err := io.Copy(part, audio); err != nil {
+		return nil, "", fmt.Errorf("failed to read audio: %w", err)
+	}
+
+	fields := map[string]string{
+		"model":           model,
+		"response_format": "verbose_json",
+	}
+	if opts.Language != "" {
+		fields["language"] = opts.Language
+	}
+	if opts.Prompt != "" {
+		fields["prompt"] = opts.Prompt
+	}
+	if opts.Temperature > 0 {
+		fields["temperature"] = strconv.FormatFloat(opts.Temperature, 'f', -1, 64)
+	}
+	for k, v := range fields {
+		if err := writer.WriteField(k, v); err != nil {
+			return nil, "", fmt.Errorf("failed to write form field %s: %w", k, err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, "", fmt.Errorf("failed to finalize multipart body: %w", err)
+	}
+	return buf.Bytes(), writer.FormDataContentType(), nil
+}
+
+// parseWhisperResponse parses the verbose_json response of OpenAI-compatible
+// transcription endpoints.
+func parseWhisperResponse(body []byte) (*Transcription, error) {
+	var result Transcription
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("error parsing transcription response: %w", err)
+	}
+	return &result, nil
+}
+
+// TranscriptionEndpoint returns the OpenAI Whisper transcription endpoint.
+func (p *OpenAIProvider) TranscriptionEndpoint(opts TranscriptionOptions) string {
+	return "https://api.openai.com/v1/audio/transcriptions"
+}
+
+// PrepareTranscriptionRequest builds a multipart Whisper upload.
+// The model defaults to "whisper-1".
+func (p *OpenAIProvider) PrepareTranscriptionRequest(audio io.Reader, opts TranscriptionOptions) ([]byte, string, error) {
+	return prepareWhisperRequest(audio, opts, "whisper-1")
+}
+
+// ParseTranscriptionResponse extracts the transcription from a Whisper response.
+func (p *OpenAIProvider) ParseTranscriptionResponse(body []byte) (*Transcription, error) {
+	return parseWhisperResponse(body)
+}
+
+// TranscriptionEndpoint returns Groq's OpenAI-compatible transcription endpoint.
+func (p *GroqProvider) TranscriptionEndpoint(opts TranscriptionOptions) string {
+	return "https://api.groq.com/openai/v1/audio/transcriptions"
+}
+
+// PrepareTranscriptionRequest builds a multipart upload for Groq's Whisper models.
+// The model defaults to "whisper-large-v3".
+func (p *GroqProvider) PrepareTranscriptionRequest(audio io.Reader, opts TranscriptionOptions) ([]byte, string, error) {
+	return prepareWhisperRequest(audio, opts, "whisper-large-v3")
+}
+
+// ParseTranscriptionResponse extracts the transcription from a Groq response.
+func (p *GroqProvider) ParseTranscriptionResponse(body []byte) (*Transcription, error) {
+	return parseWhisperResponse(body)
+}
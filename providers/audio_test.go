@@ -0,0 +1,148 @@
+package providers
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWhisperTranscriptionRequest(t *testing.T) {
+	audio := []byte("fake mp3 bytes")
+
+	tests := []struct {
+		name     string
+		provider Transcriber
+		endpoint string
+		model    string
+	}{
+		{"OpenAI", NewOpenAIProvider("fake-key", "gpt-4o", nil).(Transcriber), "https://api.openai.com/v1/audio/transcriptions", "whisper-1"},
+		{"Groq", NewGroqProvider("fake-key", "llama3", nil).(Transcriber), "https://api.groq.com/openai/v1/audio/transcriptions", "whisper-large-v3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := TranscriptionOptions{Language: "en", Filename: "clip.wav", Temperature: 0.2}
+			assert.Equal(t, tt.endpoint, tt.provider.TranscriptionEndpoint(opts))
+
+			body, contentType, err := tt.provider.PrepareTranscriptionRequest(bytes.NewReader(audio), opts)
+			require.NoError(t, err)
+
+			mediaType, params, err := mime.ParseMediaType(contentType)
+			require.NoError(t, err)
+			assert.Equal(t, "multipart/form-data", mediaType)
+
+			form, err := multipart.NewReader(bytes.NewReader(body), params["boundary"]).ReadForm(1 << 20)
+			require.NoError(t, err)
+			assert.Equal(t, []string{tt.model}, form.Value["model"])
+			assert.Equal(t, []string{"en"}, form.Value["language"])
+			assert.Equal(t, []string{"0.2"}, form.Value["temperature"])
+			assert.Equal(t, []string{"verbose_json"}, form.Value["response_format"])
+			assert.NotContains(t, form.Value, "prompt")
+
+			require.Len(t, form.File["file"], 1)
+			assert.Equal(t, "clip.wav", form.File["file"][0].Filename)
+			f, err := form.File["file"][0].Open()
+			require.NoError(t, err)
+			defer f.Close()
+			got, err := io.ReadAll(f)
+			require.NoError(t, err)
+			assert.Equal(t, audio, got)
+		})
+	}
+
+	t.Run("ParseResponse", func(t *testing.T) {
+		provider := NewOpenAIProvider("fake-key", "gpt-4o", nil).(Transcriber)
+		result, err := provider.ParseTranscriptionResponse([]byte(`{"text":"hello world","language":"english","duration":1.5,"segments":[{"start":0,"end":1.5,"text":"hello world"}]}`))
+		require.NoError(t, err)
+		assert.Equal(t, "hello world", result.Text)
+		assert.Equal(t, 1.5, result.Duration)
+		require.Len(t, result.Segments, 1)
+	})
+
+	t.Run("DeepSeekUnsupported", func(t *testing.T) {
+		_, ok := NewDeepSeekProvider("fake-key", "deepseek-chat", nil).(Transcriber)
+		assert.False(t, ok)
+	})
+}
+
+func TestDeepgramTranscription(t *testing.T) {
+	provider := NewDeepgramProvider("fake-key", "nova-2", nil)
+	provider.SetOption("smart_format", true)
+	transcriber := provider.(Transcriber)
+
+	assert.Equal(t, "Token fake-key", provider.Headers()["Authorization"])
+
+	endpoint, err := url.Parse(transcriber.TranscriptionEndpoint(TranscriptionOptions{Language: "fr"}))
+	require.NoError(t, err)
+	assert.Equal(t, "nova-2", endpoint.Query().Get("model"))
+	assert.Equal(t, "fr", endpoint.Query().Get("language"))
+	assert.Equal(t, "true", endpoint.Query().Get("smart_format"))
+
+	body, contentType, err := transcriber.PrepareTranscriptionRequest(strings.NewReader("raw audio"), TranscriptionOptions{MediaType: "audio/wav"})
+	require.NoError(t, err)
+	assert.Equal(t, "audio/wav", contentType)
+	assert.Equal(t, "raw audio", string(body))
+
+	result, err := transcriber.ParseTranscriptionResponse([]byte(`{
+		"metadata": {"duration": 2.0},
+		"results": {"channels": [{"detected_language": "fr", "alternatives": [{
+			"transcript": "bonjour",
+			"words": [{"word": "bonjour", "start": 0.1, "end": 0.6}]
+		}]}]}
+	}`))
+	require.NoError(t, err)
+	assert.Equal(t, "bonjour", result.Text)
+	assert.Equal(t, "fr", result.Language)
+	assert.Equal(t, 2.0, result.Duration)
+	require.Len(t, result.Segments, 1)
+
+	_, err = provider.PrepareRequest("hello", nil)
+	assert.Error(t, err)
+}
+
+func TestSpeechRequest(t *testing.T) {
+	t.Run("OpenAI", func(t *testing.T) {
+		provider := NewOpenAIProvider("fake-key", "gpt-4o", nil).(Speaker)
+		opts := SpeechOptions{Voice: "nova", Format: "wav", Speed: 1.25}
+		assert.Equal(t, "https://api.openai.com/v1/audio/speech", provider.SpeechEndpoint(opts))
+
+		body, err := provider.PrepareSpeechRequest("Hello there", opts)
+		require.NoError(t, err)
+
+		var req map[string]interface{}
+		require.NoError(t, json.Unmarshal(body, &req))
+		assert.Equal(t, "tts-1", req["model"])
+		assert.Equal(t, "Hello there", req["input"])
+		assert.Equal(t, "nova", req["voice"])
+		assert.Equal(t, "wav", req["response_format"])
+		assert.Equal(t, 1.25, req["speed"])
+	})
+
+	t.Run("ElevenLabs", func(t *testing.T) {
+		provider := NewElevenLabsProvider("fake-key", "", nil)
+		provider.SetOption("stability", 0.5)
+		speaker := provider.(Speaker)
+
+		assert.Equal(t, "fake-key", provider.Headers()["xi-api-key"])
+		assert.Equal(t,
+			"https://api.elevenlabs.io/v1/text-to-speech/voice123/stream?output_format=mp3_44100_128",
+			speaker.SpeechEndpoint(SpeechOptions{Voice: "voice123", Format: "mp3_44100_128"}))
+
+		body, err := speaker.PrepareSpeechRequest("Hello there", SpeechOptions{})
+		require.NoError(t, err)
+
+		var req map[string]interface{}
+		require.NoError(t, json.Unmarshal(body, &req))
+		assert.Equal(t, "Hello there", req["text"])
+		assert.Equal(t, "eleven_multilingual_v2", req["model_id"])
+		assert.Equal(t, map[string]interface{}{"stability": 0.5}, req["voice_settings"])
+	})
+}
@@ -0,0 +1,394 @@
+package providers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// BatchStatus is the state of a batch.
+type BatchStatus string
+
+const (
+	BatchInProgress BatchStatus = "in_progress" // Queued, running or finalizing
+	BatchCompleted  BatchStatus = "completed"   // Every request has a result
+	BatchFailed     BatchStatus = "failed"      // The batch was rejected
+	BatchCanceled   BatchStatus = "canceled"    // The batch was canceled
+	BatchExpired    BatchStatus = "expired"     // The batch did not complete in time
+)
+
+// BatchRequest is a request of a batch, with the body prepared by the
+// provider's PrepareRequest.
+type BatchRequest struct {
+	CustomID string
+	Body     []byte
+}
+
+// BatchInfo describes a batch as reported by the provider.
+type BatchInfo struct {
+	ID          string
+	Status      BatchStatus
+	Total       int      // Requests in the batch
+	Succeeded   int      // Requests completed successfully so far
+	Failed      int      // Requests that failed, were canceled or expired
+	ResultFiles []string // Provider references to the results, once available
+}
+
+// BatchResult is the outcome of a request of a batch: the response body, to
+// be parsed with ParseResponse, or an error message.
+type BatchResult struct {
+	CustomID string
+	Body     []byte
+	Error    string
+}
+
+// Batcher is implemented by providers with an asynchronous batch API, which
+// runs large numbers of requests at a discount within a day. Like
+// FileManager, it is an optional capability discovered through a type
+// assertion. Batch APIs take several calls, which the provider makes itself
+// with the given client.
+type Batcher interface {
+	// CreateBatch submits the requests as a new batch.
+	CreateBatch(ctx context.Context, client *http.Client, requests []BatchRequest) (*BatchInfo, error)
+
+	// GetBatch returns the current state of a batch.
+	GetBatch(ctx context.Context, client *http.Client, id string) (*BatchInfo, error)
+
+	// CancelBatch asks the provider to stop a batch.
+	CancelBatch(ctx context.Context, client *http.Client, id string) (*BatchInfo, error)
+
+	// BatchResults downloads the results of a completed batch.
+	BatchResults(ctx context.Context, client *http.Client, batch *BatchInfo) ([]BatchResult, error)
+}
+
+// apiCall sends a request to a provider API taking several calls, such as
+// batches, and decodes the JSON response into out, or returns the raw body
+// when out is nil.
+func apiCall(ctx context.Context, client *http.Client, method, url string, headers map[string]string, body []byte, out interface{}) ([]byte, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := ReadResponseBody(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API error: status code %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if out != nil {
+		if err := json.Unmarshal(data, out); err != nil {
+			return nil, fmt.Errorf("error parsing response: %w", err)
+		}
+	}
+	return data, nil
+}
+
+// scanJSONL calls fn with every non-empty line of a JSONL document.
+func scanJSONL(data []byte, fn func(line []byte) error) error {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if err := fn(line); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// anthropicBatch is a Message Batch as returned by the Anthropic API.
+type anthropicBatch struct {
+	ID               string `json:"id"`
+	ProcessingStatus string `json:"processing_status"`
+	RequestCounts    struct {
+		Processing int `json:"processing"`
+		Succeeded  int `json:"succeeded"`
+		Errored    int `json:"errored"`
+		Canceled   int `json:"canceled"`
+		Expired    int `json:"expired"`
+	} `json:"request_counts"`
+	ResultsURL string `json:"results_url"`
+}
+
+func (b *anthropicBatch) info() *BatchInfo {
+	counts := b.RequestCounts
+	info := &BatchInfo{
+		ID:        b.ID,
+		Status:    BatchInProgress,
+		Total:     counts.Processing + counts.Succeeded + counts.Errored + counts.Canceled + counts.Expired,
+		Succeeded: counts.Succeeded,
+		Failed:    counts.Errored + counts.Canceled + counts.Expired,
+	}
+	if b.ProcessingStatus == "ended" {
+		info.Status = BatchCompleted
+	}
+	if b.ResultsURL != "" {
+		info.ResultFiles = []string{b.ResultsURL}
+	}
+	return info
+}
+
+// BatchEndpoint returns the Message Batches API endpoint.
+func (p *AnthropicProvider) BatchEndpoint() string {
+	return "https://api.anthropic.com/v1/messages/batches"
+}
+
+// CreateBatch submits the requests to the Message Batches API.
+func (p *AnthropicProvider) CreateBatch(ctx context.Context, client *http.Client, requests []BatchRequest) (*BatchInfo, error) {
+	type item struct {
+		CustomID string          `json:"custom_id"`
+		Params   json.RawMessage `json:"params"`
+	}
+	items := make([]item, len(requests))
+	for i, r := range requests {
+		items[i] = item{CustomID: r.CustomID, Params: r.Body}
+	}
+	body, err := json.Marshal(map[string]interface{}{"requests": items})
+	if err != nil {
+		return nil, err
+	}
+	var batch anthropicBatch
+	if _, err := apiCall(ctx, client, http.MethodPost, p.BatchEndpoint(), p.Headers(), body, &batch); err != nil {
+		return nil, err
+	}
+	return batch.info(), nil
+}
+
+// GetBatch returns the state of a Message Batch.
+func (p *AnthropicProvider) GetBatch(ctx context.Context, client *http.Client, id string) (*BatchInfo, error) {
+	var batch anthropicBatch
+	if _, err := apiCall(ctx, client, http.MethodGet, p.BatchEndpoint()+"/"+id, p.Headers(), nil, &batch); err != nil {
+		return nil, err
+	}
+	return batch.info(), nil
+}
+
+// CancelBatch cancels a Message Batch.
+func (p *AnthropicProvider) CancelBatch(ctx context.Context, client *http.Client, id string) (*BatchInfo, error) {
+	var batch anthropicBatch
+	if _, err := apiCall(ctx, client, http.MethodPost, p.BatchEndpoint()+"/"+id+"/cancel", p.Headers(), nil, &batch); err != nil {
+		return nil, err
+	}
+	return batch.info(), nil
+}
+
+// BatchResults downloads the results of an ended Message Batch.
+func (p *AnthropicProvider) BatchResults(ctx context.Context, client *http.Client, batch *BatchInfo) ([]BatchResult, error) {
+	var results []BatchResult
+	for _, url := range batch.ResultFiles {
+		data, err := apiCall(ctx, client, http.MethodGet, url, p.Headers(), nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		err = scanJSONL(data, func(line []byte) error {
+			var entry struct {
+				CustomID string `json:"custom_id"`
+				Result   struct {
+					Type    string          `json:"type"`
+					Message json.RawMessage `json:"message"`
+					Error   struct {
+						Error struct {
+							Message string `json:"message"`
+						} `json:"error"`
+					} `json:"error"`
+				} `json:"result"`
+			}
+			if err := json.Unmarshal(line, &entry); err != nil {
+				return fmt.Errorf("error parsing batch result: %w", err)
+			}
+			result := BatchResult{CustomID: entry.CustomID}
+			switch entry.Result.Type {
+			case "succeeded":
+				result.Body = entry.Result.Message
+			case "errored":
+				result.Error = entry.Result.Error.Error.Message
+			default:
+				result.Error = "request " + entry.Result.Type
+			}
+			results = append(results, result)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+// openAIBatch is a batch as returned by the OpenAI API.
+type openAIBatch struct {
+	ID            string `json:"id"`
+	Status        string `json:"status"`
+	RequestCounts struct {
+		Total     int `json:"total"`
+		Completed int `json:"completed"`
+		Failed    int `json:"failed"`
+	} `json:"request_counts"`
+	OutputFileID string `json:"output_file_id"`
+	ErrorFileID  string `json:"error_file_id"`
+}
+
+func (b *openAIBatch) info() *BatchInfo {
+	info := &BatchInfo{
+		ID:        b.ID,
+		Total:     b.RequestCounts.Total,
+		Succeeded: b.RequestCounts.Completed,
+		Failed:    b.RequestCounts.Failed,
+	}
+	switch b.Status {
+	case "completed":
+		info.Status = BatchCompleted
+	case "failed":
+		info.Status = BatchFailed
+	case "expired":
+		info.Status = BatchExpired
+	case "cancelled":
+		info.Status = BatchCanceled
+	default:
+		info.Status = BatchInProgress
+	}
+	for _, id := range []string{b.OutputFileID, b.ErrorFileID} {
+		if id != "" {
+			info.ResultFiles = append(info.ResultFiles, id)
+		}
+	}
+	return info
+}
+
+// BatchEndpoint returns the Batch API endpoint.
+func (p *OpenAIProvider) BatchEndpoint() string {
+	return "https://api.openai.com/v1/batches"
+}
+
+// CreateBatch uploads the requests as a JSONL file and submits it to the
+// Batch API.
+func (p *OpenAIProvider) CreateBatch(ctx context.Context, client *http.Client, requests []BatchRequest) (*BatchInfo, error) {
+	var input bytes.Buffer
+	encoder := json.NewEncoder(&input)
+	for _, r := range requests {
+		line := map[string]interface{}{
+			"custom_id": r.CustomID,
+			"method":    http.MethodPost,
+			"url":       "/v1/chat/completions",
+			"body":      json.RawMessage(r.Body),
+		}
+		if err := encoder.Encode(line); err != nil {
+			return nil, err
+		}
+	}
+
+	upload, contentType, err := prepareMultipartFile(&input, "batch.jsonl", "application/jsonl", map[string]string{"purpose": "batch"})
+	if err != nil {
+		return nil, err
+	}
+	headers := p.Headers()
+	headers["Content-Type"] = contentType
+	var file struct {
+		ID string `json:"id"`
+	}
+	if _, err := apiCall(ctx, client, http.MethodPost, p.FilesEndpoint(), headers, upload, &file); err != nil {
+		return nil, fmt.Errorf("failed to upload batch input: %w", err)
+	}
+
+	body, err := json.Marshal(map[string]string{
+		"input_file_id":     file.ID,
+		"endpoint":          "/v1/chat/completions",
+		"completion_window": "24h",
+	})
+	if err != nil {
+		return nil, err
+	}
+	var batch openAIBatch
+	if _, err := apiCall(ctx, client, http.MethodPost, p.BatchEndpoint(), p.Headers(), body, &batch); err != nil {
+		return nil, err
+	}
+	return batch.info(), nil
+}
+
+// GetBatch returns the state of a batch.
+func (p *OpenAIProvider) GetBatch(ctx context.Context, client *http.Client, id string) (*BatchInfo, error) {
+	var batch openAIBatch
+	if _, err := apiCall(ctx, client, http.MethodGet, p.BatchEndpoint()+"/"+id, p.Headers(), nil, &batch); err != nil {
+		return nil, err
+	}
+	return batch.info(), nil
+}
+
+// CancelBatch cancels a batch.
+func (p *OpenAIProvider) CancelBatch(ctx context.Context, client *http.Client, id string) (*BatchInfo, error) {
+	var batch openAIBatch
+	if _, err := apiCall(ctx, client, http.MethodPost, p.BatchEndpoint()+"/"+id+"/cancel", p.Headers(), nil, &batch); err != nil {
+		return nil, err
+	}
+	return batch.info(), nil
+}
+
+// BatchResults downloads the output and error files of a batch.
+func (p *OpenAIProvider) BatchResults(ctx context.Context, client *http.Client, batch *BatchInfo) ([]BatchResult, error) {
+	var results []BatchResult
+	for _, id := range batch.ResultFiles {
+		data, err := apiCall(ctx, client, http.MethodGet, p.FilesEndpoint()+"/"+id+"/content", p.Headers(), nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		err = scanJSONL(data, func(line []byte) error {
+			var entry struct {
+				CustomID string `json:"custom_id"`
+				Response *struct {
+					StatusCode int             `json:"status_code"`
+					Body       json.RawMessage `json:"body"`
+				} `json:"response"`
+				Error *struct {
+					Message string `json:"message"`
+				} `json:"error"`
+			}
+			if err := json.Unmarshal(line, &entry); err != nil {
+				return fmt.Errorf("error parsing batch result: %w", err)
+			}
+			result := BatchResult{CustomID: entry.CustomID}
+			switch {
+			case entry.Error != nil:
+				result.Error = entry.Error.Message
+			case entry.Response == nil:
+				result.Error = "missing response"
+			case entry.Response.StatusCode != http.StatusOK:
+				var failure struct {
+					Error struct {
+						Message string `json:"message"`
+					} `json:"error"`
+				}
+				_ = json.Unmarshal(entry.Response.Body, &failure)
+				result.Error = fmt.Sprintf("status code %d: %s", entry.Response.StatusCode, failure.Error.Message)
+			default:
+				result.Body = entry.Response.Body
+			}
+			results = append(results, result)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,67 @@
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ChoicesParser is implemented by providers that return several completions
+// for a request with the "n" option. Like HealthChecker, it is an optional
+// capability discovered through a type assertion; completions are requested
+// one at a time from other providers.
+type ChoicesParser interface {
+	// SupportsChoices reports whether the "n" option is supported.
+	SupportsChoices() bool
+
+	// ParseChoices extracts the text of every completion of a response.
+	ParseChoices(body []byte) ([]string, error)
+}
+
+// SupportsChoices returns true: OpenAI supports the "n" option.
+func (p *OpenAIProvider) SupportsChoices() bool {
+	return true
+}
+
+// ParseChoices extracts the message content of every choice.
+func (p *OpenAIProvider) ParseChoices(body []byte) ([]string, error) {
+	return parseOpenAIChoices(body)
+}
+
+// SupportsChoices reports whether the provider is OpenAI-compatible.
+func (p *GenericProvider) SupportsChoices() bool {
+	return p.config.Type == TypeOpenAI
+}
+
+// ParseChoices extracts the message content of every choice of an
+// OpenAI-compatible response.
+func (p *GenericProvider) ParseChoices(body []byte) ([]string, error) {
+	if p.config.Type != TypeOpenAI {
+		return nil, fmt.Errorf("multiple choices not supported for provider type: %s", p.config.Type)
+	}
+	return parseOpenAIChoices(body)
+}
+
+func parseOpenAIChoices(body []byte) ([]string, error) {
+	var response struct {
+		Choices []struct {
+			Index   int `json:"index"`
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, err
+	}
+	if len(response.Choices) == 0 {
+		return nil, fmt.Errorf("empty response from API")
+	}
+	choices := make([]string, len(response.Choices))
+	for i, c := range response.Choices {
+		if c.Index >= 0 && c.Index < len(choices) {
+			i = c.Index
+		}
+		choices[i] = c.Message.Content
+	}
+	return choices, nil
+}
@@ -0,0 +1,193 @@
+package providers
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+// Citation is a source supporting part of a response, in the same form
+// whichever provider or pipeline produced it.
+type Citation struct {
+	Claim    string `json:"claim,omitempty"`     // Text of the response supported by the source, when reported
+	Quote    string `json:"quote,omitempty"`     // Passage of the source supporting it, when reported
+	SourceID string `json:"source_id,omitempty"` // ID or index of the cited document, when it has one
+	Title    string `json:"title,omitempty"`     // Title of the source
+	URL      string `json:"url,omitempty"`       // Address of a web source
+}
+
+// CitationParser is implemented by providers whose responses may cite
+// sources, such as documents sent with the request or web search results.
+// Like ChoicesParser, it is an optional capability discovered through a type
+// assertion.
+type CitationParser interface {
+	// ParseCitations returns the citations of a response, or nil if it has none.
+	ParseCitations(body []byte) []Citation
+}
+
+// ParseCitations returns the URL citations of an OpenAI web search response.
+func (p *OpenAIProvider) ParseCitations(body []byte) []Citation {
+	return parseOpenAICitations(body)
+}
+
+// ParseCitations returns the URL citations of an OpenRouter response, from
+// web search or from models with built-in search such as Perplexity's.
+func (p *OpenRouterProvider) ParseCitations(body []byte) []Citation {
+	return parseOpenAICitations(body)
+}
+
+// ParseCitations returns the citations of the configured API format. For the
+// OpenAI format this includes the top-level citations of Perplexity.
+func (p *GenericProvider) ParseCitations(body []byte) []Citation {
+	switch p.config.Type {
+	case TypeOpenAI:
+		return parseOpenAICitations(body)
+	case TypeAnthropic, TypeClaude:
+		return parseAnthropicCitations(body)
+	}
+	return nil
+}
+
+// ParseCitations returns the citations of documents or web search results
+// in an Anthropic response.
+func (p *AnthropicProvider) ParseCitations(body []byte) []Citation {
+	return parseAnthropicCitations(body)
+}
+
+// ParseCitations returns the citations of the documents or tool results
+// grounding a Cohere response.
+func (p *CohereProvider) ParseCitations(body []byte) []Citation {
+	var response struct {
+		Message struct {
+			Citations []struct {
+				Text    string `json:"text"`
+				Sources []struct {
+					ID       string                 `json:"id"`
+					Document map[string]interface{} `json:"document"`
+				} `json:"sources"`
+			} `json:"citations"`
+		} `json:"message"`
+	}
+	if json.Unmarshal(body, &response) != nil {
+		return nil
+	}
+	var citations []Citation
+	for _, c := range response.Message.Citations {
+		for _, source := range c.Sources {
+			citation := Citation{Claim: c.Text, SourceID: source.ID}
+			if id, ok := source.Document["id"].(string); ok && id != "" {
+				citation.SourceID = id
+			}
+			citation.Title, _ = source.Document["title"].(string)
+			citation.URL, _ = source.Document["url"].(string)
+			if citation.Quote, _ = source.Document["snippet"].(string); citation.Quote == "" {
+				citation.Quote, _ = source.Document["text"].(string)
+			}
+			citations = append(citations, citation)
+		}
+	}
+	return citations
+}
+
+// parseOpenAICitations reads the url_citation annotations of a chat
+// completion, and the top-level sources returned by Perplexity, which are
+// added unless already cited.
+func parseOpenAICitations(body []byte) []Citation {
+	var response struct {
+		Choices []struct {
+			Message struct {
+				Content     string `json:"content"`
+				Annotations []struct {
+					Type        string `json:"type"`
+					URLCitation struct {
+						URL        string `json:"url"`
+						Title      string `json:"title"`
+						Content    string `json:"content"`
+						StartIndex int    `json:"start_index"`
+						EndIndex   int    `json:"end_index"`
+					} `json:"url_citation"`
+				} `json:"annotations"`
+			} `json:"message"`
+		} `json:"choices"`
+		Citations     []string `json:"citations"`
+		SearchResults []struct {
+			Title   string `json:"title"`
+			URL     string `json:"url"`
+			Snippet string `json:"snippet"`
+		} `json:"search_results"`
+	}
+	if json.Unmarshal(body, &response) != nil {
+		return nil
+	}
+
+	var citations []Citation
+	cited := make(map[string]bool)
+	if len(response.Choices) > 0 {
+		message := response.Choices[0].Message
+		content := []rune(message.Content)
+		for _, a := range message.Annotations {
+			if a.Type != "url_citation" {
+				continue
+			}
+			c := a.URLCitation
+			citation := Citation{URL: c.URL, Title: c.Title, Quote: c.Content}
+			// Indexes are in characters
+			if c.StartIndex >= 0 && c.StartIndex < c.EndIndex && c.EndIndex <= len(content) {
+				citation.Claim = string(content[c.StartIndex:c.EndIndex])
+			}
+			citations = append(citations, citation)
+			cited[c.URL] = true
+		}
+	}
+	for _, r := range response.SearchResults {
+		if !cited[r.URL] {
+			citations = append(citations, Citation{URL: r.URL, Title: r.Title, Quote: r.Snippet})
+			cited[r.URL] = true
+		}
+	}
+	for _, url := range response.Citations {
+		if !cited[url] {
+			citations = append(citations, Citation{URL: url})
+			cited[url] = true
+		}
+	}
+	return citations
+}
+
+// parseAnthropicCitations reads the citations of the text blocks of a
+// message, which each support the text of their block.
+func parseAnthropicCitations(body []byte) []Citation {
+	var response struct {
+		Content []struct {
+			Type      string `json:"type"`
+			Text      string `json:"text"`
+			Citations []struct {
+				Type          string `json:"type"`
+				CitedText     string `json:"cited_text"`
+				DocumentIndex *int   `json:"document_index"`
+				DocumentTitle string `json:"document_title"`
+				URL           string `json:"url"`
+				Title         string `json:"title"`
+			} `json:"citations"`
+		} `json:"content"`
+	}
+	if json.Unmarshal(body, &response) != nil {
+		return nil
+	}
+	var citations []Citation
+	for _, block := range response.Content {
+		if block.Type != "text" {
+			continue
+		}
+		for _, c := range block.Citations {
+			citation := Citation{Claim: block.Text, Quote: c.CitedText, Title: c.DocumentTitle, URL: c.URL}
+			if c.Title != "" {
+				citation.Title = c.Title
+			}
+			if c.DocumentIndex != nil {
+				citation.SourceID = strconv.Itoa(*c.DocumentIndex)
+			}
+			citations = append(citations, citation)
+		}
+	}
+	return citations
+}
@@ -68,12 +68,8 @@ func (p *CohereProvider) SetOption(key string, value any) {
 // SetDefaultOptions configures standard options from the global configuration.
 // This includes temperature, max tokens, and sampling parameters.
 func (p *CohereProvider) SetDefaultOptions(config *config.Config) {
-	p.SetOption("temperature", config.Temperature)
-	p.SetOption("max_tokens", config.MaxTokens)
+	setSamplingDefaults(p, config)
 	p.SetOption("stream", false)
-	if config.Seed != nil {
-		p.SetOption("seed", *config.Seed)
-	}
 }
 
 // Name returns "cohere" as the provider identifier.
@@ -125,24 +121,11 @@ func (p *CohereProvider) Headers() map[string]string {
 //   - Serialized JSON request body
 //   - Any error encountered during preparation
 func (p *CohereProvider) PrepareRequest(prompt string, options map[string]any) ([]byte, error) {
-	requestBody := map[string]any{
-		"model": p.model,
-		"messages": []map[string]any{
-			{"role": "user", "content": prompt},
-		},
-	}
-
-	// First, add default options
-	for k, v := range p.options {
-		requestBody[k] = v
-	}
-
-	// Then, add any additional options (which may override defaults)
-	for k, v := range options {
-		requestBody[k] = v
+	request, err := newCohereRequest(p.model, prompt, options)
+	if err != nil {
+		return nil, err
 	}
-
-	return json.Marshal(requestBody)
+	return marshalRequest(request, p.options, options)
 }
 
 // PrepareRequestWithSchema creates a request that includes structured output formatting.
@@ -157,28 +140,15 @@ func (p *CohereProvider) PrepareRequest(prompt string, options map[string]any) (
 //   - Serialized JSON request body
 //   - Any error encountered during preparation
 func (p *CohereProvider) PrepareRequestWithSchema(prompt string, options map[string]any, schema any) ([]byte, error) {
-	requestBody := map[string]any{
-		"model": p.model,
-		"messages": []map[string]any{
-			{"role": "user", "content": prompt},
-		},
-		"response_format": map[string]any{
-			"type":        "json_object",
-			"json_schema": schema,
-		},
-	}
-
-	// First, add the default options
-	for k, v := range p.options {
-		requestBody[k] = v
+	request, err := newCohereRequest(p.model, prompt, options)
+	if err != nil {
+		return nil, err
 	}
-
-	// Then, add any additional options (which may override defaults)
-	for k, v := range options {
-		requestBody[k] = v
+	request.ResponseFormat = map[string]any{
+		"type":        "json_object",
+		"json_schema": schema,
 	}
-
-	return json.Marshal(requestBody)
+	return marshalRequest(request, p.options, options)
 }
 
 // ParseResponse extracts the generated text from the Cohere API response.
@@ -191,6 +161,9 @@ func (p *CohereProvider) PrepareRequestWithSchema(prompt string, options map[str
 //   - Generated text content
 //   - Any error encountered during parsing
 func (p *CohereProvider) ParseResponse(body []byte) (string, error) {
+	if err := CheckResponseBody(body); err != nil {
+		return "", err
+	}
 	var response struct {
 		Message struct {
 			Role    string `json:"role"`
@@ -278,8 +251,12 @@ func (p *CohereProvider) SupportsStreaming() bool {
 
 // PrepareStreamRequest prepares a request body for streaming
 func (p *CohereProvider) PrepareStreamRequest(prompt string, options map[string]interface{}) ([]byte, error) {
-	options["stream"] = true
-	return p.PrepareRequest(prompt, options)
+	request, err := newCohereRequest(p.model, prompt, options)
+	if err != nil {
+		return nil, err
+	}
+	request.Stream = true
+	return marshalRequest(request, p.options, options)
 }
 
 // ParseStreamResponse parses a single chunk from a streaming response
@@ -295,47 +272,54 @@ func (p *CohereProvider) ParseStreamResponse(chunk []byte) (string, error) {
 
 // PrepareRequestWithMessages creates a request using structured message objects.
 func (p *CohereProvider) PrepareRequestWithMessages(messages []types.MemoryMessage, options map[string]interface{}) ([]byte, error) {
+	// The chat history holds plain text messages only
+	if hasAttachments(options) {
+		return nil, fmt.Errorf("%w: cohere cannot send attachments with a chat history", ErrUnsupportedAttachment)
+	}
+
 	// Cohere uses a chat history format
-	chatHistory := []map[string]interface{}{}
-	var userMessage string
+	request := cohereChatRequest{Model: p.model, ChatHistory: []cohereMessage{}}
 
 	// Process messages and build chat history
 	for i, msg := range messages {
 		if i == len(messages)-1 && msg.Role == "user" {
 			// Last user message goes in the message field
-			userMessage = msg.Content
+			request.Message = msg.Content
 		} else {
 			// Previous messages go into chat history
-			chatHistory = append(chatHistory, map[string]interface{}{
-				"role":    msg.Role,
-				"message": msg.Content,
-			})
+			request.ChatHistory = append(request.ChatHistory, cohereMessage{Role: msg.Role, Message: msg.Content})
 		}
 	}
 
-	// Build request
-	request := map[string]interface{}{
-		"model":        p.model,
-		"message":      userMessage,
-		"chat_history": chatHistory,
+	// Add system prompt if present
+	if systemPrompt, ok := options["system_prompt"].(string); ok && systemPrompt != "" {
+		request.Preamble = systemPrompt
 	}
 
-	// Add other options
-	for k, v := range p.options {
-		if k != "message" && k != "chat_history" {
-			request[k] = v
-		}
-	}
-	for k, v := range options {
-		if k != "message" && k != "chat_history" && k != "system_prompt" && k != "structured_messages" {
-			request[k] = v
-		}
-	}
+	return marshalRequest(request, p.options, options)
+}
 
-	// Add system prompt if present
-	if systemPrompt, ok := options["system_prompt"].(string); ok && systemPrompt != "" {
-		request["preamble"] = systemPrompt
+// newCohereRequest builds the chat request of a single prompt, with the
+// tool_choice option translated for Cohere.
+func newCohereRequest(model, prompt string, options map[string]interface{}) (chatRequest, error) {
+	request, err := newChatRequest(model, prompt, options)
+	if err != nil {
+		return request, err
 	}
+	request.ToolChoice, request.Tools = cohereToolChoice(options["tool_choice"], options["tools"])
+	return request, nil
+}
+
+// cohereChatRequest is the body of a Cohere chat request with history.
+type cohereChatRequest struct {
+	Model       string          `json:"model"`
+	Message     string          `json:"message"`
+	ChatHistory []cohereMessage `json:"chat_history"`
+	Preamble    string          `json:"preamble,omitempty"`
+}
 
-	return json.Marshal(request)
+// cohereMessage is a message of the chat history of a Cohere chat request.
+type cohereMessage struct {
+	Role    string `json:"role"`
+	Message string `json:"message"`
 }
@@ -0,0 +1,143 @@
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ContentFilterResult is the verdict of a provider's content filter on one
+// category of harm.
+type ContentFilterResult struct {
+	Category string `json:"category"`           // e.g. "hate", "violence" or "jailbreak"
+	Severity string `json:"severity,omitempty"` // "safe", "low", "medium" or "high", when reported
+	Filtered bool   `json:"filtered"`           // Whether the category blocked the content
+	Detected bool   `json:"detected,omitempty"` // Whether the category was detected, for detection-only filters
+}
+
+// ContentFilters is the content filter information of a response, as
+// returned by Azure OpenAI.
+type ContentFilters struct {
+	Prompt   []ContentFilterResult `json:"prompt,omitempty"`   // Verdicts on the prompt
+	Response []ContentFilterResult `json:"response,omitempty"` // Verdicts on the response
+}
+
+// Filtered returns the categories that blocked the prompt or the response.
+func (f *ContentFilters) Filtered() []ContentFilterResult {
+	var filtered []ContentFilterResult
+	for _, results := range [][]ContentFilterResult{f.Prompt, f.Response} {
+		for _, r := range results {
+			if r.Filtered {
+				filtered = append(filtered, r)
+			}
+		}
+	}
+	return filtered
+}
+
+// ContentFilterParser is implemented by providers whose responses may carry
+// content filter annotations. Like CitationParser, it is an optional
+// capability discovered through a type assertion.
+type ContentFilterParser interface {
+	// ParseContentFilters returns the content filter annotations of a
+	// response, or nil if it has none.
+	ParseContentFilters(body []byte) *ContentFilters
+}
+
+// ContentFilterError is returned when a provider's content filter blocks a
+// response, in place of an empty response.
+type ContentFilterError struct {
+	Provider string
+	Results  []ContentFilterResult // The categories that blocked the response, when reported
+}
+
+// Error returns the provider and the filtered categories.
+func (e *ContentFilterError) Error() string {
+	if len(e.Results) == 0 {
+		return fmt.Sprintf("%s content filter blocked the response", e.Provider)
+	}
+	categories := make([]string, len(e.Results))
+	for i, r := range e.Results {
+		categories[i] = r.Category
+	}
+	return fmt.Sprintf("%s content filter blocked the response: %s", e.Provider, strings.Join(categories, ", "))
+}
+
+// ParseContentFilters returns the content filter annotations of a response
+// of Azure OpenAI, which shares the OpenAI response format.
+func (p *OpenAIProvider) ParseContentFilters(body []byte) *ContentFilters {
+	return parseContentFilters(body)
+}
+
+// ParseContentFilters returns the content filter annotations of a response
+// in the OpenAI format, as returned by Azure OpenAI.
+func (p *GenericProvider) ParseContentFilters(body []byte) *ContentFilters {
+	if p.config.Type != TypeOpenAI {
+		return nil
+	}
+	return parseContentFilters(body)
+}
+
+// contentFilterVerdicts are the content filter annotations of a prompt or a
+// response, by category.
+type contentFilterVerdicts map[string]struct {
+	Filtered bool   `json:"filtered"`
+	Severity string `json:"severity"`
+	Detected bool   `json:"detected"`
+}
+
+// results returns the verdicts sorted by category. Annotations that are not
+// verdicts, such as errors of the filter, are skipped.
+func (v contentFilterVerdicts) results() []ContentFilterResult {
+	var results []ContentFilterResult
+	for category, verdict := range v {
+		if category == "error" {
+			continue
+		}
+		results = append(results, ContentFilterResult{Category: category, Severity: verdict.Severity, Filtered: verdict.Filtered, Detected: verdict.Detected})
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Category < results[j].Category })
+	return results
+}
+
+// parseContentFilters reads the prompt_filter_results and the
+// content_filter_results of the first choice of a chat completion.
+func parseContentFilters(body []byte) *ContentFilters {
+	var response struct {
+		PromptFilterResults []struct {
+			ContentFilterResults contentFilterVerdicts `json:"content_filter_results"`
+		} `json:"prompt_filter_results"`
+		Choices []struct {
+			ContentFilterResults contentFilterVerdicts `json:"content_filter_results"`
+		} `json:"choices"`
+	}
+	if json.Unmarshal(body, &response) != nil {
+		return nil
+	}
+	filters := &ContentFilters{}
+	for _, p := range response.PromptFilterResults {
+		filters.Prompt = append(filters.Prompt, p.ContentFilterResults.results()...)
+	}
+	if len(response.Choices) > 0 {
+		filters.Response = response.Choices[0].ContentFilterResults.results()
+	}
+	if filters.Prompt == nil && filters.Response == nil {
+		return nil
+	}
+	return filters
+}
+
+// contentFilterError returns the error of a response whose first choice
+// finished because of the content filter.
+func contentFilterError(provider string, body []byte) *ContentFilterError {
+	err := &ContentFilterError{Provider: provider}
+	if filters := parseContentFilters(body); filters != nil {
+		for _, r := range filters.Response {
+			if r.Filtered {
+				err.Results = append(err.Results, r)
+			}
+		}
+	}
+	return err
+}
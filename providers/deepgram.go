@@ -0,0 +1,212 @@
+// Package providers implements LLM provider interfaces and implementations.
+package providers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+
+	"github.com/teilomillet/gollm/config"
+	"github.com/teilomillet/gollm/types"
+	"github.com/teilomillet/gollm/utils"
+)
+
+// DeepgramProvider implements the Provider interface for Deepgram's speech API.
+// Deepgram is a speech-to-text service, so only transcription is supported;
+// the text generation methods return an error.
+type DeepgramProvider struct {
+	apiKey       string                 // API key for authentication
+	model        string                 // Model identifier (e.g., "nova-2")
+	extraHeaders map[string]string      // Additional HTTP headers
+	options      map[string]interface{} // Model-specific options
+	logger       utils.Logger           // Logger instance
+}
+
+// NewDeepgramProvider creates a new Deepgram provider instance.
+//
+// Parameters:
+//   - apiKey: Deepgram API key for authentication
+//   - model: The transcription model to use (e.g., "nova-2")
+//   - extraHeaders: Additional HTTP headers for requests
+//
+// Returns:
+//   - A configured Deepgram Provider instance
+func NewDeepgramProvider(apiKey, model string, extraHeaders map[string]string) Provider {
+	if extraHeaders == nil {
+		extraHeaders = make(map[string]string)
+	}
+	return &DeepgramProvider{
+		apiKey:       apiKey,
+		model:        model,
+		extraHeaders: extraHeaders,
+		options:      make(map[string]interface{}),
+		logger:       utils.NewLogger(utils.LogLevelInfo),
+	}
+}
+
+// errDeepgramGeneration is returned by the text generation methods.
+var errDeepgramGeneration = fmt.Errorf("deepgram only supports audio transcription")
+
+// Name returns "deepgram" as the provider identifier.
+func (p *DeepgramProvider) Name() string {
+	return "deepgram"
+}
+
+// Endpoint returns the Deepgram pre-recorded audio endpoint.
+func (p *DeepgramProvider) Endpoint() string {
+	return "https://api.deepgram.com/v1/listen"
+}
+
+// Headers returns the HTTP headers required for Deepgram API requests.
+func (p *DeepgramProvider) Headers() map[string]string {
+	headers := map[string]string{
+		"Content-Type":  "application/json",
+		"Authorization": "Token " + p.apiKey,
+	}
+	for key, value := range p.extraHeaders {
+		headers[key] = value
+	}
+	return headers
+}
+
+// PrepareRequest is not supported by Deepgram.
+func (p *DeepgramProvider) PrepareRequest(prompt string, options map[string]interface{}) ([]byte, error) {
+	return nil, errDeepgramGeneration
+}
+
+// PrepareRequestWithSchema is not supported by Deepgram.
+func (p *DeepgramProvider) PrepareRequestWithSchema(prompt string, options map[string]interface{}, schema interface{}) ([]byte, error) {
+	return nil, errDeepgramGeneration
+}
+
+// PrepareRequestWithMessages is not supported by Deepgram.
+func (p *DeepgramProvider) PrepareRequestWithMessages(messages []types.MemoryMessage, options map[string]interface{}) ([]byte, error) {
+	return nil, errDeepgramGeneration
+}
+
+// ParseResponse is not supported by Deepgram.
+func (p *DeepgramProvider) ParseResponse(body []byte) (string, error) {
+	return "", errDeepgramGeneration
+}
+
+// SetExtraHeaders configures additional HTTP headers for API requests.
+func (p *DeepgramProvider) SetExtraHeaders(extraHeaders map[string]string) {
+	p.extraHeaders = extraHeaders
+}
+
+// HandleFunctionCalls is not supported by Deepgram.
+func (p *DeepgramProvider) HandleFunctionCalls(body []byte) ([]byte, error) {
+	return nil, errDeepgramGeneration
+}
+
+// SupportsJSONSchema returns false; Deepgram does not generate text.
+func (p *DeepgramProvider) SupportsJSONSchema() bool {
+	return false
+}
+
+// SetDefaultOptions is a no-op since generation parameters do not apply to transcription.
+func (p *DeepgramProvider) SetDefaultOptions(config *config.Config) {}
+
+// SetOption sets a query parameter passed to the listen endpoint
+// (e.g., "smart_format", "diarize", "punctuate").
+func (p *DeepgramProvider) SetOption(key string, value interface{}) {
+	p.options[key] = value
+}
+
+// SetLogger configures the logger for the Deepgram provider.
+func (p *DeepgramProvider) SetLogger(logger utils.Logger) {
+	p.logger = logger
+}
+
+// SupportsStreaming returns false; live transcription is not implemented.
+func (p *DeepgramProvider) SupportsStreaming() bool {
+	return false
+}
+
+// PrepareStreamRequest is not supported by Deepgram.
+func (p *DeepgramProvider) PrepareStreamRequest(prompt string, options map[string]interface{}) ([]byte, error) {
+	return nil, errDeepgramGeneration
+}
+
+// ParseStreamResponse is not supported by Deepgram.
+func (p *DeepgramProvider) ParseStreamResponse(chunk []byte) (string, error) {
+	return "", errDeepgramGeneration
+}
+
+// TranscriptionEndpoint returns the listen endpoint with the model, language
+// and any provider options encoded as query parameters.
+func (p *DeepgramProvider) TranscriptionEndpoint(opts TranscriptionOptions) string {
+	q := url.Values{}
+	model := opts.Model
+	if model == "" {
+		model = p.model
+	}
+	if model != "" {
+		q.Set("model", model)
+	}
+	if opts.Language != "" {
+		q.Set("language", opts.Language)
+	} else {
+		q.Set("detect_language", "true")
+	}
+	for k, v := range p.options {
+		q.Set(k, fmt.Sprint(v))
+	}
+	return p.Endpoint() + "?" + q.Encode()
+}
+
+// PrepareTranscriptionRequest sends the raw audio bytes as the request body.
+// Deepgram detects the format itself, so MediaType is optional.
+func (p *DeepgramProvider) PrepareTranscriptionRequest(audio io.Reader, opts TranscriptionOptions) ([]byte, string, error) {
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, audio); err != nil {
+		return nil, "", fmt.Errorf("failed to read audio: %w", err)
+	}
+	contentType := opts.MediaType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	return buf.Bytes(), contentType, nil
+}
+
+// ParseTranscriptionResponse extracts the first alternative of the first channel.
+func (p *DeepgramProvider) ParseTranscriptionResponse(body []byte) (*Transcription, error) {
+	var response struct {
+		Metadata struct {
+			Duration float64 `json:"duration"`
+		} `json:"metadata"`
+		Results struct {
+			Channels []struct {
+				DetectedLanguage string `json:"detected_language"`
+				Alternatives     []struct {
+					Transcript string `json:"transcript"`
+					Words      []struct {
+						Word  string  `json:"word"`
+						Start float64 `json:"start"`
+						End   float64 `json:"end"`
+					} `json:"words"`
+				} `json:"alternatives"`
+			} `json:"channels"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("error parsing transcription response: %w", err)
+	}
+	if len(response.Results.Channels) == 0 || len(response.Results.Channels[0].Alternatives) == 0 {
+		return nil, fmt.Errorf("empty transcription response")
+	}
+
+	channel := response.Results.Channels[0]
+	alt := channel.Alternatives[0]
+	result := &Transcription{
+		Text:     alt.Transcript,
+		Language: channel.DetectedLanguage,
+		Duration: response.Metadata.Duration,
+	}
+	for _, w := range alt.Words {
+		result.Segments = append(result.Segments, TranscriptionSegment{Start: w.Start, End: w.End, Text: w.Word})
+	}
+	return result, nil
+}
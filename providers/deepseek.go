@@ -3,14 +3,29 @@ package providers
 
 import (
 	"github.com/teilomillet/gollm/config"
+	"github.com/teilomillet/gollm/types"
+	"github.com/teilomillet/gollm/utils"
 )
 
 // DeepSeekProvider implements the Provider interface for DeepSeek's API.
-// It inherits from OpenAIProvider since DeepSeek uses an OpenAI-compatible API.
+// DeepSeek's chat API is OpenAI-compatible, so requests and responses are
+// handled by a wrapped OpenAIProvider. The OpenAIProvider is not embedded:
+// optional capabilities are discovered through type assertions, and an
+// embedded provider would expose OpenAI's other APIs (files, batches,
+// moderation...) to be called with the DeepSeek key. DeepSeek only has the
+// capabilities asserted below.
 type DeepSeekProvider struct {
-	OpenAIProvider
+	openai *OpenAIProvider
 }
 
+// The optional capabilities of DeepSeek.
+var (
+	_ Conversational   = (*DeepSeekProvider)(nil)
+	_ ToolCallStreamer = (*DeepSeekProvider)(nil)
+	_ HealthChecker    = (*DeepSeekProvider)(nil)
+	_ ModelLister      = (*DeepSeekProvider)(nil)
+)
+
 // NewDeepSeekProvider creates a new DeepSeek provider instance.
 // It initializes the provider with the given API key, model, and optional headers.
 //
@@ -22,11 +37,9 @@ type DeepSeekProvider struct {
 // Returns:
 //   - A configured DeepSeek Provider instance
 func NewDeepSeekProvider(apiKey, model string, extraHeaders map[string]string) Provider {
-	provider := &DeepSeekProvider{
-		OpenAIProvider: *NewOpenAIProvider(apiKey, model, extraHeaders).(*OpenAIProvider),
+	return &DeepSeekProvider{
+		openai: NewOpenAIProvider(apiKey, model, extraHeaders).(*OpenAIProvider),
 	}
-	// Override the endpoint
-	return provider
 }
 
 // Name returns "deepseek" as the provider identifier.
@@ -47,10 +60,87 @@ func (p *DeepSeekProvider) Endpoint() string {
 // Parameters:
 //   - config: The global configuration containing options to set
 func (p *DeepSeekProvider) SetDefaultOptions(config *config.Config) {
-	p.SetOption("temperature", config.Temperature)
-	p.SetOption("max_tokens", config.MaxTokens)
-	if config.Seed != nil {
-		p.SetOption("seed", *config.Seed)
+	setSamplingDefaults(p, config)
+	if config.ReasoningBudget != nil {
+		// DeepSeek has no budget: deepseek-reasoner always thinks, deepseek-chat never does
+		p.openai.logger.Debug("Reasoning budget ignored: DeepSeek models use their defaults", "model", p.openai.model)
 	}
-	p.logger.Debug("Default options set", "temperature", config.Temperature, "max_tokens", config.MaxTokens)
+	p.openai.logger.Debug("Default options set", "temperature", config.Temperature, "max_tokens", config.MaxTokens)
+}
+
+// Headers returns the headers of the OpenAI-compatible API, with the DeepSeek key.
+func (p *DeepSeekProvider) Headers() map[string]string {
+	return p.openai.Headers()
+}
+
+// SetExtraHeaders configures additional HTTP headers for API requests.
+func (p *DeepSeekProvider) SetExtraHeaders(extraHeaders map[string]string) {
+	p.openai.SetExtraHeaders(extraHeaders)
+}
+
+// SetOption sets a specific option for the DeepSeek provider.
+func (p *DeepSeekProvider) SetOption(key string, value interface{}) {
+	p.openai.SetOption(key, value)
+}
+
+// SetLogger configures the logger for the DeepSeek provider.
+func (p *DeepSeekProvider) SetLogger(logger utils.Logger) {
+	p.openai.SetLogger(logger)
+}
+
+// SupportsJSONSchema indicates whether the model supports JSON schema validation.
+func (p *DeepSeekProvider) SupportsJSONSchema() bool {
+	return p.openai.SupportsJSONSchema()
+}
+
+// SupportsStreaming indicates whether streaming is supported.
+func (p *DeepSeekProvider) SupportsStreaming() bool {
+	return p.openai.SupportsStreaming()
+}
+
+// SupportsMessages returns true: DeepSeek accepts OpenAI-style message lists.
+func (p *DeepSeekProvider) SupportsMessages() bool {
+	return true
+}
+
+// PrepareRequest creates the request body of a chat completion.
+func (p *DeepSeekProvider) PrepareRequest(prompt string, options map[string]interface{}) ([]byte, error) {
+	return p.openai.PrepareRequest(prompt, options)
+}
+
+// PrepareRequestWithSchema creates the request body of a chat completion
+// constrained by a JSON schema.
+func (p *DeepSeekProvider) PrepareRequestWithSchema(prompt string, options map[string]interface{}, schema interface{}) ([]byte, error) {
+	return p.openai.PrepareRequestWithSchema(prompt, options, schema)
+}
+
+// PrepareRequestWithMessages creates the request body of a chat completion
+// from structured messages.
+func (p *DeepSeekProvider) PrepareRequestWithMessages(messages []types.MemoryMessage, options map[string]interface{}) ([]byte, error) {
+	return p.openai.PrepareRequestWithMessages(messages, options)
+}
+
+// PrepareStreamRequest creates the request body of a streaming chat completion.
+func (p *DeepSeekProvider) PrepareStreamRequest(prompt string, options map[string]interface{}) ([]byte, error) {
+	return p.openai.PrepareStreamRequest(prompt, options)
+}
+
+// ParseResponse extracts the generated text from a chat completion.
+func (p *DeepSeekProvider) ParseResponse(body []byte) (string, error) {
+	return p.openai.ParseResponse(body)
+}
+
+// ParseStreamResponse extracts the text of a streamed chunk.
+func (p *DeepSeekProvider) ParseStreamResponse(chunk []byte) (string, error) {
+	return p.openai.ParseStreamResponse(chunk)
+}
+
+// ParseStreamToolCalls extracts the tool call fragments of a streamed chunk.
+func (p *DeepSeekProvider) ParseStreamToolCalls(chunk []byte) ([]ToolCallDelta, error) {
+	return p.openai.ParseStreamToolCalls(chunk)
+}
+
+// HandleFunctionCalls processes function calling in a chat completion.
+func (p *DeepSeekProvider) HandleFunctionCalls(body []byte) ([]byte, error) {
+	return p.openai.HandleFunctionCalls(body)
 }
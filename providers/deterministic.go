@@ -0,0 +1,67 @@
+package providers
+
+// DeterministicSampler is implemented by providers that know how to make
+// their sampling reproducible. Like ChoicesParser, it is an optional
+// capability discovered through a type assertion; deterministic mode only
+// sets the temperature of other providers and warns that their responses
+// may vary.
+type DeterministicSampler interface {
+	// DeterministicOptions returns the options making sampling reproducible
+	// with the given seed, and whether the API accepts a seed.
+	DeterministicOptions(seed int) (options map[string]interface{}, seeded bool)
+}
+
+// DeterministicOptions sets temperature 0, top_p 1 and the seed. OpenAI
+// seeds are best effort; the system_fingerprint of responses reveals
+// backend changes.
+func (p *OpenAIProvider) DeterministicOptions(seed int) (map[string]interface{}, bool) {
+	return map[string]interface{}{"temperature": 0.0, "top_p": 1.0, "seed": seed}, true
+}
+
+// DeterministicOptions sets temperature 0. Anthropic accepts no seed, and
+// rejects top_p alongside temperature on recent models.
+func (p *AnthropicProvider) DeterministicOptions(seed int) (map[string]interface{}, bool) {
+	return map[string]interface{}{"temperature": 0.0}, false
+}
+
+// DeterministicOptions sets temperature 0, top_p 1 and the seed.
+func (p *GroqProvider) DeterministicOptions(seed int) (map[string]interface{}, bool) {
+	return map[string]interface{}{"temperature": 0.0, "top_p": 1.0, "seed": seed}, true
+}
+
+// DeterministicOptions sets temperature 0, top_p 1 and the seed, which
+// Mistral names random_seed.
+func (p *MistralProvider) DeterministicOptions(seed int) (map[string]interface{}, bool) {
+	return map[string]interface{}{"temperature": 0.0, "top_p": 1.0, "random_seed": seed}, true
+}
+
+// DeterministicOptions sets temperature 0, p 1 and the seed.
+func (p *CohereProvider) DeterministicOptions(seed int) (map[string]interface{}, bool) {
+	return map[string]interface{}{"temperature": 0.0, "p": 1.0, "seed": seed}, true
+}
+
+// DeterministicOptions sets temperature 0, top_p 1 and the seed, and
+// disables Mirostat sampling, which ignores them.
+func (p *OllamaProvider) DeterministicOptions(seed int) (map[string]interface{}, bool) {
+	return map[string]interface{}{"temperature": 0.0, "top_p": 1.0, "seed": seed, "mirostat": 0}, true
+}
+
+// DeterministicOptions sets temperature 0, top_p 1 and the seed, which
+// OpenRouter forwards to the models that support it.
+func (p *OpenRouterProvider) DeterministicOptions(seed int) (map[string]interface{}, bool) {
+	return map[string]interface{}{"temperature": 0.0, "top_p": 1.0, "seed": seed}, true
+}
+
+// DeterministicOptions follows the OpenAI or Anthropic API of the provider.
+func (p *GenericProvider) DeterministicOptions(seed int) (map[string]interface{}, bool) {
+	if p.config.Type == TypeAnthropic || p.config.Type == TypeClaude {
+		return map[string]interface{}{"temperature": 0.0}, false
+	}
+	return map[string]interface{}{"temperature": 0.0, "top_p": 1.0, "seed": seed}, true
+}
+
+// DeterministicOptions sets the same options as OpenAI; the mock is
+// deterministic anyway.
+func (p *MockProvider) DeterministicOptions(seed int) (map[string]interface{}, bool) {
+	return map[string]interface{}{"temperature": 0.0, "top_p": 1.0, "seed": seed}, true
+}
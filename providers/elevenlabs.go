@@ -0,0 +1,177 @@
+// Package providers implements LLM provider interfaces and implementations.
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/teilomillet/gollm/config"
+	"github.com/teilomillet/gollm/types"
+	"github.com/teilomillet/gollm/utils"
+)
+
+// ElevenLabsProvider implements the Provider interface for ElevenLabs' speech API.
+// ElevenLabs is a text-to-speech service, so only speech synthesis is supported;
+// the text generation methods return an error.
+type ElevenLabsProvider struct {
+	apiKey       string                 // API key for authentication
+	model        string                 // Model identifier (e.g., "eleven_multilingual_v2")
+	extraHeaders map[string]string      // Additional HTTP headers
+	options      map[string]interface{} // Voice settings (e.g., "stability", "similarity_boost")
+	logger       utils.Logger           // Logger instance
+}
+
+// NewElevenLabsProvider creates a new ElevenLabs provider instance.
+//
+// Parameters:
+//   - apiKey: ElevenLabs API key for authentication
+//   - model: The speech model to use (e.g., "eleven_multilingual_v2")
+//   - extraHeaders: Additional HTTP headers for requests
+//
+// Returns:
+//   - A configured ElevenLabs Provider instance
+func NewElevenLabsProvider(apiKey, model string, extraHeaders map[string]string) Provider {
+	if extraHeaders == nil {
+		extraHeaders = make(map[string]string)
+	}
+	return &ElevenLabsProvider{
+		apiKey:       apiKey,
+		model:        model,
+		extraHeaders: extraHeaders,
+		options:      make(map[string]interface{}),
+		logger:       utils.NewLogger(utils.LogLevelInfo),
+	}
+}
+
+// errElevenLabsGeneration is returned by the text generation methods.
+var errElevenLabsGeneration = fmt.Errorf("elevenlabs only supports speech synthesis")
+
+// Name returns "elevenlabs" as the provider identifier.
+func (p *ElevenLabsProvider) Name() string {
+	return "elevenlabs"
+}
+
+// Endpoint returns the ElevenLabs text-to-speech base endpoint.
+func (p *ElevenLabsProvider) Endpoint() string {
+	return "https://api.elevenlabs.io/v1/text-to-speech"
+}
+
+// Headers returns the HTTP headers required for ElevenLabs API requests.
+func (p *ElevenLabsProvider) Headers() map[string]string {
+	headers := map[string]string{
+		"Content-Type": "application/json",
+		"xi-api-key":   p.apiKey,
+	}
+	for key, value := range p.extraHeaders {
+		headers[key] = value
+	}
+	return headers
+}
+
+// PrepareRequest is not supported by ElevenLabs.
+func (p *ElevenLabsProvider) PrepareRequest(prompt string, options map[string]interface{}) ([]byte, error) {
+	return nil, errElevenLabsGeneration
+}
+
+// PrepareRequestWithSchema is not supported by ElevenLabs.
+func (p *ElevenLabsProvider) PrepareRequestWithSchema(prompt string, options map[string]interface{}, schema interface{}) ([]byte, error) {
+	return nil, errElevenLabsGeneration
+}
+
+// PrepareRequestWithMessages is not supported by ElevenLabs.
+func (p *ElevenLabsProvider) PrepareRequestWithMessages(messages []types.MemoryMessage, options map[string]interface{}) ([]byte, error) {
+	return nil, errElevenLabsGeneration
+}
+
+// ParseResponse is not supported by ElevenLabs.
+func (p *ElevenLabsProvider) ParseResponse(body []byte) (string, error) {
+	return "", errElevenLabsGeneration
+}
+
+// SetExtraHeaders configures additional HTTP headers for API requests.
+func (p *ElevenLabsProvider) SetExtraHeaders(extraHeaders map[string]string) {
+	p.extraHeaders = extraHeaders
+}
+
+// HandleFunctionCalls is not supported by ElevenLabs.
+func (p *ElevenLabsProvider) HandleFunctionCalls(body []byte) ([]byte, error) {
+	return nil, errElevenLabsGeneration
+}
+
+// SupportsJSONSchema returns false; ElevenLabs does not generate text.
+func (p *ElevenLabsProvider) SupportsJSONSchema() bool {
+	return false
+}
+
+// SetDefaultOptions is a no-op since generation parameters do not apply to speech.
+func (p *ElevenLabsProvider) SetDefaultOptions(config *config.Config) {}
+
+// SetOption sets a voice setting sent with each request
+// (e.g., "stability", "similarity_boost", "style").
+func (p *ElevenLabsProvider) SetOption(key string, value interface{}) {
+	p.options[key] = value
+}
+
+// SetLogger configures the logger for the ElevenLabs provider.
+func (p *ElevenLabsProvider) SetLogger(logger utils.Logger) {
+	p.logger = logger
+}
+
+// SupportsStreaming returns false; text streaming does not apply to speech.
+func (p *ElevenLabsProvider) SupportsStreaming() bool {
+	return false
+}
+
+// PrepareStreamRequest is not supported by ElevenLabs.
+func (p *ElevenLabsProvider) PrepareStreamRequest(prompt string, options map[string]interface{}) ([]byte, error) {
+	return nil, errElevenLabsGeneration
+}
+
+// ParseStreamResponse is not supported by ElevenLabs.
+func (p *ElevenLabsProvider) ParseStreamResponse(chunk []byte) (string, error) {
+	return "", errElevenLabsGeneration
+}
+
+// SpeechEndpoint returns the streaming text-to-speech URL for the voice.
+// The voice defaults to "21m00Tcm4TlvDq8ikWAM" (Rachel).
+func (p *ElevenLabsProvider) SpeechEndpoint(opts SpeechOptions) string {
+	voice := opts.Voice
+	if voice == "" {
+		voice = "21m00Tcm4TlvDq8ikWAM"
+	}
+	endpoint := p.Endpoint() + "/" + url.PathEscape(voice) + "/stream"
+	if opts.Format != "" {
+		endpoint += "?output_format=" + url.QueryEscape(opts.Format)
+	}
+	return endpoint
+}
+
+// PrepareSpeechRequest builds an ElevenLabs speech request.
+// The model defaults to the provider's model, then "eleven_multilingual_v2".
+func (p *ElevenLabsProvider) PrepareSpeechRequest(text string, opts SpeechOptions) ([]byte, error) {
+	model := opts.Model
+	if model == "" {
+		model = p.model
+	}
+	if model == "" {
+		model = "eleven_multilingual_v2"
+	}
+
+	requestBody := map[string]interface{}{
+		"text":     text,
+		"model_id": model,
+	}
+
+	voiceSettings := make(map[string]interface{}, len(p.options)+1)
+	for k, v := range p.options {
+		voiceSettings[k] = v
+	}
+	if opts.Speed > 0 {
+		voiceSettings["speed"] = opts.Speed
+	}
+	if len(voiceSettings) > 0 {
+		requestBody["voice_settings"] = voiceSettings
+	}
+	return json.Marshal(requestBody)
+}
@@ -0,0 +1,137 @@
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// APIError is an error response of a provider API, parsed from the error
+// envelope of its body.
+type APIError struct {
+	// Provider is the name of the provider
+	Provider string
+
+	// StatusCode is the HTTP status of the response
+	StatusCode int
+
+	// Type is the category of the error, e.g. "invalid_request_error" or
+	// "rate_limit_error"; Gemini's status, e.g. "INVALID_ARGUMENT"
+	Type string
+
+	// Code is the specific error, e.g. "context_length_exceeded", if any
+	Code string
+
+	// Param is the request parameter at fault, if any
+	Param string
+
+	// Message is the error message of the API, or the HTTP status text
+	Message string
+
+	// RequestID identifies the request to the provider's support, if any
+	RequestID string
+}
+
+// Error returns the provider, category and message of the error.
+func (e *APIError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s API error %d", e.Provider, e.StatusCode)
+	if e.Type != "" {
+		sb.WriteString(" " + e.Type)
+	}
+	if e.Code != "" && e.Code != e.Type {
+		sb.WriteString(" (" + e.Code + ")")
+	}
+	sb.WriteString(": " + e.Message)
+	if e.RequestID != "" {
+		sb.WriteString(" [request " + e.RequestID + "]")
+	}
+	return sb.String()
+}
+
+// requestIDHeaders are the response headers carrying the request ID, by
+// provider convention.
+var requestIDHeaders = []string{"x-request-id", "request-id", "apim-request-id", "x-goog-request-id"}
+
+// ParseAPIError parses the error response of a provider API. It understands
+// the envelopes of OpenAI and OpenAI-compatible APIs ({"error": {"type",
+// "code", "message"}}), Anthropic ({"type": "error", "error": {...}}),
+// Mistral ({"object": "error", "message", "type"}), Gemini ({"error":
+// {"status", "message"}}) and plain {"error": "..."} or {"message": "..."}
+// bodies. Unknown bodies are not echoed: the message is the HTTP status text.
+func ParseAPIError(provider string, statusCode int, header http.Header, body []byte) *APIError {
+	apiErr := &APIError{Provider: provider, StatusCode: statusCode}
+	for _, name := range requestIDHeaders {
+		if id := header.Get(name); id != "" {
+			apiErr.RequestID = id
+			break
+		}
+	}
+
+	var envelope struct {
+		Error     json.RawMessage `json:"error"`
+		Message   json.RawMessage `json:"message"`
+		Type      string          `json:"type"`
+		Code      json.RawMessage `json:"code"`
+		Param     *string         `json:"param"`
+		RequestID string          `json:"request_id"`
+	}
+	if CheckResponseBody(body) == nil && json.Unmarshal(body, &envelope) == nil {
+		if apiErr.RequestID == "" {
+			apiErr.RequestID = envelope.RequestID
+		}
+		var nested struct {
+			Message json.RawMessage `json:"message"`
+			Type    string          `json:"type"`
+			Status  json.RawMessage `json:"status"` // Gemini's status name; Azure repeats the HTTP status
+			Code    json.RawMessage `json:"code"`
+			Param   *string         `json:"param"`
+		}
+		var text string
+		switch {
+		case json.Unmarshal(envelope.Error, &nested) == nil && nested.Message != nil:
+			apiErr.Message = rawText(nested.Message)
+			apiErr.Type = nested.Type
+			if apiErr.Type == "" {
+				json.Unmarshal(nested.Status, &apiErr.Type)
+			}
+			apiErr.Code = rawText(nested.Code)
+			if nested.Param != nil {
+				apiErr.Param = *nested.Param
+			}
+		case json.Unmarshal(envelope.Error, &text) == nil && text != "":
+			apiErr.Message = text
+		case envelope.Message != nil:
+			apiErr.Message = rawText(envelope.Message)
+			if envelope.Type != "error" {
+				apiErr.Type = envelope.Type
+			}
+			apiErr.Code = rawText(envelope.Code)
+			if envelope.Param != nil {
+				apiErr.Param = *envelope.Param
+			}
+		}
+	}
+	if apiErr.Code == fmt.Sprint(statusCode) {
+		// Gemini and OpenRouter repeat the HTTP status as the code
+		apiErr.Code = ""
+	}
+	if apiErr.Message == "" {
+		apiErr.Message = http.StatusText(statusCode)
+	}
+	return apiErr
+}
+
+// rawText returns a JSON string as is, and other JSON values, such as
+// numeric codes or Mistral's validation details, as JSON text.
+func rawText(raw json.RawMessage) string {
+	if len(raw) == 0 || string(raw) == "null" {
+		return ""
+	}
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	return string(raw)
+}
@@ -0,0 +1,47 @@
+package providers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAPIError(t *testing.T) {
+	header := http.Header{}
+	header.Set("x-request-id", "req_123")
+
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   APIError
+	}{
+		{"OpenAI", 400, `{"error": {"message": "This model's maximum context length is 8192 tokens.", "type": "invalid_request_error", "param": "messages", "code": "context_length_exceeded"}}`,
+			APIError{Type: "invalid_request_error", Code: "context_length_exceeded", Param: "messages", Message: "This model's maximum context length is 8192 tokens."}},
+		{"Anthropic", 529, `{"type": "error", "error": {"type": "overloaded_error", "message": "Overloaded"}, "request_id": "req_body"}`,
+			APIError{Type: "overloaded_error", Message: "Overloaded"}},
+		{"Mistral", 422, `{"object": "error", "message": {"detail": [{"msg": "field required"}]}, "type": "invalid_request_message_error", "param": null, "code": null}`,
+			APIError{Type: "invalid_request_message_error", Message: `{"detail": [{"msg": "field required"}]}`}},
+		{"Gemini", 429, `{"error": {"code": 429, "message": "Quota exceeded", "status": "RESOURCE_EXHAUSTED"}}`,
+			APIError{Type: "RESOURCE_EXHAUSTED", Message: "Quota exceeded"}},
+		{"Azure", 400, `{"error": {"message": "The response was filtered", "type": null, "param": "prompt", "code": "content_filter", "status": 400}}`,
+			APIError{Code: "content_filter", Param: "prompt", Message: "The response was filtered"}},
+		{"OpenRouter", 402, `{"error": {"code": 402, "message": "Insufficient credits"}}`,
+			APIError{Message: "Insufficient credits"}},
+		{"Ollama", 404, `{"error": "model 'llama9' not found"}`,
+			APIError{Message: "model 'llama9' not found"}},
+		{"HTML", 502, `<html><body>Bad Gateway from some proxy</body></html>`,
+			APIError{Message: "Bad Gateway"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseAPIError("test", tt.status, header, []byte(tt.body))
+			tt.want.Provider, tt.want.StatusCode, tt.want.RequestID = "test", tt.status, "req_123"
+			assert.Equal(t, tt.want, *got)
+		})
+	}
+
+	err := ParseAPIError("anthropic", 529, nil, []byte(`{"type": "error", "error": {"type": "overloaded_error", "message": "Overloaded"}, "request_id": "req_body"}`))
+	assert.Equal(t, "anthropic API error 529 overloaded_error: Overloaded [request req_body]", err.Error())
+}
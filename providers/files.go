@@ -0,0 +1,278 @@
+// Package providers implements LLM provider interfaces and implementations.
+package providers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// FileInfo describes a file stored with a provider.
+type FileInfo struct {
+	ID        string    `json:"id"`
+	Filename  string    `json:"filename"`
+	Bytes     int64     `json:"bytes"`
+	Purpose   string    `json:"purpose,omitempty"`   // Use of the file, e.g. "fine-tune"
+	MediaType string    `json:"mime_type,omitempty"` // Reported by providers that store it
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// FileUploadOptions configures a file upload.
+type FileUploadOptions struct {
+	Filename  string // Name of the file
+	MediaType string // MIME type of the content
+	Purpose   string // Use of the file; provider default if empty
+}
+
+// FileManager is implemented by providers with a Files API, which stores
+// files that callers upload, list and delete themselves, such as fine-tuning
+// data. Providers whose messages can reference files also upload the
+// documents attached to prompts once, then reference them by file ID, which
+// avoids resending large files with every request.
+// Like Transcriber, it is an optional capability discovered through a type
+// assertion.
+type FileManager interface {
+	// FilesEndpoint returns the URL of the Files API. Individual files are
+	// addressed as FilesEndpoint() + "/" + fileID.
+	FilesEndpoint() string
+
+	// FileHeaders returns the HTTP headers for Files API requests and for
+	// generation requests that reference uploaded files.
+	FileHeaders() map[string]string
+
+	// MaxFileSize returns the largest file accepted, in bytes.
+	MaxFileSize() int64
+
+	// PrepareFileUpload builds the upload request body and returns it
+	// together with the Content-Type header to send.
+	PrepareFileUpload(content io.Reader, opts FileUploadOptions) ([]byte, string, error)
+
+	// ParseFileInfo extracts the description of a file from an upload or
+	// retrieval response.
+	ParseFileInfo(body []byte) (*FileInfo, error)
+
+	// ParseFileList extracts the files of a list response.
+	ParseFileList(body []byte) ([]FileInfo, error)
+
+	// UploadsDocuments reports whether documents attached to prompts are
+	// uploaded and referenced by file ID rather than sent inline.
+	UploadsDocuments() bool
+}
+
+// fileObject is a file as described by the OpenAI, Mistral and Anthropic
+// Files APIs, which differ in the names of the size field and in the format
+// of the creation time.
+type fileObject struct {
+	ID        string          `json:"id"`
+	Filename  string          `json:"filename"`
+	Bytes     int64           `json:"bytes"`
+	SizeBytes int64           `json:"size_bytes"`
+	Purpose   string          `json:"purpose"`
+	MimeType  string          `json:"mime_type"`
+	CreatedAt json.RawMessage `json:"created_at"`
+}
+
+// info converts the object, reading its creation time as Unix seconds or
+// RFC 3339.
+func (f fileObject) info() FileInfo {
+	info := FileInfo{ID: f.ID, Filename: f.Filename, Bytes: f.Bytes, Purpose: f.Purpose, MediaType: f.MimeType}
+	if info.Bytes == 0 {
+		info.Bytes = f.SizeBytes
+	}
+	var seconds int64
+	var text string
+	if json.Unmarshal(f.CreatedAt, &seconds) == nil {
+		info.CreatedAt = time.Unix(seconds, 0).UTC()
+	} else if json.Unmarshal(f.CreatedAt, &text) == nil {
+		info.CreatedAt, _ = time.Parse(time.RFC3339, text)
+	}
+	return info
+}
+
+// parseFileObject parses a single file object.
+func parseFileObject(body []byte) (*FileInfo, error) {
+	var file fileObject
+	if err := json.Unmarshal(body, &file); err != nil {
+		return nil, fmt.Errorf("error parsing file response: %w", err)
+	}
+	if file.ID == "" {
+		return nil, fmt.Errorf("file response has no id")
+	}
+	info := file.info()
+	return &info, nil
+}
+
+// parseFileObjects parses a list of file objects under "data".
+func parseFileObjects(body []byte) ([]FileInfo, error) {
+	var list struct {
+		Data []fileObject `json:"data"`
+	}
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, fmt.Errorf("error parsing file list response: %w", err)
+	}
+	files := make([]FileInfo, len(list.Data))
+	for i, file := range list.Data {
+		files[i] = file.info()
+	}
+	return files, nil
+}
+
+// prepareMultipartFile builds a multipart form with a single "file" part
+// carrying the given media type, followed by any extra form fields.
+func prepareMultipartFile(content io.Reader, filename, mediaType string, fields map[string]string) ([]byte, string, error) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+
+	if mediaType == "" {
+		mediaType = "application/octet-stream"
+	}
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file"; filename="%s"`, strings.ReplaceAll(filename, `"`, `\"`)))
+	header.Set("Content-Type", mediaType)
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create form file: %w", err)
+	}
+	if _, err := io.Copy(part, content); err != nil {
+		return nil, "", fmt.Errorf("failed to read file content: %w", err)
+	}
+
+	for k, v := range fields {
+		if err := writer.WriteField(k, v); err != nil {
+			return nil, "", fmt.Errorf("failed to write form field %s: %w", k, err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, "", fmt.Errorf("failed to finalize multipart body: %w", err)
+	}
+	return buf.Bytes(), writer.FormDataContentType(), nil
+}
+
+// FilesEndpoint returns the Anthropic Files API endpoint.
+func (p *AnthropicProvider) FilesEndpoint() string {
+	return "https://api.anthropic.com/v1/files"
+}
+
+// FileHeaders returns the standard Anthropic headers with the Files API beta enabled.
+func (p *AnthropicProvider) FileHeaders() map[string]string {
+	headers := p.Headers()
+	if beta := headers["anthropic-beta"]; beta != "" {
+		headers["anthropic-beta"] = beta + ",files-api-2025-04-14"
+	} else {
+		headers["anthropic-beta"] = "files-api-2025-04-14"
+	}
+	return headers
+}
+
+// MaxFileSize returns the 500 MB limit of the Anthropic Files API.
+func (p *AnthropicProvider) MaxFileSize() int64 {
+	return 500 << 20
+}
+
+// PrepareFileUpload builds a multipart upload for the Anthropic Files API,
+// which has no purposes.
+func (p *AnthropicProvider) PrepareFileUpload(content io.Reader, opts FileUploadOptions) ([]byte, string, error) {
+	return prepareMultipartFile(content, opts.Filename, opts.MediaType, nil)
+}
+
+// ParseFileInfo extracts the description of an Anthropic file.
+func (p *AnthropicProvider) ParseFileInfo(body []byte) (*FileInfo, error) {
+	return parseFileObject(body)
+}
+
+// ParseFileList extracts the files of an Anthropic list response.
+func (p *AnthropicProvider) ParseFileList(body []byte) ([]FileInfo, error) {
+	return parseFileObjects(body)
+}
+
+// UploadsDocuments returns true: Anthropic document blocks reference
+// uploaded files.
+func (p *AnthropicProvider) UploadsDocuments() bool {
+	return true
+}
+
+// FilesEndpoint returns the OpenAI Files API endpoint.
+func (p *OpenAIProvider) FilesEndpoint() string {
+	return "https://api.openai.com/v1/files"
+}
+
+// FileHeaders returns the standard OpenAI headers.
+func (p *OpenAIProvider) FileHeaders() map[string]string {
+	return p.Headers()
+}
+
+// MaxFileSize returns the 512 MB limit of the OpenAI Files API.
+func (p *OpenAIProvider) MaxFileSize() int64 {
+	return 512 << 20
+}
+
+// PrepareFileUpload builds a multipart upload for the OpenAI Files API.
+// The purpose defaults to "user_data".
+func (p *OpenAIProvider) PrepareFileUpload(content io.Reader, opts FileUploadOptions) ([]byte, string, error) {
+	purpose := opts.Purpose
+	if purpose == "" {
+		purpose = "user_data"
+	}
+	return prepareMultipartFile(content, opts.Filename, opts.MediaType, map[string]string{"purpose": purpose})
+}
+
+// ParseFileInfo extracts the description of an OpenAI file.
+func (p *OpenAIProvider) ParseFileInfo(body []byte) (*FileInfo, error) {
+	return parseFileObject(body)
+}
+
+// ParseFileList extracts the files of an OpenAI list response.
+func (p *OpenAIProvider) ParseFileList(body []byte) ([]FileInfo, error) {
+	return parseFileObjects(body)
+}
+
+// UploadsDocuments returns false: documents are sent inline as file data.
+func (p *OpenAIProvider) UploadsDocuments() bool {
+	return false
+}
+
+// FilesEndpoint returns the Mistral Files API endpoint.
+func (p *MistralProvider) FilesEndpoint() string {
+	return "https://api.mistral.ai/v1/files"
+}
+
+// FileHeaders returns the standard Mistral headers.
+func (p *MistralProvider) FileHeaders() map[string]string {
+	return p.Headers()
+}
+
+// MaxFileSize returns the 512 MB limit of the Mistral Files API.
+func (p *MistralProvider) MaxFileSize() int64 {
+	return 512 << 20
+}
+
+// PrepareFileUpload builds a multipart upload for the Mistral Files API.
+// The purpose defaults to "fine-tune".
+func (p *MistralProvider) PrepareFileUpload(content io.Reader, opts FileUploadOptions) ([]byte, string, error) {
+	purpose := opts.Purpose
+	if purpose == "" {
+		purpose = "fine-tune"
+	}
+	return prepareMultipartFile(content, opts.Filename, opts.MediaType, map[string]string{"purpose": purpose})
+}
+
+// ParseFileInfo extracts the description of a Mistral file.
+func (p *MistralProvider) ParseFileInfo(body []byte) (*FileInfo, error) {
+	return parseFileObject(body)
+}
+
+// ParseFileList extracts the files of a Mistral list response.
+func (p *MistralProvider) ParseFileList(body []byte) ([]FileInfo, error) {
+	return parseFileObjects(body)
+}
+
+// UploadsDocuments returns false: Mistral messages cannot reference files.
+func (p *MistralProvider) UploadsDocuments() bool {
+	return false
+}
@@ -0,0 +1,316 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FineTuneStatus is the state of a fine-tuning job.
+type FineTuneStatus string
+
+const (
+	FineTuneQueued    FineTuneStatus = "queued"    // Validating files or waiting to start
+	FineTuneRunning   FineTuneStatus = "running"   // Training, or being canceled
+	FineTuneSucceeded FineTuneStatus = "succeeded" // The fine-tuned model is ready
+	FineTuneFailed    FineTuneStatus = "failed"    // Validation or training failed
+	FineTuneCanceled  FineTuneStatus = "canceled"  // The job was canceled
+)
+
+// FineTuneRequest describes a fine-tuning job to create.
+type FineTuneRequest struct {
+	Model           string                 // Base model to fine-tune
+	TrainingFile    string                 // ID of the uploaded training data
+	ValidationFile  string                 // ID of the uploaded validation data, optional
+	Suffix          string                 // Added to the name of the fine-tuned model, optional
+	Hyperparameters map[string]interface{} // Provider-specific settings, e.g. "n_epochs"
+}
+
+// FineTuneInfo describes a fine-tuning job as reported by the provider.
+type FineTuneInfo struct {
+	ID             string
+	Model          string // Base model
+	FineTunedModel string // Name of the resulting model, once succeeded
+	Status         FineTuneStatus
+	TrainingFile   string
+	CreatedAt      time.Time
+	Error          string // Reason of a failure
+}
+
+// FineTuneEvent is a progress message of a fine-tuning job, such as a status
+// change or the metrics of a training step.
+type FineTuneEvent struct {
+	ID        string
+	CreatedAt time.Time
+	Level     string                 // "info", "warn" or "error", when reported
+	Message   string                 // Description of the event
+	Data      map[string]interface{} // Details, e.g. "step" and "train_loss"
+}
+
+// FineTuner is implemented by providers with a fine-tuning API. Like Batcher,
+// it is an optional capability discovered through a type assertion, and its
+// methods make their calls with the given client.
+type FineTuner interface {
+	// CreateFineTune starts a fine-tuning job.
+	CreateFineTune(ctx context.Context, client *http.Client, request FineTuneRequest) (*FineTuneInfo, error)
+
+	// GetFineTune returns the current state of a job.
+	GetFineTune(ctx context.Context, client *http.Client, id string) (*FineTuneInfo, error)
+
+	// CancelFineTune asks the provider to stop a job.
+	CancelFineTune(ctx context.Context, client *http.Client, id string) (*FineTuneInfo, error)
+
+	// ListFineTunes returns the most recent jobs.
+	ListFineTunes(ctx context.Context, client *http.Client) ([]FineTuneInfo, error)
+
+	// FineTuneEvents returns the events of a job, oldest first.
+	FineTuneEvents(ctx context.Context, client *http.Client, id string) ([]FineTuneEvent, error)
+}
+
+// fineTuneStatus normalizes the job states of OpenAI and Mistral.
+func fineTuneStatus(status string) FineTuneStatus {
+	switch strings.ToLower(status) {
+	case "succeeded", "success":
+		return FineTuneSucceeded
+	case "failed", "failed_validation":
+		return FineTuneFailed
+	case "cancelled", "canceled":
+		return FineTuneCanceled
+	case "running", "started", "cancellation_requested":
+		return FineTuneRunning
+	default:
+		return FineTuneQueued
+	}
+}
+
+// fineTuningJob is a fine-tuning job as returned by the OpenAI and Mistral
+// APIs, which differ in their training files and error fields.
+type fineTuningJob struct {
+	ID             string   `json:"id"`
+	Model          string   `json:"model"`
+	FineTunedModel *string  `json:"fine_tuned_model"`
+	Status         string   `json:"status"`
+	TrainingFile   string   `json:"training_file"`
+	TrainingFiles  []string `json:"training_files"`
+	CreatedAt      int64    `json:"created_at"`
+	Error          *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+	Events []struct {
+		Name      string                 `json:"name"`
+		Data      map[string]interface{} `json:"data"`
+		CreatedAt int64                  `json:"created_at"`
+	} `json:"events"`
+}
+
+func (j *fineTuningJob) info() *FineTuneInfo {
+	info := &FineTuneInfo{
+		ID:           j.ID,
+		Model:        j.Model,
+		Status:       fineTuneStatus(j.Status),
+		TrainingFile: j.TrainingFile,
+		CreatedAt:    time.Unix(j.CreatedAt, 0).UTC(),
+	}
+	if j.FineTunedModel != nil {
+		info.FineTunedModel = *j.FineTunedModel
+	}
+	if info.TrainingFile == "" && len(j.TrainingFiles) > 0 {
+		info.TrainingFile = j.TrainingFiles[0]
+	}
+	if j.Error != nil {
+		info.Error = j.Error.Message
+	}
+	return info
+}
+
+// fineTuningJobs is a list of jobs of the OpenAI and Mistral APIs.
+type fineTuningJobs struct {
+	Data []fineTuningJob `json:"data"`
+}
+
+func (l *fineTuningJobs) infos() []FineTuneInfo {
+	infos := make([]FineTuneInfo, len(l.Data))
+	for i := range l.Data {
+		infos[i] = *l.Data[i].info()
+	}
+	return infos
+}
+
+// FineTuningEndpoint returns the OpenAI fine-tuning jobs endpoint.
+func (p *OpenAIProvider) FineTuningEndpoint() string {
+	return "https://api.openai.com/v1/fine_tuning/jobs"
+}
+
+// CreateFineTune starts an OpenAI fine-tuning job.
+func (p *OpenAIProvider) CreateFineTune(ctx context.Context, client *http.Client, request FineTuneRequest) (*FineTuneInfo, error) {
+	body := map[string]interface{}{
+		"model":         request.Model,
+		"training_file": request.TrainingFile,
+	}
+	if request.ValidationFile != "" {
+		body["validation_file"] = request.ValidationFile
+	}
+	if request.Suffix != "" {
+		body["suffix"] = request.Suffix
+	}
+	if len(request.Hyperparameters) > 0 {
+		body["hyperparameters"] = request.Hyperparameters
+	}
+	return postFineTune(ctx, client, p.FineTuningEndpoint(), p.Headers(), body)
+}
+
+// GetFineTune returns the state of an OpenAI fine-tuning job.
+func (p *OpenAIProvider) GetFineTune(ctx context.Context, client *http.Client, id string) (*FineTuneInfo, error) {
+	var job fineTuningJob
+	if _, err := apiCall(ctx, client, http.MethodGet, p.FineTuningEndpoint()+"/"+id, p.Headers(), nil, &job); err != nil {
+		return nil, err
+	}
+	return job.info(), nil
+}
+
+// CancelFineTune cancels an OpenAI fine-tuning job.
+func (p *OpenAIProvider) CancelFineTune(ctx context.Context, client *http.Client, id string) (*FineTuneInfo, error) {
+	var job fineTuningJob
+	if _, err := apiCall(ctx, client, http.MethodPost, p.FineTuningEndpoint()+"/"+id+"/cancel", p.Headers(), []byte("{}"), &job); err != nil {
+		return nil, err
+	}
+	return job.info(), nil
+}
+
+// ListFineTunes returns the most recent OpenAI fine-tuning jobs.
+func (p *OpenAIProvider) ListFineTunes(ctx context.Context, client *http.Client) ([]FineTuneInfo, error) {
+	var jobs fineTuningJobs
+	if _, err := apiCall(ctx, client, http.MethodGet, p.FineTuningEndpoint(), p.Headers(), nil, &jobs); err != nil {
+		return nil, err
+	}
+	return jobs.infos(), nil
+}
+
+// FineTuneEvents returns the events of an OpenAI fine-tuning job, which the
+// API lists newest first.
+func (p *OpenAIProvider) FineTuneEvents(ctx context.Context, client *http.Client, id string) ([]FineTuneEvent, error) {
+	var list struct {
+		Data []struct {
+			ID        string                 `json:"id"`
+			CreatedAt int64                  `json:"created_at"`
+			Level     string                 `json:"level"`
+			Message   string                 `json:"message"`
+			Data      map[string]interface{} `json:"data"`
+		} `json:"data"`
+	}
+	if _, err := apiCall(ctx, client, http.MethodGet, p.FineTuningEndpoint()+"/"+id+"/events?limit=100", p.Headers(), nil, &list); err != nil {
+		return nil, err
+	}
+	events := make([]FineTuneEvent, len(list.Data))
+	for i, e := range list.Data {
+		events[len(events)-1-i] = FineTuneEvent{
+			ID:        e.ID,
+			CreatedAt: time.Unix(e.CreatedAt, 0).UTC(),
+			Level:     e.Level,
+			Message:   e.Message,
+			Data:      e.Data,
+		}
+	}
+	return events, nil
+}
+
+// FineTuningEndpoint returns the Mistral fine-tuning jobs endpoint.
+func (p *MistralProvider) FineTuningEndpoint() string {
+	return "https://api.mistral.ai/v1/fine_tuning/jobs"
+}
+
+// CreateFineTune starts a Mistral fine-tuning job, which starts as soon as
+// its data is validated.
+func (p *MistralProvider) CreateFineTune(ctx context.Context, client *http.Client, request FineTuneRequest) (*FineTuneInfo, error) {
+	body := map[string]interface{}{
+		"model":          request.Model,
+		"training_files": []map[string]interface{}{{"file_id": request.TrainingFile, "weight": 1}},
+		"auto_start":     true,
+	}
+	if request.ValidationFile != "" {
+		body["validation_files"] = []string{request.ValidationFile}
+	}
+	if request.Suffix != "" {
+		body["suffix"] = request.Suffix
+	}
+	if len(request.Hyperparameters) > 0 {
+		body["hyperparameters"] = request.Hyperparameters
+	}
+	return postFineTune(ctx, client, p.FineTuningEndpoint(), p.Headers(), body)
+}
+
+// GetFineTune returns the state of a Mistral fine-tuning job.
+func (p *MistralProvider) GetFineTune(ctx context.Context, client *http.Client, id string) (*FineTuneInfo, error) {
+	job, err := p.getFineTune(ctx, client, id)
+	if err != nil {
+		return nil, err
+	}
+	return job.info(), nil
+}
+
+func (p *MistralProvider) getFineTune(ctx context.Context, client *http.Client, id string) (*fineTuningJob, error) {
+	var job fineTuningJob
+	if _, err := apiCall(ctx, client, http.MethodGet, p.FineTuningEndpoint()+"/"+id, p.Headers(), nil, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// CancelFineTune cancels a Mistral fine-tuning job.
+func (p *MistralProvider) CancelFineTune(ctx context.Context, client *http.Client, id string) (*FineTuneInfo, error) {
+	var job fineTuningJob
+	if _, err := apiCall(ctx, client, http.MethodPost, p.FineTuningEndpoint()+"/"+id+"/cancel", p.Headers(), nil, &job); err != nil {
+		return nil, err
+	}
+	return job.info(), nil
+}
+
+// ListFineTunes returns the most recent Mistral fine-tuning jobs.
+func (p *MistralProvider) ListFineTunes(ctx context.Context, client *http.Client) ([]FineTuneInfo, error) {
+	var jobs fineTuningJobs
+	if _, err := apiCall(ctx, client, http.MethodGet, p.FineTuningEndpoint(), p.Headers(), nil, &jobs); err != nil {
+		return nil, err
+	}
+	return jobs.infos(), nil
+}
+
+// FineTuneEvents returns the events of a Mistral fine-tuning job, which are
+// part of the job. Events have no ID, so one is derived from their position.
+func (p *MistralProvider) FineTuneEvents(ctx context.Context, client *http.Client, id string) ([]FineTuneEvent, error) {
+	job, err := p.getFineTune(ctx, client, id)
+	if err != nil {
+		return nil, err
+	}
+	events := make([]FineTuneEvent, len(job.Events))
+	for i, e := range job.Events {
+		events[i] = FineTuneEvent{
+			ID:        id + "-" + strconv.Itoa(i),
+			CreatedAt: time.Unix(e.CreatedAt, 0).UTC(),
+			Message:   e.Name,
+			Data:      e.Data,
+		}
+	}
+	// Listed newest first, like OpenAI's
+	if len(events) > 1 && events[0].CreatedAt.After(events[len(events)-1].CreatedAt) {
+		for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 {
+			events[i], events[j] = events[j], events[i]
+		}
+	}
+	return events, nil
+}
+
+// postFineTune creates a job with the OpenAI-style jobs endpoint.
+func postFineTune(ctx context.Context, client *http.Client, endpoint string, headers map[string]string, body map[string]interface{}) (*FineTuneInfo, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	var job fineTuningJob
+	if _, err := apiCall(ctx, client, http.MethodPost, endpoint, headers, data, &job); err != nil {
+		return nil, err
+	}
+	return job.info(), nil
+}
@@ -160,6 +160,9 @@ func (p *GenericProvider) PrepareRequestWithSchema(prompt string, options map[st
 
 // ParseResponse extracts the generated text from the API response.
 func (p *GenericProvider) ParseResponse(body []byte) (string, error) {
+	if err := CheckResponseBody(body); err != nil {
+		return "", err
+	}
 	switch p.config.Type {
 	case TypeOpenAI:
 		return p.parseOpenAIResponse(body)
@@ -197,11 +200,24 @@ func (p *GenericProvider) SupportsJSONSchema() bool {
 // SetDefaultOptions configures provider-specific defaults from the global configuration.
 func (p *GenericProvider) SetDefaultOptions(config *config.Config) {
 	// Common options
-	p.SetOption("temperature", config.Temperature)
-	p.SetOption("max_tokens", config.MaxTokens)
+	setSamplingDefaults(p, config)
+
+	if config.Logprobs != nil && p.config.Type == TypeOpenAI {
+		p.SetOption("logprobs", true)
+		if *config.Logprobs > 0 {
+			p.SetOption("top_logprobs", *config.Logprobs)
+		}
+	}
 
-	if config.Seed != nil {
-		p.SetOption("seed", *config.Seed)
+	if config.ReasoningBudget != nil {
+		switch {
+		case !supportsReasoning(p.Name(), p.model):
+			p.logger.Warn("Reasoning budget ignored: the model does not reason", "model", p.model)
+		case p.config.Type == TypeOpenAI:
+			setReasoningEffort(p.options, *config.ReasoningBudget)
+		case p.config.Type == TypeAnthropic || p.config.Type == TypeClaude:
+			setThinking(p.options, *config.ReasoningBudget)
+		}
 	}
 
 	p.logger.Debug("Default options set", "temperature", config.Temperature, "max_tokens", config.MaxTokens)
@@ -234,6 +250,10 @@ func (p *GenericProvider) PrepareStreamRequest(prompt string, options map[string
 		streamOptions[k] = v
 	}
 	streamOptions["stream"] = true
+	if _, ok := streamOptions["stream_options"]; !ok && p.config.Type == TypeOpenAI {
+		// Report the usage in a last chunk, as non-streaming responses do
+		streamOptions["stream_options"] = map[string]interface{}{"include_usage": true}
+	}
 
 	return p.PrepareRequest(prompt, streamOptions)
 }
@@ -252,53 +272,44 @@ func (p *GenericProvider) ParseStreamResponse(chunk []byte) (string, error) {
 
 // OpenAI implementation methods
 func (p *GenericProvider) prepareOpenAIRequest(prompt string, options map[string]interface{}, schema interface{}) ([]byte, error) {
-	requestOptions := make(map[string]interface{})
-
-	// Copy default options
-	for k, v := range p.options {
-		requestOptions[k] = v
-	}
-
-	// Override with passed options
-	for k, v := range options {
-		requestOptions[k] = v
+	request, err := newChatRequest(p.model, prompt, options)
+	if err != nil {
+		return nil, err
 	}
 
-	// Set model
-	requestOptions["model"] = p.model
-
-	// Handle messages format
-	if _, ok := requestOptions["messages"]; !ok {
-		// Convert simple prompt to messages format
-		requestOptions["messages"] = []map[string]interface{}{
-			{
-				"role":    "user",
-				"content": prompt,
-			},
+	// Constrain decoding with a grammar generated from the schema when
+	// the server accepts one
+	if schema != nil && p.config.SupportsGrammar {
+		grammar, err := SchemaToGBNF(schema)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert schema to grammar: %w", err)
 		}
+		return marshalRequest(request, p.options, options, map[string]interface{}{"grammar": grammar})
 	}
 
 	// Handle JSON schema if provided
 	if schema != nil {
 		// Set response format for JSON
-		requestOptions["response_format"] = map[string]string{
+		request.ResponseFormat = map[string]string{
 			"type": "json_object",
 		}
 
 		// Add function calling for schema
-		requestOptions["functions"] = []map[string]interface{}{
-			{
-				"name":        "output_formatter",
-				"description": "Format the output according to the schema",
-				"parameters":  schema,
+		return marshalRequest(request, p.options, options, map[string]interface{}{
+			"functions": []map[string]interface{}{
+				{
+					"name":        "output_formatter",
+					"description": "Format the output according to the schema",
+					"parameters":  schema,
+				},
 			},
-		}
-		requestOptions["function_call"] = map[string]string{
-			"name": "output_formatter",
-		}
+			"function_call": map[string]string{
+				"name": "output_formatter",
+			},
+		})
 	}
 
-	return json.Marshal(requestOptions)
+	return marshalRequest(request, p.options, options)
 }
 
 func (p *GenericProvider) parseOpenAIResponse(body []byte) (string, error) {
@@ -333,6 +344,9 @@ func (p *GenericProvider) parseOpenAIResponse(body []byte) (string, error) {
 	if response.Choices[0].Message.FunctionCall.Arguments != "" {
 		return response.Choices[0].Message.FunctionCall.Arguments, nil
 	}
+	if response.Choices[0].Message.Content == "" && response.Choices[0].FinishReason == "content_filter" {
+		return "", contentFilterError(p.Name(), body)
+	}
 
 	return response.Choices[0].Message.Content, nil
 }
@@ -377,40 +391,16 @@ func (p *GenericProvider) parseOpenAIStreamResponse(chunk []byte) (string, error
 
 // Anthropic implementation methods
 func (p *GenericProvider) prepareAnthropicRequest(prompt string, options map[string]interface{}) ([]byte, error) {
-	requestOptions := make(map[string]interface{})
-
-	// Copy default options
-	for k, v := range p.options {
-		requestOptions[k] = v
-	}
-
-	// Override with passed options
-	for k, v := range options {
-		requestOptions[k] = v
-	}
-
-	// Set model
-	requestOptions["model"] = p.model
-
-	// Handle messages format
-	if _, ok := requestOptions["messages"]; !ok {
-		// Convert simple prompt to messages format
-		requestOptions["messages"] = []map[string]interface{}{
-			{
-				"role":    "user",
-				"content": prompt,
-			},
-		}
+	request := newAnthropicRequest(p.model, p.options, options)
+	if systemPrompt, ok := options["system_prompt"].(string); ok && systemPrompt != "" {
+		request.System = systemPrompt
 	}
-
-	// Set max tokens if needed
-	if maxTokens, ok := requestOptions["max_tokens"]; ok {
-		requestOptions["max_tokens"] = maxTokens
-	} else {
-		requestOptions["max_tokens"] = 1024 // Default
+	content, err := anthropicUserContent(prompt, options)
+	if err != nil {
+		return nil, err
 	}
-
-	return json.Marshal(requestOptions)
+	request.Messages = []anthropicMessage{{Role: "user", Content: content}}
+	return marshalRequest(request, p.options, options)
 }
 
 func (p *GenericProvider) prepareAnthropicStructuredRequest(prompt string, options map[string]interface{}, schema interface{}) ([]byte, error) {
@@ -517,71 +507,50 @@ func (p *GenericProvider) PrepareRequestWithMessages(messages []types.MemoryMess
 
 // prepareOpenAIRequestWithMessages creates a request for OpenAI APIs using structured messages
 func (p *GenericProvider) prepareOpenAIRequestWithMessages(messages []types.MemoryMessage, options map[string]interface{}) ([]byte, error) {
-	requestOptions := make(map[string]interface{})
-
-	// Copy default options
-	for k, v := range p.options {
-		requestOptions[k] = v
-	}
-
-	// Override with passed options
-	for k, v := range options {
-		requestOptions[k] = v
-	}
-
-	// Set model
-	requestOptions["model"] = p.model
-
-	// Handle messages
-	openAIMessages := []map[string]interface{}{}
+	var chat []chatMessage
 
 	// Add system message first if present
 	if systemPrompt, ok := options["system_prompt"].(string); ok && systemPrompt != "" {
-		openAIMessages = append(openAIMessages, map[string]interface{}{
-			"role":    "system",
-			"content": systemPrompt,
-		})
+		chat = append(chat, chatMessage{Role: "system", Content: systemPrompt})
 	}
 
 	// Add all other messages
 	for _, msg := range messages {
-		message := map[string]interface{}{
-			"role":    msg.Role,
-			"content": msg.Content,
-		}
-		openAIMessages = append(openAIMessages, message)
+		chat = append(chat, chatMessage{Role: msg.Role, Content: msg.Content})
+	}
+	if err := attachImages(chat, options); err != nil {
+		return nil, err
 	}
 
-	requestOptions["messages"] = openAIMessages
-
-	return json.Marshal(requestOptions)
+	request := chatRequest{
+		Model:      p.model,
+		Messages:   chat,
+		Tools:      options["tools"],
+		ToolChoice: openAIToolChoice(options["tool_choice"]),
+	}
+	return marshalRequest(request, p.options, options)
 }
 
 // prepareAnthropicRequestWithMessages creates a request for Anthropic APIs using structured messages
 func (p *GenericProvider) prepareAnthropicRequestWithMessages(messages []types.MemoryMessage, options map[string]interface{}) ([]byte, error) {
-	requestOptions := make(map[string]interface{})
-
-	// Copy default options
-	for k, v := range p.options {
-		requestOptions[k] = v
-	}
-
-	// Override with passed options
-	for k, v := range options {
-		requestOptions[k] = v
-	}
-
-	// Set model
-	requestOptions["model"] = p.model
+	request := newAnthropicRequest(p.model, p.options, options)
 
 	// Set system prompt if provided
 	if systemPrompt, ok := options["system_prompt"].(string); ok && systemPrompt != "" {
-		requestOptions["system"] = systemPrompt
+		request.System = systemPrompt
+	}
+
+	// Images and documents are attached to the most recent user message
+	attach := hasAttachments(options)
+	lastUser := -1
+	for i, msg := range messages {
+		if msg.Role == "user" {
+			lastUser = i
+		}
 	}
 
 	// Format messages for Anthropic
-	anthropicMessages := []map[string]interface{}{}
-	for _, msg := range messages {
+	for i, msg := range messages {
 		content := []map[string]interface{}{
 			{
 				"type": "text",
@@ -594,22 +563,16 @@ func (p *GenericProvider) prepareAnthropicRequestWithMessages(messages []types.M
 			content[0]["cache_control"] = map[string]string{"type": msg.CacheControl}
 		}
 
-		message := map[string]interface{}{
-			"role":    msg.Role,
-			"content": content,
+		if i == lastUser && attach {
+			attachments, err := anthropicAttachmentBlocks(options)
+			if err != nil {
+				return nil, err
+			}
+			content = append(attachments, content...)
 		}
 
-		anthropicMessages = append(anthropicMessages, message)
-	}
-
-	requestOptions["messages"] = anthropicMessages
-
-	// Set max tokens if needed
-	if maxTokens, ok := requestOptions["max_tokens"]; ok {
-		requestOptions["max_tokens"] = maxTokens
-	} else {
-		requestOptions["max_tokens"] = 1024 // Default
+		request.Messages = append(request.Messages, anthropicMessage{Role: msg.Role, Content: content})
 	}
 
-	return json.Marshal(requestOptions)
+	return marshalRequest(request, p.options, options)
 }
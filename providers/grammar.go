@@ -0,0 +1,262 @@
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Primitive GBNF rules shared by the grammars generated from JSON schemas.
+// Whitespace is bounded so a model cannot pad the output indefinitely.
+var gbnfPrimitives = map[string]string{
+	"ws":      `| " " | "\n" [ \t]{0,20}`,
+	"string":  `"\"" ( [^"\\\x7F\x00-\x1F] | "\\" ( ["\\/bfnrt] | "u" [0-9a-fA-F]{4} ) )* "\"" ws`,
+	"number":  `"-"? ( [0-9] | [1-9] [0-9]{0,15} ) ( "." [0-9]+ )? ( [eE] [-+]? [0-9]+ )? ws`,
+	"integer": `"-"? ( [0-9] | [1-9] [0-9]{0,15} ) ws`,
+	"boolean": `( "true" | "false" ) ws`,
+	"null":    `"null" ws`,
+	"value":   `object | array | string | number | boolean | null`,
+	"object":  `"{" ws ( string ":" ws value ( "," ws string ":" ws value )* )? "}" ws`,
+	"array":   `"[" ws ( value ( "," ws value )* )? "]" ws`,
+}
+
+// primitiveDependencies lists the rules each primitive rule refers to.
+var primitiveDependencies = map[string][]string{
+	"string":  {"ws"},
+	"number":  {"ws"},
+	"integer": {"ws"},
+	"boolean": {"ws"},
+	"null":    {"ws"},
+	"value":   {"object", "array", "string", "number", "boolean", "null"},
+	"object":  {"ws", "string", "value"},
+	"array":   {"ws", "value"},
+}
+
+var gbnfInvalidName = regexp.MustCompile(`[^a-zA-Z0-9-]+`)
+
+// SchemaToGBNF converts a JSON schema into a GBNF grammar, the format
+// llama.cpp uses to constrain decoding, so local models can only produce
+// conforming JSON. The schema may be a JSON string, JSON bytes, a map or any
+// value marshalling to a schema.
+//
+// Supported keywords are type (including type lists), properties, items,
+// enum, const, anyOf and oneOf. Objects are generated with every declared
+// property, in alphabetical order, which satisfies both required and optional
+// properties; objects without properties and schemas without a type accept
+// any JSON value. References ($ref) are not supported.
+//
+// Example:
+//
+//	grammar, err := providers.SchemaToGBNF(`{"type": "object", "properties": {"answer": {"type": "string"}}}`)
+func SchemaToGBNF(schema interface{}) (string, error) {
+	schemaMap, err := schemaAsMap(schema)
+	if err != nil {
+		return "", err
+	}
+	g := &gbnfGrammar{rules: make(map[string]string)}
+	root, err := g.visit(schemaMap, "root")
+	if err != nil {
+		return "", err
+	}
+	if root != "root" {
+		g.add("root", root)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "root ::= %s\n", g.rules["root"])
+	names := make([]string, 0, len(g.rules))
+	for name := range g.rules {
+		if name != "root" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(&b, "%s ::= %s\n", name, g.rules[name])
+	}
+	return b.String(), nil
+}
+
+// gbnfGrammar collects the rules of a grammar being generated.
+type gbnfGrammar struct {
+	rules map[string]string
+}
+
+func (g *gbnfGrammar) add(name, rule string) string {
+	g.rules[name] = rule
+	return name
+}
+
+// primitive adds a primitive rule and the rules it depends on.
+func (g *gbnfGrammar) primitive(name string) string {
+	if _, ok := g.rules[name]; !ok {
+		g.add(name, gbnfPrimitives[name])
+		for _, dep := range primitiveDependencies[name] {
+			g.primitive(dep)
+		}
+	}
+	return name
+}
+
+// visit returns the rule, or a reference to a rule, matching the schema.
+func (g *gbnfGrammar) visit(schema map[string]interface{}, name string) (string, error) {
+	if _, ok := schema["$ref"]; ok {
+		return "", fmt.Errorf("schema references are not supported: %v", schema["$ref"])
+	}
+	if value, ok := schema["const"]; ok {
+		literal, err := gbnfJSONLiteral(value)
+		if err != nil {
+			return "", err
+		}
+		g.primitive("ws")
+		return g.add(name, literal+" ws"), nil
+	}
+	if values, ok := schema["enum"].([]interface{}); ok {
+		literals := make([]string, len(values))
+		for i, v := range values {
+			literal, err := gbnfJSONLiteral(v)
+			if err != nil {
+				return "", err
+			}
+			literals[i] = literal
+		}
+		g.primitive("ws")
+		return g.add(name, "( "+strings.Join(literals, " | ")+" ) ws"), nil
+	}
+	for _, key := range []string{"anyOf", "oneOf"} {
+		if options, ok := schema[key].([]interface{}); ok {
+			return g.alternatives(options, name)
+		}
+	}
+
+	switch t := schema["type"].(type) {
+	case []interface{}:
+		options := make([]interface{}, len(t))
+		for i, typ := range t {
+			options[i] = map[string]interface{}{"type": typ}
+		}
+		// Keep the keywords of the schema for each type, e.g. the items of an array
+		for i := range options {
+			for k, v := range schema {
+				if k != "type" {
+					options[i].(map[string]interface{})[k] = v
+				}
+			}
+		}
+		return g.alternatives(options, name)
+	case string:
+		switch t {
+		case "object":
+			return g.object(schema, name)
+		case "array":
+			return g.array(schema, name)
+		case "string", "number", "integer", "boolean", "null":
+			return g.primitive(t), nil
+		default:
+			return "", fmt.Errorf("unsupported schema type: %s", t)
+		}
+	case nil:
+		if _, ok := schema["properties"]; ok {
+			return g.object(schema, name)
+		}
+		return g.primitive("value"), nil
+	default:
+		return "", fmt.Errorf("invalid schema type: %v", t)
+	}
+}
+
+func (g *gbnfGrammar) alternatives(options []interface{}, name string) (string, error) {
+	refs := make([]string, len(options))
+	for i, option := range options {
+		sub, ok := option.(map[string]interface{})
+		if !ok {
+			return "", fmt.Errorf("invalid schema alternative: %v", option)
+		}
+		ref, err := g.visit(sub, fmt.Sprintf("%s-%d", name, i))
+		if err != nil {
+			return "", err
+		}
+		refs[i] = ref
+	}
+	return g.add(name, strings.Join(refs, " | ")), nil
+}
+
+func (g *gbnfGrammar) object(schema map[string]interface{}, name string) (string, error) {
+	properties, _ := schema["properties"].(map[string]interface{})
+	if len(properties) == 0 {
+		return g.primitive("object"), nil
+	}
+	keys := make([]string, 0, len(properties))
+	for key := range properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	g.primitive("ws")
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		sub, ok := properties[key].(map[string]interface{})
+		if !ok {
+			return "", fmt.Errorf("invalid schema for property %q", key)
+		}
+		ref, err := g.visit(sub, name+"-"+gbnfInvalidName.ReplaceAllString(key, "-"))
+		if err != nil {
+			return "", err
+		}
+		literal, err := gbnfJSONLiteral(key)
+		if err != nil {
+			return "", err
+		}
+		parts[i] = fmt.Sprintf(`%s ws ":" ws %s`, literal, ref)
+	}
+	return g.add(name, `"{" ws `+strings.Join(parts, ` "," ws `)+` "}" ws`), nil
+}
+
+func (g *gbnfGrammar) array(schema map[string]interface{}, name string) (string, error) {
+	items, ok := schema["items"].(map[string]interface{})
+	if !ok {
+		return g.primitive("array"), nil
+	}
+	item, err := g.visit(items, name+"-item")
+	if err != nil {
+		return "", err
+	}
+	g.primitive("ws")
+	return g.add(name, fmt.Sprintf(`"[" ws ( %s ( "," ws %s )* )? "]" ws`, item, item)), nil
+}
+
+// gbnfJSONLiteral returns a GBNF literal matching the JSON encoding of v.
+func gbnfJSONLiteral(v interface{}) (string, error) {
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+	return `"` + replacer.Replace(string(encoded)) + `"`, nil
+}
+
+// schemaAsMap decodes a schema given in any of the forms accepted by
+// GenerateWithSchema.
+func schemaAsMap(schema interface{}) (map[string]interface{}, error) {
+	var data []byte
+	switch s := schema.(type) {
+	case map[string]interface{}:
+		return s, nil
+	case string:
+		data = []byte(s)
+	case []byte:
+		data = s
+	default:
+		var err error
+		if data, err = json.Marshal(schema); err != nil {
+			return nil, fmt.Errorf("failed to marshal schema: %w", err)
+		}
+	}
+	var schemaMap map[string]interface{}
+	if err := json.Unmarshal(data, &schemaMap); err != nil {
+		return nil, fmt.Errorf("failed to parse schema: %w", err)
+	}
+	return schemaMap, nil
+}
@@ -0,0 +1,69 @@
+package providers
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaToGBNF(t *testing.T) {
+	schema := `{
+		"type": "object",
+		"properties": {
+			"name": {"type": "string"},
+			"age": {"type": "integer"},
+			"mood": {"enum": ["happy", "sad"]},
+			"tags": {"type": "array", "items": {"type": "string"}},
+			"note": {"type": ["string", "null"]}
+		},
+		"required": ["name", "age"]
+	}`
+	grammar, err := SchemaToGBNF(schema)
+	require.NoError(t, err)
+
+	assert.Contains(t, grammar, `root ::= "{" ws "\"age\"" ws ":" ws integer "," ws "\"mood\"" ws ":" ws root-mood "," ws "\"name\"" ws ":" ws string "," ws "\"note\"" ws ":" ws root-note "," ws "\"tags\"" ws ":" ws root-tags "}" ws`)
+	assert.Contains(t, grammar, `root-mood ::= ( "\"happy\"" | "\"sad\"" ) ws`)
+	assert.Contains(t, grammar, `root-note ::= string | null`)
+	assert.Contains(t, grammar, `root-tags ::= "[" ws ( string ( "," ws string )* )? "]" ws`)
+	for _, rule := range []string{"ws", "string", "integer", "null"} {
+		assert.Contains(t, grammar, "\n"+rule+" ::= ")
+	}
+	assert.NotContains(t, grammar, "boolean ::=", "only rules in use are emitted")
+
+	grammar, err = SchemaToGBNF(map[string]interface{}{"type": "boolean"})
+	require.NoError(t, err)
+	assert.Contains(t, grammar, "root ::= boolean\n")
+
+	_, err = SchemaToGBNF(`{"$ref": "#/definitions/x"}`)
+	assert.Error(t, err)
+}
+
+func TestGrammarConstrainedRequests(t *testing.T) {
+	schema := map[string]interface{}{"type": "object", "properties": map[string]interface{}{"ok": map[string]interface{}{"type": "boolean"}}}
+
+	t.Run("LlamaCpp", func(t *testing.T) {
+		provider := NewLlamaCppProvider("", "local-model", nil)
+		require.True(t, provider.SupportsJSONSchema())
+		body, err := provider.PrepareRequestWithSchema("Is it ok?", nil, schema)
+		require.NoError(t, err)
+
+		var request map[string]interface{}
+		require.NoError(t, json.Unmarshal(body, &request))
+		assert.Contains(t, request["grammar"], `root ::= "{" ws "\"ok\"" ws ":" ws boolean "}" ws`)
+		assert.NotContains(t, request, "functions")
+	})
+
+	t.Run("Ollama", func(t *testing.T) {
+		provider := NewOllamaProvider("", "llama3", nil)
+		require.True(t, provider.SupportsJSONSchema())
+		body, err := provider.PrepareRequestWithSchema("Is it ok?", map[string]interface{}{"temperature": 0.1}, schema)
+		require.NoError(t, err)
+
+		var request map[string]interface{}
+		require.NoError(t, json.Unmarshal(body, &request))
+		assert.Equal(t, schema, request["format"])
+		assert.Equal(t, 0.1, request["temperature"])
+	})
+}
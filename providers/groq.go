@@ -74,11 +74,7 @@ func (p *GroqProvider) SetOption(key string, value interface{}) {
 // SetDefaultOptions configures standard options from the global configuration.
 // This includes temperature, max tokens, and sampling parameters.
 func (p *GroqProvider) SetDefaultOptions(config *config.Config) {
-	p.SetOption("temperature", config.Temperature)
-	p.SetOption("max_tokens", config.MaxTokens)
-	if config.Seed != nil {
-		p.SetOption("seed", *config.Seed)
-	}
+	setSamplingDefaults(p, config)
 }
 
 // SupportsJSONSchema indicates whether this provider supports JSON schema validation.
@@ -113,52 +109,31 @@ func (p *GroqProvider) Headers() map[string]string {
 //   - Serialized JSON request body
 //   - Any error encountered during preparation
 func (p *GroqProvider) PrepareRequest(prompt string, options map[string]interface{}) ([]byte, error) {
-	requestBody := map[string]interface{}{
-		"model": p.model,
-		"messages": []map[string]string{
-			{"role": "user", "content": prompt},
-		},
-	}
-
-	// First, add the default options
-	for k, v := range p.options {
-		requestBody[k] = v
-	}
-
-	// Then, add any additional options (which may override defaults)
-	for k, v := range options {
-		requestBody[k] = v
+	request, err := newChatRequest(p.model, prompt, options)
+	if err != nil {
+		return nil, err
 	}
-
-	return json.Marshal(requestBody)
+	return marshalRequest(request, p.options, options)
 }
 
 // PrepareRequestWithSchema creates a request with JSON schema validation.
 // Since Groq doesn't support schema validation natively, this falls back to
 // standard request preparation.
 func (p *GroqProvider) PrepareRequestWithSchema(prompt string, options map[string]interface{}, schema interface{}) ([]byte, error) {
-	requestBody := map[string]interface{}{
-		"model": p.model,
-		"messages": []map[string]string{
-			{"role": "user", "content": prompt},
-		},
-		"response_format": map[string]interface{}{
-			"type":   "json_schema",
-			"schema": schema,
-		},
+	responseFormat := map[string]interface{}{
+		"type":   "json_schema",
+		"schema": schema,
 	}
-
-	// Add any additional options
-	for k, v := range options {
-		requestBody[k] = v
-	}
-
-	// Add strict option if provided
 	if strict, ok := options["strict"].(bool); ok && strict {
-		requestBody["response_format"].(map[string]interface{})["strict"] = true
+		responseFormat["strict"] = true
 	}
 
-	return json.Marshal(requestBody)
+	request, err := newChatRequest(p.model, prompt, options)
+	if err != nil {
+		return nil, err
+	}
+	request.ResponseFormat = responseFormat
+	return marshalRequest(request, options)
 }
 
 // ParseResponse extracts the generated text from the Groq API response.
@@ -171,6 +146,9 @@ func (p *GroqProvider) PrepareRequestWithSchema(prompt string, options map[strin
 //   - Generated text content
 //   - Any error encountered during parsing
 func (p *GroqProvider) ParseResponse(body []byte) (string, error) {
+	if err := CheckResponseBody(body); err != nil {
+		return "", err
+	}
 	var response struct {
 		Choices []struct {
 			Message struct {
@@ -220,8 +198,12 @@ func (p *GroqProvider) SupportsStreaming() bool {
 
 // PrepareStreamRequest prepares a request body for streaming
 func (p *GroqProvider) PrepareStreamRequest(prompt string, options map[string]interface{}) ([]byte, error) {
-	options["stream"] = true
-	return p.PrepareRequest(prompt, options)
+	request, err := newChatRequest(p.model, prompt, options)
+	if err != nil {
+		return nil, err
+	}
+	request.Stream = true
+	return marshalRequest(request, p.options, options)
 }
 
 // ParseStreamResponse parses a single chunk from a streaming response
@@ -245,38 +227,24 @@ func (p *GroqProvider) ParseStreamResponse(chunk []byte) (string, error) {
 // PrepareRequestWithMessages creates a request body using structured message objects
 // rather than a flattened prompt string.
 func (p *GroqProvider) PrepareRequestWithMessages(messages []types.MemoryMessage, options map[string]interface{}) ([]byte, error) {
-	request := map[string]interface{}{
-		"model":    p.model,
-		"messages": []map[string]interface{}{},
-	}
+	var chat []chatMessage
 
 	// Add system prompt if present
 	if systemPrompt, ok := options["system_prompt"].(string); ok && systemPrompt != "" {
-		request["messages"] = append(request["messages"].([]map[string]interface{}), map[string]interface{}{
-			"role":    "system",
-			"content": systemPrompt,
-		})
+		chat = append(chat, chatMessage{Role: "system", Content: systemPrompt})
 	}
 
 	// Convert structured messages to Groq format (OpenAI compatible)
-	for _, msg := range messages {
-		request["messages"] = append(request["messages"].([]map[string]interface{}), map[string]interface{}{
-			"role":    msg.Role,
-			"content": msg.Content,
-		})
+	chat = append(chat, chatMessages(messages)...)
+	if err := attachImages(chat, options); err != nil {
+		return nil, err
 	}
 
-	// Add other options from provider and request
-	for k, v := range p.options {
-		if k != "messages" {
-			request[k] = v
-		}
+	request := chatRequest{
+		Model:      p.model,
+		Messages:   chat,
+		Tools:      options["tools"],
+		ToolChoice: openAIToolChoice(options["tool_choice"]),
 	}
-	for k, v := range options {
-		if k != "messages" && k != "system_prompt" && k != "structured_messages" {
-			request[k] = v
-		}
-	}
-
-	return json.Marshal(request)
+	return marshalRequest(request, p.options, options)
 }
@@ -0,0 +1,52 @@
+package providers
+
+// HealthChecker is implemented by providers with a cheap authenticated
+// endpoint, such as model listing, that tells whether the API is reachable
+// and the credentials are valid. Like Moderator, it is an optional capability
+// discovered through a type assertion; providers without it are checked with
+// a one-token generation instead.
+type HealthChecker interface {
+	// HealthEndpoint returns the URL of a GET request that succeeds when the
+	// provider is healthy.
+	HealthEndpoint() string
+}
+
+// HealthEndpoint returns the model listing endpoint.
+func (p *OpenAIProvider) HealthEndpoint() string {
+	return "https://api.openai.com/v1/models"
+}
+
+// HealthEndpoint returns the model listing endpoint.
+func (p *AnthropicProvider) HealthEndpoint() string {
+	return "https://api.anthropic.com/v1/models"
+}
+
+// HealthEndpoint returns the model listing endpoint.
+func (p *MistralProvider) HealthEndpoint() string {
+	return "https://api.mistral.ai/v1/models"
+}
+
+// HealthEndpoint returns the model listing endpoint.
+func (p *GroqProvider) HealthEndpoint() string {
+	return "https://api.groq.com/openai/v1/models"
+}
+
+// HealthEndpoint returns the model listing endpoint.
+func (p *DeepSeekProvider) HealthEndpoint() string {
+	return "https://api.deepseek.com/models"
+}
+
+// HealthEndpoint returns the model listing endpoint.
+func (p *CohereProvider) HealthEndpoint() string {
+	return "https://api.cohere.com/v1/models"
+}
+
+// HealthEndpoint returns the model listing endpoint.
+func (p *OpenRouterProvider) HealthEndpoint() string {
+	return "https://openrouter.ai/api/v1/models"
+}
+
+// HealthEndpoint returns the endpoint listing the local models.
+func (p *OllamaProvider) HealthEndpoint() string {
+	return p.endpoint + "/api/tags"
+}
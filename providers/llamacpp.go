@@ -0,0 +1,11 @@
+package providers
+
+// NewLlamaCppProvider creates a provider for a llama.cpp server
+// (llama-server), which exposes an OpenAI-compatible API on
+// http://localhost:8080 by default; use SetEndpoint for another address.
+// Structured output is constrained with a GBNF grammar generated from the
+// JSON schema, so the model can only produce conforming JSON. The API key is
+// only needed when the server runs with --api-key.
+func NewLlamaCppProvider(apiKey, model string, extraHeaders map[string]string) Provider {
+	return NewGenericProvider(apiKey, model, "llamacpp", extraHeaders)
+}
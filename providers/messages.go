@@ -0,0 +1,32 @@
+package providers
+
+// Conversational is implemented by providers whose APIs take a conversation
+// as separate messages. Like ChoicesParser, it is an optional capability
+// discovered through a type assertion: other providers, such as Ollama's
+// generate API, flatten messages into a single text, so content meant as
+// prior turns (e.g. few-shot examples) is better sent inline.
+type Conversational interface {
+	// SupportsMessages reports whether messages are sent as separate turns.
+	SupportsMessages() bool
+}
+
+// SupportsMessages returns true: OpenAI takes chat messages.
+func (p *OpenAIProvider) SupportsMessages() bool { return true }
+
+// SupportsMessages returns true: Anthropic takes user and assistant messages.
+func (p *AnthropicProvider) SupportsMessages() bool { return true }
+
+// SupportsMessages returns true: Groq takes chat messages.
+func (p *GroqProvider) SupportsMessages() bool { return true }
+
+// SupportsMessages returns true: Mistral takes chat messages.
+func (p *MistralProvider) SupportsMessages() bool { return true }
+
+// SupportsMessages returns true: Cohere takes a chat history.
+func (p *CohereProvider) SupportsMessages() bool { return true }
+
+// SupportsMessages returns true: OpenRouter takes chat messages.
+func (p *OpenRouterProvider) SupportsMessages() bool { return true }
+
+// SupportsMessages returns true: the mock records messages as given.
+func (p *MockProvider) SupportsMessages() bool { return true }
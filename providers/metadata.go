@@ -0,0 +1,79 @@
+package providers
+
+import "sort"
+
+// MetadataUserKey is the request metadata key holding the end-user ID, sent
+// to the providers that use it for abuse monitoring.
+const MetadataUserKey = "user_id"
+
+// MetadataMapper is implemented by providers whose API accepts request
+// metadata, such as the end user or tags shown in their dashboard. Like
+// ChoicesParser, it is an optional capability discovered through a type
+// assertion; other providers only see metadata on the client side, in logs
+// and cost tracking.
+type MetadataMapper interface {
+	// MetadataOptions returns the request options carrying the metadata.
+	MetadataOptions(metadata map[string]string) map[string]interface{}
+}
+
+// openAIMetadataLimits are the maximum number of metadata pairs and length
+// of values accepted by OpenAI.
+const (
+	openAIMetadataPairs  = 16
+	openAIMetadataLength = 512
+)
+
+// MetadataOptions sends the user ID as "user" and the metadata as
+// "metadata", keeping the first 16 keys in sorted order.
+func (p *OpenAIProvider) MetadataOptions(metadata map[string]string) map[string]interface{} {
+	options := make(map[string]interface{})
+	if user := metadata[MetadataUserKey]; user != "" {
+		options["user"] = user
+	}
+	keys := make([]string, 0, len(metadata))
+	for k := range metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	if len(keys) > openAIMetadataPairs {
+		keys = keys[:openAIMetadataPairs]
+	}
+	tags := make(map[string]string, len(keys))
+	for _, k := range keys {
+		v := metadata[k]
+		if len(v) > openAIMetadataLength {
+			v = v[:openAIMetadataLength]
+		}
+		tags[k] = v
+	}
+	if len(tags) > 0 {
+		options["metadata"] = tags
+	}
+	return options
+}
+
+// MetadataOptions sends the user ID as metadata.user_id, the only metadata
+// Anthropic accepts.
+func (p *AnthropicProvider) MetadataOptions(metadata map[string]string) map[string]interface{} {
+	if user := metadata[MetadataUserKey]; user != "" {
+		return map[string]interface{}{"metadata": map[string]string{"user_id": user}}
+	}
+	return nil
+}
+
+// MetadataOptions sends the user ID as "user".
+func (p *OpenRouterProvider) MetadataOptions(metadata map[string]string) map[string]interface{} {
+	if user := metadata[MetadataUserKey]; user != "" {
+		return map[string]interface{}{"user": user}
+	}
+	return nil
+}
+
+// MetadataOptions sends the user ID as "user" to OpenAI-compatible APIs,
+// which commonly accept it, and nothing to the others.
+func (p *GenericProvider) MetadataOptions(metadata map[string]string) map[string]interface{} {
+	if user := metadata[MetadataUserKey]; user != "" && p.config.Type == TypeOpenAI {
+		return map[string]interface{}{"user": user}
+	}
+	return nil
+}
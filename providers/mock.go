@@ -0,0 +1,466 @@
+// Package providers implements LLM provider interfaces and implementations.
+package providers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/teilomillet/gollm/config"
+	"github.com/teilomillet/gollm/types"
+	"github.com/teilomillet/gollm/utils"
+)
+
+// InProcessProvider is implemented by providers that answer requests in
+// process instead of over the network. Requests to such providers are sent
+// through the returned transport.
+type InProcessProvider interface {
+	Transport() http.RoundTripper
+}
+
+// MockCall records a request received by a MockProvider.
+// Option values have been through JSON, so numbers are float64.
+type MockCall struct {
+	Prompt   string                 `json:"prompt,omitempty"`
+	Messages []types.MemoryMessage  `json:"messages,omitempty"`
+	Options  map[string]interface{} `json:"options,omitempty"`
+	Schema   interface{}            `json:"schema,omitempty"`
+	Stream   bool                   `json:"stream,omitempty"`
+}
+
+// MockToolCall is a scripted tool call returned by a MockProvider.
+type MockToolCall struct {
+	Name      string      `json:"name"`
+	Arguments interface{} `json:"arguments"`
+}
+
+// MockResponder computes the response to a call dynamically.
+// Returning an error makes the request fail with a server error.
+type MockResponder func(call MockCall) (string, error)
+
+// mockStep is a scripted reply, consumed in order.
+type mockStep struct {
+	text       string
+	toolCalls  []MockToolCall
+	statusCode int
+	message    string
+}
+
+// mockResponse is the wire format between the mock transport and ParseResponse.
+type mockResponse struct {
+	Content      string         `json:"content,omitempty"`
+	ToolCalls    []MockToolCall `json:"tool_calls,omitempty"`
+	FinishReason string         `json:"finish_reason,omitempty"`
+	Error        string         `json:"error,omitempty"`
+	Usage        *mockUsage     `json:"usage,omitempty"`
+}
+
+// mockUsage reports token usage, approximated as whitespace-separated words.
+type mockUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+// MockProvider is a Provider for unit tests that answers requests in process,
+// without HTTP or API keys. Replies are scripted in order with QueueResponse,
+// QueueToolCall and QueueError; once the script is exhausted the responder or
+// default response is used. Every request is recorded for assertions.
+//
+// It is registered as "mock", and the instance used by an LLM is returned by
+// gollm.GetMockProvider:
+//
+//	llm, _ := gollm.NewLLM(gollm.SetProvider("mock"), gollm.SetMaxRetries(0))
+//	mock, _ := gollm.GetMockProvider(llm)
+//	mock.QueueResponse("Paris")
+//	answer, _ := llm.Generate(ctx, gollm.NewPrompt("Capital of France?"))
+//	// answer == "Paris", mock.Calls()[0].Prompt contains "Capital of France?"
+type MockProvider struct {
+	mu              sync.Mutex
+	model           string
+	extraHeaders    map[string]string
+	options         map[string]interface{}
+	logger          utils.Logger
+	script          []mockStep
+	calls           []MockCall
+	latency         time.Duration
+	defaultResponse string
+	responder       MockResponder
+}
+
+// NewMockProvider creates a new mock provider. The API key is ignored.
+func NewMockProvider(apiKey, model string, extraHeaders map[string]string) Provider {
+	if extraHeaders == nil {
+		extraHeaders = make(map[string]string)
+	}
+	return &MockProvider{
+		model:           model,
+		extraHeaders:    extraHeaders,
+		options:         make(map[string]interface{}),
+		logger:          utils.NewLogger(utils.LogLevelInfo),
+		defaultResponse: "mock response",
+	}
+}
+
+// QueueResponse scripts text replies, returned in order by upcoming calls.
+func (p *MockProvider) QueueResponse(texts ...string) *MockProvider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, text := range texts {
+		p.script = append(p.script, mockStep{text: text})
+	}
+	return p
+}
+
+// QueueToolCall scripts a reply consisting of a single tool call.
+func (p *MockProvider) QueueToolCall(name string, arguments interface{}) *MockProvider {
+	return p.QueueToolCalls(MockToolCall{Name: name, Arguments: arguments})
+}
+
+// QueueToolCalls scripts a reply consisting of several parallel tool calls.
+func (p *MockProvider) QueueToolCalls(calls ...MockToolCall) *MockProvider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.script = append(p.script, mockStep{toolCalls: calls})
+	return p
+}
+
+// QueueError scripts a failed reply with the given HTTP status code,
+// e.g. http.StatusTooManyRequests to exercise retry handling.
+func (p *MockProvider) QueueError(statusCode int, message string) *MockProvider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.script = append(p.script, mockStep{statusCode: statusCode, message: message})
+	return p
+}
+
+// SetLatency delays every reply. Cancelling the request context interrupts the delay.
+func (p *MockProvider) SetLatency(latency time.Duration) *MockProvider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.latency = latency
+	return p
+}
+
+// SetDefaultResponse sets the reply used when the script is exhausted and no
+// responder is set. Defaults to "mock response".
+func (p *MockProvider) SetDefaultResponse(text string) *MockProvider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.defaultResponse = text
+	return p
+}
+
+// SetResponder computes replies once the script is exhausted.
+func (p *MockProvider) SetResponder(responder MockResponder) *MockProvider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.responder = responder
+	return p
+}
+
+// Calls returns the requests received so far, in order.
+func (p *MockProvider) Calls() []MockCall {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	calls := make([]MockCall, len(p.calls))
+	copy(calls, p.calls)
+	return calls
+}
+
+// CallCount returns the number of requests received so far.
+func (p *MockProvider) CallCount() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.calls)
+}
+
+// LastCall returns the most recent request, if any.
+func (p *MockProvider) LastCall() (MockCall, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.calls) == 0 {
+		return MockCall{}, false
+	}
+	return p.calls[len(p.calls)-1], true
+}
+
+// Pending returns the number of scripted replies not yet consumed.
+func (p *MockProvider) Pending() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.script)
+}
+
+// Reset clears the script, the recorded calls, the latency and the responder.
+func (p *MockProvider) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.script = nil
+	p.calls = nil
+	p.latency = 0
+	p.responder = nil
+	p.defaultResponse = "mock response"
+}
+
+// Name returns "mock" as the provider identifier.
+func (p *MockProvider) Name() string {
+	return "mock"
+}
+
+// Endpoint returns a placeholder URL; requests never leave the process.
+func (p *MockProvider) Endpoint() string {
+	return "http://mock.gollm.local/v1/generate"
+}
+
+// Headers returns the HTTP headers of mock requests.
+func (p *MockProvider) Headers() map[string]string {
+	headers := map[string]string{"Content-Type": "application/json"}
+	for key, value := range p.extraHeaders {
+		headers[key] = value
+	}
+	return headers
+}
+
+// PrepareRequest encodes the prompt and options for the mock transport.
+func (p *MockProvider) PrepareRequest(prompt string, options map[string]interface{}) ([]byte, error) {
+	return p.encode(MockCall{Prompt: prompt}, options)
+}
+
+// PrepareRequestWithSchema encodes the prompt, options and schema for the mock transport.
+func (p *MockProvider) PrepareRequestWithSchema(prompt string, options map[string]interface{}, schema interface{}) ([]byte, error) {
+	return p.encode(MockCall{Prompt: prompt, Schema: schema}, options)
+}
+
+// PrepareRequestWithMessages encodes structured messages and options for the
+// mock transport, streaming the reply when the stream option is set.
+func (p *MockProvider) PrepareRequestWithMessages(messages []types.MemoryMessage, options map[string]interface{}) ([]byte, error) {
+	stream, _ := options["stream"].(bool)
+	return p.encode(MockCall{Messages: messages, Stream: stream}, options)
+}
+
+// PrepareStreamRequest encodes a streaming request for the mock transport.
+func (p *MockProvider) PrepareStreamRequest(prompt string, options map[string]interface{}) ([]byte, error) {
+	return p.encode(MockCall{Prompt: prompt, Stream: true}, options)
+}
+
+// encode merges default and request options into the call and serializes it.
+func (p *MockProvider) encode(call MockCall, options map[string]interface{}) ([]byte, error) {
+	p.mu.Lock()
+	call.Options = make(map[string]interface{}, len(p.options)+len(options)+1)
+	for k, v := range p.options {
+		call.Options[k] = v
+	}
+	p.mu.Unlock()
+	for k, v := range options {
+		call.Options[k] = v
+	}
+	if p.model != "" {
+		call.Options["model"] = p.model
+	}
+	return json.Marshal(call)
+}
+
+// ParseResponse extracts the reply text, formatting tool calls the same way
+// as the OpenAI provider.
+func (p *MockProvider) ParseResponse(body []byte) (string, error) {
+	if err := CheckResponseBody(body); err != nil {
+		return "", err
+	}
+	var response mockResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return "", fmt.Errorf("error parsing response: %w", err)
+	}
+	if response.Error != "" {
+		return "", fmt.Errorf("%s", response.Error)
+	}
+	if len(response.ToolCalls) > 0 {
+		var functionCalls []string
+		for _, call := range response.ToolCalls {
+			functionCall, err := utils.FormatFunctionCall(call.Name, call.Arguments)
+			if err != nil {
+				return "", fmt.Errorf("error formatting function call: %w", err)
+			}
+			functionCalls = append(functionCalls, functionCall)
+		}
+		return strings.Join(functionCalls, "\n"), nil
+	}
+	return response.Content, nil
+}
+
+// ParseStreamResponse extracts the token from a mock stream event.
+func (p *MockProvider) ParseStreamResponse(chunk []byte) (string, error) {
+	chunk = bytes.TrimSpace(chunk)
+	if len(chunk) == 0 {
+		return "", fmt.Errorf("skip token")
+	}
+	if bytes.Equal(chunk, []byte("[DONE]")) {
+		return "", io.EOF
+	}
+	var event struct {
+		Token string `json:"token"`
+	}
+	if err := json.Unmarshal(chunk, &event); err != nil {
+		return "", err
+	}
+	if event.Token == "" {
+		return "", fmt.Errorf("skip token") // The final usage event
+	}
+	return event.Token, nil
+}
+
+// SetExtraHeaders configures additional HTTP headers for mock requests.
+func (p *MockProvider) SetExtraHeaders(extraHeaders map[string]string) {
+	p.extraHeaders = extraHeaders
+}
+
+// HandleFunctionCalls returns the function calls found in the response, if any.
+func (p *MockProvider) HandleFunctionCalls(body []byte) ([]byte, error) {
+	functionCalls, err := utils.ExtractFunctionCalls(string(body))
+	if err != nil {
+		return nil, fmt.Errorf("error extracting function calls: %w", err)
+	}
+	if len(functionCalls) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(functionCalls)
+}
+
+// SupportsJSONSchema returns true so that schema requests are recorded with their schema.
+func (p *MockProvider) SupportsJSONSchema() bool {
+	return true
+}
+
+// SetDefaultOptions records the generation parameters from the configuration,
+// so tests can assert on them in MockCall.Options.
+func (p *MockProvider) SetDefaultOptions(config *config.Config) {
+	setSamplingDefaults(p, config)
+}
+
+// SetOption sets a default option included in every recorded call.
+func (p *MockProvider) SetOption(key string, value interface{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.options[key] = value
+}
+
+// SetLogger configures the logger for the mock provider.
+func (p *MockProvider) SetLogger(logger utils.Logger) {
+	p.logger = logger
+}
+
+// SupportsStreaming returns true; scripted replies are streamed word by word.
+func (p *MockProvider) SupportsStreaming() bool {
+	return true
+}
+
+// Transport returns the in-process transport that answers mock requests.
+func (p *MockProvider) Transport() http.RoundTripper {
+	return mockTransport{p}
+}
+
+// next records a call and returns its scripted or computed reply.
+func (p *MockProvider) next(call MockCall) (mockStep, time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls = append(p.calls, call)
+	if len(p.script) > 0 {
+		step := p.script[0]
+		p.script = p.script[1:]
+		return step, p.latency
+	}
+	if p.responder != nil {
+		responder := p.responder
+		latency := p.latency
+		p.mu.Unlock()
+		text, err := responder(call)
+		p.mu.Lock()
+		if err != nil {
+			return mockStep{statusCode: http.StatusInternalServerError, message: err.Error()}, latency
+		}
+		return mockStep{text: text}, latency
+	}
+	return mockStep{text: p.defaultResponse}, p.latency
+}
+
+// mockTransport serves MockProvider requests without network access.
+type mockTransport struct {
+	provider *MockProvider
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t mockTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var call MockCall
+	if req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(body, &call); err != nil {
+			return nil, fmt.Errorf("invalid mock request: %w", err)
+		}
+	}
+
+	step, latency := t.provider.next(call)
+	if latency > 0 {
+		timer := time.NewTimer(latency)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+
+	input := len(strings.Fields(call.Prompt))
+	for _, message := range call.Messages {
+		input += len(strings.Fields(message.Content))
+	}
+	var body []byte
+	status := http.StatusOK
+	contentType := "application/json"
+	switch {
+	case step.statusCode != 0:
+		status = step.statusCode
+		body, _ = json.Marshal(mockResponse{Error: step.message})
+	case call.Stream:
+		contentType = "text/event-stream"
+		var buf bytes.Buffer
+		for _, token := range strings.SplitAfter(step.text, " ") {
+			if token == "" {
+				continue
+			}
+			data, _ := json.Marshal(map[string]string{"token": token})
+			fmt.Fprintf(&buf, "data: %s\n\n", data)
+		}
+		usage := &mockUsage{InputTokens: input, OutputTokens: len(strings.Fields(step.text))}
+		data, _ := json.Marshal(map[string]interface{}{"model": t.provider.model, "finish_reason": "stop", "usage": usage})
+		fmt.Fprintf(&buf, "data: %s\n\n", data)
+		buf.WriteString("data: [DONE]\n\n")
+		body = buf.Bytes()
+	default:
+		usage := &mockUsage{InputTokens: input, OutputTokens: len(strings.Fields(step.text))}
+		finishReason := "stop"
+		if len(step.toolCalls) > 0 {
+			finishReason = "tool_calls"
+		}
+		body, _ = json.Marshal(mockResponse{Content: step.text, ToolCalls: step.toolCalls, FinishReason: finishReason, Usage: usage})
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{contentType}},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
@@ -0,0 +1,268 @@
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// ModelLister is implemented by providers with an API listing the models
+// available to the account. Like HealthChecker, it is an optional capability
+// discovered through a type assertion.
+type ModelLister interface {
+	// ModelsEndpoint returns the URL of the GET request listing the models.
+	ModelsEndpoint() string
+
+	// ParseModels reads the listing into catalog entries. Prices, context
+	// windows, capabilities and modalities are only set when the listing
+	// reports them.
+	ParseModels(body []byte) ([]ModelInfo, error)
+}
+
+// parseOpenAIModels reads a listing of the OpenAI format, which only has the
+// model IDs. Groq adds the context window and whether the model is active.
+func parseOpenAIModels(provider string, body []byte) ([]ModelInfo, error) {
+	var list struct {
+		Data []struct {
+			ID            string `json:"id"`
+			ContextWindow int    `json:"context_window"`
+			Active        *bool  `json:"active"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, fmt.Errorf("error parsing model list: %w", err)
+	}
+	models := make([]ModelInfo, len(list.Data))
+	for i, m := range list.Data {
+		models[i] = ModelInfo{Provider: provider, Model: m.ID, ContextWindow: m.ContextWindow}
+		models[i].Deprecated = m.Active != nil && !*m.Active
+	}
+	return models, nil
+}
+
+// ModelsEndpoint returns the OpenAI model listing endpoint.
+func (p *OpenAIProvider) ModelsEndpoint() string {
+	return "https://api.openai.com/v1/models"
+}
+
+// ParseModels reads the OpenAI model IDs.
+func (p *OpenAIProvider) ParseModels(body []byte) ([]ModelInfo, error) {
+	return parseOpenAIModels(p.Name(), body)
+}
+
+// ModelsEndpoint returns the DeepSeek model listing endpoint.
+func (p *DeepSeekProvider) ModelsEndpoint() string {
+	return "https://api.deepseek.com/models"
+}
+
+// ParseModels reads the DeepSeek model IDs.
+func (p *DeepSeekProvider) ParseModels(body []byte) ([]ModelInfo, error) {
+	return parseOpenAIModels(p.Name(), body)
+}
+
+// ModelsEndpoint returns the Groq model listing endpoint.
+func (p *GroqProvider) ModelsEndpoint() string {
+	return "https://api.groq.com/openai/v1/models"
+}
+
+// ParseModels reads the Groq models and their context windows.
+func (p *GroqProvider) ParseModels(body []byte) ([]ModelInfo, error) {
+	return parseOpenAIModels(p.Name(), body)
+}
+
+// ModelsEndpoint returns the Anthropic model listing endpoint, with the
+// largest page so that every model is listed.
+func (p *AnthropicProvider) ModelsEndpoint() string {
+	return "https://api.anthropic.com/v1/models?limit=1000"
+}
+
+// ParseModels reads the Anthropic model IDs.
+func (p *AnthropicProvider) ParseModels(body []byte) ([]ModelInfo, error) {
+	var list struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, fmt.Errorf("error parsing model list: %w", err)
+	}
+	models := make([]ModelInfo, len(list.Data))
+	for i, m := range list.Data {
+		models[i] = ModelInfo{Provider: p.Name(), Model: m.ID}
+	}
+	return models, nil
+}
+
+// ModelsEndpoint returns the Mistral model listing endpoint.
+func (p *MistralProvider) ModelsEndpoint() string {
+	return "https://api.mistral.ai/v1/models"
+}
+
+// ParseModels reads the Mistral models with their context windows,
+// capabilities and deprecation dates.
+func (p *MistralProvider) ParseModels(body []byte) ([]ModelInfo, error) {
+	var list struct {
+		Data []struct {
+			ID               string `json:"id"`
+			MaxContextLength int    `json:"max_context_length"`
+			Deprecation      string `json:"deprecation"`
+			Capabilities     struct {
+				CompletionChat  bool `json:"completion_chat"`
+				FunctionCalling bool `json:"function_calling"`
+				Vision          bool `json:"vision"`
+			} `json:"capabilities"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, fmt.Errorf("error parsing model list: %w", err)
+	}
+	models := make([]ModelInfo, len(list.Data))
+	for i, m := range list.Data {
+		info := ModelInfo{Provider: p.Name(), Model: m.ID, ContextWindow: m.MaxContextLength, Deprecated: m.Deprecation != "", Deprecation: m.Deprecation}
+		if m.Capabilities.CompletionChat {
+			info.Capabilities = append(info.Capabilities, CapabilityStreaming)
+			info.Modalities = []string{"text"}
+		}
+		if m.Capabilities.FunctionCalling {
+			info.Capabilities = append(info.Capabilities, CapabilityTools)
+		}
+		if m.Capabilities.Vision {
+			info.Capabilities = append(info.Capabilities, CapabilityVision)
+			info.Modalities = append(info.Modalities, "image")
+		}
+		models[i] = info
+	}
+	return models, nil
+}
+
+// ModelsEndpoint returns the Cohere model listing endpoint, with the largest
+// page so that every model is listed.
+func (p *CohereProvider) ModelsEndpoint() string {
+	return "https://api.cohere.com/v1/models?page_size=1000"
+}
+
+// ParseModels reads the Cohere models with their context windows and
+// capabilities.
+func (p *CohereProvider) ParseModels(body []byte) ([]ModelInfo, error) {
+	var list struct {
+		Models []struct {
+			Name           string   `json:"name"`
+			Endpoints      []string `json:"endpoints"`
+			ContextLength  float64  `json:"context_length"`
+			Features       []string `json:"features"`
+			SupportsVision bool     `json:"supports_vision"`
+			IsDeprecated   bool     `json:"is_deprecated"`
+		} `json:"models"`
+	}
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, fmt.Errorf("error parsing model list: %w", err)
+	}
+	models := make([]ModelInfo, len(list.Models))
+	for i, m := range list.Models {
+		info := ModelInfo{Provider: p.Name(), Model: m.Name, ContextWindow: int(m.ContextLength), Deprecated: m.IsDeprecated}
+		for _, endpoint := range m.Endpoints {
+			if endpoint == "chat" {
+				info.Capabilities = append(info.Capabilities, CapabilityStreaming)
+				info.Modalities = []string{"text"}
+			}
+		}
+		for _, feature := range m.Features {
+			switch feature {
+			case "tools":
+				info.Capabilities = append(info.Capabilities, CapabilityTools)
+			case "json_schema":
+				info.Capabilities = append(info.Capabilities, CapabilityJSONSchema)
+			}
+		}
+		if m.SupportsVision {
+			info.Capabilities = append(info.Capabilities, CapabilityVision)
+			info.Modalities = append(info.Modalities, "image")
+		}
+		models[i] = info
+	}
+	return models, nil
+}
+
+// ModelsEndpoint returns the OpenRouter model listing endpoint.
+func (p *OpenRouterProvider) ModelsEndpoint() string {
+	return "https://openrouter.ai/api/v1/models"
+}
+
+// ParseModels reads the OpenRouter models with their prices, context
+// windows, capabilities and input modalities.
+func (p *OpenRouterProvider) ParseModels(body []byte) ([]ModelInfo, error) {
+	var list struct {
+		Data []struct {
+			ID            string `json:"id"`
+			ContextLength int    `json:"context_length"`
+			Architecture  struct {
+				InputModalities []string `json:"input_modalities"`
+			} `json:"architecture"`
+			Pricing struct {
+				Prompt     string `json:"prompt"`
+				Completion string `json:"completion"`
+			} `json:"pricing"`
+			SupportedParameters []string `json:"supported_parameters"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, fmt.Errorf("error parsing model list: %w", err)
+	}
+	models := make([]ModelInfo, len(list.Data))
+	for i, m := range list.Data {
+		info := ModelInfo{
+			Provider:      p.Name(),
+			Model:         m.ID,
+			ContextWindow: m.ContextLength,
+			Modalities:    m.Architecture.InputModalities,
+			Capabilities:  []Capability{CapabilityStreaming},
+		}
+		// Prices are in USD per token
+		if price, err := strconv.ParseFloat(m.Pricing.Prompt, 64); err == nil {
+			info.InputPerMillion = price * 1e6
+		}
+		if price, err := strconv.ParseFloat(m.Pricing.Completion, 64); err == nil {
+			info.OutputPerMillion = price * 1e6
+		}
+		for _, parameter := range m.SupportedParameters {
+			switch parameter {
+			case "tools":
+				info.Capabilities = append(info.Capabilities, CapabilityTools)
+			case "structured_outputs":
+				info.Capabilities = append(info.Capabilities, CapabilityJSONSchema)
+			}
+		}
+		for _, modality := range m.Architecture.InputModalities {
+			switch modality {
+			case "image":
+				info.Capabilities = append(info.Capabilities, CapabilityVision)
+			case "file":
+				info.Capabilities = append(info.Capabilities, CapabilityDocuments)
+			}
+		}
+		models[i] = info
+	}
+	return models, nil
+}
+
+// ModelsEndpoint returns the endpoint listing the local models.
+func (p *OllamaProvider) ModelsEndpoint() string {
+	return p.endpoint + "/api/tags"
+}
+
+// ParseModels reads the names of the local models.
+func (p *OllamaProvider) ParseModels(body []byte) ([]ModelInfo, error) {
+	var list struct {
+		Models []struct {
+			Name string `json:"name"`
+		} `json:"models"`
+	}
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, fmt.Errorf("error parsing model list: %w", err)
+	}
+	models := make([]ModelInfo, len(list.Models))
+	for i, m := range list.Models {
+		models[i] = ModelInfo{Provider: p.Name(), Model: m.Name}
+	}
+	return models, nil
+}
@@ -0,0 +1,170 @@
+// Package providers implements LLM provider interfaces and implementations.
+package providers
+
+import (
+	"sort"
+	"sync"
+)
+
+// Capability is a feature a model may support.
+type Capability string
+
+const (
+	CapabilityTools      Capability = "tools"       // Function calling
+	CapabilityVision     Capability = "vision"      // Image inputs
+	CapabilityDocuments  Capability = "documents"   // PDF and document inputs
+	CapabilityJSONSchema Capability = "json_schema" // Native structured output
+	CapabilityStreaming  Capability = "streaming"   // Streaming responses
+	CapabilityReasoning  Capability = "reasoning"   // Thinking with a reasoning budget
+)
+
+// ModelInfo describes the pricing and capabilities of a model.
+type ModelInfo struct {
+	Provider         string       `json:"provider" yaml:"provider"`
+	Model            string       `json:"model" yaml:"model"`
+	InputPerMillion  float64      `json:"input_per_million" yaml:"input_per_million"`   // USD per million prompt tokens
+	OutputPerMillion float64      `json:"output_per_million" yaml:"output_per_million"` // USD per million generated tokens
+	ContextWindow    int          `json:"context_window" yaml:"context_window"`         // Maximum prompt plus response tokens
+	Capabilities     []Capability `json:"capabilities" yaml:"capabilities"`
+	Modalities       []string     `json:"modalities,omitempty" yaml:"modalities,omitempty"`   // Input modalities, e.g. "text" and "image"
+	Deprecated       bool         `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`   // Scheduled for retirement or retired
+	Deprecation      string       `json:"deprecation,omitempty" yaml:"deprecation,omitempty"` // Retirement date, when announced
+}
+
+// Has reports whether the model supports every given capability.
+func (m ModelInfo) Has(capabilities ...Capability) bool {
+	for _, want := range capabilities {
+		found := false
+		for _, c := range m.Capabilities {
+			if c == want {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Cost returns the price in USD of a request with the given token counts.
+func (m ModelInfo) Cost(inputTokens, outputTokens int) float64 {
+	return (float64(inputTokens)*m.InputPerMillion + float64(outputTokens)*m.OutputPerMillion) / 1e6
+}
+
+// ModelCatalog is a table of model pricing and capabilities, keyed by
+// provider and model. It is safe for concurrent use.
+type ModelCatalog struct {
+	mu     sync.RWMutex
+	models map[string]ModelInfo
+}
+
+// NewModelCatalog creates a catalog holding the given models.
+func NewModelCatalog(models ...ModelInfo) *ModelCatalog {
+	c := &ModelCatalog{models: make(map[string]ModelInfo)}
+	c.Register(models...)
+	return c
+}
+
+// Register adds models to the catalog, replacing existing entries for the
+// same provider and model.
+func (c *ModelCatalog) Register(models ...ModelInfo) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, m := range models {
+		c.models[m.Provider+"/"+m.Model] = m
+	}
+}
+
+// Merge adds models discovered with a provider's model listing. Known
+// models keep their prices, context window and capabilities unless the
+// listing reports them and they are unset; modalities and deprecation are
+// taken from the listing. Unknown models are added as listed.
+func (c *ModelCatalog) Merge(models ...ModelInfo) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, m := range models {
+		key := m.Provider + "/" + m.Model
+		known, ok := c.models[key]
+		if !ok {
+			c.models[key] = m
+			continue
+		}
+		if known.InputPerMillion == 0 && known.OutputPerMillion == 0 {
+			known.InputPerMillion, known.OutputPerMillion = m.InputPerMillion, m.OutputPerMillion
+		}
+		if known.ContextWindow == 0 {
+			known.ContextWindow = m.ContextWindow
+		}
+		for _, capability := range m.Capabilities {
+			if !known.Has(capability) {
+				known.Capabilities = append(known.Capabilities[:len(known.Capabilities):len(known.Capabilities)], capability)
+			}
+		}
+		if len(m.Modalities) > 0 {
+			known.Modalities = m.Modalities
+		}
+		known.Deprecated, known.Deprecation = m.Deprecated, m.Deprecation
+		c.models[key] = known
+	}
+}
+
+// Lookup returns the entry of a model.
+func (c *ModelCatalog) Lookup(provider, model string) (ModelInfo, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	m, ok := c.models[provider+"/"+model]
+	return m, ok
+}
+
+// Models returns every entry, sorted by provider and model.
+func (c *ModelCatalog) Models() []ModelInfo {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	models := make([]ModelInfo, 0, len(c.models))
+	for _, m := range c.models {
+		models = append(models, m)
+	}
+	sort.Slice(models, func(i, j int) bool {
+		if models[i].Provider != models[j].Provider {
+			return models[i].Provider < models[j].Provider
+		}
+		return models[i].Model < models[j].Model
+	})
+	return models
+}
+
+var (
+	defaultCatalog     *ModelCatalog
+	defaultCatalogOnce sync.Once
+)
+
+// DefaultModelCatalog returns the built-in catalog of popular models. Prices
+// are list prices at the time of writing and may be outdated; register
+// current prices, or your negotiated ones, to override them.
+func DefaultModelCatalog() *ModelCatalog {
+	defaultCatalogOnce.Do(func() {
+		all := []Capability{CapabilityTools, CapabilityVision, CapabilityJSONSchema, CapabilityStreaming}
+		text := []Capability{CapabilityTools, CapabilityStreaming}
+		anthropic := []Capability{CapabilityTools, CapabilityVision, CapabilityDocuments, CapabilityStreaming}
+		defaultCatalog = NewModelCatalog(
+			ModelInfo{Provider: "openai", Model: "gpt-4o", InputPerMillion: 2.5, OutputPerMillion: 10, ContextWindow: 128000, Capabilities: all},
+			ModelInfo{Provider: "openai", Model: "gpt-4o-mini", InputPerMillion: 0.15, OutputPerMillion: 0.6, ContextWindow: 128000, Capabilities: all},
+			ModelInfo{Provider: "openai", Model: "o3-mini", InputPerMillion: 1.1, OutputPerMillion: 4.4, ContextWindow: 200000, Capabilities: []Capability{CapabilityTools, CapabilityJSONSchema, CapabilityStreaming, CapabilityReasoning}},
+			ModelInfo{Provider: "anthropic", Model: "claude-3-7-sonnet-latest", InputPerMillion: 3, OutputPerMillion: 15, ContextWindow: 200000, Capabilities: append([]Capability{CapabilityReasoning}, anthropic...)},
+			ModelInfo{Provider: "anthropic", Model: "claude-3-5-sonnet-latest", InputPerMillion: 3, OutputPerMillion: 15, ContextWindow: 200000, Capabilities: anthropic},
+			ModelInfo{Provider: "anthropic", Model: "claude-3-5-haiku-latest", InputPerMillion: 0.8, OutputPerMillion: 4, ContextWindow: 200000, Capabilities: text},
+			ModelInfo{Provider: "anthropic", Model: "claude-3-opus-latest", InputPerMillion: 15, OutputPerMillion: 75, ContextWindow: 200000, Capabilities: anthropic},
+			ModelInfo{Provider: "mistral", Model: "mistral-large-latest", InputPerMillion: 2, OutputPerMillion: 6, ContextWindow: 128000, Capabilities: []Capability{CapabilityTools, CapabilityJSONSchema, CapabilityStreaming}},
+			ModelInfo{Provider: "mistral", Model: "mistral-small-latest", InputPerMillion: 0.2, OutputPerMillion: 0.6, ContextWindow: 32000, Capabilities: text},
+			ModelInfo{Provider: "groq", Model: "llama-3.3-70b-versatile", InputPerMillion: 0.59, OutputPerMillion: 0.79, ContextWindow: 128000, Capabilities: text},
+			ModelInfo{Provider: "groq", Model: "llama-3.1-8b-instant", InputPerMillion: 0.05, OutputPerMillion: 0.08, ContextWindow: 128000, Capabilities: text},
+			ModelInfo{Provider: "deepseek", Model: "deepseek-chat", InputPerMillion: 0.27, OutputPerMillion: 1.1, ContextWindow: 64000, Capabilities: text},
+			ModelInfo{Provider: "deepseek", Model: "deepseek-reasoner", InputPerMillion: 0.55, OutputPerMillion: 2.19, ContextWindow: 64000, Capabilities: []Capability{CapabilityStreaming, CapabilityReasoning}},
+			ModelInfo{Provider: "cohere", Model: "command-r-plus", InputPerMillion: 2.5, OutputPerMillion: 10, ContextWindow: 128000, Capabilities: text},
+			ModelInfo{Provider: "cohere", Model: "command-r", InputPerMillion: 0.15, OutputPerMillion: 0.6, ContextWindow: 128000, Capabilities: text},
+		)
+	})
+	return defaultCatalog
+}
@@ -0,0 +1,129 @@
+// Package providers implements LLM provider interfaces and implementations.
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// ModerationOptions configures a moderation request.
+type ModerationOptions struct {
+	Model string // Moderation model; provider default if empty
+}
+
+// ModerationResult holds the classification of a text by a moderation model.
+type ModerationResult struct {
+	Flagged        bool               `json:"flagged"`
+	Categories     map[string]bool    `json:"categories"`
+	CategoryScores map[string]float64 `json:"category_scores"`
+}
+
+// FlaggedCategories returns the names of the flagged categories, sorted.
+func (r *ModerationResult) FlaggedCategories() []string {
+	var flagged []string
+	for category, hit := range r.Categories {
+		if hit {
+			flagged = append(flagged, category)
+		}
+	}
+	sort.Strings(flagged)
+	return flagged
+}
+
+// Moderator is implemented by providers that offer a moderation endpoint.
+// Like Transcriber and Speaker, it is an optional capability discovered
+// through a type assertion.
+type Moderator interface {
+	// ModerationEndpoint returns the URL for moderation requests.
+	ModerationEndpoint() string
+
+	// PrepareModerationRequest builds the JSON request body for classifying text.
+	PrepareModerationRequest(text string, opts ModerationOptions) ([]byte, error)
+
+	// ParseModerationResponse extracts the classification from the API response.
+	ParseModerationResponse(body []byte) (*ModerationResult, error)
+}
+
+// parseModerationResponse decodes the results array shared by the OpenAI and
+// Mistral moderation APIs. Mistral does not report an overall flag, so the
+// result is flagged when any category is.
+func parseModerationResponse(body []byte) (*ModerationResult, error) {
+	var response struct {
+		Results []struct {
+			Flagged        *bool              `json:"flagged"`
+			Categories     map[string]bool    `json:"categories"`
+			CategoryScores map[string]float64 `json:"category_scores"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse moderation response: %w", err)
+	}
+	if len(response.Results) == 0 {
+		return nil, fmt.Errorf("moderation response contains no results")
+	}
+
+	first := response.Results[0]
+	result := &ModerationResult{
+		Categories:     first.Categories,
+		CategoryScores: first.CategoryScores,
+	}
+	if result.Categories == nil {
+		result.Categories = make(map[string]bool)
+	}
+	if result.CategoryScores == nil {
+		result.CategoryScores = make(map[string]float64)
+	}
+	if first.Flagged != nil {
+		result.Flagged = *first.Flagged
+	} else {
+		result.Flagged = len(result.FlaggedCategories()) > 0
+	}
+	return result, nil
+}
+
+// ModerationEndpoint returns the OpenAI moderation endpoint.
+func (p *OpenAIProvider) ModerationEndpoint() string {
+	return "https://api.openai.com/v1/moderations"
+}
+
+// PrepareModerationRequest builds an OpenAI moderation request.
+// The model defaults to "omni-moderation-latest".
+func (p *OpenAIProvider) PrepareModerationRequest(text string, opts ModerationOptions) ([]byte, error) {
+	model := opts.Model
+	if model == "" {
+		model = "omni-moderation-latest"
+	}
+	return json.Marshal(map[string]interface{}{
+		"model": model,
+		"input": text,
+	})
+}
+
+// ParseModerationResponse extracts the classification from an OpenAI moderation response.
+func (p *OpenAIProvider) ParseModerationResponse(body []byte) (*ModerationResult, error) {
+	return parseModerationResponse(body)
+}
+
+// ModerationEndpoint returns the Mistral moderation endpoint.
+func (p *MistralProvider) ModerationEndpoint() string {
+	return "https://api.mistral.ai/v1/moderations"
+}
+
+// PrepareModerationRequest builds a Mistral moderation request.
+// The model defaults to "mistral-moderation-latest".
+func (p *MistralProvider) PrepareModerationRequest(text string, opts ModerationOptions) ([]byte, error) {
+	model := opts.Model
+	if model == "" {
+		model = "mistral-moderation-latest"
+	}
+	return json.Marshal(map[string]interface{}{
+		"model": model,
+		"input": []string{text},
+	})
+}
+
+// ParseModerationResponse extracts the classification from a Mistral moderation response.
+func (p *MistralProvider) ParseModerationResponse(body []byte) (*ModerationResult, error) {
+	return parseModerationResponse(body)
+}
@@ -0,0 +1,60 @@
+package providers
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestModerationRequest(t *testing.T) {
+	t.Run("OpenAI", func(t *testing.T) {
+		provider := NewOpenAIProvider("fake-key", "gpt-4o", nil).(Moderator)
+		assert.Equal(t, "https://api.openai.com/v1/moderations", provider.ModerationEndpoint())
+
+		body, err := provider.PrepareModerationRequest("some text", ModerationOptions{})
+		require.NoError(t, err)
+		var req map[string]interface{}
+		require.NoError(t, json.Unmarshal(body, &req))
+		assert.Equal(t, "omni-moderation-latest", req["model"])
+		assert.Equal(t, "some text", req["input"])
+
+		result, err := provider.ParseModerationResponse([]byte(`{"id":"modr-1","results":[{
+			"flagged": true,
+			"categories": {"harassment": true, "violence": false, "hate": true},
+			"category_scores": {"harassment": 0.91, "violence": 0.02, "hate": 0.7}
+		}]}`))
+		require.NoError(t, err)
+		assert.True(t, result.Flagged)
+		assert.Equal(t, []string{"harassment", "hate"}, result.FlaggedCategories())
+		assert.Equal(t, 0.91, result.CategoryScores["harassment"])
+	})
+
+	t.Run("Mistral", func(t *testing.T) {
+		provider := NewMistralProvider("fake-key", "mistral-large-latest", nil).(Moderator)
+		assert.Equal(t, "https://api.mistral.ai/v1/moderations", provider.ModerationEndpoint())
+
+		body, err := provider.PrepareModerationRequest("some text", ModerationOptions{Model: "custom-moderation"})
+		require.NoError(t, err)
+		var req map[string]interface{}
+		require.NoError(t, json.Unmarshal(body, &req))
+		assert.Equal(t, "custom-moderation", req["model"])
+		assert.Equal(t, []interface{}{"some text"}, req["input"])
+
+		result, err := provider.ParseModerationResponse([]byte(`{"id":"m1","model":"mistral-moderation-latest","results":[{
+			"categories": {"sexual": false, "pii": true},
+			"category_scores": {"sexual": 0.01, "pii": 0.88}
+		}]}`))
+		require.NoError(t, err)
+		assert.True(t, result.Flagged, "any flagged category flags the result")
+		assert.Equal(t, []string{"pii"}, result.FlaggedCategories())
+
+		result, err = provider.ParseModerationResponse([]byte(`{"results":[{"categories":{"pii":false},"category_scores":{"pii":0.1}}]}`))
+		require.NoError(t, err)
+		assert.False(t, result.Flagged)
+
+		_, err = provider.ParseModerationResponse([]byte(`{"results":[]}`))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,226 @@
+// Package providers implements LLM provider interfaces and implementations.
+package providers
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/teilomillet/gollm/types"
+)
+
+// ErrUnsupportedAttachment is returned when preparing a request with images or
+// documents the provider cannot send.
+var ErrUnsupportedAttachment = errors.New("unsupported attachment")
+
+// imagesFromOptions extracts image attachments passed through the request options.
+func imagesFromOptions(options map[string]interface{}) []types.Image {
+	images, _ := options["images"].([]types.Image)
+	return images
+}
+
+// documentsFromOptions extracts document attachments passed through the request options.
+func documentsFromOptions(options map[string]interface{}) []types.Document {
+	documents, _ := options["documents"].([]types.Document)
+	return documents
+}
+
+// hasAttachments reports whether the request options carry images or documents.
+func hasAttachments(options map[string]interface{}) bool {
+	return len(imagesFromOptions(options)) > 0 || len(documentsFromOptions(options)) > 0
+}
+
+// openAIUserContent builds the content of an OpenAI-style user message.
+// Without attachments the plain prompt string is returned; otherwise the content
+// becomes an array of text, file and image_url parts.
+func openAIUserContent(prompt string, options map[string]interface{}) (interface{}, error) {
+	if !hasAttachments(options) {
+		return prompt, nil
+	}
+
+	parts := []map[string]interface{}{
+		{"type": "text", "text": prompt},
+	}
+	for _, doc := range documentsFromOptions(options) {
+		file := map[string]interface{}{}
+		switch {
+		case doc.FileID != "":
+			file["file_id"] = doc.FileID
+		case doc.IsRemote():
+			return nil, fmt.Errorf("remote document URLs are not supported, download or upload the file first: %s", doc.URL)
+		default:
+			dataURL, err := doc.DataURL()
+			if err != nil {
+				return nil, fmt.Errorf("failed to prepare document: %w", err)
+			}
+			file["filename"] = doc.Filename()
+			file["file_data"] = dataURL
+		}
+		parts = append(parts, map[string]interface{}{
+			"type": "file",
+			"file": file,
+		})
+	}
+	images, err := openAIImageParts(imagesFromOptions(options))
+	if err != nil {
+		return nil, err
+	}
+	return append(parts, images...), nil
+}
+
+// rejectDocuments returns an ErrUnsupportedAttachment error when the options
+// carry documents, for APIs without document inputs.
+func rejectDocuments(options map[string]interface{}) error {
+	if len(documentsFromOptions(options)) > 0 {
+		return fmt.Errorf("%w: documents are not supported by this provider", ErrUnsupportedAttachment)
+	}
+	return nil
+}
+
+// openAIImageContent builds the content of an OpenAI-style user message for
+// APIs taking images but no documents, such as Groq and Mistral. Without
+// images the plain prompt string is returned.
+func openAIImageContent(prompt string, images []types.Image) (interface{}, error) {
+	if len(images) == 0 {
+		return prompt, nil
+	}
+	parts, err := openAIImageParts(images)
+	if err != nil {
+		return nil, err
+	}
+	return append([]map[string]interface{}{{"type": "text", "text": prompt}}, parts...), nil
+}
+
+// openAIImageParts converts image attachments into image_url content parts.
+func openAIImageParts(images []types.Image) ([]map[string]interface{}, error) {
+	parts := make([]map[string]interface{}, 0, len(images))
+	for _, img := range images {
+		url, err := img.DataURL()
+		if err != nil {
+			return nil, fmt.Errorf("failed to prepare image: %w", err)
+		}
+		imageURL := map[string]interface{}{"url": url}
+		if img.Detail != "" {
+			imageURL["detail"] = img.Detail
+		}
+		parts = append(parts, map[string]interface{}{
+			"type":      "image_url",
+			"image_url": imageURL,
+		})
+	}
+	return parts, nil
+}
+
+// anthropicUserContent builds the content of an Anthropic user message.
+// Without attachments the plain prompt string is returned; otherwise the
+// attachment blocks are followed by a text block.
+func anthropicUserContent(prompt string, options map[string]interface{}) (interface{}, error) {
+	if !hasAttachments(options) {
+		return prompt, nil
+	}
+	blocks, err := anthropicAttachmentBlocks(options)
+	if err != nil {
+		return nil, err
+	}
+	return append(blocks, map[string]interface{}{"type": "text", "text": prompt}), nil
+}
+
+// anthropicImageBlocks converts image attachments into Anthropic content blocks.
+// Remote images use a url source, everything else is sent inline as base64.
+func anthropicImageBlocks(images []types.Image) ([]map[string]interface{}, error) {
+	blocks := make([]map[string]interface{}, 0, len(images))
+	for _, img := range images {
+		if img.IsRemote() {
+			blocks = append(blocks, map[string]interface{}{
+				"type":   "image",
+				"source": map[string]interface{}{"type": "url", "url": img.URL},
+			})
+			continue
+		}
+		data, mediaType, err := img.Base64()
+		if err != nil {
+			return nil, fmt.Errorf("failed to prepare image: %w", err)
+		}
+		blocks = append(blocks, map[string]interface{}{
+			"type": "image",
+			"source": map[string]interface{}{
+				"type":       "base64",
+				"media_type": mediaType,
+				"data":       data,
+			},
+		})
+	}
+	return blocks, nil
+}
+
+// anthropicAttachmentBlocks returns the document and image content blocks for
+// an Anthropic user message. Documents come first, then images, and both
+// precede the text block as recommended by Anthropic.
+func anthropicAttachmentBlocks(options map[string]interface{}) ([]map[string]interface{}, error) {
+	blocks, err := anthropicDocumentBlocks(documentsFromOptions(options))
+	if err != nil {
+		return nil, err
+	}
+	imageBlocks, err := anthropicImageBlocks(imagesFromOptions(options))
+	if err != nil {
+		return nil, err
+	}
+	return append(blocks, imageBlocks...), nil
+}
+
+// anthropicDocumentBlocks converts document attachments into Anthropic document blocks.
+// Uploaded files are referenced by ID, remote documents by URL, plain text is sent
+// as a text source and everything else (e.g., PDFs) inline as base64.
+func anthropicDocumentBlocks(documents []types.Document) ([]map[string]interface{}, error) {
+	blocks := make([]map[string]interface{}, 0, len(documents))
+	for _, doc := range documents {
+		var source map[string]interface{}
+		switch {
+		case doc.FileID != "":
+			source = map[string]interface{}{"type": "file", "file_id": doc.FileID}
+		case doc.IsRemote():
+			source = map[string]interface{}{"type": "url", "url": doc.URL}
+		default:
+			data, mediaType, err := doc.Base64()
+			if err != nil {
+				return nil, fmt.Errorf("failed to prepare document: %w", err)
+			}
+			if strings.HasPrefix(mediaType, "text/") {
+				raw, err := base64.StdEncoding.DecodeString(data)
+				if err != nil {
+					return nil, fmt.Errorf("invalid base64 document data: %w", err)
+				}
+				source = map[string]interface{}{"type": "text", "media_type": "text/plain", "data": string(raw)}
+			} else {
+				source = map[string]interface{}{"type": "base64", "media_type": mediaType, "data": data}
+			}
+		}
+		block := map[string]interface{}{
+			"type":   "document",
+			"source": source,
+		}
+		if doc.Name != "" {
+			block["title"] = doc.Name
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks, nil
+}
+
+// ollamaImages converts image attachments into the base64 list expected by
+// Ollama's multimodal models (e.g., llava). Ollama cannot fetch remote URLs.
+func ollamaImages(images []types.Image) ([]string, error) {
+	encoded := make([]string, 0, len(images))
+	for _, img := range images {
+		if img.IsRemote() {
+			return nil, fmt.Errorf("ollama does not support remote image URLs: %s", img.URL)
+		}
+		data, _, err := img.Base64()
+		if err != nil {
+			return nil, fmt.Errorf("failed to prepare image: %w", err)
+		}
+		encoded = append(encoded, data)
+	}
+	return encoded, nil
+}
@@ -0,0 +1,265 @@
+package providers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm/types"
+)
+
+func TestImageRequestFormatting(t *testing.T) {
+	pngBytes := []byte("\x89PNG\r\n\x1a\nfake")
+	encoded := base64.StdEncoding.EncodeToString(pngBytes)
+
+	imagePath := filepath.Join(t.TempDir(), "photo.png")
+	require.NoError(t, os.WriteFile(imagePath, pngBytes, 0o600))
+
+	images := []types.Image{
+		{URL: "https://example.com/cat.jpg"},
+		{Data: encoded, MediaType: "image/png"},
+		{Path: imagePath},
+	}
+	options := map[string]interface{}{"images": images}
+
+	t.Run("OpenAI", func(t *testing.T) {
+		provider := NewOpenAIProvider("fake-key", "gpt-4o", nil)
+		body, err := provider.PrepareRequest("What is in these images?", options)
+		require.NoError(t, err)
+
+		var req map[string]interface{}
+		require.NoError(t, json.Unmarshal(body, &req))
+		assert.NotContains(t, req, "images")
+
+		messages := req["messages"].([]interface{})
+		content := messages[len(messages)-1].(map[string]interface{})["content"].([]interface{})
+		require.Len(t, content, 4)
+		assert.Equal(t, "text", content[0].(map[string]interface{})["type"])
+		assert.Equal(t, "https://example.com/cat.jpg", content[1].(map[string]interface{})["image_url"].(map[string]interface{})["url"])
+		assert.Equal(t, "data:image/png;base64,"+encoded, content[2].(map[string]interface{})["image_url"].(map[string]interface{})["url"])
+		assert.Equal(t, "data:image/png;base64,"+encoded, content[3].(map[string]interface{})["image_url"].(map[string]interface{})["url"])
+	})
+
+	t.Run("Anthropic", func(t *testing.T) {
+		provider := NewAnthropicProvider("fake-key", "claude-3-5-sonnet-latest", nil)
+		body, err := provider.PrepareRequest("What is in these images?", options)
+		require.NoError(t, err)
+
+		var req map[string]interface{}
+		require.NoError(t, json.Unmarshal(body, &req))
+		assert.NotContains(t, req, "images")
+
+		messages := req["messages"].([]interface{})
+		content := messages[0].(map[string]interface{})["content"].([]interface{})
+		require.Len(t, content, 4)
+		assert.Equal(t, "url", content[0].(map[string]interface{})["source"].(map[string]interface{})["type"])
+		source := content[1].(map[string]interface{})["source"].(map[string]interface{})
+		assert.Equal(t, "base64", source["type"])
+		assert.Equal(t, "image/png", source["media_type"])
+		assert.Equal(t, encoded, source["data"])
+		assert.Equal(t, "text", content[3].(map[string]interface{})["type"])
+	})
+
+	t.Run("Ollama", func(t *testing.T) {
+		provider := NewOllamaProvider("", "llava", nil)
+		body, err := provider.PrepareRequest("Describe this", map[string]interface{}{"images": images[1:]})
+		require.NoError(t, err)
+
+		var req map[string]interface{}
+		require.NoError(t, json.Unmarshal(body, &req))
+		assert.Equal(t, []interface{}{encoded, encoded}, req["images"])
+
+		_, err = provider.PrepareRequest("Describe this", options)
+		assert.Error(t, err, "remote URLs are not supported by Ollama")
+	})
+
+	t.Run("OpenAICompatible", func(t *testing.T) {
+		for _, provider := range []Provider{
+			NewGroqProvider("fake-key", "llama-3.2-11b-vision-preview", nil),
+			NewMistralProvider("fake-key", "pixtral-12b-2409", nil),
+			NewCohereProvider("fake-key", "command-a-vision-07-2025", nil),
+			&GenericProvider{model: "llava", config: ProviderConfig{Type: TypeOpenAI}, options: map[string]interface{}{}},
+		} {
+			body, err := provider.PrepareRequest("What is in these images?", options)
+			require.NoError(t, err)
+
+			var req map[string]interface{}
+			require.NoError(t, json.Unmarshal(body, &req))
+			assert.NotContains(t, req, "images")
+
+			messages := req["messages"].([]interface{})
+			content := messages[len(messages)-1].(map[string]interface{})["content"].([]interface{})
+			require.Len(t, content, 4)
+			assert.Equal(t, "text", content[0].(map[string]interface{})["type"])
+			assert.Equal(t, "https://example.com/cat.jpg", content[1].(map[string]interface{})["image_url"].(map[string]interface{})["url"])
+		}
+	})
+
+	t.Run("StreamAndSchema", func(t *testing.T) {
+		schema := map[string]interface{}{"type": "object"}
+		for _, provider := range []Provider{
+			NewOpenAIProvider("fake-key", "gpt-4o", nil),
+			NewAnthropicProvider("fake-key", "claude-3-5-sonnet-latest", nil),
+			NewGroqProvider("fake-key", "llama-3.2-11b-vision-preview", nil),
+			NewMistralProvider("fake-key", "pixtral-12b-2409", nil),
+		} {
+			stream, err := provider.PrepareStreamRequest("What is in these images?", options)
+			require.NoError(t, err)
+			withSchema, err := provider.PrepareRequestWithSchema("What is in these images?", options, schema)
+			require.NoError(t, err)
+			for _, body := range [][]byte{stream, withSchema} {
+				var req struct {
+					Messages []struct {
+						Content []interface{} `json:"content"`
+					} `json:"messages"`
+				}
+				require.NoError(t, json.Unmarshal(body, &req), provider.Name())
+				assert.Len(t, req.Messages[len(req.Messages)-1].Content, 4, provider.Name())
+			}
+		}
+	})
+
+	t.Run("MissingFile", func(t *testing.T) {
+		provider := NewOpenAIProvider("fake-key", "gpt-4o", nil)
+		_, err := provider.PrepareRequest("hi", map[string]interface{}{
+			"images": []types.Image{{Path: filepath.Join(t.TempDir(), "missing.png")}},
+		})
+		assert.Error(t, err)
+	})
+}
+
+func TestImageWithStructuredMessages(t *testing.T) {
+	messages := []types.MemoryMessage{
+		{Role: "user", Content: "Hello"},
+		{Role: "assistant", Content: "Hi there"},
+		{Role: "user", Content: "What is this?"},
+	}
+	options := map[string]interface{}{
+		"images": []types.Image{{URL: "https://example.com/dog.png"}},
+	}
+
+	for _, provider := range []Provider{
+		NewOpenAIProvider("fake-key", "gpt-4o", nil),
+		NewGroqProvider("fake-key", "llama-3.2-11b-vision-preview", nil),
+		NewMistralProvider("fake-key", "pixtral-12b-2409", nil),
+		&GenericProvider{model: "llava", config: ProviderConfig{Type: TypeOpenAI}, options: map[string]interface{}{}},
+	} {
+		body, err := provider.PrepareRequestWithMessages(messages, options)
+		require.NoError(t, err)
+
+		var req map[string]interface{}
+		require.NoError(t, json.Unmarshal(body, &req))
+		msgs := req["messages"].([]interface{})
+		assert.Equal(t, "Hello", msgs[0].(map[string]interface{})["content"])
+		assert.IsType(t, []interface{}{}, msgs[2].(map[string]interface{})["content"])
+	}
+
+	t.Run("Cohere", func(t *testing.T) {
+		provider := NewCohereProvider("fake-key", "command-r", nil)
+		_, err := provider.PrepareRequestWithMessages(messages, options)
+		assert.ErrorIs(t, err, ErrUnsupportedAttachment)
+	})
+}
+
+func TestDocumentRequestFormatting(t *testing.T) {
+	pdfBytes := []byte("%PDF-1.4 fake")
+	encoded := base64.StdEncoding.EncodeToString(pdfBytes)
+
+	pdfPath := filepath.Join(t.TempDir(), "report.pdf")
+	require.NoError(t, os.WriteFile(pdfPath, pdfBytes, 0o600))
+
+	documents := []types.Document{
+		{Path: pdfPath, Name: "Q3 report"},
+		{Data: base64.StdEncoding.EncodeToString([]byte("plain notes")), MediaType: "text/plain"},
+		{FileID: "file_abc"},
+	}
+	options := map[string]interface{}{"documents": documents}
+
+	t.Run("Anthropic", func(t *testing.T) {
+		provider := NewAnthropicProvider("fake-key", "claude-3-5-sonnet-latest", nil)
+		body, err := provider.PrepareRequest("Summarize these", options)
+		require.NoError(t, err)
+
+		var req map[string]interface{}
+		require.NoError(t, json.Unmarshal(body, &req))
+		assert.NotContains(t, req, "documents")
+
+		content := req["messages"].([]interface{})[0].(map[string]interface{})["content"].([]interface{})
+		require.Len(t, content, 4)
+
+		pdf := content[0].(map[string]interface{})
+		assert.Equal(t, "document", pdf["type"])
+		assert.Equal(t, "Q3 report", pdf["title"])
+		assert.Equal(t, map[string]interface{}{"type": "base64", "media_type": "application/pdf", "data": encoded}, pdf["source"])
+
+		text := content[1].(map[string]interface{})["source"].(map[string]interface{})
+		assert.Equal(t, "text", text["type"])
+		assert.Equal(t, "plain notes", text["data"])
+
+		file := content[2].(map[string]interface{})["source"].(map[string]interface{})
+		assert.Equal(t, map[string]interface{}{"type": "file", "file_id": "file_abc"}, file)
+		assert.Equal(t, "text", content[3].(map[string]interface{})["type"])
+	})
+
+	t.Run("OpenAI", func(t *testing.T) {
+		provider := NewOpenAIProvider("fake-key", "gpt-4o", nil)
+		body, err := provider.PrepareRequest("Summarize these", options)
+		require.NoError(t, err)
+
+		var req map[string]interface{}
+		require.NoError(t, json.Unmarshal(body, &req))
+		assert.NotContains(t, req, "documents")
+
+		messages := req["messages"].([]interface{})
+		content := messages[len(messages)-1].(map[string]interface{})["content"].([]interface{})
+		require.Len(t, content, 4)
+		file := content[1].(map[string]interface{})["file"].(map[string]interface{})
+		assert.Equal(t, "Q3 report", file["filename"])
+		assert.Equal(t, "data:application/pdf;base64,"+encoded, file["file_data"])
+		assert.Equal(t, "file_abc", content[3].(map[string]interface{})["file"].(map[string]interface{})["file_id"])
+
+		_, err = provider.PrepareRequest("hi", map[string]interface{}{
+			"documents": []types.Document{{URL: "https://example.com/a.pdf"}},
+		})
+		assert.Error(t, err, "OpenAI cannot fetch remote documents")
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		messages := []types.MemoryMessage{{Role: "user", Content: "hi"}}
+		for _, provider := range []Provider{
+			NewOllamaProvider("", "llama3", nil),
+			NewGroqProvider("fake-key", "llama-3.3-70b-versatile", nil),
+			NewMistralProvider("fake-key", "mistral-large-latest", nil),
+			NewCohereProvider("fake-key", "command-r", nil),
+			&GenericProvider{model: "llava", config: ProviderConfig{Name: "local", Type: TypeOpenAI, SupportsStreaming: true}, options: map[string]interface{}{}},
+		} {
+			_, err := provider.PrepareRequest("hi", options)
+			assert.ErrorIs(t, err, ErrUnsupportedAttachment, provider.Name())
+			_, err = provider.PrepareStreamRequest("hi", options)
+			assert.ErrorIs(t, err, ErrUnsupportedAttachment, provider.Name())
+			_, err = provider.PrepareRequestWithMessages(messages, options)
+			assert.ErrorIs(t, err, ErrUnsupportedAttachment, provider.Name())
+		}
+	})
+}
+
+func TestAnthropicFileUpload(t *testing.T) {
+	provider := NewAnthropicProvider("fake-key", "claude-3-5-sonnet-latest", nil).(FileManager)
+	assert.True(t, provider.UploadsDocuments())
+	assert.Equal(t, "prompt-caching-2024-07-31,files-api-2025-04-14", provider.FileHeaders()["anthropic-beta"])
+
+	body, contentType, err := provider.PrepareFileUpload(strings.NewReader("%PDF"), FileUploadOptions{Filename: "report.pdf", MediaType: "application/pdf"})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(contentType, "multipart/form-data"))
+	assert.Contains(t, string(body), `filename="report.pdf"`)
+	assert.Contains(t, string(body), "Content-Type: application/pdf")
+
+	file, err := provider.ParseFileInfo([]byte(`{"id":"file_011","type":"file"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "file_011", file.ID)
+}
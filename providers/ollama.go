@@ -6,7 +6,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"strings"
 
@@ -90,11 +89,7 @@ func (p *OllamaProvider) SetOption(key string, value interface{}) {
 // SetDefaultOptions configures standard options from the global configuration.
 // This includes temperature and other generation parameters.
 func (p *OllamaProvider) SetDefaultOptions(config *config.Config) {
-	p.SetOption("temperature", config.Temperature)
-	p.SetOption("num_predict", config.MaxTokens)
-	if config.Seed != nil {
-		p.SetOption("seed", *config.Seed)
-	}
+	setSamplingDefaults(p, config)
 	if config.OllamaEndpoint != "" {
 		p.SetEndpoint(config.OllamaEndpoint)
 	}
@@ -109,17 +104,21 @@ func (p *OllamaProvider) SetDefaultOptions(config *config.Config) {
 }
 
 // SupportsJSONSchema indicates whether this provider supports JSON schema validation.
-// Currently, Ollama does not natively support JSON schema validation.
+// Ollama constrains decoding to the schema passed as the request format.
 func (p *OllamaProvider) SupportsJSONSchema() bool {
-	return false
+	return true
 }
 
 // Headers returns the HTTP headers required for Ollama API requests.
 // This includes content type and any custom headers.
 func (p *OllamaProvider) Headers() map[string]string {
-	return map[string]string{
+	headers := map[string]string{
 		"Content-Type": "application/json",
 	}
+	for k, v := range p.extraHeaders {
+		headers[k] = v
+	}
+	return headers
 }
 
 // PrepareRequest creates the request body for an Ollama API call.
@@ -133,25 +132,62 @@ func (p *OllamaProvider) Headers() map[string]string {
 //   - Serialized JSON request body
 //   - Any error encountered during preparation
 func (p *OllamaProvider) PrepareRequest(prompt string, options map[string]interface{}) ([]byte, error) {
-	requestBody := map[string]interface{}{
-		"model":  p.model,
-		"prompt": prompt,
+	request, err := newOllamaRequest(p.model, prompt, options)
+	if err != nil {
+		return nil, err
 	}
 
-	for k, v := range options {
-		requestBody[k] = v
+	// Options set on the provider are defaults for the request options
+	return marshalRequest(request, p.options, options)
+}
+
+// ollamaRequest is the body of an Ollama generate request. Other parameters,
+// such as temperature, are passed through as options.
+type ollamaRequest struct {
+	Model  string      `json:"model"`
+	Prompt string      `json:"prompt"`
+	System string      `json:"system,omitempty"`
+	Images []string    `json:"images,omitempty"`
+	Format interface{} `json:"format,omitempty"`
+	Stream bool        `json:"stream,omitempty"`
+}
+
+// newOllamaRequest builds the request of a prompt with the system prompt and
+// images of the options.
+func newOllamaRequest(model, prompt string, options map[string]interface{}) (ollamaRequest, error) {
+	request := ollamaRequest{Model: model, Prompt: prompt}
+	if len(documentsFromOptions(options)) > 0 {
+		return request, fmt.Errorf("%w: ollama does not support document attachments", ErrUnsupportedAttachment)
+	}
+	if systemPrompt, ok := options["system_prompt"].(string); ok {
+		request.System = systemPrompt
 	}
 
-	return json.Marshal(requestBody)
+	// Multimodal models (e.g., llava) take base64 images alongside the prompt
+	if images := imagesFromOptions(options); len(images) > 0 {
+		encoded, err := ollamaImages(images)
+		if err != nil {
+			return request, err
+		}
+		request.Images = encoded
+	}
+	return request, nil
 }
 
 // PrepareRequestWithSchema creates a request with JSON schema validation.
-// Since Ollama doesn't support schema validation natively, this falls back to
-// standard request preparation.
+// The schema is passed as the request format, which Ollama turns into a
+// grammar constraining the model's output to conforming JSON.
 func (p *OllamaProvider) PrepareRequestWithSchema(prompt string, options map[string]interface{}, schema interface{}) ([]byte, error) {
-	// Ollama doesn't support JSON schema validation natively
-	// We'll just use the regular PrepareRequest method
-	return p.PrepareRequest(prompt, options)
+	format, err := schemaAsMap(schema)
+	if err != nil {
+		return nil, err
+	}
+	request, err := newOllamaRequest(p.model, prompt, options)
+	if err != nil {
+		return nil, err
+	}
+	request.Format = format
+	return marshalRequest(request, p.options, options)
 }
 
 // ParseResponse extracts the generated text from the Ollama API response.
@@ -164,6 +200,9 @@ func (p *OllamaProvider) PrepareRequestWithSchema(prompt string, options map[str
 //   - Generated text content
 //   - Any error encountered during parsing
 func (p *OllamaProvider) ParseResponse(body []byte) (string, error) {
+	if err := CheckResponseBody(body); err != nil {
+		return "", err
+	}
 	var fullResponse strings.Builder
 	decoder := json.NewDecoder(bytes.NewReader(body))
 
@@ -172,10 +211,14 @@ func (p *OllamaProvider) ParseResponse(body []byte) (string, error) {
 			Model    string `json:"model"`
 			Response string `json:"response"`
 			Done     bool   `json:"done"`
+			Error    string `json:"error"`
 		}
 		if err := decoder.Decode(&response); err != nil {
 			return "", fmt.Errorf("error parsing Ollama response: %w", err)
 		}
+		if response.Error != "" {
+			return "", fmt.Errorf("ollama error: %s", response.Error)
+		}
 		fullResponse.WriteString(response.Response)
 		if response.Done {
 			break
@@ -246,7 +289,7 @@ func (p *OllamaProvider) Generate(ctx context.Context, prompt string) (string, s
 	}
 	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	body, err := ReadResponseBody(resp.Body)
 	if err != nil {
 		return "", "", err
 	}
@@ -274,8 +317,12 @@ func (p *OllamaProvider) SupportsStreaming() bool {
 
 // PrepareStreamRequest prepares a request body for streaming
 func (p *OllamaProvider) PrepareStreamRequest(prompt string, options map[string]interface{}) ([]byte, error) {
-	options["stream"] = true
-	return p.PrepareRequest(prompt, options)
+	request, err := newOllamaRequest(p.model, prompt, options)
+	if err != nil {
+		return nil, err
+	}
+	request.Stream = true
+	return marshalRequest(request, p.options, options)
 }
 
 // ParseStreamResponse parses a single chunk from a streaming response
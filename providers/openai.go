@@ -69,10 +69,19 @@ func (p *OpenAIProvider) SetOption(key string, value interface{}) {
 // SetDefaultOptions configures standard options from the global configuration.
 // This includes temperature, max tokens, and sampling parameters.
 func (p *OpenAIProvider) SetDefaultOptions(config *config.Config) {
-	p.SetOption("temperature", config.Temperature)
-	p.SetOption("max_tokens", config.MaxTokens)
-	if config.Seed != nil {
-		p.SetOption("seed", *config.Seed)
+	setSamplingDefaults(p, config)
+	if config.Logprobs != nil {
+		p.SetOption("logprobs", true)
+		if *config.Logprobs > 0 {
+			p.SetOption("top_logprobs", *config.Logprobs)
+		}
+	}
+	if config.ReasoningBudget != nil {
+		if supportsReasoning(p.Name(), p.model) {
+			setReasoningEffort(p.options, *config.ReasoningBudget)
+		} else {
+			p.logger.Warn("Reasoning budget ignored: the model does not reason", "model", p.model)
+		}
 	}
 	p.logger.Debug("Default options set", "temperature", config.Temperature, "max_tokens", config.MaxTokens, "seed", config.Seed)
 }
@@ -128,60 +137,23 @@ func (p *OpenAIProvider) Headers() map[string]string {
 //   - Serialized JSON request body
 //   - Any error encountered during preparation
 func (p *OpenAIProvider) PrepareRequest(prompt string, options map[string]interface{}) ([]byte, error) {
-	request := map[string]interface{}{
-		"model":    p.model,
-		"messages": []map[string]interface{}{},
-	}
+	var messages []chatMessage
 
 	// Handle system prompt as developer message
 	if systemPrompt, ok := options["system_prompt"].(string); ok && systemPrompt != "" {
-		request["messages"] = append(request["messages"].([]map[string]interface{}), map[string]interface{}{
-			"role":    "developer",
-			"content": systemPrompt,
-		})
-	}
-
-	// Add user message
-	request["messages"] = append(request["messages"].([]map[string]interface{}), map[string]interface{}{
-		"role":    "user",
-		"content": prompt,
-	})
-
-	// Handle tool_choice
-	if toolChoice, ok := options["tool_choice"].(string); ok {
-		request["tool_choice"] = toolChoice
-	}
-
-	// Handle tools
-	if tools, ok := options["tools"].([]utils.Tool); ok && len(tools) > 0 {
-		openAITools := make([]map[string]interface{}, len(tools))
-		for i, tool := range tools {
-			openAITools[i] = map[string]interface{}{
-				"type": "function",
-				"function": map[string]interface{}{
-					"name":        tool.Function.Name,
-					"description": tool.Function.Description,
-					"parameters":  tool.Function.Parameters,
-				},
-				"strict": true, // Add this if you want strict mode
-			}
-		}
-		request["tools"] = openAITools
+		messages = append(messages, chatMessage{Role: "developer", Content: systemPrompt})
 	}
 
-	// Add other options
-	for k, v := range p.options {
-		if k != "tools" && k != "tool_choice" && k != "system_prompt" {
-			request[k] = v
-		}
-	}
-	for k, v := range options {
-		if k != "tools" && k != "tool_choice" && k != "system_prompt" {
-			request[k] = v
-		}
+	// Add user message, including any image attachments
+	userContent, err := openAIUserContent(prompt, options)
+	if err != nil {
+		return nil, err
 	}
+	messages = append(messages, chatMessage{Role: "user", Content: userContent})
 
-	return json.Marshal(request)
+	request := chatRequest{Model: p.model, Messages: messages}
+	request.Tools, request.ToolChoice = chatTools(options)
+	return marshalRequest(request, p.options, options)
 }
 
 // PrepareRequestWithSchema creates a request that includes JSON schema validation.
@@ -229,12 +201,22 @@ func (p *OpenAIProvider) PrepareRequestWithSchema(prompt string, options map[str
 	cleanSchemaJSON, _ := json.MarshalIndent(cleanSchema, "", "  ")
 	p.logger.Debug("Cleaned schema for OpenAI", "schema", string(cleanSchemaJSON))
 
-	request := map[string]interface{}{
-		"model": p.model,
-		"messages": []map[string]interface{}{
-			{"role": "user", "content": prompt},
-		},
-		"response_format": map[string]interface{}{
+	var messages []chatMessage
+
+	// Handle system prompt as system message
+	if systemPrompt, ok := options["system_prompt"].(string); ok && systemPrompt != "" {
+		messages = append(messages, chatMessage{Role: "system", Content: systemPrompt})
+	}
+	userContent, err := openAIUserContent(prompt, options)
+	if err != nil {
+		return nil, err
+	}
+	messages = append(messages, chatMessage{Role: "user", Content: userContent})
+
+	request := chatRequest{
+		Model:    p.model,
+		Messages: messages,
+		ResponseFormat: map[string]interface{}{
 			"type": "json_schema",
 			"json_schema": map[string]interface{}{
 				"name":   "structured_response",
@@ -243,22 +225,9 @@ func (p *OpenAIProvider) PrepareRequestWithSchema(prompt string, options map[str
 			},
 		},
 	}
+	request.Tools, request.ToolChoice = chatTools(options)
 
-	// Handle system prompt as system message
-	if systemPrompt, ok := options["system_prompt"].(string); ok && systemPrompt != "" {
-		request["messages"] = append([]map[string]interface{}{
-			{"role": "system", "content": systemPrompt},
-		}, request["messages"].([]map[string]interface{})...)
-	}
-
-	// Add other options
-	for k, v := range options {
-		if k != "system_prompt" {
-			request[k] = v
-		}
-	}
-
-	reqJSON, err := json.Marshal(request)
+	reqJSON, err := marshalRequest(request, p.options, options)
 	if err != nil {
 		p.logger.Error("Failed to marshal request with schema", "error", err)
 		return nil, err
@@ -309,6 +278,9 @@ func cleanSchemaForOpenAI(schema interface{}) interface{} {
 //   - Generated text content
 //   - Any error encountered during parsing
 func (p *OpenAIProvider) ParseResponse(body []byte) (string, error) {
+	if err := CheckResponseBody(body); err != nil {
+		return "", err
+	}
 	var response struct {
 		Choices []struct {
 			Message struct {
@@ -322,11 +294,12 @@ func (p *OpenAIProvider) ParseResponse(body []byte) (string, error) {
 					} `json:"function"`
 				} `json:"tool_calls"`
 			} `json:"message"`
+			FinishReason string `json:"finish_reason"`
 		} `json:"choices"`
 	}
 
 	if err := json.Unmarshal(body, &response); err != nil {
-		return "", err
+		return "", fmt.Errorf("error parsing response: %w", err)
 	}
 
 	if len(response.Choices) == 0 {
@@ -337,6 +310,9 @@ func (p *OpenAIProvider) ParseResponse(body []byte) (string, error) {
 	if message.Content != "" {
 		return message.Content, nil
 	}
+	if response.Choices[0].FinishReason == "content_filter" && len(message.ToolCalls) == 0 {
+		return "", contentFilterError(p.Name(), body)
+	}
 
 	if len(message.ToolCalls) > 0 {
 		var functionCalls []string
@@ -390,23 +366,31 @@ func (p *OpenAIProvider) SupportsStreaming() bool {
 
 // PrepareStreamRequest creates a request body for streaming API calls
 func (p *OpenAIProvider) PrepareStreamRequest(prompt string, options map[string]interface{}) ([]byte, error) {
-	// Start with regular request preparation
-	requestBody := map[string]interface{}{
-		"model": p.model,
-		"messages": []map[string]string{
-			{"role": "user", "content": prompt},
-		},
-		"stream": true,
+	userContent, err := openAIUserContent(prompt, options)
+	if err != nil {
+		return nil, err
 	}
-
-	// Add other options
-	for k, v := range options {
-		if k != "stream" { // Don't override stream setting
-			requestBody[k] = v
-		}
+	request := chatRequest{
+		Model:         p.model,
+		Messages:      []chatMessage{{Role: "user", Content: userContent}},
+		Stream:        true,
+		StreamOptions: openAIStreamOptions(options),
+	}
+	if systemPrompt, ok := options["system_prompt"].(string); ok && systemPrompt != "" {
+		request.Messages = append([]chatMessage{{Role: "developer", Content: systemPrompt}}, request.Messages...)
 	}
+	request.Tools, request.ToolChoice = chatTools(options)
+	return marshalRequest(request, p.options, options)
+}
 
-	return json.Marshal(requestBody)
+// openAIStreamOptions returns the stream options of a streaming request: the
+// usage is reported in a last chunk, as non-streaming responses do, unless
+// the options set their own.
+func openAIStreamOptions(options map[string]interface{}) interface{} {
+	if streamOptions, ok := options["stream_options"]; ok {
+		return streamOptions
+	}
+	return map[string]interface{}{"include_usage": true}
 }
 
 // ParseStreamResponse processes a single chunk from a streaming response
@@ -465,69 +449,40 @@ func (p *OpenAIProvider) ParseStreamResponse(chunk []byte) (string, error) {
 //   - Serialized JSON request body
 //   - Any error encountered during preparation
 func (p *OpenAIProvider) PrepareRequestWithMessages(messages []types.MemoryMessage, options map[string]interface{}) ([]byte, error) {
-	request := map[string]interface{}{
-		"model":    p.model,
-		"messages": []map[string]interface{}{},
-	}
+	var chat []chatMessage
 
 	// Handle system prompt as system message
 	if systemPrompt, ok := options["system_prompt"].(string); ok && systemPrompt != "" {
-		request["messages"] = append(request["messages"].([]map[string]interface{}), map[string]interface{}{
-			"role":    "system",
-			"content": systemPrompt,
-		})
+		chat = append(chat, chatMessage{Role: "system", Content: systemPrompt})
 	}
 
-	// Convert MemoryMessage objects to OpenAI messages format
-	for _, msg := range messages {
-		message := map[string]interface{}{
-			"role":    msg.Role,
-			"content": msg.Content,
-		}
-
-		// Add metadata if present
-		if len(msg.Metadata) > 0 {
-			for k, v := range msg.Metadata {
-				message[k] = v
-			}
+	// Images and documents are attached to the most recent user message
+	attach := hasAttachments(options)
+	lastUser := -1
+	for i, msg := range messages {
+		if msg.Role == "user" {
+			lastUser = i
 		}
-
-		request["messages"] = append(request["messages"].([]map[string]interface{}), message)
-	}
-
-	// Handle tool_choice
-	if toolChoice, ok := options["tool_choice"].(string); ok {
-		request["tool_choice"] = toolChoice
 	}
 
-	// Handle tools
-	if tools, ok := options["tools"].([]utils.Tool); ok && len(tools) > 0 {
-		openAITools := make([]map[string]interface{}, len(tools))
-		for i, tool := range tools {
-			openAITools[i] = map[string]interface{}{
-				"type": "function",
-				"function": map[string]interface{}{
-					"name":        tool.Function.Name,
-					"description": tool.Function.Description,
-					"parameters":  tool.Function.Parameters,
-				},
-				"strict": true, // Add this if you want strict mode
+	// Convert MemoryMessage objects to OpenAI messages format
+	for i, msg := range messages {
+		var content interface{} = msg.Content
+		if i == lastUser && attach {
+			var err error
+			content, err = openAIUserContent(msg.Content, options)
+			if err != nil {
+				return nil, err
 			}
 		}
-		request["tools"] = openAITools
+		chat = append(chat, chatMessage{Role: msg.Role, Content: content, Metadata: msg.Metadata})
 	}
 
-	// Add other options, but exclude the structured_messages parameter
-	for k, v := range p.options {
-		if k != "tools" && k != "tool_choice" && k != "system_prompt" && k != "structured_messages" {
-			request[k] = v
-		}
+	request := chatRequest{Model: p.model, Messages: chat}
+	request.Tools, request.ToolChoice = chatTools(options)
+	if stream, ok := options["stream"].(bool); ok && stream {
+		request.Stream = true
+		request.StreamOptions = openAIStreamOptions(options)
 	}
-	for k, v := range options {
-		if k != "tools" && k != "tool_choice" && k != "system_prompt" && k != "structured_messages" {
-			request[k] = v
-		}
-	}
-
-	return json.Marshal(request)
+	return marshalRequest(request, p.options, options)
 }
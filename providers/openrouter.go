@@ -86,17 +86,16 @@ func (p *OpenRouterProvider) SetOption(key string, value interface{}) {
 
 // SetDefaultOptions configures standard options from the global configuration.
 func (p *OpenRouterProvider) SetDefaultOptions(config *config.Config) {
-	p.SetOption("temperature", config.Temperature)
-	p.SetOption("max_tokens", config.MaxTokens)
-	if config.Seed != nil {
-		p.SetOption("seed", *config.Seed)
-	}
+	setSamplingDefaults(p, config)
 
 	// OpenRouter-specific defaults
 	// Reasoning transforms are enabled via options rather than config
 	if _, ok := p.options["enable_reasoning"]; ok {
 		p.SetOption("transforms", []string{"reasoning"})
 	}
+	if config.ReasoningBudget != nil {
+		setOpenRouterReasoning(p.options, *config.ReasoningBudget)
+	}
 }
 
 // SupportsJSONSchema indicates whether this provider supports JSON schema validation.
@@ -124,209 +123,102 @@ func (p *OpenRouterProvider) Headers() map[string]string {
 	return headers
 }
 
-// PrepareRequest creates a chat completion request for the OpenRouter API.
-func (p *OpenRouterProvider) PrepareRequest(prompt string, options map[string]interface{}) ([]byte, error) {
-	// Start with the passed options
-	req := map[string]interface{}{}
-	for k, v := range options {
-		req[k] = v
-	}
-
-	// Add model
-	req["model"] = p.model
-
-	// Add options from the provider
+// requestOptions merges the provider and request options, later ones taking
+// precedence, and turns the OpenRouter routing options into API fields. It
+// returns the model to request and the options passed through to the API.
+func (p *OpenRouterProvider) requestOptions(options map[string]interface{}) (string, map[string]interface{}) {
+	merged := make(map[string]interface{}, len(p.options)+len(options))
 	for k, v := range p.options {
-		if _, exists := req[k]; !exists {
-			req[k] = v
-		}
+		merged[k] = v
+	}
+	for k, v := range options {
+		merged[k] = v
 	}
 
+	model := p.model
 	// Handle fallback models if specified
-	if fallbackModels, ok := req["fallback_models"].([]string); ok {
-		req["models"] = append([]string{p.model}, fallbackModels...)
-		delete(req, "fallback_models")
-	} else if autoRoute, ok := req["auto_route"].(bool); ok && autoRoute {
+	if fallbackModels, ok := merged["fallback_models"].([]string); ok {
+		merged["models"] = append([]string{p.model}, fallbackModels...)
+	} else if autoRoute, ok := merged["auto_route"].(bool); ok && autoRoute {
 		// Use OpenRouter's auto-routing capability
-		req["model"] = "openrouter/auto"
-		delete(req, "auto_route")
+		model = "openrouter/auto"
 	}
+	delete(merged, "fallback_models")
+	delete(merged, "auto_route")
 
 	// Handle provider routing preferences if provided
-	if providerPrefs, ok := req["provider_preferences"].(map[string]interface{}); ok {
-		req["provider"] = providerPrefs
-		delete(req, "provider_preferences")
+	if providerPrefs, ok := merged["provider_preferences"].(map[string]interface{}); ok {
+		merged["provider"] = providerPrefs
 	}
+	delete(merged, "provider_preferences")
 
-	// Create messages array with system and user messages
-	messages := []map[string]interface{}{}
+	// Prompt caching is applied to the messages, OpenRouter handles it
+	// automatically for other providers
+	delete(merged, "enable_prompt_caching")
 
-	// If there's a system message in the options, use it
-	if sysMsg, ok := req["system_message"].(string); ok {
-		messages = append(messages, map[string]interface{}{
-			"role":    "system",
-			"content": sysMsg,
-		})
-		delete(req, "system_message")
-	}
-
-	// Add the user prompt
-	messages = append(messages, map[string]interface{}{
-		"role":    "user",
-		"content": prompt,
-	})
-
-	req["messages"] = messages
+	return model, merged
+}
 
-	// Handle tools/function calling if provided
-	if tools, ok := req["tools"].([]interface{}); ok && len(tools) > 0 {
-		req["tools"] = tools
+// newRequest builds the chat completion request of a single prompt, preceded
+// by the system prompt of the options, with any attachments of the options.
+func (p *OpenRouterProvider) newRequest(prompt string, options map[string]interface{}) (chatRequest, map[string]interface{}, error) {
+	userContent, err := openAIUserContent(prompt, options)
+	if err != nil {
+		return chatRequest{}, nil, err
 	}
-
-	if toolChoice, ok := req["tool_choice"]; ok {
-		req["tool_choice"] = toolChoice
+	var messages []chatMessage
+	if systemPrompt, ok := options["system_prompt"].(string); ok && systemPrompt != "" {
+		messages = append(messages, chatMessage{Role: "system", Content: systemPrompt})
 	}
+	messages = append(messages, chatMessage{Role: "user", Content: userContent})
 
-	// Add streaming if requested
-	if stream, ok := req["stream"].(bool); ok && stream {
-		req["stream"] = true
-	}
+	model, merged := p.requestOptions(options)
+	request := chatRequest{Model: model, Messages: messages}
+	request.Tools, request.ToolChoice = chatTools(merged)
+	return request, merged, nil
+}
 
-	// Handle prompt caching for supported models
-	if caching, ok := req["enable_prompt_caching"].(bool); ok && caching {
-		// OpenRouter handles caching automatically for supported providers
-		delete(req, "enable_prompt_caching")
+// PrepareRequest creates a chat completion request for the OpenRouter API.
+func (p *OpenRouterProvider) PrepareRequest(prompt string, options map[string]interface{}) ([]byte, error) {
+	request, merged, err := p.newRequest(prompt, options)
+	if err != nil {
+		return nil, err
 	}
+	return marshalRequest(request, merged)
+}
 
-	return json.Marshal(req)
+// openRouterCompletionRequest is the body of an OpenRouter text completion
+// request. Other parameters are passed through as options.
+type openRouterCompletionRequest struct {
+	Model  string `json:"model"`
+	Prompt string `json:"prompt"`
 }
 
 // PrepareCompletionRequest creates a text completion request for the OpenRouter API.
 // This uses the legacy completions endpoint rather than chat completions.
 func (p *OpenRouterProvider) PrepareCompletionRequest(prompt string, options map[string]interface{}) ([]byte, error) {
-	// Start with the passed options
-	req := map[string]interface{}{}
-	for k, v := range options {
-		req[k] = v
-	}
-
-	// Add model
-	req["model"] = p.model
-
-	// Add options from the provider
-	for k, v := range p.options {
-		if _, exists := req[k]; !exists {
-			req[k] = v
-		}
-	}
-
-	// Handle fallback models if specified
-	if fallbackModels, ok := req["fallback_models"].([]string); ok {
-		req["models"] = append([]string{p.model}, fallbackModels...)
-		delete(req, "fallback_models")
-	} else if autoRoute, ok := req["auto_route"].(bool); ok && autoRoute {
-		// Use OpenRouter's auto-routing capability
-		req["model"] = "openrouter/auto"
-		delete(req, "auto_route")
-	}
-
-	// Add the prompt
-	req["prompt"] = prompt
-
-	// Handle provider routing preferences if provided
-	if providerPrefs, ok := req["provider_preferences"].(map[string]interface{}); ok {
-		req["provider"] = providerPrefs
-		delete(req, "provider_preferences")
-	}
-
-	// Add streaming if requested
-	if stream, ok := req["stream"].(bool); ok && stream {
-		req["stream"] = true
-	}
-
-	return json.Marshal(req)
+	model, merged := p.requestOptions(options)
+	return marshalRequest(openRouterCompletionRequest{Model: model, Prompt: prompt}, merged)
 }
 
 // PrepareRequestWithSchema creates a request with JSON schema validation.
 func (p *OpenRouterProvider) PrepareRequestWithSchema(prompt string, options map[string]interface{}, schema interface{}) ([]byte, error) {
-	// Start with standard request preparation
-	req := map[string]interface{}{}
-	for k, v := range options {
-		req[k] = v
-	}
-
-	// Add model
-	req["model"] = p.model
-
-	// Add default options from the provider
-	for k, v := range p.options {
-		if _, exists := req[k]; !exists {
-			req[k] = v
-		}
-	}
-
-	// Handle fallback models if specified
-	if fallbackModels, ok := req["fallback_models"].([]string); ok {
-		req["models"] = append([]string{p.model}, fallbackModels...)
-		delete(req, "fallback_models")
-	} else if autoRoute, ok := req["auto_route"].(bool); ok && autoRoute {
-		// Use OpenRouter's auto-routing capability
-		req["model"] = "openrouter/auto"
-		delete(req, "auto_route")
-	}
-
-	// Handle provider routing preferences if provided
-	if providerPrefs, ok := req["provider_preferences"].(map[string]interface{}); ok {
-		req["provider"] = providerPrefs
-		delete(req, "provider_preferences")
-	}
-
-	// Create messages array with system and user messages
-	messages := []map[string]interface{}{}
-
-	// If there's a system message in the options, use it
-	if sysMsg, ok := req["system_message"].(string); ok {
-		messages = append(messages, map[string]interface{}{
-			"role":    "system",
-			"content": sysMsg,
-		})
-		delete(req, "system_message")
+	request, merged, err := p.newRequest(prompt, options)
+	if err != nil {
+		return nil, err
 	}
-
-	// Add the user prompt
-	messages = append(messages, map[string]interface{}{
-		"role":    "user",
-		"content": prompt,
-	})
-
-	req["messages"] = messages
-
-	// Add JSON schema to the response format
-	req["response_format"] = map[string]interface{}{
+	request.ResponseFormat = map[string]interface{}{
 		"type":   "json_object",
 		"schema": schema,
 	}
-
-	// Handle tools/function calling if provided
-	if tools, ok := req["tools"].([]interface{}); ok && len(tools) > 0 {
-		req["tools"] = tools
-	}
-
-	if toolChoice, ok := req["tool_choice"]; ok {
-		req["tool_choice"] = toolChoice
-	}
-
-	// Handle prompt caching for supported models
-	if caching, ok := req["enable_prompt_caching"].(bool); ok && caching {
-		// OpenRouter handles caching automatically for supported providers
-		delete(req, "enable_prompt_caching")
-	}
-
-	return json.Marshal(req)
+	return marshalRequest(request, merged)
 }
 
 // ParseResponse extracts the completion text from the OpenRouter API response.
 func (p *OpenRouterProvider) ParseResponse(body []byte) (string, error) {
+	if err := CheckResponseBody(body); err != nil {
+		return "", err
+	}
 	// First try to parse as chat completion to see if it's a chat/completions response
 	var chatResp struct {
 		Choices []struct {
@@ -470,12 +362,12 @@ func (p *OpenRouterProvider) SupportsStreaming() bool {
 
 // PrepareStreamRequest creates a streaming request for the OpenRouter API.
 func (p *OpenRouterProvider) PrepareStreamRequest(prompt string, options map[string]interface{}) ([]byte, error) {
-	streamOptions := make(map[string]interface{})
-	for k, v := range options {
-		streamOptions[k] = v
+	request, merged, err := p.newRequest(prompt, options)
+	if err != nil {
+		return nil, err
 	}
-	streamOptions["stream"] = true
-	return p.PrepareRequest(prompt, streamOptions)
+	request.Stream = true
+	return marshalRequest(request, merged)
 }
 
 // ParseStreamResponse processes a chunk from a streaming OpenRouter response.
@@ -556,88 +448,53 @@ func (p *OpenRouterProvider) ParseStreamResponse(chunk []byte) (string, error) {
 
 // PrepareRequestWithMessages creates a request with structured message objects.
 func (p *OpenRouterProvider) PrepareRequestWithMessages(messages []types.MemoryMessage, options map[string]interface{}) ([]byte, error) {
-	// Start with the passed options
-	req := map[string]interface{}{}
-	for k, v := range options {
-		req[k] = v
+	var chat []chatMessage
+	if systemPrompt, ok := options["system_prompt"].(string); ok && systemPrompt != "" {
+		chat = append(chat, chatMessage{Role: "system", Content: systemPrompt})
 	}
 
-	// Add model
-	req["model"] = p.model
-
-	// Add options from the provider
-	for k, v := range p.options {
-		if _, exists := req[k]; !exists {
-			req[k] = v
+	// Images and documents are attached to the most recent user message
+	attach := hasAttachments(options)
+	lastUser := -1
+	for i, msg := range messages {
+		if msg.Role == "user" {
+			lastUser = i
 		}
 	}
 
-	// Handle fallback models if specified
-	if fallbackModels, ok := req["fallback_models"].([]string); ok {
-		req["models"] = append([]string{p.model}, fallbackModels...)
-		delete(req, "fallback_models")
-	} else if autoRoute, ok := req["auto_route"].(bool); ok && autoRoute {
-		// Use OpenRouter's auto-routing capability
-		req["model"] = "openrouter/auto"
-		delete(req, "auto_route")
+	caching, _ := p.options["enable_prompt_caching"].(bool)
+	if enabled, ok := options["enable_prompt_caching"].(bool); ok {
+		caching = enabled
 	}
-
-	// Handle provider routing preferences if provided
-	if providerPrefs, ok := req["provider_preferences"].(map[string]interface{}); ok {
-		req["provider"] = providerPrefs
-		delete(req, "provider_preferences")
-	}
-
-	// Convert memory messages to OpenRouter format
-	formattedMessages := make([]map[string]interface{}, 0, len(messages))
-	for _, msg := range messages {
-		formattedMsg := map[string]interface{}{
-			"role":    msg.Role,
-			"content": msg.Content,
-		}
-
-		// Handle Anthropic-style prompt caching if enabled
-		if caching, ok := req["enable_prompt_caching"].(bool); ok && caching && msg.Role == "user" {
-			// Check if the message is large enough to benefit from caching
-			if len(msg.Content) > 1000 {
-				// For Anthropic models, we need to use multipart messages with cache_control
-				if strings.HasPrefix(p.model, "anthropic/") {
-					formattedMsg["content"] = []map[string]interface{}{
-						{
-							"type": "text",
-							"text": msg.Content,
-							"cache_control": map[string]string{
-								"type": "ephemeral",
-							},
-						},
-					}
-				}
+	for i, msg := range messages {
+		var content interface{} = msg.Content
+		switch {
+		case i == lastUser && attach:
+			var err error
+			content, err = openAIUserContent(msg.Content, options)
+			if err != nil {
+				return nil, err
+			}
+		case caching && msg.Role == "user" && len(msg.Content) > 1000 && strings.HasPrefix(p.model, "anthropic/"):
+			// For Anthropic models, large messages benefit from caching
+			// through multipart messages with cache_control
+			content = []map[string]interface{}{
+				{
+					"type": "text",
+					"text": msg.Content,
+					"cache_control": map[string]string{
+						"type": "ephemeral",
+					},
+				},
 			}
 		}
-
-		formattedMessages = append(formattedMessages, formattedMsg)
-	}
-
-	req["messages"] = formattedMessages
-
-	// Handle tools/function calling if provided
-	if tools, ok := req["tools"].([]interface{}); ok && len(tools) > 0 {
-		req["tools"] = tools
-	}
-
-	if toolChoice, ok := req["tool_choice"]; ok {
-		req["tool_choice"] = toolChoice
-	}
-
-	// Remove prompt caching flag as it's been handled
-	delete(req, "enable_prompt_caching")
-
-	// Add streaming if requested
-	if stream, ok := req["stream"].(bool); ok && stream {
-		req["stream"] = true
+		chat = append(chat, chatMessage{Role: msg.Role, Content: content, Metadata: msg.Metadata})
 	}
 
-	return json.Marshal(req)
+	model, merged := p.requestOptions(options)
+	request := chatRequest{Model: model, Messages: chat}
+	request.Tools, request.ToolChoice = chatTools(merged)
+	return marshalRequest(request, merged)
 }
 
 func init() {
@@ -102,6 +102,38 @@ func TestOpenRouterProvider(t *testing.T) {
 		assert.Equal(t, "Hello, world!", userMsg["content"])
 	})
 
+	t.Run("PrepareRequest sends the system prompt and images as messages", func(t *testing.T) {
+		options := map[string]interface{}{
+			"system_prompt":       "Be brief",
+			"images":              []types.Image{{URL: "https://example.com/dog.png"}},
+			"structured_messages": []types.MemoryMessage{{Role: "user", Content: "Hi"}},
+		}
+
+		for name, prepare := range map[string]func() ([]byte, error){
+			"PrepareRequest":       func() ([]byte, error) { return provider.PrepareRequest("Describe", options) },
+			"PrepareStreamRequest": func() ([]byte, error) { return provider.PrepareStreamRequest("Describe", options) },
+			"PrepareRequestWithSchema": func() ([]byte, error) {
+				return provider.PrepareRequestWithSchema("Describe", options, map[string]interface{}{"type": "object"})
+			},
+		} {
+			body, err := prepare()
+			assert.NoError(t, err, name)
+
+			var req map[string]interface{}
+			assert.NoError(t, json.Unmarshal(body, &req), name)
+			for _, key := range []string{"system_prompt", "images", "structured_messages"} {
+				assert.NotContains(t, req, key, name)
+			}
+
+			messages := req["messages"].([]interface{})
+			assert.Len(t, messages, 2, name)
+			assert.Equal(t, map[string]interface{}{"role": "system", "content": "Be brief"}, messages[0], name)
+			parts := messages[1].(map[string]interface{})["content"].([]interface{})
+			assert.Len(t, parts, 2, name)
+			assert.Equal(t, "image_url", parts[1].(map[string]interface{})["type"], name)
+		}
+	})
+
 	t.Run("PrepareRequest handles fallback models", func(t *testing.T) {
 		options := map[string]interface{}{
 			"fallback_models": []string{"openai/gpt-4o", "mistral/mistral-large"},
@@ -0,0 +1,200 @@
+package providers
+
+import (
+	"fmt"
+
+	"github.com/teilomillet/gollm/config"
+)
+
+// Generic sampling parameters, named as in the OpenAI API. Options with
+// these names are translated to each provider's naming and value ranges by
+// TranslateParams.
+const (
+	ParamTemperature = "temperature"
+	ParamTopP        = "top_p"
+	ParamMaxTokens   = "max_tokens"
+	ParamStop        = "stop"
+	ParamSeed        = "seed"
+)
+
+// ParamSpec describes how a provider's API takes a generic parameter.
+type ParamSpec struct {
+	// Name is the provider's name of the parameter, or empty if the API does
+	// not support it
+	Name string
+
+	// Min and Max bound numeric values, when Max is greater than Min
+	Min, Max float64
+}
+
+// ParamMapper is implemented by providers whose API names or bounds the
+// generic parameters differently from OpenAI. The returned specs override
+// the OpenAI ones.
+type ParamMapper interface {
+	ParamSpecs() map[string]ParamSpec
+}
+
+// defaultParamSpecs are the generic parameters as taken by OpenAI.
+var defaultParamSpecs = map[string]ParamSpec{
+	ParamTemperature: {Name: "temperature", Min: 0, Max: 2},
+	ParamTopP:        {Name: "top_p", Min: 0, Max: 1},
+	ParamMaxTokens:   {Name: "max_tokens"},
+	ParamStop:        {Name: "stop"},
+	ParamSeed:        {Name: "seed"},
+}
+
+// anthropicParamSpecs are the generic parameters as taken by Anthropic.
+var anthropicParamSpecs = map[string]ParamSpec{
+	ParamTemperature: {Name: "temperature", Min: 0, Max: 1},
+	ParamStop:        {Name: "stop_sequences"},
+	ParamSeed:        {},
+}
+
+// ParamSpecs returns the spec of each generic parameter for the provider.
+func ParamSpecs(p Provider) map[string]ParamSpec {
+	specs := make(map[string]ParamSpec, len(defaultParamSpecs))
+	for k, v := range defaultParamSpecs {
+		specs[k] = v
+	}
+	if mapper, ok := p.(ParamMapper); ok {
+		for k, v := range mapper.ParamSpecs() {
+			specs[k] = v
+		}
+	}
+	return specs
+}
+
+// TranslateParams rewrites the generic parameters of options, in place, to
+// the provider's naming and value ranges. Out of range values are clamped,
+// and unsupported or invalid parameters are removed; a warning describes
+// each change. Options already using the provider's name take precedence
+// over the generic ones.
+func TranslateParams(p Provider, options map[string]interface{}) []string {
+	var warnings []string
+	specs := ParamSpecs(p)
+	for _, param := range []string{ParamTemperature, ParamTopP, ParamMaxTokens, ParamStop, ParamSeed} {
+		value, ok := options[param]
+		if !ok {
+			continue
+		}
+		spec := specs[param]
+		if spec.Name == "" {
+			delete(options, param)
+			warnings = append(warnings, fmt.Sprintf("%s is not supported by %s and was dropped", param, p.Name()))
+			continue
+		}
+
+		switch param {
+		case ParamMaxTokens:
+			if n, ok := toFloat(value); !ok || n < 1 {
+				delete(options, param)
+				warnings = append(warnings, fmt.Sprintf("invalid %s %v was dropped", param, value))
+				continue
+			}
+		case ParamStop:
+			// A single stop sequence is sent as a list, which every API takes
+			if s, ok := value.(string); ok {
+				value = []string{s}
+			}
+		default:
+			if spec.Max > spec.Min {
+				n, ok := toFloat(value)
+				if !ok {
+					delete(options, param)
+					warnings = append(warnings, fmt.Sprintf("invalid %s %v was dropped", param, value))
+					continue
+				}
+				if clamped := clamp(n, spec.Min, spec.Max); clamped != n {
+					warnings = append(warnings, fmt.Sprintf("%s %v is out of range for %s and was clamped to %v", param, value, p.Name(), clamped))
+					value = clamped
+				}
+			}
+		}
+
+		if spec.Name != param {
+			delete(options, param)
+			if _, ok := options[spec.Name]; ok {
+				continue
+			}
+		}
+		options[spec.Name] = value
+	}
+	return warnings
+}
+
+// setSamplingDefaults sets the temperature, maximum tokens and seed of the
+// configuration as provider options, under the provider's names.
+func setSamplingDefaults(p Provider, cfg *config.Config) {
+	options := map[string]interface{}{
+		ParamTemperature: cfg.Temperature,
+		ParamMaxTokens:   cfg.MaxTokens,
+	}
+	if cfg.Seed != nil {
+		options[ParamSeed] = *cfg.Seed
+	}
+	TranslateParams(p, options)
+	for k, v := range options {
+		p.SetOption(k, v)
+	}
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	default:
+		return 0, false
+	}
+}
+
+func clamp(v, min, max float64) float64 {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
+
+// ParamSpecs returns Anthropic's naming and ranges: temperature is bounded
+// to 1, stop sequences are stop_sequences and there is no seed.
+func (p *AnthropicProvider) ParamSpecs() map[string]ParamSpec {
+	return anthropicParamSpecs
+}
+
+// ParamSpecs returns Cohere's naming and ranges: top_p is p, bounded to
+// 0.01-0.99, and stop sequences are stop_sequences.
+func (p *CohereProvider) ParamSpecs() map[string]ParamSpec {
+	return map[string]ParamSpec{
+		ParamTopP: {Name: "p", Min: 0.01, Max: 0.99},
+		ParamStop: {Name: "stop_sequences"},
+	}
+}
+
+// ParamSpecs returns Mistral's naming: the seed is random_seed.
+func (p *MistralProvider) ParamSpecs() map[string]ParamSpec {
+	return map[string]ParamSpec{ParamSeed: {Name: "random_seed"}}
+}
+
+// ParamSpecs returns Ollama's naming: the maximum number of tokens is
+// num_predict.
+func (p *OllamaProvider) ParamSpecs() map[string]ParamSpec {
+	return map[string]ParamSpec{ParamMaxTokens: {Name: "num_predict"}}
+}
+
+// ParamSpecs follows the OpenAI or Anthropic API of the provider.
+func (p *GenericProvider) ParamSpecs() map[string]ParamSpec {
+	if p.config.Type == TypeAnthropic || p.config.Type == TypeClaude {
+		return anthropicParamSpecs
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package providers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/teilomillet/gollm/config"
+)
+
+func TestTranslateParams(t *testing.T) {
+	t.Run("Anthropic", func(t *testing.T) {
+		options := map[string]interface{}{"temperature": 1.5, "stop": "END", "seed": 42, "max_tokens": 100, "metadata": "kept"}
+		warnings := TranslateParams(NewAnthropicProvider("key", "claude-3-5-haiku-latest", nil), options)
+		assert.Equal(t, map[string]interface{}{"temperature": 1.0, "stop_sequences": []string{"END"}, "max_tokens": 100, "metadata": "kept"}, options)
+		assert.Len(t, warnings, 2)
+	})
+
+	t.Run("Cohere", func(t *testing.T) {
+		options := map[string]interface{}{"top_p": 1, "stop": []string{"a", "b"}}
+		TranslateParams(NewCohereProvider("key", "command-r", nil), options)
+		assert.Equal(t, map[string]interface{}{"p": 0.99, "stop_sequences": []string{"a", "b"}}, options)
+	})
+
+	t.Run("ProviderNameWins", func(t *testing.T) {
+		options := map[string]interface{}{"max_tokens": 100, "num_predict": 50}
+		TranslateParams(NewOllamaProvider("", "llama3", nil), options)
+		assert.Equal(t, map[string]interface{}{"num_predict": 50}, options)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		options := map[string]interface{}{"max_tokens": 0, "temperature": "hot", "seed": 7}
+		warnings := TranslateParams(NewMistralProvider("key", "mistral-small-latest", nil), options)
+		assert.Equal(t, map[string]interface{}{"random_seed": 7}, options)
+		assert.Len(t, warnings, 2)
+	})
+}
+
+func TestSamplingDefaults(t *testing.T) {
+	seed := 7
+	cfg := &config.Config{Temperature: 0.5, MaxTokens: 200, Seed: &seed}
+
+	ollama := NewOllamaProvider("", "llama3", nil).(*OllamaProvider)
+	ollama.SetDefaultOptions(cfg)
+	assert.Equal(t, 200, ollama.options["num_predict"])
+	assert.NotContains(t, ollama.options, "max_tokens")
+
+	anthropic := NewAnthropicProvider("key", "claude-3-5-haiku-latest", nil).(*AnthropicProvider)
+	anthropic.SetDefaultOptions(cfg)
+	assert.Equal(t, map[string]interface{}{"temperature": 0.5, "max_tokens": 200}, anthropic.options)
+}
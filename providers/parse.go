@@ -0,0 +1,67 @@
+package providers
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+const (
+	// MaxResponseSize is the largest response body read from a provider API.
+	// Generated text is far smaller; a larger body is a misbehaving server
+	// or proxy.
+	MaxResponseSize = 32 << 20
+
+	// MaxJSONDepth is the deepest nesting of objects and arrays accepted in a
+	// response body. Provider responses nest a few levels; deeply nested
+	// bodies only cost memory and stack to decode.
+	MaxJSONDepth = 64
+)
+
+// ReadResponseBody reads a response body of at most MaxResponseSize bytes.
+func ReadResponseBody(r io.Reader) ([]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(r, MaxResponseSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > MaxResponseSize {
+		return nil, fmt.Errorf("response body exceeds %d bytes", MaxResponseSize)
+	}
+	return body, nil
+}
+
+// CheckResponseBody rejects response bodies that are empty, larger than
+// MaxResponseSize or nested deeper than MaxJSONDepth, so that ParseResponse
+// implementations fail with a clear error before decoding them.
+func CheckResponseBody(body []byte) error {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return fmt.Errorf("empty response body")
+	}
+	if len(body) > MaxResponseSize {
+		return fmt.Errorf("response body of %d bytes exceeds %d bytes", len(body), MaxResponseSize)
+	}
+	depth, inString, escaped := 0, false, false
+	for _, c := range body {
+		switch {
+		case escaped:
+			escaped = false
+		case inString:
+			switch c {
+			case '\\':
+				escaped = true
+			case '"':
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case c == '{' || c == '[':
+			depth++
+			if depth > MaxJSONDepth {
+				return fmt.Errorf("response body is nested deeper than %d levels", MaxJSONDepth)
+			}
+		case c == '}' || c == ']':
+			depth--
+		}
+	}
+	return nil
+}
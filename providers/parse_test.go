@@ -0,0 +1,71 @@
+package providers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/teilomillet/gollm/utils"
+)
+
+func parsingProviders() []Provider {
+	all := []Provider{
+		NewOpenAIProvider("key", "gpt-4o", nil),
+		NewAnthropicProvider("key", "claude-3-5-haiku-latest", nil),
+		NewGroqProvider("key", "llama-3.1-8b-instant", nil),
+		NewMistralProvider("key", "mistral-small-latest", nil),
+		NewCohereProvider("key", "command-r", nil),
+		NewOllamaProvider("key", "llama3", nil),
+		NewOpenRouterProvider("key", "openai/gpt-4o", nil),
+		NewMockProvider("key", "mock", nil),
+		NewGenericProvider("key", "gpt-4o", "azure-openai", nil),
+	}
+	for _, p := range all {
+		p.SetLogger(utils.NewLogger(utils.LogLevelOff))
+	}
+	return all
+}
+
+func TestCheckResponseBody(t *testing.T) {
+	assert.NoError(t, CheckResponseBody([]byte(`{"choices": [{"message": {"content": "[[[{{{"}}]}`)), "brackets in strings are not nesting")
+	assert.ErrorContains(t, CheckResponseBody([]byte(" \n")), "empty")
+	assert.ErrorContains(t, CheckResponseBody([]byte(strings.Repeat("[", MaxJSONDepth+1))), "nested")
+
+	for _, p := range parsingProviders() {
+		_, err := p.ParseResponse([]byte(strings.Repeat(`{"a":`, 100000)))
+		assert.ErrorContains(t, err, "nested", p.Name())
+		_, err = p.ParseResponse(nil)
+		assert.ErrorContains(t, err, "empty response body", p.Name())
+	}
+
+	_, err := ReadResponseBody(strings.NewReader(strings.Repeat(" ", MaxResponseSize+1)))
+	assert.ErrorContains(t, err, "exceeds")
+}
+
+func FuzzParseResponse(f *testing.F) {
+	for _, seed := range []string{
+		`{"choices":[{"message":{"content":"Hello"}}]}`,
+		`{"choices":[{"message":{"tool_calls":[{"function":{"name":"f","arguments":"{\"a\":1}"}}]}}]}`,
+		`{"choices":[{"message":{"tool_calls":[{"function":{"name":"f","arguments":null}}]}}]}`,
+		`{"choices":[{"text":"Hello"}]}`,
+		`{"content":[{"type":"text","text":"Hello"},{"type":"tool_use","name":"f","input":{}}]}`,
+		`{"content":[{"type":"tool_use"}]}`,
+		`{"message":{"content":[{"type":"text","text":"Hello"}],"tool_calls":[{"function":{"arguments":""}}]}}`,
+		`{"response":"Hel","done":false}{"response":"lo","done":true}`,
+		`{"error":{"message":"bad"}}`,
+		`{"choices":[null]}`,
+		`{"choices":{}}`,
+		`null`,
+		`[]`,
+	} {
+		f.Add([]byte(seed))
+	}
+	providers := parsingProviders()
+	f.Fuzz(func(t *testing.T, body []byte) {
+		for _, p := range providers {
+			// Parsing must fail or succeed without panicking
+			_, _ = p.ParseResponse(body)
+			_, _ = p.ParseStreamResponse(body)
+		}
+	})
+}
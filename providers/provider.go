@@ -133,6 +133,11 @@ type ProviderConfig struct {
 
 	// SupportsStreaming indicates if streaming is supported
 	SupportsStreaming bool
+
+	// SupportsGrammar indicates if the API accepts a GBNF grammar, as
+	// llama.cpp servers do; JSON schemas are then enforced with a grammar
+	// generated by SchemaToGBNF
+	SupportsGrammar bool
 }
 
 // ProviderConstructor defines a function type for creating new provider instances.
@@ -159,6 +164,10 @@ type ProviderRegistry struct {
 //   - "mistral": Mistral AI's models
 //   - "cohere": Cohere's models
 //   - "deepseek": DeepSeek's models
+//   - "deepgram": Deepgram speech-to-text (transcription only)
+//   - "elevenlabs": ElevenLabs text-to-speech (speech synthesis only)
+//   - "llamacpp": Local llama.cpp server, with grammar-constrained structured output
+//   - "mock": In-process MockProvider for unit tests
 //
 // Example usage:
 //
@@ -175,13 +184,17 @@ func NewProviderRegistry(providerNames ...string) *ProviderRegistry {
 
 	// Register all known providers
 	knownProviders := map[string]ProviderConstructor{
-		"openai":    NewOpenAIProvider,
-		"anthropic": NewAnthropicProvider,
-		"groq":      NewGroqProvider,
-		"ollama":    NewOllamaProvider,
-		"mistral":   NewMistralProvider,
-		"cohere":    NewCohereProvider,
-		"deepseek":  NewDeepSeekProvider,
+		"openai":     NewOpenAIProvider,
+		"anthropic":  NewAnthropicProvider,
+		"groq":       NewGroqProvider,
+		"ollama":     NewOllamaProvider,
+		"mistral":    NewMistralProvider,
+		"cohere":     NewCohereProvider,
+		"deepseek":   NewDeepSeekProvider,
+		"deepgram":   NewDeepgramProvider,
+		"elevenlabs": NewElevenLabsProvider,
+		"mock":       NewMockProvider,
+		"llamacpp":   NewLlamaCppProvider,
 		// Add other providers here as they are implemented
 	}
 
@@ -247,6 +260,17 @@ func NewProviderRegistry(providerNames ...string) *ProviderRegistry {
 			SupportsSchema:    true,
 			SupportsStreaming: true,
 		},
+		"llamacpp": {
+			Name:              "llamacpp",
+			Type:              TypeOpenAI,
+			Endpoint:          "http://localhost:8080/v1/chat/completions",
+			AuthHeader:        "Authorization", // Only checked when the server runs with --api-key
+			AuthPrefix:        "Bearer ",
+			RequiredHeaders:   map[string]string{"Content-Type": "application/json"},
+			SupportsSchema:    true,
+			SupportsStreaming: true,
+			SupportsGrammar:   true,
+		},
 		// Add other provider configurations
 	}
 
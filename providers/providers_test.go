@@ -101,3 +101,27 @@ func TestStructuredMessagesFormat(t *testing.T) {
 
 	// Add more provider-specific tests as needed
 }
+
+// TestDeepSeekCapabilities verifies that DeepSeek exposes only the APIs it
+// has, so that none of OpenAI's other APIs is called with the DeepSeek key.
+func TestDeepSeekCapabilities(t *testing.T) {
+	var provider interface{} = NewDeepSeekProvider("fake-key", "deepseek-chat", nil)
+
+	assert.Implements(t, (*Conversational)(nil), provider)
+	assert.Implements(t, (*ToolCallStreamer)(nil), provider)
+	assert.Implements(t, (*HealthChecker)(nil), provider)
+	assert.Implements(t, (*ModelLister)(nil), provider)
+
+	for name, capability := range map[string]interface{}{
+		"Transcriber":    (*Transcriber)(nil),
+		"Speaker":        (*Speaker)(nil),
+		"Realtimer":      (*Realtimer)(nil),
+		"AssistantsHost": (*AssistantsHost)(nil),
+		"Moderator":      (*Moderator)(nil),
+		"Batcher":        (*Batcher)(nil),
+		"FileManager":    (*FileManager)(nil),
+		"FineTuner":      (*FineTuner)(nil),
+	} {
+		assert.NotImplements(t, capability, provider, name)
+	}
+}
@@ -0,0 +1,252 @@
+// Package providertest provides a conformance suite for Provider
+// implementations. Third-party providers registered in the provider registry
+// can run it from their own tests to verify that they behave as gollm
+// expects: options reach the request, schemas are sent when supported,
+// responses and errors are parsed, and streams are decoded.
+//
+// Example usage:
+//
+//	func TestConformance(t *testing.T) {
+//	    providertest.Run(t, providertest.Config{
+//	        New:          NewAcmeProvider,
+//	        Model:        "acme-large",
+//	        Response:     []byte(`{"output": {"text": "Hello"}}`),
+//	        ResponseText: "Hello",
+//	        ErrorResponse: []byte(`{"error": "invalid model"}`),
+//	    })
+//	}
+package providertest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/teilomillet/gollm/config"
+	"github.com/teilomillet/gollm/providers"
+	"github.com/teilomillet/gollm/types"
+	"github.com/teilomillet/gollm/utils"
+)
+
+// Config describes the provider under test and sample payloads of its API.
+type Config struct {
+	// New creates the provider under test
+	New providers.ProviderConstructor
+
+	// Name is the name the provider is registered under, if any. The suite
+	// then also checks that the default registry returns it.
+	Name string
+
+	// Model is the model passed to New
+	Model string
+
+	// Response is the body of a successful response whose text is
+	// ResponseText
+	Response     []byte
+	ResponseText string
+
+	// ErrorResponse is the body of an error response of the API, if the
+	// API returns errors in the body
+	ErrorResponse []byte
+
+	// StreamChunks are the data of the events of a streaming response whose
+	// text is StreamText, if the provider supports streaming
+	StreamChunks [][]byte
+	StreamText   string
+}
+
+// Prompt is the prompt of the requests prepared by the suite.
+const Prompt = "conformance prompt"
+
+// Run runs the conformance suite against the provider, one subtest per
+// area.
+func Run(t *testing.T, cfg Config) {
+	t.Helper()
+	if cfg.New == nil {
+		t.Fatal("providertest: Config.New is required")
+	}
+	newProvider := func(t *testing.T) providers.Provider {
+		p := cfg.New("test-key", cfg.Model, nil)
+		if p == nil {
+			t.Fatal("constructor returned nil")
+		}
+		p.SetLogger(utils.NewLogger(utils.LogLevelOff))
+		return p
+	}
+
+	t.Run("Identity", func(t *testing.T) {
+		p := newProvider(t)
+		if p.Name() == "" {
+			t.Error("Name() is empty")
+		}
+		if cfg.Name != "" {
+			if p.Name() != cfg.Name {
+				t.Errorf("Name() = %q, want %q", p.Name(), cfg.Name)
+			}
+			registered, err := providers.GetDefaultRegistry().Get(cfg.Name, "test-key", cfg.Model, nil)
+			if err != nil {
+				t.Errorf("provider is not registered: %v", err)
+			} else if registered.Name() != cfg.Name {
+				t.Errorf("registry returned provider %q, want %q", registered.Name(), cfg.Name)
+			}
+		}
+		if p.Endpoint() == "" {
+			t.Error("Endpoint() is empty")
+		}
+
+		p.SetExtraHeaders(map[string]string{"X-Conformance": "1"})
+		if p.Headers()["X-Conformance"] != "1" {
+			t.Errorf("Headers() = %v, want the extra header X-Conformance", p.Headers())
+		}
+	})
+
+	t.Run("Options", func(t *testing.T) {
+		p := newProvider(t)
+		p.SetDefaultOptions(config.NewConfig())
+
+		body, err := p.PrepareRequest(Prompt, nil)
+		request := decodeRequest(t, "PrepareRequest with nil options", body, err)
+		if !containsValue(request, func(s string) bool { return strings.Contains(s, Prompt) }) {
+			t.Errorf("request does not contain the prompt: %s", body)
+		}
+
+		p.SetOption("temperature", 0.25)
+		body, err = p.PrepareRequest(Prompt, map[string]interface{}{"max_tokens": 123})
+		request = decodeRequest(t, "PrepareRequest", body, err)
+		if !containsValue(request, 123.0) {
+			t.Errorf("request does not contain the max_tokens request option: %s", body)
+		}
+		if !containsValue(request, 0.25) {
+			t.Errorf("request does not contain the temperature set with SetOption: %s", body)
+		}
+
+		body, err = p.PrepareRequestWithMessages([]types.MemoryMessage{
+			{Role: "system", Content: "Be brief."},
+			{Role: "user", Content: Prompt},
+		}, map[string]interface{}{})
+		request = decodeRequest(t, "PrepareRequestWithMessages", body, err)
+		if !containsValue(request, func(s string) bool { return strings.Contains(s, Prompt) }) {
+			t.Errorf("request does not contain the user message: %s", body)
+		}
+	})
+
+	t.Run("Schema", func(t *testing.T) {
+		p := newProvider(t)
+		schema := map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{"conformance_answer": map[string]interface{}{"type": "string"}},
+			"required":   []string{"conformance_answer"},
+		}
+		body, err := p.PrepareRequestWithSchema(Prompt, map[string]interface{}{}, schema)
+		if !p.SupportsJSONSchema() {
+			if err == nil && !json.Valid(body) {
+				t.Errorf("PrepareRequestWithSchema returned invalid JSON: %s", body)
+			}
+			return
+		}
+		decodeRequest(t, "PrepareRequestWithSchema", body, err)
+		if !bytes.Contains(body, []byte("conformance_answer")) {
+			t.Errorf("provider supports JSON schema but the request does not contain it: %s", body)
+		}
+	})
+
+	t.Run("Response", func(t *testing.T) {
+		p := newProvider(t)
+		if cfg.Response != nil {
+			text, err := p.ParseResponse(cfg.Response)
+			if err != nil {
+				t.Errorf("ParseResponse failed: %v", err)
+			} else if text != cfg.ResponseText {
+				t.Errorf("ParseResponse = %q, want %q", text, cfg.ResponseText)
+			}
+		}
+		if cfg.ErrorResponse != nil {
+			if text, err := p.ParseResponse(cfg.ErrorResponse); err == nil {
+				t.Errorf("ParseResponse of an error response = %q, want an error", text)
+			}
+		}
+		for _, body := range []string{"", "not json", "[1, 2]", `{"unexpected": true}`} {
+			if text, err := p.ParseResponse([]byte(body)); err == nil && text != "" {
+				t.Errorf("ParseResponse(%q) = %q, want an error or no text", body, text)
+			}
+		}
+	})
+
+	t.Run("Stream", func(t *testing.T) {
+		p := newProvider(t)
+		if !p.SupportsStreaming() {
+			if cfg.StreamChunks != nil {
+				t.Error("stream chunks are set but SupportsStreaming() is false")
+			}
+			return
+		}
+		body, err := p.PrepareStreamRequest(Prompt, map[string]interface{}{})
+		request := decodeRequest(t, "PrepareStreamRequest", body, err)
+		if !containsValue(request, func(s string) bool { return strings.Contains(s, Prompt) }) {
+			t.Errorf("stream request does not contain the prompt: %s", body)
+		}
+
+		// Tokens are read until io.EOF; other errors skip the chunk
+		var text strings.Builder
+		for _, chunk := range cfg.StreamChunks {
+			token, err := p.ParseStreamResponse(chunk)
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err == nil {
+				text.WriteString(token)
+			}
+		}
+		if cfg.StreamChunks != nil && text.String() != cfg.StreamText {
+			t.Errorf("stream text = %q, want %q", text.String(), cfg.StreamText)
+		}
+		for _, chunk := range []string{"", "not json", "{}"} {
+			if token, err := p.ParseStreamResponse([]byte(chunk)); err == nil && token != "" {
+				t.Errorf("ParseStreamResponse(%q) = %q, want an error or no text", chunk, token)
+			}
+		}
+	})
+}
+
+// decodeRequest checks that a prepared request is a JSON object.
+func decodeRequest(t *testing.T, method string, body []byte, err error) map[string]interface{} {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("%s failed: %v", method, err)
+	}
+	var request map[string]interface{}
+	if err := json.Unmarshal(body, &request); err != nil {
+		t.Fatalf("%s returned invalid JSON: %v: %s", method, err, body)
+	}
+	return request
+}
+
+// containsValue reports whether a decoded JSON value contains want at any
+// depth; want is a value compared for equality or a string predicate.
+func containsValue(v interface{}, want interface{}) bool {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for _, item := range v {
+			if containsValue(item, want) {
+				return true
+			}
+		}
+		return false
+	case []interface{}:
+		for _, item := range v {
+			if containsValue(item, want) {
+				return true
+			}
+		}
+		return false
+	}
+	if match, ok := want.(func(string) bool); ok {
+		s, isString := v.(string)
+		return isString && match(s)
+	}
+	return fmt.Sprint(v) == fmt.Sprint(want)
+}
@@ -0,0 +1,67 @@
+package providertest
+
+import (
+	"testing"
+
+	"github.com/teilomillet/gollm/providers"
+)
+
+var openAIChunks = [][]byte{
+	[]byte(`{"choices":[{"delta":{"role":"assistant","content":""}}]}`),
+	[]byte(`{"choices":[{"delta":{"content":"Hel"}}]}`),
+	[]byte(`{"choices":[{"delta":{"content":"lo"}}]}`),
+	[]byte(`{"choices":[{"delta":{},"finish_reason":"stop"}]}`),
+	[]byte(`[DONE]`),
+}
+
+func TestBuiltinProviders(t *testing.T) {
+	openAIResponse := []byte(`{"choices":[{"message":{"role":"assistant","content":"Hello"}}]}`)
+	openAIError := []byte(`{"error":{"message":"Invalid model","type":"invalid_request_error"}}`)
+
+	for _, cfg := range []Config{
+		{New: providers.NewOpenAIProvider, Name: "openai", Model: "gpt-4o-mini", Response: openAIResponse, ResponseText: "Hello", ErrorResponse: openAIError, StreamChunks: openAIChunks, StreamText: "Hello"},
+		{New: providers.NewGroqProvider, Name: "groq", Model: "llama-3.1-8b-instant", Response: openAIResponse, ResponseText: "Hello", ErrorResponse: openAIError, StreamChunks: openAIChunks, StreamText: "Hello"},
+		{New: providers.NewDeepSeekProvider, Name: "deepseek", Model: "deepseek-chat", Response: openAIResponse, ResponseText: "Hello", ErrorResponse: openAIError, StreamChunks: openAIChunks, StreamText: "Hello"},
+		{New: providers.NewLlamaCppProvider, Name: "llamacpp", Model: "local", Response: openAIResponse, ResponseText: "Hello", ErrorResponse: openAIError, StreamChunks: openAIChunks, StreamText: "Hello"},
+		{New: providers.NewOpenRouterProvider, Model: "openai/gpt-4o-mini", Response: openAIResponse, ResponseText: "Hello", ErrorResponse: openAIError, StreamChunks: openAIChunks, StreamText: "Hello"},
+		{New: providers.NewMistralProvider, Name: "mistral", Model: "mistral-small-latest", Response: openAIResponse, ResponseText: "Hello", ErrorResponse: openAIError, StreamChunks: openAIChunks, StreamText: "Hello"},
+		{
+			New: providers.NewAnthropicProvider, Name: "anthropic", Model: "claude-3-5-haiku-latest",
+			Response:      []byte(`{"type":"message","role":"assistant","content":[{"type":"text","text":"Hello"}],"stop_reason":"end_turn"}`),
+			ResponseText:  "Hello",
+			ErrorResponse: []byte(`{"type":"error","error":{"type":"invalid_request_error","message":"Invalid model"}}`),
+			StreamChunks: [][]byte{
+				[]byte(`{"type":"message_start","message":{"role":"assistant"}}`),
+				[]byte(`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hel"}}`),
+				[]byte(`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"lo"}}`),
+				[]byte(`{"type":"message_stop"}`),
+			},
+			StreamText: "Hello",
+		},
+		{
+			New: providers.NewCohereProvider, Name: "cohere", Model: "command-r",
+			Response:     []byte(`{"message":{"role":"assistant","content":[{"type":"text","text":"Hello"}]}}`),
+			ResponseText: "Hello",
+		},
+		{
+			New: providers.NewOllamaProvider, Name: "ollama", Model: "llama3",
+			Response:      []byte(`{"model":"llama3","response":"Hello","done":true}`),
+			ResponseText:  "Hello",
+			ErrorResponse: []byte(`{"error":"model 'llama3' not found"}`),
+			StreamChunks: [][]byte{
+				[]byte(`{"response":"Hel","done":false}`),
+				[]byte(`{"response":"lo","done":false}`),
+				[]byte(`{"response":"","done":true}`),
+			},
+			StreamText: "Hello",
+		},
+		{New: providers.NewMockProvider, Name: "mock", Model: "mock", Response: []byte(`{"content":"Hello"}`), ResponseText: "Hello", ErrorResponse: []byte(`{"error":"boom"}`)},
+	} {
+		cfg := cfg
+		name := cfg.Name
+		if name == "" {
+			name = cfg.New("", cfg.Model, nil).Name()
+		}
+		t.Run(name, func(t *testing.T) { Run(t, cfg) })
+	}
+}
@@ -0,0 +1,85 @@
+// Package providers implements LLM provider interfaces and implementations.
+package providers
+
+import (
+	"encoding/json"
+	"net/url"
+
+	"github.com/teilomillet/gollm/utils"
+)
+
+// RealtimeOptions configures a realtime session.
+// Zero values are omitted from the session so provider defaults apply.
+type RealtimeOptions struct {
+	Model        string       // Realtime model; provider default if empty
+	Instructions string       // System instructions of the session
+	Voice        string       // Voice of the audio responses
+	Modalities   []string     // Response modalities, "text" and/or "audio"
+	Tools        []utils.Tool // Functions the model may call
+	Temperature  float64      // Sampling temperature
+}
+
+// Realtimer is implemented by providers that offer a realtime WebSocket API
+// for low-latency voice and text conversations. Like Transcriber, it is an
+// optional capability discovered through a type assertion.
+type Realtimer interface {
+	// RealtimeEndpoint returns the WebSocket URL of a session.
+	RealtimeEndpoint(opts RealtimeOptions) string
+
+	// RealtimeHeaders returns the headers of the WebSocket handshake.
+	RealtimeHeaders() map[string]string
+
+	// PrepareRealtimeSession builds the event configuring the session, sent
+	// once connected.
+	PrepareRealtimeSession(opts RealtimeOptions) ([]byte, error)
+}
+
+// RealtimeEndpoint returns the OpenAI Realtime WebSocket endpoint.
+// The model defaults to "gpt-4o-realtime-preview".
+func (p *OpenAIProvider) RealtimeEndpoint(opts RealtimeOptions) string {
+	model := opts.Model
+	if model == "" {
+		model = "gpt-4o-realtime-preview"
+	}
+	return "wss://api.openai.com/v1/realtime?model=" + url.QueryEscape(model)
+}
+
+// RealtimeHeaders returns the OpenAI headers with the realtime beta opt-in.
+func (p *OpenAIProvider) RealtimeHeaders() map[string]string {
+	headers := p.Headers()
+	delete(headers, "Content-Type")
+	headers["OpenAI-Beta"] = "realtime=v1"
+	return headers
+}
+
+// PrepareRealtimeSession builds an OpenAI session.update event.
+func (p *OpenAIProvider) PrepareRealtimeSession(opts RealtimeOptions) ([]byte, error) {
+	session := map[string]interface{}{}
+	if opts.Instructions != "" {
+		session["instructions"] = opts.Instructions
+	}
+	if opts.Voice != "" {
+		session["voice"] = opts.Voice
+	}
+	if len(opts.Modalities) > 0 {
+		session["modalities"] = opts.Modalities
+	}
+	if opts.Temperature != 0 {
+		session["temperature"] = opts.Temperature
+	}
+	if len(opts.Tools) > 0 {
+		// Realtime tools are flat, without the "function" wrapper of chat
+		tools := make([]map[string]interface{}, len(opts.Tools))
+		for i, tool := range opts.Tools {
+			tools[i] = map[string]interface{}{
+				"type":        "function",
+				"name":        tool.Function.Name,
+				"description": tool.Function.Description,
+				"parameters":  tool.Function.Parameters,
+			}
+		}
+		session["tools"] = tools
+		session["tool_choice"] = "auto"
+	}
+	return json.Marshal(map[string]interface{}{"type": "session.update", "session": session})
+}
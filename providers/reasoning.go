@@ -0,0 +1,69 @@
+package providers
+
+// minThinkingBudget is the smallest budget of Anthropic extended thinking.
+const minThinkingBudget = 1024
+
+// reasoningEffort maps a reasoning budget in tokens to the effort levels of
+// OpenAI reasoning models.
+func reasoningEffort(budget int) string {
+	switch {
+	case budget <= 2048:
+		return "low"
+	case budget <= 8192:
+		return "medium"
+	default:
+		return "high"
+	}
+}
+
+// supportsReasoning reports whether a model takes a reasoning budget,
+// according to DefaultModelCatalog. Unlisted models are assumed to, leaving
+// the last word to the API.
+func supportsReasoning(provider, model string) bool {
+	if info, ok := DefaultModelCatalog().Lookup(provider, model); ok {
+		return info.Has(CapabilityReasoning)
+	}
+	return true
+}
+
+// setReasoningEffort sets the reasoning effort of an OpenAI reasoning model.
+// These models take no temperature, and their token limit, which counts the
+// reasoning tokens, is max_completion_tokens.
+func setReasoningEffort(options map[string]interface{}, budget int) {
+	options["reasoning_effort"] = reasoningEffort(budget)
+	delete(options, "temperature")
+	if maxTokens, ok := options["max_tokens"]; ok {
+		delete(options, "max_tokens")
+		options["max_completion_tokens"] = maxTokens
+	}
+}
+
+// setThinking enables Anthropic extended thinking. The API requires
+// max_tokens to exceed the budget, so the budget is added to a smaller
+// limit, and rejects a modified temperature.
+func setThinking(options map[string]interface{}, budget int) {
+	if budget <= 0 {
+		return
+	}
+	if budget < minThinkingBudget {
+		budget = minThinkingBudget
+	}
+	options["thinking"] = map[string]interface{}{"type": "enabled", "budget_tokens": budget}
+	delete(options, "temperature")
+	maxTokens := 1024
+	if n, ok := toFloat(options["max_tokens"]); ok {
+		maxTokens = int(n)
+	}
+	if maxTokens <= budget {
+		options["max_tokens"] = budget + maxTokens
+	}
+}
+
+// setOpenRouterReasoning sets OpenRouter's unified reasoning parameter.
+func setOpenRouterReasoning(options map[string]interface{}, budget int) {
+	if budget <= 0 {
+		options["reasoning"] = map[string]interface{}{"enabled": false}
+		return
+	}
+	options["reasoning"] = map[string]interface{}{"max_tokens": budget}
+}
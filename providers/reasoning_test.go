@@ -0,0 +1,102 @@
+package providers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/teilomillet/gollm/config"
+	"github.com/teilomillet/gollm/types"
+)
+
+func TestReasoningBudget(t *testing.T) {
+	budget := 4000
+	cfg := &config.Config{Temperature: 0.7, MaxTokens: 1000, ReasoningBudget: &budget}
+
+	t.Run("Anthropic", func(t *testing.T) {
+		p := NewAnthropicProvider("key", "claude-3-7-sonnet-latest", nil).(*AnthropicProvider)
+		p.SetDefaultOptions(cfg)
+		assert.Equal(t, map[string]interface{}{
+			"thinking":   map[string]interface{}{"type": "enabled", "budget_tokens": 4000},
+			"max_tokens": 5000,
+		}, p.options)
+
+		small := 100
+		p = NewAnthropicProvider("key", "claude-sonnet-4-0", nil).(*AnthropicProvider)
+		p.SetDefaultOptions(&config.Config{MaxTokens: 2000, ReasoningBudget: &small})
+		assert.Equal(t, map[string]interface{}{"type": "enabled", "budget_tokens": 1024}, p.options["thinking"], "unlisted models get the minimum budget")
+		assert.Equal(t, 2000, p.options["max_tokens"])
+
+		// A temperature set on the LLM is passed with the request options
+		body, err := p.PrepareRequest("Hi", map[string]interface{}{"temperature": 0.7})
+		assert.NoError(t, err)
+		assert.NotContains(t, string(body), "temperature", "thinking rejects a temperature")
+		body, err = p.PrepareRequestWithMessages([]types.MemoryMessage{{Role: "user", Content: "Hi"}}, map[string]interface{}{"temperature": 0.7})
+		assert.NoError(t, err)
+		assert.NotContains(t, string(body), "temperature")
+
+		// The thinking set on the provider applies to every kind of request
+		prepared := map[string]func() ([]byte, error){
+			"Stream": func() ([]byte, error) { return p.PrepareStreamRequest("Hi", nil) },
+			"Schema": func() ([]byte, error) {
+				return p.PrepareRequestWithSchema("Hi", nil, map[string]interface{}{"type": "object"})
+			},
+			"Messages": func() ([]byte, error) {
+				return p.PrepareRequestWithMessages([]types.MemoryMessage{{Role: "user", Content: "Hi"}}, nil)
+			},
+		}
+		for name, prepare := range prepared {
+			body, err := prepare()
+			assert.NoError(t, err, name)
+			assert.Contains(t, string(body), `"thinking":{"budget_tokens":1024,"type":"enabled"}`, name)
+		}
+	})
+
+	t.Run("OpenAI", func(t *testing.T) {
+		p := NewOpenAIProvider("key", "o3-mini", nil).(*OpenAIProvider)
+		p.SetDefaultOptions(cfg)
+		assert.Equal(t, map[string]interface{}{"reasoning_effort": "medium", "max_completion_tokens": 1000}, p.options)
+		body, err := p.PrepareRequest("Hi", map[string]interface{}{"temperature": 0.7})
+		assert.NoError(t, err)
+		assert.NotContains(t, string(body), "temperature")
+
+		prepared := map[string]func() ([]byte, error){
+			"Stream": func() ([]byte, error) { return p.PrepareStreamRequest("Hi", nil) },
+			"Schema": func() ([]byte, error) {
+				return p.PrepareRequestWithSchema("Hi", nil, map[string]interface{}{"type": "object"})
+			},
+			"Messages": func() ([]byte, error) {
+				return p.PrepareRequestWithMessages([]types.MemoryMessage{{Role: "user", Content: "Hi"}}, nil)
+			},
+		}
+		for name, prepare := range prepared {
+			body, err := prepare()
+			assert.NoError(t, err, name)
+			assert.Contains(t, string(body), `"reasoning_effort":"medium"`, name)
+			assert.Contains(t, string(body), `"max_completion_tokens":1000`, name)
+		}
+	})
+
+	t.Run("NotReasoning", func(t *testing.T) {
+		p := NewOpenAIProvider("key", "gpt-4o", nil).(*OpenAIProvider)
+		p.SetDefaultOptions(cfg)
+		assert.Equal(t, map[string]interface{}{"temperature": 0.7, "max_tokens": 1000}, p.options)
+		body, err := p.PrepareRequest("Hi", map[string]interface{}{"temperature": 0.2})
+		assert.NoError(t, err)
+		assert.Contains(t, string(body), `"temperature":0.2`)
+	})
+
+	t.Run("OpenRouter", func(t *testing.T) {
+		p := NewOpenRouterProvider("key", "anthropic/claude-3.7-sonnet", nil).(*OpenRouterProvider)
+		p.SetDefaultOptions(cfg)
+		assert.Equal(t, map[string]interface{}{"max_tokens": 4000}, p.options["reasoning"])
+	})
+
+	t.Run("DeepSeek", func(t *testing.T) {
+		p := NewDeepSeekProvider("key", "deepseek-reasoner", nil).(*DeepSeekProvider)
+		p.SetDefaultOptions(cfg)
+		assert.NotContains(t, p.openai.options, "reasoning_effort")
+	})
+
+	assert.Equal(t, "low", reasoningEffort(0))
+	assert.Equal(t, "high", reasoningEffort(16000))
+}
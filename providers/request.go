@@ -0,0 +1,238 @@
+package providers
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/teilomillet/gollm/types"
+	"github.com/teilomillet/gollm/utils"
+)
+
+// internalOptions are options consumed by providers and the llm package to
+// build requests, never sent to APIs as is.
+var internalOptions = map[string]bool{
+	"system_prompt":       true,
+	"tools":               true,
+	"tool_choice":         true,
+	"images":              true,
+	"documents":           true,
+	"enable_caching":      true,
+	"structured_messages": true,
+}
+
+// marshalRequest marshals a typed request body followed by the options
+// passed through to the API, later option maps taking precedence. Options
+// naming a field the request sets, such as model or messages, are dropped:
+// the provider owns those fields, and an option must not silently replace
+// them. Fields left empty with omitempty can be set by options.
+func marshalRequest(request interface{}, options ...map[string]interface{}) ([]byte, error) {
+	data, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	set := setFields(request)
+	extra := make(map[string]interface{})
+	for _, opts := range options {
+		for k, v := range opts {
+			if !set[k] && !internalOptions[k] {
+				extra[k] = v
+			}
+		}
+	}
+	dropTemperature(extra)
+	if len(extra) == 0 {
+		return data, nil
+	}
+	extraData, err := json.Marshal(extra)
+	if err != nil {
+		return nil, err
+	}
+
+	// Splice the two objects: {"model":...} and {"temperature":...}
+	body := make([]byte, 0, len(data)+len(extraData))
+	body = append(body, data[:len(data)-1]...)
+	if len(data) > 2 {
+		body = append(body, ',')
+	}
+	return append(body, extraData[1:]...), nil
+}
+
+// dropTemperature removes the temperature of requests with extended thinking
+// or a reasoning effort, which the APIs reject along with a temperature.
+// Thinking is set by the provider while the temperature may come from any
+// option map, so the conflict is resolved on the merged options.
+func dropTemperature(options map[string]interface{}) {
+	thinking, _ := options["thinking"].(map[string]interface{})
+	_, reasoning := options["reasoning_effort"]
+	if thinking["type"] == "enabled" || reasoning {
+		delete(options, "temperature")
+	}
+}
+
+// requestField is a JSON field of a request struct.
+type requestField struct {
+	index     int
+	name      string
+	omitEmpty bool
+}
+
+var requestFields sync.Map // reflect.Type -> []requestField
+
+// setFields returns the JSON names of the fields of a request struct that
+// appear in its JSON encoding.
+func setFields(request interface{}) map[string]bool {
+	v := reflect.Indirect(reflect.ValueOf(request))
+	fields, ok := requestFields.Load(v.Type())
+	if !ok {
+		var parsed []requestField
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Type().Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "-" && opts == "" {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			parsed = append(parsed, requestField{index: i, name: name, omitEmpty: strings.Contains(opts, "omitempty")})
+		}
+		fields, _ = requestFields.LoadOrStore(v.Type(), parsed)
+	}
+
+	set := make(map[string]bool)
+	for _, f := range fields.([]requestField) {
+		if !f.omitEmpty || !isEmptyValue(v.Field(f.index)) {
+			set[f.name] = true
+		}
+	}
+	return set
+}
+
+// isEmptyValue reports whether encoding/json omits the value of an
+// omitempty field.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Interface, reflect.Pointer:
+		return v.IsNil()
+	default:
+		return v.IsZero()
+	}
+}
+
+// chatRequest is the body of an OpenAI-compatible chat completion request.
+// Other parameters, such as temperature, are passed through as options.
+type chatRequest struct {
+	Model          string        `json:"model"`
+	Messages       []chatMessage `json:"messages"`
+	Stream         bool          `json:"stream,omitempty"`
+	StreamOptions  interface{}   `json:"stream_options,omitempty"`
+	Tools          interface{}   `json:"tools,omitempty"`
+	ToolChoice     interface{}   `json:"tool_choice,omitempty"`
+	ResponseFormat interface{}   `json:"response_format,omitempty"`
+}
+
+// chatMessage is a message of a chat completion request. Metadata holds
+// additional fields of the message, such as tool_call_id.
+type chatMessage struct {
+	Role     string                 `json:"role"`
+	Content  interface{}            `json:"content"`
+	Metadata map[string]interface{} `json:"-"`
+}
+
+// MarshalJSON encodes the message with its metadata fields.
+func (m chatMessage) MarshalJSON() ([]byte, error) {
+	type message chatMessage // Without the MarshalJSON method
+	return marshalRequest(message(m), m.Metadata)
+}
+
+// chatMessages converts memory messages, with their metadata, to chat
+// messages.
+func chatMessages(messages []types.MemoryMessage) []chatMessage {
+	converted := make([]chatMessage, len(messages))
+	for i, msg := range messages {
+		converted[i] = chatMessage{Role: msg.Role, Content: msg.Content, Metadata: msg.Metadata}
+	}
+	return converted
+}
+
+// chatTool is a function tool of a chat completion request.
+type chatTool struct {
+	Type     string         `json:"type"`
+	Function utils.Function `json:"function"`
+	Strict   bool           `json:"strict,omitempty"`
+}
+
+// chatTools returns the tools and tool choice options of a chat completion
+// request. Tools given as utils.Tool are sent in strict mode; other values
+// are sent as is.
+func chatTools(options map[string]interface{}) (tools, toolChoice interface{}) {
+	tools = options["tools"]
+	if list, ok := tools.([]utils.Tool); ok {
+		if len(list) == 0 {
+			tools = nil
+		} else {
+			converted := make([]chatTool, len(list))
+			for i, tool := range list {
+				converted[i] = chatTool{Type: "function", Function: tool.Function, Strict: true}
+			}
+			tools = converted
+		}
+	}
+	return tools, openAIToolChoice(options["tool_choice"])
+}
+
+// newChatRequest builds the chat completion request of a single prompt,
+// preceded by the system prompt of the options, with their images as content
+// parts and their tools sent as is. Documents are rejected.
+func newChatRequest(model, prompt string, options map[string]interface{}) (chatRequest, error) {
+	if err := rejectDocuments(options); err != nil {
+		return chatRequest{}, err
+	}
+	var messages []chatMessage
+	if systemPrompt, ok := options["system_prompt"].(string); ok && systemPrompt != "" {
+		messages = append(messages, chatMessage{Role: "system", Content: systemPrompt})
+	}
+	userContent, err := openAIImageContent(prompt, imagesFromOptions(options))
+	if err != nil {
+		return chatRequest{}, err
+	}
+	messages = append(messages, chatMessage{Role: "user", Content: userContent})
+	return chatRequest{
+		Model:      model,
+		Messages:   messages,
+		Tools:      options["tools"],
+		ToolChoice: openAIToolChoice(options["tool_choice"]),
+	}, nil
+}
+
+// attachImages sets the images of the options as content parts of the most
+// recent user message. Documents are rejected.
+func attachImages(messages []chatMessage, options map[string]interface{}) error {
+	if err := rejectDocuments(options); err != nil {
+		return err
+	}
+	images := imagesFromOptions(options)
+	if len(images) == 0 {
+		return nil
+	}
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role != "user" {
+			continue
+		}
+		text, _ := messages[i].Content.(string)
+		content, err := openAIImageContent(text, images)
+		if err != nil {
+			return err
+		}
+		messages[i].Content = content
+		return nil
+	}
+	return nil
+}
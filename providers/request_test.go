@@ -0,0 +1,66 @@
+package providers
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm/types"
+)
+
+func TestMarshalRequest(t *testing.T) {
+	t.Run("OptionsCannotReplaceFields", func(t *testing.T) {
+		request := chatRequest{Model: "gpt-4o", Messages: []chatMessage{{Role: "user", Content: "hi"}}}
+		body, err := marshalRequest(request, map[string]interface{}{"model": "other", "temperature": 0.5},
+			map[string]interface{}{"messages": "injected", "system_prompt": "hidden", "temperature": 0.2})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}],"temperature":0.2}`, string(body))
+	})
+
+	t.Run("OptionsSetEmptyFields", func(t *testing.T) {
+		body, err := marshalRequest(chatRequest{Model: "gpt-4o"}, map[string]interface{}{
+			"response_format": map[string]string{"type": "json_object"},
+		})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"model":"gpt-4o","messages":null,"response_format":{"type":"json_object"}}`, string(body))
+	})
+
+	t.Run("MessageMetadata", func(t *testing.T) {
+		body, err := json.Marshal(chatMessage{Role: "tool", Content: "42", Metadata: map[string]interface{}{"tool_call_id": "call_1", "role": "user"}})
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"role":"tool","content":"42","tool_call_id":"call_1"}`, string(body))
+	})
+}
+
+func TestPrepareRequestKeepsModelAndMessages(t *testing.T) {
+	options := map[string]interface{}{"model": "injected", "messages": []string{"injected"}, "temperature": 0.3}
+	for _, p := range []Provider{
+		NewOpenAIProvider("key", "model", nil),
+		NewAnthropicProvider("key", "model", nil),
+		NewGroqProvider("key", "model", nil),
+		NewMistralProvider("key", "model", nil),
+		NewCohereProvider("key", "model", nil),
+	} {
+		t.Run(p.Name(), func(t *testing.T) {
+			for name, prepare := range map[string]func() ([]byte, error){
+				"Prompt": func() ([]byte, error) { return p.PrepareRequest("hello", options) },
+				"Stream": func() ([]byte, error) { return p.PrepareStreamRequest("hello", options) },
+				"Messages": func() ([]byte, error) {
+					return p.PrepareRequestWithMessages([]types.MemoryMessage{{Role: "user", Content: "hello"}}, options)
+				},
+			} {
+				if name == "Messages" && p.Name() == "cohere" {
+					continue // Cohere takes a history, not messages
+				}
+				body, err := prepare()
+				require.NoError(t, err, name)
+				var request map[string]interface{}
+				require.NoError(t, json.Unmarshal(body, &request), name)
+				assert.Equal(t, "model", request["model"], name)
+				assert.NotContains(t, string(body), "injected", name)
+				assert.Equal(t, 0.3, request["temperature"], name)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+// Package providers implements LLM provider interfaces and implementations.
+package providers
+
+import (
+	"encoding/json"
+)
+
+// SpeechOptions configures a text-to-speech request.
+// Zero values are omitted from the request so provider defaults apply.
+type SpeechOptions struct {
+	Voice  string  // Voice name or ID (e.g., "alloy" for OpenAI, a voice ID for ElevenLabs)
+	Model  string  // Speech model (e.g., "tts-1"); provider default if empty
+	Format string  // Audio output format (e.g., "mp3", "wav", "mp3_44100_128")
+	Speed  float64 // Playback speed multiplier, where supported
+}
+
+// Speaker is implemented by providers that offer text-to-speech.
+// Like Transcriber, it is an optional capability discovered through a type assertion.
+// The response body of a speech request is the raw audio.
+type Speaker interface {
+	// SpeechEndpoint returns the URL for speech requests.
+	SpeechEndpoint(opts SpeechOptions) string
+
+	// PrepareSpeechRequest builds the JSON request body for synthesizing text.
+	PrepareSpeechRequest(text string, opts SpeechOptions) ([]byte, error)
+}
+
+// SpeechEndpoint returns the OpenAI text-to-speech endpoint.
+func (p *OpenAIProvider) SpeechEndpoint(opts SpeechOptions) string {
+	return "https://api.openai.com/v1/audio/speech"
+}
+
+// PrepareSpeechRequest builds an OpenAI TTS request.
+// The model defaults to "tts-1" and the voice to "alloy".
+func (p *OpenAIProvider) PrepareSpeechRequest(text string, opts SpeechOptions) ([]byte, error) {
+	model := opts.Model
+	if model == "" {
+		model = "tts-1"
+	}
+	voice := opts.Voice
+	if voice == "" {
+		voice = "alloy"
+	}
+
+	requestBody := map[string]interface{}{
+		"model": model,
+		"input": text,
+		"voice": voice,
+	}
+	if opts.Format != "" {
+		requestBody["response_format"] = opts.Format
+	}
+	if opts.Speed > 0 {
+		requestBody["speed"] = opts.Speed
+	}
+	return json.Marshal(requestBody)
+}
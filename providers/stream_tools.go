@@ -0,0 +1,125 @@
+// Package providers implements LLM provider interfaces and implementations.
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/teilomillet/gollm/utils"
+)
+
+// ToolCallDelta is an incremental piece of a tool call in a streaming response.
+// The first delta of a call carries its ID and name; later deltas append to
+// its arguments, which are only valid JSON once the call is complete.
+type ToolCallDelta struct {
+	Index     int    // Position of the call in the response; deltas of the same call share it
+	ID        string // Call ID, set on the first delta of the call
+	Name      string // Tool name, set on the first delta of the call
+	Arguments string // Fragment of the JSON arguments to append
+}
+
+// ToolCallStreamer is implemented by providers that report tool calls
+// incrementally while streaming. Like Transcriber, it is an optional
+// capability discovered through a type assertion.
+type ToolCallStreamer interface {
+	// ParseStreamToolCalls extracts the tool call deltas of a stream chunk.
+	// Chunks without tool calls return no deltas and no error.
+	ParseStreamToolCalls(chunk []byte) ([]ToolCallDelta, error)
+}
+
+// parseChatCompletionToolCalls extracts tool call deltas from an
+// OpenAI-style chat completion chunk, as sent by OpenAI and Mistral. Mistral
+// sends each call whole and may omit the index, in which case the position in
+// the chunk is used.
+func parseChatCompletionToolCalls(chunk []byte) ([]ToolCallDelta, error) {
+	var response struct {
+		Choices []struct {
+			Delta struct {
+				ToolCalls []struct {
+					Index    *int   `json:"index"`
+					ID       string `json:"id"`
+					Function struct {
+						Name      string `json:"name"`
+						Arguments string `json:"arguments"`
+					} `json:"function"`
+				} `json:"tool_calls"`
+			} `json:"delta"`
+		} `json:"choices"`
+	}
+	if err := json.Unmarshal(chunk, &response); err != nil {
+		return nil, fmt.Errorf("malformed response: %w", err)
+	}
+	if len(response.Choices) == 0 {
+		return nil, nil
+	}
+
+	var deltas []ToolCallDelta
+	for i, call := range response.Choices[0].Delta.ToolCalls {
+		index := i
+		if call.Index != nil {
+			index = *call.Index
+		}
+		deltas = append(deltas, ToolCallDelta{
+			Index:     index,
+			ID:        call.ID,
+			Name:      call.Function.Name,
+			Arguments: call.Function.Arguments,
+		})
+	}
+	return deltas, nil
+}
+
+// ParseStreamToolCalls extracts tool call deltas from an OpenAI stream chunk.
+func (p *OpenAIProvider) ParseStreamToolCalls(chunk []byte) ([]ToolCallDelta, error) {
+	return parseChatCompletionToolCalls(chunk)
+}
+
+// ParseStreamToolCalls extracts tool calls from a Mistral stream chunk.
+func (p *MistralProvider) ParseStreamToolCalls(chunk []byte) ([]ToolCallDelta, error) {
+	return parseChatCompletionToolCalls(chunk)
+}
+
+// ParseStreamToolCalls extracts tool call deltas from Anthropic stream events.
+// A tool_use content block start carries the call ID and name, and each
+// input_json_delta carries a fragment of the arguments. The index is the
+// content block index, which counts text blocks too.
+func (p *AnthropicProvider) ParseStreamToolCalls(chunk []byte) ([]ToolCallDelta, error) {
+	var event struct {
+		Type         string `json:"type"`
+		Index        int    `json:"index"`
+		ContentBlock struct {
+			Type string `json:"type"`
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"content_block"`
+		Delta struct {
+			Type        string `json:"type"`
+			PartialJSON string `json:"partial_json"`
+		} `json:"delta"`
+	}
+	if err := json.Unmarshal(chunk, &event); err != nil {
+		return nil, fmt.Errorf("malformed event: %w", err)
+	}
+
+	switch {
+	case event.Type == "content_block_start" && event.ContentBlock.Type == "tool_use":
+		return []ToolCallDelta{{Index: event.Index, ID: event.ContentBlock.ID, Name: event.ContentBlock.Name}}, nil
+	case event.Type == "content_block_delta" && event.Delta.Type == "input_json_delta":
+		return []ToolCallDelta{{Index: event.Index, Arguments: event.Delta.PartialJSON}}, nil
+	default:
+		return nil, nil
+	}
+}
+
+// anthropicTools converts tool definitions to Anthropic's format.
+func anthropicTools(tools []utils.Tool) []map[string]interface{} {
+	converted := make([]map[string]interface{}, len(tools))
+	for i, tool := range tools {
+		converted[i] = map[string]interface{}{
+			"name":         tool.Function.Name,
+			"description":  tool.Function.Description,
+			"input_schema": tool.Function.Parameters,
+		}
+	}
+	return converted
+}
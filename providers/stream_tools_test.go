@@ -0,0 +1,68 @@
+package providers
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm/utils"
+)
+
+func TestParseStreamToolCalls(t *testing.T) {
+	t.Run("OpenAI", func(t *testing.T) {
+		p := NewOpenAIProvider("fake-key", "gpt-4o", nil).(ToolCallStreamer)
+		deltas, err := p.ParseStreamToolCalls([]byte(`{"choices":[{"delta":{"role":"assistant","content":null,"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"get_weather","arguments":""}}]}}]}`))
+		require.NoError(t, err)
+		assert.Equal(t, []ToolCallDelta{{Index: 0, ID: "call_1", Name: "get_weather"}}, deltas)
+
+		deltas, err = p.ParseStreamToolCalls([]byte(`{"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"city\":"}}]}}]}`))
+		require.NoError(t, err)
+		assert.Equal(t, []ToolCallDelta{{Index: 0, Arguments: `{"city":`}}, deltas)
+
+		deltas, err = p.ParseStreamToolCalls([]byte(`{"choices":[{"delta":{"content":"Hello"}}]}`))
+		require.NoError(t, err)
+		assert.Empty(t, deltas)
+	})
+
+	t.Run("Mistral", func(t *testing.T) {
+		p := NewMistralProvider("fake-key", "mistral-large-latest", nil).(ToolCallStreamer)
+		deltas, err := p.ParseStreamToolCalls([]byte(`{"choices":[{"delta":{"tool_calls":[{"id":"a1","function":{"name":"search","arguments":"{\"q\":\"go\"}"}},{"id":"a2","function":{"name":"time","arguments":"{}"}}]}}]}`))
+		require.NoError(t, err)
+		assert.Equal(t, []ToolCallDelta{
+			{Index: 0, ID: "a1", Name: "search", Arguments: `{"q":"go"}`},
+			{Index: 1, ID: "a2", Name: "time", Arguments: `{}`},
+		}, deltas)
+	})
+
+	t.Run("Anthropic", func(t *testing.T) {
+		p := NewAnthropicProvider("fake-key", "claude-3-5-sonnet-latest", nil).(ToolCallStreamer)
+		deltas, err := p.ParseStreamToolCalls([]byte(`{"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_1","name":"get_weather","input":{}}}`))
+		require.NoError(t, err)
+		assert.Equal(t, []ToolCallDelta{{Index: 1, ID: "toolu_1", Name: "get_weather"}}, deltas)
+
+		deltas, err = p.ParseStreamToolCalls([]byte(`{"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"city\": \"Par"}}`))
+		require.NoError(t, err)
+		assert.Equal(t, []ToolCallDelta{{Index: 1, Arguments: `{"city": "Par`}}, deltas)
+
+		deltas, err = p.ParseStreamToolCalls([]byte(`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hi"}}`))
+		require.NoError(t, err)
+		assert.Empty(t, deltas)
+	})
+}
+
+func TestAnthropicStreamRequestTools(t *testing.T) {
+	p := NewAnthropicProvider("fake-key", "claude-3-5-sonnet-latest", nil)
+	tools := []utils.Tool{{Type: "function", Function: utils.Function{Name: "get_weather", Parameters: map[string]interface{}{"type": "object"}}}}
+	body, err := p.PrepareStreamRequest("Weather in Paris?", map[string]interface{}{"tools": tools})
+	require.NoError(t, err)
+
+	var req map[string]interface{}
+	require.NoError(t, json.Unmarshal(body, &req))
+	assert.Equal(t, []interface{}{map[string]interface{}{
+		"name":         "get_weather",
+		"description":  "",
+		"input_schema": map[string]interface{}{"type": "object"},
+	}}, req["tools"])
+	assert.Equal(t, map[string]interface{}{"type": "auto"}, req["tool_choice"])
+}
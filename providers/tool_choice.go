@@ -0,0 +1,137 @@
+package providers
+
+import (
+	"strings"
+
+	"github.com/teilomillet/gollm/utils"
+)
+
+// Tool choice modes of the "tool_choice" option. Any other string names the
+// tool the model must call. Each provider translates the option to its own
+// semantics; values other than a mode, a tool name or a {"type": ...} map are
+// sent as is.
+const (
+	ToolChoiceAuto     = "auto"     // The model decides whether to call tools
+	ToolChoiceNone     = "none"     // The model must not call tools
+	ToolChoiceRequired = "required" // The model must call at least one tool
+)
+
+// toolChoice is a provider-neutral tool_choice option: a mode, or the name
+// of the tool to call.
+type toolChoice struct {
+	mode string
+	name string
+}
+
+// parseToolChoice reads the tool_choice option given as a mode or tool name,
+// or in the OpenAI or Anthropic format. It returns false for other values,
+// e.g. with provider-specific settings, which are sent as is.
+func parseToolChoice(value interface{}) (toolChoice, bool) {
+	var fields map[string]interface{}
+	switch v := value.(type) {
+	case string:
+		return toolChoiceOf(v)
+	case map[string]interface{}:
+		fields = v
+	case map[string]string:
+		fields = make(map[string]interface{}, len(v))
+		for k, s := range v {
+			fields[k] = s
+		}
+	default:
+		return toolChoice{}, false
+	}
+
+	kind, _ := fields["type"].(string)
+	switch {
+	case kind == "function" && len(fields) == 2:
+		// OpenAI: {"type": "function", "function": {"name": "..."}}
+		function, _ := fields["function"].(map[string]interface{})
+		if name, ok := function["name"].(string); ok && name != "" {
+			return toolChoice{name: name}, true
+		}
+	case kind == "tool" && len(fields) == 2:
+		// Anthropic: {"type": "tool", "name": "..."}
+		if name, ok := fields["name"].(string); ok && name != "" {
+			return toolChoice{name: name}, true
+		}
+	case len(fields) == 1:
+		// {"type": mode} as set by WithToolChoice, including a tool name
+		return toolChoiceOf(kind)
+	}
+	return toolChoice{}, false
+}
+
+// toolChoiceOf reads a mode or tool name. Anthropic's "any" is the required
+// mode.
+func toolChoiceOf(s string) (toolChoice, bool) {
+	switch s {
+	case "":
+		return toolChoice{}, false
+	case ToolChoiceAuto, ToolChoiceNone, ToolChoiceRequired:
+		return toolChoice{mode: s}, true
+	case "any":
+		return toolChoice{mode: ToolChoiceRequired}, true
+	default:
+		return toolChoice{name: s}, true
+	}
+}
+
+// openAIToolChoice translates the tool_choice option for OpenAI-compatible
+// APIs, which take the mode as a string and a tool as a function object.
+func openAIToolChoice(value interface{}) interface{} {
+	choice, ok := parseToolChoice(value)
+	switch {
+	case !ok:
+		return value
+	case choice.name != "":
+		return map[string]interface{}{"type": "function", "function": map[string]interface{}{"name": choice.name}}
+	default:
+		return choice.mode
+	}
+}
+
+// anthropicToolChoice translates the tool_choice option for Anthropic, which
+// calls the required mode "any". It defaults to auto.
+func anthropicToolChoice(value interface{}) interface{} {
+	if value == nil {
+		return map[string]interface{}{"type": ToolChoiceAuto}
+	}
+	choice, ok := parseToolChoice(value)
+	switch {
+	case !ok:
+		return value
+	case choice.name != "":
+		return map[string]interface{}{"type": "tool", "name": choice.name}
+	case choice.mode == ToolChoiceRequired:
+		return map[string]interface{}{"type": "any"}
+	default:
+		return map[string]interface{}{"type": choice.mode}
+	}
+}
+
+// cohereToolChoice translates the tool_choice option for Cohere, which only
+// takes REQUIRED and NONE, auto being the default. A named tool is forced by
+// requiring a tool call and sending only that tool.
+func cohereToolChoice(value, tools interface{}) (interface{}, interface{}) {
+	choice, ok := parseToolChoice(value)
+	switch {
+	case !ok:
+		return value, tools
+	case choice.name != "":
+		if list, ok := tools.([]utils.Tool); ok {
+			var named []utils.Tool
+			for _, tool := range list {
+				if tool.Function.Name == choice.name {
+					named = append(named, tool)
+				}
+			}
+			tools = named
+		}
+		return "REQUIRED", tools
+	case choice.mode == ToolChoiceAuto:
+		return nil, tools
+	default:
+		return strings.ToUpper(choice.mode), tools
+	}
+}
@@ -0,0 +1,69 @@
+package providers
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm/utils"
+)
+
+func TestToolChoice(t *testing.T) {
+	tools := []utils.Tool{
+		{Type: "function", Function: utils.Function{Name: "get_weather", Parameters: map[string]interface{}{"type": "object"}}},
+		{Type: "function", Function: utils.Function{Name: "get_time", Parameters: map[string]interface{}{"type": "object"}}},
+	}
+	prepare := func(t *testing.T, p Provider, choice interface{}) map[string]interface{} {
+		options := map[string]interface{}{"tools": tools}
+		if choice != nil {
+			options["tool_choice"] = choice
+		}
+		body, err := p.PrepareRequest("What's the weather?", options)
+		require.NoError(t, err)
+		var request map[string]interface{}
+		require.NoError(t, json.Unmarshal(body, &request))
+		return request
+	}
+
+	for _, tc := range []struct {
+		choice                    interface{}
+		openAI, anthropic, cohere string
+	}{
+		{ToolChoiceAuto, `"auto"`, `{"type":"auto"}`, `null`},
+		{ToolChoiceNone, `"none"`, `{"type":"none"}`, `"NONE"`},
+		{ToolChoiceRequired, `"required"`, `{"type":"any"}`, `"REQUIRED"`},
+		{"any", `"required"`, `{"type":"any"}`, `"REQUIRED"`},
+		{"get_weather", `{"type":"function","function":{"name":"get_weather"}}`, `{"type":"tool","name":"get_weather"}`, `"REQUIRED"`},
+		{map[string]interface{}{"type": "get_weather"}, `{"type":"function","function":{"name":"get_weather"}}`, `{"type":"tool","name":"get_weather"}`, `"REQUIRED"`},
+		{map[string]interface{}{"type": "tool", "name": "get_weather"}, `{"type":"function","function":{"name":"get_weather"}}`, `{"type":"tool","name":"get_weather"}`, `"REQUIRED"`},
+	} {
+		name, _ := json.Marshal(tc.choice)
+		t.Run(string(name), func(t *testing.T) {
+			choice, _ := json.Marshal(prepare(t, NewOpenAIProvider("key", "gpt-4o", nil), tc.choice)["tool_choice"])
+			assert.JSONEq(t, tc.openAI, string(choice), "openai")
+
+			choice, _ = json.Marshal(prepare(t, NewAnthropicProvider("key", "claude", nil), tc.choice)["tool_choice"])
+			assert.JSONEq(t, tc.anthropic, string(choice), "anthropic")
+
+			request := prepare(t, NewCohereProvider("key", "command-r", nil), tc.choice)
+			choice, _ = json.Marshal(request["tool_choice"])
+			assert.JSONEq(t, tc.cohere, string(choice), "cohere")
+			if tc.cohere == `"REQUIRED"` && tc.choice != ToolChoiceRequired && tc.choice != "any" {
+				assert.Len(t, request["tools"], 1, "cohere is only sent the named tool")
+			}
+		})
+	}
+
+	t.Run("Default", func(t *testing.T) {
+		assert.NotContains(t, prepare(t, NewOpenAIProvider("key", "gpt-4o", nil), nil), "tool_choice")
+		choice, _ := json.Marshal(prepare(t, NewAnthropicProvider("key", "claude", nil), nil)["tool_choice"])
+		assert.JSONEq(t, `{"type":"auto"}`, string(choice))
+	})
+
+	t.Run("ProviderSpecific", func(t *testing.T) {
+		choice := map[string]interface{}{"type": "auto", "disable_parallel_tool_use": true}
+		sent, _ := json.Marshal(prepare(t, NewAnthropicProvider("key", "claude", nil), choice)["tool_choice"])
+		assert.JSONEq(t, `{"type":"auto","disable_parallel_tool_use":true}`, string(sent))
+	})
+}
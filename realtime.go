@@ -0,0 +1,70 @@
+// Package gollm provides realtime functionality for Language Learning Models.
+// This file contains type definitions and re-exports for low-latency voice and
+// text conversations over a provider's realtime WebSocket API.
+package gollm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/teilomillet/gollm/llm"
+)
+
+// Re-export realtime types from the llm package
+type (
+	// RealtimeSession is a realtime voice and text conversation over a WebSocket.
+	RealtimeSession = llm.RealtimeSession
+
+	// RealtimeEvent is a server event of a realtime session.
+	RealtimeEvent = llm.RealtimeEvent
+
+	// RealtimeEventType is the kind of a RealtimeEvent.
+	RealtimeEventType = llm.RealtimeEventType
+
+	// RealtimeOption configures a realtime session.
+	RealtimeOption = llm.RealtimeOption
+)
+
+// Kinds of realtime events.
+const (
+	RealtimeAudio        = llm.RealtimeAudio        // A chunk of response audio
+	RealtimeText         = llm.RealtimeText         // A delta of response text
+	RealtimeTranscript   = llm.RealtimeTranscript   // A delta of the transcript of response audio
+	RealtimeToolCall     = llm.RealtimeToolCall     // A complete function call
+	RealtimeResponseDone = llm.RealtimeResponseDone // The end of a response
+	RealtimeError        = llm.RealtimeError        // An error reported by the server or the connection
+	RealtimeServerEvent  = llm.RealtimeServerEvent  // Any other server event
+)
+
+// Re-export realtime options from the llm package
+var (
+	// WithRealtimeModel overrides the provider's default realtime model.
+	WithRealtimeModel = llm.WithRealtimeModel
+
+	// WithRealtimeInstructions sets the system instructions of the session.
+	WithRealtimeInstructions = llm.WithRealtimeInstructions
+
+	// WithRealtimeVoice sets the voice of the audio responses.
+	WithRealtimeVoice = llm.WithRealtimeVoice
+
+	// WithRealtimeModalities sets the response modalities.
+	WithRealtimeModalities = llm.WithRealtimeModalities
+
+	// WithRealtimeTools sets the functions the model may call.
+	WithRealtimeTools = llm.WithRealtimeTools
+
+	// WithRealtimeTemperature sets the sampling temperature of the session.
+	WithRealtimeTemperature = llm.WithRealtimeTemperature
+)
+
+// Realtime opens a realtime session using the configured provider.
+// The supported provider is "openai".
+func (l *llmImpl) Realtime(ctx context.Context, opts ...RealtimeOption) (*RealtimeSession, error) {
+	r, ok := l.LLM.(interface {
+		Realtime(context.Context, ...llm.RealtimeOption) (*llm.RealtimeSession, error)
+	})
+	if !ok {
+		return nil, fmt.Errorf("realtime sessions not supported by provider %s", l.provider.Name())
+	}
+	return r.Realtime(ctx, opts...)
+}
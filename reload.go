@@ -0,0 +1,372 @@
+package gollm
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/teilomillet/gollm/config"
+	"github.com/teilomillet/gollm/llm"
+	"github.com/teilomillet/gollm/utils"
+)
+
+// ReloadableLLM is an LLM whose configuration can be reloaded at runtime from
+// a config file and the environment, e.g. to rotate API keys or change models
+// and rate limits without restarting.
+//
+// Every reload creates a new LLM and swaps it in atomically: requests already
+// in flight complete with the settings they started with, and new requests
+// use the reloaded ones. Settings changed through methods such as SetOption
+// or SetSystemPrompt are reapplied to every reloaded LLM.
+type ReloadableLLM struct {
+	path    string
+	opts    []ConfigOption
+	current atomic.Pointer[reloadState]
+
+	mu       sync.Mutex  // Serializes reloads and setter updates
+	setters  []func(LLM) // Setter calls replayed on reloaded LLMs
+	onReload func(LLM)
+}
+
+// reloadState is an LLM along with the configuration and the modification
+// time of the config file it was loaded from.
+type reloadState struct {
+	llm     LLM
+	cfg     *config.Config
+	modTime time.Time
+}
+
+// NewReloadableLLM creates an LLM configured from the environment and the
+// YAML or JSON config file at path (see config.LoadConfigFile). The options
+// are applied after the file and take precedence over it. An empty path
+// loads the configuration from the environment only.
+//
+// Example usage:
+//
+//	l, err := gollm.NewReloadableLLM("gollm.yaml")
+//	go l.Watch(ctx, 10*time.Second, func(err error) { log.Print(err) })
+func NewReloadableLLM(path string, opts ...ConfigOption) (*ReloadableLLM, error) {
+	r := &ReloadableLLM{path: path, opts: opts}
+	state, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+	r.current.Store(state)
+	return r, nil
+}
+
+// OnReload registers a function called with the new LLM after every
+// successful reload.
+func (r *ReloadableLLM) OnReload(fn func(LLM)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.onReload = fn
+}
+
+// Current returns the LLM serving new requests. Callers making several
+// requests that must share the same settings can hold on to it.
+func (r *ReloadableLLM) Current() LLM {
+	return r.current.Load().llm
+}
+
+// Reload re-reads the config file and the environment and swaps in a new LLM.
+// If the configuration is invalid, the current LLM stays active and the error
+// is returned.
+func (r *ReloadableLLM) Reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	state, err := r.load()
+	if err != nil {
+		return err
+	}
+	for _, set := range r.setters {
+		set(state.llm)
+	}
+	r.current.Store(state)
+	if r.onReload != nil {
+		r.onReload(state.llm)
+	}
+	return nil
+}
+
+// Watch polls the config file and the API key secrets (see
+// config.SetAPIKeySecret) every interval and reloads when either changed, so
+// rotated keys are picked up. It blocks until ctx is done. Reload errors are
+// passed to onError (if non-nil) and the previous LLM stays active.
+// Environment changes are only picked up by Reload, e.g. on SIGHUP.
+func (r *ReloadableLLM) Watch(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			changed, err := r.changed(ctx)
+			if err == nil && changed {
+				err = r.Reload()
+			}
+			if err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// changed reports whether the config file was modified or an API key secret
+// changed since the last load.
+func (r *ReloadableLLM) changed(ctx context.Context) (bool, error) {
+	state := r.current.Load()
+	if r.path != "" {
+		info, err := os.Stat(r.path)
+		if err != nil {
+			return false, err
+		}
+		if !info.ModTime().Equal(state.modTime) {
+			return true, nil
+		}
+	}
+	for provider, ref := range state.cfg.APIKeySecrets {
+		key, err := ref.Source.GetSecret(ctx, ref.Name)
+		if err != nil {
+			return false, err
+		}
+		if key != state.cfg.APIKeys[provider] {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// load creates an LLM from the current config file and environment.
+func (r *ReloadableLLM) load() (*reloadState, error) {
+	var cfg *config.Config
+	var modTime time.Time
+	var err error
+	if r.path == "" {
+		cfg, err = LoadConfig()
+	} else {
+		var info os.FileInfo
+		if info, err = os.Stat(r.path); err != nil {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+		modTime = info.ModTime()
+		cfg, err = config.LoadConfigFile(r.path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	ApplyOptions(cfg, r.opts...)
+
+	l, err := newLLMFromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &reloadState{llm: l, cfg: cfg, modTime: modTime}, nil
+}
+
+// set applies a setter to the current LLM and records it for reloads.
+func (r *ReloadableLLM) set(fn func(LLM)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.setters = append(r.setters, fn)
+	fn(r.Current())
+}
+
+// Generate generates text with the current LLM.
+func (r *ReloadableLLM) Generate(ctx context.Context, prompt *Prompt, opts ...llm.GenerateOption) (string, error) {
+	return r.Current().Generate(ctx, prompt, opts...)
+}
+
+// GenerateWithSchema generates schema-conforming text with the current LLM.
+func (r *ReloadableLLM) GenerateWithSchema(ctx context.Context, prompt *Prompt, schema interface{}, opts ...llm.GenerateOption) (string, error) {
+	return r.Current().GenerateWithSchema(ctx, prompt, schema, opts...)
+}
+
+// GenerateFromTemplate generates text from a prompt template with the current LLM.
+func (r *ReloadableLLM) GenerateFromTemplate(ctx context.Context, tmpl *PromptTemplate, vars map[string]interface{}, opts ...llm.GenerateOption) (string, error) {
+	return r.Current().GenerateFromTemplate(ctx, tmpl, vars, opts...)
+}
+
+// Stream streams a response from the current LLM.
+func (r *ReloadableLLM) Stream(ctx context.Context, prompt *Prompt, opts ...llm.StreamOption) (llm.TokenStream, error) {
+	return r.Current().Stream(ctx, prompt, opts...)
+}
+
+// SupportsStreaming reports whether the current LLM supports streaming.
+func (r *ReloadableLLM) SupportsStreaming() bool {
+	return r.Current().SupportsStreaming()
+}
+
+// SupportsJSONSchema reports whether the current LLM supports JSON schemas.
+func (r *ReloadableLLM) SupportsJSONSchema() bool {
+	return r.Current().SupportsJSONSchema()
+}
+
+// Transcribe converts speech to text with the current LLM.
+func (r *ReloadableLLM) Transcribe(ctx context.Context, audio io.Reader, opts ...TranscriptionOption) (*Transcription, error) {
+	return r.Current().Transcribe(ctx, audio, opts...)
+}
+
+// TranscribeLong transcribes long audio in chunks with the current LLM.
+func (r *ReloadableLLM) TranscribeLong(ctx context.Context, audio io.Reader, opts ...LongTranscriptionOption) (*Transcription, error) {
+	return r.Current().TranscribeLong(ctx, audio, opts...)
+}
+
+// Speak converts text to speech with the current LLM.
+func (r *ReloadableLLM) Speak(ctx context.Context, text, voice string, opts ...SpeechOption) (io.ReadCloser, error) {
+	return r.Current().Speak(ctx, text, voice, opts...)
+}
+
+// Moderate classifies text with the current LLM's moderation API.
+func (r *ReloadableLLM) Moderate(ctx context.Context, text string, opts ...ModerationOption) (*ModerationResult, error) {
+	return r.Current().Moderate(ctx, text, opts...)
+}
+
+// Realtime opens a realtime session with the current LLM.
+func (r *ReloadableLLM) Realtime(ctx context.Context, opts ...RealtimeOption) (*RealtimeSession, error) {
+	return r.Current().Realtime(ctx, opts...)
+}
+
+// DeleteUploadedFiles removes the documents uploaded by the current LLM.
+func (r *ReloadableLLM) DeleteUploadedFiles(ctx context.Context) error {
+	return r.Current().DeleteUploadedFiles(ctx)
+}
+
+// UploadFile stores a file with the current LLM's provider.
+func (r *ReloadableLLM) UploadFile(ctx context.Context, filename string, content io.Reader, opts ...FileOption) (*FileInfo, error) {
+	return r.Current().UploadFile(ctx, filename, content, opts...)
+}
+
+// ListFiles returns the files stored with the current LLM's provider.
+func (r *ReloadableLLM) ListFiles(ctx context.Context) ([]FileInfo, error) {
+	return r.Current().ListFiles(ctx)
+}
+
+// DeleteFile removes a file from the current LLM's provider.
+func (r *ReloadableLLM) DeleteFile(ctx context.Context, fileID string) error {
+	return r.Current().DeleteFile(ctx, fileID)
+}
+
+// HealthCheck checks the provider of the current LLM.
+func (r *ReloadableLLM) HealthCheck(ctx context.Context) error {
+	return r.Current().HealthCheck(ctx)
+}
+
+// NewAssistant creates an assistant with the current LLM. The assistant
+// keeps using that LLM after a reload.
+func (r *ReloadableLLM) NewAssistant(ctx context.Context, config AssistantConfig) (*Assistant, error) {
+	return r.Current().NewAssistant(ctx, config)
+}
+
+// ListModels lists the models of the current LLM's provider.
+func (r *ReloadableLLM) ListModels(ctx context.Context) ([]ModelInfo, error) {
+	return r.Current().ListModels(ctx)
+}
+
+// SubmitBatch submits a batch with the current LLM.
+func (r *ReloadableLLM) SubmitBatch(ctx context.Context, items []BatchItem, opts ...llm.GenerateOption) (*BatchJob, error) {
+	return r.Current().SubmitBatch(ctx, items, opts...)
+}
+
+// GetBatch returns a batch submitted with the current LLM's provider.
+func (r *ReloadableLLM) GetBatch(ctx context.Context, id string) (*BatchJob, error) {
+	return r.Current().GetBatch(ctx, id)
+}
+
+// CreateFineTune starts a fine-tuning job with the current LLM's provider.
+func (r *ReloadableLLM) CreateFineTune(ctx context.Context, model, trainingFile string, opts ...FineTuneOption) (*FineTuneJob, error) {
+	return r.Current().CreateFineTune(ctx, model, trainingFile, opts...)
+}
+
+// GetFineTune returns a fine-tuning job of the current LLM's provider.
+func (r *ReloadableLLM) GetFineTune(ctx context.Context, id string) (*FineTuneJob, error) {
+	return r.Current().GetFineTune(ctx, id)
+}
+
+// ListFineTunes returns the fine-tuning jobs of the current LLM's provider.
+func (r *ReloadableLLM) ListFineTunes(ctx context.Context) ([]*FineTuneJob, error) {
+	return r.Current().ListFineTunes(ctx)
+}
+
+// NewPrompt creates a new prompt instance.
+func (r *ReloadableLLM) NewPrompt(input string) *Prompt {
+	return r.Current().NewPrompt(input)
+}
+
+// GetPromptJSONSchema returns the JSON schema of prompts.
+func (r *ReloadableLLM) GetPromptJSONSchema(opts ...SchemaOption) ([]byte, error) {
+	return r.Current().GetPromptJSONSchema(opts...)
+}
+
+// GetProvider returns the provider of the current LLM.
+func (r *ReloadableLLM) GetProvider() string {
+	return r.Current().GetProvider()
+}
+
+// GetModel returns the model of the current LLM.
+func (r *ReloadableLLM) GetModel() string {
+	return r.Current().GetModel()
+}
+
+// GetLogger returns the logger of the current LLM.
+func (r *ReloadableLLM) GetLogger() utils.Logger {
+	return r.Current().GetLogger()
+}
+
+// GetLogLevel returns the log level of the current LLM.
+func (r *ReloadableLLM) GetLogLevel() LogLevel {
+	return r.Current().GetLogLevel()
+}
+
+// Debug logs a debug message with the current LLM's logger.
+func (r *ReloadableLLM) Debug(msg string, keysAndValues ...interface{}) {
+	r.Current().Debug(msg, keysAndValues...)
+}
+
+// SetOption sets a provider option, also on reloaded LLMs.
+func (r *ReloadableLLM) SetOption(key string, value interface{}) {
+	r.set(func(l LLM) { l.SetOption(key, value) })
+}
+
+// SetToolChoice sets the default tool choice, also on reloaded LLMs.
+func (r *ReloadableLLM) SetToolChoice(choice string) {
+	r.set(func(l LLM) { l.SetToolChoice(choice) })
+}
+
+// SetLogLevel sets the internal log level, also on reloaded LLMs.
+func (r *ReloadableLLM) SetLogLevel(level utils.LogLevel) {
+	r.set(func(l LLM) { l.SetLogLevel(level) })
+}
+
+// UpdateLogLevel sets the log level, also on reloaded LLMs.
+func (r *ReloadableLLM) UpdateLogLevel(level LogLevel) {
+	r.set(func(l LLM) { l.UpdateLogLevel(level) })
+}
+
+// SetEndpoint sets the API endpoint, also on reloaded LLMs.
+func (r *ReloadableLLM) SetEndpoint(endpoint string) {
+	r.set(func(l LLM) { l.SetEndpoint(endpoint) })
+}
+
+// SetOllamaEndpoint sets the Ollama endpoint, also on reloaded LLMs.
+func (r *ReloadableLLM) SetOllamaEndpoint(endpoint string) error {
+	if err := r.Current().SetOllamaEndpoint(endpoint); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.setters = append(r.setters, func(l LLM) { _ = l.SetOllamaEndpoint(endpoint) })
+	return nil
+}
+
+// SetSystemPrompt sets the system prompt, also on reloaded LLMs.
+func (r *ReloadableLLM) SetSystemPrompt(prompt string, cacheType CacheType) {
+	r.set(func(l LLM) { l.SetSystemPrompt(prompt, cacheType) })
+}
+
+var _ LLM = (*ReloadableLLM)(nil)
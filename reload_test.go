@@ -0,0 +1,119 @@
+package gollm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReloadableLLM(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gollm.yaml")
+	write := func(content string, modTime time.Time) {
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+	start := time.Now().Add(-time.Hour)
+	write("provider: mock\nmodel: first-model\ntemperature: 0.3\n", start)
+
+	l, err := NewReloadableLLM(path, SetMaxRetries(0), SetLogLevel(LogLevelOff))
+	require.NoError(t, err)
+	assert.Equal(t, "first-model", l.GetModel())
+	l.SetOption("top_p", 0.4)
+
+	ctx := context.Background()
+	first := l.Current()
+	_, err = l.Generate(ctx, NewPrompt("Hi"))
+	require.NoError(t, err)
+	firstMock, err := GetMockProvider(first)
+	require.NoError(t, err)
+	call, _ := firstMock.LastCall()
+	assert.Equal(t, 0.3, call.Options["temperature"])
+
+	t.Run("Reload", func(t *testing.T) {
+		write("provider: mock\nmodel: second-model\nrate_limit: 1\n", start.Add(time.Minute))
+		require.NoError(t, l.Reload())
+		assert.Equal(t, "second-model", l.GetModel())
+		assert.Equal(t, "first-model", first.GetModel(), "LLMs in use keep their settings")
+
+		_, err = l.Generate(ctx, NewPrompt("Hi"))
+		require.NoError(t, err)
+		secondMock, err := GetMockProvider(l.Current())
+		require.NoError(t, err)
+		call, _ := secondMock.LastCall()
+		assert.Equal(t, 0.4, call.Options["top_p"], "setters are replayed on reload")
+
+		// The rate limit of one request per second blocks the next request
+		shortCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		_, err = l.Generate(shortCtx, NewPrompt("Too soon"))
+		assert.Error(t, err)
+	})
+
+	t.Run("InvalidConfig", func(t *testing.T) {
+		write("provider: [not a string\n", start.Add(2*time.Minute))
+		assert.Error(t, l.Reload())
+		assert.Equal(t, "second-model", l.GetModel())
+	})
+
+	t.Run("Watch", func(t *testing.T) {
+		write("provider: mock\nmodel: watched-model\n", start.Add(3*time.Minute))
+		reloaded := make(chan LLM, 1)
+		l.OnReload(func(current LLM) { reloaded <- current })
+
+		watchCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go l.Watch(watchCtx, 10*time.Millisecond, nil)
+		select {
+		case current := <-reloaded:
+			assert.Equal(t, "watched-model", current.GetModel())
+		case <-time.After(2 * time.Second):
+			t.Fatal("config change was not picked up")
+		}
+	})
+}
+
+// rotatingSource is a secret source whose value can be changed by the test.
+type rotatingSource struct {
+	mu    sync.Mutex
+	value string
+}
+
+func (s *rotatingSource) Name() string { return "rotating" }
+
+func (s *rotatingSource) GetSecret(context.Context, string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.value, nil
+}
+
+func (s *rotatingSource) rotate(value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.value = value
+}
+
+func TestReloadableLLMSecretRotation(t *testing.T) {
+	source := &rotatingSource{value: "key-1"}
+	l, err := NewReloadableLLM("", SetProvider("mock"), SetModel("m"), SetLogLevel(LogLevelOff), SetAPIKeySecret("mock", source, "mock-key"))
+	require.NoError(t, err)
+
+	reloaded := make(chan struct{}, 1)
+	l.OnReload(func(LLM) { reloaded <- struct{}{} })
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go l.Watch(ctx, 10*time.Millisecond, nil)
+
+	source.rotate("key-2")
+	select {
+	case <-reloaded:
+		assert.Equal(t, "key-2", l.current.Load().cfg.APIKeys["mock"])
+	case <-time.After(2 * time.Second):
+		t.Fatal("rotated key was not picked up")
+	}
+}
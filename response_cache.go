@@ -0,0 +1,57 @@
+// Package gollm provides response caching for Language Learning Models.
+// This file contains type definitions and re-exports for caching generated
+// responses with custom cache keys and per-request cache control.
+package gollm
+
+import (
+	"github.com/teilomillet/gollm/llm"
+)
+
+// Re-export response cache types from the llm package
+type (
+	// ResponseCache stores generated responses by cache key.
+	ResponseCache = llm.ResponseCache
+
+	// MemoryCache is an in-memory ResponseCache whose entries expire.
+	MemoryCache = llm.MemoryCache
+
+	// CacheKeyRequest is a request about to be sent, from which its cache key is computed.
+	CacheKeyRequest = llm.CacheKeyRequest
+
+	// CacheKeyFunc computes the cache key of a request.
+	CacheKeyFunc = llm.CacheKeyFunc
+
+	// CacheControl sets how a request uses the response cache.
+	CacheControl = llm.CacheControl
+)
+
+// Cache controls of a request.
+const (
+	CacheDefault  = llm.CacheDefault  // Serve cached responses and cache new ones
+	CacheBypass   = llm.CacheBypass   // Neither read nor write the cache
+	CacheRefresh  = llm.CacheRefresh  // Skip cached responses but cache the new one
+	CacheReadOnly = llm.CacheReadOnly // Serve cached responses without caching new ones
+)
+
+// Re-export response cache functions from the llm package
+var (
+	// NewMemoryCache creates an in-memory response cache whose entries expire after a TTL.
+	NewMemoryCache = llm.NewMemoryCache
+
+	// DefaultCacheKey keys requests by provider and request body.
+	DefaultCacheKey = llm.DefaultCacheKey
+
+	// WithCacheControl sets how a Generate call uses the response cache.
+	WithCacheControl = llm.WithCacheControl
+
+	// WithCacheKey computes the cache key of a Generate call with a custom function.
+	WithCacheKey = llm.WithCacheKey
+)
+
+// UseResponseCache caches the responses of the LLM in cache, keyed by key,
+// or DefaultCacheKey when nil. A nil cache turns caching off.
+func (l *llmImpl) UseResponseCache(cache ResponseCache, key CacheKeyFunc) {
+	if c, ok := l.LLM.(llm.ResponseCacher); ok {
+		c.UseResponseCache(cache, key)
+	}
+}
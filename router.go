@@ -0,0 +1,413 @@
+package gollm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/teilomillet/gollm/llm"
+	"github.com/teilomillet/gollm/providers"
+)
+
+// RouteCandidate is a model a Router can send requests to.
+type RouteCandidate struct {
+	LLM LLM
+
+	// Info holds the pricing and capabilities of the model. When left empty,
+	// it is looked up in the router's catalog by provider and model.
+	Info providers.ModelInfo
+
+	// Latency is the expected latency of a request, checked against
+	// RouteRequirements.MaxLatency. Zero means unknown, which satisfies any limit.
+	Latency time.Duration
+
+	priced bool // Whether Info came with pricing
+}
+
+// Name returns "provider/model" for logs and errors.
+func (c *RouteCandidate) Name() string {
+	return c.LLM.GetProvider() + "/" + c.LLM.GetModel()
+}
+
+// RouteRequest describes a request being routed.
+type RouteRequest struct {
+	Prompt       *Prompt
+	Requirements llm.RouteRequirements // Router defaults, per-request overrides and the prompt's needs
+	InputTokens  int                   // Estimated prompt tokens
+	RetryBudget  *llm.RetryBudget      // Shared by the attempts on every candidate, if any
+}
+
+// EstimatedCost returns the expected price in USD of the request on a
+// candidate, or false if the candidate has no pricing.
+func (r *RouteRequest) EstimatedCost(c *RouteCandidate) (float64, bool) {
+	if !c.priced {
+		return 0, false
+	}
+	return c.Info.Cost(r.InputTokens, r.Requirements.OutputTokens), true
+}
+
+// Satisfies reports whether a candidate meets the requirements of the request.
+func (r *RouteRequest) Satisfies(c *RouteCandidate) bool {
+	req := r.Requirements
+	if req.Provider != "" && c.LLM.GetProvider() != req.Provider {
+		return false
+	}
+	if req.MaxLatency > 0 && c.Latency > req.MaxLatency {
+		return false
+	}
+	if len(req.Capabilities) > 0 && !c.Info.Has(req.Capabilities...) {
+		return false
+	}
+	if c.Info.ContextWindow > 0 {
+		if req.MinContextWindow > c.Info.ContextWindow || r.InputTokens+req.OutputTokens > c.Info.ContextWindow {
+			return false
+		}
+	} else if req.MinContextWindow > 0 {
+		return false
+	}
+	return true
+}
+
+// RouterPolicy decides which candidates serve a request.
+type RouterPolicy interface {
+	// Route returns the candidates to try, in order: the router falls back to
+	// the next one when a candidate fails. It returns an error when no
+	// candidate is suitable.
+	Route(ctx context.Context, req *RouteRequest, candidates []*RouteCandidate) ([]*RouteCandidate, error)
+}
+
+// RouteObserver is implemented by policies that learn from the outcome of the
+// requests they route. Like the optional provider capabilities, it is
+// discovered through a type assertion.
+type RouteObserver interface {
+	// Observe is called after every attempt on a candidate with its latency
+	// and error, nil on success.
+	Observe(c *RouteCandidate, latency time.Duration, err error)
+}
+
+// CostPolicy routes each request to the cheapest candidate satisfying its
+// requirements, falling back to the next cheapest ones. Candidates without
+// pricing come last, in their configured order.
+type CostPolicy struct{}
+
+// Route implements RouterPolicy.
+func (CostPolicy) Route(_ context.Context, req *RouteRequest, candidates []*RouteCandidate) ([]*RouteCandidate, error) {
+	eligible := make([]*RouteCandidate, 0, len(candidates))
+	for _, c := range candidates {
+		if req.Satisfies(c) {
+			eligible = append(eligible, c)
+		}
+	}
+	if len(eligible) == 0 {
+		return nil, llm.NewLLMError(llm.ErrorTypeInvalidInput, "no model satisfies the routing requirements", nil)
+	}
+	sort.SliceStable(eligible, func(i, j int) bool {
+		ci, iok := req.EstimatedCost(eligible[i])
+		cj, jok := req.EstimatedCost(eligible[j])
+		if iok != jok {
+			return iok
+		}
+		return ci < cj
+	})
+	return eligible, nil
+}
+
+// RouterConfig configures a Router.
+type RouterConfig struct {
+	// Candidates are the models requests can be routed to
+	Candidates []RouteCandidate
+
+	// Policy orders the candidates of each request; defaults to CostPolicy
+	Policy RouterPolicy
+
+	// Requirements apply to every request; WithRouteRequirements overrides
+	// them per request
+	Requirements llm.RouteRequirements
+
+	// Catalog provides the pricing and capabilities of candidates without
+	// Info; defaults to providers.DefaultModelCatalog()
+	Catalog *providers.ModelCatalog
+
+	// MaxAttempts and MaxElapsed bound each Generate and GenerateWithSchema
+	// call across the candidates and their retries, unless the request sets
+	// its own budget with WithRetryBudget; zero means no limit
+	MaxAttempts int
+	MaxElapsed  time.Duration
+
+	// Clock times the health checks of WatchHealth and the latency of
+	// attempts; defaults to the system clock
+	Clock Clock
+}
+
+// Router is an LLM that sends each request to one of several models chosen
+// by a RouterPolicy, falling back to the next choice when a model fails.
+// Requests needing tools, images or documents are only routed to models with
+// the matching capability. Candidates failing their health check are only
+// tried after the healthy ones. Methods other than generation, streaming and
+// health checks are served by the first candidate.
+type Router struct {
+	LLM
+	candidates   []*RouteCandidate
+	policy       RouterPolicy
+	requirements llm.RouteRequirements
+	maxAttempts  int
+	maxElapsed   time.Duration
+	clock        Clock
+
+	healthMu sync.RWMutex
+	down     map[*RouteCandidate]error // Candidates whose last health check failed
+}
+
+// NewRouter creates a router over the configured candidates.
+//
+// Example usage:
+//
+//	router, err := gollm.NewRouter(gollm.RouterConfig{
+//	    Candidates: []gollm.RouteCandidate{{LLM: mini}, {LLM: sonnet}, {LLM: llama}},
+//	    Requirements: llm.RouteRequirements{OutputTokens: 500},
+//	})
+func NewRouter(cfg RouterConfig) (*Router, error) {
+	if len(cfg.Candidates) == 0 {
+		return nil, fmt.Errorf("router needs at least one candidate")
+	}
+	if cfg.Policy == nil {
+		cfg.Policy = CostPolicy{}
+	}
+	if cfg.Catalog == nil {
+		cfg.Catalog = providers.DefaultModelCatalog()
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = SystemClock()
+	}
+
+	r := &Router{
+		LLM:          cfg.Candidates[0].LLM,
+		policy:       cfg.Policy,
+		requirements: cfg.Requirements,
+		maxAttempts:  cfg.MaxAttempts,
+		maxElapsed:   cfg.MaxElapsed,
+		clock:        cfg.Clock,
+	}
+	for i := range cfg.Candidates {
+		c := cfg.Candidates[i]
+		if c.LLM == nil {
+			return nil, fmt.Errorf("router candidate %d has no LLM", i)
+		}
+		c.priced = c.Info.InputPerMillion > 0 || c.Info.OutputPerMillion > 0
+		if c.Info.Model == "" {
+			if info, ok := cfg.Catalog.Lookup(c.LLM.GetProvider(), c.LLM.GetModel()); ok {
+				c.Info, c.priced = info, true
+			}
+		}
+		r.candidates = append(r.candidates, &c)
+	}
+	return r, nil
+}
+
+// Candidates returns the router's candidates.
+func (r *Router) Candidates() []*RouteCandidate {
+	return r.candidates
+}
+
+// Generate sends the prompt to the first suitable candidate that succeeds.
+func (r *Router) Generate(ctx context.Context, prompt *Prompt, opts ...llm.GenerateOption) (string, error) {
+	var response string
+	opts = r.withRetryBudget(opts)
+	err := r.route(ctx, prompt, opts, nil, func(c *RouteCandidate) (err error) {
+		response, err = c.LLM.Generate(ctx, prompt, opts...)
+		return err
+	})
+	return response, err
+}
+
+// GenerateWithSchema sends the prompt to the first suitable candidate that
+// returns a response conforming to the schema.
+func (r *Router) GenerateWithSchema(ctx context.Context, prompt *Prompt, schema interface{}, opts ...llm.GenerateOption) (string, error) {
+	var response string
+	opts = r.withRetryBudget(opts)
+	err := r.route(ctx, prompt, opts, nil, func(c *RouteCandidate) (err error) {
+		response, err = c.LLM.GenerateWithSchema(ctx, prompt, schema, opts...)
+		return err
+	})
+	return response, err
+}
+
+// GenerateFromTemplate executes the template and routes the resulting prompt.
+func (r *Router) GenerateFromTemplate(ctx context.Context, tmpl *PromptTemplate, vars map[string]interface{}, opts ...llm.GenerateOption) (string, error) {
+	prompt, err := tmpl.Execute(vars)
+	if err != nil {
+		return "", fmt.Errorf("failed to execute template %s: %w", tmpl.Name, err)
+	}
+	return r.Generate(ctx, prompt, opts...)
+}
+
+// Stream streams from the first suitable candidate that starts streaming.
+// Failures after the stream has started are not retried on other candidates.
+func (r *Router) Stream(ctx context.Context, prompt *Prompt, opts ...llm.StreamOption) (llm.TokenStream, error) {
+	var stream llm.TokenStream
+	streaming := []providers.Capability{providers.CapabilityStreaming}
+	err := r.route(ctx, prompt, nil, streaming, func(c *RouteCandidate) (err error) {
+		if !c.LLM.SupportsStreaming() {
+			return llm.NewLLMError(llm.ErrorTypeUnsupported, "streaming not supported by provider", nil)
+		}
+		stream, err = c.LLM.Stream(ctx, prompt, opts...)
+		return err
+	})
+	return stream, err
+}
+
+// SupportsStreaming reports whether any candidate supports streaming.
+func (r *Router) SupportsStreaming() bool {
+	for _, c := range r.candidates {
+		if c.LLM.SupportsStreaming() {
+			return true
+		}
+	}
+	return false
+}
+
+// HealthCheck checks every candidate and records those failing, which are
+// then only tried after the healthy ones until a later check succeeds. It
+// returns nil when at least one candidate is healthy, and an error joining
+// the failures otherwise.
+func (r *Router) HealthCheck(ctx context.Context) error {
+	errs := make([]error, len(r.candidates))
+	var wg sync.WaitGroup
+	for i, c := range r.candidates {
+		wg.Add(1)
+		go func(i int, c *RouteCandidate) {
+			defer wg.Done()
+			errs[i] = c.LLM.HealthCheck(ctx)
+		}(i, c)
+	}
+	wg.Wait()
+
+	r.healthMu.Lock()
+	defer r.healthMu.Unlock()
+	r.down = make(map[*RouteCandidate]error)
+	var failures []error
+	for i, c := range r.candidates {
+		if errs[i] != nil {
+			r.down[c] = errs[i]
+			failures = append(failures, fmt.Errorf("%s: %w", c.Name(), errs[i]))
+		}
+	}
+	if len(failures) < len(r.candidates) {
+		return nil
+	}
+	return fmt.Errorf("no healthy candidate: %w", errors.Join(failures...))
+}
+
+// WatchHealth runs HealthCheck every interval, timed by the router's clock,
+// until the context is cancelled.
+//
+// Example usage:
+//
+//	go router.WatchHealth(ctx, time.Minute)
+func (r *Router) WatchHealth(ctx context.Context, interval time.Duration) {
+	for {
+		_ = r.HealthCheck(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-r.clock.After(interval):
+		}
+	}
+}
+
+// healthy moves the candidates whose last health check failed to the end.
+func (r *Router) healthy(candidates []*RouteCandidate) []*RouteCandidate {
+	r.healthMu.RLock()
+	defer r.healthMu.RUnlock()
+	if len(r.down) == 0 {
+		return candidates
+	}
+	ordered := make([]*RouteCandidate, 0, len(candidates))
+	var down []*RouteCandidate
+	for _, c := range candidates {
+		if _, ok := r.down[c]; ok {
+			down = append(down, c)
+		} else {
+			ordered = append(ordered, c)
+		}
+	}
+	return append(ordered, down...)
+}
+
+// route asks the policy for the candidates of a request and calls try on
+// each of them until one succeeds.
+func (r *Router) route(ctx context.Context, prompt *Prompt, opts []llm.GenerateOption, needs []providers.Capability, try func(*RouteCandidate) error) error {
+	req := r.newRequest(prompt, opts, needs)
+	candidates, err := r.policy.Route(ctx, req, r.candidates)
+	if err != nil {
+		return err
+	}
+	candidates = r.healthy(candidates)
+
+	observer, _ := r.policy.(RouteObserver)
+	var errs []error
+	for _, c := range candidates {
+		start := r.clock.Now()
+		err := try(c)
+		if observer != nil && (err == nil || ctx.Err() == nil) {
+			observer.Observe(c, r.clock.Now().Sub(start), err)
+		}
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", c.Name(), err))
+		if ctx.Err() != nil || req.RetryBudget.Exhausted() {
+			break
+		}
+	}
+	if req.RetryBudget.Exhausted() {
+		message := fmt.Sprintf("retry budget exhausted after %d attempts", req.RetryBudget.Attempts())
+		return llm.NewLLMError(llm.ErrorTypeRetryBudgetExhausted, message, errors.Join(errs...))
+	}
+	return fmt.Errorf("all routes failed: %w", errors.Join(errs...))
+}
+
+// withRetryBudget adds a budget from the router's limits to the options of
+// a request without its own.
+func (r *Router) withRetryBudget(opts []llm.GenerateOption) []llm.GenerateOption {
+	if r.maxAttempts == 0 && r.maxElapsed == 0 {
+		return opts
+	}
+	gen := &llm.GenerateConfig{}
+	for _, opt := range opts {
+		opt(gen)
+	}
+	if gen.RetryBudget != nil {
+		return opts
+	}
+	budget := llm.NewRetryBudget(r.maxAttempts, r.maxElapsed)
+	return append(opts[:len(opts):len(opts)], llm.WithRetryBudget(budget))
+}
+
+// newRequest combines the router's requirements, the per-request overrides
+// and the capabilities the prompt needs.
+func (r *Router) newRequest(prompt *Prompt, opts []llm.GenerateOption, needs []providers.Capability) *RouteRequest {
+	gen := &llm.GenerateConfig{}
+	for _, opt := range opts {
+		opt(gen)
+	}
+	requirements := r.requirements
+	if gen.Route != nil {
+		requirements = requirements.Merge(*gen.Route)
+	}
+	if len(prompt.Tools) > 0 {
+		needs = append(needs, providers.CapabilityTools)
+	}
+	if len(prompt.Images) > 0 {
+		needs = append(needs, providers.CapabilityVision)
+	}
+	if len(prompt.Documents) > 0 {
+		needs = append(needs, providers.CapabilityDocuments)
+	}
+	requirements = requirements.Merge(llm.RouteRequirements{Capabilities: needs})
+
+	return &RouteRequest{Prompt: prompt, Requirements: requirements, InputTokens: llm.EstimateTokens(prompt.String()), RetryBudget: gen.RetryBudget}
+}
@@ -0,0 +1,248 @@
+package gollm
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/teilomillet/gollm/utils"
+)
+
+// AdaptivePolicy is a RouterPolicy that tracks the rolling latency and error
+// rate of each candidate and shifts traffic toward the healthiest ones.
+//
+// Candidates are first ordered by the Base policy, then reordered by score:
+// the moving average of their latency, inflated by their error rate.
+// Hysteresis keeps the current leader first until another candidate scores
+// clearly better, so traffic does not flap between similar endpoints.
+// Candidates whose error rate exceeds MaxErrorRate are moved to the end, where
+// they only serve as fallbacks, until Cooldown has passed; they are then
+// probed with a request and return to service once their error rate falls
+// below half of MaxErrorRate. Candidates without observations keep their base
+// order after the observed ones.
+//
+// The zero value is ready to use with the defaults below.
+//
+// Example usage:
+//
+//	router, err := gollm.NewRouter(gollm.RouterConfig{
+//	    Candidates: candidates,
+//	    Policy:     &gollm.AdaptivePolicy{Base: gollm.CostPolicy{}},
+//	})
+type AdaptivePolicy struct {
+	// Base selects and orders the eligible candidates before they are
+	// reordered by health; defaults to CostPolicy
+	Base RouterPolicy
+
+	// Alpha is the weight of a new observation in the moving averages,
+	// between 0 and 1; defaults to 0.2
+	Alpha float64
+
+	// Hysteresis is the fraction by which a candidate's score must beat the
+	// leader's to take over; defaults to 0.2
+	Hysteresis float64
+
+	// MaxErrorRate is the error rate above which a candidate is considered
+	// unhealthy; defaults to 0.5
+	MaxErrorRate float64
+
+	// Cooldown is how long an unhealthy candidate is kept out of rotation
+	// before it is probed again; defaults to 30s
+	Cooldown time.Duration
+
+	// Clock times the cooldowns; defaults to the system clock
+	Clock utils.Clock
+
+	mu     sync.Mutex
+	stats  map[*RouteCandidate]*endpointStats
+	leader *RouteCandidate
+}
+
+// endpointStats are the rolling statistics of a candidate.
+type endpointStats struct {
+	latency     float64 // Moving average of successful request latencies in seconds
+	errorRate   float64 // Moving average of failures, between 0 and 1
+	unhealthy   bool
+	lastFailure time.Time
+}
+
+// EndpointStats is a snapshot of the statistics of a candidate.
+type EndpointStats struct {
+	Latency   time.Duration // Moving average of successful request latencies
+	ErrorRate float64       // Moving average of failures, between 0 and 1
+	Healthy   bool
+}
+
+func (p *AdaptivePolicy) alpha() float64 {
+	if p.Alpha <= 0 || p.Alpha > 1 {
+		return 0.2
+	}
+	return p.Alpha
+}
+
+func (p *AdaptivePolicy) hysteresis() float64 {
+	if p.Hysteresis <= 0 {
+		return 0.2
+	}
+	return p.Hysteresis
+}
+
+func (p *AdaptivePolicy) maxErrorRate() float64 {
+	if p.MaxErrorRate <= 0 {
+		return 0.5
+	}
+	return p.MaxErrorRate
+}
+
+func (p *AdaptivePolicy) cooldown() time.Duration {
+	if p.Cooldown <= 0 {
+		return 30 * time.Second
+	}
+	return p.Cooldown
+}
+
+func (p *AdaptivePolicy) clock() time.Time {
+	if p.Clock != nil {
+		return p.Clock.Now()
+	}
+	return time.Now()
+}
+
+// score is the expected cost of sending a request to a candidate, in seconds.
+// Each failure costs as much as a slow request, so error-prone candidates
+// score worse even when they are fast.
+func (s *endpointStats) score() float64 {
+	return s.latency * (1 + 4*s.errorRate)
+}
+
+// Route implements RouterPolicy.
+func (p *AdaptivePolicy) Route(ctx context.Context, req *RouteRequest, candidates []*RouteCandidate) ([]*RouteCandidate, error) {
+	base := p.Base
+	if base == nil {
+		base = CostPolicy{}
+	}
+	eligible, err := base.Route(ctx, req, candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.clock()
+
+	var probes, observed, unobserved, unhealthy []*RouteCandidate
+	for _, c := range eligible {
+		s, ok := p.stats[c]
+		switch {
+		case !ok:
+			unobserved = append(unobserved, c)
+		case s.unhealthy && now.Sub(s.lastFailure) >= p.cooldown():
+			probes = append(probes, c)
+		case s.unhealthy:
+			unhealthy = append(unhealthy, c)
+		default:
+			observed = append(observed, c)
+		}
+	}
+	sort.SliceStable(observed, func(i, j int) bool {
+		return p.stats[observed[i]].score() < p.stats[observed[j]].score()
+	})
+
+	// Keep the leader first unless the best candidate beats it clearly
+	if len(observed) > 1 && p.leader != nil && observed[0] != p.leader {
+		for i, c := range observed {
+			if c != p.leader {
+				continue
+			}
+			if p.stats[observed[0]].score() >= p.stats[c].score()*(1-p.hysteresis()) {
+				copy(observed[1:i+1], observed[:i])
+				observed[0] = c
+			}
+			break
+		}
+	}
+	if len(observed) > 0 {
+		p.leader = observed[0]
+	}
+
+	ordered := make([]*RouteCandidate, 0, len(eligible))
+	ordered = append(ordered, probes...)
+	ordered = append(ordered, observed...)
+	ordered = append(ordered, unobserved...)
+	ordered = append(ordered, unhealthy...)
+	return ordered, nil
+}
+
+// Observe implements RouteObserver.
+func (p *AdaptivePolicy) Observe(c *RouteCandidate, latency time.Duration, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stats == nil {
+		p.stats = make(map[*RouteCandidate]*endpointStats)
+	}
+	alpha := p.alpha()
+	s, ok := p.stats[c]
+	if !ok {
+		s = &endpointStats{latency: math.NaN()}
+		p.stats[c] = s
+	}
+
+	failed := 0.0
+	if err != nil {
+		failed = 1
+		s.lastFailure = p.clock()
+	} else if math.IsNaN(s.latency) {
+		s.latency = latency.Seconds()
+	} else {
+		s.latency += alpha * (latency.Seconds() - s.latency)
+	}
+	switch {
+	case !ok:
+		s.errorRate = failed
+	case s.unhealthy && err == nil:
+		// Successful probes bring a candidate back quickly
+		s.errorRate /= 2
+	default:
+		s.errorRate += alpha * (failed - s.errorRate)
+	}
+	if math.IsNaN(s.latency) {
+		// No success yet: assume the slowest latency seen so far
+		s.latency = p.slowest()
+	}
+
+	switch {
+	case s.errorRate > p.maxErrorRate():
+		s.unhealthy = true
+	case s.unhealthy && err == nil && s.errorRate < p.maxErrorRate()/2:
+		s.unhealthy = false
+	}
+}
+
+// slowest returns the highest latency average of the candidates with one.
+func (p *AdaptivePolicy) slowest() float64 {
+	slowest := 0.0
+	for _, s := range p.stats {
+		if !math.IsNaN(s.latency) && s.latency > slowest {
+			slowest = s.latency
+		}
+	}
+	return slowest
+}
+
+// Stats returns the statistics of a candidate, or false if it has not
+// served any request yet.
+func (p *AdaptivePolicy) Stats(c *RouteCandidate) (EndpointStats, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	s, ok := p.stats[c]
+	if !ok {
+		return EndpointStats{}, false
+	}
+	return EndpointStats{
+		Latency:   time.Duration(s.latency * float64(time.Second)),
+		ErrorRate: s.errorRate,
+		Healthy:   !s.unhealthy,
+	}, true
+}
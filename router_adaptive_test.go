@@ -0,0 +1,93 @@
+package gollm_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm"
+)
+
+func TestAdaptivePolicy(t *testing.T) {
+	fast, _ := newRouteCandidate(t, "fast", 1, 0)
+	slow, _ := newRouteCandidate(t, "slow", 1, 0)
+	router, err := gollm.NewRouter(gollm.RouterConfig{Candidates: []gollm.RouteCandidate{fast, slow}})
+	require.NoError(t, err)
+	a, b := router.Candidates()[0], router.Candidates()[1]
+
+	clock := gollm.NewFakeClock(time.Now())
+	policy := &gollm.AdaptivePolicy{Cooldown: time.Minute, Clock: clock}
+	ctx := context.Background()
+	order := func() []string {
+		ordered, err := policy.Route(ctx, &gollm.RouteRequest{Prompt: gollm.NewPrompt("Hi")}, router.Candidates())
+		require.NoError(t, err)
+		names := make([]string, len(ordered))
+		for i, c := range ordered {
+			names[i] = c.LLM.GetModel()
+		}
+		return names
+	}
+
+	assert.Equal(t, []string{"fast", "slow"}, order(), "base order without observations")
+
+	policy.Observe(a, 100*time.Millisecond, nil)
+	policy.Observe(b, 200*time.Millisecond, nil)
+	assert.Equal(t, []string{"fast", "slow"}, order())
+
+	t.Run("Hysteresis", func(t *testing.T) {
+		policy.Observe(a, 110*time.Millisecond, nil) // a averages 102ms
+		for i := 0; i < 20; i++ {
+			policy.Observe(b, 95*time.Millisecond, nil)
+		}
+		assert.Equal(t, []string{"fast", "slow"}, order(), "a slightly better candidate does not take over")
+
+		for i := 0; i < 20; i++ {
+			policy.Observe(a, time.Second, nil)
+		}
+		assert.Equal(t, []string{"slow", "fast"}, order(), "a clearly better candidate takes over")
+	})
+
+	t.Run("Unhealthy", func(t *testing.T) {
+		for i := 0; i < 5; i++ {
+			policy.Observe(b, 0, errors.New("unavailable"))
+		}
+		stats, ok := policy.Stats(b)
+		require.True(t, ok)
+		assert.False(t, stats.Healthy)
+		assert.Equal(t, []string{"fast", "slow"}, order(), "unhealthy candidates are only fallbacks")
+
+		clock.Advance(2 * time.Minute)
+		assert.Equal(t, []string{"slow", "fast"}, order(), "probed after the cooldown")
+		for i := 0; i < 3; i++ {
+			policy.Observe(b, 95*time.Millisecond, nil)
+		}
+		stats, _ = policy.Stats(b)
+		assert.True(t, stats.Healthy, "recovers after successful probes")
+	})
+}
+
+func TestRouterWithAdaptivePolicy(t *testing.T) {
+	primary, primaryMock := newRouteCandidate(t, "primary", 0.1, 0)
+	backup, _ := newRouteCandidate(t, "backup", 1, 0)
+	policy := &gollm.AdaptivePolicy{}
+	router, err := gollm.NewRouter(gollm.RouterConfig{Candidates: []gollm.RouteCandidate{primary, backup}, Policy: policy})
+	require.NoError(t, err)
+
+	primaryMock.SetResponder(nil)
+	for i := 0; i < 3; i++ {
+		primaryMock.QueueError(http.StatusServiceUnavailable, "overloaded")
+	}
+	for i := 0; i < 3; i++ {
+		response, err := router.Generate(context.Background(), gollm.NewPrompt("Hi"))
+		require.NoError(t, err)
+		assert.Equal(t, "backup", response)
+	}
+	assert.Equal(t, 1, primaryMock.CallCount(), "traffic shifts away from the failing candidate")
+	stats, ok := policy.Stats(router.Candidates()[0])
+	require.True(t, ok)
+	assert.False(t, stats.Healthy)
+}
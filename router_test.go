@@ -0,0 +1,185 @@
+package gollm_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teilomillet/gollm"
+	"github.com/teilomillet/gollm/gollmtest"
+	"github.com/teilomillet/gollm/llm"
+	"github.com/teilomillet/gollm/providers"
+	"github.com/teilomillet/gollm/utils"
+)
+
+func newRouteCandidate(t *testing.T, model string, price float64, latency time.Duration, caps ...providers.Capability) (gollm.RouteCandidate, *gollm.MockProvider) {
+	t.Helper()
+	l, mock := gollmtest.NewMockLLM(t, gollm.SetModel(model))
+	mock.SetResponder(func(gollm.MockCall) (string, error) { return model, nil })
+	info := providers.ModelInfo{Provider: "mock", Model: model, InputPerMillion: price, OutputPerMillion: price, ContextWindow: 1000, Capabilities: caps}
+	return gollm.RouteCandidate{LLM: l, Info: info, Latency: latency}, mock
+}
+
+func TestCostRouter(t *testing.T) {
+	cheap, cheapMock := newRouteCandidate(t, "cheap", 0.1, 5*time.Second)
+	mid, _ := newRouteCandidate(t, "mid", 1, time.Second, providers.CapabilityTools)
+	premium, _ := newRouteCandidate(t, "premium", 10, time.Second, providers.CapabilityTools, providers.CapabilityVision)
+
+	router, err := gollm.NewRouter(gollm.RouterConfig{Candidates: []gollm.RouteCandidate{premium, mid, cheap}})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	t.Run("Cheapest", func(t *testing.T) {
+		response, err := router.Generate(ctx, gollm.NewPrompt("Hi"))
+		require.NoError(t, err)
+		assert.Equal(t, "cheap", response)
+	})
+
+	t.Run("Capabilities", func(t *testing.T) {
+		prompt := gollm.NewPrompt("Weather?", gollm.WithTools([]utils.Tool{{Type: "function", Function: utils.Function{Name: "weather"}}}))
+		response, err := router.Generate(ctx, prompt)
+		require.NoError(t, err)
+		assert.Equal(t, "mid", response)
+	})
+
+	t.Run("PerRequestOverride", func(t *testing.T) {
+		response, err := router.Generate(ctx, gollm.NewPrompt("Hi"), llm.WithRouteRequirements(llm.RouteRequirements{MaxLatency: 2 * time.Second}))
+		require.NoError(t, err)
+		assert.Equal(t, "mid", response)
+	})
+
+	t.Run("ContextWindow", func(t *testing.T) {
+		_, err := router.Generate(ctx, gollm.NewPrompt("Hi"), llm.WithRouteRequirements(llm.RouteRequirements{OutputTokens: 5000}))
+		assert.ErrorContains(t, err, "no model satisfies")
+
+		// A short prompt still counts towards the context window
+		_, err = router.Generate(ctx, gollm.NewPrompt("Hi"), llm.WithRouteRequirements(llm.RouteRequirements{OutputTokens: 1000}))
+		assert.ErrorContains(t, err, "no model satisfies")
+	})
+
+	t.Run("Fallback", func(t *testing.T) {
+		cheapMock.SetResponder(nil)
+		cheapMock.QueueError(http.StatusInternalServerError, "down")
+		response, err := router.Generate(ctx, gollm.NewPrompt("Hi"))
+		require.NoError(t, err)
+		assert.Equal(t, "mid", response)
+	})
+}
+
+func TestRouterCatalogLookup(t *testing.T) {
+	catalog := providers.NewModelCatalog(providers.ModelInfo{Provider: "mock", Model: "listed", InputPerMillion: 5, OutputPerMillion: 5})
+	listed, _ := gollmtest.NewMockLLM(t, gollm.SetModel("listed"))
+	unlisted, _ := gollmtest.NewMockLLM(t, gollm.SetModel("unlisted"))
+
+	router, err := gollm.NewRouter(gollm.RouterConfig{Candidates: []gollm.RouteCandidate{{LLM: unlisted}, {LLM: listed}}, Catalog: catalog})
+	require.NoError(t, err)
+	ordered, err := gollm.CostPolicy{}.Route(context.Background(), &gollm.RouteRequest{Prompt: gollm.NewPrompt("Hi")}, router.Candidates())
+	require.NoError(t, err)
+	require.Len(t, ordered, 2)
+	assert.Equal(t, "mock/listed", ordered[0].Name(), "priced candidates come before unpriced ones")
+}
+
+func TestRouterHealthCheck(t *testing.T) {
+	cheap, cheapMock := newRouteCandidate(t, "cheap", 0.1, 0)
+	premium, _ := newRouteCandidate(t, "premium", 10, 0)
+	router, err := gollm.NewRouter(gollm.RouterConfig{Candidates: []gollm.RouteCandidate{premium, cheap}})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	// Candidates without a health endpoint are pinged with a generation
+	cheapMock.SetResponder(nil)
+	cheapMock.QueueError(http.StatusUnauthorized, "invalid key")
+	require.NoError(t, router.HealthCheck(ctx), "one healthy candidate is enough")
+	assert.Equal(t, 1, cheapMock.CallCount())
+
+	cheapMock.SetResponder(func(gollm.MockCall) (string, error) { return "cheap", nil })
+	response, err := router.Generate(ctx, gollm.NewPrompt("Hi"))
+	require.NoError(t, err)
+	assert.Equal(t, "premium", response, "unhealthy candidates are skipped")
+
+	require.NoError(t, router.HealthCheck(ctx))
+	response, err = router.Generate(ctx, gollm.NewPrompt("Hi"))
+	require.NoError(t, err)
+	assert.Equal(t, "cheap", response, "candidates return once healthy")
+	assert.NoError(t, gollm.Healthy(ctx, router, cheap.LLM))
+}
+
+// latencyPolicy routes to the candidates in order and records the latency
+// of each attempt.
+type latencyPolicy struct{ latencies []time.Duration }
+
+func (p *latencyPolicy) Route(_ context.Context, _ *gollm.RouteRequest, candidates []*gollm.RouteCandidate) ([]*gollm.RouteCandidate, error) {
+	return candidates, nil
+}
+
+func (p *latencyPolicy) Observe(_ *gollm.RouteCandidate, latency time.Duration, _ error) {
+	p.latencies = append(p.latencies, latency)
+}
+
+func TestRouterClock(t *testing.T) {
+	clock := gollm.NewFakeClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	candidate, mock := newRouteCandidate(t, "cheap", 0.1, 0)
+	policy := &latencyPolicy{}
+	router, err := gollm.NewRouter(gollm.RouterConfig{Candidates: []gollm.RouteCandidate{candidate}, Policy: policy, Clock: clock})
+	require.NoError(t, err)
+
+	mock.SetResponder(func(gollm.MockCall) (string, error) {
+		clock.Advance(2 * time.Second)
+		return "cheap", nil
+	})
+	_, err = router.Generate(context.Background(), gollm.NewPrompt("Hi"))
+	require.NoError(t, err)
+	assert.Equal(t, []time.Duration{2 * time.Second}, policy.latencies, "latencies are timed by the router's clock")
+
+	mock.SetResponder(func(gollm.MockCall) (string, error) { return "cheap", nil })
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		router.WatchHealth(ctx, time.Minute)
+		close(done)
+	}()
+	waitFor := func(calls int) {
+		t.Helper()
+		require.Eventually(t, func() bool { return mock.CallCount() == calls && clock.Waiters() == 1 }, time.Second, time.Millisecond)
+	}
+	waitFor(2)
+	clock.Advance(time.Minute)
+	waitFor(3)
+	cancel()
+	<-done
+}
+
+func TestRouterRetryBudget(t *testing.T) {
+	var mocks []*gollm.MockProvider
+	var candidates []gollm.RouteCandidate
+	for _, model := range []string{"first", "second", "third"} {
+		c, mock := newRouteCandidate(t, model, 1, 0)
+		mock.SetResponder(func(gollm.MockCall) (string, error) {
+			return "", fmt.Errorf("down")
+		})
+		candidates = append(candidates, c)
+		mocks = append(mocks, mock)
+	}
+	router, err := gollm.NewRouter(gollm.RouterConfig{Candidates: candidates, MaxAttempts: 2})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	_, err = router.Generate(ctx, gollm.NewPrompt("Hi"))
+	var llmErr *llm.LLMError
+	require.ErrorAs(t, err, &llmErr)
+	assert.Equal(t, llm.ErrorTypeRetryBudgetExhausted, llmErr.Type)
+	assert.Equal(t, 1, mocks[0].CallCount())
+	assert.Equal(t, 1, mocks[1].CallCount())
+	assert.Equal(t, 0, mocks[2].CallCount(), "the budget stops the fallback chain")
+
+	// A per-request budget replaces the router's
+	budget := gollm.NewRetryBudget(3, 0)
+	_, err = router.Generate(ctx, gollm.NewPrompt("Hi"), gollm.WithRetryBudget(budget))
+	require.Error(t, err)
+	assert.Equal(t, 3, budget.Attempts())
+	assert.Equal(t, 1, mocks[2].CallCount())
+}
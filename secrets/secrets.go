@@ -0,0 +1,211 @@
+// Package secrets fetches API keys and other credentials from secret stores,
+// so they do not have to be kept in plain text in the configuration.
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Source fetches secrets by name. The meaning of the name depends on the
+// source: an environment variable, a file, or a secret path in a store.
+type Source interface {
+	Name() string
+	GetSecret(ctx context.Context, name string) (string, error)
+}
+
+// EnvSource reads secrets from environment variables.
+type EnvSource struct{}
+
+// NewEnvSource creates a source reading environment variables.
+func NewEnvSource() EnvSource { return EnvSource{} }
+
+// Name returns "env".
+func (EnvSource) Name() string { return "env" }
+
+// GetSecret returns the value of the environment variable name.
+func (EnvSource) GetSecret(_ context.Context, name string) (string, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok || value == "" {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+	return value, nil
+}
+
+// FileSource reads secrets from files in a directory, one secret per file, as
+// mounted by Docker and Kubernetes secrets.
+type FileSource struct {
+	dir string
+}
+
+// NewFileSource creates a source reading files in dir.
+func NewFileSource(dir string) *FileSource {
+	return &FileSource{dir: dir}
+}
+
+// Name returns "file".
+func (s *FileSource) Name() string { return "file" }
+
+// GetSecret returns the content of the file name, without surrounding whitespace.
+func (s *FileSource) GetSecret(_ context.Context, name string) (string, error) {
+	if name == "" || filepath.Base(name) != name {
+		return "", fmt.Errorf("invalid secret name %q", name)
+	}
+	data, err := os.ReadFile(filepath.Join(s.dir, name))
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// Cache caches the secrets of a source for a fixed time, after which they are
+// fetched again so rotated secrets are picked up. If a refresh fails, the
+// previous value keeps being served until the refresh succeeds.
+type Cache struct {
+	source Source
+	ttl    time.Duration
+	now    func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	value   string
+	fetched time.Time
+}
+
+// NewCache caches the secrets of source for ttl.
+//
+// Example usage:
+//
+//	source := secrets.NewCache(secrets.NewVaultSource("", ""), 5*time.Minute)
+func NewCache(source Source, ttl time.Duration) *Cache {
+	return &Cache{source: source, ttl: ttl, now: time.Now, entries: make(map[string]cacheEntry)}
+}
+
+// Name returns the name of the cached source.
+func (c *Cache) Name() string { return c.source.Name() }
+
+// GetSecret returns the cached secret, fetching it if it is missing or expired.
+func (c *Cache) GetSecret(ctx context.Context, name string) (string, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[name]
+	c.mu.Unlock()
+	if ok && c.now().Sub(entry.fetched) < c.ttl {
+		return entry.value, nil
+	}
+
+	value, err := c.source.GetSecret(ctx, name)
+	if err != nil {
+		if ok {
+			return entry.value, nil
+		}
+		return "", err
+	}
+	c.mu.Lock()
+	c.entries[name] = cacheEntry{value: value, fetched: c.now()}
+	c.mu.Unlock()
+	return value, nil
+}
+
+// Invalidate drops a cached secret, e.g. after the provider rejected it, so
+// the next lookup fetches it again.
+func (c *Cache) Invalidate(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, name)
+}
+
+// storeClient holds the HTTP settings shared by the secret store sources.
+type storeClient struct {
+	httpClient *http.Client
+	endpoint   string
+}
+
+// Option configures a secret store source.
+type Option func(*storeClient)
+
+// WithHTTPClient sets the HTTP client used to reach the secret store.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *storeClient) {
+		c.httpClient = client
+	}
+}
+
+// WithEndpoint overrides the secret store URL, e.g. for a proxy or a local emulator.
+func WithEndpoint(endpoint string) Option {
+	return func(c *storeClient) {
+		c.endpoint = strings.TrimSuffix(endpoint, "/")
+	}
+}
+
+// newStoreClient creates the HTTP settings of a source with the given default endpoint.
+func newStoreClient(endpoint string, opts []Option) storeClient {
+	c := storeClient{httpClient: &http.Client{Timeout: 30 * time.Second}, endpoint: strings.TrimSuffix(endpoint, "/")}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+// do sends a request and decodes the JSON response into v.
+func (c storeClient) do(req *http.Request, v interface{}) error {
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("secret request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("failed to read secret response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("secret store error: status code %d - %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to parse secret response: %w", err)
+	}
+	return nil
+}
+
+// splitField splits a "name#field" secret reference. Secrets stored as JSON
+// objects hold several values; the field selects one of them.
+func splitField(ref string) (name, field string) {
+	name, field, _ = strings.Cut(ref, "#")
+	return name, field
+}
+
+// selectField returns the field of a JSON object secret, or the secret itself
+// when no field is requested.
+func selectField(secret, field string) (string, error) {
+	if field == "" {
+		return secret, nil
+	}
+	var values map[string]interface{}
+	if err := json.Unmarshal([]byte(secret), &values); err != nil {
+		return "", fmt.Errorf("secret is not a JSON object: %w", err)
+	}
+	return lookupField(values, field)
+}
+
+func lookupField(values map[string]interface{}, field string) (string, error) {
+	value, ok := values[field]
+	if !ok {
+		return "", fmt.Errorf("secret has no field %q", field)
+	}
+	s, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("secret field %q is not a string", field)
+	}
+	return s, nil
+}
@@ -0,0 +1,154 @@
+package secrets
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingSource returns a numbered value on every fetch.
+type countingSource struct {
+	calls int
+	fail  bool
+}
+
+func (s *countingSource) Name() string { return "counting" }
+
+func (s *countingSource) GetSecret(_ context.Context, name string) (string, error) {
+	if s.fail {
+		return "", fmt.Errorf("unavailable")
+	}
+	s.calls++
+	return fmt.Sprintf("%s-%d", name, s.calls), nil
+}
+
+func TestEnvAndFileSources(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("TEST_SECRET_KEY", "from-env")
+	value, err := NewEnvSource().GetSecret(ctx, "TEST_SECRET_KEY")
+	require.NoError(t, err)
+	assert.Equal(t, "from-env", value)
+	_, err = NewEnvSource().GetSecret(ctx, "TEST_SECRET_MISSING")
+	assert.Error(t, err)
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "openai"), []byte("from-file\n"), 0o600))
+	files := NewFileSource(dir)
+	value, err = files.GetSecret(ctx, "openai")
+	require.NoError(t, err)
+	assert.Equal(t, "from-file", value)
+	_, err = files.GetSecret(ctx, "../openai")
+	assert.Error(t, err)
+}
+
+func TestCache(t *testing.T) {
+	ctx := context.Background()
+	source := &countingSource{}
+	cache := NewCache(source, time.Minute)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	value, _ := cache.GetSecret(ctx, "key")
+	assert.Equal(t, "key-1", value)
+	value, _ = cache.GetSecret(ctx, "key")
+	assert.Equal(t, "key-1", value, "cached until the TTL expires")
+
+	now = now.Add(2 * time.Minute)
+	value, _ = cache.GetSecret(ctx, "key")
+	assert.Equal(t, "key-2", value, "rotated value is fetched after the TTL")
+
+	now = now.Add(2 * time.Minute)
+	source.fail = true
+	value, err := cache.GetSecret(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, "key-2", value, "stale value is served while the source fails")
+
+	cache.Invalidate("key")
+	_, err = cache.GetSecret(ctx, "key")
+	assert.Error(t, err)
+}
+
+func TestVaultSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "vault-token", r.Header.Get("X-Vault-Token"))
+		switch r.URL.Path {
+		case "/v1/secret/data/llm":
+			w.Write([]byte(`{"data":{"data":{"openai":"sk-openai","anthropic":"sk-ant"},"metadata":{"version":2}}}`))
+		case "/v1/kv/single":
+			w.Write([]byte(`{"data":{"value":"only"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	vault := NewVaultSource(server.URL, "vault-token")
+	ctx := context.Background()
+	value, err := vault.GetSecret(ctx, "secret/data/llm#openai")
+	require.NoError(t, err)
+	assert.Equal(t, "sk-openai", value)
+	value, err = vault.GetSecret(ctx, "kv/single")
+	require.NoError(t, err)
+	assert.Equal(t, "only", value)
+	_, err = vault.GetSecret(ctx, "secret/data/llm")
+	assert.Error(t, err, "a field is required for secrets with several values")
+	_, err = vault.GetSecret(ctx, "missing")
+	assert.Error(t, err)
+}
+
+func TestGCPSecretSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer gcp-token", r.Header.Get("Authorization"))
+		payload := "sk-latest"
+		switch r.URL.Path {
+		case "/v1/projects/my-project/secrets/openai/versions/3:access":
+			payload = "sk-v3"
+		case "/v1/projects/my-project/secrets/keys/versions/latest:access":
+			payload = `{"openai":"sk-json"}`
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"payload": map[string]string{"data": base64.StdEncoding.EncodeToString([]byte(payload))},
+		})
+	}))
+	defer server.Close()
+
+	token := func(context.Context) (string, error) { return "gcp-token", nil }
+	gcp := NewGCPSecretSource("my-project", token, WithEndpoint(server.URL))
+	ctx := context.Background()
+	for ref, want := range map[string]string{"openai": "sk-latest", "openai@3": "sk-v3", "keys#openai": "sk-json"} {
+		value, err := gcp.GetSecret(ctx, ref)
+		require.NoError(t, err, ref)
+		assert.Equal(t, want, value, ref)
+	}
+}
+
+func TestAWSSecretsSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secretsmanager.GetSecretValue", r.Header.Get("X-Amz-Target"))
+		assert.Equal(t, "session", r.Header.Get("X-Amz-Security-Token"))
+		auth := r.Header.Get("Authorization")
+		assert.True(t, strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/20240102/eu-west-1/secretsmanager/aws4_request, SignedHeaders=content-type;host;x-amz-date;x-amz-security-token;x-amz-target, Signature="), auth)
+		var body struct{ SecretId string }
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "prod/llm", body.SecretId)
+		w.Write([]byte(`{"SecretString":"{\"openai\":\"sk-aws\"}"}`))
+	}))
+	defer server.Close()
+
+	aws := NewAWSSecretsSource("eu-west-1", AWSCredentials{AccessKeyID: "AKID", SecretAccessKey: "secret", SessionToken: "session"}, WithEndpoint(server.URL))
+	aws.now = func() time.Time { return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC) }
+	value, err := aws.GetSecret(context.Background(), "prod/llm#openai")
+	require.NoError(t, err)
+	assert.Equal(t, "sk-aws", value)
+}
@@ -0,0 +1,285 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// VaultSource reads secrets from HashiCorp Vault's KV secrets engine.
+// Secrets are referenced as "path#field", e.g. "secret/data/llm#openai"; the
+// field may be omitted when the secret holds a single value. Both KV version
+// 1 and 2 responses are supported.
+type VaultSource struct {
+	token  string
+	client storeClient
+}
+
+// NewVaultSource creates a Vault source. The address and token default to the
+// VAULT_ADDR and VAULT_TOKEN environment variables when empty.
+func NewVaultSource(addr, token string, opts ...Option) *VaultSource {
+	if addr == "" {
+		addr = os.Getenv("VAULT_ADDR")
+	}
+	if token == "" {
+		token = os.Getenv("VAULT_TOKEN")
+	}
+	return &VaultSource{token: token, client: newStoreClient(addr, opts)}
+}
+
+// Name returns "vault".
+func (s *VaultSource) Name() string { return "vault" }
+
+// GetSecret implements Source.
+func (s *VaultSource) GetSecret(ctx context.Context, ref string) (string, error) {
+	if s.client.endpoint == "" {
+		return "", fmt.Errorf("vault address is not set")
+	}
+	path, field := splitField(ref)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.client.endpoint+"/v1/"+strings.TrimPrefix(path, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", s.token)
+
+	var response struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := s.client.do(req, &response); err != nil {
+		return "", err
+	}
+	values := response.Data
+	// KV version 2 nests the values under data.data
+	if nested, ok := values["data"].(map[string]interface{}); ok {
+		values = nested
+	}
+	if field == "" {
+		if len(values) != 1 {
+			return "", fmt.Errorf("vault secret %s has %d fields; select one with %s#field", path, len(values), path)
+		}
+		for key := range values {
+			field = key
+		}
+	}
+	return lookupField(values, field)
+}
+
+// TokenFunc returns an OAuth access token for a cloud API.
+type TokenFunc func(ctx context.Context) (string, error)
+
+// GCPSecretSource reads secrets from Google Cloud Secret Manager. Secrets are
+// referenced by name, optionally with a version and a JSON field:
+// "openai-key", "openai-key@3" or "llm-keys#openai". The latest version is
+// used by default.
+type GCPSecretSource struct {
+	project string
+	token   TokenFunc
+	client  storeClient
+}
+
+// NewGCPSecretSource creates a Secret Manager source for a project. When
+// token is nil, access tokens are requested from the GCE metadata server of
+// the instance's service account.
+func NewGCPSecretSource(project string, token TokenFunc, opts ...Option) *GCPSecretSource {
+	s := &GCPSecretSource{project: project, token: token, client: newStoreClient("https://secretmanager.googleapis.com", opts)}
+	if s.token == nil {
+		s.token = s.metadataToken
+	}
+	return s
+}
+
+// Name returns "gcp".
+func (s *GCPSecretSource) Name() string { return "gcp" }
+
+// GetSecret implements Source.
+func (s *GCPSecretSource) GetSecret(ctx context.Context, ref string) (string, error) {
+	ref, field := splitField(ref)
+	name, version, ok := strings.Cut(ref, "@")
+	if !ok {
+		version = "latest"
+	}
+	token, err := s.token(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get access token: %w", err)
+	}
+	endpoint := fmt.Sprintf("%s/v1/projects/%s/secrets/%s/versions/%s:access",
+		s.client.endpoint, url.PathEscape(s.project), url.PathEscape(name), url.PathEscape(version))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	var response struct {
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}
+	if err := s.client.do(req, &response); err != nil {
+		return "", err
+	}
+	data, err := base64.StdEncoding.DecodeString(response.Payload.Data)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode secret payload: %w", err)
+	}
+	return selectField(string(data), field)
+}
+
+// metadataToken requests an access token from the GCE metadata server.
+func (s *GCPSecretSource) metadataToken(ctx context.Context) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		"http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	var response struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := s.client.do(req, &response); err != nil {
+		return "", err
+	}
+	return response.AccessToken, nil
+}
+
+// AWSCredentials are the credentials used to sign AWS requests.
+type AWSCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string // Only set for temporary credentials
+}
+
+// AWSSecretsSource reads secrets from AWS Secrets Manager. Secrets are
+// referenced by name or ARN, optionally with a JSON field: "prod/openai" or
+// "prod/llm-keys#openai".
+type AWSSecretsSource struct {
+	region string
+	creds  AWSCredentials
+	now    func() time.Time
+	client storeClient
+}
+
+// NewAWSSecretsSource creates a Secrets Manager source. The region defaults to
+// the AWS_REGION or AWS_DEFAULT_REGION environment variable and the
+// credentials to AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and
+// AWS_SESSION_TOKEN when empty.
+func NewAWSSecretsSource(region string, creds AWSCredentials, opts ...Option) *AWSSecretsSource {
+	if region == "" {
+		region = os.Getenv("AWS_REGION")
+	}
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if creds.AccessKeyID == "" {
+		creds = AWSCredentials{
+			AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		}
+	}
+	return &AWSSecretsSource{
+		region: region,
+		creds:  creds,
+		now:    time.Now,
+		client: newStoreClient(fmt.Sprintf("https://secretsmanager.%s.amazonaws.com", region), opts),
+	}
+}
+
+// Name returns "aws".
+func (s *AWSSecretsSource) Name() string { return "aws" }
+
+// GetSecret implements Source.
+func (s *AWSSecretsSource) GetSecret(ctx context.Context, ref string) (string, error) {
+	if s.creds.AccessKeyID == "" || s.creds.SecretAccessKey == "" {
+		return "", fmt.Errorf("AWS credentials are not set")
+	}
+	name, field := splitField(ref)
+	payload, err := json.Marshal(map[string]string{"SecretId": name})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.client.endpoint+"/", bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	s.sign(req, payload)
+
+	var response struct {
+		SecretString string `json:"SecretString"`
+	}
+	if err := s.client.do(req, &response); err != nil {
+		return "", err
+	}
+	return selectField(response.SecretString, field)
+}
+
+// sign adds an AWS Signature Version 4 to the request.
+func (s *AWSSecretsSource) sign(req *http.Request, payload []byte) {
+	now := s.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	if s.creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.creds.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for key, values := range req.Header {
+		name := strings.ToLower(key)
+		if name == "content-type" || strings.HasPrefix(name, "x-amz-") {
+			headers[name] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method, path, req.URL.RawQuery, canonicalHeaders.String(), signedHeaders, hashHex(payload),
+	}, "\n")
+	scope := date + "/" + s.region + "/secretsmanager/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hashHex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+s.creds.SecretAccessKey), date)
+	for _, part := range []string{s.region, "secretsmanager", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.creds.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -20,7 +20,107 @@ type (
 
 	// RetryStrategy defines the interface for handling stream interruptions.
 	RetryStrategy = llm.RetryStrategy
+
+	// ToolCallDelta is an incremental piece of a streamed tool call.
+	ToolCallDelta = llm.ToolCallDelta
+
+	// ToolCallAccumulator assembles streamed tool call deltas into complete calls.
+	ToolCallAccumulator = llm.ToolCallAccumulator
+
+	// StreamResult is the text and tool calls of a collected stream.
+	StreamResult = llm.StreamResult
+
+	// CollectOption configures CollectStream.
+	CollectOption = llm.CollectOption
+
+	// StopCondition ends a stream on the client side once the text matches it.
+	StopCondition = llm.StopCondition
+
+	// StreamDone is the usage, finish reason and model reported at the end of a stream.
+	StreamDone = llm.StreamDone
+
+	// BackpressurePolicy is what a buffered stream does when its consumer falls behind.
+	BackpressurePolicy = llm.BackpressurePolicy
+
+	// AbortableStream is a stream that can be stopped while it is read, e.g. by a chat's stop button.
+	AbortableStream = llm.AbortableStream
+)
+
+// Types of stream tokens that carry no text.
+const (
+	TokenTypeToolCall = llm.TokenTypeToolCall // Carries a tool call delta
+	TokenTypeDone     = llm.TokenTypeDone     // Ends the stream with its StreamDone metadata
+)
+
+// Reasons a response or collected stream finished.
+const (
+	FinishReasonStop      = llm.FinishReasonStop
+	FinishReasonDeadline  = llm.FinishReasonDeadline
+	FinishReasonLength    = llm.FinishReasonLength
+	FinishReasonToolCalls = llm.FinishReasonToolCalls
+	FinishReasonAborted   = llm.FinishReasonAborted
+)
+
+// Backpressure policies of buffered streams.
+const (
+	BackpressureBlock      = llm.BackpressureBlock      // Stop reading until the consumer catches up
+	BackpressureDropOldest = llm.BackpressureDropOldest // Discard the oldest buffered text
+	BackpressureCoalesce   = llm.BackpressureCoalesce   // Merge new text into the newest buffered token
 )
 
 // StreamOption is a function type that modifies StreamConfig
 type StreamOption = llm.StreamOption
+
+// Re-export stream adapters from the llm package
+var (
+	// ConsumeStream calls a function for every token of a stream and closes it.
+	ConsumeStream = llm.ConsumeStream
+
+	// StreamWithCallback streams a response and calls a function for every token.
+	StreamWithCallback = llm.StreamWithCallback
+
+	// StreamToWriter streams a response into an io.Writer and returns the complete text.
+	StreamToWriter = llm.StreamToWriter
+
+	// StreamToChannel delivers the tokens of a stream on a channel.
+	StreamToChannel = llm.StreamToChannel
+
+	// TeeStream splits a stream into several streams that each receive every token.
+	TeeStream = llm.TeeStream
+
+	// CollectStream reads a stream to the end, returning the partial text when the deadline approaches.
+	CollectStream = llm.CollectStream
+
+	// WithDeadlineMargin stops collecting a stream some time before the context deadline.
+	WithDeadlineMargin = llm.WithDeadlineMargin
+
+	// WithCancelOnDeadline drops the provider connection when a collected stream is cut short.
+	WithCancelOnDeadline = llm.WithCancelOnDeadline
+
+	// WithStopConditions ends a stream as soon as a stop condition is met.
+	WithStopConditions = llm.WithStopConditions
+
+	// StopStream wraps a stream so it ends as soon as a stop condition is met.
+	StopStream = llm.StopStream
+
+	// StopOnSequence stops a stream before any of the sequences.
+	StopOnSequence = llm.StopOnSequence
+
+	// StopOnRegexp stops a stream before the first match of an expression.
+	StopOnRegexp = llm.StopOnRegexp
+
+	// StopWhen stops a stream once a callback accepts the text received.
+	StopWhen = llm.StopWhen
+
+	// WithBackpressure reads a stream in the background, applying a policy when the consumer falls behind.
+	WithBackpressure = llm.WithBackpressure
+
+	// WithBufferSize sets how many tokens a stream read with WithBackpressure buffers.
+	WithBufferSize = llm.WithBufferSize
+
+	// BufferStream reads a stream in the background into a bounded buffer.
+	BufferStream = llm.BufferStream
+
+	// StartStream starts streaming a response, returning a stream that can be aborted.
+	StartStream = llm.StartStream
+)
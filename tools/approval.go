@@ -0,0 +1,168 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrRejected is returned, wrapped, when a tool call is rejected by the approver.
+var ErrRejected = errors.New("tool call rejected")
+
+// ApprovalRequest describes a tool call awaiting confirmation.
+type ApprovalRequest struct {
+	Tool      string                 `json:"tool"`
+	Arguments map[string]interface{} `json:"arguments"`
+}
+
+// Decision is the outcome of an approval request.
+type Decision struct {
+	Approved bool   `json:"approved"`
+	Reason   string `json:"reason,omitempty"`
+	// Arguments, when set on an approval, replace the arguments of the call,
+	// letting the reviewer correct them
+	Arguments map[string]interface{} `json:"arguments,omitempty"`
+	DecidedAt time.Time              `json:"decided_at"`
+}
+
+// Approver confirms tool calls that require approval. Approve blocks until a
+// decision is made or the context is cancelled.
+type Approver interface {
+	Approve(ctx context.Context, req ApprovalRequest) (Decision, error)
+}
+
+// ApproverFunc adapts a function to the Approver interface.
+type ApproverFunc func(ctx context.Context, req ApprovalRequest) (Decision, error)
+
+// Approve calls f.
+func (f ApproverFunc) Approve(ctx context.Context, req ApprovalRequest) (Decision, error) {
+	return f(ctx, req)
+}
+
+// PendingApproval is a tool call paused until the application approves or
+// rejects it. Exactly one of Approve, ApproveWith or Reject should be called.
+type PendingApproval struct {
+	Request  ApprovalRequest
+	decision chan Decision
+}
+
+// Approve resumes the tool call.
+func (p *PendingApproval) Approve() {
+	p.resolve(Decision{Approved: true})
+}
+
+// ApproveWith resumes the tool call with corrected arguments.
+func (p *PendingApproval) ApproveWith(arguments map[string]interface{}) {
+	p.resolve(Decision{Approved: true, Arguments: arguments})
+}
+
+// Reject cancels the tool call; the reason is reported to the model.
+func (p *PendingApproval) Reject(reason string) {
+	p.resolve(Decision{Approved: false, Reason: reason})
+}
+
+func (p *PendingApproval) resolve(d Decision) {
+	select {
+	case p.decision <- d:
+	default:
+	}
+}
+
+// ApprovalQueue is an Approver that surfaces tool calls on a channel, so an
+// application can present them to a person and resume or reject them later,
+// e.g. from a web handler.
+//
+// Example usage:
+//
+//	queue := tools.NewApprovalQueue()
+//	registry.SetApprover(queue)
+//	go func() {
+//	    for pending := range queue.Requests() {
+//	        if confirm(pending.Request) {
+//	            pending.Approve()
+//	        } else {
+//	            pending.Reject("declined by operator")
+//	        }
+//	    }
+//	}()
+type ApprovalQueue struct {
+	requests chan *PendingApproval
+}
+
+// NewApprovalQueue creates an approval queue.
+func NewApprovalQueue() *ApprovalQueue {
+	return &ApprovalQueue{requests: make(chan *PendingApproval)}
+}
+
+// Requests returns the channel of tool calls awaiting a decision.
+func (q *ApprovalQueue) Requests() <-chan *PendingApproval {
+	return q.requests
+}
+
+// Approve implements Approver, blocking until the pending call is resolved.
+func (q *ApprovalQueue) Approve(ctx context.Context, req ApprovalRequest) (Decision, error) {
+	pending := &PendingApproval{Request: req, decision: make(chan Decision, 1)}
+	select {
+	case q.requests <- pending:
+	case <-ctx.Done():
+		return Decision{}, ctx.Err()
+	}
+	select {
+	case d := <-pending.decision:
+		return d, nil
+	case <-ctx.Done():
+		return Decision{}, ctx.Err()
+	}
+}
+
+// Execute runs the named tool like Call, asking the registry's approver first
+// when the tool requires approval. The decision, if any, is returned so that
+// callers can record it; a rejection is returned as an error wrapping
+// ErrRejected.
+func (r *Registry) Execute(ctx context.Context, name string, arguments interface{}) (string, *Decision, error) {
+	tool, ok := r.Get(name)
+	if !ok {
+		return "", nil, fmt.Errorf("unknown tool %q", name)
+	}
+	args, err := decodeArguments(arguments)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid arguments for tool %q: %w", name, err)
+	}
+	if !tool.RequiresApproval {
+		output, err := tool.Handler(ctx, args)
+		return output, nil, err
+	}
+
+	r.mu.RLock()
+	approver := r.approver
+	r.mu.RUnlock()
+	if approver == nil {
+		return "", nil, fmt.Errorf("tool %q requires approval but no approver is configured", name)
+	}
+	decision, err := approver.Approve(ctx, ApprovalRequest{Tool: name, Arguments: args})
+	if err != nil {
+		return "", nil, fmt.Errorf("approval of tool %q failed: %w", name, err)
+	}
+	if decision.DecidedAt.IsZero() {
+		decision.DecidedAt = time.Now()
+	}
+	if !decision.Approved {
+		if decision.Reason != "" {
+			return "", &decision, fmt.Errorf("%w: %s", ErrRejected, decision.Reason)
+		}
+		return "", &decision, ErrRejected
+	}
+	if decision.Arguments != nil {
+		args = decision.Arguments
+	}
+	output, err := tool.Handler(ctx, args)
+	return output, &decision, err
+}
+
+// SetApprover sets the approver consulted for tools that require approval.
+func (r *Registry) SetApprover(approver Approver) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.approver = approver
+}
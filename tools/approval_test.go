@@ -0,0 +1,74 @@
+package tools
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func deleteTool(deleted *[]string) Tool {
+	return Tool{
+		Name:             "delete_file",
+		Description:      "Deletes a file",
+		RequiresApproval: true,
+		Handler: func(ctx context.Context, args map[string]interface{}) (string, error) {
+			path, err := StringArg(args, "path")
+			if err != nil {
+				return "", err
+			}
+			*deleted = append(*deleted, path)
+			return "deleted " + path, nil
+		},
+	}
+}
+
+func TestApproval(t *testing.T) {
+	ctx := context.Background()
+	var deleted []string
+	registry, err := NewRegistry(deleteTool(&deleted))
+	require.NoError(t, err)
+
+	_, err = registry.Call(ctx, "delete_file", `{"path": "a.txt"}`)
+	assert.ErrorContains(t, err, "no approver")
+
+	queue := NewApprovalQueue()
+	registry.SetApprover(queue)
+	go func() {
+		for pending := range queue.Requests() {
+			switch pending.Request.Arguments["path"] {
+			case "/etc/passwd":
+				pending.Reject("system files are off limits")
+			case "tmp.txt":
+				pending.ApproveWith(map[string]interface{}{"path": "/tmp/tmp.txt"})
+			default:
+				pending.Approve()
+			}
+		}
+	}()
+
+	out, decision, err := registry.Execute(ctx, "delete_file", `{"path": "a.txt"}`)
+	require.NoError(t, err)
+	assert.Equal(t, "deleted a.txt", out)
+	require.NotNil(t, decision)
+	assert.True(t, decision.Approved)
+	assert.False(t, decision.DecidedAt.IsZero())
+
+	_, decision, err = registry.Execute(ctx, "delete_file", `{"path": "/etc/passwd"}`)
+	assert.ErrorIs(t, err, ErrRejected)
+	assert.Equal(t, "system files are off limits", decision.Reason)
+
+	out, _, err = registry.Execute(ctx, "delete_file", `{"path": "tmp.txt"}`)
+	require.NoError(t, err)
+	assert.Equal(t, "deleted /tmp/tmp.txt", out)
+	assert.Equal(t, []string{"a.txt", "/tmp/tmp.txt"}, deleted)
+
+	// A call nobody answers is abandoned when the context ends
+	registry.SetApprover(NewApprovalQueue())
+	timeout, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	_, _, err = registry.Execute(timeout, "delete_file", `{"path": "b.txt"}`)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
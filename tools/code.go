@@ -0,0 +1,291 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Interpreter describes how to run code of a language.
+type Interpreter struct {
+	// Command runs the source file, which is appended as the last argument
+	Command []string
+	// Extension is the file extension of source files, including the dot
+	Extension string
+}
+
+// DefaultInterpreters are the languages supported out of the box. An
+// interpreter is only usable when its command is installed.
+var DefaultInterpreters = map[string]Interpreter{
+	"python":     {Command: []string{"python3", "-I", "-B"}, Extension: ".py"},
+	"javascript": {Command: []string{"node"}, Extension: ".js"},
+	"bash":       {Command: []string{"bash", "--noprofile", "--norc"}, Extension: ".sh"},
+}
+
+// ExecutionResult is the outcome of running code.
+type ExecutionResult struct {
+	Stdout   string        `json:"stdout"`
+	Stderr   string        `json:"stderr"`
+	ExitCode int           `json:"exit_code"`
+	Duration time.Duration `json:"duration"`
+	TimedOut bool          `json:"timed_out"`
+	// Truncated reports that the output exceeded the output limit
+	Truncated bool `json:"truncated"`
+}
+
+// CodeSandbox runs model-generated code in a separate process with resource
+// limits: wall-clock time, CPU time, address space, file size and output
+// size. Each run gets an empty temporary working directory and a minimal
+// environment without the parent's variables, so API keys are not exposed.
+//
+// The process limits do not isolate the filesystem or the network. Use
+// WithCommandPrefix to run the interpreter under an isolation tool such as
+// bwrap, firejail or nsjail when executing untrusted code.
+type CodeSandbox struct {
+	interpreters map[string]Interpreter
+	timeout      time.Duration
+	cpuSeconds   int
+	memoryBytes  int64
+	fileBytes    int64
+	maxOutput    int
+	prefix       []string
+	env          []string
+}
+
+// SandboxOption configures a CodeSandbox.
+type SandboxOption func(*CodeSandbox)
+
+// WithInterpreter adds or replaces the interpreter of a language.
+func WithInterpreter(language string, interpreter Interpreter) SandboxOption {
+	return func(s *CodeSandbox) {
+		s.interpreters[language] = interpreter
+	}
+}
+
+// WithExecutionTimeout limits the wall-clock time of a run. Defaults to 10 seconds.
+func WithExecutionTimeout(timeout time.Duration) SandboxOption {
+	return func(s *CodeSandbox) {
+		s.timeout = timeout
+	}
+}
+
+// WithCPULimit limits the CPU time of a run, in seconds. Defaults to 5.
+func WithCPULimit(seconds int) SandboxOption {
+	return func(s *CodeSandbox) {
+		s.cpuSeconds = seconds
+	}
+}
+
+// WithMemoryLimit limits the address space of a run. Defaults to 512 MiB;
+// 0 disables the limit, which some runtimes such as node require.
+func WithMemoryLimit(bytes int64) SandboxOption {
+	return func(s *CodeSandbox) {
+		s.memoryBytes = bytes
+	}
+}
+
+// WithMaxOutput limits the size of stdout and stderr kept from a run.
+// Defaults to 64 KiB each.
+func WithMaxOutput(bytes int) SandboxOption {
+	return func(s *CodeSandbox) {
+		s.maxOutput = bytes
+	}
+}
+
+// WithCommandPrefix runs the interpreter through a wrapper command, e.g.
+// []string{"bwrap", "--ro-bind", "/", "/", "--unshare-net", "--"}.
+func WithCommandPrefix(prefix ...string) SandboxOption {
+	return func(s *CodeSandbox) {
+		s.prefix = prefix
+	}
+}
+
+// WithSandboxEnv adds environment variables, as "KEY=value", to runs.
+func WithSandboxEnv(env ...string) SandboxOption {
+	return func(s *CodeSandbox) {
+		s.env = append(s.env, env...)
+	}
+}
+
+// NewCodeSandbox creates a sandbox with the default interpreters.
+func NewCodeSandbox(opts ...SandboxOption) *CodeSandbox {
+	s := &CodeSandbox{
+		interpreters: make(map[string]Interpreter, len(DefaultInterpreters)),
+		timeout:      10 * time.Second,
+		cpuSeconds:   5,
+		memoryBytes:  512 << 20,
+		fileBytes:    16 << 20,
+		maxOutput:    64 << 10,
+	}
+	for language, interpreter := range DefaultInterpreters {
+		s.interpreters[language] = interpreter
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Languages returns the configured languages whose interpreter is installed.
+func (s *CodeSandbox) Languages() []string {
+	var languages []string
+	for language, interpreter := range s.interpreters {
+		if len(interpreter.Command) > 0 {
+			if _, err := exec.LookPath(interpreter.Command[0]); err == nil {
+				languages = append(languages, language)
+			}
+		}
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// Run executes code and returns its output. A non-zero exit status or a
+// timeout is reported in the result, not as an error; errors are reserved
+// for failures to start the run.
+func (s *CodeSandbox) Run(ctx context.Context, language, code string) (*ExecutionResult, error) {
+	interpreter, ok := s.interpreters[strings.ToLower(language)]
+	if !ok || len(interpreter.Command) == 0 {
+		return nil, fmt.Errorf("unsupported language %q", language)
+	}
+
+	dir, err := os.MkdirTemp("", "gollm-sandbox-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sandbox directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	source := filepath.Join(dir, "main"+interpreter.Extension)
+	if err := os.WriteFile(source, []byte(code), 0o600); err != nil {
+		return nil, fmt.Errorf("failed to write source file: %w", err)
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	args := append(append(append([]string{}, s.prefix...), interpreter.Command...), source)
+	cmd := s.command(runCtx, args)
+	cmd.Dir = dir
+	cmd.Env = append([]string{"PATH=" + os.Getenv("PATH"), "HOME=" + dir, "TMPDIR=" + dir, "LANG=C.UTF-8"}, s.env...)
+	stdout := &limitedBuffer{limit: s.maxOutput}
+	stderr := &limitedBuffer{limit: s.maxOutput}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	start := time.Now()
+	err = cmd.Run()
+	result := &ExecutionResult{
+		Stdout:    stdout.String(),
+		Stderr:    stderr.String(),
+		Duration:  time.Since(start),
+		TimedOut:  errors.Is(runCtx.Err(), context.DeadlineExceeded),
+		Truncated: stdout.truncated || stderr.truncated,
+	}
+	if ctx.Err() != nil {
+		return result, ctx.Err()
+	}
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case errors.As(err, &exitErr):
+		result.ExitCode = exitErr.ExitCode()
+	case result.TimedOut:
+		result.ExitCode = -1
+	default:
+		return nil, fmt.Errorf("failed to run %s code: %w", language, err)
+	}
+	return result, nil
+}
+
+// limitedBuffer keeps the first limit bytes written and discards the rest.
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if remaining := b.limit - b.buf.Len(); remaining < len(p) {
+		b.truncated = true
+		if remaining > 0 {
+			b.buf.Write(p[:remaining])
+		}
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+func (b *limitedBuffer) String() string {
+	return b.buf.String()
+}
+
+// NewCodeTool creates an "execute_code" tool that runs code in the sandbox
+// and returns its output.
+//
+// Example usage:
+//
+//	sandbox := tools.NewCodeSandbox(tools.WithExecutionTimeout(5 * time.Second))
+//	registry, _ := tools.NewRegistry(tools.NewCodeTool(sandbox))
+func NewCodeTool(sandbox *CodeSandbox) Tool {
+	languages := sandbox.Languages()
+	return Tool{
+		Name:        "execute_code",
+		Description: "Executes a program and returns its stdout, stderr and exit code. Use it for calculations and data processing. Print the values you need; each run starts from scratch.",
+		Parameters: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"language": map[string]interface{}{
+					"type": "string",
+					"enum": languages,
+				},
+				"code": map[string]interface{}{
+					"type":        "string",
+					"description": "The complete source code to run",
+				},
+			},
+			"required": []string{"language", "code"},
+		},
+		Handler: func(ctx context.Context, args map[string]interface{}) (string, error) {
+			language, err := StringArg(args, "language")
+			if err != nil {
+				return "", err
+			}
+			code, err := StringArg(args, "code")
+			if err != nil {
+				return "", err
+			}
+			result, err := sandbox.Run(ctx, language, code)
+			if err != nil {
+				return "", err
+			}
+			return FormatExecutionResult(result), nil
+		},
+	}
+}
+
+// FormatExecutionResult renders an execution result for the model.
+func FormatExecutionResult(result *ExecutionResult) string {
+	var b strings.Builder
+	switch {
+	case result.TimedOut:
+		b.WriteString("Execution timed out.\n")
+	default:
+		fmt.Fprintf(&b, "Exit code: %d\n", result.ExitCode)
+	}
+	if result.Stdout != "" {
+		fmt.Fprintf(&b, "Stdout:\n%s\n", strings.TrimRight(result.Stdout, "\n"))
+	}
+	if result.Stderr != "" {
+		fmt.Fprintf(&b, "Stderr:\n%s\n", strings.TrimRight(result.Stderr, "\n"))
+	}
+	if result.Truncated {
+		b.WriteString("[output truncated]\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
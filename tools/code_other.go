@@ -0,0 +1,17 @@
+//go:build !unix
+
+package tools
+
+import (
+	"context"
+	"os/exec"
+	"time"
+)
+
+// command builds the command without process limits, which are only
+// supported on Unix systems; the execution timeout still applies.
+func (s *CodeSandbox) command(ctx context.Context, args []string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.WaitDelay = time.Second
+	return cmd
+}
@@ -0,0 +1,58 @@
+package tools
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodeSandbox(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash is not installed")
+	}
+	ctx := context.Background()
+	t.Setenv("SECRET_API_KEY", "sk-test")
+
+	t.Run("Output", func(t *testing.T) {
+		sandbox := NewCodeSandbox()
+		result, err := sandbox.Run(ctx, "bash", "echo hello; echo oops >&2; echo \"key=$SECRET_API_KEY\"; exit 3")
+		require.NoError(t, err)
+		assert.Equal(t, "hello\nkey=\n", result.Stdout)
+		assert.Equal(t, "oops\n", result.Stderr)
+		assert.Equal(t, 3, result.ExitCode)
+		assert.False(t, result.TimedOut)
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		sandbox := NewCodeSandbox(WithExecutionTimeout(200 * time.Millisecond))
+		start := time.Now()
+		result, err := sandbox.Run(ctx, "bash", "sleep 5 & sleep 5")
+		require.NoError(t, err)
+		assert.True(t, result.TimedOut)
+		assert.Less(t, time.Since(start), 3*time.Second)
+	})
+
+	t.Run("OutputLimit", func(t *testing.T) {
+		sandbox := NewCodeSandbox(WithMaxOutput(8))
+		result, err := sandbox.Run(ctx, "bash", "printf '0123456789abcdef'")
+		require.NoError(t, err)
+		assert.Equal(t, "01234567", result.Stdout)
+		assert.True(t, result.Truncated)
+	})
+
+	t.Run("Tool", func(t *testing.T) {
+		tool := NewCodeTool(NewCodeSandbox())
+		out, err := tool.Handler(ctx, map[string]interface{}{"language": "bash", "code": "echo $((6 * 7))"})
+		require.NoError(t, err)
+		assert.Equal(t, "Exit code: 0\nStdout:\n42", out)
+
+		_, err = tool.Handler(ctx, map[string]interface{}{"language": "cobol", "code": "DISPLAY 'HI'."})
+		assert.ErrorContains(t, err, "unsupported language")
+		assert.True(t, strings.Contains(strings.Join(NewCodeSandbox().Languages(), ","), "bash"))
+	})
+}
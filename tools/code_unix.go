@@ -0,0 +1,34 @@
+//go:build unix
+
+package tools
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// command builds the sandboxed command. Resource limits are applied with the
+// shell's ulimit before exec'ing the interpreter, and the process runs in its
+// own process group so that a timeout also kills any children it spawned.
+func (s *CodeSandbox) command(ctx context.Context, args []string) *exec.Cmd {
+	limits := ""
+	if s.cpuSeconds > 0 {
+		limits += fmt.Sprintf("ulimit -t %d; ", s.cpuSeconds)
+	}
+	if s.memoryBytes > 0 {
+		limits += fmt.Sprintf("ulimit -v %d; ", s.memoryBytes/1024)
+	}
+	if s.fileBytes > 0 {
+		limits += fmt.Sprintf("ulimit -f %d; ", s.fileBytes/512)
+	}
+	cmd := exec.CommandContext(ctx, "/bin/sh", append([]string{"-c", limits + `exec "$@"`, "sandbox"}, args...)...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	cmd.WaitDelay = time.Second
+	return cmd
+}
@@ -0,0 +1,369 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Page is the readable content of a fetched URL.
+type Page struct {
+	URL         string `json:"url"` // Final URL after redirects
+	Title       string `json:"title,omitempty"`
+	Text        string `json:"text"`
+	ContentType string `json:"content_type"`
+	Truncated   bool   `json:"truncated"` // The body or the text exceeded a size limit
+}
+
+// Fetcher retrieves web pages for agents within configurable limits. By
+// default only public http and https addresses can be fetched, so models
+// cannot reach internal services.
+type Fetcher struct {
+	client          *http.Client
+	allowed         []string
+	denied          []string
+	maxBytes        int64
+	maxChars        int
+	privateNetworks bool
+	userAgent       string
+}
+
+// FetchOption configures a Fetcher.
+type FetchOption func(*Fetcher)
+
+// WithAllowedDomains restricts fetching to the given domains and their subdomains.
+func WithAllowedDomains(domains ...string) FetchOption {
+	return func(f *Fetcher) {
+		f.allowed = append(f.allowed, domains...)
+	}
+}
+
+// WithDeniedDomains blocks the given domains and their subdomains. Denials
+// take precedence over the allow list.
+func WithDeniedDomains(domains ...string) FetchOption {
+	return func(f *Fetcher) {
+		f.denied = append(f.denied, domains...)
+	}
+}
+
+// WithMaxBytes limits how much of the response body is read. Defaults to 2 MiB.
+func WithMaxBytes(n int64) FetchOption {
+	return func(f *Fetcher) {
+		f.maxBytes = n
+	}
+}
+
+// WithMaxChars limits the length of the extracted text. Defaults to 20000.
+func WithMaxChars(n int) FetchOption {
+	return func(f *Fetcher) {
+		f.maxChars = n
+	}
+}
+
+// WithPrivateNetworks allows fetching loopback, private and link-local addresses.
+func WithPrivateNetworks() FetchOption {
+	return func(f *Fetcher) {
+		f.privateNetworks = true
+	}
+}
+
+// WithFetchHTTPClient sets the HTTP client. Private network blocking relies
+// on the default client's dialer and is not applied to custom clients.
+func WithFetchHTTPClient(client *http.Client) FetchOption {
+	return func(f *Fetcher) {
+		f.client = client
+	}
+}
+
+// WithUserAgent sets the User-Agent header of requests.
+func WithUserAgent(userAgent string) FetchOption {
+	return func(f *Fetcher) {
+		f.userAgent = userAgent
+	}
+}
+
+// NewFetcher creates a fetcher.
+func NewFetcher(opts ...FetchOption) *Fetcher {
+	f := &Fetcher{
+		maxBytes:  2 << 20,
+		maxChars:  20000,
+		userAgent: "gollm-fetch/1.0",
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	if f.client == nil {
+		dialer := &net.Dialer{Timeout: 10 * time.Second}
+		if !f.privateNetworks {
+			dialer.Control = denyPrivateAddresses
+		}
+		f.client = &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: &http.Transport{DialContext: dialer.DialContext},
+		}
+	}
+	client := *f.client
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 5 {
+			return fmt.Errorf("stopped after 5 redirects")
+		}
+		return f.checkURL(req.URL)
+	}
+	f.client = &client
+	return f
+}
+
+// Fetch retrieves the URL and extracts its readable text. HTML pages are
+// reduced to their main content; other text formats are returned as is.
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) (*Page, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+	if err := f.checkURL(u); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", f.userAgent)
+	req.Header.Set("Accept", "text/html, text/plain, application/json;q=0.9, */*;q=0.1")
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", u, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("failed to fetch %s: status code %d", u, resp.StatusCode)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	page := &Page{URL: resp.Request.URL.String(), ContentType: mediaType}
+	isHTML := mediaType == "text/html" || mediaType == "application/xhtml+xml"
+	if !isHTML && !strings.HasPrefix(mediaType, "text/") && mediaType != "application/json" && mediaType != "application/xml" && mediaType != "" {
+		return nil, fmt.Errorf("unsupported content type %q", mediaType)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, f.maxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", u, err)
+	}
+	if int64(len(body)) > f.maxBytes {
+		body = body[:f.maxBytes]
+		page.Truncated = true
+	}
+
+	if isHTML {
+		page.Title, page.Text, err = ExtractText(strings.NewReader(string(body)))
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		page.Text = strings.TrimSpace(string(body))
+	}
+	if f.maxChars > 0 && len([]rune(page.Text)) > f.maxChars {
+		page.Text = string([]rune(page.Text)[:f.maxChars])
+		page.Truncated = true
+	}
+	return page, nil
+}
+
+// checkURL enforces the scheme and the allow and deny lists.
+func (f *Fetcher) checkURL(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("unsupported URL scheme %q", u.Scheme)
+	}
+	host := strings.ToLower(u.Hostname())
+	if host == "" {
+		return fmt.Errorf("URL has no host")
+	}
+	for _, domain := range f.denied {
+		if matchDomain(host, domain) {
+			return fmt.Errorf("domain %s is denied", host)
+		}
+	}
+	if len(f.allowed) == 0 {
+		return nil
+	}
+	for _, domain := range f.allowed {
+		if matchDomain(host, domain) {
+			return nil
+		}
+	}
+	return fmt.Errorf("domain %s is not allowed", host)
+}
+
+// matchDomain reports whether host is domain or one of its subdomains.
+func matchDomain(host, domain string) bool {
+	domain = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(domain), "."))
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+// denyPrivateAddresses is a dialer control function rejecting connections to
+// non-public addresses. It runs after DNS resolution, so it also catches
+// public host names that resolve to internal addresses.
+func denyPrivateAddresses(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsUnspecified() || ip.IsMulticast() {
+		return fmt.Errorf("connections to %s are not allowed", host)
+	}
+	return nil
+}
+
+// skippedElements never contain readable content.
+var skippedElements = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true,
+	atom.Svg: true, atom.Iframe: true, atom.Form: true, atom.Button: true,
+	atom.Nav: true, atom.Header: true, atom.Footer: true, atom.Aside: true,
+}
+
+// blockElements start a new line in the extracted text.
+var blockElements = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Br: true, atom.Li: true, atom.Tr: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Section: true, atom.Article: true, atom.Main: true, atom.Blockquote: true,
+	atom.Pre: true, atom.Table: true, atom.Ul: true, atom.Ol: true, atom.Dd: true, atom.Dt: true,
+}
+
+// ExtractText returns the title and the readable text of an HTML document.
+// Like reader modes, it keeps the <article> or <main> element when present,
+// and drops scripts, styles, navigation, headers, footers and forms.
+func ExtractText(r io.Reader) (title, text string, err error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to parse HTML: %w", err)
+	}
+
+	var root, body *html.Node
+	var find func(*html.Node)
+	find = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.DataAtom {
+			case atom.Title:
+				if title == "" && n.FirstChild != nil {
+					title = strings.TrimSpace(n.FirstChild.Data)
+				}
+			case atom.Article, atom.Main:
+				if root == nil {
+					root = n
+				}
+			case atom.Body:
+				body = n
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			find(c)
+		}
+	}
+	find(doc)
+	if root == nil {
+		root = body
+	}
+	if root == nil {
+		root = doc
+	}
+
+	var b strings.Builder
+	// Line breaks in text only matter inside preformatted blocks
+	var walk func(n *html.Node, pre bool)
+	walk = func(n *html.Node, pre bool) {
+		switch n.Type {
+		case html.TextNode:
+			if pre {
+				b.WriteString(n.Data)
+			} else {
+				b.WriteString(strings.ReplaceAll(n.Data, "\n", " "))
+			}
+			return
+		case html.ElementNode:
+			if skippedElements[n.DataAtom] {
+				return
+			}
+		}
+		block := n.Type == html.ElementNode && blockElements[n.DataAtom]
+		if block {
+			b.WriteString("\n")
+		}
+		if n.DataAtom == atom.Li {
+			b.WriteString("- ")
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c, pre || n.DataAtom == atom.Pre)
+		}
+		if block {
+			b.WriteString("\n")
+		}
+	}
+	walk(root, false)
+
+	// Collapse whitespace within lines and drop blank lines
+	var lines []string
+	for _, line := range strings.Split(b.String(), "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return title, strings.Join(lines, "\n"), nil
+}
+
+// NewFetchTool creates a "fetch_url" tool that returns the readable text of a
+// web page.
+//
+// Example usage:
+//
+//	fetch := tools.NewFetchTool(tools.WithDeniedDomains("internal.example.com"), tools.WithMaxChars(8000))
+//	registry, _ := tools.NewRegistry(fetch)
+func NewFetchTool(opts ...FetchOption) Tool {
+	fetcher := NewFetcher(opts...)
+	return Tool{
+		Name:        "fetch_url",
+		Description: "Fetches a web page and returns its title and main text content.",
+		Parameters: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"url": map[string]interface{}{
+					"type":        "string",
+					"description": "The http or https URL to fetch",
+				},
+			},
+			"required": []string{"url"},
+		},
+		Handler: func(ctx context.Context, args map[string]interface{}) (string, error) {
+			rawURL, err := StringArg(args, "url")
+			if err != nil {
+				return "", err
+			}
+			page, err := fetcher.Fetch(ctx, rawURL)
+			if err != nil {
+				return "", err
+			}
+			var b strings.Builder
+			fmt.Fprintf(&b, "URL: %s\n", page.URL)
+			if page.Title != "" {
+				fmt.Fprintf(&b, "Title: %s\n", page.Title)
+			}
+			b.WriteString("\n")
+			b.WriteString(page.Text)
+			if page.Truncated {
+				b.WriteString("\n\n[content truncated]")
+			}
+			return b.String(), nil
+		},
+	}
+}
@@ -0,0 +1,93 @@
+package tools
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPage = `<!DOCTYPE html>
+<html><head><title>Go Release</title><style>body { color: red; }</style></head>
+<body>
+<nav><a href="/">Home</a></nav>
+<article>
+  <h1>Go 1.22 is released</h1>
+  <p>Loop   variables are now
+  per-iteration.</p>
+  <ul><li>Range over integers</li><li>Enhanced routing</li></ul>
+  <script>track()</script>
+</article>
+<footer>Copyright</footer>
+</body></html>`
+
+func TestExtractText(t *testing.T) {
+	title, text, err := ExtractText(strings.NewReader(testPage))
+	require.NoError(t, err)
+	assert.Equal(t, "Go Release", title)
+	assert.Equal(t, "Go 1.22 is released\nLoop variables are now per-iteration.\n- Range over integers\n- Enhanced routing", text)
+}
+
+func TestFetcher(t *testing.T) {
+	ctx := context.Background()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/page":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write([]byte(testPage))
+		case "/big":
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte(strings.Repeat("a", 100)))
+		case "/image":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte{0x89})
+		}
+	}))
+	defer server.Close()
+
+	t.Run("Page", func(t *testing.T) {
+		tool := NewFetchTool(WithPrivateNetworks())
+		out, err := tool.Handler(ctx, map[string]interface{}{"url": server.URL + "/page"})
+		require.NoError(t, err)
+		assert.Contains(t, out, "Title: Go Release")
+		assert.Contains(t, out, "Enhanced routing")
+		assert.NotContains(t, out, "Copyright")
+	})
+
+	t.Run("Limits", func(t *testing.T) {
+		page, err := NewFetcher(WithPrivateNetworks(), WithMaxBytes(10)).Fetch(ctx, server.URL+"/big")
+		require.NoError(t, err)
+		assert.True(t, page.Truncated)
+		assert.Len(t, page.Text, 10)
+
+		_, err = NewFetcher(WithPrivateNetworks()).Fetch(ctx, server.URL+"/image")
+		assert.ErrorContains(t, err, "unsupported content type")
+	})
+
+	t.Run("Sandbox", func(t *testing.T) {
+		_, err := NewFetcher().Fetch(ctx, server.URL+"/page")
+		assert.ErrorContains(t, err, "not allowed")
+
+		_, err = NewFetcher().Fetch(ctx, "file:///etc/passwd")
+		assert.ErrorContains(t, err, "unsupported URL scheme")
+
+		f := NewFetcher(WithAllowedDomains("example.com"), WithDeniedDomains("private.example.com"))
+		_, err = f.Fetch(ctx, "https://private.example.com/")
+		assert.ErrorContains(t, err, "denied")
+		_, err = f.Fetch(ctx, "https://golang.org/")
+		assert.ErrorContains(t, err, "not allowed")
+		assert.NoError(t, f.checkURL(mustParseURL(t, "https://docs.example.com/x")))
+	})
+}
+
+func mustParseURL(t *testing.T, raw string) *url.URL {
+	t.Helper()
+	u, err := url.Parse(raw)
+	require.NoError(t, err)
+	return u
+}
@@ -0,0 +1,276 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// SearchResult is a web search hit.
+type SearchResult struct {
+	Title   string `json:"title"`
+	URL     string `json:"url"`
+	Snippet string `json:"snippet"`
+}
+
+// SearchBackend queries a web search service.
+type SearchBackend interface {
+	Name() string
+	Search(ctx context.Context, query string, maxResults int) ([]SearchResult, error)
+}
+
+// searchClient holds the HTTP settings shared by the search backends.
+type searchClient struct {
+	httpClient *http.Client
+	endpoint   string
+}
+
+// SearchOption configures a search backend.
+type SearchOption func(*searchClient)
+
+// WithSearchHTTPClient sets the HTTP client used for search requests.
+func WithSearchHTTPClient(client *http.Client) SearchOption {
+	return func(c *searchClient) {
+		c.httpClient = client
+	}
+}
+
+// WithSearchEndpoint overrides the search API URL, e.g. for a proxy.
+func WithSearchEndpoint(endpoint string) SearchOption {
+	return func(c *searchClient) {
+		c.endpoint = endpoint
+	}
+}
+
+// newSearchClient creates the HTTP settings of a backend with the given default endpoint.
+func newSearchClient(endpoint string, opts []SearchOption) searchClient {
+	c := searchClient{httpClient: &http.Client{Timeout: 30 * time.Second}, endpoint: endpoint}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+// do sends a request and decodes the JSON response into v.
+func (c searchClient) do(req *http.Request, v interface{}) error {
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("search request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+	if err != nil {
+		return fmt.Errorf("failed to read search response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("search API error: status code %d - %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to parse search response: %w", err)
+	}
+	return nil
+}
+
+// TavilySearch queries the Tavily search API.
+type TavilySearch struct {
+	apiKey string
+	client searchClient
+}
+
+// NewTavilySearch creates a Tavily backend. The API key defaults to the
+// TAVILY_API_KEY environment variable when empty.
+func NewTavilySearch(apiKey string, opts ...SearchOption) *TavilySearch {
+	if apiKey == "" {
+		apiKey = os.Getenv("TAVILY_API_KEY")
+	}
+	return &TavilySearch{apiKey: apiKey, client: newSearchClient("https://api.tavily.com/search", opts)}
+}
+
+// Name returns "tavily".
+func (s *TavilySearch) Name() string { return "tavily" }
+
+// Search implements SearchBackend.
+func (s *TavilySearch) Search(ctx context.Context, query string, maxResults int) ([]SearchResult, error) {
+	if s.apiKey == "" {
+		return nil, fmt.Errorf("tavily API key is not set")
+	}
+	payload, err := json.Marshal(map[string]interface{}{"query": query, "max_results": maxResults})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.client.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+s.apiKey)
+
+	var response struct {
+		Results []struct {
+			Title   string `json:"title"`
+			URL     string `json:"url"`
+			Content string `json:"content"`
+		} `json:"results"`
+	}
+	if err := s.client.do(req, &response); err != nil {
+		return nil, err
+	}
+	results := make([]SearchResult, 0, len(response.Results))
+	for _, r := range response.Results {
+		results = append(results, SearchResult{Title: r.Title, URL: r.URL, Snippet: r.Content})
+	}
+	return results, nil
+}
+
+// BraveSearch queries the Brave Search API.
+type BraveSearch struct {
+	apiKey string
+	client searchClient
+}
+
+// NewBraveSearch creates a Brave backend. The API key defaults to the
+// BRAVE_API_KEY environment variable when empty.
+func NewBraveSearch(apiKey string, opts ...SearchOption) *BraveSearch {
+	if apiKey == "" {
+		apiKey = os.Getenv("BRAVE_API_KEY")
+	}
+	return &BraveSearch{apiKey: apiKey, client: newSearchClient("https://api.search.brave.com/res/v1/web/search", opts)}
+}
+
+// Name returns "brave".
+func (s *BraveSearch) Name() string { return "brave" }
+
+// Search implements SearchBackend.
+func (s *BraveSearch) Search(ctx context.Context, query string, maxResults int) ([]SearchResult, error) {
+	if s.apiKey == "" {
+		return nil, fmt.Errorf("brave API key is not set")
+	}
+	params := url.Values{"q": {query}, "count": {fmt.Sprint(maxResults)}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.client.endpoint+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Subscription-Token", s.apiKey)
+
+	var response struct {
+		Web struct {
+			Results []struct {
+				Title       string `json:"title"`
+				URL         string `json:"url"`
+				Description string `json:"description"`
+			} `json:"results"`
+		} `json:"web"`
+	}
+	if err := s.client.do(req, &response); err != nil {
+		return nil, err
+	}
+	results := make([]SearchResult, 0, len(response.Web.Results))
+	for _, r := range response.Web.Results {
+		results = append(results, SearchResult{Title: r.Title, URL: r.URL, Snippet: r.Description})
+	}
+	return results, nil
+}
+
+// SearxNGSearch queries a self-hosted SearxNG instance. The instance must
+// have the JSON output format enabled.
+type SearxNGSearch struct {
+	client searchClient
+}
+
+// NewSearxNGSearch creates a SearxNG backend for the instance at baseURL,
+// e.g. "http://localhost:8080".
+func NewSearxNGSearch(baseURL string, opts ...SearchOption) *SearxNGSearch {
+	return &SearxNGSearch{client: newSearchClient(strings.TrimSuffix(baseURL, "/")+"/search", opts)}
+}
+
+// Name returns "searxng".
+func (s *SearxNGSearch) Name() string { return "searxng" }
+
+// Search implements SearchBackend.
+func (s *SearxNGSearch) Search(ctx context.Context, query string, maxResults int) ([]SearchResult, error) {
+	params := url.Values{"q": {query}, "format": {"json"}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.client.endpoint+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Results []struct {
+			Title   string `json:"title"`
+			URL     string `json:"url"`
+			Content string `json:"content"`
+		} `json:"results"`
+	}
+	if err := s.client.do(req, &response); err != nil {
+		return nil, err
+	}
+	results := make([]SearchResult, 0, len(response.Results))
+	for _, r := range response.Results {
+		if len(results) == maxResults {
+			break
+		}
+		results = append(results, SearchResult{Title: r.Title, URL: r.URL, Snippet: r.Content})
+	}
+	return results, nil
+}
+
+// NewSearchTool creates a "web_search" tool backed by the given search
+// service. maxResults caps the results returned to the model; 0 means 5.
+//
+// Example usage:
+//
+//	registry, _ := tools.NewRegistry(tools.NewSearchTool(tools.NewTavilySearch(""), 5))
+//	a, _ := agent.NewReActAgent(llm, registry)
+func NewSearchTool(backend SearchBackend, maxResults int) Tool {
+	if maxResults <= 0 {
+		maxResults = 5
+	}
+	return Tool{
+		Name:        "web_search",
+		Description: "Searches the web for current information. Returns the title, URL and a snippet of the top results.",
+		Parameters: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"query": map[string]interface{}{
+					"type":        "string",
+					"description": "The search query",
+				},
+			},
+			"required": []string{"query"},
+		},
+		Handler: func(ctx context.Context, args map[string]interface{}) (string, error) {
+			query, err := StringArg(args, "query")
+			if err != nil {
+				return "", err
+			}
+			if strings.TrimSpace(query) == "" {
+				return "", fmt.Errorf("query cannot be empty")
+			}
+			results, err := backend.Search(ctx, query, maxResults)
+			if err != nil {
+				return "", fmt.Errorf("%s search failed: %w", backend.Name(), err)
+			}
+			return FormatSearchResults(results), nil
+		},
+	}
+}
+
+// FormatSearchResults renders search results as a numbered list for the model.
+func FormatSearchResults(results []SearchResult) string {
+	if len(results) == 0 {
+		return "No results found."
+	}
+	var b strings.Builder
+	for i, r := range results {
+		fmt.Fprintf(&b, "%d. %s\n   %s\n   %s\n", i+1, r.Title, r.URL, strings.TrimSpace(r.Snippet))
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
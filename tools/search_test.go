@@ -0,0 +1,74 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchBackends(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Tavily", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "Bearer key", r.Header.Get("Authorization"))
+			var body map[string]interface{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.Equal(t, "golang", body["query"])
+			w.Write([]byte(`{"results": [{"title": "Go", "url": "https://go.dev", "content": "The Go language"}]}`))
+		}))
+		defer server.Close()
+
+		results, err := NewTavilySearch("key", WithSearchEndpoint(server.URL)).Search(ctx, "golang", 3)
+		require.NoError(t, err)
+		assert.Equal(t, []SearchResult{{Title: "Go", URL: "https://go.dev", Snippet: "The Go language"}}, results)
+	})
+
+	t.Run("Brave", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "key", r.Header.Get("X-Subscription-Token"))
+			assert.Equal(t, "golang", r.URL.Query().Get("q"))
+			assert.Equal(t, "3", r.URL.Query().Get("count"))
+			w.Write([]byte(`{"web": {"results": [{"title": "Go", "url": "https://go.dev", "description": "The Go language"}]}}`))
+		}))
+		defer server.Close()
+
+		results, err := NewBraveSearch("key", WithSearchEndpoint(server.URL)).Search(ctx, "golang", 3)
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, "The Go language", results[0].Snippet)
+	})
+
+	t.Run("SearxNGTool", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/search", r.URL.Path)
+			assert.Equal(t, "json", r.URL.Query().Get("format"))
+			w.Write([]byte(`{"results": [{"title": "A", "url": "https://a", "content": "a"}, {"title": "B", "url": "https://b", "content": "b"}]}`))
+		}))
+		defer server.Close()
+
+		registry, err := NewRegistry(NewSearchTool(NewSearxNGSearch(server.URL+"/"), 1))
+		require.NoError(t, err)
+		out, err := registry.Call(ctx, "web_search", `{"query": "letters"}`)
+		require.NoError(t, err)
+		assert.Equal(t, "1. A\n   https://a\n   a", out)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "quota exceeded", http.StatusTooManyRequests)
+		}))
+		defer server.Close()
+
+		_, err := NewSearxNGSearch(server.URL).Search(ctx, "q", 5)
+		assert.ErrorContains(t, err, "429")
+		t.Setenv("BRAVE_API_KEY", "")
+		_, err = NewBraveSearch("", WithSearchEndpoint(server.URL)).Search(ctx, "q", 5)
+		assert.ErrorContains(t, err, "not set")
+	})
+}
@@ -0,0 +1,182 @@
+// Package tools provides a registry of callable tools that agents expose to
+// language models, along with ready-made tools.
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/teilomillet/gollm/utils"
+)
+
+// Handler executes a tool call. Arguments are the decoded JSON object sent by
+// the model; the returned text is passed back to the model as the observation.
+type Handler func(ctx context.Context, args map[string]interface{}) (string, error)
+
+// Tool is a function the model can call.
+type Tool struct {
+	// Name identifies the tool in model requests; it must be unique within a registry
+	Name string
+
+	// Description tells the model what the tool does and when to use it
+	Description string
+
+	// Parameters is the JSON schema of the arguments object
+	Parameters map[string]interface{}
+
+	// Handler executes the tool
+	Handler Handler
+
+	// RequiresApproval pauses calls to the tool until the registry's
+	// approver confirms them, for destructive or sensitive operations
+	RequiresApproval bool
+}
+
+// Definition returns the tool definition sent to providers with gollm.WithTools.
+func (t Tool) Definition() utils.Tool {
+	parameters := t.Parameters
+	if parameters == nil {
+		parameters = map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+	}
+	return utils.Tool{
+		Type: "function",
+		Function: utils.Function{
+			Name:        t.Name,
+			Description: t.Description,
+			Parameters:  parameters,
+		},
+	}
+}
+
+// Registry holds the tools available to an agent. It is safe for concurrent use.
+type Registry struct {
+	mu          sync.RWMutex
+	tools       map[string]Tool
+	approver    Approver
+	definitions []utils.Tool // Definitions of the tools, built on first use
+}
+
+// NewRegistry creates a registry containing the given tools.
+func NewRegistry(tools ...Tool) (*Registry, error) {
+	r := &Registry{tools: make(map[string]Tool)}
+	for _, tool := range tools {
+		if err := r.Register(tool); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// Register adds a tool to the registry. Names must be unique.
+func (r *Registry) Register(tool Tool) error {
+	if tool.Name == "" {
+		return fmt.Errorf("tool name cannot be empty")
+	}
+	if tool.Handler == nil {
+		return fmt.Errorf("tool %q has no handler", tool.Name)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.tools[tool.Name]; exists {
+		return fmt.Errorf("tool %q is already registered", tool.Name)
+	}
+	r.tools[tool.Name] = tool
+	r.definitions = nil
+	return nil
+}
+
+// Get returns the named tool.
+func (r *Registry) Get(name string) (Tool, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	tool, ok := r.tools[name]
+	return tool, ok
+}
+
+// List returns the registered tools sorted by name.
+func (r *Registry) List() []Tool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := make([]Tool, 0, len(r.tools))
+	for _, tool := range r.tools {
+		list = append(list, tool)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// Definitions returns the definitions of the registered tools sorted by name.
+// They are built once and shared by every request until a tool is registered.
+func (r *Registry) Definitions() []utils.Tool {
+	r.mu.RLock()
+	definitions := r.definitions
+	r.mu.RUnlock()
+	if definitions == nil {
+		list := r.List()
+		definitions = make([]utils.Tool, len(list))
+		for i, tool := range list {
+			definitions[i] = tool.Definition()
+		}
+		r.mu.Lock()
+		if len(r.tools) == len(definitions) {
+			r.definitions = definitions
+		}
+		r.mu.Unlock()
+	}
+	return append([]utils.Tool(nil), definitions...)
+}
+
+// Call executes the named tool. Arguments may be a map, a JSON string or raw
+// JSON, as produced by the different provider formats. Tools that require
+// approval are confirmed first; see Execute.
+func (r *Registry) Call(ctx context.Context, name string, arguments interface{}) (string, error) {
+	output, _, err := r.Execute(ctx, name, arguments)
+	return output, err
+}
+
+// decodeArguments normalizes tool call arguments to a map.
+func decodeArguments(arguments interface{}) (map[string]interface{}, error) {
+	var data []byte
+	switch v := arguments.(type) {
+	case nil:
+		return map[string]interface{}{}, nil
+	case map[string]interface{}:
+		return v, nil
+	case string:
+		if v == "" {
+			return map[string]interface{}{}, nil
+		}
+		data = []byte(v)
+	case []byte:
+		data = v
+	case json.RawMessage:
+		data = v
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		data = encoded
+	}
+	args := map[string]interface{}{}
+	if err := json.Unmarshal(data, &args); err != nil {
+		return nil, err
+	}
+	return args, nil
+}
+
+// StringArg returns a string argument, or an error if it is missing or not a string.
+func StringArg(args map[string]interface{}, name string) (string, error) {
+	value, ok := args[name]
+	if !ok {
+		return "", fmt.Errorf("missing argument %q", name)
+	}
+	s, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("argument %q must be a string", name)
+	}
+	return s, nil
+}
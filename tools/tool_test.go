@@ -0,0 +1,51 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	echo := Tool{
+		Name:        "echo",
+		Description: "Echoes its input",
+		Handler: func(ctx context.Context, args map[string]interface{}) (string, error) {
+			return StringArg(args, "text")
+		},
+	}
+	registry, err := NewRegistry(echo)
+	require.NoError(t, err)
+
+	assert.Error(t, registry.Register(echo), "duplicate names are rejected")
+	assert.Error(t, registry.Register(Tool{Name: "nohandler"}))
+
+	for _, args := range []interface{}{
+		map[string]interface{}{"text": "hi"},
+		`{"text": "hi"}`,
+		json.RawMessage(`{"text": "hi"}`),
+	} {
+		out, err := registry.Call(context.Background(), "echo", args)
+		require.NoError(t, err)
+		assert.Equal(t, "hi", out)
+	}
+
+	_, err = registry.Call(context.Background(), "echo", `{"text": 1}`)
+	assert.Error(t, err)
+	_, err = registry.Call(context.Background(), "missing", nil)
+	assert.Error(t, err)
+
+	definitions := registry.Definitions()
+	require.Len(t, definitions, 1)
+	assert.Equal(t, "function", definitions[0].Type)
+	assert.Equal(t, "object", definitions[0].Function.Parameters["type"])
+
+	definitions[0].Function.Name = "renamed"
+	assert.Equal(t, "echo", registry.Definitions()[0].Function.Name, "callers get their own slice")
+	echo.Name = "echo2"
+	require.NoError(t, registry.Register(echo))
+	assert.Len(t, registry.Definitions(), 2, "registering a tool rebuilds the definitions")
+}
@@ -0,0 +1,297 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ExporterOption configures the Langfuse and LangSmith exporters.
+type ExporterOption func(*exporterConfig)
+
+type exporterConfig struct {
+	host   string
+	client *http.Client
+}
+
+// WithHost sets the base URL of the platform, e.g. a self-hosted instance or
+// a regional endpoint such as "https://us.cloud.langfuse.com".
+func WithHost(host string) ExporterOption {
+	return func(c *exporterConfig) {
+		c.host = strings.TrimRight(host, "/")
+	}
+}
+
+// WithHTTPClient sets the client sending the batches.
+func WithHTTPClient(client *http.Client) ExporterOption {
+	return func(c *exporterConfig) {
+		c.client = client
+	}
+}
+
+func newExporterConfig(host string, opts []ExporterOption) exporterConfig {
+	c := exporterConfig{host: host, client: &http.Client{Timeout: 30 * time.Second}}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+// post sends a JSON body, failing on non-2xx responses.
+func (c exporterConfig) post(ctx context.Context, path string, body interface{}, header http.Header) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal batch: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.host+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header = header.Clone()
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s returned status %d: %s", path, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// LangfuseExporter sends batches to the Langfuse ingestion API.
+type LangfuseExporter struct {
+	config exporterConfig
+	header http.Header
+}
+
+// NewLangfuseExporter returns an exporter to the Langfuse project of the
+// given API keys, on Langfuse Cloud unless WithHost is set.
+func NewLangfuseExporter(publicKey, secretKey string, opts ...ExporterOption) *LangfuseExporter {
+	req, _ := http.NewRequest(http.MethodPost, "/", nil)
+	req.SetBasicAuth(publicKey, secretKey)
+	return &LangfuseExporter{
+		config: newExporterConfig("https://cloud.langfuse.com", opts),
+		header: http.Header{"Authorization": req.Header["Authorization"]},
+	}
+}
+
+// langfuseEvent is an event of the Langfuse ingestion API.
+type langfuseEvent struct {
+	ID        string                 `json:"id"`
+	Timestamp string                 `json:"timestamp"`
+	Type      string                 `json:"type"`
+	Body      map[string]interface{} `json:"body"`
+}
+
+// Export sends the batch to Langfuse. Generations recorded outside a trace
+// are sent with a trace of their own.
+func (e *LangfuseExporter) Export(ctx context.Context, batch Batch) error {
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	var events []langfuseEvent
+	add := func(kind string, body map[string]interface{}) {
+		events = append(events, langfuseEvent{ID: newID(), Timestamp: now, Type: kind, Body: body})
+	}
+
+	for _, o := range batch.Observations {
+		body := map[string]interface{}{
+			"id":        o.ID,
+			"name":      o.Name,
+			"input":     o.Input,
+			"output":    o.Output,
+			"startTime": o.Start.UTC().Format(time.RFC3339Nano),
+		}
+		if len(o.Metadata) > 0 {
+			body["metadata"] = o.Metadata
+		}
+		if o.Type == ObservationTrace {
+			delete(body, "startTime")
+			body["timestamp"] = o.Start.UTC().Format(time.RFC3339Nano)
+			add("trace-create", body)
+			continue
+		}
+
+		if o.TraceID == o.ID {
+			add("trace-create", map[string]interface{}{
+				"id":        o.TraceID,
+				"name":      o.Name,
+				"input":     o.Input,
+				"output":    o.Output,
+				"timestamp": o.Start.UTC().Format(time.RFC3339Nano),
+			})
+			// The observation needs an ID of its own within its trace
+			body["id"] = o.ID + "-observation"
+		}
+		body["traceId"] = o.TraceID
+		body["endTime"] = o.End.UTC().Format(time.RFC3339Nano)
+		if o.ParentID != "" {
+			body["parentObservationId"] = o.ParentID
+		}
+		if o.Error != "" {
+			body["level"] = "ERROR"
+			body["statusMessage"] = o.Error
+		}
+		if o.Type == ObservationTool {
+			add("span-create", body)
+			continue
+		}
+		body["model"] = o.Model
+		if o.Provider != "" {
+			body["modelParameters"] = map[string]interface{}{"provider": o.Provider}
+		}
+		if o.Usage != nil {
+			body["usage"] = map[string]interface{}{
+				"input":  o.Usage.InputTokens,
+				"output": o.Usage.OutputTokens,
+				"total":  o.Usage.TotalTokens,
+				"unit":   "TOKENS",
+			}
+		}
+		add("generation-create", body)
+	}
+
+	for _, s := range batch.Scores {
+		body := map[string]interface{}{
+			"id":      s.ID,
+			"traceId": s.TraceID,
+			"name":    s.Name,
+			"value":   s.Value,
+		}
+		if s.ObservationID != "" && s.ObservationID != s.TraceID {
+			body["observationId"] = s.ObservationID
+		}
+		if s.Comment != "" {
+			body["comment"] = s.Comment
+		}
+		add("score-create", body)
+	}
+
+	// Langfuse answers 207 with per-event errors for partially invalid
+	// batches; the valid events are ingested
+	if err := e.config.post(ctx, "/api/public/ingestion", map[string]interface{}{"batch": events}, e.header); err != nil {
+		return fmt.Errorf("langfuse: %w", err)
+	}
+	return nil
+}
+
+// LangSmithExporter sends batches to the LangSmith runs API.
+type LangSmithExporter struct {
+	config  exporterConfig
+	header  http.Header
+	project string
+}
+
+// NewLangSmithExporter returns an exporter to the LangSmith project of the
+// given name, created by LangSmith if needed, on the LangSmith cloud unless
+// WithHost is set.
+func NewLangSmithExporter(apiKey, project string, opts ...ExporterOption) *LangSmithExporter {
+	return &LangSmithExporter{
+		config:  newExporterConfig("https://api.smith.langchain.com", opts),
+		header:  http.Header{"X-Api-Key": []string{apiKey}},
+		project: project,
+	}
+}
+
+// Export sends the batch to LangSmith as runs, and the scores as feedback.
+func (e *LangSmithExporter) Export(ctx context.Context, batch Batch) error {
+	runs := make([]map[string]interface{}, 0, len(batch.Observations))
+	for _, o := range batch.Observations {
+		run := map[string]interface{}{
+			"id":           o.ID,
+			"trace_id":     o.TraceID,
+			"name":         o.Name,
+			"start_time":   o.Start.UTC().Format(time.RFC3339Nano),
+			"end_time":     o.End.UTC().Format(time.RFC3339Nano),
+			"inputs":       map[string]interface{}{"input": o.Input},
+			"outputs":      map[string]interface{}{"output": o.Output},
+			"dotted_order": dottedOrder(o),
+			"session_name": e.project,
+		}
+		switch o.Type {
+		case ObservationTrace:
+			run["run_type"] = "chain"
+		case ObservationTool:
+			run["run_type"] = "tool"
+		default:
+			run["run_type"] = "llm"
+		}
+		if o.ID != o.TraceID {
+			parent := o.ParentID
+			if parent == "" {
+				parent = o.TraceID
+			}
+			run["parent_run_id"] = parent
+		}
+		if o.Error != "" {
+			run["error"] = o.Error
+		}
+		metadata := map[string]interface{}{}
+		for k, v := range o.Metadata {
+			metadata[k] = v
+		}
+		if o.Provider != "" {
+			metadata["ls_provider"] = o.Provider
+		}
+		if o.Model != "" {
+			metadata["ls_model_name"] = o.Model
+		}
+		if len(metadata) > 0 {
+			run["extra"] = map[string]interface{}{"metadata": metadata}
+		}
+		if o.Usage != nil {
+			run["outputs"].(map[string]interface{})["usage_metadata"] = map[string]interface{}{
+				"input_tokens":  o.Usage.InputTokens,
+				"output_tokens": o.Usage.OutputTokens,
+				"total_tokens":  o.Usage.TotalTokens,
+			}
+		}
+		runs = append(runs, run)
+	}
+	if len(runs) > 0 {
+		if err := e.config.post(ctx, "/runs/batch", map[string]interface{}{"post": runs}, e.header); err != nil {
+			return fmt.Errorf("langsmith: %w", err)
+		}
+	}
+
+	for _, s := range batch.Scores {
+		run := s.ObservationID
+		if run == "" {
+			run = s.TraceID
+		}
+		feedback := map[string]interface{}{
+			"id":      s.ID,
+			"run_id":  run,
+			"key":     s.Name,
+			"score":   s.Value,
+			"comment": s.Comment,
+		}
+		if err := e.config.post(ctx, "/feedback", feedback, e.header); err != nil {
+			return fmt.Errorf("langsmith: %w", err)
+		}
+	}
+	return nil
+}
+
+// dottedOrder returns the LangSmith ordering key of a run: the start time and
+// ID of its trace root, followed by its own for child runs.
+func dottedOrder(o Observation) string {
+	key := func(start time.Time, id string) string {
+		return strings.Replace(start.UTC().Format("20060102T150405.000000Z"), ".", "", 1) + id
+	}
+	traceStart := o.TraceStart
+	if traceStart.IsZero() {
+		traceStart = o.Start
+	}
+	root := key(traceStart, o.TraceID)
+	if o.ID == o.TraceID {
+		return root
+	}
+	return root + "." + key(o.Start, o.ID)
+}
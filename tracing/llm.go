@@ -0,0 +1,156 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/teilomillet/gollm"
+	"github.com/teilomillet/gollm/llm"
+)
+
+// Wrap returns an LLM recording a generation for each request, with its
+// prompt, response, usage and latency. Requests made with the context of a
+// trace belong to it; the others get a trace of their own.
+func (t *Tracer) Wrap(l gollm.LLM) gollm.LLM {
+	return &tracedLLM{LLM: l, tracer: t}
+}
+
+// tracedLLM records the requests of an LLM as generations.
+type tracedLLM struct {
+	gollm.LLM
+	tracer *Tracer
+}
+
+func (l *tracedLLM) Generate(ctx context.Context, prompt *gollm.Prompt, opts ...llm.GenerateOption) (string, error) {
+	return l.generate(ctx, "generate", prompt, opts, func(opts []llm.GenerateOption) (string, error) {
+		return l.LLM.Generate(ctx, prompt, opts...)
+	})
+}
+
+func (l *tracedLLM) GenerateWithSchema(ctx context.Context, prompt *gollm.Prompt, schema interface{}, opts ...llm.GenerateOption) (string, error) {
+	return l.generate(ctx, "generate_with_schema", prompt, opts, func(opts []llm.GenerateOption) (string, error) {
+		return l.LLM.GenerateWithSchema(ctx, prompt, schema, opts...)
+	})
+}
+
+func (l *tracedLLM) GenerateFromTemplate(ctx context.Context, tmpl *gollm.PromptTemplate, vars map[string]interface{}, opts ...llm.GenerateOption) (string, error) {
+	prompt, err := tmpl.Execute(vars)
+	if err != nil {
+		return "", fmt.Errorf("failed to execute template %s: %w", tmpl.Name, err)
+	}
+	return l.generate(ctx, tmpl.Name, prompt, opts, func(opts []llm.GenerateOption) (string, error) {
+		return l.LLM.Generate(ctx, prompt, opts...)
+	})
+}
+
+func (l *tracedLLM) Stream(ctx context.Context, prompt *gollm.Prompt, opts ...llm.StreamOption) (llm.TokenStream, error) {
+	o := l.generation(ctx, "stream", prompt)
+	config := &llm.StreamConfig{}
+	for _, opt := range opts {
+		opt(config)
+	}
+	o.Metadata = metadataAttributes(config.Metadata)
+	stream, err := l.LLM.Stream(ctx, prompt, opts...)
+	if err != nil {
+		o.End, o.Error = time.Now(), err.Error()
+		l.tracer.Record(o)
+		return nil, err
+	}
+	return &tracedStream{TokenStream: stream, tracer: l.tracer, observation: o}, nil
+}
+
+func (l *tracedLLM) generate(ctx context.Context, name string, prompt *gollm.Prompt, opts []llm.GenerateOption, call func([]llm.GenerateOption) (string, error)) (string, error) {
+	o := l.generation(ctx, name, prompt)
+
+	// Capture the usage, sharing the caller's WithUsage if any
+	gen := &llm.GenerateConfig{}
+	for _, opt := range opts {
+		opt(gen)
+	}
+	usage := gen.Usage
+	if usage == nil {
+		usage = &llm.Usage{}
+		opts = append(opts[:len(opts):len(opts)], llm.WithUsage(usage))
+	}
+	o.Metadata = metadataAttributes(gen.Metadata)
+
+	response, err := call(opts)
+	o.End, o.Output = time.Now(), response
+	if err != nil {
+		o.Error = err.Error()
+	}
+	if usage.TotalTokens > 0 {
+		recorded := *usage
+		o.Usage = &recorded
+	}
+	l.tracer.Record(o)
+	return response, err
+}
+
+// generation returns the observation of a request.
+func (l *tracedLLM) generation(ctx context.Context, name string, prompt *gollm.Prompt) Observation {
+	o := l.tracer.observation(ctx, ObservationGeneration, name)
+	o.Provider, o.Model, o.Input, o.Start = l.GetProvider(), l.GetModel(), prompt.String(), time.Now()
+	return o
+}
+
+// tracedStream records the generation of a stream once it ends, with the
+// usage and model reported in its terminal token.
+type tracedStream struct {
+	llm.TokenStream
+	tracer      *Tracer
+	observation Observation
+	output      strings.Builder
+	once        sync.Once
+}
+
+func (s *tracedStream) Next(ctx context.Context) (*llm.StreamToken, error) {
+	token, err := s.TokenStream.Next(ctx)
+	if token != nil {
+		s.output.WriteString(token.Text)
+		if token.Type == llm.TokenTypeDone && token.Done != nil {
+			s.observation.Usage = token.Done.Usage
+			if token.Done.Model != "" {
+				s.observation.Model = token.Done.Model
+			}
+		}
+	}
+	switch {
+	case err == io.EOF:
+		s.finish(nil)
+	case err != nil:
+		s.finish(err)
+	}
+	return token, err
+}
+
+func (s *tracedStream) Close() error {
+	s.finish(nil)
+	return s.TokenStream.Close()
+}
+
+func (s *tracedStream) finish(err error) {
+	s.once.Do(func() {
+		s.observation.End, s.observation.Output = time.Now(), s.output.String()
+		if err != nil {
+			s.observation.Error = err.Error()
+		}
+		s.tracer.Record(s.observation)
+	})
+}
+
+// metadataAttributes returns the request metadata as observation metadata.
+func metadataAttributes(metadata map[string]string) map[string]interface{} {
+	if len(metadata) == 0 {
+		return nil
+	}
+	attributes := make(map[string]interface{}, len(metadata))
+	for k, v := range metadata {
+		attributes[k] = v
+	}
+	return attributes
+}
@@ -0,0 +1,308 @@
+// Package tracing exports the generations, tool calls and evaluations of
+// gollm LLMs to LLM observability platforms such as Langfuse and LangSmith,
+// so teams with an existing LLM-ops stack get traces without instrumenting
+// every call.
+//
+// Example usage:
+//
+//	tracer := tracing.NewTracer(tracing.NewLangfuseExporter(publicKey, secretKey))
+//	defer tracer.Close(context.Background())
+//
+//	traced := tracer.Wrap(llm)
+//	ctx, trace := tracer.StartTrace(ctx, "support-answer", question)
+//	answer, err := traced.Generate(ctx, gollm.NewPrompt(question))
+//	trace.End(answer, err)
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/teilomillet/gollm/eval"
+	"github.com/teilomillet/gollm/llm"
+)
+
+// ObservationType is the kind of an Observation.
+type ObservationType string
+
+// Kinds of observations.
+const (
+	ObservationTrace      ObservationType = "trace"      // A trace grouping the observations of a task
+	ObservationGeneration ObservationType = "generation" // An LLM request
+	ObservationTool       ObservationType = "tool"       // A tool call
+)
+
+// Observation is a traced operation.
+type Observation struct {
+	Type     ObservationType
+	ID       string
+	TraceID  string // ID of the trace; equal to ID for traces and standalone generations
+	ParentID string // ID of the parent observation, if any
+
+	// TraceStart is the start time of the trace, which exporters use to
+	// order the observations of a trace
+	TraceStart time.Time
+
+	Name     string
+	Provider string
+	Model    string
+	Input    string
+	Output   string
+	Usage    *llm.Usage
+	Start    time.Time
+	End      time.Time
+	Error    string
+	Metadata map[string]interface{}
+}
+
+// Score is an evaluation of a trace or of one of its observations.
+type Score struct {
+	ID            string
+	TraceID       string
+	ObservationID string // Empty to score the whole trace
+	Name          string
+	Value         float64
+	Comment       string
+}
+
+// Batch is a set of observations and scores sent to an exporter.
+type Batch struct {
+	Observations []Observation
+	Scores       []Score
+}
+
+// Exporter ships batches to an observability platform.
+type Exporter interface {
+	Export(ctx context.Context, batch Batch) error
+}
+
+// Tracer records observations and exports them in batches in the background.
+// It is safe for concurrent use; Close must be called to export the last
+// batch.
+type Tracer struct {
+	exporter  Exporter
+	batchSize int
+	interval  time.Duration
+	onError   func(error)
+
+	mu      sync.Mutex
+	pending Batch
+	flush   chan struct{}
+	stop    chan struct{}
+	stopped chan struct{}
+	once    sync.Once
+}
+
+// TracerOption configures a Tracer.
+type TracerOption func(*Tracer)
+
+// WithBatchSize exports as soon as n observations and scores are pending
+// (default 50).
+func WithBatchSize(n int) TracerOption {
+	return func(t *Tracer) {
+		t.batchSize = n
+	}
+}
+
+// WithFlushInterval exports the pending observations at least every d
+// (default 5s).
+func WithFlushInterval(d time.Duration) TracerOption {
+	return func(t *Tracer) {
+		t.interval = d
+	}
+}
+
+// WithErrorHandler is called when a background export fails; the batch is
+// dropped. Errors are ignored by default, so tracing never fails requests.
+func WithErrorHandler(fn func(error)) TracerOption {
+	return func(t *Tracer) {
+		t.onError = fn
+	}
+}
+
+// NewTracer returns a tracer exporting to exporter.
+func NewTracer(exporter Exporter, opts ...TracerOption) *Tracer {
+	t := &Tracer{
+		exporter:  exporter,
+		batchSize: 50,
+		interval:  5 * time.Second,
+		onError:   func(error) {},
+		flush:     make(chan struct{}, 1),
+		stop:      make(chan struct{}),
+		stopped:   make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	if t.batchSize < 1 {
+		t.batchSize = 1
+	}
+	go t.run()
+	return t
+}
+
+// run exports the pending batch periodically or once it is full.
+func (t *Tracer) run() {
+	defer close(t.stopped)
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-t.flush:
+		case <-t.stop:
+			return
+		}
+		if err := t.Flush(context.Background()); err != nil {
+			t.onError(err)
+		}
+	}
+}
+
+// Flush exports the pending observations and scores now.
+func (t *Tracer) Flush(ctx context.Context) error {
+	t.mu.Lock()
+	batch := t.pending
+	t.pending = Batch{}
+	t.mu.Unlock()
+	if len(batch.Observations) == 0 && len(batch.Scores) == 0 {
+		return nil
+	}
+	if err := t.exporter.Export(ctx, batch); err != nil {
+		return fmt.Errorf("failed to export %d observations and %d scores: %w", len(batch.Observations), len(batch.Scores), err)
+	}
+	return nil
+}
+
+// Close stops the background exports and exports the pending batch.
+func (t *Tracer) Close(ctx context.Context) error {
+	t.once.Do(func() {
+		close(t.stop)
+		<-t.stopped
+	})
+	return t.Flush(ctx)
+}
+
+// Record adds an observation to the next batch.
+func (t *Tracer) Record(o Observation) {
+	t.mu.Lock()
+	t.pending.Observations = append(t.pending.Observations, o)
+	full := len(t.pending.Observations)+len(t.pending.Scores) >= t.batchSize
+	t.mu.Unlock()
+	if full {
+		t.requestFlush()
+	}
+}
+
+// Score adds a score to the next batch.
+func (t *Tracer) Score(s Score) {
+	if s.ID == "" {
+		s.ID = newID()
+	}
+	t.mu.Lock()
+	t.pending.Scores = append(t.pending.Scores, s)
+	full := len(t.pending.Observations)+len(t.pending.Scores) >= t.batchSize
+	t.mu.Unlock()
+	if full {
+		t.requestFlush()
+	}
+}
+
+// ScoreJudgement records the scores of an eval judgement, one per criterion
+// plus "overall", for the given trace and observation.
+func (t *Tracer) ScoreJudgement(traceID, observationID string, j *eval.Judgement) {
+	for _, s := range j.Scores {
+		t.Score(Score{TraceID: traceID, ObservationID: observationID, Name: s.Criterion, Value: s.Normalized, Comment: s.Reasoning})
+	}
+	t.Score(Score{TraceID: traceID, ObservationID: observationID, Name: "overall", Value: j.Overall})
+}
+
+func (t *Tracer) requestFlush() {
+	select {
+	case t.flush <- struct{}{}:
+	default:
+	}
+}
+
+// Trace groups the observations recorded with its context.
+type Trace struct {
+	ID    string
+	Name  string
+	Input string
+	Start time.Time
+
+	tracer *Tracer
+	once   sync.Once
+}
+
+type traceKey struct{}
+
+// StartTrace starts a trace; the generations and tool calls recorded with
+// the returned context belong to it. End must be called to record the trace.
+func (t *Tracer) StartTrace(ctx context.Context, name, input string) (context.Context, *Trace) {
+	trace := &Trace{ID: newID(), Name: name, Input: input, Start: time.Now(), tracer: t}
+	return context.WithValue(ctx, traceKey{}, trace), trace
+}
+
+// TraceFromContext returns the trace of the context, or nil.
+func TraceFromContext(ctx context.Context) *Trace {
+	trace, _ := ctx.Value(traceKey{}).(*Trace)
+	return trace
+}
+
+// End records the trace with its output or error. Later calls do nothing.
+func (tr *Trace) End(output string, err error) {
+	tr.once.Do(func() {
+		o := Observation{
+			Type:       ObservationTrace,
+			ID:         tr.ID,
+			TraceID:    tr.ID,
+			TraceStart: tr.Start,
+			Name:       tr.Name,
+			Input:      tr.Input,
+			Output:     output,
+			Start:      tr.Start,
+			End:        time.Now(),
+		}
+		if err != nil {
+			o.Error = err.Error()
+		}
+		tr.tracer.Record(o)
+	})
+}
+
+// RecordToolCall records a tool call, within the trace of the context if
+// any.
+func (t *Tracer) RecordToolCall(ctx context.Context, name, arguments, result string, start time.Time, err error) {
+	o := t.observation(ctx, ObservationTool, name)
+	o.Input, o.Output, o.Start, o.End = arguments, result, start, time.Now()
+	if err != nil {
+		o.Error = err.Error()
+	}
+	t.Record(o)
+}
+
+// observation returns a new observation in the trace of the context, or in
+// a trace of its own.
+func (t *Tracer) observation(ctx context.Context, kind ObservationType, name string) Observation {
+	o := Observation{Type: kind, ID: newID(), Name: name}
+	if trace := TraceFromContext(ctx); trace != nil {
+		o.TraceID, o.TraceStart = trace.ID, trace.Start
+	} else {
+		o.TraceID = o.ID
+	}
+	return o
+}
+
+// newID returns a random UUID, the ID format both Langfuse and LangSmith
+// accept.
+func newID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	b[6] = b[6]&0x0f | 0x40 // Version 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
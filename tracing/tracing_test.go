@@ -0,0 +1,226 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/teilomillet/gollm"
+	"github.com/teilomillet/gollm/eval"
+	"github.com/teilomillet/gollm/gollmtest"
+	"github.com/teilomillet/gollm/llm"
+)
+
+// recordingExporter keeps the exported batches.
+type recordingExporter struct {
+	mu      sync.Mutex
+	batches []Batch
+}
+
+func (e *recordingExporter) Export(ctx context.Context, batch Batch) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.batches = append(e.batches, batch)
+	return nil
+}
+
+func (e *recordingExporter) observations() []Observation {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var all []Observation
+	for _, b := range e.batches {
+		all = append(all, b.Observations...)
+	}
+	return all
+}
+
+func TestTracer(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Generations", func(t *testing.T) {
+		exporter := &recordingExporter{}
+		tracer := NewTracer(exporter, WithFlushInterval(time.Hour))
+		l, _ := gollmtest.NewMockLLM(t, gollm.SetModel("test-model"))
+		traced := tracer.Wrap(l)
+
+		traceCtx, trace := tracer.StartTrace(ctx, "answer", "Hi")
+		response, err := traced.Generate(traceCtx, gollm.NewPrompt("Hi"), gollm.WithMetadata(map[string]string{"feature": "greeting"}))
+		require.NoError(t, err)
+		tracer.RecordToolCall(traceCtx, "search", `{"q":"hi"}`, "results", time.Now(), errors.New("timeout"))
+		trace.End(response, nil)
+		trace.End("ignored", nil)
+		_, err = traced.Generate(ctx, gollm.NewPrompt("Standalone"))
+		require.NoError(t, err)
+		require.NoError(t, tracer.Close(ctx))
+
+		observations := exporter.observations()
+		require.Len(t, observations, 4)
+		generation, tool, root, standalone := observations[0], observations[1], observations[2], observations[3]
+
+		assert.Equal(t, ObservationGeneration, generation.Type)
+		assert.Equal(t, trace.ID, generation.TraceID)
+		assert.Equal(t, "mock", generation.Provider)
+		assert.Equal(t, "test-model", generation.Model)
+		assert.Contains(t, generation.Input, "Hi")
+		assert.Equal(t, response, generation.Output)
+		require.NotNil(t, generation.Usage)
+		assert.Positive(t, generation.Usage.TotalTokens)
+		assert.Equal(t, map[string]interface{}{"feature": "greeting"}, generation.Metadata)
+
+		assert.Equal(t, ObservationTool, tool.Type)
+		assert.Equal(t, "timeout", tool.Error)
+		assert.Equal(t, ObservationTrace, root.Type)
+		assert.Equal(t, trace.ID, root.ID)
+		assert.Equal(t, standalone.ID, standalone.TraceID)
+	})
+
+	t.Run("Stream", func(t *testing.T) {
+		exporter := &recordingExporter{}
+		tracer := NewTracer(exporter, WithFlushInterval(time.Hour))
+		l, _ := gollmtest.NewMockLLM(t, gollm.SetModel("test-model"))
+		traced := tracer.Wrap(l)
+
+		stream, err := traced.Stream(ctx, gollm.NewPrompt("Hi"))
+		require.NoError(t, err)
+		var text strings.Builder
+		for {
+			token, err := stream.Next(ctx)
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			text.WriteString(token.Text)
+		}
+		require.NoError(t, stream.Close())
+		require.NoError(t, tracer.Close(ctx))
+
+		observations := exporter.observations()
+		require.Len(t, observations, 1, "the stream is recorded once")
+		assert.Equal(t, text.String(), observations[0].Output)
+		assert.NotNil(t, observations[0].Usage)
+	})
+
+	t.Run("BatchSize", func(t *testing.T) {
+		exporter := &recordingExporter{}
+		tracer := NewTracer(exporter, WithBatchSize(2), WithFlushInterval(time.Hour))
+		tracer.Score(Score{TraceID: "t", Name: "a", Value: 1})
+		tracer.Score(Score{TraceID: "t", Name: "b", Value: 1})
+		assert.Eventually(t, func() bool {
+			exporter.mu.Lock()
+			defer exporter.mu.Unlock()
+			return len(exporter.batches) == 1
+		}, time.Second, 10*time.Millisecond)
+		require.NoError(t, tracer.Close(ctx))
+	})
+}
+
+func TestLangfuseExporter(t *testing.T) {
+	var body struct {
+		Batch []langfuseEvent `json:"batch"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/public/ingestion", r.URL.Path)
+		user, password, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "pk-lf-test", user)
+		assert.Equal(t, "sk-lf-test", password)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		w.WriteHeader(http.StatusMultiStatus)
+	}))
+	defer server.Close()
+
+	exporter := NewLangfuseExporter("pk-lf-test", "sk-lf-test", WithHost(server.URL))
+	tracer := NewTracer(exporter, WithFlushInterval(time.Hour))
+	ctx, trace := tracer.StartTrace(context.Background(), "answer", "Hi")
+	tracer.Record(Observation{
+		Type: ObservationGeneration, ID: "gen", TraceID: trace.ID, Name: "generate", Model: "gpt-4o",
+		Usage: &llm.Usage{InputTokens: 3, OutputTokens: 2, TotalTokens: 5}, Start: time.Now(), End: time.Now(),
+	})
+	tracer.RecordToolCall(ctx, "search", "{}", "", time.Now(), nil)
+	trace.End("Hello", nil)
+	tracer.ScoreJudgement(trace.ID, "gen", &eval.Judgement{
+		Scores:  []eval.Score{{Criterion: "relevance", Normalized: 0.9, Reasoning: "on topic"}},
+		Overall: 0.9,
+	})
+	require.NoError(t, tracer.Close(context.Background()))
+
+	var kinds []string
+	for _, event := range body.Batch {
+		kinds = append(kinds, event.Type)
+		if event.Type == "generation-create" {
+			assert.Equal(t, trace.ID, event.Body["traceId"])
+			assert.Equal(t, map[string]interface{}{"input": 3.0, "output": 2.0, "total": 5.0, "unit": "TOKENS"}, event.Body["usage"])
+		}
+	}
+	assert.Equal(t, []string{"generation-create", "span-create", "trace-create", "score-create", "score-create"}, kinds)
+	assert.Equal(t, "gen", body.Batch[3].Body["observationId"])
+}
+
+func TestLangSmithExporter(t *testing.T) {
+	var runs struct {
+		Post []map[string]interface{} `json:"post"`
+	}
+	var feedback []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "ls-key", r.Header.Get("X-Api-Key"))
+		switch r.URL.Path {
+		case "/runs/batch":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&runs))
+		case "/feedback":
+			var f map[string]interface{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&f))
+			feedback = append(feedback, f)
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	exporter := NewLangSmithExporter("ls-key", "my-project", WithHost(server.URL))
+	start := time.Date(2024, 5, 1, 12, 0, 0, 123456000, time.UTC)
+	err := exporter.Export(context.Background(), Batch{
+		Observations: []Observation{
+			{Type: ObservationTrace, ID: "root", TraceID: "root", TraceStart: start, Start: start, End: start},
+			{Type: ObservationGeneration, ID: "gen", TraceID: "root", TraceStart: start, Start: start, End: start, Model: "gpt-4o",
+				Usage: &llm.Usage{InputTokens: 3, OutputTokens: 2, TotalTokens: 5}},
+		},
+		Scores: []Score{{ID: "s", TraceID: "root", Name: "overall", Value: 0.5}},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, runs.Post, 2)
+	assert.Equal(t, "chain", runs.Post[0]["run_type"])
+	assert.Equal(t, "20240501T120000123456Zroot", runs.Post[0]["dotted_order"])
+	assert.Equal(t, "llm", runs.Post[1]["run_type"])
+	assert.Equal(t, "root", runs.Post[1]["parent_run_id"])
+	assert.Equal(t, "20240501T120000123456Zroot.20240501T120000123456Zgen", runs.Post[1]["dotted_order"])
+	assert.Equal(t, "my-project", runs.Post[1]["session_name"])
+	assert.Equal(t, 5.0, runs.Post[1]["outputs"].(map[string]interface{})["usage_metadata"].(map[string]interface{})["total_tokens"])
+
+	require.Len(t, feedback, 1)
+	assert.Equal(t, "root", feedback[0]["run_id"])
+	assert.Equal(t, "overall", feedback[0]["key"])
+}
+
+func TestExportError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid key", http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	tracer := NewTracer(NewLangfuseExporter("pk", "sk", WithHost(server.URL)), WithFlushInterval(time.Hour))
+	tracer.Score(Score{TraceID: "t", Name: "a"})
+	err := tracer.Close(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid key")
+}
@@ -0,0 +1,91 @@
+package types
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Document represents a file attachment, such as a PDF, sent alongside a prompt
+// to a model with native document understanding. Exactly one of URL, Data,
+// Path or FileID should be set:
+//   - URL references a remotely hosted document (or a data: URL)
+//   - Data holds base64-encoded file bytes, described by MediaType
+//   - Path points to a local file that is read when the request is prepared
+//   - FileID references a file already uploaded to the provider's Files API
+type Document struct {
+	URL       string `json:"url,omitempty"`        // Remote document URL
+	Data      string `json:"data,omitempty"`       // Base64-encoded document data
+	MediaType string `json:"media_type,omitempty"` // MIME type (e.g., "application/pdf")
+	Path      string `json:"path,omitempty"`       // Local file path to load the document from
+	FileID    string `json:"file_id,omitempty"`    // Provider file ID from a previous upload
+	Name      string `json:"name,omitempty"`       // Optional file name shown to the model
+}
+
+// IsRemote reports whether the document is referenced by an http(s) URL
+// rather than carried inline.
+func (d Document) IsRemote() bool {
+	return d.URL != "" && !strings.HasPrefix(d.URL, "data:")
+}
+
+// IsInline reports whether the document content is available locally,
+// either as base64 data, a data: URL or a file path.
+func (d Document) IsInline() bool {
+	return d.FileID == "" && (d.Data != "" || d.Path != "" || strings.HasPrefix(d.URL, "data:"))
+}
+
+// Base64 returns the base64-encoded document data together with its media type.
+// Local files are read from disk and data: URLs are decoded into their parts.
+// Remote URLs and file IDs cannot be resolved here and return an error.
+func (d Document) Base64() (data string, mediaType string, err error) {
+	if d.FileID != "" {
+		return "", "", fmt.Errorf("document %s is an uploaded file and has no inline data", d.FileID)
+	}
+	return inlineBase64("document", d.URL, d.Data, d.MediaType, d.Path)
+}
+
+// DataURL returns the document as an inline data: URL.
+func (d Document) DataURL() (string, error) {
+	data, mediaType, err := d.Base64()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("data:%s;base64,%s", mediaType, data), nil
+}
+
+// Filename returns the name of the document, derived from Name, Path or URL.
+func (d Document) Filename() string {
+	switch {
+	case d.Name != "":
+		return d.Name
+	case d.Path != "":
+		return filepath.Base(d.Path)
+	case d.IsRemote():
+		if base := path.Base(strings.SplitN(d.URL, "?", 2)[0]); base != "." && base != "/" {
+			return base
+		}
+	}
+	return "document"
+}
+
+// ContentHash returns a stable hash of the document content. It is used to
+// cache uploads so the same file is only sent to a provider once.
+func (d Document) ContentHash() (string, error) {
+	data, mediaType, err := d.Base64()
+	if err != nil {
+		return "", err
+	}
+	raw, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return "", fmt.Errorf("invalid base64 document data: %w", err)
+	}
+	h := sha256.New()
+	h.Write([]byte(mediaType))
+	h.Write([]byte{0})
+	h.Write(raw)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,88 @@
+package types
+
+import (
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Image represents an image attachment sent alongside a prompt to a
+// vision-capable model. Exactly one of URL, Data or Path should be set:
+//   - URL references a remotely hosted image (or a data: URL)
+//   - Data holds base64-encoded image bytes, described by MediaType
+//   - Path points to a local file that is read when the request is prepared
+type Image struct {
+	URL       string `json:"url,omitempty"`        // Remote image URL
+	Data      string `json:"data,omitempty"`       // Base64-encoded image data
+	MediaType string `json:"media_type,omitempty"` // MIME type of the image (e.g., "image/png")
+	Path      string `json:"path,omitempty"`       // Local file path to load the image from
+	Detail    string `json:"detail,omitempty"`     // Optional detail hint ("low", "high", "auto") for providers that support it
+}
+
+// IsRemote reports whether the image is referenced by an http(s) URL
+// rather than carried inline.
+func (i Image) IsRemote() bool {
+	return i.URL != "" && !strings.HasPrefix(i.URL, "data:")
+}
+
+// Base64 returns the base64-encoded image data together with its media type.
+// Local files are read from disk and data: URLs are decoded into their parts.
+// Remote URLs cannot be resolved here and return an error.
+func (i Image) Base64() (data string, mediaType string, err error) {
+	return inlineBase64("image", i.URL, i.Data, i.MediaType, i.Path)
+}
+
+// inlineBase64 resolves an attachment given by URL, base64 data or local path
+// into base64 data and a media type. kind is used in error messages.
+func inlineBase64(kind, url, data, mediaType, path string) (string, string, error) {
+	switch {
+	case data != "":
+		if mediaType == "" {
+			raw, decErr := base64.StdEncoding.DecodeString(data)
+			if decErr != nil {
+				return "", "", fmt.Errorf("invalid base64 %s data: %w", kind, decErr)
+			}
+			mediaType = http.DetectContentType(raw)
+		}
+		return data, mediaType, nil
+	case path != "":
+		raw, readErr := os.ReadFile(path)
+		if readErr != nil {
+			return "", "", fmt.Errorf("failed to read %s file %s: %w", kind, path, readErr)
+		}
+		if mediaType == "" {
+			mediaType = mime.TypeByExtension(strings.ToLower(filepath.Ext(path)))
+		}
+		if mediaType == "" {
+			mediaType = http.DetectContentType(raw)
+		}
+		return base64.StdEncoding.EncodeToString(raw), mediaType, nil
+	case strings.HasPrefix(url, "data:"):
+		header, payload, found := strings.Cut(strings.TrimPrefix(url, "data:"), ",")
+		if !found || !strings.HasSuffix(header, ";base64") {
+			return "", "", fmt.Errorf("unsupported data URL: expected base64 encoding")
+		}
+		return payload, strings.TrimSuffix(header, ";base64"), nil
+	case url != "":
+		return "", "", fmt.Errorf("%s %s is a remote URL and has no inline data", kind, url)
+	default:
+		return "", "", fmt.Errorf("%s has no URL, data or path", kind)
+	}
+}
+
+// DataURL returns the image as a URL suitable for APIs that accept either
+// remote URLs or inline data: URLs. Remote URLs are returned unchanged.
+func (i Image) DataURL() (string, error) {
+	if i.URL != "" {
+		return i.URL, nil
+	}
+	data, mediaType, err := i.Base64()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("data:%s;base64,%s", mediaType, data), nil
+}
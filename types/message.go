@@ -2,6 +2,8 @@
 // It helps avoid import cycles while providing common data structures.
 package types
 
+import "context"
+
 // MemoryMessage represents a single message in the conversation history.
 // It includes the role of the speaker, the content of the message,
 // and the number of tokens in the message for efficient memory management.
@@ -12,3 +14,21 @@ type MemoryMessage struct {
 	CacheControl string                 // Caching strategy for this message ("ephemeral", "persistent", etc.)
 	Metadata     map[string]interface{} // Additional provider-specific metadata
 }
+
+// MemoryPinnedKey is the metadata key marking a message as pinned: memory
+// strategies keep pinned messages however the conversation is trimmed.
+const MemoryPinnedKey = "pinned"
+
+// Pinned reports whether the message is pinned.
+func (m MemoryMessage) Pinned() bool {
+	pinned, _ := m.Metadata[MemoryPinnedKey].(bool)
+	return pinned
+}
+
+// MemoryStrategy selects the messages a conversation memory keeps. It is
+// applied before each request with the conversation so far, oldest first,
+// and returns the messages to keep, in order. maxTokens is the token limit
+// of the memory, or zero for none.
+type MemoryStrategy interface {
+	Trim(ctx context.Context, messages []MemoryMessage, maxTokens int) ([]MemoryMessage, error)
+}
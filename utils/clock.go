@@ -0,0 +1,130 @@
+package utils
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock tells the time and waits. Retry delays, cache expiry, cooldowns and
+// the current date added to prompts are read from a Clock, so tests can
+// replace the system clock with a FakeClock.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// After returns a channel receiving the current time once d has passed.
+	After(d time.Duration) <-chan time.Time
+}
+
+// SystemClock returns the clock of the system.
+func SystemClock() Clock {
+	return systemClock{}
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// Rand is a source of random numbers for sampling decisions, such as the
+// variant of an experiment. *rand.Rand implements it, so a seeded
+// rand.New(rand.NewSource(1)) makes them reproducible.
+type Rand interface {
+	// Float64 returns a number in [0, 1).
+	Float64() float64
+
+	// Intn returns a number in [0, n).
+	Intn(n int) int
+}
+
+// SystemRand returns the shared random source of math/rand.
+func SystemRand() Rand {
+	return systemRand{}
+}
+
+type systemRand struct{}
+
+func (systemRand) Float64() float64 { return rand.Float64() }
+func (systemRand) Intn(n int) int   { return rand.Intn(n) }
+
+// FakeClock is a Clock whose time only moves when it is advanced, for fast
+// and deterministic tests. Waits end when the clock is advanced past them,
+// or immediately with SetAutoAdvance.
+//
+// Example usage:
+//
+//	clock := utils.NewFakeClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+//	clock.SetAutoAdvance(true) // Retry delays return at once
+//	l, err := gollm.NewLLM(gollm.SetClock(clock), gollm.SetRetryDelay(time.Minute))
+type FakeClock struct {
+	mu          sync.Mutex
+	now         time.Time
+	autoAdvance bool
+	waiters     []fakeWaiter
+}
+
+type fakeWaiter struct {
+	at time.Time
+	c  chan time.Time
+}
+
+// NewFakeClock creates a fake clock set to now.
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Now returns the time of the clock.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// After returns a channel receiving the time of the clock once it has been
+// advanced by d. With auto-advance, the clock is advanced by d at once.
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	ch := make(chan time.Time, 1)
+	c.waiters = append(c.waiters, fakeWaiter{at: c.now.Add(d), c: ch})
+	auto := c.autoAdvance
+	c.mu.Unlock()
+	if auto || d <= 0 {
+		c.Advance(max(d, 0))
+	}
+	return ch
+}
+
+// Advance moves the clock forward by d, ending the waits due by then.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	sort.SliceStable(c.waiters, func(i, j int) bool { return c.waiters[i].at.Before(c.waiters[j].at) })
+	pending := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.at.After(c.now) {
+			pending = append(pending, w)
+			continue
+		}
+		w.c <- c.now
+	}
+	c.waiters = pending
+}
+
+// SetAutoAdvance sets whether each wait advances the clock by its duration
+// at once, so code waiting in a loop, such as retries, runs without delay.
+func (c *FakeClock) SetAutoAdvance(auto bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.autoAdvance = auto
+}
+
+// Waiters returns the number of waits in progress, so a test can check that
+// the code under test is waiting before advancing the clock.
+func (c *FakeClock) Waiters() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.waiters)
+}